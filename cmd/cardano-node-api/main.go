@@ -15,45 +15,168 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"net/http"
-	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "go.uber.org/automaxprocs"
 
 	"github.com/blinklabs-io/cardano-node-api/internal/api"
 	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/debug"
 	"github.com/blinklabs-io/cardano-node-api/internal/logging"
 	"github.com/blinklabs-io/cardano-node-api/internal/node"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
 	"github.com/blinklabs-io/cardano-node-api/internal/utxorpc"
 	"github.com/blinklabs-io/cardano-node-api/internal/version"
 )
 
 var cmdlineFlags struct {
-	configFile string
+	configFile     string
+	strictConfig   bool
+	exampleConfig  bool
+	version        bool
+	apiAddress     string
+	apiPort        uint
+	nodeSocketPath string
+	networkMagic   uint
+	logLevel       string
 }
 
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"Usage: %s [flags]\n\n"+
+				"Flags take precedence over environment variables, which take\n"+
+				"precedence over the config file.\n\n",
+			os.Args[0],
+		)
+		flag.PrintDefaults()
+	}
 	flag.StringVar(
 		&cmdlineFlags.configFile,
 		"config",
 		"",
-		"path to config file to load",
+		"path to YAML or TOML config file to load",
+	)
+	flag.BoolVar(
+		&cmdlineFlags.strictConfig,
+		"strict-config",
+		false,
+		"fail on unknown keys in the config file instead of warning",
+	)
+	flag.BoolVar(
+		&cmdlineFlags.exampleConfig,
+		"example-config",
+		false,
+		"print an example config file with the default values and exit",
+	)
+	flag.BoolVar(
+		&cmdlineFlags.version,
+		"version",
+		false,
+		"print the version and build info and exit",
+	)
+	flag.StringVar(
+		&cmdlineFlags.apiAddress,
+		"api-address",
+		"",
+		"address to bind for API calls (env: API_LISTEN_ADDRESS)",
+	)
+	flag.UintVar(
+		&cmdlineFlags.apiPort,
+		"api-port",
+		0,
+		"port to bind for API calls (env: API_LISTEN_PORT)",
+	)
+	flag.StringVar(
+		&cmdlineFlags.nodeSocketPath,
+		"node-socket",
+		"",
+		"path to the cardano-node socket (env: CARDANO_NODE_SOCKET_PATH)",
+	)
+	flag.UintVar(
+		&cmdlineFlags.networkMagic,
+		"network-magic",
+		0,
+		"network magic of the cardano-node, overriding the network name "+
+			"(env: CARDANO_NODE_NETWORK_MAGIC)",
+	)
+	flag.StringVar(
+		&cmdlineFlags.logLevel,
+		"log-level",
+		"",
+		"logging level for log output (env: LOGGING_LEVEL)",
 	)
 	flag.Parse()
 
+	if cmdlineFlags.version {
+		fmt.Printf(
+			"cardano-node-api %s\nbuild date: %s\ngo version: %s\n",
+			version.GetVersionString(),
+			version.BuildDate,
+			runtime.Version(),
+		)
+		return
+	}
+
+	if cmdlineFlags.exampleConfig {
+		buf, err := config.Example()
+		if err != nil {
+			fmt.Printf("Failed to generate example config: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(buf))
+		return
+	}
+
+	// Only flags that were actually provided override other config sources
+	overrides := &config.Overrides{}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "api-address":
+			overrides.ApiAddress = &cmdlineFlags.apiAddress
+		case "api-port":
+			overrides.ApiPort = &cmdlineFlags.apiPort
+		case "node-socket":
+			overrides.NodeSocketPath = &cmdlineFlags.nodeSocketPath
+		case "network-magic":
+			networkMagic := uint32(cmdlineFlags.networkMagic)
+			overrides.NetworkMagic = &networkMagic
+		case "log-level":
+			overrides.LoggingLevel = &cmdlineFlags.logLevel
+		}
+	})
+
 	// Load config
-	cfg, err := config.Load(cmdlineFlags.configFile)
+	cfg, err := config.Load(
+		cmdlineFlags.configFile,
+		cmdlineFlags.strictConfig,
+		overrides,
+	)
 	if err != nil {
 		fmt.Printf("Failed to load config: %s\n", err)
 		os.Exit(1)
 	}
+	// Report every problem with the config before anything is started
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("Invalid config:\n")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("  - %s\n", line)
+		}
+		os.Exit(1)
+	}
 
 	// Configure logging
 	logging.Setup(&cfg.Logging)
-	logger := logging.GetLogger()
+	logger := logging.GetLogger(logging.ComponentMain)
 	// Sync logger on exit
 	defer func() {
 		if err := logger.Sync(); err != nil {
@@ -62,14 +185,29 @@ func main() {
 			return
 		}
 	}()
+	for _, warning := range config.Warnings() {
+		logger.Warnf("config file: %s", warning)
+	}
 
-	// Test node connection
-	if oConn, err := node.GetConnection(nil); err != nil {
-		logger.Fatalf("failed to connect to node: %s", err)
-	} else {
-		oConn.Close()
+	// Configure trace export
+	tracingShutdown, err := tracing.Setup(context.Background())
+	if err != nil {
+		logger.Fatalf("failed to configure tracing: %s", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracingShutdown(ctx); err != nil {
+			logger.Errorf("failed to flush traces: %s", err)
+		}
+	}()
+	if tracing.Enabled() {
+		logger.Infof("trace export enabled")
 	}
 
+	// Set up metrics before anything can update them
+	api.InitMetrics()
+
 	logger.Infof(
 		"starting cardano-node-api version %s",
 		version.GetVersionString(),
@@ -77,47 +215,117 @@ func main() {
 
 	// Start debug listener
 	if cfg.Debug.ListenPort > 0 {
-		logger.Infof(
-			"starting debug listener on %s:%d",
+		logger.Warnf(
+			"DEBUG LISTENER ENABLED on %s:%d: this exposes pprof profiles and internal state without authentication, do not expose it publicly",
 			cfg.Debug.ListenAddress,
 			cfg.Debug.ListenPort,
 		)
 		go func() {
-			err := http.ListenAndServe(
-				fmt.Sprintf(
-					"%s:%d",
-					cfg.Debug.ListenAddress,
-					cfg.Debug.ListenPort,
-				),
-				nil,
-			)
-			if err != nil {
+			if err := debug.Start(cfg); err != nil {
 				logger.Fatalf("failed to start debug listener: %s", err)
 			}
 		}()
 	}
 
-	// Start API listener
-	logger.Infof(
-		"starting API listener on %s:%d",
-		cfg.Api.ListenAddress,
-		cfg.Api.ListenPort,
+	// Shut down gracefully on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGINT,
+		syscall.SIGTERM,
 	)
+	defer stop()
+
+	// Connect to the node in the background so that the listeners come up
+	// while the node is still starting
+	go func() {
+		if err := node.ManageConnection(ctx, cfg); err != nil {
+			logger.Fatalf("failed to connect to node: %s", err)
+		}
+	}()
+	// Share node connections between API requests
+	node.StartConnectionPool(ctx)
+
+	// Reopen log files on SIGHUP so that they can be rotated externally, and
+	// reload the config
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 	go func() {
-		if err := api.Start(cfg); err != nil {
-			logger.Fatalf("failed to start API: %s", err)
+		for range hupChan {
+			if err := logging.Reopen(); err != nil {
+				logger.Errorf("failed to reopen log files: %s", err)
+			} else {
+				logger.Infof("reopened log files")
+			}
+			reloadConfig()
 		}
 	}()
 
+	// Start mempool metrics poller
+	if cfg.Metrics.MempoolPoll {
+		logger.Infof(
+			"starting mempool metrics poller with interval %ds",
+			cfg.Metrics.MempoolPollInterval,
+		)
+		node.StartMempoolPoller(ctx, cfg)
+	}
+
+	// Start chain tip metrics poller
+	if cfg.Metrics.TipPoll {
+		logger.Infof(
+			"starting chain tip metrics poller with interval %ds",
+			cfg.Metrics.TipPollInterval,
+		)
+		node.StartTipPoller(ctx, cfg)
+	}
+
 	// Start UTxO RPC gRPC listener
 	logger.Infof(
 		"starting gRPC listener on %s:%d",
 		cfg.Utxorpc.ListenAddress,
 		cfg.Utxorpc.ListenPort,
 	)
-	if err := utxorpc.Start(cfg); err != nil {
-		logger.Fatalf("failed to start gRPC: %s", err)
+	go func() {
+		if err := utxorpc.Start(cfg); err != nil {
+			logger.Fatalf("failed to start gRPC: %s", err)
+		}
+	}()
+
+	// Start API listener
+	logger.Infof(
+		"starting API listener on %s:%d",
+		cfg.Api.ListenAddress,
+		cfg.Api.ListenPort,
+	)
+	if err := api.Start(ctx, cfg); err != nil {
+		logger.Fatalf("API failure: %s", err)
 	}
+	logger.Infof("shutdown complete")
+}
 
-	select {}
+// reloadConfig reloads the config and logs the result for each changed key
+func reloadConfig() {
+	logger := logging.GetLogger(logging.ComponentMain)
+	results, err := config.Reload()
+	for _, warning := range config.Warnings() {
+		logger.Warnf("config file: %s", warning)
+	}
+	if err != nil {
+		logger.Errorf("failed to reload config: %s", err)
+		api.RecordConfigReload([]config.ReloadResult{
+			{Key: "config", Result: config.ReloadResultFailed, Err: err},
+		})
+		return
+	}
+	for _, result := range results {
+		switch result.Result {
+		case config.ReloadResultApplied:
+			logger.Infof("config reload: applied %s", result.Key)
+		case config.ReloadResultSkipped:
+			logger.Warnf("config reload: skipped %s: %s", result.Key, result.Err)
+		case config.ReloadResultFailed:
+			logger.Errorf("config reload: failed %s: %s", result.Key, result.Err)
+		}
+	}
+	logger.Infof("reloaded config")
+	api.RecordConfigReload(results)
 }
@@ -24,6 +24,35 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/chainsync/stream": {
+            "get": {
+                "description": "Follows the chain from the current tip and streams server-sent events. A block event with the slot, hash, height, era, TX count, and size in bytes is sent for each new block, and a rollback event with the slot and hash of the point that the chain rolled back to is sent when the node switches forks. The node starts with a rollback to the tip that the stream started from. The stream resumes by itself if the connection to the node is lost, after sending a reconnect event with the point that it resumed from, and failover set if it resumed on a different node endpoint. An error event is sent if it can't be resumed, and the stream ends. A shutdown event is sent before the stream ends when the server shuts down. Each stream follows the chain on its own node connection, so a slow client only holds up its own stream. Heartbeat comments are sent while no blocks arrive.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "chainsync"
+                ],
+                "summary": "Stream new blocks",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
         "/chainsync/sync": {
             "get": {
                 "tags": [
@@ -69,20 +98,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/localstatequery/current-era": {
+        "/convert/slot-to-time/{slot}": {
             "get": {
+                "description": "Returns the wall-clock start time of a slot and the era that it falls in, using the era history from the node. Slots past the end of the era history are marked as projected, since a hard fork could change the result, or rejected if projected results are disabled.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "localstatequery"
+                    "convert"
+                ],
+                "summary": "Convert Slot To Time",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "slot number",
+                        "name": "slot",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Query Current Era",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQueryCurrentEra"
+                            "$ref": "#/definitions/api.responseConvert"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
                         }
                     },
                     "500": {
@@ -90,24 +141,52 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localstatequery/era-history": {
+        "/convert/time-to-slot/{time}": {
             "get": {
+                "description": "Returns the slot at an RFC3339 time and the era that it falls in, using the era history from the node. Times past the end of the era history are marked as projected, since a hard fork could change the result, or rejected if projected results are disabled. The time in the response is the start of the slot.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "localstatequery"
+                    "convert"
+                ],
+                "summary": "Convert Time To Slot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time",
+                        "name": "time",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Query Era History",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQueryEraHistory"
+                            "$ref": "#/definitions/api.responseConvert"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
                         }
                     },
                     "500": {
@@ -115,49 +194,74 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localstatequery/genesis-config": {
+        "/localstatequery/block-height": {
             "get": {
+                "description": "Returns the block number and slot of the chain tip, for cheap polling. The response has an ETag, and a 304 is returned if it matches If-None-Match.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "localstatequery"
                 ],
-                "summary": "Query Genesis Config",
+                "summary": "Query Block Height",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQueryGenesisConfig"
+                            "$ref": "#/definitions/api.responseLocalStateQueryBlockHeight"
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localstatequery/protocol-params": {
+        "/localstatequery/cost-models": {
             "get": {
+                "description": "Returns the Plutus cost models by language version, along with the execution unit prices and limits. Each cost model has the parameters in ledger order, and by name where the names are known for the language version.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "localstatequery"
                 ],
-                "summary": "Query Current Protocol Parameters",
+                "summary": "Query Cost Models",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQueryProtocolParams"
+                            "$ref": "#/definitions/api.responseLocalStateQueryCostModels"
                         }
                     },
                     "500": {
@@ -165,11 +269,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localstatequery/system-start": {
+        "/localstatequery/current-era": {
             "get": {
                 "produces": [
                     "application/json"
@@ -177,12 +287,12 @@ const docTemplate = `{
                 "tags": [
                     "localstatequery"
                 ],
-                "summary": "Query System Start",
+                "summary": "Query Current Era",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQuerySystemStart"
+                            "$ref": "#/definitions/api.responseLocalStateQueryCurrentEra"
                         }
                     },
                     "500": {
@@ -194,20 +304,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/localstatequery/tip": {
+        "/localstatequery/epoch": {
             "get": {
+                "description": "Returns the current epoch number with the first and last slots of the epoch and the times that it starts and ends. The last slot and end time are null when the era history doesn't cover the end of the epoch yet, which can happen right after a hard fork.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "localstatequery"
                 ],
-                "summary": "Query Chain Tip",
+                "summary": "Query Current Epoch",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalStateQueryTip"
+                            "$ref": "#/definitions/api.responseLocalStateQueryEpoch"
                         }
                     },
                     "500": {
@@ -215,27 +326,31 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localtxmonitor/has_tx/{tx_hash}": {
+        "/localstatequery/era": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the current era and the first slot of the era. Both come from the same ledger state, so they're consistent across a hard fork.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "localtxmonitor"
+                    "localstatequery"
                 ],
-                "summary": "Check if a particular TX exists in the mempool",
+                "summary": "Query Era",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalTxMonitorHasTx"
+                            "$ref": "#/definitions/api.responseLocalStateQueryEra"
                         }
                     },
                     "500": {
@@ -243,27 +358,31 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localtxmonitor/sizes": {
+        "/localstatequery/era-history": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the bounds and parameters of each era along with the system start, which are needed to convert between slots and times. The end of the last era is null when it's unbounded.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "localtxmonitor"
+                    "localstatequery"
                 ],
-                "summary": "Get mempool capacity, size, and TX count",
+                "summary": "Query Era History",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.responseLocalTxMonitorSizes"
+                            "$ref": "#/definitions/api.responseLocalStateQueryEraHistory"
                         }
                     },
                     "500": {
@@ -271,30 +390,31 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localtxmonitor/txs": {
+        "/localstatequery/genesis": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the Shelley genesis config of the network. The result is cached until the connection to the node is lost.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "localtxmonitor"
+                    "localstatequery"
                 ],
-                "summary": "List all transactions in the mempool",
+                "summary": "Query Genesis Config",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/api.responseLocalTxMonitorTxs"
-                            }
+                            "$ref": "#/definitions/api.responseLocalStateQueryGenesis"
                         }
                     },
                     "500": {
@@ -302,156 +422,4027 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.responseApiError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
                     }
                 }
             }
         },
-        "/localtxsubmission/tx": {
-            "post": {
-                "description": "Submit an already serialized transaction to the network.",
+        "/localstatequery/governance/committee": {
+            "get": {
+                "description": "Returns the constitutional committee members with their cold and hot credentials, hot credential status, member status, and expiry epochs, ordered by cold credential, along with the quorum threshold. Credentials can be CIP-129 bech32 or hex, which is the CIP-129 header byte and the hash, or a key hash. Needs the Conway era.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Submit Tx",
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Constitutional Committee",
                 "parameters": [
                     {
-                        "enum": [
-                            "application/cbor"
-                        ],
-                        "type": "string",
-                        "description": "Content type",
-                        "name": "Content-Type",
-                        "in": "header",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return the member with this cold credential, which can be repeated",
+                        "name": "cold_credential",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return the member with this hot credential, which can be repeated",
+                        "name": "hot_credential",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "active",
+                                "expired",
+                                "unrecognized"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return members with this status, which can be repeated",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Ok",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.responseLocalStateQueryCommittee"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.responseApiError"
                         }
                     },
-                    "415": {
-                        "description": "Unsupported Media Type",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.responseApiError"
                         }
                     },
                     "500": {
-                        "description": "Server Error",
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.responseApiError": {
-            "type": "object",
-            "properties": {
-                "msg": {
-                    "type": "string",
-                    "example": "error message"
-                }
-            }
-        },
-        "api.responseLocalStateQueryCurrentEra": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "api.responseLocalStateQueryEraHistory": {
-            "type": "object"
-        },
-        "api.responseLocalStateQueryGenesisConfig": {
-            "type": "object"
-        },
-        "api.responseLocalStateQueryProtocolParams": {
-            "type": "object"
         },
-        "api.responseLocalStateQuerySystemStart": {
-            "type": "object",
-            "properties": {
-                "day": {
-                    "type": "integer"
-                },
-                "picoseconds": {
-                    "type": "integer"
-                },
-                "year": {
-                    "type": "integer"
+        "/localstatequery/governance/constitution": {
+            "get": {
+                "description": "Returns the anchor of the current constitution and the hash of its guardrails script, if there is one. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Constitution",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryConstitution"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/governance/drep-stake": {
+            "get": {
+                "description": "Returns the stake delegated to each DRep in lovelace, including the abstain and no confidence DReps, ordered by type and then hash. DRep IDs are given like for the DReps query, or as abstain or no_confidence. The DReps are paged through by passing the next_cursor from the previous response as the cursor. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query DRep Stake Distribution",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return this DRep, which can be repeated",
+                        "name": "drep_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of DReps to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryDrepStake"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/governance/dreps": {
+            "get": {
+                "description": "Returns the registered DReps with their expiry epochs, anchors, and deposits in lovelace, ordered by type and then hash. DRep IDs can be CIP-129 or CIP-105 bech32, or hex, which is the CIP-129 header byte and the hash, or a key hash. The DReps are paged through by passing the next_cursor from the previous response as the cursor. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query DReps",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return this DRep, which can be repeated",
+                        "name": "drep_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of DReps to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryDreps"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/governance/proposals": {
+            "get": {
+                "description": "Returns the live governance actions in the order the node returns them, with their proposals and the votes cast so far. Action IDs are given as txHash#index, with the # URL-encoded as %23. The vote counts are the number of committee members, DReps, and stake pools that cast each vote. Use include_votes=false to leave out the individual votes. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Governance Proposals",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return this action, which can be repeated",
+                        "name": "action_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "parameter_change",
+                                "hard_fork",
+                                "treasury_withdrawals",
+                                "no_confidence",
+                                "update_committee",
+                                "new_constitution",
+                                "info"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return actions of this type, which can be repeated",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include the individual votes (default true)",
+                        "name": "include_votes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryProposals"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/governance/spo-stake": {
+            "get": {
+                "description": "Returns the voting stake of each stake pool in lovelace, ordered by pool ID, along with the total of the pools returned. Pool IDs can be bech32 or hex. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query SPO Voting Stake Distribution",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only return this pool, which can be repeated",
+                        "name": "pool_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQuerySpoStake"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/governance/state": {
+            "get": {
+                "description": "Returns the current protocol version, the enacted constitutional committee, the treasury in lovelace, and the previous enacted governance action of each purpose. Needs the Conway era.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Governance State",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryGovState"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/pools": {
+            "get": {
+                "description": "Returns the IDs of all registered stake pools, in bech32 and hex.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Pools",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responsePool"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/pools/{pool_id}": {
+            "get": {
+                "description": "Returns the registered parameters of one or more stake pools, in the order requested. Pool IDs can be bech32 or hex, and more than one can be given separated by commas or with the pool_id query parameter. Pools that aren't registered are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Pool Params",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pool ID, or comma-separated pool IDs",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "additional pool IDs",
+                        "name": "pool_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responsePoolParams"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/pools/{pool_id}/snapshots": {
+            "get": {
+                "description": "Returns the stake of one or more pools in the mark, set, and go snapshots, along with the total active stake in each snapshot. Pool IDs can be bech32 or hex, separated by commas. Use the pool ID all with all=true for every pool, which is expensive on mainnet. The snapshots of all pools are cached until the end of the epoch. Stake is in lovelace, as strings.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pool ID, comma-separated pool IDs, or all",
+                        "name": "pool_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "required to query all pools",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryStakeSnapshots"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/protocol-parameters": {
+            "get": {
+                "description": "Returns the protocol parameters for the current era using the same field names for every era. Fields that don't apply to the current era are null. The cbor and hex formats return the parameters as CBOR.",
+                "produces": [
+                    "application/json",
+                    "application/cbor",
+                    "text/plain"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Current Protocol Parameters With Stable Field Names",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "cbor",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "response format, which overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseProtocolParameters"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/protocol-parameters/cli": {
+            "get": {
+                "description": "Returns the protocol parameters for the current era with the same field names and nesting as the output of cardano-cli query protocol-parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Current Protocol Parameters In cardano-cli Format",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/protocol-params": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/cbor",
+                    "text/plain"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Current Protocol Parameters",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "cbor",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "response format, which overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryProtocolParams"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/raw": {
+            "post": {
+                "description": "Sends a LocalStateQuery query given as hex CBOR to the node and returns the result as hex CBOR, along with a best-effort JSON rendering of it. The query is sent as is, so ledger queries must include the era wrapping, such as [0, [0, [era, query]]]. The ledger state is the volatile tip, the immutable tip, or a point given by its slot and block hash. A ledger query for a different era than the ledger state is reported as unprocessable. Only served when API_RAW_QUERY_ENABLED is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Raw Query",
+                "parameters": [
+                    {
+                        "description": "query and ledger state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.requestLocalStateQueryRaw"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryRaw"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/stake-distribution": {
+            "get": {
+                "description": "Returns the stake distribution for the current epoch, ordered by descending stake. Stake fractions are decimal strings to avoid losing precision. The result is cached until the end of the epoch.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Distribution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only return this bech32 pool ID",
+                        "name": "pool",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only return pools with at least this stake fraction, such as 0.0001",
+                        "name": "min_share",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryStakeDistribution"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/stake/accounts": {
+            "post": {
+                "description": "Returns the stake address info for each of a list of stake addresses, in the order requested, from a single ledger state with one query for all of them. Unregistered stake addresses are included. The number of stake addresses per request is limited by the API_MAX_STAKE_ACCOUNTS setting.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Accounts",
+                "parameters": [
+                    {
+                        "description": "bech32 stake addresses",
+                        "name": "stake_addresses",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responseLocalStateQueryStakeAddress"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/stake/{stake_address}": {
+            "get": {
+                "description": "Returns whether a stake address is registered, the pool that it delegates to, its available rewards in lovelace, and its DRep delegation, which is only included from the Conway era. Unregistered stake addresses aren't an error.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Stake Address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "bech32 stake address",
+                        "name": "stake_address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryStakeAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/system-start": {
+            "get": {
+                "description": "Returns the network's system start time, along with the year, day of the year, and picoseconds within the day that the node sends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query System Start",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQuerySystemStart"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/tip": {
+            "get": {
+                "description": "Returns the tip of the volatile ledger state, with the wall-clock time of the tip slot.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query Chain Tip",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryTip"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/utxo": {
+            "post": {
+                "description": "Returns the UTxOs for a list of TX inputs, in the same order as the request. Inputs that are spent or unknown are null in the response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query UTxOs By TX Inputs",
+                "parameters": [
+                    {
+                        "description": "TX inputs",
+                        "name": "tx_ins",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.requestLocalStateQueryUtxoTxIn"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responseUtxo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/utxo/{tx_hash}/{index}": {
+            "get": {
+                "description": "Returns the UTxO for a TX input, or a utxo_not_found error if the output is spent or unknown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query UTxO By TX Input",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "TX hash",
+                        "name": "tx_hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "output index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseUtxo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localstatequery/utxos": {
+            "get": {
+                "description": "Returns the UTxOs at one or more addresses, ordered by TX hash and output index. The UTxOs can be filtered by policy ID, asset, or minimum lovelace, and paged through by passing the next_cursor from the previous response as the cursor. The total is the number of UTxOs that pass the filters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localstatequery"
+                ],
+                "summary": "Query UTxOs By Address",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "bech32 address, which can be repeated",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of UTxOs to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only return UTxOs holding assets with this policy ID",
+                        "name": "policy_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only return UTxOs holding this asset, as policyId.assetNameHex",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only return UTxOs holding at least this much lovelace",
+                        "name": "min_lovelace",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalStateQueryUtxos"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/has_tx/{tx_hash}": {
+            "get": {
+                "description": "Returns whether a transaction is in a mempool snapshot. The slot is the chain tip that the snapshot is for. With a wait duration, such as 30s, the request waits for the transaction to reach the mempool, checking each new snapshot as the mempool changes. The response is sent as soon as the transaction is found, or with the last snapshot when the wait runs out. The wait must be less than the TX submission wait timeout.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "Check if a particular TX exists in the mempool",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tx_hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "How long to wait for the transaction, as a Go duration",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxMonitorHasTx"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/sizes": {
+            "get": {
+                "description": "Returns the capacity and size of a mempool snapshot in bytes, its number of transactions, and its size as a percentage of its capacity. The slot is the chain tip that the snapshot is for.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "Get mempool capacity, size, and TX count",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxMonitorSizes"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/stream": {
+            "get": {
+                "description": "Streams server-sent events for the mempool. A snapshot event with the slot, sizes, and TX count is sent each time the mempool changes, followed by tx_removed and tx_added events for the transactions that left or entered it since the last snapshot. The first snapshot lists every transaction in the mempool as added. Events aren't replayed, so a client that reconnects starts over from the current mempool, and Last-Event-ID is ignored. Heartbeat comments are sent while the mempool is idle. An error event is sent if the connection to the node is lost, and the stream ends. A shutdown event is sent before the stream ends when the server shuts down.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "Stream mempool changes",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/tx/{tx_hash}": {
+            "get": {
+                "description": "Returns a transaction from a mempool snapshot, with its size in bytes and its CBOR in hex. The slot is the chain tip that the snapshot is for. Returns the raw transaction for the cbor and hex formats.",
+                "produces": [
+                    "application/json",
+                    "application/cbor",
+                    "text/plain"
+                ],
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "Get a transaction from the mempool",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tx_hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "cbor",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "response format, which overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxMonitorTxCbor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/txs": {
+            "get": {
+                "description": "Returns the transactions in a mempool snapshot, with their ID, size in bytes, and era, and their CBOR in hex if include_cbor is set. The slot is the chain tip that the snapshot is for, and tx_count is the number of transactions in the snapshot. The limit stops listing transactions early, and the response is marked as truncated if there were more. The era of a transaction that can't be decoded is unknown. With one or more addresses, only the transactions with an output to one of them are listed, along with the outputs that matched, and the limit applies to the matching transactions. Returns a CBOR array of the raw transactions for the cbor and hex formats.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/cbor",
+                    "text/plain"
+                ],
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "List all transactions in the mempool",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "cbor",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "response format, which overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to include the transaction CBOR",
+                        "name": "include_cbor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of transactions to list, or 0 for all of them",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only list transactions with an output to one of these addresses",
+                        "name": "address",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxMonitorTxs"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxmonitor/ws": {
+            "get": {
+                "description": "Sends the same snapshot, tx_added, and tx_removed events as the /localtxmonitor/stream endpoint, as JSON messages with the event type and data. The client can send a filter message with tx_ids and addresses lists, after which TX events are only sent for TXs with one of the IDs or with an output to one of the addresses. Each filter message replaces the last one, and empty lists remove the filter. Snapshot events are always sent. Clients that don't answer pings, or that fall too far behind on messages, are disconnected.",
+                "tags": [
+                    "localtxmonitor"
+                ],
+                "summary": "Subscribe to mempool changes using a websocket",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxsubmission/evaluate": {
+            "post": {
+                "description": "Evaluate the Plutus scripts of a transaction to find the execution units for each redeemer, before it is submitted. The transaction can be signed or unsigned. The body is either the transaction CBOR like the /localtxsubmission/tx endpoint, or JSON with the transaction CBOR as hex or base64 and additional UTxOs. The additional UTxOs are used for inputs that aren't on chain yet, such as the outputs of transactions that haven't been submitted, and the other inputs, collateral, and reference inputs are looked up from the node. If a script fails, the error details have the error and trace for each redeemer. Script evaluation needs an evaluator backend, and the status is 501 if the server doesn't have one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Evaluate Tx",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/json",
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Transaction and additional UTxOs, for JSON requests",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.requestTxEvaluate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseTxEvaluation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxsubmission/tx": {
+            "post": {
+                "description": "Submit an already serialized transaction to the network. The body is the transaction CBOR, either raw or encoded as hex (optionally with a 0x prefix) or standard or URL-safe base64, which are detected in that order. Whitespace in encoded bodies is ignored. The detected encoding is returned in the X-Tx-Encoding header. If the body can't be decoded, the error details list the attempted encodings. If the node rejects the transaction, the error details have its era and the failed ledger rules, decoded where known, along with the rejection CBOR. Before the transaction is sent to the node, it is checked against the current protocol parameters and slot. It must be for a supported era, be within the max TX size unless that check is disabled, have witnesses, not be past its TTL, and pay at least the min fee for its size. Each failed check has its own error code. The skip_validation query parameter skips these checks. Retries with the same Idempotency-Key header and body get the response to the first request, unless it was a server error. If callbacks are enabled, the X-Callback-Url header sets a URL that is sent a POST once the transaction has the configured number of confirmations, or when it expires without being included. The callback body is JSON with the event (confirmed or expired), the transaction ID, and the block, and it is signed with an HMAC-SHA256 of the body in the X-Signature-256 header.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Submit Tx",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to identify retries of the same submission",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL to send a callback to when the transaction is confirmed",
+                        "name": "X-Callback-Url",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to skip the checks before submitting",
+                        "name": "skip_validation",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Ok",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxSubmission"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxsubmission/tx/wait": {
+            "post": {
+                "description": "Submit an already serialized transaction to the network, like the /localtxsubmission/tx endpoint, and then wait until it reaches the mempool or is included in a block. The wait mode is mempool (the default), block, or confirmations:N to wait for the block with the transaction and N-1 blocks after it. The response has the furthest stage that the transaction reached, and the block it was included in for the block stage. If the wait times out, the response is sent with a 202 status and the stage that was reached. Idempotency-Key headers aren't supported, since retries of a wait should wait again.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Submit Tx and wait",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stage to wait for: mempool, block, or confirmations:N",
+                        "name": "wait",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to skip the checks before submitting",
+                        "name": "skip_validation",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxSubmissionWait"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseLocalTxSubmissionWait"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/localtxsubmission/txs": {
+            "post": {
+                "description": "Submit a list of already serialized transactions to the network, in order over a single node connection, so that transactions can spend the outputs of earlier ones. Each transaction is its CBOR encoded as hex or standard or URL-safe base64. The results are in the same order as the request, with the transaction ID or the error for each one. Invalid transactions, including those that fail the same checks as the /localtxsubmission/tx endpoint, are found before anything is submitted. With stop_on_error (the default), nothing is submitted if any transaction is invalid, and the transactions after one that is rejected are skipped. Failures communicating with the node always skip the rest. The response status is 202 if every transaction was accepted, and 200 otherwise.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Submit Tx batch",
+                "parameters": [
+                    {
+                        "description": "Transactions",
+                        "name": "txs",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to skip the rest of the batch after a failure (default true)",
+                        "name": "stop_on_error",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to skip the checks before submitting",
+                        "name": "skip_validation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to identify retries of the same submission",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responseTxBatchItem"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.responseTxBatchItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "500": {
+                        "description": "Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/node/connection": {
+            "get": {
+                "description": "Returns the details negotiated by the connection manager's current handshake with the node, the protocol versions that were offered, and the requests served per mini-protocol since it connected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "node"
+                ],
+                "summary": "Node Connection",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseNodeConnection"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/submit/tx": {
+            "post": {
+                "description": "Submits a transaction with the same request and responses as cardano-submit-api, for clients that expect its contract. It's only served when API_SUBMIT_API_COMPAT is enabled, and always at /api/submit/tx rather than under the versioned base path. The body is the raw transaction CBOR with the application/cbor content type, and the response is the transaction ID as a JSON string. Errors for bodies that can't be decoded are JSON strings, such as the message for hex-encoded bodies. Failures from the node are tagged objects in the format of cardano-submit-api, with the failed ledger rules rendered as text for rejections. The node endpoint that served the request is returned in the X-Node-Endpoint header.",
+                "consumes": [
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "localtxsubmission"
+                ],
+                "summary": "Submit Tx (cardano-submit-api compatible)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Transaction ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.submitApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    },
+                    "500": {
+                        "description": "Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.submitApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tx/decode": {
+            "post": {
+                "description": "Decode a transaction to JSON, without a connection to the node. The body is the transaction CBOR, either raw or encoded as hex or base64, like the /localtxsubmission/tx endpoint. The era is the earliest one whose format the transaction can be decoded as. Outputs have the transaction ID and their index, like UTxOs, and the collateral return output has the index after the last output. Certificates have their type and CBOR. Metadata is rendered as JSON like cardano-cli does without a schema, with the names of well-known CIP-10 labels, or as CBOR if it can't be rendered that way. The witnesses are counted by type.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "Decode Tx",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseTxDecode"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tx/hash": {
+            "post": {
+                "description": "Returns the ID of a signed or unsigned transaction, which is the blake2b-256 hash of the transaction body. The body is hashed as it appears in the request CBOR, since encoding it again may not give the same bytes. The request body is either a transaction or a bare transaction body, as raw CBOR or encoded as hex or base64, like the /localtxsubmission/tx endpoint.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "Hash Tx",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseTxHash"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tx/metadata": {
+            "post": {
+                "description": "Returns the auxiliary data of a transaction, without a connection to the node. The body is the transaction CBOR, either raw or encoded as hex or base64, like the /localtxsubmission/tx endpoint. Metadata is rendered as JSON in the same schemas as cardano-cli. With no_schema, byte strings are hex with a 0x prefix and map keys are strings. With detailed, each value is an object with its type. Metadata that can't be rendered in the schema, like a map with list keys without a schema, has its CBOR in hex instead. Scripts in the auxiliary data have their type and CBOR in hex.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "Tx Metadata",
+                "parameters": [
+                    {
+                        "enum": [
+                            "application/cbor",
+                            "application/octet-stream",
+                            "text/plain"
+                        ],
+                        "type": "string",
+                        "description": "Content type",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "no_schema",
+                            "detailed"
+                        ],
+                        "type": "string",
+                        "default": "no_schema",
+                        "description": "Metadata JSON schema",
+                        "name": "schema",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseTxAuxData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/api.responseApiError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.requestLocalStateQueryRaw": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "acquire": {
+                    "type": "string",
+                    "enum": [
+                        "volatile",
+                        "immutable",
+                        "point"
+                    ],
+                    "example": "volatile"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "820082008206811a"
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 134217728
+                }
+            }
+        },
+        "api.requestLocalStateQueryUtxoTxIn": {
+            "type": "object",
+            "properties": {
+                "output_index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "tx_hash": {
+                    "type": "string",
+                    "example": "9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"
+                }
+            }
+        },
+        "api.requestTxEvaluate": {
+            "type": "object",
+            "required": [
+                "tx"
+            ],
+            "properties": {
+                "additional_utxos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.requestTxEvaluateUtxo"
+                    }
+                },
+                "tx": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.requestTxEvaluateUtxo": {
+            "type": "object",
+            "properties": {
+                "output": {
+                    "type": "string"
+                },
+                "output_index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "tx_hash": {
+                    "type": "string",
+                    "example": "9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"
+                }
+            }
+        },
+        "api.responseApiError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "bad_request",
+                        "unauthorized",
+                        "forbidden",
+                        "not_found",
+                        "method_not_allowed",
+                        "unsupported_media_type",
+                        "rate_limited",
+                        "timeout",
+                        "invalid_cbor",
+                        "tx_rejected",
+                        "node_unavailable",
+                        "node_error",
+                        "acquire_failed",
+                        "beyond_horizon",
+                        "utxo_not_found",
+                        "request_too_large",
+                        "invalid_encoding",
+                        "tx_too_large",
+                        "idempotency_conflict",
+                        "callback_limit",
+                        "unsupported_era",
+                        "missing_witnesses",
+                        "tx_expired",
+                        "fee_too_low",
+                        "script_failure",
+                        "evaluation_unavailable",
+                        "not_in_mempool",
+                        "stream_limit",
+                        "internal_error"
+                    ],
+                    "example": "node_unavailable"
+                },
+                "details": {},
+                "msg": {
+                    "type": "string",
+                    "example": "error message"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "0b8e5a8e-3e0f-4b6a-9d55-1c1c2b9f8f51"
+                }
+            }
+        },
+        "api.responseConvert": {
+            "type": "object",
+            "properties": {
+                "era": {
+                    "type": "string",
+                    "example": "conway"
+                },
+                "projected": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 133660800
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2024-09-01T21:44:51Z"
+                }
+            }
+        },
+        "api.responseCostModel": {
+            "type": "object",
+            "properties": {
+                "named": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.responseDrep": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "drep1yfxhc8568u4ccm27pgdjc02wtas8rq5n5j6ud4lglyqsyqc4zuzvc"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "key_hash",
+                        "script_hash",
+                        "abstain",
+                        "no_confidence"
+                    ],
+                    "example": "key_hash"
+                }
+            }
+        },
+        "api.responseExecutionUnitPrices": {
+            "type": "object",
+            "properties": {
+                "priceMemory": {
+                    "type": "number",
+                    "example": 0.0577
+                },
+                "priceSteps": {
+                    "type": "number",
+                    "example": 7.21e-05
+                }
+            }
+        },
+        "api.responseExecutionUnits": {
+            "type": "object",
+            "properties": {
+                "memory": {
+                    "type": "integer",
+                    "example": 14000000
+                },
+                "steps": {
+                    "type": "integer",
+                    "example": 10000000000
+                }
+            }
+        },
+        "api.responseGovActionId": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "tx_hash": {
+                    "type": "string",
+                    "example": "b4c5c5e2c9abe6d8bbd3f5a2ca43a21d1e2b8c0a74dc4d6a70e2d0e5d1c9f4e1"
+                }
+            }
+        },
+        "api.responseGovernanceAnchor": {
+            "type": "object",
+            "properties": {
+                "data_hash": {
+                    "type": "string",
+                    "example": "ca41a91f399259bcefe57f9858e91f6d00e1a38d6d9c63d4052914ea7bd70cb2"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "ipfs://bafkreifnwj6zpu3ixa4siz2lndqybyc5wnnt3jkwyutci4e2tmbnj3xrdm"
+                }
+            }
+        },
+        "api.responseGovernanceCommittee": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceCommitteeMember"
+                    }
+                },
+                "threshold": {
+                    "type": "string",
+                    "example": "0.66666666666666666667"
+                }
+            }
+        },
+        "api.responseGovernanceCommitteeMember": {
+            "type": "object",
+            "properties": {
+                "cold_credential": {
+                    "$ref": "#/definitions/api.responseGovernanceCredential"
+                },
+                "expiry_epoch": {
+                    "type": "integer",
+                    "example": 580
+                }
+            }
+        },
+        "api.responseGovernanceCommitteeMemberState": {
+            "type": "object",
+            "properties": {
+                "cold_credential": {
+                    "$ref": "#/definitions/api.responseGovernanceCredential"
+                },
+                "expiry_epoch": {
+                    "type": "integer",
+                    "example": 580
+                },
+                "hot_credential": {
+                    "$ref": "#/definitions/api.responseGovernanceCredential"
+                },
+                "hot_credential_status": {
+                    "type": "string",
+                    "enum": [
+                        "authorized",
+                        "not_authorized",
+                        "resigned"
+                    ],
+                    "example": "authorized"
+                },
+                "resignation_anchor": {
+                    "$ref": "#/definitions/api.responseGovernanceAnchor"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "expired",
+                        "unrecognized"
+                    ],
+                    "example": "active"
+                }
+            }
+        },
+        "api.responseGovernanceCredential": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string",
+                    "example": "0c86027437572ce5449eeb24ebff9c1c01adb7b26a8b5d434f6b2c88"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "cc_cold1zgxgvqn5xatjee2ynm4jf6llnswqrtdhkf4gkh2rfa4jezquzmxw5"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "key_hash",
+                        "script_hash"
+                    ],
+                    "example": "key_hash"
+                }
+            }
+        },
+        "api.responseGovernanceCredentialVote": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/api.responseGovernanceCredential"
+                },
+                "vote": {
+                    "type": "string",
+                    "enum": [
+                        "yes",
+                        "no",
+                        "abstain"
+                    ],
+                    "example": "yes"
+                }
+            }
+        },
+        "api.responseGovernanceDrep": {
+            "type": "object",
+            "properties": {
+                "anchor": {
+                    "$ref": "#/definitions/api.responseGovernanceAnchor"
+                },
+                "deposit": {
+                    "type": "integer",
+                    "example": 500000000
+                },
+                "drep": {
+                    "$ref": "#/definitions/api.responseDrep"
+                },
+                "expiry_epoch": {
+                    "type": "integer",
+                    "example": 600
+                }
+            }
+        },
+        "api.responseGovernanceDrepStake": {
+            "type": "object",
+            "properties": {
+                "drep": {
+                    "$ref": "#/definitions/api.responseDrep"
+                },
+                "stake": {
+                    "type": "string",
+                    "example": "1234567890"
+                }
+            }
+        },
+        "api.responseGovernancePoolVote": {
+            "type": "object",
+            "properties": {
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "vote": {
+                    "type": "string",
+                    "enum": [
+                        "yes",
+                        "no",
+                        "abstain"
+                    ],
+                    "example": "yes"
+                }
+            }
+        },
+        "api.responseGovernancePrevActionIds": {
+            "type": "object",
+            "properties": {
+                "committee": {
+                    "$ref": "#/definitions/api.responseGovActionId"
+                },
+                "constitution": {
+                    "$ref": "#/definitions/api.responseGovActionId"
+                },
+                "hard_fork": {
+                    "$ref": "#/definitions/api.responseGovActionId"
+                },
+                "parameter_change": {
+                    "$ref": "#/definitions/api.responseGovActionId"
+                }
+            }
+        },
+        "api.responseGovernanceProposal": {
+            "type": "object",
+            "properties": {
+                "action_id": {
+                    "$ref": "#/definitions/api.responseGovActionId"
+                },
+                "anchor": {
+                    "$ref": "#/definitions/api.responseGovernanceAnchor"
+                },
+                "deposit": {
+                    "type": "integer",
+                    "example": 100000000000
+                },
+                "expiry_epoch": {
+                    "type": "integer",
+                    "example": 513
+                },
+                "proposed_epoch": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "return_address": {
+                    "type": "string",
+                    "example": "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "parameter_change",
+                        "hard_fork",
+                        "treasury_withdrawals",
+                        "no_confidence",
+                        "update_committee",
+                        "new_constitution",
+                        "info"
+                    ],
+                    "example": "info"
+                },
+                "vote_counts": {
+                    "$ref": "#/definitions/api.responseGovernanceVoteTallies"
+                },
+                "votes": {
+                    "$ref": "#/definitions/api.responseGovernanceVotes"
+                }
+            }
+        },
+        "api.responseGovernanceVoteCounts": {
+            "type": "object",
+            "properties": {
+                "abstain": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "no": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "yes": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "api.responseGovernanceVoteTallies": {
+            "type": "object",
+            "properties": {
+                "committee": {
+                    "$ref": "#/definitions/api.responseGovernanceVoteCounts"
+                },
+                "drep": {
+                    "$ref": "#/definitions/api.responseGovernanceVoteCounts"
+                },
+                "spo": {
+                    "$ref": "#/definitions/api.responseGovernanceVoteCounts"
+                }
+            }
+        },
+        "api.responseGovernanceVotes": {
+            "type": "object",
+            "properties": {
+                "committee": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceCredentialVote"
+                    }
+                },
+                "drep": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceCredentialVote"
+                    }
+                },
+                "spo": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernancePoolVote"
+                    }
+                }
+            }
+        },
+        "api.responseLocalStateQueryBlockHeight": {
+            "type": "object",
+            "properties": {
+                "block_no": {
+                    "type": "integer",
+                    "example": 10817965
+                },
+                "slot_no": {
+                    "type": "integer",
+                    "example": 133427511
+                }
+            }
+        },
+        "api.responseLocalStateQueryCommittee": {
+            "type": "object",
+            "properties": {
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceCommitteeMemberState"
+                    }
+                },
+                "threshold": {
+                    "type": "string",
+                    "example": "0.66666666666666666667"
+                }
+            }
+        },
+        "api.responseLocalStateQueryConstitution": {
+            "type": "object",
+            "properties": {
+                "anchor": {
+                    "$ref": "#/definitions/api.responseGovernanceAnchor"
+                },
+                "guardrails_script_hash": {
+                    "type": "string",
+                    "example": "fa24fb305126805cf2164c161d852a0e7330cf988f1fe558cf7d4a64"
+                }
+            }
+        },
+        "api.responseLocalStateQueryCostModels": {
+            "type": "object",
+            "properties": {
+                "cost_models": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.responseCostModel"
+                    }
+                },
+                "execution_unit_prices": {
+                    "$ref": "#/definitions/api.responseExecutionUnitPrices"
+                },
+                "max_block_execution_units": {
+                    "$ref": "#/definitions/api.responseExecutionUnits"
+                },
+                "max_tx_execution_units": {
+                    "$ref": "#/definitions/api.responseExecutionUnits"
+                }
+            }
+        },
+        "api.responseLocalStateQueryCurrentEra": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.responseLocalStateQueryDrepStake": {
+            "type": "object",
+            "properties": {
+                "dreps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceDrepStake"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "ACJMn3j01Hp7P6QVS3kXPPqJ6-hpFB0-7ZPahEJM"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseLocalStateQueryDreps": {
+            "type": "object",
+            "properties": {
+                "dreps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceDrep"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "ACJMn3j01Hp7P6QVS3kXPPqJ6-hpFB0-7ZPahEJM"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseLocalStateQueryEpoch": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string",
+                    "example": "2024-09-06T21:44:51Z"
+                },
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "first_slot": {
+                    "type": "integer",
+                    "example": 133660800
+                },
+                "last_slot": {
+                    "type": "integer",
+                    "example": 134092799
+                },
+                "start_time": {
+                    "type": "string",
+                    "example": "2024-09-01T21:44:51Z"
+                }
+            }
+        },
+        "api.responseLocalStateQueryEra": {
+            "type": "object",
+            "properties": {
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2024-10-08T06:36:42Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "name": {
+                    "type": "string",
+                    "example": "conway"
+                },
+                "start_slot": {
+                    "type": "integer",
+                    "example": 133660800
+                }
+            }
+        },
+        "api.responseLocalStateQueryEraHistory": {
+            "type": "object",
+            "properties": {
+                "eras": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseLocalStateQueryEraHistoryEra"
+                    }
+                },
+                "system_start": {
+                    "type": "string",
+                    "example": "2017-09-23T21:44:51Z"
+                }
+            }
+        },
+        "api.responseLocalStateQueryEraHistoryBound": {
+            "type": "object",
+            "properties": {
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 208
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 4492800
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2020-07-29T21:44:51Z"
+                }
+            }
+        },
+        "api.responseLocalStateQueryEraHistoryEra": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "$ref": "#/definitions/api.responseLocalStateQueryEraHistoryBound"
+                },
+                "era": {
+                    "type": "string",
+                    "example": "shelley"
+                },
+                "parameters": {
+                    "$ref": "#/definitions/api.responseLocalStateQueryEraHistoryParams"
+                },
+                "start": {
+                    "$ref": "#/definitions/api.responseLocalStateQueryEraHistoryBound"
+                }
+            }
+        },
+        "api.responseLocalStateQueryEraHistoryParams": {
+            "type": "object",
+            "properties": {
+                "epoch_length": {
+                    "type": "integer",
+                    "example": 432000
+                },
+                "safe_zone": {
+                    "type": "integer",
+                    "example": 129600
+                },
+                "slot_length_milliseconds": {
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "api.responseLocalStateQueryGenesis": {
+            "type": "object",
+            "properties": {
+                "active_slots_coefficient": {
+                    "$ref": "#/definitions/api.responseRational"
+                },
+                "epoch_length": {
+                    "type": "integer",
+                    "example": 432000
+                },
+                "max_kes_evolutions": {
+                    "type": "integer",
+                    "example": 62
+                },
+                "max_lovelace_supply": {
+                    "type": "integer",
+                    "example": 45000000000000000
+                },
+                "network_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "network_magic": {
+                    "type": "integer",
+                    "example": 764824073
+                },
+                "security_param": {
+                    "type": "integer",
+                    "example": 2160
+                },
+                "slot_length_milliseconds": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "slots_per_kes_period": {
+                    "type": "integer",
+                    "example": 129600
+                },
+                "system_start": {
+                    "type": "string",
+                    "example": "2017-09-23T21:44:51Z"
+                },
+                "update_quorum": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "api.responseLocalStateQueryGovState": {
+            "type": "object",
+            "properties": {
+                "committee": {
+                    "$ref": "#/definitions/api.responseGovernanceCommittee"
+                },
+                "prev_gov_action_ids": {
+                    "$ref": "#/definitions/api.responseGovernancePrevActionIds"
+                },
+                "protocol_version": {
+                    "$ref": "#/definitions/api.responseProtocolVersion"
+                },
+                "treasury": {
+                    "type": "string",
+                    "example": "1523463600599097"
+                }
+            }
+        },
+        "api.responseLocalStateQueryProposals": {
+            "type": "object",
+            "properties": {
+                "proposals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseGovernanceProposal"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseLocalStateQueryProtocolParams": {
+            "type": "object"
+        },
+        "api.responseLocalStateQueryRaw": {
+            "type": "object",
+            "properties": {
+                "diagnostic": {
+                    "type": "object"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "8119020d"
+                }
+            }
+        },
+        "api.responseLocalStateQuerySpoStake": {
+            "type": "object",
+            "properties": {
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responsePoolVotingStake"
+                    }
+                },
+                "total": {
+                    "type": "string",
+                    "example": "64135748075088"
+                }
+            }
+        },
+        "api.responseLocalStateQueryStakeAddress": {
+            "type": "object",
+            "properties": {
+                "drep": {
+                    "$ref": "#/definitions/api.responseDrep"
+                },
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "registered": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "rewards": {
+                    "type": "integer",
+                    "example": 1500000
+                },
+                "stake_address": {
+                    "type": "string",
+                    "example": "stake1uxpdrerp9wrxunfh6ukyv5267j70fzxgw0fr3z8zeac5vyqhf9jhy"
+                }
+            }
+        },
+        "api.responseLocalStateQueryStakeDistribution": {
+            "type": "object",
+            "properties": {
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseStakeDistributionPool"
+                    }
+                }
+            }
+        },
+        "api.responseLocalStateQueryStakeSnapshots": {
+            "type": "object",
+            "properties": {
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responsePoolSnapshots"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/api.responsePoolSnapshotsStake"
+                }
+            }
+        },
+        "api.responseLocalStateQuerySystemStart": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "type": "integer",
+                    "example": 266
+                },
+                "picoseconds": {
+                    "type": "integer",
+                    "example": 78291000000000000
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2017-09-23T21:44:51Z"
+                },
+                "year": {
+                    "type": "integer",
+                    "example": 2017
                 }
             }
         },
         "api.responseLocalStateQueryTip": {
             "type": "object",
             "properties": {
-                "block_no": {
+                "block_no": {
+                    "type": "integer",
+                    "example": 10817965
+                },
+                "epoch_no": {
+                    "type": "integer",
+                    "example": 507
+                },
+                "era": {
+                    "type": "string",
+                    "example": "Conway"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "4e7e3a23e3da6bb6e1a8f20fc3e335f513dcd1b1a6e68a481c4e970b23d25db1"
+                },
+                "slot_no": {
+                    "type": "integer",
+                    "example": 133427511
+                },
+                "slot_time": {
+                    "type": "string",
+                    "example": "2024-10-08T06:36:42Z"
+                }
+            }
+        },
+        "api.responseLocalStateQueryUtxos": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string",
+                    "example": "mlt7SjqJ4KHw_B0dodvVjjiUx6FOosvMic1uOk-fH18AAAAA"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "utxos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseUtxo"
+                    }
+                }
+            }
+        },
+        "api.responseLocalTxMonitorHasTx": {
+            "type": "object",
+            "properties": {
+                "present": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 134217728
+                }
+            }
+        },
+        "api.responseLocalTxMonitorSizes": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 178176
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8920
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 134217728
+                },
+                "tx_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "utilization": {
+                    "type": "number",
+                    "example": 5.01
+                }
+            }
+        },
+        "api.responseLocalTxMonitorTx": {
+            "type": "object",
+            "properties": {
+                "cbor": {
+                    "type": "string",
+                    "example": "84a400..."
+                },
+                "era": {
+                    "type": "string",
+                    "example": "conway"
+                },
+                "matched_outputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseUtxo"
+                    }
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 512
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseLocalTxMonitorTxCbor": {
+            "type": "object",
+            "properties": {
+                "cbor": {
+                    "type": "string",
+                    "example": "84a400..."
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 512
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 134217728
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseLocalTxMonitorTxs": {
+            "type": "object",
+            "properties": {
+                "slot": {
+                    "type": "integer",
+                    "example": 134217728
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "tx_count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "txs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseLocalTxMonitorTx"
+                    }
+                }
+            }
+        },
+        "api.responseLocalTxSubmission": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "accepted",
+                        "already_submitted"
+                    ],
+                    "example": "accepted"
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseLocalTxSubmissionWait": {
+            "type": "object",
+            "properties": {
+                "block": {
+                    "$ref": "#/definitions/api.responseTxBlock"
+                },
+                "confirmations": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "error": {
+                    "type": "string",
+                    "example": "server shutting down"
+                },
+                "stage": {
+                    "type": "string",
+                    "enum": [
+                        "submitted",
+                        "mempool",
+                        "block"
+                    ],
+                    "example": "block"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "accepted",
+                        "already_submitted"
+                    ],
+                    "example": "accepted"
+                },
+                "timed_out": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseNodeConnection": {
+            "type": "object",
+            "properties": {
+                "connected_at": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "network_magic": {
+                    "type": "integer"
+                },
+                "offered_protocol_versions": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "protocol_version": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.responsePool": {
+            "type": "object",
+            "properties": {
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "pool_id_hex": {
+                    "type": "string",
+                    "example": "0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735"
+                }
+            }
+        },
+        "api.responsePoolParams": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer",
+                    "example": 170000000
+                },
+                "margin": {
+                    "type": "number",
+                    "example": 0.01
+                },
+                "metadata_hash": {
+                    "type": "string",
+                    "example": "b8c4b1fd4f7e74f1c5eead7d9b5de4cc4b8c2bd0c1a03e8b3fcaf6c9b8fa2f6e"
+                },
+                "metadata_url": {
+                    "type": "string",
+                    "example": "https://example.com/pool.json"
+                },
+                "owners": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pledge": {
+                    "type": "integer",
+                    "example": 100000000000
+                },
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "pool_id_hex": {
+                    "type": "string",
+                    "example": "0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735"
+                },
+                "relays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responsePoolRelay"
+                    }
+                },
+                "reward_account": {
+                    "type": "string",
+                    "example": "stake1uxpdrerp9wrxunfh6ukyv5267j70fzxgw0fr3z8zeac5vyqhf9jhy"
+                },
+                "vrf_key_hash": {
+                    "type": "string",
+                    "example": "b348e7ce3a7356e3b3ddbb2d8989de39247c4fe0e7d6e4abc47ac0ade1ae5d5d"
+                }
+            }
+        },
+        "api.responsePoolRelay": {
+            "type": "object",
+            "properties": {
+                "host": {
+                    "type": "string",
+                    "example": "relay.example.com"
+                },
+                "ipv4": {
+                    "type": "string",
+                    "example": "192.0.2.1"
+                },
+                "ipv6": {
+                    "type": "string",
+                    "example": "2001:db8::1"
+                },
+                "port": {
+                    "type": "integer",
+                    "example": 3001
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "address",
+                        "host",
+                        "dns"
+                    ],
+                    "example": "host"
+                }
+            }
+        },
+        "api.responsePoolSnapshots": {
+            "type": "object",
+            "properties": {
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "stake": {
+                    "$ref": "#/definitions/api.responsePoolSnapshotsStake"
+                }
+            }
+        },
+        "api.responsePoolSnapshotsStake": {
+            "type": "object",
+            "properties": {
+                "go": {
+                    "type": "string",
+                    "example": "64117030928998"
+                },
+                "mark": {
+                    "type": "string",
+                    "example": "64135748075088"
+                },
+                "set": {
+                    "type": "string",
+                    "example": "64126196541243"
+                }
+            }
+        },
+        "api.responsePoolVotingStake": {
+            "type": "object",
+            "properties": {
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "stake": {
+                    "type": "string",
+                    "example": "64135748075088"
+                }
+            }
+        },
+        "api.responseProtocolParameters": {
+            "type": "object",
+            "properties": {
+                "a0": {
+                    "type": "number",
+                    "example": 0.3
+                },
+                "collateralPercentage": {
+                    "type": "integer",
+                    "example": 150
+                },
+                "committeeMaxTermLength": {
+                    "type": "integer",
+                    "example": 146
+                },
+                "committeeMinSize": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "costModels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "dRepActivity": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "dRepDeposit": {
+                    "type": "integer",
+                    "example": 500000000
+                },
+                "dRepVotingThresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "decentralization": {
+                    "type": "number"
+                },
+                "era": {
+                    "type": "string",
+                    "example": "conway"
+                },
+                "executionUnitPrices": {
+                    "$ref": "#/definitions/api.responseExecutionUnitPrices"
+                },
+                "govActionDeposit": {
+                    "type": "integer",
+                    "example": 100000000000
+                },
+                "govActionLifetime": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "keyDeposit": {
+                    "type": "integer",
+                    "example": 2000000
+                },
+                "maxBlockBodySize": {
+                    "type": "integer",
+                    "example": 90112
+                },
+                "maxBlockExecutionUnits": {
+                    "$ref": "#/definitions/api.responseExecutionUnits"
+                },
+                "maxBlockHeaderSize": {
+                    "type": "integer",
+                    "example": 1100
+                },
+                "maxCollateralInputs": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "maxEpoch": {
+                    "type": "integer",
+                    "example": 18
+                },
+                "maxTxExecutionUnits": {
+                    "$ref": "#/definitions/api.responseExecutionUnits"
+                },
+                "maxTxSize": {
+                    "type": "integer",
+                    "example": 16384
+                },
+                "maxValueSize": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "minFeeA": {
+                    "type": "integer",
+                    "example": 44
+                },
+                "minFeeB": {
+                    "type": "integer",
+                    "example": 155381
+                },
+                "minFeeRefScriptCostPerByte": {
+                    "type": "number",
+                    "example": 15
+                },
+                "minPoolCost": {
+                    "type": "integer",
+                    "example": 170000000
+                },
+                "minUtxoValue": {
                     "type": "integer"
                 },
-                "epoch_no": {
+                "nOpt": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "poolDeposit": {
+                    "type": "integer",
+                    "example": 500000000
+                },
+                "poolVotingThresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "protocolVersion": {
+                    "$ref": "#/definitions/api.responseProtocolVersion"
+                },
+                "rho": {
+                    "type": "number",
+                    "example": 0.003
+                },
+                "tau": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "utxoCostPerByte": {
+                    "type": "integer",
+                    "example": 4310
+                },
+                "utxoCostPerWord": {
                     "type": "integer"
+                }
+            }
+        },
+        "api.responseProtocolVersion": {
+            "type": "object",
+            "properties": {
+                "major": {
+                    "type": "integer",
+                    "example": 9
                 },
-                "era": {
-                    "type": "string"
+                "minor": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseRational": {
+            "type": "object",
+            "properties": {
+                "decimal": {
+                    "type": "string",
+                    "example": "0.05"
+                },
+                "denominator": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "numerator": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseStakeDistributionPool": {
+            "type": "object",
+            "properties": {
+                "pool_id": {
+                    "type": "string",
+                    "example": "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+                },
+                "stake_fraction": {
+                    "type": "string",
+                    "example": "0.00287856386020331632"
+                },
+                "vrf_key_hash": {
+                    "type": "string",
+                    "example": "b348e7ce3a7356e3b3ddbb2d8989de39247c4fe0e7d6e4abc47ac0ade1ae5d5d"
+                }
+            }
+        },
+        "api.responseTxAuxData": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxMetadata"
+                    }
+                },
+                "scripts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxScript"
+                    }
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseTxBatchItem": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/api.responseApiError"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "accepted",
+                        "already_submitted",
+                        "invalid",
+                        "rejected",
+                        "failed",
+                        "skipped"
+                    ],
+                    "example": "accepted"
                 },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseTxBlock": {
+            "type": "object",
+            "properties": {
                 "hash": {
+                    "type": "string",
+                    "example": "4ac0a0a3a1f0a7ad3e1ed3a1c2b2bcbf143a6f1e00c4e1e5f5b4936a4f4aab62"
+                },
+                "number": {
+                    "type": "integer",
+                    "example": 10839424
+                },
+                "slot": {
+                    "type": "integer",
+                    "example": 130048962
+                }
+            }
+        },
+        "api.responseTxCertificate": {
+            "type": "object",
+            "properties": {
+                "cbor": {
+                    "type": "string",
+                    "example": "83028200581c..."
+                },
+                "type": {
+                    "type": "string",
+                    "example": "stake_delegation"
+                }
+            }
+        },
+        "api.responseTxDecode": {
+            "type": "object",
+            "properties": {
+                "auxiliary_data_hash": {
+                    "type": "string",
+                    "example": "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+                },
+                "certificates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxCertificate"
+                    }
+                },
+                "collateral": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxIn"
+                    }
+                },
+                "collateral_return": {
+                    "$ref": "#/definitions/api.responseUtxo"
+                },
+                "era": {
+                    "type": "string",
+                    "example": "conway"
+                },
+                "fee": {
+                    "type": "integer",
+                    "example": 168053
+                },
+                "inputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxIn"
+                    }
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxMetadata"
+                    }
+                },
+                "mint": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxMintAsset"
+                    }
+                },
+                "outputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseUtxo"
+                    }
+                },
+                "proposal_procedure_count": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "reference_inputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxIn"
+                    }
+                },
+                "required_signers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "e9c5bd6d1b4fb5ff9b4e5c7f4b5e1d1a8c2a6f3c5e7d9b1a3c5e7f91"
+                    ]
+                },
+                "script_data_hash": {
+                    "type": "string",
+                    "example": "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+                },
+                "total_collateral": {
+                    "type": "integer",
+                    "example": 5000000
+                },
+                "ttl": {
+                    "type": "integer",
+                    "example": 134217728
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "validity_interval_start": {
+                    "type": "integer",
+                    "example": 134210000
+                },
+                "withdrawals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxWithdrawal"
+                    }
+                },
+                "witnesses": {
+                    "$ref": "#/definitions/api.responseTxWitnesses"
+                }
+            }
+        },
+        "api.responseTxEvaluation": {
+            "type": "object",
+            "properties": {
+                "redeemers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseTxEvaluationRedeemer"
+                    }
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                }
+            }
+        },
+        "api.responseTxEvaluationRedeemer": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
-                "slot_no": {
-                    "type": "integer"
+                "execution_units": {
+                    "$ref": "#/definitions/api.responseExecutionUnits"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "purpose": {
+                    "type": "string",
+                    "enum": [
+                        "spend",
+                        "mint",
+                        "publish",
+                        "withdraw",
+                        "vote",
+                        "propose"
+                    ],
+                    "example": "spend"
+                },
+                "trace": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.responseLocalTxMonitorHasTx": {
+        "api.responseTxHash": {
             "type": "object",
             "properties": {
-                "has_tx": {
-                    "type": "boolean"
+                "input": {
+                    "type": "string",
+                    "enum": [
+                        "transaction",
+                        "transaction_body"
+                    ],
+                    "example": "transaction"
+                },
+                "tx_id": {
+                    "type": "string",
+                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
                 }
             }
         },
-        "api.responseLocalTxMonitorSizes": {
+        "api.responseTxIn": {
             "type": "object",
             "properties": {
-                "capacity": {
-                    "type": "integer"
+                "output_index": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "size": {
-                    "type": "integer"
+                "tx_hash": {
+                    "type": "string",
+                    "example": "9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"
+                }
+            }
+        },
+        "api.responseTxMetadata": {
+            "type": "object",
+            "properties": {
+                "cbor": {
+                    "type": "string",
+                    "example": "a1636d736781656869207468657265"
                 },
-                "tx_count": {
-                    "type": "integer"
+                "json": {},
+                "label": {
+                    "type": "integer",
+                    "example": 674
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CIP-20 message"
                 }
             }
         },
-        "api.responseLocalTxMonitorTxs": {
+        "api.responseTxMintAsset": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "MIN"
+                },
+                "name_hex": {
+                    "type": "string",
+                    "example": "4d494e"
+                },
+                "policy_id": {
+                    "type": "string",
+                    "example": "29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "api.responseTxScript": {
+            "type": "object",
+            "properties": {
+                "cbor": {
+                    "type": "string",
+                    "example": "8200581c29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "native",
+                        "plutus_v1",
+                        "plutus_v2",
+                        "plutus_v3"
+                    ],
+                    "example": "native"
+                }
+            }
+        },
+        "api.responseTxWithdrawal": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"
+                },
+                "lovelace": {
+                    "type": "integer",
+                    "example": 1500000
+                }
+            }
+        },
+        "api.responseTxWitnesses": {
+            "type": "object",
+            "properties": {
+                "bootstrap": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "native_scripts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "plutus_data": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "plutus_v1_scripts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "plutus_v2_scripts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "plutus_v3_scripts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "redeemers": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "vkeys": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.responseUtxo": {
             "type": "object",
             "properties": {
-                "tx_bytes": {
+                "address": {
+                    "type": "string",
+                    "example": "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"
+                },
+                "assets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.responseUtxoAsset"
+                    }
+                },
+                "datum_hash": {
+                    "type": "string",
+                    "example": "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+                },
+                "inline_datum": {
+                    "type": "string",
+                    "example": "d87980"
+                },
+                "lovelace": {
+                    "type": "integer",
+                    "example": 1500000
+                },
+                "output_index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "reference_script_hash": {
                     "type": "string",
-                    "format": "base64",
-                    "example": "\u003cbase64 encoded transaction bytes\u003e"
+                    "example": "e9c5bd6d1b4fb5ff9b4e5c7f4b5e1d1a8c2a6f3c5e7d9b1a3c5e7f91"
                 },
                 "tx_hash": {
                     "type": "string",
-                    "format": "base16",
-                    "example": "96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"
+                    "example": "9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"
+                }
+            }
+        },
+        "api.responseUtxoAsset": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "MIN"
+                },
+                "name_hex": {
+                    "type": "string",
+                    "example": "4d494e"
+                },
+                "policy_id": {
+                    "type": "string",
+                    "example": "29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "api.submitApiError": {
+            "type": "object",
+            "properties": {
+                "contents": {},
+                "tag": {
+                    "type": "string",
+                    "example": "TxSubmitFail"
                 }
             }
         }
@@ -462,7 +4453,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost",
-	BasePath:         "/api",
+	BasePath:         "/api/v1",
 	Schemes:          []string{"http"},
 	Title:            "cardano-node-api",
 	Description:      "Cardano Node API",
@@ -0,0 +1,250 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// accessLogFilter decides which requests are written to the access log. Paths
+// are prefixes relative to the base path. Requests that aren't logged are still
+// counted in metrics, since that's handled by separate middleware
+type accessLogFilter struct {
+	basePath     string
+	healthchecks bool
+	skipPrefixes atomic.Pointer[[]string]
+	samples      []*accessLogSample
+	// Routes for checking the skip paths when they're reloaded
+	routes gin.RoutesInfo
+}
+
+type accessLogSample struct {
+	prefix string
+	rate   uint64
+	count  atomic.Uint64
+}
+
+func newAccessLogFilter(cfg *config.Config) *accessLogFilter {
+	f := &accessLogFilter{
+		basePath:     cfg.Api.BasePath,
+		healthchecks: cfg.Logging.Healthchecks,
+	}
+	skipPrefixes := f.buildSkipPrefixes(cfg.Logging.SkipPaths)
+	f.skipPrefixes.Store(&skipPrefixes)
+	for prefix, rate := range cfg.Logging.SampleRates {
+		f.samples = append(
+			f.samples,
+			&accessLogSample{prefix: prefix, rate: uint64(rate)},
+		)
+	}
+	// Use the longest matching prefix for sampling
+	sort.Slice(f.samples, func(i, j int) bool {
+		return len(f.samples[i].prefix) > len(f.samples[j].prefix)
+	})
+	return f
+}
+
+// buildSkipPrefixes returns the configured skip paths along with the
+// healthcheck paths, if enabled
+func (f *accessLogFilter) buildSkipPrefixes(skipPaths []string) []string {
+	skipPrefixes := append([]string{}, skipPaths...)
+	if f.healthchecks {
+		skipPrefixes = append(
+			skipPrefixes,
+			"/healthcheck",
+			"/livez",
+			"/readyz",
+		)
+	}
+	return skipPrefixes
+}
+
+// setSkipPaths replaces the skip paths after checking them against the routes
+func (f *accessLogFilter) setSkipPaths(skipPaths []string) error {
+	skipPrefixes := f.buildSkipPrefixes(skipPaths)
+	if err := validateAccessLogPrefixes(
+		f.basePath,
+		skipPrefixes,
+		f.routes,
+	); err != nil {
+		return err
+	}
+	f.skipPrefixes.Store(&skipPrefixes)
+	return nil
+}
+
+// skip reports whether the request should be left out of the access log
+func (f *accessLogFilter) skip(c *gin.Context) bool {
+	path, ok := strings.CutPrefix(c.Request.URL.Path, f.basePath)
+	if !ok {
+		return false
+	}
+	for _, prefix := range *f.skipPrefixes.Load() {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	for _, sample := range f.samples {
+		if strings.HasPrefix(path, sample.prefix) {
+			// Log the first of every N requests
+			return (sample.count.Add(1)-1)%sample.rate != 0
+		}
+	}
+	return false
+}
+
+// validate returns an error for any configured prefix that doesn't match a
+// registered route, which is most likely a typo
+func (f *accessLogFilter) validate(routes gin.RoutesInfo) error {
+	f.routes = routes
+	prefixes := append([]string{}, *f.skipPrefixes.Load()...)
+	for _, sample := range f.samples {
+		prefixes = append(prefixes, sample.prefix)
+	}
+	return validateAccessLogPrefixes(f.basePath, prefixes, routes)
+}
+
+func validateAccessLogPrefixes(
+	basePath string,
+	prefixes []string,
+	routes gin.RoutesInfo,
+) error {
+	for _, prefix := range prefixes {
+		found := false
+		for _, route := range routes {
+			if strings.HasPrefix(route.Path, basePath+prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf(
+				"access log path %s does not match any route",
+				prefix,
+			)
+		}
+	}
+	return nil
+}
+
+// accessLogMiddleware writes an access log entry with the configured fields for
+// each request that isn't skipped by the filter
+func accessLogMiddleware(
+	logger *zap.Logger,
+	filter *accessLogFilter,
+	fieldNames []string,
+) gin.HandlerFunc {
+	enabled := make(map[string]bool, len(fieldNames))
+	for _, name := range fieldNames {
+		enabled[name] = true
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		// Other middleware may modify these
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+		c.Next()
+		if filter.skip(c) {
+			return
+		}
+		fields := accessLogFields(c, enabled, path, query, time.Since(start))
+		if len(c.Errors) > 0 {
+			for _, e := range c.Errors.Errors() {
+				logger.Error(e, fields...)
+			}
+			return
+		}
+		logger.Info("", fields...)
+	}
+}
+
+// accessLogFields returns the enabled access log fields for the request
+func accessLogFields(
+	c *gin.Context,
+	enabled map[string]bool,
+	path string,
+	query string,
+	latency time.Duration,
+) []zapcore.Field {
+	fields := []zapcore.Field{}
+	if enabled[config.AccessLogFieldStatus] {
+		fields = append(fields, zap.Int("status", c.Writer.Status()))
+	}
+	if enabled[config.AccessLogFieldMethod] {
+		fields = append(fields, zap.String("method", c.Request.Method))
+	}
+	if enabled[config.AccessLogFieldPath] {
+		fields = append(fields, zap.String("path", path))
+	}
+	if enabled[config.AccessLogFieldQuery] {
+		fields = append(fields, zap.String("query", query))
+	}
+	if enabled[config.AccessLogFieldIp] {
+		fields = append(fields, zap.String("ip", c.ClientIP()))
+	}
+	if enabled[config.AccessLogFieldUserAgent] {
+		fields = append(fields, zap.String("user-agent", c.Request.UserAgent()))
+	}
+	if enabled[config.AccessLogFieldLatency] {
+		fields = append(fields, zap.Duration("latency", latency))
+	}
+	if enabled[config.AccessLogFieldTime] {
+		fields = append(
+			fields,
+			zap.String("time", time.Now().UTC().Format(time.RFC3339)),
+		)
+	}
+	if enabled[config.AccessLogFieldResponseSize] {
+		// The size is negative when no body has been written
+		fields = append(
+			fields,
+			zap.Int("response_size", max(c.Writer.Size(), 0)),
+		)
+	}
+	if enabled[config.AccessLogFieldRequestId] {
+		if requestId := c.GetString(contextKeyRequestId); requestId != "" {
+			fields = append(fields, zap.String("request_id", requestId))
+		}
+	}
+	if enabled[config.AccessLogFieldClientCN] {
+		if clientCN := c.GetString(contextKeyClientCN); clientCN != "" {
+			fields = append(fields, zap.String("client_cn", clientCN))
+		}
+	}
+	if enabled[config.AccessLogFieldAuthSubject] {
+		if subject := c.GetString(contextKeyAuthSubject); subject != "" {
+			fields = append(fields, zap.String("auth_subject", subject))
+		}
+	}
+	if enabled[config.AccessLogFieldTrace] {
+		fields = append(fields, traceLogFields(c)...)
+	}
+	if enabled[config.AccessLogFieldTxHash] {
+		if txHash := c.GetString(contextKeyTxHash); txHash != "" {
+			fields = append(fields, zap.String("tx_hash", txHash))
+		}
+	}
+	return fields
+}
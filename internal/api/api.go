@@ -15,19 +15,28 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/blinklabs-io/cardano-node-api/internal/config"
 	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
+	"github.com/blinklabs-io/cardano-node-api/internal/version"
 
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
 	"github.com/penglongli/gin-metrics/ginmetrics"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	_ "github.com/blinklabs-io/cardano-node-api/docs" // docs is generated by Swag CLI
-	swaggerFiles "github.com/swaggo/files"            // swagger embed files
-	ginSwagger "github.com/swaggo/gin-swagger"        // gin-swagger middleware
+	"github.com/blinklabs-io/cardano-node-api/docs" // docs is generated by Swag CLI
+	swaggerFiles "github.com/swaggo/files"          // swagger embed files
+	ginSwagger "github.com/swaggo/gin-swagger"      // gin-swagger middleware
 )
 
 // @title			cardano-node-api
@@ -35,90 +44,393 @@ import (
 // @description	Cardano Node API
 // @host			localhost
 // @Schemes		http
-// @BasePath		/api
+// @BasePath		/api/v1
 // @contact.name	Blink Labs
 // @contact.url	https://blinklabs.io
 // @contact.email	support@blinklabs.io
 //
 // @license.name	Apache 2.0
 // @license.url	http://www.apache.org/licenses/LICENSE-2.0.html
-func Start(cfg *config.Config) error {
+func Start(ctx context.Context, cfg *config.Config) error {
 	// Disable gin debug and color output
 	gin.SetMode(gin.ReleaseMode)
 	gin.DisableConsoleColor()
 
 	// Configure API router
 	router := gin.New()
+	// Return 405 rather than 404 for routes that exist with other methods
+	router.HandleMethodNotAllowed = true
+	if err := configureTrustedProxies(router, cfg.Api); err != nil {
+		return err
+	}
 	// Catch panics and return a 500
 	router.Use(gin.Recovery())
 	// Standard logging
-	logger := logging.GetLogger()
+	logger := logging.GetLogger(logging.ComponentApi)
 	// Access logging
 	accessLogger := logging.GetAccessLogger()
-	skipPaths := []string{}
+	accessLogFilter := newAccessLogFilter(cfg)
 	if cfg.Logging.Healthchecks {
-		skipPaths = append(skipPaths, "/healthcheck")
-		logger.Infof("disabling access logs for /healthcheck")
-	}
-	router.Use(ginzap.GinzapWithConfig(accessLogger, &ginzap.Config{
-		TimeFormat: time.RFC3339,
-		UTC:        true,
-		SkipPaths:  skipPaths,
-	}))
+		logger.Infof("disabling access logs for /healthcheck, /livez, and /readyz")
+	}
+	if cfg.Logging.TxSubmitDebug {
+		logger.Warnf(
+			"logging TX submission request and response bodies at debug level, this should not be left enabled in production",
+		)
+	}
+	router.Use(requestIdMiddleware)
+	if tracing.Enabled() {
+		router.Use(tracingMiddleware())
+	}
+	router.Use(
+		accessLogMiddleware(
+			accessLogger,
+			accessLogFilter,
+			cfg.Logging.AccessLogFields,
+		),
+	)
 	router.Use(ginzap.RecoveryWithZap(accessLogger, true))
+	// Record the client certificate identity, if any
+	router.Use(clientCertMiddleware)
+
+	// All routes are registered under the base path, if any
+	baseGroup := router.Group(cfg.Api.BasePath)
+	apiRoutePrefix = cfg.Api.BasePath + "/api/"
+	if cfg.Api.BasePath != "" {
+		logger.Infof("using base path %s", cfg.Api.BasePath)
+	}
 
 	// Create a healthcheck
-	router.GET("/healthcheck", handleHealthcheck)
+	baseGroup.GET("/healthcheck", handleHealthcheck)
+	baseGroup.GET("/livez", handleLivez)
+	baseGroup.GET("/readyz", handleReadyz)
+	baseGroup.GET("/version", handleVersion)
 	// Create a swagger endpoint
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	docs.SwaggerInfo.BasePath = cfg.Api.BasePath + "/api/v1"
+	if version.Version != "" {
+		docs.SwaggerInfo.Version = version.Version
+	}
+	baseGroup.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Metrics
+	InitMetrics()
+	metrics := ginmetrics.GetMonitor()
+	metrics.SetMetricPath(cfg.Metrics.Path)
+	router.NoRoute(append(metricsMiddleware, handleNoRoute)...)
+	router.NoMethod(append(metricsMiddleware, handleNoMethod)...)
+	// Also serve metrics on the API listener if configured
+	if cfg.Metrics.ApiPath != "" {
+		baseGroup.GET(cfg.Metrics.ApiPath, gin.WrapH(promhttp.Handler()))
+	}
 
 	// Configure API routes
-	apiGroup := router.Group("/api")
-	configureChainSyncRoutes(apiGroup)
-	configureLocalStateQueryRoutes(apiGroup)
-	configureLocalTxMonitorRoutes(apiGroup)
-	configureLocalTxSubmissionRoutes(apiGroup)
+	apiGroup := baseGroup.Group("/api")
+	// Use metrics middleware without exposing path in main app router
+	// We only collect metrics on the API endpoints. This must be added before
+	// the routes are configured for it to apply to them
+	if len(cfg.Metrics.ExcludePaths) > 0 {
+		apiGroup.Use(
+			metricsFilterMiddleware(metricsMiddleware, cfg.Metrics.ExcludePaths)...,
+		)
+	} else {
+		apiGroup.Use(metricsMiddleware...)
+	}
+	if len(cfg.Api.Cors.AllowedOrigins) > 0 {
+		apiGroup.Use(corsMiddleware(cfg.Api.Cors))
+		// Preflight requests are answered by the CORS middleware, but they need a
+		// matching route for the group middleware to run at all
+		apiGroup.OPTIONS("/*path", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		logger.Infof(
+			"enabling CORS for origins: %s",
+			strings.Join(cfg.Api.Cors.AllowedOrigins, ", "),
+		)
+	}
+	if cfg.Api.Compression.Enabled {
+		apiGroup.Use(
+			compressionMiddleware(int(cfg.Api.Compression.MinSize)),
+		)
+		logger.Infof("enabling API response compression")
+	}
+	defaultLimiter := newRateLimiter(
+		cfg.Api.RateLimit.RequestsPerSecond,
+		cfg.Api.RateLimit.Burst,
+	)
+	submitLimiter := newRateLimiter(
+		cfg.Api.RateLimit.SubmitRequestsPerSecond,
+		cfg.Api.RateLimit.SubmitBurst,
+	)
+	lightLimiter := newRateLimiter(
+		cfg.Api.RateLimit.LightRequestsPerSecond,
+		cfg.Api.RateLimit.LightBurst,
+	)
+	// The middleware is always used so that limits can be enabled on reload
+	apiGroup.Use(rateLimitMiddleware(defaultLimiter, submitLimiter, lightLimiter))
+	if defaultLimiter.Enabled() || submitLimiter.Enabled() ||
+		lightLimiter.Enabled() {
+		logger.Infof("enabling per-client rate limiting")
+	}
+	config.OnReload(
+		"rate limits",
+		[]string{"api.rateLimit"},
+		func(cfg *config.Config) error {
+			defaultLimiter.SetLimit(
+				cfg.Api.RateLimit.RequestsPerSecond,
+				cfg.Api.RateLimit.Burst,
+			)
+			submitLimiter.SetLimit(
+				cfg.Api.RateLimit.SubmitRequestsPerSecond,
+				cfg.Api.RateLimit.SubmitBurst,
+			)
+			lightLimiter.SetLimit(
+				cfg.Api.RateLimit.LightRequestsPerSecond,
+				cfg.Api.RateLimit.LightBurst,
+			)
+			return nil
+		},
+	)
+	if cfg.Api.Auth.Mode != config.AuthModeNone {
+		apiGroup.Use(authMiddleware(cfg.Api.Auth))
+		logger.Infof(
+			"enabling API authentication in %q mode",
+			cfg.Api.Auth.Mode,
+		)
+	}
+	apiGroup.Use(nodeAvailableMiddleware)
+	apiGroup.Use(clientDisconnectMiddleware)
+	apiGroup.Use(
+		timeoutMiddleware(cfg.Api.RequestTimeout, cfg.Api.RequestTimeouts),
+	)
+	configureApiRoutes(apiGroup.Group("/v1"), 1)
+	if cfg.Api.SubmitApiCompat {
+		configureSubmitApiRoutes(apiGroup)
+		logger.Infof("enabling cardano-submit-api compatible endpoint")
+	}
+	if cfg.Api.RawQuery.Enabled {
+		logger.Infof("enabling raw local state query endpoint")
+	}
+	// Serve the unversioned routes as an alias for v1
+	if cfg.Api.UnversionedRoutes {
+		unversionedGroup := apiGroup.Group("")
+		if cfg.Api.UnversionedDeprecation {
+			unversionedGroup.Use(deprecationMiddleware)
+		}
+		configureApiRoutes(unversionedGroup, 1)
+	} else {
+		logger.Infof("disabling unversioned API routes")
+	}
 
-	// Metrics
+	// Expose metrics on a separate listener
 	metricsRouter := gin.New()
-	metrics := ginmetrics.GetMonitor()
-	metrics.SetMetricPath("/")
 	metrics.Expose(metricsRouter)
-	// Use metrics middleware without exposing path in main app router
-	// We only collect metrics on the API endpoints
-	metrics.UseWithoutExposingEndpoint(apiGroup)
-
-	go func() {
-		// TODO: return error if we cannot initialize metrics
-		logger.Infof("starting metrics listener on %s:%d",
-			cfg.Metrics.ListenAddress,
-			cfg.Metrics.ListenPort)
-		err := metricsRouter.Run(fmt.Sprintf("%s:%d",
-			cfg.Metrics.ListenAddress,
-			cfg.Metrics.ListenPort))
+	// Serve the healthcheck on the metrics listener for probes that can't present a
+	// client certificate
+	if cfg.Api.Tls.ExemptHealthcheck {
+		metricsRouter.GET("/healthcheck", handleHealthcheck)
+		metricsRouter.GET("/livez", handleLivez)
+		metricsRouter.GET("/readyz", handleReadyz)
+	}
+
+	// Report access log paths that don't match any route now that they're all
+	// registered
+	if err := accessLogFilter.validate(router.Routes()); err != nil {
+		return err
+	}
+	config.OnReload(
+		"access log skip paths",
+		[]string{"logging.skipPaths"},
+		func(cfg *config.Config) error {
+			return accessLogFilter.setSkipPaths(cfg.Logging.SkipPaths)
+		},
+	)
+
+	// Bind all listeners up front so that failures are returned immediately
+	metricsListeners, err := openListeners(listenerConfig{
+		name:        "metrics",
+		systemdName: "metrics",
+		address:     cfg.Metrics.ListenAddress,
+		port:        cfg.Metrics.ListenPort,
+		socketPath:  cfg.Metrics.ListenSocket,
+		socketMode:  cfg.Metrics.ListenSocketMode,
+		socketOwner: cfg.Metrics.ListenSocketOwner,
+	})
+	if err != nil {
+		return err
+	}
+	apiListeners, err := openListeners(listenerConfig{
+		name:        "API",
+		systemdName: "api",
+		address:     cfg.Api.ListenAddress,
+		port:        cfg.Api.ListenPort,
+		socketPath:  cfg.Api.ListenSocket,
+		socketMode:  cfg.Api.ListenSocketMode,
+		socketOwner: cfg.Api.ListenSocketOwner,
+	})
+	if err != nil {
+		closeListeners(metricsListeners)
+		return err
+	}
+
+	// Serve all listeners until we hit an error or are asked to shut down
+	apiServer := newHttpServer(router, cfg.Api.Server)
+	apiServer.RegisterOnShutdown(notifyStreamsShutdown)
+	metricsServer := newHttpServer(metricsRouter, cfg.Api.Server)
+	errChan := make(chan error, len(apiListeners)+len(metricsListeners))
+	for _, metricsListener := range metricsListeners {
+		go func(listener net.Listener) {
+			if err := metricsServer.Serve(listener); err != nil &&
+				!errors.Is(err, http.ErrServerClosed) {
+				errChan <- fmt.Errorf("metrics listener failed: %s", err)
+			}
+		}(metricsListener)
+	}
+	if cfg.Api.Tls.CertFilePath != "" && cfg.Api.Tls.KeyFilePath != "" {
+		reloader, err := newCertReloader(
+			cfg.Api.Tls.CertFilePath,
+			cfg.Api.Tls.KeyFilePath,
+		)
 		if err != nil {
-			logger.Fatalf("failed to start metrics listener: %s", err)
+			closeListeners(apiListeners)
+			closeListeners(metricsListeners)
+			return err
+		}
+		go reloader.Watch(ctx)
+		// The certificate is reloaded whether or not the config changed, since
+		// it may have been renewed in place
+		config.OnReload(
+			"TLS certificate",
+			nil,
+			func(*config.Config) error {
+				return reloader.reload()
+			},
+		)
+		apiServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
 		}
-	}()
+		logger.Infof("enabling TLS for API listener")
+		// Require client certificates signed by the configured CA
+		if cfg.Api.Tls.ClientCaFilePath != "" {
+			caPool, err := loadCertPool(cfg.Api.Tls.ClientCaFilePath)
+			if err != nil {
+				closeListeners(apiListeners)
+				closeListeners(metricsListeners)
+				return err
+			}
+			apiServer.TLSConfig.ClientCAs = caPool
+			apiServer.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			logger.Infof("enabling client certificate authentication for API listener")
+		}
+	}
+	for _, apiListener := range apiListeners {
+		go func(listener net.Listener) {
+			var err error
+			if apiServer.TLSConfig != nil {
+				err = apiServer.ServeTLS(listener, "", "")
+			} else {
+				err = apiServer.Serve(listener)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errChan <- fmt.Errorf("API listener failed: %s", err)
+			}
+		}(apiListener)
+	}
+	if err := systemdNotify("READY=1"); err != nil {
+		logger.Warnf("%s", err)
+	}
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+	if err := systemdNotify("STOPPING=1"); err != nil {
+		logger.Warnf("%s", err)
+	}
+
+	// Stop accepting new connections and wait for in-flight requests to finish
+	logger.Infof(
+		"shutting down API and metrics listeners, waiting up to %ds for in-flight requests",
+		cfg.Api.ShutdownTimeout,
+	)
+	shutdownCtx, shutdownCancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(cfg.Api.ShutdownTimeout)*time.Second,
+	)
+	defer shutdownCancel()
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain API listener: %s", err)
+	}
+	if err := waitForStreams(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain streaming clients: %s", err)
+	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain metrics listener: %s", err)
+	}
+	return nil
+}
 
-	// Start API listener
-	err := router.Run(fmt.Sprintf("%s:%d",
-		cfg.Api.ListenAddress,
-		cfg.Api.ListenPort))
-	return err
+// configureApiRoutes registers the routes for the specified API version. The
+// version is passed down so that route groups can diverge in later versions
+func configureApiRoutes(group *gin.RouterGroup, version int) {
+	configureChainSyncRoutes(group, version)
+	configureConvertRoutes(group, version)
+	configureLocalStateQueryRoutes(group, version)
+	configureLocalTxMonitorRoutes(group, version)
+	configureLocalTxSubmissionRoutes(group, version)
+	configureNodeRoutes(group, version)
+	configureTxRoutes(group, version)
 }
 
-type responseApiError struct {
-	Msg string `json:"msg" example:"error message"`
+func handleNoRoute(c *gin.Context) {
+	respondError(
+		c,
+		http.StatusNotFound,
+		apiErrorCode(errorCodeNotFound, "not found", nil),
+	)
 }
 
-func apiError(msg string) responseApiError {
-	return responseApiError{
-		Msg: msg,
-	}
+func handleNoMethod(c *gin.Context) {
+	respondError(
+		c,
+		http.StatusMethodNotAllowed,
+		apiErrorCode(errorCodeMethodNotAllowed, "method not allowed", nil),
+	)
 }
 
-func handleHealthcheck(c *gin.Context) {
-	// TODO: add some actual health checking here
-	c.JSON(200, gin.H{"failed": false})
+// deprecationMiddleware marks responses from the unversioned API routes as
+// deprecated in favor of the v1 routes
+func deprecationMiddleware(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header(
+		"Link",
+		fmt.Sprintf(
+			"<%s>; rel=\"successor-version\"",
+			strings.Replace(
+				c.Request.URL.Path,
+				apiRoutePrefix,
+				apiRoutePrefix+"v1/",
+				1,
+			),
+		),
+	)
+	c.Next()
+}
+
+// newHttpServer returns an HTTP server with the configured timeouts. The write
+// timeout doesn't affect the websocket and server-sent events streams: the
+// websocket upgrade clears the connection deadlines set by the server, and the
+// server-sent events handlers clear the write deadline themselves
+func newHttpServer(
+	handler http.Handler,
+	serverCfg config.ServerConfig,
+) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadTimeout:       time.Duration(serverCfg.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(serverCfg.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(serverCfg.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    serverCfg.MaxHeaderBytes,
+	}
 }
@@ -0,0 +1,222 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// unixHttpClient returns an HTTP client that sends all requests to a UNIX socket
+func unixHttpClient(socketPath string) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
+func TestStartShutdown(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// Called with the API socket path before starting, to hold resources that
+		// affect the shutdown. The returned func releases them
+		setup func(*testing.T, string) func()
+		// Listen on TCP ports instead, with the port for this listener already
+		// in use, if any
+		portInUse string
+		// How long a tracked stream keeps running after shutdown starts, if any
+		streamDelay time.Duration
+		// Expected error from Start before it serves requests, if any
+		wantStartErr string
+		// Expected error from Start after shutdown, if any
+		wantErr string
+	}{
+		{
+			name: "clean shutdown",
+		},
+		{
+			name:        "waits for streams",
+			streamDelay: 200 * time.Millisecond,
+		},
+		{
+			name:        "stream outlives shutdown timeout",
+			streamDelay: 1500 * time.Millisecond,
+			wantErr:     "failed to drain streaming clients",
+		},
+		{
+			name: "socket in use",
+			setup: func(t *testing.T, socketPath string) func() {
+				listener, err := net.Listen("unix", socketPath)
+				if err != nil {
+					t.Fatalf("failed to listen on test socket: %s", err)
+				}
+				return func() { listener.Close() }
+			},
+			wantStartErr: "is already in use",
+		},
+		{
+			name:         "metrics port in use",
+			portInUse:    "metrics",
+			wantStartErr: "failed to start metrics listener",
+		},
+		{
+			name:         "API port in use",
+			portInUse:    "API",
+			wantStartErr: "failed to start API listener",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			// Shutdown notifies the streams once per process, so reset it for
+			// the other tests
+			t.Cleanup(func() {
+				streamMutex.Lock()
+				defer streamMutex.Unlock()
+				streamShutdown = make(chan struct{})
+			})
+			tmpDir := t.TempDir()
+			apiSocket := filepath.Join(tmpDir, "api.sock")
+			cfg := *config.GetConfig()
+			cfg.Api.ListenPort = 0
+			cfg.Api.ListenSocket = apiSocket
+			cfg.Api.ShutdownTimeout = 1
+			cfg.Metrics.ListenPort = 0
+			cfg.Metrics.ListenSocket = filepath.Join(tmpDir, "metrics.sock")
+			if testDef.setup != nil {
+				defer testDef.setup(t, apiSocket)()
+			}
+			// The port for the other listener, which must be free again once
+			// Start fails
+			var freePort uint
+			if testDef.portInUse != "" {
+				cfg.Api.ListenSocket = ""
+				cfg.Api.ListenAddress = "127.0.0.1"
+				cfg.Api.ListenPort = testFreePort(t)
+				cfg.Metrics.ListenSocket = ""
+				cfg.Metrics.ListenAddress = "127.0.0.1"
+				cfg.Metrics.ListenPort = testFreePort(t)
+				inUsePort, otherPort := cfg.Metrics.ListenPort, cfg.Api.ListenPort
+				if testDef.portInUse == "API" {
+					inUsePort, otherPort = otherPort, inUsePort
+				}
+				freePort = otherPort
+				listener, err := net.Listen(
+					"tcp",
+					fmt.Sprintf("127.0.0.1:%d", inUsePort),
+				)
+				if err != nil {
+					t.Fatalf("failed to listen on test port: %s", err)
+				}
+				defer listener.Close()
+			}
+			var streamDone sync.WaitGroup
+			if testDef.streamDelay > 0 {
+				// Stand in for a streaming handler, which exits some time after
+				// it's told about the shutdown
+				done := trackStream()
+				streamDone.Add(1)
+				go func() {
+					defer streamDone.Done()
+					defer done()
+					<-streamShutdownChan()
+					time.Sleep(testDef.streamDelay)
+				}()
+			}
+			defer streamDone.Wait()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- Start(ctx, &cfg)
+			}()
+			if testDef.wantStartErr == "" {
+				// The listeners are bound before Start serves them, so retry
+				// until the socket comes up
+				client := unixHttpClient(apiSocket)
+				var resp *http.Response
+				var err error
+				for i := 0; i < 50; i++ {
+					resp, err = client.Get("http://api/livez")
+					if err == nil {
+						break
+					}
+					time.Sleep(20 * time.Millisecond)
+				}
+				if err != nil {
+					t.Fatalf("failed to reach the API listener: %s", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("unexpected status: %d", resp.StatusCode)
+				}
+				cancel()
+			}
+			var err error
+			select {
+			case err = <-errChan:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("timed out waiting for Start to return")
+			}
+			if wantErr := testDef.wantStartErr + testDef.wantErr; wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), wantErr) {
+					t.Fatalf("expected error containing %q, got %v", wantErr, err)
+				}
+				if freePort > 0 {
+					listener, err := net.Listen(
+						"tcp",
+						fmt.Sprintf("127.0.0.1:%d", freePort),
+					)
+					if err != nil {
+						t.Fatalf("listener left bound after Start failed: %s", err)
+					}
+					listener.Close()
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			// The listener is closed once Start returns
+			if _, err := unixHttpClient(apiSocket).Get("http://api/livez"); err == nil {
+				t.Fatalf("API listener still accepting requests after shutdown")
+			}
+		})
+	}
+}
+
+// testFreePort returns a TCP port that nothing is listening on
+func testFreePort(t *testing.T) uint {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %s", err)
+	}
+	defer listener.Close()
+	return uint(listener.Addr().(*net.TCPAddr).Port)
+}
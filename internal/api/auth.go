@@ -0,0 +1,220 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+const (
+	apiKeyHeader = "X-Api-Key"
+)
+
+// authMiddleware authenticates requests using the configured auth mode
+func authMiddleware(authCfg config.AuthConfig) gin.HandlerFunc {
+	var validator *jwtValidator
+	// API keys can be changed on reload, such as after rotating the keys file
+	var apiKeys atomic.Pointer[[]config.ApiKeyConfig]
+	apiKeys.Store(&authCfg.ApiKeys)
+	config.OnReload(
+		"API keys",
+		[]string{"api.auth.apiKeys"},
+		func(cfg *config.Config) error {
+			tmpKeys := cfg.Api.Auth.ApiKeys
+			apiKeys.Store(&tmpKeys)
+			return nil
+		},
+	)
+	if authCfg.Mode == config.AuthModeJwt ||
+		authCfg.Mode == config.AuthModeBoth {
+		validator = newJwtValidator(authCfg.Jwt)
+		config.OnReload(
+			"JWKS cache TTL",
+			[]string{"api.auth.jwt.refreshInterval"},
+			func(cfg *config.Config) error {
+				validator.jwks.setRefreshInterval(
+					time.Duration(cfg.Api.Auth.Jwt.RefreshInterval) * time.Second,
+				)
+				return nil
+			},
+		)
+	}
+	return func(c *gin.Context) {
+		useJwt := false
+		switch authCfg.Mode {
+		case config.AuthModeJwt:
+			useJwt = true
+		case config.AuthModeBoth:
+			// Bearer tokens that look like a JWT are validated as one, and anything
+			// else is treated as an API key
+			useJwt = c.GetHeader(apiKeyHeader) == "" &&
+				looksLikeJwt(bearerToken(c))
+		}
+		if useJwt {
+			authenticateJwt(c, validator)
+		} else {
+			authenticateApiKey(c, *apiKeys.Load())
+		}
+		if c.IsAborted() {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateApiKey aborts requests that don't present one of the configured API
+// keys, or whose key is not allowed to access the requested route group
+func authenticateApiKey(c *gin.Context, apiKeys []config.ApiKeyConfig) {
+	key := requestApiKey(c)
+	if key == "" {
+		authFailure(c, http.StatusUnauthorized, "missing API key")
+		return
+	}
+	apiKey := matchApiKey(apiKeys, key)
+	if apiKey == nil {
+		authFailure(c, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+	if len(apiKey.Groups) > 0 &&
+		!slices.Contains(apiKey.Groups, routeGroup(c)) {
+		authFailure(
+			c,
+			http.StatusForbidden,
+			"API key is not allowed to access this endpoint",
+		)
+	}
+}
+
+// authenticateJwt aborts requests that don't present a valid JWT bearer token
+func authenticateJwt(c *gin.Context, validator *jwtValidator) {
+	token := bearerToken(c)
+	if token == "" {
+		authFailure(c, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+	claims, err := validator.Validate(token)
+	if err != nil {
+		authFailure(
+			c,
+			http.StatusUnauthorized,
+			fmt.Sprintf("invalid bearer token: %s", err),
+		)
+		return
+	}
+	if sub, err := claims.GetSubject(); err == nil && sub != "" {
+		c.Set(contextKeyAuthSubject, sub)
+	}
+}
+
+// requestApiKey returns the API key from either the X-Api-Key header or a bearer
+// token in the Authorization header
+func requestApiKey(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return key
+	}
+	return bearerToken(c)
+}
+
+// bearerToken returns the bearer token from the Authorization header
+func bearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// matchApiKey returns the configured API key matching the provided key. All keys are
+// compared in constant time so that timing doesn't reveal which keys exist
+func matchApiKey(
+	apiKeys []config.ApiKeyConfig,
+	key string,
+) *config.ApiKeyConfig {
+	var ret *config.ApiKeyConfig
+	for idx := range apiKeys {
+		if subtle.ConstantTimeCompare(
+			[]byte(apiKeys[idx].Key),
+			[]byte(key),
+		) == 1 {
+			ret = &apiKeys[idx]
+		}
+	}
+	return ret
+}
+
+// Path prefix of the API routes, including the configured base path
+var apiRoutePrefix = "/api/"
+
+// routeGroup returns the API route group (such as "localstatequery") for the
+// matched route, ignoring the API version
+func routeGroup(c *gin.Context) string {
+	path := apiRoutePath(c)
+	if path == "" {
+		return ""
+	}
+	return strings.Split(path[1:], "/")[0]
+}
+
+// apiRoutePath returns the matched API route (such as "/chainsync/sync") without
+// the base path, API prefix, and API version
+func apiRoutePath(c *gin.Context) string {
+	path, ok := strings.CutPrefix(c.FullPath(), apiRoutePrefix)
+	if !ok {
+		return ""
+	}
+	if version, rest, found := strings.Cut(path, "/"); isApiVersion(version) {
+		if !found {
+			return ""
+		}
+		path = rest
+	}
+	return "/" + path
+}
+
+// isApiVersion reports whether a path segment is an API version, such as "v1"
+func isApiVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func authFailure(c *gin.Context, status int, msg string) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricAuthFailures).
+		Inc([]string{routeGroup(c)})
+	code := errorCodeUnauthorized
+	if status == http.StatusForbidden {
+		code = errorCodeForbidden
+	}
+	c.Abort()
+	respondError(c, status, apiErrorCode(code, msg, nil))
+}
@@ -15,25 +15,43 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
 	"github.com/blinklabs-io/cardano-node-api/internal/node"
 
-	"github.com/blinklabs-io/adder/event"
+	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
 	ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
+// Chain-sync stream event types
+const (
+	chainSyncEventBlock     = "block"
+	chainSyncEventRollback  = "rollback"
+	chainSyncEventReconnect = "reconnect"
+	chainSyncEventError     = "error"
+)
+
+// Number of chain-sync streams being served
+var chainSyncStreams atomic.Int64
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
-func configureChainSyncRoutes(apiGroup *gin.RouterGroup) {
+func configureChainSyncRoutes(apiGroup *gin.RouterGroup, version int) {
 	group := apiGroup.Group("/chainsync")
 	group.GET("/sync", handleChainSyncSync)
+	group.GET("/stream", handleChainSyncStream)
 }
 
 type requestChainSyncSync struct {
@@ -54,84 +72,253 @@ type requestChainSyncSync struct {
 //	@Param		hash	query		string	false	"block hash to start sync at, should match slot"
 //	@Router		/chainsync/sync [get]
 func handleChainSyncSync(c *gin.Context) {
+	// Track this handler so that shutdown waits for it
+	defer trackStream()()
 	// Get parameters
 	var req requestChainSyncSync
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, apiError(err.Error()))
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
 		return
 	}
 	if !req.Tip && (req.Slot == 0 || req.Hash == "") {
-		c.JSON(
+		respondError(
+			c,
 			http.StatusBadRequest,
-			apiError(
+			apiErrorCode(
+				errorCodeBadRequest,
 				"you must provide the 'slot' and 'hash' parameters or set 'tip' to True",
+				nil,
 			),
 		)
 		return
 	}
-	// Setup event channel
-	eventChan := make(chan event.Event, 10)
-	connCfg := node.ConnectionConfig{
-		ChainSyncEventChan: eventChan,
-	}
-	// Connect to node
-	oConn, err := node.GetConnection(&connCfg)
-	if err != nil {
-		c.JSON(500, apiError(err.Error()))
-		return
-	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
 	var intersectPoints []ocommon.Point
-	if req.Tip {
-		tip, err := oConn.ChainSync().Client.GetCurrentTip()
-		if err != nil {
-			c.JSON(500, apiError(err.Error()))
-			return
-		}
-		intersectPoints = []ocommon.Point{
-			tip.Point,
-		}
-	} else {
+	if !req.Tip {
 		hashBytes, err := hex.DecodeString(req.Hash)
 		if err != nil {
-			c.JSON(500, apiError(err.Error()))
+			respondError(
+				c,
+				http.StatusBadRequest,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
 			return
 		}
 		intersectPoints = []ocommon.Point{
 			ocommon.NewPoint(req.Slot, hashBytes),
 		}
 	}
-	// Start the sync with the node
-	if err := oConn.ChainSync().Client.Sync(intersectPoints); err != nil {
-		c.JSON(500, apiError(err.Error()))
+	// Start the sync with the node. This stops when the handler returns or the
+	// client disconnects
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, err := node.StartChainSyncStream(ctx, intersectPoints)
+	if err != nil {
+		respondNodeError(c, err)
 		return
 	}
 	// Upgrade the connection
-	webConn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	webConn, err := upgrader.Upgrade(
+		c.Writer,
+		c.Request,
+		http.Header{nodeEndpointHeader: []string{stream.Endpoint()}},
+	)
 	if err != nil {
 		return
 	}
 	defer webConn.Close()
+	// The request context isn't cancelled when a websocket client goes away, so
+	// we read from the websocket to notice, which stops the stream right away.
+	// This also handles control messages from the client
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := webConn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	logger := requestLogger(c, logging.ComponentChainsync)
+	logger.Debugf("starting chain-sync at slot %d", stream.IntersectPoint().Slot)
+	defer logger.Debugf("chain-sync stream closed")
 	// Wait for events
 	for {
-		evt, ok := <-eventChan
-		if !ok {
+		select {
+		case <-streamShutdownChan():
+			// Let the client know that we're going away
+			_ = webConn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(
+					websocket.CloseGoingAway,
+					"server shutting down",
+				),
+				time.Now().Add(time.Second),
+			)
 			return
+		case evt, ok := <-stream.Events():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					logger.Warnf("chain-sync stream failed: %s", err)
+					_ = webConn.WriteControl(
+						websocket.CloseMessage,
+						websocket.FormatCloseMessage(
+							websocket.CloseInternalServerErr,
+							"lost connection to node",
+						),
+						time.Now().Add(time.Second),
+					)
+				}
+				return
+			}
+			if err := webConn.WriteJSON(evt); err != nil {
+				respondError(
+					c,
+					500,
+					apiErrorCode(errorCodeInternal, err.Error(), nil),
+				)
+				return
+			}
 		}
-		if err := webConn.WriteJSON(evt); err != nil {
-			c.JSON(500, apiError(err.Error()))
+	}
+}
+
+type responseChainSyncStreamBlock struct {
+	Slot    uint64 `json:"slot"     example:"134217728"`
+	Hash    string `json:"hash"     example:"b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"`
+	Height  uint64 `json:"height"   example:"11000000"`
+	Era     string `json:"era"      example:"conway"`
+	TxCount uint64 `json:"tx_count" example:"12"`
+	Size    int    `json:"size"     example:"24576"`
+}
+
+type responseChainSyncStreamPoint struct {
+	Slot uint64 `json:"slot" example:"134217728"`
+	Hash string `json:"hash" example:"b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"`
+}
+
+type responseChainSyncStreamReconnect struct {
+	Slot     uint64 `json:"slot"     example:"134217728"`
+	Hash     string `json:"hash"     example:"b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"`
+	Endpoint string `json:"endpoint" example:"/ipc/node.socket"`
+	Failover bool   `json:"failover" example:"false"`
+}
+
+type responseChainSyncStreamError struct {
+	Error string `json:"error" example:"lost connection to node"`
+}
+
+// handleChainSyncStream godoc
+//
+//	@Summary		Stream new blocks
+//	@Description	Follows the chain from the current tip and streams server-sent events. A block event with the slot, hash, height, era, TX count, and size in bytes is sent for each new block, and a rollback event with the slot and hash of the point that the chain rolled back to is sent when the node switches forks. The node starts with a rollback to the tip that the stream started from. The stream resumes by itself if the connection to the node is lost, after sending a reconnect event with the point that it resumed from, and failover set if it resumed on a different node endpoint. An error event is sent if it can't be resumed, and the stream ends. A shutdown event is sent before the stream ends when the server shuts down. Each stream follows the chain on its own node connection, so a slow client only holds up its own stream. Heartbeat comments are sent while no blocks arrive.
+//	@Tags			chainsync
+//	@Produce		text/event-stream
+//	@Success		200
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/chainsync/stream [get]
+func handleChainSyncStream(c *gin.Context) {
+	// Track this handler so that shutdown waits for it
+	defer trackStream()()
+	cfg := config.GetConfig().Api.ChainSyncStream
+	if chainSyncStreams.Add(1) > int64(cfg.MaxStreams) {
+		chainSyncStreams.Add(-1)
+		respondError(
+			c,
+			http.StatusServiceUnavailable,
+			apiErrorCode(
+				errorCodeStreamLimit,
+				"too many chain-sync streams are open",
+				nil,
+			),
+		)
+		return
+	}
+	defer chainSyncStreams.Add(-1)
+	// Start the sync with the node from the current tip. The request context is
+	// cancelled when the client goes away, which stops the sync
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, err := node.StartChainSyncStream(ctx, nil)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	c.Header(nodeEndpointHeader, stream.Endpoint())
+	logger := requestLogger(c, logging.ComponentChainsync)
+	startSse(c, logger)
+	logger.Debugf(
+		"starting chain-sync stream at slot %d",
+		stream.IntersectPoint().Slot,
+	)
+	defer logger.Debugf("chain-sync stream closed")
+	heartbeatInterval := time.Duration(cfg.HeartbeatInterval) * time.Second
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-streamShutdownChan():
+			// Let the client know that we're going away
+			sendSseShutdown(c)
+			return
+		case <-ctx.Done():
 			return
+		case <-heartbeat.C:
+			if !sendSseHeartbeat(c) {
+				return
+			}
+		case evt, ok := <-stream.Events():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					logger.Warnf("chain-sync stream failed: %s", err)
+					c.SSEvent(
+						chainSyncEventError,
+						responseChainSyncStreamError{
+							Error: "lost connection to node",
+						},
+					)
+					c.Writer.Flush()
+				}
+				return
+			}
+			switch payload := evt.Payload.(type) {
+			case input_chainsync.BlockEvent:
+				blockCtx, ok := evt.Context.(input_chainsync.BlockContext)
+				if !ok {
+					continue
+				}
+				c.SSEvent(chainSyncEventBlock, responseChainSyncStreamBlock{
+					Slot:    blockCtx.SlotNumber,
+					Hash:    payload.BlockHash,
+					Height:  blockCtx.BlockNumber,
+					Era:     strings.ToLower(payload.Block.Era().Name),
+					TxCount: payload.TransactionCount,
+					Size:    len(payload.BlockCbor),
+				})
+			case input_chainsync.RollbackEvent:
+				c.SSEvent(chainSyncEventRollback, responseChainSyncStreamPoint{
+					Slot: payload.SlotNumber,
+					Hash: payload.BlockHash,
+				})
+			case node.ReconnectEvent:
+				c.SSEvent(
+					chainSyncEventReconnect,
+					responseChainSyncStreamReconnect{
+						Slot:     payload.SlotNumber,
+						Hash:     payload.BlockHash,
+						Endpoint: payload.Endpoint,
+						Failover: payload.Failover,
+					},
+				)
+			default:
+				continue
+			}
+			c.Writer.Flush()
+			// Don't send a heartbeat right after an event
+			heartbeat.Reset(heartbeatInterval)
 		}
 	}
 }
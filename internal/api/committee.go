@@ -0,0 +1,212 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Committee member statuses in the committee members state query, which are
+// also used to filter it
+const (
+	committeeMemberStatusActive       = 0
+	committeeMemberStatusExpired      = 1
+	committeeMemberStatusUnrecognized = 2
+)
+
+var committeeMemberStatusNames = map[uint]string{
+	committeeMemberStatusActive:       "active",
+	committeeMemberStatusExpired:      "expired",
+	committeeMemberStatusUnrecognized: "unrecognized",
+}
+
+// Hot credential authorization statuses in the committee members state query
+const (
+	committeeHotCredAuthorized    = 0
+	committeeHotCredNotAuthorized = 1
+	committeeHotCredResigned      = 2
+)
+
+var committeeHotCredStatusNames = map[uint]string{
+	committeeHotCredAuthorized:    "authorized",
+	committeeHotCredNotAuthorized: "not_authorized",
+	committeeHotCredResigned:      "resigned",
+}
+
+// parseCommitteeMemberStatus parses a committee member status by name
+func parseCommitteeMemberStatus(status string) (uint, error) {
+	for id, name := range committeeMemberStatusNames {
+		if name == status {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid committee member status: %s", status)
+}
+
+// committeeMembersStateResult is the result of the committee members state
+// query, with the members by cold credential. The threshold is missing if there
+// isn't an enacted committee
+type committeeMembersStateResult struct {
+	cbor.StructAsArray
+	Members   map[ledgerCredential]committeeMemberState
+	Threshold strictMaybe[cbor.Rat]
+	EpochNo   uint64
+}
+
+// committeeMemberState is a member in the committee members state query result.
+// The expiry epoch is missing for a member that was proposed but isn't in the
+// committee. The change the member will see at the next epoch isn't used
+type committeeMemberState struct {
+	cbor.StructAsArray
+	HotCredAuth     committeeHotCredAuth
+	Status          uint
+	ExpiryEpoch     strictMaybe[uint64]
+	NextEpochChange cbor.RawMessage
+}
+
+// committeeHotCredAuth is the authorization status of a member's hot credential,
+// which has the hot credential if one is authorized, or the anchor given on
+// resignation, which is optional
+type committeeHotCredAuth struct {
+	Status        uint
+	HotCredential *ledgerCredential
+	Anchor        *ledger.GovAnchor
+}
+
+func (a *committeeHotCredAuth) UnmarshalCBOR(data []byte) error {
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(data, &items); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("empty hot credential authorization status")
+	}
+	if _, err := cbor.Decode(items[0], &a.Status); err != nil {
+		return err
+	}
+	switch a.Status {
+	case committeeHotCredAuthorized:
+		if len(items) != 2 {
+			return fmt.Errorf(
+				"authorized hot credential status has %d items",
+				len(items),
+			)
+		}
+		var cred ledgerCredential
+		if _, err := cbor.Decode(items[1], &cred); err != nil {
+			return err
+		}
+		a.HotCredential = &cred
+	case committeeHotCredNotAuthorized:
+	case committeeHotCredResigned:
+		if len(items) > 1 {
+			var anchor strictMaybe[ledger.GovAnchor]
+			if _, err := cbor.Decode(items[1], &anchor); err != nil {
+				return err
+			}
+			a.Anchor = anchor.Value
+		}
+	default:
+		return fmt.Errorf(
+			"unknown hot credential authorization status: %d",
+			a.Status,
+		)
+	}
+	return nil
+}
+
+// The hot credential is null unless it's authorized. The resignation anchor is
+// only set for a resigned member that gave one. The expiry epoch is null for a
+// member that isn't in the committee
+type responseGovernanceCommitteeMemberState struct {
+	ColdCredential      responseGovernanceCredential  `json:"cold_credential"`
+	HotCredential       *responseGovernanceCredential `json:"hot_credential"`
+	HotCredentialStatus string                        `json:"hot_credential_status"        example:"authorized" enums:"authorized,not_authorized,resigned"`
+	ResignationAnchor   *responseGovernanceAnchor     `json:"resignation_anchor,omitempty"`
+	Status              string                        `json:"status"                       example:"active"     enums:"active,expired,unrecognized"`
+	ExpiryEpoch         *uint64                       `json:"expiry_epoch"                 example:"580"`
+}
+
+func newResponseGovernanceCommitteeMemberState(
+	cred ledgerCredential,
+	state committeeMemberState,
+) responseGovernanceCommitteeMemberState {
+	ret := responseGovernanceCommitteeMemberState{
+		ColdCredential: newResponseGovernanceCredential(
+			cip129PrefixCommitteeCold,
+			cip129HeaderCommitteeCold,
+			cred,
+		),
+		HotCredentialStatus: committeeHotCredStatusNames[state.HotCredAuth.Status],
+		Status:              committeeMemberStatusNames[state.Status],
+		ExpiryEpoch:         state.ExpiryEpoch.Value,
+	}
+	if state.HotCredAuth.HotCredential != nil {
+		hotCred := newResponseGovernanceCredential(
+			cip129PrefixCommitteeHot,
+			cip129HeaderCommitteeHot,
+			*state.HotCredAuth.HotCredential,
+		)
+		ret.HotCredential = &hotCred
+	}
+	if state.HotCredAuth.Anchor != nil {
+		anchor := newResponseGovernanceAnchor(*state.HotCredAuth.Anchor)
+		ret.ResignationAnchor = &anchor
+	}
+	return ret
+}
+
+type requestLocalStateQueryCommittee struct {
+	ColdCredentials []string `form:"cold_credential"`
+	HotCredentials  []string `form:"hot_credential"`
+	Statuses        []string `form:"status"`
+}
+
+// The threshold is the fraction of members that must vote yes, as a decimal
+// string, or null if there isn't an enacted committee
+type responseLocalStateQueryCommittee struct {
+	EpochNo   uint64                                   `json:"epoch_no"  example:"507"`
+	Threshold *string                                  `json:"threshold" example:"0.66666666666666666667"`
+	Members   []responseGovernanceCommitteeMemberState `json:"members"`
+}
+
+// newResponseCommittee maps the committee members state, with the members
+// ordered by cold credential
+func newResponseCommittee(
+	state committeeMembersStateResult,
+) responseLocalStateQueryCommittee {
+	ret := responseLocalStateQueryCommittee{
+		EpochNo: state.EpochNo,
+		Members: make(
+			[]responseGovernanceCommitteeMemberState,
+			0,
+			len(state.Members),
+		),
+	}
+	if state.Threshold.Value != nil && state.Threshold.Value.Rat != nil {
+		threshold := formatRat(state.Threshold.Value.ToBigRat())
+		ret.Threshold = &threshold
+	}
+	for _, cred := range sortedKeys(state.Members, compareLedgerCredentials) {
+		ret.Members = append(
+			ret.Members,
+			newResponseGovernanceCommitteeMemberState(cred, state.Members[cred]),
+		)
+	}
+	return ret
+}
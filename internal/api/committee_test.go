@@ -0,0 +1,191 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// Committee members state query results, as hex. There's an active member
+// with cold and hot key hashes of 0x33 and 0x44 bytes, an expired member with a
+// cold script hash of 0x55 bytes that resigned with an anchor, and an
+// unrecognized member with a cold key hash of 0x66 bytes and no hot credential.
+// The threshold is 3/5 and the epoch is 507
+const (
+	testCommitteeActiveMemberHex = "8200581c33333333333333333333333333333333333333333333333333333333" +
+		"8482008200581c44444444444444444444444444444444444444444444444444444444" +
+		"00811902448102"
+	testCommitteeMembersStateHex = "83a3" + testCommitteeActiveMemberHex +
+		"8201581c55555555555555555555555555555555555555555555555555555555" +
+		"8482028182781f68747470733a2f2f6578616d706c652e636f6d2f72657369676e2e6a736f6e" +
+		"58207777777777777777777777777777777777777777777777777777777777777777" +
+		"01811901f48102" +
+		"8200581c66666666666666666666666666666666666666666666666666666666" +
+		"84810102808104" +
+		"81d81e8203051901fb"
+	testCommitteeFilteredHex = "83a1" + testCommitteeActiveMemberHex +
+		"81d81e8203051901fb"
+)
+
+func TestHandleLocalStateQueryCommittee(t *testing.T) {
+	threshold := "0.6"
+	activeExpiry := uint64(580)
+	expiredExpiry := uint64(500)
+	activeMember := responseGovernanceCommitteeMemberState{
+		ColdCredential: responseGovernanceCredential{
+			Type: governanceCredentialKeyHash,
+			Id:   "cc_cold1zgenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvcj6kz3e",
+			Hash: "33333333333333333333333333333333333333333333333333333333",
+		},
+		HotCredential: &responseGovernanceCredential{
+			Type: governanceCredentialKeyHash,
+			Id:   "cc_hot1qfzyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3q4s9uvm",
+			Hash: "44444444444444444444444444444444444444444444444444444444",
+		},
+		HotCredentialStatus: "authorized",
+		Status:              "active",
+		ExpiryEpoch:         &activeExpiry,
+	}
+	testDefs := []struct {
+		name  string
+		query string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+		want       responseLocalStateQueryCommittee
+	}{
+		{
+			name: "all",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [27, [], [], []]]]]
+				"82008200820684181b808080",
+			},
+			results:    []string{"06", "81" + testCommitteeMembersStateHex},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryCommittee{
+				EpochNo:   507,
+				Threshold: &threshold,
+				Members: []responseGovernanceCommitteeMemberState{
+					activeMember,
+					{
+						ColdCredential: responseGovernanceCredential{
+							Type: governanceCredentialKeyHash,
+							Id:   "cc_cold1zfnxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvesq5dqzd",
+							Hash: "66666666666666666666666666666666666666666666666666666666",
+						},
+						HotCredentialStatus: "not_authorized",
+						Status:              "unrecognized",
+					},
+					{
+						ColdCredential: responseGovernanceCredential{
+							Type: governanceCredentialScriptHash,
+							Id:   "cc_cold1zd24242424242424242424242424242424242424242424ggcjaly",
+							Hash: "55555555555555555555555555555555555555555555555555555555",
+						},
+						HotCredentialStatus: "resigned",
+						ResignationAnchor: &responseGovernanceAnchor{
+							Url:      "https://example.com/resign.json",
+							DataHash: "7777777777777777777777777777777777777777777777777777777777777777",
+						},
+						Status:      "expired",
+						ExpiryEpoch: &expiredExpiry,
+					},
+				},
+			},
+		},
+		{
+			name: "filtered",
+			query: "?cold_credential=cc_cold1zgenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvcj6kz3e" +
+				"&hot_credential=44444444444444444444444444444444444444444444444444444444" +
+				"&status=active&status=active",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [27, [[0, cold]], [[0, hot]], [0]]]]]
+				"82008200820684181b" +
+					"818200581c33333333333333333333333333333333333333333333333333333333" +
+					"818200581c44444444444444444444444444444444444444444444444444444444" +
+					"8100",
+			},
+			results:    []string{"06", "81" + testCommitteeFilteredHex},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryCommittee{
+				EpochNo:   507,
+				Threshold: &threshold,
+				Members:   []responseGovernanceCommitteeMemberState{activeMember},
+			},
+		},
+		{
+			name: "no committee",
+			queries: []string{
+				"820082028101",
+				"82008200820684181b808080",
+			},
+			results:    []string{"06", "8183a0801901fb"},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryCommittee{
+				EpochNo: 507,
+				Members: []responseGovernanceCommitteeMemberState{},
+			},
+		},
+		{
+			name:       "hot credential as cold credential",
+			query:      "?cold_credential=cc_hot1qfzyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3q4s9uvm",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid status",
+			query:      "?status=resigned",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "babbage",
+			queries:    []string{"820082028101"},
+			results:    []string{"05"},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if len(testDef.queries) > 0 {
+				startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			}
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/committee",
+				handleLocalStateQueryCommittee,
+				"/governance/committee"+testDef.query,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryCommittee
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.want) {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// compressionMiddleware gzip-compresses responses of at least minSize bytes for
+// clients that accept it. Websocket upgrades are passed through untouched
+func compressionMiddleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead ||
+			c.GetHeader("Upgrade") != "" ||
+			!acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			minSize:        minSize,
+			status:         http.StatusOK,
+		}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		// A quality value of 0 means "not acceptable"
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of a response until it reaches the minimum size
+// for compression, the response is flushed, or the handler completes
+type compressWriter struct {
+	gin.ResponseWriter
+	minSize    int
+	status     int
+	buf        []byte
+	decided    bool
+	gzipWriter *gzip.Writer
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if code > 0 && !w.decided {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) WriteHeaderNow() {}
+
+// Unwrap returns the underlying writer, so that http.ResponseController can
+// reach the connection, e.g. to clear the write deadline for streams
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided || len(w.buf) > 0
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.gzipWriter != nil {
+		return w.gzipWriter.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends any buffered data so that streamed responses aren't delayed
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start writes the response headers and any buffered data, compressing the
+// response if it's large enough and not already encoded
+func (w *compressWriter) start() error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if len(w.buf) >= w.minSize &&
+		header.Get("Content-Encoding") == "" &&
+		w.status != http.StatusNoContent &&
+		w.status != http.StatusNotModified {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		gzipWriter := gzipWriterPool.Get().(*gzip.Writer)
+		gzipWriter.Reset(w.ResponseWriter)
+		w.gzipWriter = gzipWriter
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+	if w.gzipWriter != nil {
+		_, err := w.gzipWriter.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// finish writes out anything still buffered and closes the gzip stream
+func (w *compressWriter) finish() {
+	if !w.decided {
+		// Responses below the threshold are sent as-is
+		w.minSize = len(w.buf) + 1
+		if err := w.start(); err != nil {
+			return
+		}
+	}
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Close()
+		w.gzipWriter.Reset(nil)
+		gzipWriterPool.Put(w.gzipWriter)
+		w.gzipWriter = nil
+	}
+}
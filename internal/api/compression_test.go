@@ -0,0 +1,207 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	testDefs := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{acceptEncoding: "", want: false},
+		{acceptEncoding: "gzip", want: true},
+		{acceptEncoding: "deflate, gzip", want: true},
+		{acceptEncoding: "br;q=1.0, gzip;q=0.5", want: true},
+		{acceptEncoding: "*", want: true},
+		{acceptEncoding: "identity", want: false},
+		{acceptEncoding: "gzip;q=0", want: false},
+		{acceptEncoding: "gzip; q=0.0", want: false},
+		{acceptEncoding: "gzipped", want: false},
+	}
+	for _, testDef := range testDefs {
+		if got := acceptsGzip(testDef.acceptEncoding); got != testDef.want {
+			t.Errorf(
+				"acceptsGzip(%q) = %v, want %v",
+				testDef.acceptEncoding,
+				got,
+				testDef.want,
+			)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	const minSize = 16
+	bigBody := strings.Repeat("a", minSize)
+	testDefs := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		upgrade        bool
+		handler        gin.HandlerFunc
+		wantStatus     int
+		wantCompressed bool
+		wantFlushed    bool
+		wantBody       string
+	}{
+		{
+			name:           "at threshold",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, bigBody)
+			},
+			wantStatus:     http.StatusOK,
+			wantCompressed: true,
+			wantBody:       bigBody,
+		},
+		{
+			name:           "below threshold",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, bigBody[1:])
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   bigBody[1:],
+		},
+		{
+			name:           "reaches threshold over several writes",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusCreated)
+				for i := 0; i < minSize; i++ {
+					_, _ = c.Writer.WriteString("a")
+				}
+			},
+			wantStatus:     http.StatusCreated,
+			wantCompressed: true,
+			wantBody:       bigBody,
+		},
+		{
+			name: "client doesn't accept gzip",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, bigBody)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   bigBody,
+		},
+		{
+			name:           "already encoded",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Encoding", "br")
+				c.String(http.StatusOK, bigBody)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   bigBody,
+		},
+		{
+			name:           "no content",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:           "upgrade",
+			acceptEncoding: "gzip",
+			upgrade:        true,
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, bigBody)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   bigBody,
+		},
+		{
+			name:           "flush below threshold",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "a")
+				c.Writer.Flush()
+				c.String(http.StatusOK, bigBody)
+			},
+			wantStatus:  http.StatusOK,
+			wantFlushed: true,
+			wantBody:    "a" + bigBody,
+		},
+		{
+			name:           "flush above threshold",
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, bigBody)
+				c.Writer.Flush()
+				c.String(http.StatusOK, "b")
+			},
+			wantStatus:     http.StatusOK,
+			wantCompressed: true,
+			wantFlushed:    true,
+			wantBody:       bigBody + "b",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(compressionMiddleware(minSize))
+			router.GET("/test", testDef.handler)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if testDef.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", testDef.acceptEncoding)
+			}
+			if testDef.upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			router.ServeHTTP(w, req)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status: %d", w.Code)
+			}
+			if w.Flushed != testDef.wantFlushed {
+				t.Fatalf("unexpected flushed: %v", w.Flushed)
+			}
+			compressed := w.Header().Get("Content-Encoding") == "gzip"
+			if compressed != testDef.wantCompressed {
+				t.Fatalf(
+					"unexpected Content-Encoding: %q",
+					w.Header().Get("Content-Encoding"),
+				)
+			}
+			body := w.Body.Bytes()
+			if compressed {
+				gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("invalid gzip response: %s", err)
+				}
+				body, err = io.ReadAll(gzipReader)
+				if err != nil {
+					t.Fatalf("invalid gzip response: %s", err)
+				}
+			}
+			if string(body) != testDef.wantBody {
+				t.Fatalf("unexpected body: %q", body)
+			}
+		})
+	}
+}
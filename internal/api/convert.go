@@ -0,0 +1,244 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+func configureConvertRoutes(apiGroup *gin.RouterGroup, version int) {
+	group := apiGroup.Group("/convert")
+	group.GET("/slot-to-time/:slot", handleConvertSlotToTime)
+	group.GET("/time-to-slot/:time", handleConvertTimeToSlot)
+}
+
+// eraHistoryCache holds the era history and system start from the node for the
+// conversion endpoints, so that each conversion doesn't need a ledger state query
+type eraHistoryCache struct {
+	mutex       sync.Mutex
+	systemStart time.Time
+	eraHistory  []localstatequery.EraHistoryResult
+	fetchedAt   time.Time
+}
+
+var convertEraHistory = &eraHistoryCache{}
+
+// get returns the cached era history if it hasn't expired
+func (e *eraHistoryCache) get() (time.Time, []localstatequery.EraHistoryResult, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	ttl := time.Duration(config.GetConfig().Api.Convert.CacheTtl) * time.Second
+	if e.eraHistory == nil || time.Since(e.fetchedAt) > ttl {
+		return time.Time{}, nil, false
+	}
+	return e.systemStart, e.eraHistory, true
+}
+
+func (e *eraHistoryCache) set(
+	systemStart time.Time,
+	eraHistory []localstatequery.EraHistoryResult,
+) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.systemStart = systemStart
+	e.eraHistory = eraHistory
+	e.fetchedAt = time.Now()
+}
+
+// getConvertEraHistory returns the system start and era history, querying the
+// node if the cached copy has expired. An error response has been sent if it
+// returns false
+func getConvertEraHistory(
+	c *gin.Context,
+) (time.Time, []localstatequery.EraHistoryResult, bool) {
+	if systemStart, eraHistory, ok := convertEraHistory.get(); ok {
+		return systemStart, eraHistory, true
+	}
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return time.Time{}, nil, false
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return time.Time{}, nil, false
+	}
+
+	// Get the system start and era history
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return time.Time{}, nil, false
+	}
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return time.Time{}, nil, false
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	systemStartTime := node.SystemStartTime(systemStart)
+	convertEraHistory.set(systemStartTime, eraHistory)
+	return systemStartTime, eraHistory, true
+}
+
+type responseConvert struct {
+	Slot      uint64    `json:"slot"      example:"133660800"`
+	Time      time.Time `json:"time"      example:"2024-09-01T21:44:51Z"`
+	Era       string    `json:"era"       example:"conway"`
+	Projected bool      `json:"projected" example:"false"`
+}
+
+// handleConvertSlotToTime godoc
+//
+//	@Summary		Convert Slot To Time
+//	@Description	Returns the wall-clock start time of a slot and the era that it falls in, using the era history from the node. Slots past the end of the era history are marked as projected, since a hard fork could change the result, or rejected if projected results are disabled.
+//	@Tags			convert
+//	@Produce		json
+//	@Param			slot	path		integer	true	"slot number"
+//	@Success		200		{object}	responseConvert
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/convert/slot-to-time/{slot} [get]
+func handleConvertSlotToTime(c *gin.Context) {
+	slot, err := strconv.ParseUint(c.Param("slot"), 10, 64)
+	if err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, "invalid slot number", nil),
+		)
+		return
+	}
+	systemStart, eraHistory, ok := getConvertEraHistory(c)
+	if !ok {
+		return
+	}
+	slotTime, err := node.SlotTime(systemStart, eraHistory, slot)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondConvert(c, eraHistory, slot, slotTime)
+}
+
+// handleConvertTimeToSlot godoc
+//
+//	@Summary		Convert Time To Slot
+//	@Description	Returns the slot at an RFC3339 time and the era that it falls in, using the era history from the node. Times past the end of the era history are marked as projected, since a hard fork could change the result, or rejected if projected results are disabled. The time in the response is the start of the slot.
+//	@Tags			convert
+//	@Produce		json
+//	@Param			time	path		string	true	"RFC3339 time"
+//	@Success		200		{object}	responseConvert
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/convert/time-to-slot/{time} [get]
+func handleConvertTimeToSlot(c *gin.Context) {
+	t, err := time.Parse(time.RFC3339, c.Param("time"))
+	if err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, "invalid RFC3339 time", nil),
+		)
+		return
+	}
+	systemStart, eraHistory, ok := getConvertEraHistory(c)
+	if !ok {
+		return
+	}
+	slot, err := node.SlotAtTime(systemStart, eraHistory, t)
+	if err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	slotTime, err := node.SlotTime(systemStart, eraHistory, slot)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondConvert(c, eraHistory, slot, slotTime)
+}
+
+// respondConvert sends a conversion result, or an error if it's projected and
+// projected results are disabled
+func respondConvert(
+	c *gin.Context,
+	eraHistory []localstatequery.EraHistoryResult,
+	slot uint64,
+	slotTime time.Time,
+) {
+	projected := node.SlotPastHorizon(eraHistory, slot)
+	if projected && !config.GetConfig().Api.Convert.AllowProjected {
+		respondError(
+			c,
+			http.StatusUnprocessableEntity,
+			apiErrorCode(
+				errorCodeBeyondHorizon,
+				"slot is past the end of the era history",
+				nil,
+			),
+		)
+		return
+	}
+	// The era history has an entry for each era, in order
+	era := ledger.GetEraById(uint8(node.SlotEra(eraHistory, slot)))
+	resp := responseConvert{
+		Slot:      slot,
+		Time:      slotTime.UTC(),
+		Era:       strings.ToLower(era.Name),
+		Projected: projected,
+	}
+	respondJson(c, 200, resp)
+}
@@ -0,0 +1,234 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/bech32"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Legacy CIP-105 bech32 prefix for DRep script hashes. Key hashes use the same
+// prefix as CIP-129, without the header byte
+const cip105PrefixDrepScript = "drep_script"
+
+// ledgerDrep is a DRep in ledger queries. A key hash or script hash DRep is
+// encoded like a credential, while the abstain and no confidence DReps have no
+// hash. DRep credentials decode as the key hash and script hash DReps
+type ledgerDrep struct {
+	Type uint
+	Hash ledger.Blake2b224
+}
+
+func (d *ledgerDrep) UnmarshalCBOR(data []byte) error {
+	var tmpDrep ledger.Drep
+	if _, err := cbor.Decode(data, &tmpDrep); err != nil {
+		return err
+	}
+	d.Type = uint(tmpDrep.Type)
+	d.Hash = ledger.NewBlake2b224(tmpDrep.Credential)
+	return nil
+}
+
+func (d ledgerDrep) MarshalCBOR() ([]byte, error) {
+	switch d.Type {
+	case ledger.DrepTypeAddrKeyHash, ledger.DrepTypeScriptHash:
+		return cbor.Encode([]any{d.Type, d.Hash[:]})
+	}
+	return cbor.Encode([]any{d.Type})
+}
+
+func (d ledgerDrep) response() responseDrep {
+	drep := ledger.Drep{Type: int(d.Type)}
+	switch d.Type {
+	case ledger.DrepTypeAddrKeyHash, ledger.DrepTypeScriptHash:
+		drep.Credential = d.Hash[:]
+	}
+	if ret := newResponseDrep(drep); ret != nil {
+		return *ret
+	}
+	return responseDrep{Type: strconv.FormatUint(uint64(d.Type), 10)}
+}
+
+// compareLedgerDreps orders DReps by type and then hash
+func compareLedgerDreps(a, b ledgerDrep) int {
+	if c := cmp.Compare(a.Type, b.Type); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.Hash[:], b.Hash[:])
+}
+
+// encodeDrepCursor returns an opaque pagination cursor for a DRep, which
+// encodes its type and hash
+func encodeDrepCursor(drep ledgerDrep) string {
+	return base64.RawURLEncoding.EncodeToString(
+		append([]byte{byte(drep.Type)}, drep.Hash[:]...),
+	)
+}
+
+// decodeDrepCursor returns the DRep encoded in a pagination cursor
+func decodeDrepCursor(cursor string) (ledgerDrep, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) != 1+len(ledger.Blake2b224{}) {
+		return ledgerDrep{}, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return ledgerDrep{
+		Type: uint(data[0]),
+		Hash: ledger.NewBlake2b224(data[1:]),
+	}, nil
+}
+
+// parseDrepId parses a DRep ID, which can be a CIP-129 or CIP-105 bech32 ID, or
+// hex. Hex is the CIP-129 header byte and the hash, or just the hash for a key
+// hash. The abstain and no confidence DReps are only accepted if allowSpecial is
+// set
+func parseDrepId(drepId string, allowSpecial bool) (ledgerDrep, error) {
+	switch drepId {
+	case drepTypeAbstain, drepTypeNoConfidence:
+		if !allowSpecial {
+			return ledgerDrep{}, fmt.Errorf(
+				"not a registered DRep: %s",
+				drepId,
+			)
+		}
+		if drepId == drepTypeAbstain {
+			return ledgerDrep{Type: ledger.DrepTypeAbstain}, nil
+		}
+		return ledgerDrep{Type: ledger.DrepTypeNoConfidence}, nil
+	}
+	// A CIP-105 script hash has its own prefix and no header byte
+	if strings.HasPrefix(drepId, cip105PrefixDrepScript) {
+		prefix, data, err := bech32.DecodeToBase256(drepId)
+		if err != nil ||
+			prefix != cip105PrefixDrepScript ||
+			len(data) != len(ledger.Blake2b224{}) {
+			return ledgerDrep{}, fmt.Errorf("invalid DRep ID: %s", drepId)
+		}
+		return ledgerDrep{
+			Type: ledger.DrepTypeScriptHash,
+			Hash: ledger.NewBlake2b224(data),
+		}, nil
+	}
+	cred, err := parseCip129Id(drepId, cip129PrefixDrep, cip129HeaderDrep)
+	if err != nil {
+		return ledgerDrep{}, fmt.Errorf("invalid DRep ID: %s", drepId)
+	}
+	// The DRep types for key and script hashes match the credential types
+	return ledgerDrep{Type: cred.Type, Hash: cred.Hash}, nil
+}
+
+// parseDrepIds parses a list of DRep IDs, leaving out duplicates
+func parseDrepIds(drepIds []string, allowSpecial bool) ([]ledgerDrep, error) {
+	ret := make([]ledgerDrep, 0, len(drepIds))
+	for _, drepId := range drepIds {
+		drep, err := parseDrepId(drepId, allowSpecial)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ret, drep) {
+			ret = append(ret, drep)
+		}
+	}
+	return ret, nil
+}
+
+// drepState is a DRep in the DRep state query result, which is a list of the
+// expiry epoch, the anchor, and the deposit. Newer nodes add the stake
+// credentials delegated to the DRep, which we don't use
+type drepState struct {
+	ExpiryEpoch uint64
+	Anchor      strictMaybe[ledger.GovAnchor]
+	Deposit     uint64
+}
+
+func (s *drepState) UnmarshalCBOR(data []byte) error {
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(data, &items); err != nil {
+		return err
+	}
+	if len(items) < 3 {
+		return fmt.Errorf("DRep state too short: %d items", len(items))
+	}
+	if _, err := cbor.Decode(items[0], &s.ExpiryEpoch); err != nil {
+		return err
+	}
+	if _, err := cbor.Decode(items[1], &s.Anchor); err != nil {
+		return err
+	}
+	if _, err := cbor.Decode(items[2], &s.Deposit); err != nil {
+		return err
+	}
+	return nil
+}
+
+// The anchor is null if the DRep doesn't have one. The deposit is in lovelace
+type responseGovernanceDrep struct {
+	Drep        responseDrep              `json:"drep"`
+	ExpiryEpoch uint64                    `json:"expiry_epoch" example:"600"`
+	Anchor      *responseGovernanceAnchor `json:"anchor"`
+	Deposit     uint64                    `json:"deposit"      example:"500000000"`
+}
+
+func newResponseGovernanceDrep(
+	drep ledgerDrep,
+	state drepState,
+) responseGovernanceDrep {
+	ret := responseGovernanceDrep{
+		Drep:        drep.response(),
+		ExpiryEpoch: state.ExpiryEpoch,
+		Deposit:     state.Deposit,
+	}
+	if state.Anchor.Value != nil {
+		anchor := newResponseGovernanceAnchor(*state.Anchor.Value)
+		ret.Anchor = &anchor
+	}
+	return ret
+}
+
+// The stake is in lovelace, as a string
+type responseGovernanceDrepStake struct {
+	Drep  responseDrep `json:"drep"`
+	Stake string       `json:"stake" example:"1234567890"`
+}
+
+type requestLocalStateQueryDreps struct {
+	DrepIds []string `form:"drep_id"`
+	Limit   uint     `form:"limit"`
+	Cursor  string   `form:"cursor"`
+}
+
+// The DReps are left out when encoding this, since they're streamed after the
+// other fields
+type responseLocalStateQueryDreps struct {
+	Total      int                      `json:"total"           example:"1"`
+	NextCursor *string                  `json:"next_cursor"     example:"ACJMn3j01Hp7P6QVS3kXPPqJ6-hpFB0-7ZPahEJM"`
+	Dreps      []responseGovernanceDrep `json:"dreps,omitempty"`
+}
+
+// The DReps are left out when encoding this, since they're streamed after the
+// other fields
+type responseLocalStateQueryDrepStake struct {
+	Total      int                           `json:"total"           example:"1"`
+	NextCursor *string                       `json:"next_cursor"     example:"ACJMn3j01Hp7P6QVS3kXPPqJ6-hpFB0-7ZPahEJM"`
+	Dreps      []responseGovernanceDrepStake `json:"dreps,omitempty"`
+}
@@ -0,0 +1,380 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Test DReps with a key hash of 0x11 bytes and a script hash of 0x22 bytes
+const (
+	testDrepKeyId       = "drep1ygg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg42v5vz"
+	testDrepScriptId    = "drep1yv3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygsaxnf3t"
+	testDrepKeyCursor   = "ABERERERERERERERERERERERERERERERERERERE"
+	testDrepKeyHashHex  = "11111111111111111111111111111111111111111111111111111111"
+	testDrepScriptHexId = "2322222222222222222222222222222222222222222222222222222222"
+)
+
+var (
+	testDrepKey = ledgerDrep{
+		Type: ledger.DrepTypeAddrKeyHash,
+		Hash: ledger.NewBlake2b224(bytes.Repeat([]byte{0x11}, 28)),
+	}
+	testDrepScript = ledgerDrep{
+		Type: ledger.DrepTypeScriptHash,
+		Hash: ledger.NewBlake2b224(bytes.Repeat([]byte{0x22}, 28)),
+	}
+)
+
+func TestParseDrepId(t *testing.T) {
+	testDefs := []struct {
+		name         string
+		drepId       string
+		allowSpecial bool
+		want         ledgerDrep
+		wantErr      bool
+	}{
+		{
+			name:   "cip-129 key hash",
+			drepId: testDrepKeyId,
+			want:   testDrepKey,
+		},
+		{
+			name:   "cip-129 script hash",
+			drepId: testDrepScriptId,
+			want:   testDrepScript,
+		},
+		{
+			name:   "cip-105 key hash",
+			drepId: "drep1zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zkqglyq",
+			want:   testDrepKey,
+		},
+		{
+			name:   "cip-105 script hash",
+			drepId: "drep_script1yg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyvq5gyt",
+			want:   testDrepScript,
+		},
+		{
+			name:   "hex key hash",
+			drepId: testDrepKeyHashHex,
+			want:   testDrepKey,
+		},
+		{
+			name:   "hex with header",
+			drepId: testDrepScriptHexId,
+			want:   testDrepScript,
+		},
+		{
+			name:         "abstain",
+			drepId:       "abstain",
+			allowSpecial: true,
+			want:         ledgerDrep{Type: ledger.DrepTypeAbstain},
+		},
+		{
+			name:         "no confidence",
+			drepId:       "no_confidence",
+			allowSpecial: true,
+			want:         ledgerDrep{Type: ledger.DrepTypeNoConfidence},
+		},
+		{
+			name:    "abstain not allowed",
+			drepId:  "abstain",
+			wantErr: true,
+		},
+		{
+			name:    "committee hot credential",
+			drepId:  "cc_hot1qgg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg8777l0",
+			wantErr: true,
+		},
+		{
+			name:    "hex with wrong header",
+			drepId:  "02" + testDrepKeyHashHex,
+			wantErr: true,
+		},
+		{
+			name:    "bad checksum",
+			drepId:  testDrepKeyId[:len(testDrepKeyId)-1] + "q",
+			wantErr: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			drep, err := parseDrepId(testDef.drepId, testDef.allowSpecial)
+			if testDef.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", drep)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if drep != testDef.want {
+				t.Fatalf("unexpected DRep: %+v", drep)
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQueryDreps(t *testing.T) {
+	// The script hash DRep has no anchor
+	drepStateResult := "81a28201581c22222222222222222222222222222222222222222222222222222222" +
+		"84190258801a1dcd650080" +
+		"8200581c11111111111111111111111111111111111111111111111111111111" +
+		"831902448182781d68747470733a2f2f6578616d706c652e636f6d2f647265702e6a736f6e" +
+		"58209999999999999999999999999999999999999999999999999999999999999999" +
+		"1a1dcd6500"
+	keyDrep := responseGovernanceDrep{
+		Drep:        responseDrep{Type: drepTypeKeyHash, Id: testDrepKeyId},
+		ExpiryEpoch: 580,
+		Anchor: &responseGovernanceAnchor{
+			Url:      "https://example.com/drep.json",
+			DataHash: "9999999999999999999999999999999999999999999999999999999999999999",
+		},
+		Deposit: 500000000,
+	}
+	scriptDrep := responseGovernanceDrep{
+		Drep:        responseDrep{Type: drepTypeScriptHash, Id: testDrepScriptId},
+		ExpiryEpoch: 600,
+		Deposit:     500000000,
+	}
+	testDefs := []struct {
+		name  string
+		query string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+		want       responseLocalStateQueryDreps
+	}{
+		{
+			name: "all",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [25, []]]]]
+				"82008200820682181980",
+			},
+			results:    []string{"06", drepStateResult},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDreps{
+				Total: 2,
+				Dreps: []responseGovernanceDrep{keyDrep, scriptDrep},
+			},
+		},
+		{
+			name:  "first page",
+			query: "?limit=1",
+			queries: []string{
+				"820082028101",
+				"82008200820682181980",
+			},
+			results:    []string{"06", drepStateResult},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDreps{
+				Total:      2,
+				NextCursor: func() *string { s := testDrepKeyCursor; return &s }(),
+				Dreps:      []responseGovernanceDrep{keyDrep},
+			},
+		},
+		{
+			name:  "second page",
+			query: "?limit=1&cursor=" + testDrepKeyCursor,
+			queries: []string{
+				"820082028101",
+				"82008200820682181980",
+			},
+			results:    []string{"06", drepStateResult},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDreps{
+				Total: 2,
+				Dreps: []responseGovernanceDrep{scriptDrep},
+			},
+		},
+		{
+			name:  "filtered",
+			query: "?drep_id=" + testDrepKeyHashHex + "&drep_id=" + testDrepScriptHexId + "&drep_id=" + testDrepKeyId,
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [25, [[0, key hash], [1, script hash]]]]]]
+				"820082008206821819828200581c" + testDrepKeyHashHex +
+					"8201581c22222222222222222222222222222222222222222222222222222222",
+			},
+			results:    []string{"06", drepStateResult},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDreps{
+				Total: 2,
+				Dreps: []responseGovernanceDrep{keyDrep, scriptDrep},
+			},
+		},
+		{
+			name:       "invalid drep id",
+			query:      "?drep_id=abstain",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid cursor",
+			query:      "?cursor=AAAA",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "babbage",
+			queries:    []string{"820082028101"},
+			results:    []string{"05"},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if len(testDef.queries) > 0 {
+				startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			}
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/dreps",
+				handleLocalStateQueryDreps,
+				"/governance/dreps"+testDef.query,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryDreps
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.want) {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQueryDrepStake(t *testing.T) {
+	testDefs := []struct {
+		name  string
+		query string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+		want       responseLocalStateQueryDrepStake
+	}{
+		{
+			name: "all",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [26, []]]]]
+				"82008200820682181a80",
+			},
+			results: []string{
+				"06",
+				// {[3]: 7, [2]: 1000, [1, script hash]: 20, [0, key hash]: 300}
+				"81a481030781021903e8" +
+					"8201581c22222222222222222222222222222222222222222222222222222222" +
+					"14" +
+					"8200581c" + testDrepKeyHashHex + "19012c",
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDrepStake{
+				Total: 4,
+				Dreps: []responseGovernanceDrepStake{
+					{
+						Drep:  responseDrep{Type: drepTypeKeyHash, Id: testDrepKeyId},
+						Stake: "300",
+					},
+					{
+						Drep:  responseDrep{Type: drepTypeScriptHash, Id: testDrepScriptId},
+						Stake: "20",
+					},
+					{
+						Drep:  responseDrep{Type: drepTypeAbstain},
+						Stake: "1000",
+					},
+					{
+						Drep:  responseDrep{Type: drepTypeNoConfidence},
+						Stake: "7",
+					},
+				},
+			},
+		},
+		{
+			name:  "filtered",
+			query: "?drep_id=abstain&drep_id=" + testDrepKeyId,
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [26, [[2], [0, key hash]]]]]]
+				"82008200820682181a8281028200581c" + testDrepKeyHashHex,
+			},
+			results: []string{
+				"06",
+				"81a281021903e88200581c" + testDrepKeyHashHex + "19012c",
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryDrepStake{
+				Total: 2,
+				Dreps: []responseGovernanceDrepStake{
+					{
+						Drep:  responseDrep{Type: drepTypeKeyHash, Id: testDrepKeyId},
+						Stake: "300",
+					},
+					{
+						Drep:  responseDrep{Type: drepTypeAbstain},
+						Stake: "1000",
+					},
+				},
+			},
+		},
+		{
+			name:       "babbage",
+			queries:    []string{"820082028101"},
+			results:    []string{"05"},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/drep-stake",
+				handleLocalStateQueryDrepStake,
+				"/governance/drep-stake"+testDef.query,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryDrepStake
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.want) {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
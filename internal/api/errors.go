@@ -0,0 +1,222 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Machine-readable error codes returned in API error responses. These are part of
+// the API and must not be changed
+const (
+	errorCodeBadRequest            = "bad_request"
+	errorCodeUnauthorized          = "unauthorized"
+	errorCodeForbidden             = "forbidden"
+	errorCodeNotFound              = "not_found"
+	errorCodeMethodNotAllowed      = "method_not_allowed"
+	errorCodeUnsupportedMediaType  = "unsupported_media_type"
+	errorCodeRateLimited           = "rate_limited"
+	errorCodeTimeout               = "timeout"
+	errorCodeInvalidCbor           = "invalid_cbor"
+	errorCodeTxRejected            = "tx_rejected"
+	errorCodeNodeUnavailable       = "node_unavailable"
+	errorCodeNodeError             = "node_error"
+	errorCodeAcquireFailed         = "acquire_failed"
+	errorCodeBeyondHorizon         = "beyond_horizon"
+	errorCodeUtxoNotFound          = "utxo_not_found"
+	errorCodeRequestTooLarge       = "request_too_large"
+	errorCodeInvalidEncoding       = "invalid_encoding"
+	errorCodeTxTooLarge            = "tx_too_large"
+	errorCodeIdempotencyConflict   = "idempotency_conflict"
+	errorCodeCallbackLimit         = "callback_limit"
+	errorCodeUnsupportedEra        = "unsupported_era"
+	errorCodeMissingWitnesses      = "missing_witnesses"
+	errorCodeTxExpired             = "tx_expired"
+	errorCodeFeeTooLow             = "fee_too_low"
+	errorCodeScriptFailure         = "script_failure"
+	errorCodeEvaluationUnavailable = "evaluation_unavailable"
+	errorCodeNotInMempool          = "not_in_mempool"
+	errorCodeStreamLimit           = "stream_limit"
+	errorCodeInternal              = "internal_error"
+)
+
+// Non-standard status, as used by nginx, for requests where the client
+// disconnected before we could respond. It only shows up in the access log
+const statusClientClosedRequest = 499
+
+type responseApiError struct {
+	Code      string `json:"code,omitempty"       example:"node_unavailable" enums:"bad_request,unauthorized,forbidden,not_found,method_not_allowed,unsupported_media_type,rate_limited,timeout,invalid_cbor,tx_rejected,node_unavailable,node_error,acquire_failed,beyond_horizon,utxo_not_found,request_too_large,invalid_encoding,tx_too_large,idempotency_conflict,callback_limit,unsupported_era,missing_witnesses,tx_expired,fee_too_low,script_failure,evaluation_unavailable,not_in_mempool,stream_limit,internal_error"`
+	Msg       string `json:"msg"                  example:"error message"`
+	Details   any    `json:"details,omitempty"`
+	RequestId string `json:"request_id,omitempty" example:"0b8e5a8e-3e0f-4b6a-9d55-1c1c2b9f8f51"`
+}
+
+func apiError(msg string) responseApiError {
+	return responseApiError{
+		Msg: msg,
+	}
+}
+
+// apiErrorCode returns an error response with a machine-readable code and optional
+// structured details
+func apiErrorCode(code string, msg string, details any) responseApiError {
+	return responseApiError{
+		Code:    code,
+		Msg:     msg,
+		Details: details,
+	}
+}
+
+// respondError sends an error response, adding the request ID if enabled
+func respondError(c *gin.Context, status int, resp responseApiError) {
+	if config.GetConfig().Api.ErrorRequestId {
+		resp.RequestId = c.GetString(contextKeyRequestId)
+	}
+	c.JSON(status, resp)
+}
+
+// respondNodeUnavailable sends an error response for a failed node connection
+func respondNodeUnavailable(c *gin.Context, err error) {
+	if abortDisconnected(c) {
+		return
+	}
+	if respondBreakerOpen(c, err) {
+		return
+	}
+	respondError(
+		c,
+		500,
+		apiErrorCode(errorCodeNodeUnavailable, err.Error(), nil),
+	)
+}
+
+// abortDisconnected aborts the request without a response if the client has gone
+// away, since there's nobody to respond to
+func abortDisconnected(c *gin.Context) bool {
+	if !errors.Is(c.Request.Context().Err(), context.Canceled) {
+		return false
+	}
+	c.AbortWithStatus(statusClientClosedRequest)
+	return true
+}
+
+// respondBreakerOpen sends a service unavailable response if the error is from the
+// node connection circuit breaker, with a Retry-After header for when the breaker
+// next probes the node
+func respondBreakerOpen(c *gin.Context, err error) bool {
+	var breakerErr *node.BreakerOpenError
+	if !errors.As(err, &breakerErr) {
+		return false
+	}
+	retryAfter := max(int(math.Ceil(breakerErr.RetryAfter.Seconds())), 1)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	respondError(
+		c,
+		http.StatusServiceUnavailable,
+		apiErrorCode(errorCodeNodeUnavailable, err.Error(), nil),
+	)
+	return true
+}
+
+// nodeAvailableMiddleware rejects requests until the connection manager has
+// connected to the node, such as before the node has finished starting up
+func nodeAvailableMiddleware(c *gin.Context) {
+	if !node.HasConnected() {
+		respondError(
+			c,
+			http.StatusServiceUnavailable,
+			apiErrorCode(
+				errorCodeNodeUnavailable,
+				"not connected to node",
+				nil,
+			),
+		)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// respondNodeError sends an error response for a failed node operation. Failures
+// caused by the request deadline passing are reported as a gateway timeout
+func respondNodeError(c *gin.Context, err error) {
+	if abortDisconnected(c) {
+		return
+	}
+	if respondBreakerOpen(c, err) {
+		return
+	}
+	var timeoutErr *node.TimeoutError
+	if errors.As(err, &timeoutErr) ||
+		errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		respondError(
+			c,
+			http.StatusGatewayTimeout,
+			apiErrorCode(
+				errorCodeTimeout,
+				"timed out waiting for response from node",
+				nil,
+			),
+		)
+		return
+	}
+	code := errorCodeNodeError
+	var pointTooOldErr localstatequery.AcquireFailurePointTooOldError
+	var pointNotOnChainErr localstatequery.AcquireFailurePointNotOnChainError
+	if errors.As(err, &pointTooOldErr) || errors.As(err, &pointNotOnChainErr) {
+		code = errorCodeAcquireFailed
+	}
+	respondError(c, 500, apiErrorCode(code, err.Error(), nil))
+}
+
+// respondAcquireError sends an error response for a failure to acquire the ledger
+// state. The node can't serve queries right now, so this is reported as service
+// unavailable, unless the request timed out or the client went away. A point that
+// the node can't acquire is reported as unprocessable, since it came from the
+// request
+func respondAcquireError(c *gin.Context, err error) {
+	var timeoutErr *node.TimeoutError
+	var breakerErr *node.BreakerOpenError
+	if errors.As(err, &timeoutErr) || errors.As(err, &breakerErr) ||
+		c.Request.Context().Err() != nil {
+		respondNodeError(c, err)
+		return
+	}
+	var pointTooOldErr localstatequery.AcquireFailurePointTooOldError
+	var pointNotOnChainErr localstatequery.AcquireFailurePointNotOnChainError
+	if errors.As(err, &pointTooOldErr) || errors.As(err, &pointNotOnChainErr) {
+		respondError(
+			c,
+			http.StatusUnprocessableEntity,
+			apiErrorCode(errorCodeAcquireFailed, err.Error(), nil),
+		)
+		return
+	}
+	respondError(
+		c,
+		http.StatusServiceUnavailable,
+		apiErrorCode(errorCodeAcquireFailed, err.Error(), nil),
+	)
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondNotModified sets the ETag header for a response and sends a 304 if the
+// client already has it, in which case it returns true and the response body
+// shouldn't be sent
+func respondNotModified(c *gin.Context, etag string) bool {
+	etag = `"` + etag + `"`
+	c.Header("ETag", etag)
+	for _, match := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		// Weak comparison is used for If-None-Match
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == etag || match == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
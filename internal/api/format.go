@@ -0,0 +1,67 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"mime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response formats for endpoints that can return ledger CBOR
+const (
+	responseFormatJson = "json"
+	responseFormatCbor = "cbor"
+	responseFormatHex  = "hex"
+)
+
+const (
+	mimeTypeCbor        = "application/cbor"
+	mimeTypeOctetStream = "application/octet-stream"
+	mimeTypeText        = "text/plain"
+)
+
+// responseFormat returns the response format requested by the client, using the
+// "format" query parameter if present and the Accept header otherwise. Anything
+// unrecognized results in JSON
+func responseFormat(c *gin.Context) string {
+	switch format := c.Query("format"); format {
+	case responseFormatJson, responseFormatCbor, responseFormatHex:
+		return format
+	}
+	for _, accept := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == mimeTypeCbor {
+			return responseFormatCbor
+		}
+	}
+	return responseFormatJson
+}
+
+// respondCbor sends CBOR data as a hex string for the hex format and as raw bytes
+// otherwise
+func respondCbor(c *gin.Context, status int, cborData []byte) {
+	if responseFormat(c) == responseFormatHex {
+		c.Data(
+			status,
+			"text/plain; charset=utf-8",
+			[]byte(hex.EncodeToString(cborData)),
+		)
+		return
+	}
+	c.Data(status, mimeTypeCbor, cborData)
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+type responseLocalStateQueryGenesis struct {
+	SystemStart            time.Time        `json:"system_start"             example:"2017-09-23T21:44:51Z"`
+	NetworkMagic           uint32           `json:"network_magic"            example:"764824073"`
+	NetworkId              uint8            `json:"network_id"               example:"1"`
+	ActiveSlotsCoefficient responseRational `json:"active_slots_coefficient"`
+	SecurityParam          uint64           `json:"security_param"           example:"2160"`
+	EpochLength            uint64           `json:"epoch_length"             example:"432000"`
+	SlotLength             uint64           `json:"slot_length_milliseconds" example:"1000"`
+	SlotsPerKesPeriod      uint64           `json:"slots_per_kes_period"     example:"129600"`
+	MaxKesEvolutions       uint64           `json:"max_kes_evolutions"       example:"62"`
+	UpdateQuorum           uint64           `json:"update_quorum"            example:"5"`
+	MaxLovelaceSupply      uint64           `json:"max_lovelace_supply"      example:"45000000000000000"`
+}
+
+// responseRational is a rational number, with the decimal string for clients
+// that don't need the exact value
+type responseRational struct {
+	Numerator   int64  `json:"numerator"   example:"1"`
+	Denominator int64  `json:"denominator" example:"20"`
+	Decimal     string `json:"decimal"     example:"0.05"`
+}
+
+func newResponseGenesis(
+	genesisConfig *localstatequery.GenesisConfigResult,
+) (responseLocalStateQueryGenesis, error) {
+	activeSlotsCoeff, err := newResponseRational(genesisConfig.ActiveSlotsCoeff)
+	if err != nil {
+		return responseLocalStateQueryGenesis{}, fmt.Errorf(
+			"invalid active slots coefficient: %s",
+			err,
+		)
+	}
+	ret := responseLocalStateQueryGenesis{
+		SystemStart:            node.SystemStartTime(&genesisConfig.Start),
+		NetworkMagic:           uint32(genesisConfig.NetworkMagic),
+		NetworkId:              genesisConfig.NetworkId,
+		ActiveSlotsCoefficient: activeSlotsCoeff,
+		SecurityParam:          uint64(genesisConfig.SecurityParam),
+		EpochLength:            uint64(genesisConfig.EpochLength),
+		// The slot length is in microseconds
+		SlotLength:        uint64(genesisConfig.SlotLength) / 1_000,
+		SlotsPerKesPeriod: uint64(genesisConfig.SlotsPerKESPeriod),
+		MaxKesEvolutions:  uint64(genesisConfig.MaxKESEvolutions),
+		UpdateQuorum:      uint64(genesisConfig.UpdateQuorum),
+		MaxLovelaceSupply: uint64(genesisConfig.MaxLovelaceSupply),
+	}
+	return ret, nil
+}
+
+// newResponseRational returns a rational from its decoded CBOR numerator and
+// denominator
+func newResponseRational(value []any) (responseRational, error) {
+	if len(value) != 2 {
+		return responseRational{}, fmt.Errorf(
+			"expected numerator and denominator, got %d values",
+			len(value),
+		)
+	}
+	var parts [2]int64
+	for idx, part := range value {
+		switch v := part.(type) {
+		case int64:
+			parts[idx] = v
+		case uint64:
+			parts[idx] = int64(v)
+		default:
+			return responseRational{}, fmt.Errorf("unexpected type: %T", part)
+		}
+	}
+	if parts[1] == 0 {
+		return responseRational{}, fmt.Errorf("zero denominator")
+	}
+	return responseRational{
+		Numerator:   parts[0],
+		Denominator: parts[1],
+		Decimal:     formatRat(big.NewRat(parts[0], parts[1])),
+	}, nil
+}
+
+// genesisCache holds the genesis config, which doesn't change for a network. It's
+// only fetched again after the connection manager reconnects to the node, in
+// case that's a different node
+type genesisCache struct {
+	mutex       sync.Mutex
+	genesis     *responseLocalStateQueryGenesis
+	connectedAt time.Time
+}
+
+var networkGenesis = &genesisCache{}
+
+// get returns the cached genesis config if it was fetched on the connection
+// manager's current connection
+func (g *genesisCache) get() (*responseLocalStateQueryGenesis, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.genesis == nil || !g.connectedAt.Equal(managedConnectedAt()) {
+		return nil, false
+	}
+	return g.genesis, true
+}
+
+func (g *genesisCache) set(
+	genesis *responseLocalStateQueryGenesis,
+	connectedAt time.Time,
+) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.genesis = genesis
+	g.connectedAt = connectedAt
+}
+
+// managedConnectedAt returns when the connection manager's current connection
+// was made, or the zero time if it isn't connected
+func managedConnectedAt() time.Time {
+	if conn := node.GetManagedConnection(); conn != nil {
+		return conn.ConnectedAt
+	}
+	return time.Time{}
+}
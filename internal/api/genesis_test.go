@@ -0,0 +1,90 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// CBOR for the GetGenesisConfig result on mainnet, built from the mainnet Shelley
+// genesis file. The genesis delegations are left out, since we don't use them
+const mainnetGenesisConfigHex = "8f831907e119010a1b0116253fec1c30001a2d964a0901d81e8201141908701a000697801a0001fa40183e1a000f4240051b009fdf42f6e4800092182c1a00025ef51a0001000019400019044c1a001e84801a1dcd6500121896d81e82030ad81e82031903e8d81e820105d81e820101810002001a000f42401a1443fd00a0a0a0"
+
+func TestNewResponseGenesis(t *testing.T) {
+	testDefs := []struct {
+		name      string
+		genesis   string
+		expected  responseLocalStateQueryGenesis
+		expectErr bool
+	}{
+		{
+			name:    "mainnet",
+			genesis: mainnetGenesisConfigHex,
+			expected: responseLocalStateQueryGenesis{
+				SystemStart:  time.Date(2017, time.September, 23, 21, 44, 51, 0, time.UTC),
+				NetworkMagic: 764824073,
+				NetworkId:    1,
+				ActiveSlotsCoefficient: responseRational{
+					Numerator:   1,
+					Denominator: 20,
+					Decimal:     "0.05",
+				},
+				SecurityParam:     2160,
+				EpochLength:       432000,
+				SlotLength:        1000,
+				SlotsPerKesPeriod: 129600,
+				MaxKesEvolutions:  62,
+				UpdateQuorum:      5,
+				MaxLovelaceSupply: 45000000000000000,
+			},
+		},
+		{
+			name:      "zero denominator for the active slots coefficient",
+			genesis:   "8f831907e119010a1b0116253fec1c30001a2d964a0901d81e8201001908701a000697801a0001fa40183e1a000f4240051b009fdf42f6e4800092182c1a00025ef51a0001000019400019044c1a001e84801a1dcd6500121896d81e82030ad81e82031903e8d81e820105d81e820101810002001a000f42401a1443fd00a0a0a0",
+			expectErr: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			genesisCbor, err := hex.DecodeString(testDef.genesis)
+			if err != nil {
+				t.Fatalf("failed to decode fixture hex: %s", err)
+			}
+			var genesisConfig localstatequery.GenesisConfigResult
+			if _, err := cbor.Decode(genesisCbor, &genesisConfig); err != nil {
+				t.Fatalf("failed to decode genesis config: %s", err)
+			}
+			genesis, err := newResponseGenesis(&genesisConfig)
+			if testDef.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got: %+v", genesis)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(genesis, testDef.expected) {
+				t.Fatalf("unexpected genesis:\n got: %+v\nwant: %+v", genesis, testDef.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Credential types in governance responses
+const (
+	governanceCredentialKeyHash    = "key_hash"
+	governanceCredentialScriptHash = "script_hash"
+)
+
+type responseGovernanceCredential struct {
+	Type string `json:"type" example:"key_hash" enums:"key_hash,script_hash"`
+	Id   string `json:"id"   example:"cc_cold1zgxgvqn5xatjee2ynm4jf6llnswqrtdhkf4gkh2rfa4jezquzmxw5"`
+	Hash string `json:"hash" example:"0c86027437572ce5449eeb24ebff9c1c01adb7b26a8b5d434f6b2c88"`
+}
+
+func newResponseGovernanceCredential(
+	prefix string,
+	header byte,
+	cred ledgerCredential,
+) responseGovernanceCredential {
+	ret := responseGovernanceCredential{
+		Type: governanceCredentialKeyHash,
+		Id: cip129Id(
+			prefix,
+			header,
+			cred.Type == credentialTypeScriptHash,
+			cred.Hash[:],
+		),
+		Hash: cred.Hash.String(),
+	}
+	if cred.Type == credentialTypeScriptHash {
+		ret.Type = governanceCredentialScriptHash
+	}
+	return ret
+}
+
+// compareLedgerCredentials orders credentials by type and then hash
+func compareLedgerCredentials(a, b ledgerCredential) int {
+	if a.Type != b.Type {
+		if a.Type < b.Type {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a.Hash[:], b.Hash[:])
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[K comparable, V any](
+	m map[K]V,
+	compare func(a, b K) int,
+) []K {
+	ret := make([]K, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	slices.SortFunc(ret, compare)
+	return ret
+}
+
+// pageKeys returns the page of sorted keys after the cursor key, up to the
+// limit if it's set, and the last key of the page if there are more. The
+// cursor key doesn't need to be one of the keys
+func pageKeys[K any](
+	keys []K,
+	cursor *K,
+	limit uint,
+	compare func(a, b K) int,
+) ([]K, *K) {
+	if cursor != nil {
+		start, found := slices.BinarySearchFunc(keys, *cursor, compare)
+		if found {
+			start++
+		}
+		keys = keys[start:]
+	}
+	if limit == 0 || uint(len(keys)) <= limit {
+		return keys, nil
+	}
+	keys = keys[:limit]
+	return keys, &keys[len(keys)-1]
+}
+
+type responseGovernanceAnchor struct {
+	Url      string `json:"url"       example:"ipfs://bafkreifnwj6zpu3ixa4siz2lndqybyc5wnnt3jkwyutci4e2tmbnj3xrdm"`
+	DataHash string `json:"data_hash" example:"ca41a91f399259bcefe57f9858e91f6d00e1a38d6d9c63d4052914ea7bd70cb2"`
+}
+
+func newResponseGovernanceAnchor(
+	anchor ledger.GovAnchor,
+) responseGovernanceAnchor {
+	return responseGovernanceAnchor{
+		Url:      anchor.Url,
+		DataHash: hex.EncodeToString(anchor.DataHash[:]),
+	}
+}
+
+type responseGovActionId struct {
+	TxHash string `json:"tx_hash" example:"b4c5c5e2c9abe6d8bbd3f5a2ca43a21d1e2b8c0a74dc4d6a70e2d0e5d1c9f4e1"`
+	Index  uint32 `json:"index"   example:"0"`
+}
+
+func newResponseGovActionId(id ledger.GovActionId) *responseGovActionId {
+	return &responseGovActionId{
+		TxHash: hex.EncodeToString(id.TransactionId[:]),
+		Index:  id.GovActionIdx,
+	}
+}
+
+type responseLocalStateQueryConstitution struct {
+	Anchor               responseGovernanceAnchor `json:"anchor"`
+	GuardrailsScriptHash string                   `json:"guardrails_script_hash,omitempty" example:"fa24fb305126805cf2164c161d852a0e7330cf988f1fe558cf7d4a64"`
+}
+
+// constitutionResult is the result of the constitution query. The guardrails
+// script hash is null if there isn't one
+type constitutionResult struct {
+	cbor.StructAsArray
+	Anchor     ledger.GovAnchor
+	ScriptHash *ledger.Blake2b224
+}
+
+func newResponseConstitution(
+	constitution constitutionResult,
+) responseLocalStateQueryConstitution {
+	ret := responseLocalStateQueryConstitution{
+		Anchor: newResponseGovernanceAnchor(constitution.Anchor),
+	}
+	if constitution.ScriptHash != nil {
+		ret.GuardrailsScriptHash = constitution.ScriptHash.String()
+	}
+	return ret
+}
+
+// The previous enacted governance action of each purpose, which new actions of
+// that purpose must refer to. These are null if none has been enacted
+type responseGovernancePrevActionIds struct {
+	ParameterChange *responseGovActionId `json:"parameter_change"`
+	HardFork        *responseGovActionId `json:"hard_fork"`
+	Committee       *responseGovActionId `json:"committee"`
+	Constitution    *responseGovActionId `json:"constitution"`
+}
+
+type responseGovernanceCommitteeMember struct {
+	ColdCredential responseGovernanceCredential `json:"cold_credential"`
+	ExpiryEpoch    uint64                       `json:"expiry_epoch"    example:"580"`
+}
+
+// The threshold is the fraction of members that must vote yes, as a decimal
+// string
+type responseGovernanceCommittee struct {
+	Members   []responseGovernanceCommitteeMember `json:"members"`
+	Threshold string                              `json:"threshold" example:"0.66666666666666666667"`
+}
+
+// The committee is null if there isn't an enacted committee. The treasury is in
+// lovelace, as a string
+type responseLocalStateQueryGovState struct {
+	ProtocolVersion  *responseProtocolVersion        `json:"protocol_version"`
+	Committee        *responseGovernanceCommittee    `json:"committee"`
+	Treasury         string                          `json:"treasury"            example:"1523463600599097"`
+	PrevGovActionIds responseGovernancePrevActionIds `json:"prev_gov_action_ids"`
+}
+
+// Items of the gov state query result that we use, which is a list of the
+// proposals, the committee, the constitution, and the current, previous, and
+// future protocol parameters, followed by the DRep pulsing state
+const (
+	govStateIdxProposals  = 0
+	govStateIdxCommittee  = 1
+	govStateIdxCurPParams = 3
+)
+
+// govStateProposals is the proposals in the gov state, as the previous enacted
+// governance action of each purpose and then the live actions
+type govStateProposals struct {
+	cbor.StructAsArray
+	Roots struct {
+		cbor.StructAsArray
+		ParameterChange strictMaybe[ledger.GovActionId]
+		HardFork        strictMaybe[ledger.GovActionId]
+		Committee       strictMaybe[ledger.GovActionId]
+		Constitution    strictMaybe[ledger.GovActionId]
+	}
+	Actions cbor.RawMessage
+}
+
+// governanceCommittee is the committee members with their expiry epochs, and
+// the quorum threshold
+type governanceCommittee struct {
+	cbor.StructAsArray
+	Members   map[ledgerCredential]uint64
+	Threshold cbor.Rat
+}
+
+// accountStateResult is the result of the account state query
+type accountStateResult struct {
+	cbor.StructAsArray
+	Treasury uint64
+	Reserves uint64
+}
+
+// newResponseGovState maps the result of the gov state query, with the treasury
+// from the account state query
+func newResponseGovState(
+	govState []byte,
+	accountState accountStateResult,
+) (responseLocalStateQueryGovState, error) {
+	ret := responseLocalStateQueryGovState{
+		Treasury: strconv.FormatUint(accountState.Treasury, 10),
+	}
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(govState, &items); err != nil {
+		return ret, fmt.Errorf("failed to decode gov state: %s", err)
+	}
+	if len(items) <= govStateIdxCurPParams {
+		return ret, fmt.Errorf("gov state too short: %d items", len(items))
+	}
+
+	var proposals govStateProposals
+	if _, err := cbor.Decode(items[govStateIdxProposals], &proposals); err != nil {
+		return ret, fmt.Errorf("failed to decode gov state proposals: %s", err)
+	}
+	for _, root := range []struct {
+		id   strictMaybe[ledger.GovActionId]
+		dest **responseGovActionId
+	}{
+		{proposals.Roots.ParameterChange, &ret.PrevGovActionIds.ParameterChange},
+		{proposals.Roots.HardFork, &ret.PrevGovActionIds.HardFork},
+		{proposals.Roots.Committee, &ret.PrevGovActionIds.Committee},
+		{proposals.Roots.Constitution, &ret.PrevGovActionIds.Constitution},
+	} {
+		if root.id.Value != nil {
+			*root.dest = newResponseGovActionId(*root.id.Value)
+		}
+	}
+
+	// The committee is null if there isn't one
+	var committee *governanceCommittee
+	if _, err := cbor.Decode(items[govStateIdxCommittee], &committee); err != nil {
+		return ret, fmt.Errorf("failed to decode gov state committee: %s", err)
+	}
+	if committee != nil {
+		ret.Committee = newResponseGovernanceCommittee(committee)
+	}
+
+	// The protocol version comes from the current protocol parameters, which
+	// are decoded generically like the ones from the protocol parameters query
+	var pparams any
+	if _, err := cbor.Decode(items[govStateIdxCurPParams], &pparams); err != nil {
+		return ret, fmt.Errorf(
+			"failed to decode gov state protocol parameters: %s",
+			err,
+		)
+	}
+	params, err := newResponseProtocolParameters(
+		ledger.GetEraById(ledger.EraIdConway),
+		pparams,
+	)
+	if err != nil {
+		return ret, err
+	}
+	ret.ProtocolVersion = params.ProtocolVersion
+	return ret, nil
+}
+
+// newResponseGovernanceCommittee maps a committee, with the members ordered by
+// cold credential
+func newResponseGovernanceCommittee(
+	committee *governanceCommittee,
+) *responseGovernanceCommittee {
+	creds := sortedKeys(committee.Members, compareLedgerCredentials)
+	ret := &responseGovernanceCommittee{
+		Members: make(
+			[]responseGovernanceCommitteeMember,
+			0,
+			len(committee.Members),
+		),
+	}
+	for _, cred := range creds {
+		ret.Members = append(
+			ret.Members,
+			responseGovernanceCommitteeMember{
+				ColdCredential: newResponseGovernanceCredential(
+					cip129PrefixCommitteeCold,
+					cip129HeaderCommitteeCold,
+					cred,
+				),
+				ExpiryEpoch: committee.Members[cred],
+			},
+		)
+	}
+	if committee.Threshold.Rat != nil {
+		ret.Threshold = formatRat(committee.Threshold.ToBigRat())
+	}
+	return ret
+}
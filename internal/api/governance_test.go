@@ -0,0 +1,247 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+)
+
+// CBOR for parts of the Conway ledger query results, in the encoding used by the
+// node
+const (
+	// The constitution, with an anchor and a guardrails script hash
+	testConstitutionHex = "82827842697066733a2f2f6261666b726569666e776a367a7075336978613473697a326c6e64717962796335776e6e74336a6b777975746369346532746d626e6a337872646d5820ca41a91f399259bcefe57f9858e91f6d00e1a38d6d9c63d4052914ea7bd70cb2581cfa24fb305126805cf2164c161d852a0e7330cf988f1fe558cf7d4a64"
+	// The Conway protocol parameters, with protocol version 10.0
+	testConwayPParamsHex = "981f182c1a00025ef51a0001600019400019044c1a001e84801a1dcd6500121901f4d81e82030ad81e82031903e8d81e820105820a001a0a21fe801910d6a30082186418c8018119012c028119019082d81e82190241192710d81e821902d11a00989680821a00d59f801b00000002540be400821a03b20b801b00000004a817c80019138818960385d81e8218331864d81e8218331864d81e8218331864d81e8218331864d81e82183318648ad81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864d81e8218431864071892061b000000174876e8001a1dcd650014d81e820f01"
+	// Proposals with enacted hard fork and constitution actions, and no live
+	// actions
+	testGovStateProposalsHex = "82848081825820b4c5c5e2c9abe6d8bbd3f5a2ca43a21d1e2b8c0a74dc4d6a70e2d0e5d1c9f4e1008081825820aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa0180"
+	// A committee with a key hash member and a script hash member, and a
+	// threshold of 2/3
+	testCommitteeHex = "82a28200581c0c86027437572ce5449eeb24ebff9c1c01adb7b26a8b5d434f6b2c881902448201581c44444444444444444444444444444444444444444444444444444444190258d81e820203"
+	// The gov state, with the future protocol parameters and the DRep pulsing
+	// state at the end
+	testGovStateHex = "87" + testGovStateProposalsHex + testCommitteeHex + testConstitutionHex + testConwayPParamsHex + testConwayPParamsHex + "8100" + "8101"
+	// The account state, as the treasury and the reserves
+	testAccountStateHex = "821b0005699502bdf0391b001c6bf526340000"
+)
+
+var testGovState = responseLocalStateQueryGovState{
+	ProtocolVersion: &responseProtocolVersion{Major: 10, Minor: 0},
+	Committee: &responseGovernanceCommittee{
+		Members: []responseGovernanceCommitteeMember{
+			{
+				ColdCredential: responseGovernanceCredential{
+					Type: governanceCredentialKeyHash,
+					Id:   "cc_cold1zgxgvqn5xatjee2ynm4jf6llnswqrtdhkf4gkh2rfa4jezquzmxw5",
+					Hash: "0c86027437572ce5449eeb24ebff9c1c01adb7b26a8b5d434f6b2c88",
+				},
+				ExpiryEpoch: 580,
+			},
+			{
+				ColdCredential: responseGovernanceCredential{
+					Type: governanceCredentialScriptHash,
+					Id:   "cc_cold1zdzyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3qr7p3k4",
+					Hash: "44444444444444444444444444444444444444444444444444444444",
+				},
+				ExpiryEpoch: 600,
+			},
+		},
+		Threshold: "0.66666666666666666667",
+	},
+	Treasury: "1523463600599097",
+	PrevGovActionIds: responseGovernancePrevActionIds{
+		HardFork: &responseGovActionId{
+			TxHash: "b4c5c5e2c9abe6d8bbd3f5a2ca43a21d1e2b8c0a74dc4d6a70e2d0e5d1c9f4e1",
+			Index:  0,
+		},
+		Constitution: &responseGovActionId{
+			TxHash: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			Index:  1,
+		},
+	},
+}
+
+func TestNewResponseConstitution(t *testing.T) {
+	testAnchor := responseGovernanceAnchor{
+		Url:      "ipfs://bafkreifnwj6zpu3ixa4siz2lndqybyc5wnnt3jkwyutci4e2tmbnj3xrdm",
+		DataHash: "ca41a91f399259bcefe57f9858e91f6d00e1a38d6d9c63d4052914ea7bd70cb2",
+	}
+	testDefs := []struct {
+		name         string
+		constitution string
+		expected     responseLocalStateQueryConstitution
+	}{
+		{
+			name:         "guardrails script",
+			constitution: testConstitutionHex,
+			expected: responseLocalStateQueryConstitution{
+				Anchor:               testAnchor,
+				GuardrailsScriptHash: "fa24fb305126805cf2164c161d852a0e7330cf988f1fe558cf7d4a64",
+			},
+		},
+		{
+			name: "no guardrails script",
+			// The script hash is null
+			constitution: testConstitutionHex[:len(testConstitutionHex)-60] + "f6",
+			expected: responseLocalStateQueryConstitution{
+				Anchor: testAnchor,
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			constitutionCbor, err := hex.DecodeString(testDef.constitution)
+			if err != nil {
+				t.Fatalf("invalid constitution hex: %s", err)
+			}
+			var constitution constitutionResult
+			if _, err := cbor.Decode(constitutionCbor, &constitution); err != nil {
+				t.Fatalf("failed to decode constitution: %s", err)
+			}
+			resp := newResponseConstitution(constitution)
+			if !reflect.DeepEqual(resp, testDef.expected) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestNewResponseGovState(t *testing.T) {
+	testDefs := []struct {
+		name      string
+		govState  string
+		expected  responseLocalStateQueryGovState
+		expectErr bool
+	}{
+		{
+			name:     "committee",
+			govState: testGovStateHex,
+			expected: testGovState,
+		},
+		{
+			name: "no committee or enacted actions",
+			govState: "87" + "828480808080" + "80" + "f6" + testConstitutionHex +
+				testConwayPParamsHex + testConwayPParamsHex + "8100" + "8101",
+			expected: responseLocalStateQueryGovState{
+				ProtocolVersion: &responseProtocolVersion{Major: 10, Minor: 0},
+				Treasury:        "1523463600599097",
+			},
+		},
+		{
+			name:      "too short",
+			govState:  "83" + testGovStateProposalsHex + testCommitteeHex + testConstitutionHex,
+			expectErr: true,
+		},
+	}
+	accountStateCbor, err := hex.DecodeString(testAccountStateHex)
+	if err != nil {
+		t.Fatalf("invalid account state hex: %s", err)
+	}
+	var accountState accountStateResult
+	if _, err := cbor.Decode(accountStateCbor, &accountState); err != nil {
+		t.Fatalf("failed to decode account state: %s", err)
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			govState, err := hex.DecodeString(testDef.govState)
+			if err != nil {
+				t.Fatalf("invalid gov state hex: %s", err)
+			}
+			resp, err := newResponseGovState(govState, accountState)
+			if testDef.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.expected) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQueryGovState(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+	}{
+		{
+			name: "conway",
+			queries: []string{
+				// [0, [2, [1]]]
+				"820082028101",
+				// [0, [0, [6, [24]]]]
+				"820082008206811818",
+				// [0, [0, [6, [29]]]]
+				"82008200820681181d",
+			},
+			results: []string{
+				"06",
+				"81" + testGovStateHex,
+				"81" + testAccountStateHex,
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "babbage",
+			queries: []string{
+				"820082028101",
+			},
+			results: []string{
+				"05",
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/state",
+				handleLocalStateQueryGovState,
+				"/governance/state",
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryGovState
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testGovState) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Names of the individual healthcheck stages, reported on failure
+const (
+	healthcheckCheckConnection = "connection"
+	healthcheckCheckBreaker    = "breaker"
+	healthcheckCheckSocket     = "socket"
+	healthcheckCheckHandshake  = "handshake"
+	healthcheckCheckQuery      = "query"
+	healthcheckCheckTip        = "tip"
+)
+
+type responseHealthcheck struct {
+	Failed     bool   `json:"failed"`
+	Check      string `json:"check,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Breaker    string `json:"breaker,omitempty"`
+}
+
+type responseLivez struct {
+	Status string `json:"status"`
+}
+
+// handleLivez reports that the HTTP server is responding, without checking the node
+func handleLivez(c *gin.Context) {
+	c.JSON(http.StatusOK, responseLivez{Status: "ok"})
+}
+
+func handleHealthcheck(c *gin.Context) {
+	respondHealthcheck(c, false)
+}
+
+// handleReadyz checks that the node can serve queries, and optionally that the node
+// tip is close to the current wall-clock slot. The node is checked on every
+// request, so readiness recovers as soon as the node is reachable again. We're
+// never ready while the connection manager is waiting to connect to the node, or
+// while the node connection circuit breaker isn't closed
+func handleReadyz(c *gin.Context) {
+	breakerState := node.BreakerState()
+	if !node.Connected() {
+		c.JSON(
+			http.StatusServiceUnavailable,
+			responseHealthcheck{
+				Failed:  true,
+				Check:   healthcheckCheckConnection,
+				Error:   "waiting for connection to node",
+				Breaker: breakerState,
+			},
+		)
+		return
+	}
+	if breakerState != node.BreakerStateClosed {
+		c.JSON(
+			http.StatusServiceUnavailable,
+			responseHealthcheck{
+				Failed: true,
+				Check:  healthcheckCheckBreaker,
+				Error: fmt.Sprintf(
+					"node connection circuit breaker is %s",
+					breakerState,
+				),
+				Breaker: breakerState,
+			},
+		)
+		return
+	}
+	respondHealthcheck(c, config.GetConfig().Api.ReadyzMaxSlotLag > 0)
+}
+
+func respondHealthcheck(c *gin.Context, checkTip bool) {
+	cfg := config.GetConfig()
+	startTime := time.Now()
+	// Run the check in the background so that we can bound it with a timeout
+	var stage atomic.Value
+	stage.Store(healthcheckCheckSocket)
+	resultChan := make(chan responseHealthcheck, 1)
+	go func() {
+		resultChan <- runHealthcheck(cfg, &stage, checkTip)
+	}()
+	var resp responseHealthcheck
+	select {
+	case resp = <-resultChan:
+	case <-time.After(time.Duration(cfg.Api.HealthcheckTimeout) * time.Second):
+		resp = responseHealthcheck{
+			Failed: true,
+			Check:  stage.Load().(string),
+			Error: fmt.Sprintf(
+				"timed out after %ds",
+				cfg.Api.HealthcheckTimeout,
+			),
+		}
+	}
+	resp.DurationMs = time.Since(startTime).Milliseconds()
+	resp.Breaker = node.BreakerState()
+	if resp.Failed {
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// runHealthcheck checks that the node socket exists, performs a handshake, and runs
+// a cheap local state query. If checkTip is set, it also checks that the node tip is
+// within the configured number of slots of the wall-clock slot. The stage value is
+// updated as each check starts
+func runHealthcheck(
+	cfg *config.Config,
+	stage *atomic.Value,
+	checkTip bool,
+) responseHealthcheck {
+	// Check that the node socket exists when not connecting via TCP
+	if cfg.Node.Address == "" && cfg.Node.SocketPath != "" {
+		if err := node.CheckSocketPath(cfg.Node.SocketPath); err != nil {
+			return responseHealthcheck{
+				Failed: true,
+				Check:  healthcheckCheckSocket,
+				Error:  err.Error(),
+			}
+		}
+	}
+	// Connect to node, which performs the handshake
+	stage.Store(healthcheckCheckHandshake)
+	oConn, err := node.GetConnection(nil)
+	if err != nil {
+		return responseHealthcheck{
+			Failed: true,
+			Check:  healthcheckCheckHandshake,
+			Error:  err.Error(),
+		}
+	}
+	defer func() {
+		// Close Ouroboros connection
+		oConn.Close()
+	}()
+	// Query the chain tip
+	stage.Store(healthcheckCheckQuery)
+	oConn.LocalStateQuery().Client.Start()
+	point, err := oConn.LocalStateQuery().Client.GetChainPoint()
+	if err != nil {
+		return responseHealthcheck{
+			Failed: true,
+			Check:  healthcheckCheckQuery,
+			Error:  err.Error(),
+		}
+	}
+	if !checkTip {
+		return responseHealthcheck{}
+	}
+	// Compare the tip against the wall-clock slot
+	stage.Store(healthcheckCheckTip)
+	err = checkTipLag(
+		oConn.LocalStateQuery().Client,
+		point.Slot,
+		cfg.Api.ReadyzMaxSlotLag,
+	)
+	if err != nil {
+		return responseHealthcheck{
+			Failed: true,
+			Check:  healthcheckCheckTip,
+			Error:  err.Error(),
+		}
+	}
+	return responseHealthcheck{}
+}
+
+// checkTipLag returns an error if the tip slot is more than maxSlotLag slots behind
+// the wall-clock slot
+func checkTipLag(
+	client *localstatequery.Client,
+	tipSlot uint64,
+	maxSlotLag uint,
+) error {
+	systemStart, err := client.GetSystemStart()
+	if err != nil {
+		return err
+	}
+	eraHistory, err := client.GetEraHistory()
+	if err != nil {
+		return err
+	}
+	currentSlot, err := node.SlotAtTime(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	if currentSlot > tipSlot && currentSlot-tipSlot > uint64(maxSlotLag) {
+		return fmt.Errorf(
+			"node tip at slot %d is %d slots behind the current slot %d",
+			tipSlot,
+			currentSlot-tipSlot,
+			currentSlot,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// Set on responses that were replayed from an earlier request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// Longer keys are rejected, so that clients can't use up the cache memory
+	idempotencyKeyMaxLength = 255
+	// Larger responses aren't remembered
+	idempotentResponseMaxBytes = 64 * 1024
+)
+
+// idempotencyCache remembers the responses to requests with an idempotency key,
+// so that retries get the same response without the request being handled again.
+// The least recently used keys are dropped when it's full
+type idempotencyCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// Most recently used at the front
+	order *list.List
+}
+
+// idempotencyEntry is a request with an idempotency key. Done is closed once the
+// response is recorded, or the entry is removed because there's nothing to replay
+type idempotencyEntry struct {
+	key       string
+	bodyHash  [sha256.Size]byte
+	done      chan struct{}
+	response  *idempotentResponse
+	expiresAt time.Time
+}
+
+type idempotentResponse struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+func newIdempotencyCache(size int, ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// acquire returns the entry for the key and whether the caller should handle the
+// request, which is the case when there wasn't one. An entry for a different body
+// is returned with ok set to false
+func (i *idempotencyCache) acquire(
+	key string,
+	bodyHash [sha256.Size]byte,
+) (*idempotencyEntry, bool, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	now := time.Now()
+	if elem, ok := i.entries[key]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		if entry.response == nil || now.Before(entry.expiresAt) {
+			i.order.MoveToFront(elem)
+			return entry, false, entry.bodyHash == bodyHash
+		}
+		i.remove(elem)
+	}
+	entry := &idempotencyEntry{
+		key:      key,
+		bodyHash: bodyHash,
+		done:     make(chan struct{}),
+	}
+	i.entries[key] = i.order.PushFront(entry)
+	i.evict(now)
+	return entry, true, true
+}
+
+// complete records the response for an entry. A nil response removes the entry
+// instead, so that the next retry is handled again
+func (i *idempotencyCache) complete(
+	entry *idempotencyEntry,
+	response *idempotentResponse,
+) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if response != nil {
+		entry.response = response
+		entry.expiresAt = time.Now().Add(i.ttl)
+	} else if elem, ok := i.entries[entry.key]; ok &&
+		elem.Value.(*idempotencyEntry) == entry {
+		i.remove(elem)
+	}
+	close(entry.done)
+}
+
+// evict drops expired entries and then the least recently used ones over the
+// size. Requests that are still being handled are kept, since dropping them would
+// let a retry be handled at the same time
+func (i *idempotencyCache) evict(now time.Time) {
+	for elem := i.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		entry := elem.Value.(*idempotencyEntry)
+		if entry.response != nil &&
+			(i.order.Len() > i.size || !now.Before(entry.expiresAt)) {
+			i.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+func (i *idempotencyCache) remove(elem *list.Element) {
+	i.order.Remove(elem)
+	delete(i.entries, elem.Value.(*idempotencyEntry).key)
+}
+
+// idempotencyMiddleware replays the response to an earlier request with the same
+// Idempotency-Key header and body. Requests with the same key and a different body
+// are rejected. A retry that arrives while the first request is being handled
+// waits for its response. Server errors aren't remembered, so those requests can
+// be retried
+func idempotencyMiddleware(cache *idempotencyCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			respondIdempotencyError(
+				c,
+				http.StatusBadRequest,
+				errorCodeBadRequest,
+				"idempotency key is too long",
+			)
+			return
+		}
+		reqBody, err := io.ReadAll(c.Request.Body)
+		_ = c.Request.Body.Close()
+		if err != nil {
+			// Leave the read error for the handler to respond to
+			c.Request.Body = io.NopCloser(
+				io.MultiReader(bytes.NewReader(reqBody), errorReader{err}),
+			)
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		bodyHash := sha256.Sum256(reqBody)
+		for {
+			entry, handle, ok := cache.acquire(key, bodyHash)
+			if !ok {
+				respondIdempotencyError(
+					c,
+					http.StatusConflict,
+					errorCodeIdempotencyConflict,
+					"idempotency key was already used for a different request body",
+				)
+				return
+			}
+			if handle {
+				handleIdempotentRequest(c, cache, entry)
+				return
+			}
+			select {
+			case <-entry.done:
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+			if entry.response != nil {
+				replayIdempotentResponse(c, entry.response)
+				return
+			}
+			// The other request had nothing to remember, so try again
+		}
+	}
+}
+
+// handleIdempotentRequest runs the handler and records its response for retries
+func handleIdempotentRequest(
+	c *gin.Context,
+	cache *idempotencyCache,
+	entry *idempotencyEntry,
+) {
+	w := &bodyCaptureWriter{
+		ResponseWriter: c.Writer,
+		maxBytes:       idempotentResponseMaxBytes,
+	}
+	c.Writer = w
+	var response *idempotentResponse
+	defer func() {
+		c.Writer = w.ResponseWriter
+		cache.complete(entry, response)
+	}()
+	c.Next()
+	if w.Written() && w.Status() < http.StatusInternalServerError &&
+		w.size == len(w.body) {
+		response = &idempotentResponse{
+			status:      w.Status(),
+			contentType: w.Header().Get("Content-Type"),
+			body:        w.body,
+		}
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, response *idempotentResponse) {
+	c.Header(idempotentReplayedHeader, "true")
+	c.Data(response.status, response.contentType, response.body)
+	c.Abort()
+}
+
+func respondIdempotencyError(
+	c *gin.Context,
+	status int,
+	code string,
+	msg string,
+) {
+	c.Abort()
+	respondError(c, status, apiErrorCode(code, msg, nil))
+}
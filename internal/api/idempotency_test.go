@@ -0,0 +1,235 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type idempotencyTestRequest struct {
+	key  string
+	body string
+	// Time to wait before sending the request
+	delay time.Duration
+	// These are sent at once after the others, and can finish in any order
+	concurrent bool
+	wantStatus int
+	wantReplay bool
+	// Number of times the handler has run after the request
+	wantHandled int
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	testDefs := []struct {
+		name      string
+		cacheSize int
+		ttl       time.Duration
+		requests  []idempotencyTestRequest
+	}{
+		{
+			name: "replays same request",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantReplay: true, wantHandled: 1},
+			},
+		},
+		{
+			name: "rejects different body",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "a", body: "tx2", wantStatus: http.StatusConflict, wantHandled: 1},
+			},
+		},
+		{
+			name: "no key",
+			requests: []idempotencyTestRequest{
+				{body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 2},
+			},
+		},
+		{
+			name: "separate keys",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "b", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 2},
+			},
+		},
+		{
+			name: "replays client error",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "bad", wantStatus: http.StatusBadRequest, wantHandled: 1},
+				{key: "a", body: "bad", wantStatus: http.StatusBadRequest, wantReplay: true, wantHandled: 1},
+			},
+		},
+		{
+			name: "doesn't remember server error",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "fail", wantStatus: http.StatusInternalServerError, wantHandled: 1},
+				{key: "a", body: "fail", wantStatus: http.StatusInternalServerError, wantHandled: 2},
+			},
+		},
+		{
+			name: "key too long",
+			requests: []idempotencyTestRequest{
+				{key: strings.Repeat("a", idempotencyKeyMaxLength+1), body: "tx1", wantStatus: http.StatusBadRequest},
+			},
+		},
+		{
+			name: "expired",
+			ttl:  50 * time.Millisecond,
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "a", body: "tx2", delay: 100 * time.Millisecond, wantStatus: http.StatusAccepted, wantHandled: 2},
+			},
+		},
+		{
+			name:      "evicts least recently used",
+			cacheSize: 2,
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "b", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 2},
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantReplay: true, wantHandled: 2},
+				{key: "c", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 3},
+				{key: "a", body: "tx1", wantStatus: http.StatusAccepted, wantReplay: true, wantHandled: 3},
+				{key: "b", body: "tx1", wantStatus: http.StatusAccepted, wantHandled: 4},
+			},
+		},
+		{
+			name: "concurrent retry waits for response",
+			requests: []idempotencyTestRequest{
+				{key: "a", body: "slow", concurrent: true, wantStatus: http.StatusAccepted, wantHandled: 1},
+				{key: "a", body: "slow", concurrent: true, wantStatus: http.StatusAccepted, wantHandled: 1},
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if testDef.cacheSize == 0 {
+				testDef.cacheSize = 100
+			}
+			if testDef.ttl == 0 {
+				testDef.ttl = time.Hour
+			}
+			var handledMutex sync.Mutex
+			var handled int
+			router := gin.New()
+			router.Use(
+				idempotencyMiddleware(
+					newIdempotencyCache(testDef.cacheSize, testDef.ttl),
+				),
+			)
+			router.POST("/tx", func(c *gin.Context) {
+				handledMutex.Lock()
+				handled++
+				count := handled
+				handledMutex.Unlock()
+				body, _ := io.ReadAll(c.Request.Body)
+				switch string(body) {
+				case "fail":
+					c.String(http.StatusInternalServerError, "failed")
+				case "bad":
+					c.String(http.StatusBadRequest, "bad")
+				case "slow":
+					time.Sleep(100 * time.Millisecond)
+					fallthrough
+				default:
+					c.String(http.StatusAccepted, strconv.Itoa(count))
+				}
+			})
+			send := func(req idempotencyTestRequest) *httptest.ResponseRecorder {
+				time.Sleep(req.delay)
+				w := httptest.NewRecorder()
+				httpReq := httptest.NewRequest(
+					http.MethodPost,
+					"/tx",
+					strings.NewReader(req.body),
+				)
+				if req.key != "" {
+					httpReq.Header.Set(idempotencyKeyHeader, req.key)
+				}
+				router.ServeHTTP(w, httpReq)
+				return w
+			}
+			// Replays have the body of the response they replay
+			bodies := make(map[string]string)
+			check := func(req idempotencyTestRequest, w *httptest.ResponseRecorder) {
+				if w.Code != req.wantStatus {
+					t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+				}
+				replayed := w.Header().Get(idempotentReplayedHeader) == "true"
+				if !req.concurrent && replayed != req.wantReplay {
+					t.Fatalf("unexpected replayed: %v", replayed)
+				}
+				if req.key == "" || w.Code == http.StatusConflict {
+					return
+				}
+				if body, ok := bodies[req.key]; ok && replayed &&
+					body != w.Body.String() {
+					t.Fatalf("replayed body %q, want %q", w.Body.String(), body)
+				}
+				bodies[req.key] = w.Body.String()
+			}
+			var concurrent []idempotencyTestRequest
+			for _, req := range testDef.requests {
+				if req.concurrent {
+					concurrent = append(concurrent, req)
+					continue
+				}
+				check(req, send(req))
+				handledMutex.Lock()
+				count := handled
+				handledMutex.Unlock()
+				if count != req.wantHandled {
+					t.Fatalf("request handled %d times, want %d", count, req.wantHandled)
+				}
+			}
+			if len(concurrent) == 0 {
+				return
+			}
+			responses := make([]*httptest.ResponseRecorder, len(concurrent))
+			var wg sync.WaitGroup
+			for idx, req := range concurrent {
+				wg.Add(1)
+				go func(idx int, req idempotencyTestRequest) {
+					defer wg.Done()
+					responses[idx] = send(req)
+				}(idx, req)
+			}
+			wg.Wait()
+			replays := 0
+			for idx, req := range concurrent {
+				check(req, responses[idx])
+				if responses[idx].Header().Get(idempotentReplayedHeader) == "true" {
+					replays++
+				}
+			}
+			if replays != len(concurrent)-1 {
+				t.Fatalf("got %d replays, want %d", replays, len(concurrent)-1)
+			}
+			if handled != concurrent[len(concurrent)-1].wantHandled {
+				t.Fatalf("request handled %d times", handled)
+			}
+		})
+	}
+}
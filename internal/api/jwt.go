@@ -0,0 +1,316 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+const (
+	// Minimum time between JWKS fetches triggered by an unknown key ID
+	jwksMinRefreshInterval = 1 * time.Minute
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+// jwtValidator validates JWT bearer tokens against the configured issuer and
+// audience using keys from the issuer's JWKS
+type jwtValidator struct {
+	jwks   *jwksCache
+	parser *jwt.Parser
+}
+
+func newJwtValidator(cfg config.JwtConfig) *jwtValidator {
+	return &jwtValidator{
+		jwks: &jwksCache{
+			issuer:          cfg.Issuer,
+			jwksUrl:         cfg.JwksUrl,
+			refreshInterval: time.Duration(cfg.RefreshInterval) * time.Second,
+			client:          &http.Client{Timeout: jwksFetchTimeout},
+		},
+		parser: jwt.NewParser(
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithValidMethods([]string{
+				"RS256", "RS384", "RS512",
+				"PS256", "PS384", "PS512",
+				"ES256", "ES384", "ES512",
+				"EdDSA",
+			}),
+		),
+	}
+}
+
+// Validate parses and validates a token, returning its claims
+func (v *jwtValidator) Validate(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(
+		tokenString,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return v.jwks.Key(kid)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// looksLikeJwt returns whether a token has the three-part form of a JWT
+func looksLikeJwt(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// jwksCache fetches and caches the public keys from a JWKS endpoint. Fetches
+// happen without holding the mutex, so a slow JWKS endpoint doesn't hold up
+// requests with cached keys, and only one fetch runs at a time
+type jwksCache struct {
+	issuer          string
+	refreshInterval time.Duration
+	client          *http.Client
+	mutex           sync.Mutex
+	jwksUrl         string
+	keys            map[string]interface{}
+	lastFetch       time.Time
+	lastAttempt     time.Time
+	// Closed when the fetch in progress completes, nil if there isn't one
+	refreshDone chan struct{}
+	refreshErr  error
+}
+
+// Key returns the public key with the specified key ID, refreshing the key set if
+// the key is unknown or the cached set has expired. A cached key is used while
+// another request refreshes the key set
+func (j *jwksCache) Key(kid string) (interface{}, error) {
+	j.mutex.Lock()
+	key, ok := j.keys[kid]
+	expired := time.Since(j.lastFetch) > j.refreshInterval
+	refreshing := j.refreshDone != nil
+	canRefresh := expired || refreshing ||
+		time.Since(j.lastAttempt) > jwksMinRefreshInterval
+	j.mutex.Unlock()
+	if ok && (!expired || refreshing) {
+		return key, nil
+	}
+	if canRefresh {
+		if err := j.refresh(); err != nil {
+			// Keep using the cached key if we can't refresh
+			if ok {
+				logging.GetLogger(logging.ComponentApi).Warnf(
+					"failed to refresh JWKS: %s",
+					err,
+				)
+				return key, nil
+			}
+			return nil, err
+		}
+		j.mutex.Lock()
+		key, ok = j.keys[kid]
+		j.mutex.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	return key, nil
+}
+
+// setRefreshInterval changes how long the fetched key set is cached for
+func (j *jwksCache) setRefreshInterval(refreshInterval time.Duration) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.refreshInterval = refreshInterval
+}
+
+// refresh fetches the key set, or waits for the fetch in progress and returns
+// its result. It must be called without holding the mutex
+func (j *jwksCache) refresh() error {
+	j.mutex.Lock()
+	if done := j.refreshDone; done != nil {
+		j.mutex.Unlock()
+		<-done
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+		return j.refreshErr
+	}
+	done := make(chan struct{})
+	j.refreshDone = done
+	j.lastAttempt = time.Now()
+	jwksUrl := j.jwksUrl
+	j.mutex.Unlock()
+	keys, jwksUrl, err := j.fetch(jwksUrl)
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if err == nil {
+		j.jwksUrl = jwksUrl
+		j.keys = keys
+		j.lastFetch = time.Now()
+	}
+	j.refreshErr = err
+	j.refreshDone = nil
+	close(done)
+	return err
+}
+
+// fetch returns the keys from the JWKS endpoint, discovering its URL from the
+// issuer first if it isn't known. The URL that was used is returned with the keys
+func (j *jwksCache) fetch(
+	jwksUrl string,
+) (map[string]interface{}, string, error) {
+	if jwksUrl == "" {
+		var err error
+		if jwksUrl, err = j.discoverJwksUrl(); err != nil {
+			return nil, "", err
+		}
+	}
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := j.getJson(jwksUrl, &keySet); err != nil {
+		return nil, "", fmt.Errorf("failed to fetch JWKS: %s", err)
+	}
+	keys := make(map[string]interface{}, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		// Skip keys that aren't meant for signatures
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logging.GetLogger(logging.ComponentApi).Warnf(
+				"skipping JWKS key %q: %s",
+				jwk.Kid,
+				err,
+			)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, jwksUrl, nil
+}
+
+// discoverJwksUrl fetches the JWKS URL from the issuer's OpenID configuration
+func (j *jwksCache) discoverJwksUrl() (string, error) {
+	var oidcConfig struct {
+		JwksUri string `json:"jwks_uri"`
+	}
+	discoveryUrl := strings.TrimSuffix(j.issuer, "/") +
+		"/.well-known/openid-configuration"
+	if err := j.getJson(discoveryUrl, &oidcConfig); err != nil {
+		return "", fmt.Errorf("failed to fetch OpenID configuration: %s", err)
+	}
+	if oidcConfig.JwksUri == "" {
+		return "", fmt.Errorf("OpenID configuration does not contain jwks_uri")
+	}
+	return oidcConfig.JwksUri, nil
+}
+
+func (j *jwksCache) getJson(url string, dest interface{}) error {
+	resp, err := j.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJwkInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJwkInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve: %s", k.Crv)
+		}
+		x, err := decodeJwkInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJwkInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size: %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeJwkInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+const (
+	testJwtIssuer   = "https://issuer.example.com"
+	testJwtAudience = "cardano-node-api"
+)
+
+type testJwks struct {
+	server  *httptest.Server
+	keys    map[string]ed25519.PrivateKey
+	fetches atomic.Int64
+	// Closed to let JWKS fetches complete, if set
+	release chan struct{}
+}
+
+func newTestJwks(t *testing.T, kids ...string) *testJwks {
+	t.Helper()
+	j := &testJwks{keys: map[string]ed25519.PrivateKey{}}
+	for _, kid := range kids {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %s", err)
+		}
+		j.keys[kid] = key
+	}
+	j.server = httptest.NewServer(http.HandlerFunc(j.serve))
+	t.Cleanup(j.server.Close)
+	return j
+}
+
+func (j *testJwks) serve(w http.ResponseWriter, r *http.Request) {
+	j.fetches.Add(1)
+	if j.release != nil {
+		<-j.release
+	}
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	for kid, key := range j.keys {
+		keySet.Keys = append(keySet.Keys, jsonWebKey{
+			Kty: "OKP",
+			Kid: kid,
+			Crv: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(
+				key.Public().(ed25519.PublicKey),
+			),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(keySet)
+}
+
+func (j *testJwks) validator() *jwtValidator {
+	return newJwtValidator(config.JwtConfig{
+		Issuer:          testJwtIssuer,
+		Audience:        testJwtAudience,
+		JwksUrl:         j.server.URL,
+		RefreshInterval: 3600,
+	})
+}
+
+func (j *testJwks) sign(
+	t *testing.T,
+	kid string,
+	key ed25519.PrivateKey,
+	claims jwt.MapClaims,
+) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	ret, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+	return ret
+}
+
+func TestJwtValidatorValidate(t *testing.T) {
+	jwks := newTestJwks(t, "key1")
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": testJwtIssuer,
+			"aud": testJwtAudience,
+			"sub": "user1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	testDefs := []struct {
+		name    string
+		kid     string
+		key     ed25519.PrivateKey
+		claims  func(jwt.MapClaims)
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			kid:  "key1",
+			key:  jwks.keys["key1"],
+		},
+		{
+			name:    "expired",
+			kid:     "key1",
+			key:     jwks.keys["key1"],
+			claims:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			wantErr: true,
+		},
+		{
+			name:    "no expiration",
+			kid:     "key1",
+			key:     jwks.keys["key1"],
+			claims:  func(c jwt.MapClaims) { delete(c, "exp") },
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			kid:     "key1",
+			key:     jwks.keys["key1"],
+			claims:  func(c jwt.MapClaims) { c["aud"] = "other" },
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			kid:     "key1",
+			key:     jwks.keys["key1"],
+			claims:  func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "unknown key ID",
+			kid:     "key2",
+			key:     jwks.keys["key1"],
+			wantErr: true,
+		},
+		{
+			name:    "bad signature",
+			kid:     "key1",
+			key:     otherKey,
+			wantErr: true,
+		},
+	}
+	validator := jwks.validator()
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			claims := validClaims()
+			if testDef.claims != nil {
+				testDef.claims(claims)
+			}
+			token := jwks.sign(t, testDef.kid, testDef.key, claims)
+			gotClaims, err := validator.Validate(token)
+			if testDef.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got claims %v", gotClaims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if gotClaims["sub"] != "user1" {
+				t.Fatalf("unexpected sub claim: %v", gotClaims["sub"])
+			}
+		})
+	}
+}
+
+func TestJwksCacheDiscovery(t *testing.T) {
+	jwks := newTestJwks(t, "key1")
+	issuer := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/.well-known/openid-configuration" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"jwks_uri": jwks.server.URL,
+			})
+		},
+	))
+	defer issuer.Close()
+	cache := &jwksCache{
+		issuer:          issuer.URL + "/",
+		refreshInterval: time.Hour,
+		client:          http.DefaultClient,
+	}
+	if _, err := cache.Key("key1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cache.jwksUrl != jwks.server.URL {
+		t.Fatalf("unexpected JWKS URL: %s", cache.jwksUrl)
+	}
+}
+
+func TestJwksCacheUnknownKeyRefresh(t *testing.T) {
+	jwks := newTestJwks(t, "key1")
+	cache := jwks.validator().jwks
+	if _, err := cache.Key("key1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Unknown key IDs don't refetch the key set more than once per interval
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Key("key2"); err == nil {
+			t.Fatalf("expected an error for an unknown key ID")
+		}
+	}
+	if fetches := jwks.fetches.Load(); fetches != 1 {
+		t.Fatalf("expected 1 JWKS fetch, got %d", fetches)
+	}
+}
+
+func TestJwksCacheSlowRefresh(t *testing.T) {
+	jwks := newTestJwks(t, "key1")
+	cache := jwks.validator().jwks
+	if _, err := cache.Key("key1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Expire the key set and hold up the next fetch
+	jwks.release = make(chan struct{})
+	cache.setRefreshInterval(0)
+	refreshErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Key("key1")
+		refreshErr <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for jwks.fetches.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the JWKS refresh to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The cached key is still used while the refresh is in progress
+	keyErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Key("key1")
+		keyErr <- err
+	}()
+	select {
+	case err := <-keyErr:
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("key lookup blocked on the JWKS refresh")
+	}
+	close(jwks.release)
+	if err := <-refreshErr; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fetches := jwks.fetches.Load(); fetches != 2 {
+		t.Fatalf("expected 2 JWKS fetches, got %d", fetches)
+	}
+}
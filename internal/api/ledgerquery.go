@@ -0,0 +1,341 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/bech32"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Shelley-based ledger query types that gouroboros doesn't have
+const (
+	queryTypeShelleyConstitution           = 23
+	queryTypeShelleyGovState               = 24
+	queryTypeShelleyDRepState              = 25
+	queryTypeShelleyDRepStakeDistr         = 26
+	queryTypeShelleyCommitteeMembersState  = 27
+	queryTypeShelleyFilteredVoteDelegatees = 28
+	queryTypeShelleyAccountState           = 29
+	queryTypeShelleySPOStakeDistr          = 30
+	queryTypeShelleyProposals              = 31
+)
+
+// Credential types in ledger queries
+const (
+	credentialTypeKeyHash    = 0
+	credentialTypeScriptHash = 1
+)
+
+// CIP-129 bech32 prefixes and header bytes for governance credentials. The low
+// bit of the header byte is set for a script hash
+const (
+	cip129PrefixDrep          = "drep"
+	cip129HeaderDrep          = 0x22
+	cip129PrefixCommitteeHot  = "cc_hot"
+	cip129HeaderCommitteeHot  = 0x02
+	cip129PrefixCommitteeCold = "cc_cold"
+	cip129HeaderCommitteeCold = 0x12
+)
+
+// ledgerCredential is a stake, DRep, or committee credential in ledger queries
+type ledgerCredential struct {
+	cbor.StructAsArray
+	Type uint
+	Hash ledger.Blake2b224
+}
+
+// strictMaybe is an optional value in ledger query results, which the ledger
+// encodes as an empty list or a list of the value. Some fields use null instead
+type strictMaybe[T any] struct {
+	Value *T
+}
+
+func (m *strictMaybe[T]) UnmarshalCBOR(data []byte) error {
+	// CBOR null
+	if len(data) == 1 && data[0] == 0xf6 {
+		m.Value = nil
+		return nil
+	}
+	var items []T
+	if _, err := cbor.Decode(data, &items); err != nil {
+		return err
+	}
+	switch len(items) {
+	case 0:
+		m.Value = nil
+	case 1:
+		m.Value = &items[0]
+	default:
+		return fmt.Errorf(
+			"expected an optional value with 0 or 1 items, got %d",
+			len(items),
+		)
+	}
+	return nil
+}
+
+// cip129Id returns the CIP-129 bech32 ID of a governance credential
+func cip129Id(prefix string, header byte, isScript bool, hash []byte) string {
+	if isScript {
+		header |= 0x01
+	}
+	// This only fails for a bad prefix
+	ret, _ := bech32.EncodeFromBase256(
+		prefix,
+		append([]byte{header}, hash...),
+	)
+	return ret
+}
+
+// parseCip129Id parses the CIP-129 bech32 ID of a governance credential, or hex.
+// Hex is the header byte and the hash, or just the hash for a key hash. A
+// bech32 ID without the header byte is also taken as a key hash, which is the
+// CIP-105 form
+func parseCip129Id(
+	id string,
+	prefix string,
+	header byte,
+) (ledgerCredential, error) {
+	invalidErr := fmt.Errorf("invalid %s ID: %s", prefix, id)
+	var data []byte
+	if strings.HasPrefix(id, prefix) {
+		tmpPrefix, tmpData, err := bech32.DecodeToBase256(id)
+		if err != nil || tmpPrefix != prefix {
+			return ledgerCredential{}, invalidErr
+		}
+		data = tmpData
+	} else {
+		tmpData, err := hex.DecodeString(id)
+		if err != nil {
+			return ledgerCredential{}, invalidErr
+		}
+		data = tmpData
+	}
+	hashSize := len(ledger.Blake2b224{})
+	if len(data) == hashSize {
+		data = append([]byte{header}, data...)
+	}
+	if len(data) != 1+hashSize || data[0]&^0x01 != header {
+		return ledgerCredential{}, invalidErr
+	}
+	return ledgerCredential{
+		Type: uint(data[0] & 0x01),
+		Hash: ledger.NewBlake2b224(data[1:]),
+	}, nil
+}
+
+// parseCip129Ids parses a list of governance credential IDs like parseCip129Id,
+// leaving out duplicates
+func parseCip129Ids(
+	ids []string,
+	prefix string,
+	header byte,
+) ([]ledgerCredential, error) {
+	ret := make([]ledgerCredential, 0, len(ids))
+	for _, id := range ids {
+		cred, err := parseCip129Id(id, prefix, header)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ret, cred) {
+			ret = append(ret, cred)
+		}
+	}
+	return ret, nil
+}
+
+// openLedgerQuery connects to the node for ledger queries and acquires a ledger
+// state. The caller must close it. An error response has been sent if it
+// returns nil
+func openLedgerQuery(
+	c *gin.Context,
+	target node.AcquireTarget,
+) *node.LedgerQuery {
+	ctx := c.Request.Context()
+	query, err := node.OpenLedgerQuery(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return nil
+	}
+	c.Header(nodeEndpointHeader, query.Endpoint())
+	if err := query.Acquire(ctx, target); err != nil {
+		query.Close()
+		respondAcquireError(c, err)
+		return nil
+	}
+	return query
+}
+
+// queryLedger sends a Shelley-based ledger query and decodes the result. An
+// error response has been sent if it returns false
+func queryLedger[T any](
+	c *gin.Context,
+	query *node.LedgerQuery,
+	op string,
+	queryParams ...any,
+) (T, bool) {
+	var ret T
+	result, err := query.QueryLedger(c.Request.Context(), op, queryParams...)
+	if err != nil {
+		respondLedgerQueryError(c, err)
+		return ret, false
+	}
+	if _, err := cbor.Decode(result, &ret); err != nil {
+		respondError(
+			c,
+			500,
+			apiErrorCode(
+				errorCodeInternal,
+				fmt.Sprintf("failed to decode %s result: %s", op, err),
+				nil,
+			),
+		)
+		return ret, false
+	}
+	return ret, true
+}
+
+// queryEra returns the era of the acquired ledger state. An error response has
+// been sent if it returns false
+func queryEra(c *gin.Context, query *node.LedgerQuery) (ledger.Era, bool) {
+	eraId, err := query.CurrentEra(c.Request.Context())
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, false
+	}
+	return ledger.GetEraById(uint8(eraId)), true
+}
+
+// requireConway checks that the acquired ledger state is in the Conway era or
+// later, since the governance queries don't exist in earlier eras. An error
+// response has been sent if it returns false
+func requireConway(c *gin.Context, query *node.LedgerQuery) bool {
+	era, ok := queryEra(c, query)
+	if !ok {
+		return false
+	}
+	if era.Id < ledger.EraIdConway {
+		respondError(
+			c,
+			http.StatusUnprocessableEntity,
+			apiErrorCode(
+				errorCodeUnsupportedEra,
+				fmt.Sprintf(
+					"governance queries need the Conway era, but the ledger state is in the %s era",
+					era.Name,
+				),
+				nil,
+			),
+		)
+		return false
+	}
+	return true
+}
+
+// queryEpochEnd returns the end of an epoch from the system start and era
+// history of the acquired ledger state, or nil if it isn't known, which is the
+// case right after a hard fork. An error response has been sent if it returns
+// false
+func queryEpochEnd(
+	c *gin.Context,
+	query *node.LedgerQuery,
+	epochNo uint64,
+) (*time.Time, bool) {
+	ctx := c.Request.Context()
+	result, err := query.Query(
+		ctx,
+		"query system-start",
+		[]any{localstatequery.QueryTypeSystemStart},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return nil, false
+	}
+	var systemStart localstatequery.SystemStartResult
+	if _, err := cbor.Decode(result, &systemStart); err != nil {
+		respondNodeError(
+			c,
+			fmt.Errorf("failed to decode system start: %s", err),
+		)
+		return nil, false
+	}
+	result, err = query.Query(
+		ctx,
+		"query era-history",
+		[]any{
+			localstatequery.QueryTypeBlock,
+			[]any{
+				localstatequery.QueryTypeHardFork,
+				[]any{localstatequery.QueryTypeHardForkEraHistory},
+			},
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return nil, false
+	}
+	var eraHistory []localstatequery.EraHistoryResult
+	if _, err := cbor.Decode(result, &eraHistory); err != nil {
+		respondNodeError(
+			c,
+			fmt.Errorf("failed to decode era history: %s", err),
+		)
+		return nil, false
+	}
+	bounds, err := node.GetEpochBounds(
+		node.SystemStartTime(&systemStart),
+		eraHistory,
+		epochNo,
+	)
+	if err != nil {
+		return nil, true
+	}
+	return bounds.EndTime, true
+}
+
+// respondLedgerQueryError sends an error response for a failed ledger query. A
+// query for a different era than the ledger state is reported as unprocessable
+func respondLedgerQueryError(c *gin.Context, err error) {
+	var mismatchErr *node.EraMismatchError
+	if errors.As(err, &mismatchErr) {
+		respondError(
+			c,
+			http.StatusUnprocessableEntity,
+			apiErrorCode(
+				errorCodeUnsupportedEra,
+				err.Error(),
+				gin.H{
+					"query_era":  mismatchErr.QueryEra,
+					"ledger_era": mismatchErr.LedgerEra,
+				},
+			),
+		)
+		return
+	}
+	respondNodeError(c, err)
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// listenerConfig describes where a server should listen. A port of 0 disables the
+// TCP listener and an empty socket path disables the UNIX socket listener. Sockets
+// passed in by systemd with a matching name are used instead, if present
+type listenerConfig struct {
+	name        string
+	systemdName string
+	address     string
+	port        uint
+	socketPath  string
+	socketMode  string
+	socketOwner string
+}
+
+// openListeners binds the TCP and UNIX socket listeners for a server
+func openListeners(listenerCfg listenerConfig) ([]net.Listener, error) {
+	logger := logging.GetLogger(logging.ComponentApi)
+	activated, err := systemdListeners()
+	if err != nil {
+		return nil, err
+	}
+	if listeners := activated[listenerCfg.systemdName]; len(listeners) > 0 {
+		for _, listener := range listeners {
+			logger.Infof("using systemd socket %s for %s listener",
+				listener.Addr(),
+				listenerCfg.name)
+		}
+		return listeners, nil
+	}
+	var listeners []net.Listener
+	if listenerCfg.port > 0 {
+		logger.Infof("starting %s listener on %s:%d",
+			listenerCfg.name,
+			listenerCfg.address,
+			listenerCfg.port)
+		listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d",
+			listenerCfg.address,
+			listenerCfg.port))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to start %s listener: %s",
+				listenerCfg.name,
+				err,
+			)
+		}
+		listeners = append(listeners, listener)
+	}
+	if listenerCfg.socketPath != "" {
+		logger.Infof("starting %s listener on UNIX socket %s",
+			listenerCfg.name,
+			listenerCfg.socketPath)
+		listener, err := listenUnix(
+			listenerCfg.socketPath,
+			listenerCfg.socketMode,
+			listenerCfg.socketOwner,
+		)
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf(
+				"failed to start %s listener: %s",
+				listenerCfg.name,
+				err,
+			)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// listenUnix listens on a UNIX socket, removing a stale socket file left behind by
+// a previous process, and applies the configured permissions and ownership
+func listenUnix(path string, mode string, owner string) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		fileMode, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("invalid socket mode %q: %s", mode, err)
+		}
+		if err := os.Chmod(path, fs.FileMode(fileMode)); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to set socket mode: %s", err)
+		}
+	}
+	if owner != "" {
+		uid, gid, err := lookupOwner(owner)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		if err := os.Chown(path, uid, gid); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to set socket ownership: %s", err)
+		}
+	}
+	return listener, nil
+}
+
+// removeStaleSocket removes an existing socket file if nothing is listening on it
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	conn, err := net.DialTimeout("unix", path, 1*time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is already in use", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check existing socket %s: %s", path, err)
+	}
+	logging.GetLogger(logging.ComponentApi).
+		Infof("removing stale socket %s", path)
+	return os.Remove(path)
+}
+
+// lookupOwner resolves an owner of the form USER, USER:GROUP, or :GROUP, where the
+// user and group may be names or numeric IDs. Unspecified values are returned as
+// -1, which leaves them unchanged
+func lookupOwner(owner string) (int, int, error) {
+	userName, groupName, _ := strings.Cut(owner, ":")
+	uid, gid := -1, -1
+	if userName != "" {
+		id := userName
+		if _, err := strconv.Atoi(userName); err != nil {
+			u, err := user.Lookup(userName)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to look up socket owner: %s", err)
+			}
+			id = u.Uid
+		}
+		uid, _ = strconv.Atoi(id)
+	}
+	if groupName != "" {
+		id := groupName
+		if _, err := strconv.Atoi(groupName); err != nil {
+			g, err := user.LookupGroup(groupName)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to look up socket group: %s", err)
+			}
+			id = g.Gid
+		}
+		gid, _ = strconv.Atoi(id)
+	}
+	return uid, gid, nil
+}
@@ -15,24 +15,66 @@
 package api
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
 	"github.com/gin-gonic/gin"
 
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
 	"github.com/blinklabs-io/cardano-node-api/internal/node"
 )
 
-func configureLocalStateQueryRoutes(apiGroup *gin.RouterGroup) {
+func configureLocalStateQueryRoutes(apiGroup *gin.RouterGroup, version int) {
 	group := apiGroup.Group("/localstatequery")
 	group.GET("/current-era", handleLocalStateQueryCurrentEra)
+	group.GET("/era", handleLocalStateQueryEra)
 	group.GET("/system-start", handleLocalStateQuerySystemStart)
 	group.GET("/tip", handleLocalStateQueryTip)
+	group.GET("/block-height", handleLocalStateQueryBlockHeight)
+	group.GET("/epoch", handleLocalStateQueryEpoch)
 	group.GET("/era-history", handleLocalStateQueryEraHistory)
 	group.GET("/protocol-params", handleLocalStateQueryProtocolParams)
-	// TODO: uncomment after this is fixed:
-	// - https://github.com/blinklabs-io/gouroboros/issues/584
-	// group.GET("/genesis-config", handleLocalStateQueryGenesisConfig)
+	group.GET("/protocol-parameters", handleLocalStateQueryProtocolParameters)
+	group.GET(
+		"/protocol-parameters/cli",
+		handleLocalStateQueryProtocolParametersCli,
+	)
+	group.GET("/cost-models", handleLocalStateQueryCostModels)
+	group.GET("/utxos", handleLocalStateQueryUtxos)
+	group.GET("/utxo/:tx_hash/:index", handleLocalStateQueryUtxo)
+	group.POST("/utxo", handleLocalStateQueryUtxoBatch)
+	group.GET("/stake-distribution", handleLocalStateQueryStakeDistribution)
+	group.GET("/pools", handleLocalStateQueryPools)
+	group.GET("/pools/:pool_id", handleLocalStateQueryPoolParams)
+	group.GET("/genesis", handleLocalStateQueryGenesis)
+	group.GET("/stake/:stake_address", handleLocalStateQueryStakeAddress)
+	group.POST("/stake/accounts", handleLocalStateQueryStakeAccounts)
+	group.GET("/pools/:pool_id/snapshots", handleLocalStateQueryStakeSnapshots)
+	group.GET("/governance/constitution", handleLocalStateQueryConstitution)
+	group.GET("/governance/state", handleLocalStateQueryGovState)
+	group.GET("/governance/dreps", handleLocalStateQueryDreps)
+	group.GET("/governance/drep-stake", handleLocalStateQueryDrepStake)
+	group.GET("/governance/committee", handleLocalStateQueryCommittee)
+	group.GET("/governance/spo-stake", handleLocalStateQuerySpoStake)
+	group.GET("/governance/proposals", handleLocalStateQueryProposals)
+	if rawQuery := config.GetConfig().Api.RawQuery; rawQuery.Enabled {
+		group.POST(
+			"/raw",
+			maxBodyBytesMiddleware(int64(rawQuery.MaxBytes)),
+			handleLocalStateQueryRaw,
+		)
+	}
 }
 
 type responseLocalStateQueryCurrentEra struct {
@@ -49,220 +91,634 @@ type responseLocalStateQueryCurrentEra struct {
 //	@Failure	500	{object}	responseApiError
 //	@Router		/localstatequery/current-era [get]
 func handleLocalStateQueryCurrentEra(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondNodeError(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
 
 	// Get era
-	eraNum, err := oConn.LocalStateQuery().Client.GetCurrentEra()
+	eraNum, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query current-era",
+		oConn.LocalStateQuery().Client.GetCurrentEra,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 
+	_ = oConn.ReleaseLocalState(ctx)
+
 	// Create response
 	era := ledger.GetEraById(uint8(eraNum))
 	resp := responseLocalStateQueryCurrentEra{
 		Id:   era.Id,
 		Name: era.Name,
 	}
-	c.JSON(200, resp)
+	respondJson(c, 200, resp)
+}
+
+type responseLocalStateQueryEra struct {
+	Id        uint8     `json:"id"                   example:"6"`
+	Name      string    `json:"name"                 example:"conway"`
+	StartSlot *uint64   `json:"start_slot,omitempty" example:"133660800"`
+	FetchedAt time.Time `json:"fetched_at"           example:"2024-10-08T06:36:42Z"`
+}
+
+// handleLocalStateQueryEra godoc
+//
+//	@Summary		Query Era
+//	@Description	Returns the current era and the first slot of the era. Both come from the same ledger state, so they're consistent across a hard fork.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryEra
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/era [get]
+func handleLocalStateQueryEra(c *gin.Context) {
+	ctx := c.Request.Context()
+	// The gouroboros client caches the era as it acquires a ledger state, which
+	// races with reading it, so this uses its own ledger query connection
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	fetchedAt := time.Now().UTC()
+
+	// Get era
+	era, ok := queryEra(c, query)
+	if !ok {
+		return
+	}
+
+	// Get era history for the start of the era
+	result, err := query.Query(
+		ctx,
+		"query era-history",
+		[]any{
+			localstatequery.QueryTypeBlock,
+			[]any{
+				localstatequery.QueryTypeHardFork,
+				[]any{localstatequery.QueryTypeHardForkEraHistory},
+			},
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	var eraHistory []localstatequery.EraHistoryResult
+	if _, err := cbor.Decode(result, &eraHistory); err != nil {
+		respondNodeError(
+			c,
+			fmt.Errorf("failed to decode era history: %s", err),
+		)
+		return
+	}
+
+	// Create response
+	resp := responseLocalStateQueryEra{
+		Id:        era.Id,
+		Name:      strings.ToLower(era.Name),
+		FetchedAt: fetchedAt,
+	}
+	// The era history has an entry for each era, in order
+	if int(era.Id) < len(eraHistory) {
+		startSlot := uint64(eraHistory[era.Id].Begin.SlotNo)
+		resp.StartSlot = &startSlot
+	}
+	respondJson(c, 200, resp)
 }
 
 type responseLocalStateQuerySystemStart struct {
-	Year        int    `json:"year"`
-	Day         int    `json:"day"`
-	Picoseconds uint64 `json:"picoseconds"`
+	Time        time.Time `json:"time"        example:"2017-09-23T21:44:51Z"`
+	Year        int       `json:"year"        example:"2017"`
+	Day         int       `json:"day"         example:"266"`
+	Picoseconds uint64    `json:"picoseconds" example:"78291000000000000"`
 }
 
 // handleLocalStateQuerySystemStart godoc
 //
-//	@Summary	Query System Start
-//	@Tags		localstatequery
-//	@Produce	json
-//	@Success	200	{object}	responseLocalStateQuerySystemStart
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localstatequery/system-start [get]
+//	@Summary		Query System Start
+//	@Description	Returns the network's system start time, along with the year, day of the year, and picoseconds within the day that the node sends.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQuerySystemStart
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/system-start [get]
 func handleLocalStateQuerySystemStart(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
 
 	// Get system start
-	result, err := oConn.LocalStateQuery().Client.GetSystemStart()
+	result, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 
+	_ = oConn.ReleaseLocalState(ctx)
+
 	// Create response
 	resp := responseLocalStateQuerySystemStart{
+		Time:        node.SystemStartTime(result).UTC(),
 		Year:        result.Year,
 		Day:         result.Day,
 		Picoseconds: result.Picoseconds,
 	}
-	c.JSON(200, resp)
+	respondJson(c, 200, resp)
 }
 
 type responseLocalStateQueryTip struct {
-	Era     string `json:"era"`
-	EpochNo int    `json:"epoch_no"`
-	BlockNo int64  `json:"block_no"`
-	Slot    uint64 `json:"slot_no"`
-	Hash    string `json:"hash"`
+	Era      string    `json:"era"       example:"Conway"`
+	EpochNo  int       `json:"epoch_no"  example:"507"`
+	BlockNo  int64     `json:"block_no"  example:"10817965"`
+	Slot     uint64    `json:"slot_no"   example:"133427511"`
+	Hash     string    `json:"hash"      example:"4e7e3a23e3da6bb6e1a8f20fc3e335f513dcd1b1a6e68a481c4e970b23d25db1"`
+	SlotTime time.Time `json:"slot_time" example:"2024-10-08T06:36:42Z"`
 }
 
 // handleLocalStateQueryTip godoc
 //
-//	@Summary	Query Chain Tip
-//	@Tags		localstatequery
-//	@Produce	json
-//	@Success	200	{object}	responseLocalStateQueryTip
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localstatequery/tip [get]
+//	@Summary		Query Chain Tip
+//	@Description	Returns the tip of the volatile ledger state, with the wall-clock time of the tip slot.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryTip
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/tip [get]
 func handleLocalStateQueryTip(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
 
 	// Get era
-	eraNum, err := oConn.LocalStateQuery().Client.GetCurrentEra()
+	eraNum, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query current-era",
+		oConn.LocalStateQuery().Client.GetCurrentEra,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 	era := ledger.GetEraById(uint8(eraNum))
 
 	// Get epochNo
-	epochNo, err := oConn.LocalStateQuery().Client.GetEpochNo()
+	epochNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query epoch-no",
+		oConn.LocalStateQuery().Client.GetEpochNo,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 
 	// Get blockNo
-	blockNo, err := oConn.LocalStateQuery().Client.GetChainBlockNo()
+	blockNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-block-no",
+		oConn.LocalStateQuery().Client.GetChainBlockNo,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 
 	// Get chain point (slot and hash)
-	point, err := oConn.LocalStateQuery().Client.GetChainPoint()
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
+		return
+	}
+
+	// Get the system start and era history to convert the slot to a time
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	slotTime, err := node.SlotTime(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+		point.Slot,
+	)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
 		return
 	}
 
 	// Create response
 	resp := responseLocalStateQueryTip{
-		Era:     era.Name,
-		EpochNo: epochNo,
+		Era:      era.Name,
+		EpochNo:  epochNo,
+		BlockNo:  blockNo,
+		Slot:     point.Slot,
+		Hash:     hex.EncodeToString(point.Hash),
+		SlotTime: slotTime.UTC(),
+	}
+	respondJson(c, 200, resp)
+}
+
+type responseLocalStateQueryBlockHeight struct {
+	BlockNo int64  `json:"block_no" example:"10817965"`
+	Slot    uint64 `json:"slot_no"  example:"133427511"`
+}
+
+// handleLocalStateQueryBlockHeight godoc
+//
+//	@Summary		Query Block Height
+//	@Description	Returns the block number and slot of the chain tip, for cheap polling. The response has an ETag, and a 304 is returned if it matches If-None-Match.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			If-None-Match	header		string	false	"ETag of a previous response"
+//	@Success		200				{object}	responseLocalStateQueryBlockHeight
+//	@Success		304
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/block-height [get]
+func handleLocalStateQueryBlockHeight(c *gin.Context) {
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get blockNo
+	blockNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-block-no",
+		oConn.LocalStateQuery().Client.GetChainBlockNo,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	// Get chain point (slot and hash)
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// The hash is included since a rollback can replace the tip with another
+	// block at the same height and slot
+	etag := fmt.Sprintf("%d-%d-%x", blockNo, point.Slot, point.Hash)
+	if respondNotModified(c, etag) {
+		return
+	}
+
+	// Create response
+	resp := responseLocalStateQueryBlockHeight{
 		BlockNo: blockNo,
 		Slot:    point.Slot,
-		Hash:    hex.EncodeToString(point.Hash),
 	}
-	c.JSON(200, resp)
+	respondJson(c, 200, resp)
 }
 
-// TODO: fill this in
+type responseLocalStateQueryEpoch struct {
+	EpochNo   int        `json:"epoch_no"   example:"507"`
+	FirstSlot uint64     `json:"first_slot" example:"133660800"`
+	LastSlot  *uint64    `json:"last_slot"  example:"134092799"`
+	StartTime time.Time  `json:"start_time" example:"2024-09-01T21:44:51Z"`
+	EndTime   *time.Time `json:"end_time"   example:"2024-09-06T21:44:51Z"`
+}
+
+// handleLocalStateQueryEpoch godoc
 //
-//nolint:unused
+//	@Summary		Query Current Epoch
+//	@Description	Returns the current epoch number with the first and last slots of the epoch and the times that it starts and ends. The last slot and end time are null when the era history doesn't cover the end of the epoch yet, which can happen right after a hard fork.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryEpoch
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/epoch [get]
+func handleLocalStateQueryEpoch(c *gin.Context) {
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get epochNo
+	epochNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query epoch-no",
+		oConn.LocalStateQuery().Client.GetEpochNo,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	// Get the system start and era history to find the epoch boundaries
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	bounds, err := node.GetEpochBounds(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+		uint64(epochNo),
+	)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+
+	// Create response
+	resp := responseLocalStateQueryEpoch{
+		EpochNo:   epochNo,
+		FirstSlot: bounds.FirstSlot,
+		LastSlot:  bounds.LastSlot,
+		StartTime: bounds.StartTime.UTC(),
+	}
+	if bounds.EndTime != nil {
+		endTime := bounds.EndTime.UTC()
+		resp.EndTime = &endTime
+	}
+	respondJson(c, 200, resp)
+}
+
 type responseLocalStateQueryEraHistory struct {
+	SystemStart time.Time                              `json:"system_start" example:"2017-09-23T21:44:51Z"`
+	Eras        []responseLocalStateQueryEraHistoryEra `json:"eras"`
+}
+
+type responseLocalStateQueryEraHistoryEra struct {
+	Era        string                                  `json:"era"        example:"shelley"`
+	Start      responseLocalStateQueryEraHistoryBound  `json:"start"`
+	End        *responseLocalStateQueryEraHistoryBound `json:"end"`
+	Parameters responseLocalStateQueryEraHistoryParams `json:"parameters"`
+}
+
+type responseLocalStateQueryEraHistoryBound struct {
+	Slot    uint64    `json:"slot"     example:"4492800"`
+	EpochNo uint64    `json:"epoch_no" example:"208"`
+	Time    time.Time `json:"time"     example:"2020-07-29T21:44:51Z"`
+}
+
+type responseLocalStateQueryEraHistoryParams struct {
+	SlotLength  uint64 `json:"slot_length_milliseconds" example:"1000"`
+	EpochLength uint64 `json:"epoch_length"             example:"432000"`
+	SafeZone    uint64 `json:"safe_zone"                example:"129600"`
 }
 
 // handleLocalStateQueryEraHistory godoc
 //
-//	@Summary	Query Era History
-//	@Tags		localstatequery
-//	@Produce	json
-//	@Success	200	{object}	responseLocalStateQueryEraHistory
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localstatequery/era-history [get]
+//	@Summary		Query Era History
+//	@Description	Returns the bounds and parameters of each era along with the system start, which are needed to convert between slots and times. The end of the last era is null when it's unbounded.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryEraHistory
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/era-history [get]
 func handleLocalStateQueryEraHistory(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get the system start for the era bound times
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
 
 	// Get eraHistory
-	eraHistory, err := oConn.LocalStateQuery().Client.GetEraHistory()
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
 
+	_ = oConn.ReleaseLocalState(ctx)
+
 	// Create response
-	//resp := responseLocalStateQueryProtocolParams{
-	//}
-	c.JSON(200, eraHistory)
+	resp, err := newResponseEraHistory(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+	)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondJson(c, 200, resp)
+}
+
+func newResponseEraHistory(
+	systemStart time.Time,
+	eraHistory []localstatequery.EraHistoryResult,
+) (responseLocalStateQueryEraHistory, error) {
+	resp := responseLocalStateQueryEraHistory{
+		SystemStart: systemStart.UTC(),
+		Eras: make(
+			[]responseLocalStateQueryEraHistoryEra,
+			0,
+			len(eraHistory),
+		),
+	}
+	// The era history has an entry for each era, in order
+	for idx, era := range eraHistory {
+		startTime, err := node.EraBoundTime(systemStart, era.Begin.Timespan)
+		if err != nil {
+			return resp, err
+		}
+		tmpEra := responseLocalStateQueryEraHistoryEra{
+			Era: strings.ToLower(ledger.GetEraById(uint8(idx)).Name),
+			Start: responseLocalStateQueryEraHistoryBound{
+				Slot:    uint64(era.Begin.SlotNo),
+				EpochNo: uint64(era.Begin.EpochNo),
+				Time:    startTime.UTC(),
+			},
+			Parameters: responseLocalStateQueryEraHistoryParams{
+				SlotLength:  uint64(era.Params.SlotLength),
+				EpochLength: uint64(era.Params.EpochLength),
+				// gouroboros decodes the safe zone as SlotsPerKESPeriod
+				SafeZone: uint64(era.Params.SlotsPerKESPeriod.Value),
+			},
+		}
+		// An unbounded era has no end
+		if era.End.Timespan != nil {
+			endTime, err := node.EraBoundTime(systemStart, era.End.Timespan)
+			if err != nil {
+				return resp, err
+			}
+			tmpEra.End = &responseLocalStateQueryEraHistoryBound{
+				Slot:    uint64(era.End.SlotNo),
+				EpochNo: uint64(era.End.EpochNo),
+				Time:    endTime.UTC(),
+			}
+		}
+		resp.Eras = append(resp.Eras, tmpEra)
+	}
+	return resp, nil
 }
 
 // TODO: fill this in
@@ -275,92 +731,1945 @@ type responseLocalStateQueryProtocolParams struct {
 //
 //	@Summary	Query Current Protocol Parameters
 //	@Tags		localstatequery
-//	@Produce	json
-//	@Success	200	{object}	responseLocalStateQueryProtocolParams
-//	@Failure	500	{object}	responseApiError
+//	@Produce	json,application/cbor,plain
+//	@Param		format	query		string	false	"response format, which overrides the Accept header"	Enums(json, cbor, hex)
+//	@Success	200		{object}	responseLocalStateQueryProtocolParams
+//	@Failure	500		{object}	responseApiError
 //	@Router		/localstatequery/protocol-params [get]
 func handleLocalStateQueryProtocolParams(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondNodeError(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
 
 	// Get protoParams
-	protoParams, err := oConn.LocalStateQuery().Client.GetCurrentProtocolParams()
+	protoParams, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query protocol-params",
+		oConn.LocalStateQuery().Client.GetCurrentProtocolParams,
+	)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// Send CBOR if requested. The node response has already been decoded, so
+	// this is re-encoded from the decoded protocol params
+	if responseFormat(c) != responseFormatJson {
+		cborData, err := cbor.Encode(protoParams)
+		if err != nil {
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
+			return
+		}
+		respondCbor(c, 200, cborData)
 		return
 	}
 
 	// Create response
 	//resp := responseLocalStateQueryProtocolParams{
 	//}
-	c.JSON(200, protoParams)
+	respondJson(c, 200, protoParams)
 }
 
-// TODO: fill this in
+// handleLocalStateQueryProtocolParameters godoc
 //
-//nolint:unused
-type responseLocalStateQueryGenesisConfig struct {
+//	@Summary		Query Current Protocol Parameters With Stable Field Names
+//	@Description	Returns the protocol parameters for the current era using the same field names for every era. Fields that don't apply to the current era are null. The cbor and hex formats return the parameters as CBOR.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Produce		application/cbor
+//	@Produce		text/plain
+//	@Param			format	query		string	false	"response format, which overrides the Accept header"	Enums(json, cbor, hex)
+//	@Success		200		{object}	responseProtocolParameters
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/protocol-parameters [get]
+func handleLocalStateQueryProtocolParameters(c *gin.Context) {
+	era, protoParams, ok := queryProtocolParameters(c)
+	if !ok {
+		return
+	}
+
+	// Send CBOR if requested. The node response has already been decoded, so
+	// this is re-encoded from the decoded protocol params
+	if responseFormat(c) != responseFormatJson {
+		cborData, err := cbor.Encode(protoParams)
+		if err != nil {
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
+			return
+		}
+		respondCbor(c, 200, cborData)
+		return
+	}
+
+	// Create response
+	resp, err := newResponseProtocolParameters(era, protoParams)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondJson(c, 200, resp)
 }
 
-// handleLocalStateQueryGenesisConfig godoc
+// handleLocalStateQueryProtocolParametersCli godoc
 //
-//	@Summary	Query Genesis Config
-//	@Tags		localstatequery
-//	@Produce	json
-//	@Success	200	{object}	responseLocalStateQueryGenesisConfig
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localstatequery/genesis-config [get]
+//	@Summary		Query Current Protocol Parameters In cardano-cli Format
+//	@Description	Returns the protocol parameters for the current era with the same field names and nesting as the output of cardano-cli query protocol-parameters.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	map[string]any
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/protocol-parameters/cli [get]
+func handleLocalStateQueryProtocolParametersCli(c *gin.Context) {
+	era, protoParams, ok := queryProtocolParameters(c)
+	if !ok {
+		return
+	}
+	params, err := newResponseProtocolParameters(era, protoParams)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondJson(c, 200, cliProtocolParameters(era, params))
+}
+
+// handleLocalStateQueryCostModels godoc
 //
-//nolint:unused
-func handleLocalStateQueryGenesisConfig(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+//	@Summary		Query Cost Models
+//	@Description	Returns the Plutus cost models by language version, along with the execution unit prices and limits. Each cost model has the parameters in ledger order, and by name where the names are known for the language version.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryCostModels
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/cost-models [get]
+func handleLocalStateQueryCostModels(c *gin.Context) {
+	era, protoParams, ok := queryProtocolParameters(c)
+	if !ok {
+		return
+	}
+	params, err := newResponseProtocolParameters(era, protoParams)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
+	respondJson(c, 200, newResponseCostModels(params))
+}
+
+// queryProtocolParameters queries the current era and protocol parameters, which
+// are cached until the end of the epoch. An error response has been sent if it
+// returns false
+func queryProtocolParameters(
+	c *gin.Context,
+) (ledger.Era, localstatequery.CurrentProtocolParamsResult, bool) {
+	if era, protoParams, ok := protocolParams.get(); ok {
+		return era, protoParams, true
+	}
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return ledger.Era{}, nil, false
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return ledger.Era{}, nil, false
+	}
+
+	// Get era
+	eraNum, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query current-era",
+		oConn.LocalStateQuery().Client.GetCurrentEra,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, nil, false
+	}
+
+	// Get protoParams
+	protoParams, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query protocol-params",
+		oConn.LocalStateQuery().Client.GetCurrentProtocolParams,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, nil, false
+	}
+
+	// Get the epoch, system start, and era history to find the end of the
+	// epoch
+	epochNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query epoch-no",
+		oConn.LocalStateQuery().Client.GetEpochNo,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, nil, false
+	}
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, nil, false
+	}
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return ledger.Era{}, nil, false
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	era := ledger.GetEraById(uint8(eraNum))
+	// The end of the epoch isn't known right after a hard fork, so the result
+	// isn't cached then
+	bounds, err := node.GetEpochBounds(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+		uint64(epochNo),
+	)
+	if err == nil && bounds.EndTime != nil {
+		protocolParams.set(era, protoParams, *bounds.EndTime)
+	}
+	return era, protoParams, true
+}
+
+type requestLocalStateQueryUtxos struct {
+	Addresses   []string `form:"address"`
+	Limit       uint     `form:"limit"`
+	Cursor      string   `form:"cursor"`
+	PolicyId    string   `form:"policy_id"`
+	Asset       string   `form:"asset"`
+	MinLovelace uint64   `form:"min_lovelace"`
+}
+
+// The UTxOs are left out when encoding this, since they're streamed after the
+// other fields
+type responseLocalStateQueryUtxos struct {
+	Total      int            `json:"total"           example:"1"`
+	NextCursor *string        `json:"next_cursor"     example:"mlt7SjqJ4KHw_B0dodvVjjiUx6FOosvMic1uOk-fH18AAAAA"`
+	Utxos      []responseUtxo `json:"utxos,omitempty"`
+}
+
+// handleLocalStateQueryUtxos godoc
+//
+//	@Summary		Query UTxOs By Address
+//	@Description	Returns the UTxOs at one or more addresses, ordered by TX hash and output index. The UTxOs can be filtered by policy ID, asset, or minimum lovelace, and paged through by passing the next_cursor from the previous response as the cursor. The total is the number of UTxOs that pass the filters.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			address			query		[]string	true	"bech32 address, which can be repeated"	collectionFormat(multi)
+//	@Param			limit			query		integer		false	"maximum number of UTxOs to return"
+//	@Param			cursor			query		string		false	"cursor from the previous page"
+//	@Param			policy_id		query		string		false	"only return UTxOs holding assets with this policy ID"
+//	@Param			asset			query		string		false	"only return UTxOs holding this asset, as policyId.assetNameHex"
+//	@Param			min_lovelace	query		integer		false	"only return UTxOs holding at least this much lovelace"
+//	@Success		200				{object}	responseLocalStateQueryUtxos
+//	@Failure		400				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/utxos [get]
+func handleLocalStateQueryUtxos(c *gin.Context) {
+	// Get parameters
+	var req requestLocalStateQueryUtxos
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	if len(req.Addresses) == 0 {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, "no address specified", nil),
+		)
+		return
+	}
+	addrs := make([]ledger.Address, 0, len(req.Addresses))
+	for _, addrStr := range req.Addresses {
+		addr, err := ledger.NewAddress(addrStr)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf("invalid address %q: %s", addrStr, err),
+					nil,
+				),
+			)
+			return
+		}
+		addrs = append(addrs, addr)
+	}
+	filter, err := newUtxoFilter(req.PolicyId, req.Asset, req.MinLovelace)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	var cursor *localstatequery.UtxoId
+	if req.Cursor != "" {
+		tmpCursor, err := decodeUtxoCursor(req.Cursor)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
 			return
 		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
+		cursor = &tmpCursor
+	}
 
-	// Get genesisConfig
-	genesisConfig, err := oConn.LocalStateQuery().Client.GetGenesisConfig()
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
 		return
 	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
 
-	// Create response
-	//resp := responseLocalStateQueryGenesisConfig{
-	//}
-	c.JSON(200, genesisConfig)
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get UTxOs
+	utxos, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query utxo-by-address",
+		func() (*localstatequery.UTxOByAddressResult, error) {
+			return oConn.LocalStateQuery().Client.GetUTxOByAddress(addrs)
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// Filter and page through the UTxOs before converting them. The cursor is
+	// the last UTxO of the previous page, so the next page starts after it
+	utxoIds := filteredUtxoIds(utxos.Results, filter)
+	resp := responseLocalStateQueryUtxos{
+		Total: len(utxoIds),
+	}
+	if cursor != nil {
+		start, found := slices.BinarySearchFunc(
+			utxoIds,
+			*cursor,
+			compareUtxoIds,
+		)
+		if found {
+			start++
+		}
+		utxoIds = utxoIds[start:]
+	}
+	if req.Limit > 0 && uint(len(utxoIds)) > req.Limit {
+		utxoIds = utxoIds[:req.Limit]
+		nextCursor := encodeUtxoCursor(utxoIds[len(utxoIds)-1])
+		resp.NextCursor = &nextCursor
+	}
+
+	// Create response. There can be a lot of UTxOs, so each one is converted
+	// as it's written
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"utxos",
+		len(utxoIds),
+		func(idx int) (any, error) {
+			utxoId := utxoIds[idx]
+			return newResponseUtxo(utxoId, utxos.Results[utxoId])
+		},
+	)
+}
+
+type requestLocalStateQueryUtxo struct {
+	TxHash string `uri:"tx_hash" binding:"required"`
+	Index  uint32 `uri:"index"`
+}
+
+// handleLocalStateQueryUtxo godoc
+//
+//	@Summary		Query UTxO By TX Input
+//	@Description	Returns the UTxO for a TX input, or a utxo_not_found error if the output is spent or unknown.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			tx_hash	path		string	true	"TX hash"
+//	@Param			index	path		integer	true	"output index"
+//	@Success		200		{object}	responseUtxo
+//	@Failure		400		{object}	responseApiError
+//	@Failure		404		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/utxo/{tx_hash}/{index} [get]
+func handleLocalStateQueryUtxo(c *gin.Context) {
+	// Get parameters
+	var req requestLocalStateQueryUtxo
+	if err := c.ShouldBindUri(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	txIn, err := newTxIn(req.TxHash, req.Index)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	utxos, ok := queryUtxosByTxIn(c, []ledger.ShelleyTransactionInput{txIn})
+	if !ok {
+		return
+	}
+	utxoId := localstatequery.UtxoId{
+		Hash: txIn.TxId,
+		Idx:  int(txIn.OutputIndex),
+	}
+	output, ok := utxos[utxoId]
+	if !ok {
+		respondError(
+			c,
+			404,
+			apiErrorCode(
+				errorCodeUtxoNotFound,
+				"UTxO is spent or unknown",
+				nil,
+			),
+		)
+		return
+	}
+	resp, err := newResponseUtxo(utxoId, output)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondJson(c, 200, resp)
+}
+
+type requestLocalStateQueryUtxoTxIn struct {
+	TxHash      string `json:"tx_hash"      example:"9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"`
+	OutputIndex uint32 `json:"output_index" example:"0"`
+}
+
+// handleLocalStateQueryUtxoBatch godoc
+//
+//	@Summary		Query UTxOs By TX Inputs
+//	@Description	Returns the UTxOs for a list of TX inputs, in the same order as the request. Inputs that are spent or unknown are null in the response.
+//	@Tags			localstatequery
+//	@Accept			json
+//	@Produce		json
+//	@Param			tx_ins	body		[]requestLocalStateQueryUtxoTxIn	true	"TX inputs"
+//	@Success		200		{array}		responseUtxo
+//	@Failure		400		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/utxo [post]
+func handleLocalStateQueryUtxoBatch(c *gin.Context) {
+	// Get parameters
+	var req []requestLocalStateQueryUtxoTxIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	maxTxIns := config.GetConfig().Api.MaxUtxoTxIns
+	if len(req) == 0 || uint(len(req)) > maxTxIns {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf("between 1 and %d TX inputs must be specified", maxTxIns),
+				nil,
+			),
+		)
+		return
+	}
+	txIns := make([]ledger.ShelleyTransactionInput, 0, len(req))
+	for _, reqTxIn := range req {
+		txIn, err := newTxIn(reqTxIn.TxHash, reqTxIn.OutputIndex)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		txIns = append(txIns, txIn)
+	}
+	utxos, ok := queryUtxosByTxIn(c, txIns)
+	if !ok {
+		return
+	}
+	// Create response in the request order
+	resp := make([]*responseUtxo, len(txIns))
+	for idx, txIn := range txIns {
+		utxoId := localstatequery.UtxoId{
+			Hash: txIn.TxId,
+			Idx:  int(txIn.OutputIndex),
+		}
+		output, ok := utxos[utxoId]
+		if !ok {
+			continue
+		}
+		tmpUtxo, err := newResponseUtxo(utxoId, output)
+		if err != nil {
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
+			return
+		}
+		resp[idx] = &tmpUtxo
+	}
+	respondJson(c, 200, resp)
+}
+
+// newTxIn returns a TX input from a hex TX hash and an output index
+func newTxIn(txHash string, index uint32) (ledger.ShelleyTransactionInput, error) {
+	txId, err := hex.DecodeString(txHash)
+	if err != nil || len(txId) != len(ledger.Blake2b256{}) {
+		return ledger.ShelleyTransactionInput{}, fmt.Errorf(
+			"invalid TX hash: %s",
+			txHash,
+		)
+	}
+	return ledger.ShelleyTransactionInput{
+		TxId:        ledger.NewBlake2b256(txId),
+		OutputIndex: index,
+	}, nil
+}
+
+// queryUtxosByTxIn queries the node for the UTxOs of TX inputs. An error
+// response has been sent if it returns false
+func queryUtxosByTxIn(
+	c *gin.Context,
+	txIns []ledger.ShelleyTransactionInput,
+) (map[localstatequery.UtxoId]ledger.BabbageTransactionOutput, bool) {
+	// The node expects a set, so duplicates are removed
+	queryTxIns := make([]ledger.TransactionInput, 0, len(txIns))
+	for idx, txIn := range txIns {
+		if !slices.Contains(txIns[:idx], txIn) {
+			queryTxIns = append(queryTxIns, txIn)
+		}
+	}
+
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return nil, false
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return nil, false
+	}
+
+	// Get UTxOs
+	utxos, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query utxo-by-txin",
+		func() (*localstatequery.UTxOByTxInResult, error) {
+			return oConn.LocalStateQuery().Client.GetUTxOByTxIn(queryTxIns)
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return nil, false
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	return utxos.Results, true
+}
+
+type requestLocalStateQueryStakeDistribution struct {
+	Pool     string `form:"pool"`
+	MinShare string `form:"min_share"`
+}
+
+// The pools are left out when encoding this, since they're streamed after the
+// other fields
+type responseLocalStateQueryStakeDistribution struct {
+	EpochNo int                             `json:"epoch_no"        example:"507"`
+	Pools   []responseStakeDistributionPool `json:"pools,omitempty"`
+}
+
+// handleLocalStateQueryStakeDistribution godoc
+//
+//	@Summary		Query Stake Distribution
+//	@Description	Returns the stake distribution for the current epoch, ordered by descending stake. Stake fractions are decimal strings to avoid losing precision. The result is cached until the end of the epoch.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			pool		query		string	false	"only return this bech32 pool ID"
+//	@Param			min_share	query		string	false	"only return pools with at least this stake fraction, such as 0.0001"
+//	@Success		200			{object}	responseLocalStateQueryStakeDistribution
+//	@Failure		400			{object}	responseApiError
+//	@Failure		500			{object}	responseApiError
+//	@Failure		503			{object}	responseApiError
+//	@Router			/localstatequery/stake-distribution [get]
+func handleLocalStateQueryStakeDistribution(c *gin.Context) {
+	// Get parameters
+	var req requestLocalStateQueryStakeDistribution
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	var poolId *ledger.PoolId
+	if req.Pool != "" {
+		tmpPoolId, err := ledger.NewPoolIdFromBech32(req.Pool)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf("invalid pool ID: %s", req.Pool),
+					nil,
+				),
+			)
+			return
+		}
+		poolId = &tmpPoolId
+	}
+	minShare := new(big.Rat)
+	if req.MinShare != "" {
+		if _, ok := minShare.SetString(req.MinShare); !ok {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf("invalid min share: %s", req.MinShare),
+					nil,
+				),
+			)
+			return
+		}
+	}
+
+	epochNo, pools, ok := stakeDistribution.get()
+	if !ok {
+		epochNo, pools, ok = queryStakeDistribution(c)
+		if !ok {
+			return
+		}
+	}
+
+	// Filter the pools before converting them
+	filtered := make([]stakeDistributionPool, 0, len(pools))
+	for _, pool := range pools {
+		if poolId != nil && pool.poolId != *poolId {
+			continue
+		}
+		if pool.stakeFraction.Cmp(minShare) < 0 {
+			continue
+		}
+		filtered = append(filtered, pool)
+	}
+
+	// Create response
+	resp := responseLocalStateQueryStakeDistribution{
+		EpochNo: epochNo,
+	}
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"pools",
+		len(filtered),
+		func(idx int) (any, error) {
+			return filtered[idx].response(), nil
+		},
+	)
+}
+
+// queryStakeDistribution queries the node for the stake distribution and caches
+// it until the end of the epoch. An error response has been sent if it returns
+// false
+func queryStakeDistribution(
+	c *gin.Context,
+) (int, []stakeDistributionPool, bool) {
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return 0, nil, false
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return 0, nil, false
+	}
+
+	// Get epochNo
+	epochNo, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query epoch-no",
+		oConn.LocalStateQuery().Client.GetEpochNo,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return 0, nil, false
+	}
+
+	// Get the system start and era history to find the end of the epoch
+	systemStart, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query system-start",
+		oConn.LocalStateQuery().Client.GetSystemStart,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return 0, nil, false
+	}
+	eraHistory, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query era-history",
+		oConn.LocalStateQuery().Client.GetEraHistory,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return 0, nil, false
+	}
+
+	// Get stake distribution
+	result, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query stake-distribution",
+		oConn.LocalStateQuery().Client.GetStakeDistribution,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return 0, nil, false
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	pools := newStakeDistributionPools(result)
+	// The end of the epoch isn't known right after a hard fork, so the result
+	// isn't cached then
+	bounds, err := node.GetEpochBounds(
+		node.SystemStartTime(systemStart),
+		eraHistory,
+		uint64(epochNo),
+	)
+	if err == nil && bounds.EndTime != nil {
+		stakeDistribution.set(epochNo, pools, *bounds.EndTime)
+	}
+	return epochNo, pools, true
+}
+
+// handleLocalStateQueryPools godoc
+//
+//	@Summary		Query Stake Pools
+//	@Description	Returns the IDs of all registered stake pools, in bech32 and hex.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{array}		responsePool
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/pools [get]
+func handleLocalStateQueryPools(c *gin.Context) {
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get stake pools
+	result, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query stake-pools",
+		oConn.LocalStateQuery().Client.GetStakePools,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// Create response
+	poolIds := slices.Clone(result.Results)
+	slices.SortFunc(poolIds, func(a, b ledger.PoolId) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	resp := make([]responsePool, 0, len(poolIds))
+	for _, poolId := range poolIds {
+		resp = append(resp, newResponsePool(poolId))
+	}
+	respondJson(c, 200, resp)
+}
+
+// handleLocalStateQueryPoolParams godoc
+//
+//	@Summary		Query Stake Pool Params
+//	@Description	Returns the registered parameters of one or more stake pools, in the order requested. Pool IDs can be bech32 or hex, and more than one can be given separated by commas or with the pool_id query parameter. Pools that aren't registered are left out.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			pool_id	path		string		true	"pool ID, or comma-separated pool IDs"
+//	@Param			pool_id	query		[]string	false	"additional pool IDs"
+//	@Success		200		{array}		responsePoolParams
+//	@Failure		400		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/pools/{pool_id} [get]
+func handleLocalStateQueryPoolParams(c *gin.Context) {
+	// Get parameters
+	poolIdStrs := strings.Split(c.Param("pool_id"), ",")
+	poolIdStrs = append(poolIdStrs, c.QueryArray("pool_id")...)
+	var poolIds []ledger.PoolId
+	for _, poolIdStr := range poolIdStrs {
+		poolId, err := parsePoolId(strings.TrimSpace(poolIdStr))
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		if !slices.Contains(poolIds, poolId) {
+			poolIds = append(poolIds, poolId)
+		}
+	}
+
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get stake pool params
+	result, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query stake-pool-params",
+		func() (*localstatequery.StakePoolParamsResult, error) {
+			return oConn.LocalStateQuery().Client.GetStakePoolParams(poolIds)
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// Create response
+	resp := []responsePoolParams{}
+	for _, poolId := range poolIds {
+		params, ok := result.Results[poolId]
+		if !ok {
+			continue
+		}
+		tmpPool, err := newResponsePoolParams(poolId, params)
+		if err != nil {
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
+			return
+		}
+		resp = append(resp, tmpPool)
+	}
+	respondJson(c, 200, resp)
+}
+
+// handleLocalStateQueryGenesis godoc
+//
+//	@Summary		Query Genesis Config
+//	@Description	Returns the Shelley genesis config of the network. The result is cached until the connection to the node is lost.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryGenesis
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/genesis [get]
+func handleLocalStateQueryGenesis(c *gin.Context) {
+	if resp, ok := networkGenesis.get(); ok {
+		respondJson(c, 200, resp)
+		return
+	}
+	// Note the connection before querying, so that a reconnect during the
+	// query doesn't leave a stale result in the cache
+	connectedAt := managedConnectedAt()
+
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+
+	// Acquire the current ledger state
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return
+	}
+
+	// Get genesisConfig
+	genesisConfig, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query genesis-config",
+		oConn.LocalStateQuery().Client.GetGenesisConfig,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+
+	_ = oConn.ReleaseLocalState(ctx)
+
+	// Create response
+	resp, err := newResponseGenesis(genesisConfig)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	networkGenesis.set(&resp, connectedAt)
+	respondJson(c, 200, resp)
+}
+
+// handleLocalStateQueryStakeAddress godoc
+//
+//	@Summary		Query Stake Address
+//	@Description	Returns whether a stake address is registered, the pool that it delegates to, its available rewards in lovelace, and its DRep delegation, which is only included from the Conway era. Unregistered stake addresses aren't an error.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			stake_address	path		string	true	"bech32 stake address"
+//	@Success		200				{object}	responseLocalStateQueryStakeAddress
+//	@Failure		400				{object}	responseApiError
+//	@Failure		422				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/stake/{stake_address} [get]
+func handleLocalStateQueryStakeAddress(c *gin.Context) {
+	// Get parameters
+	stakeAddress, err := parseStakeAddress(c.Param("stake_address"))
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+
+	resp, ok := queryStakeAccounts(c, []ledger.Address{stakeAddress})
+	if !ok {
+		return
+	}
+	respondJson(c, 200, resp[0])
+}
+
+// handleLocalStateQueryStakeAccounts godoc
+//
+//	@Summary		Query Stake Accounts
+//	@Description	Returns the stake address info for each of a list of stake addresses, in the order requested, from a single ledger state with one query for all of them. Unregistered stake addresses are included. The number of stake addresses per request is limited by the API_MAX_STAKE_ACCOUNTS setting.
+//	@Tags			localstatequery
+//	@Accept			json
+//	@Produce		json
+//	@Param			stake_addresses	body		[]string	true	"bech32 stake addresses"
+//	@Success		200				{array}		responseLocalStateQueryStakeAddress
+//	@Failure		400				{object}	responseApiError
+//	@Failure		422				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/stake/accounts [post]
+func handleLocalStateQueryStakeAccounts(c *gin.Context) {
+	// Get parameters
+	var req []string
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	maxStakeAccounts := config.GetConfig().Api.MaxStakeAccounts
+	if len(req) == 0 || uint(len(req)) > maxStakeAccounts {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf(
+					"between 1 and %d stake addresses must be specified",
+					maxStakeAccounts,
+				),
+				nil,
+			),
+		)
+		return
+	}
+	// All of the stake addresses are checked before querying the node
+	stakeAddresses := make([]ledger.Address, 0, len(req))
+	for idx, reqStakeAddress := range req {
+		stakeAddress, err := parseStakeAddress(reqStakeAddress)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf("stake address %d: %s", idx, err),
+					nil,
+				),
+			)
+			return
+		}
+		stakeAddresses = append(stakeAddresses, stakeAddress)
+	}
+
+	resp, ok := queryStakeAccounts(c, stakeAddresses)
+	if !ok {
+		return
+	}
+	respondJson(c, 200, resp)
+}
+
+// handleLocalStateQueryStakeSnapshots godoc
+//
+//	@Summary		Query Stake Snapshots
+//	@Description	Returns the stake of one or more pools in the mark, set, and go snapshots, along with the total active stake in each snapshot. Pool IDs can be bech32 or hex, separated by commas. Use the pool ID all with all=true for every pool, which is expensive on mainnet. The snapshots of all pools are cached until the end of the epoch. Stake is in lovelace, as strings.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			pool_id	path		string	true	"pool ID, comma-separated pool IDs, or all"
+//	@Param			all		query		bool	false	"required to query all pools"
+//	@Success		200		{object}	responseLocalStateQueryStakeSnapshots
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/pools/{pool_id}/snapshots [get]
+func handleLocalStateQueryStakeSnapshots(c *gin.Context) {
+	// Get parameters. No pool IDs means all pools
+	var poolIds []ledger.PoolId
+	if c.Param("pool_id") == "all" {
+		if c.Query("all") != "true" {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					"all=true must be specified to query all pools",
+					nil,
+				),
+			)
+			return
+		}
+	} else {
+		for _, poolIdStr := range strings.Split(c.Param("pool_id"), ",") {
+			poolId, err := parsePoolId(strings.TrimSpace(poolIdStr))
+			if err != nil {
+				respondError(
+					c,
+					400,
+					apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+				)
+				return
+			}
+			if !slices.Contains(poolIds, poolId) {
+				poolIds = append(poolIds, poolId)
+			}
+		}
+	}
+
+	epochNo, snapshots, ok := stakeSnapshots.get()
+	if !ok {
+		epochNo, snapshots, ok = queryStakeSnapshots(c, poolIds)
+		if !ok {
+			return
+		}
+	}
+
+	// Create response. The pools are in the order requested, or ordered by
+	// pool ID for all pools
+	if poolIds == nil {
+		poolIds = make([]ledger.PoolId, 0, len(snapshots.Pools))
+		for poolId := range snapshots.Pools {
+			poolIds = append(poolIds, poolId)
+		}
+		slices.SortFunc(poolIds, func(a, b ledger.PoolId) int {
+			return bytes.Compare(a[:], b[:])
+		})
+	}
+	resp := responseLocalStateQueryStakeSnapshots{
+		EpochNo: epochNo,
+		Total:   snapshots.total().response(),
+	}
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"pools",
+		len(poolIds),
+		func(idx int) (any, error) {
+			return responsePoolSnapshots{
+				PoolId: poolIds[idx].String(),
+				Stake:  snapshots.Pools[poolIds[idx]].response(),
+			}, nil
+		},
+	)
+}
+
+// queryStakeSnapshots queries the node for the stake snapshots of the pools, or
+// of all pools if there are none. The snapshots of all pools are cached until the
+// end of the epoch. An error response has been sent if it returns false
+func queryStakeSnapshots(
+	c *gin.Context,
+	poolIds []ledger.PoolId,
+) (int, *stakeSnapshotsResult, bool) {
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return 0, nil, false
+	}
+	defer query.Close()
+
+	// Get epochNo
+	epochNo, ok := queryLedger[uint64](
+		c,
+		query,
+		"query epoch-no",
+		localstatequery.QueryTypeShelleyEpochNo,
+	)
+	if !ok {
+		return 0, nil, false
+	}
+
+	// Get stake snapshots. The pool IDs are an optional set, where nothing
+	// means all pools
+	poolIdsParam := []any{}
+	if poolIds != nil {
+		poolIdsParam = append(poolIdsParam, poolIds)
+	}
+	snapshots, ok := queryLedger[*stakeSnapshotsResult](
+		c,
+		query,
+		"query stake-snapshots",
+		localstatequery.QueryTypeShelleyStakeSnapshots,
+		poolIdsParam,
+	)
+	if !ok {
+		return 0, nil, false
+	}
+	if poolIds != nil {
+		return int(epochNo), snapshots, true
+	}
+
+	// The end of the epoch isn't known right after a hard fork, so the result
+	// isn't cached then
+	endTime, ok := queryEpochEnd(c, query, epochNo)
+	if !ok {
+		return 0, nil, false
+	}
+	if endTime != nil {
+		stakeSnapshots.set(int(epochNo), snapshots, *endTime)
+	}
+	return int(epochNo), snapshots, true
+}
+
+// handleLocalStateQueryConstitution godoc
+//
+//	@Summary		Query Constitution
+//	@Description	Returns the anchor of the current constitution and the hash of its guardrails script, if there is one. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryConstitution
+//	@Failure		422	{object}	responseApiError
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/governance/constitution [get]
+func handleLocalStateQueryConstitution(c *gin.Context) {
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get constitution
+	constitution, ok := queryLedger[constitutionResult](
+		c,
+		query,
+		"query constitution",
+		queryTypeShelleyConstitution,
+	)
+	if !ok {
+		return
+	}
+
+	// Create response
+	respondJson(c, 200, newResponseConstitution(constitution))
+}
+
+// handleLocalStateQueryGovState godoc
+//
+//	@Summary		Query Governance State
+//	@Description	Returns the current protocol version, the enacted constitutional committee, the treasury in lovelace, and the previous enacted governance action of each purpose. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Success		200	{object}	responseLocalStateQueryGovState
+//	@Failure		422	{object}	responseApiError
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localstatequery/governance/state [get]
+func handleLocalStateQueryGovState(c *gin.Context) {
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get gov state. It's decoded when creating the response, since only some
+	// of it is used
+	govState, ok := queryLedger[cbor.RawMessage](
+		c,
+		query,
+		"query gov-state",
+		queryTypeShelleyGovState,
+	)
+	if !ok {
+		return
+	}
+
+	// Get the treasury from the account state
+	accountState, ok := queryLedger[accountStateResult](
+		c,
+		query,
+		"query account-state",
+		queryTypeShelleyAccountState,
+	)
+	if !ok {
+		return
+	}
+
+	// Create response
+	resp, err := newResponseGovState(govState, accountState)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	respondJson(c, 200, resp)
+}
+
+// handleLocalStateQueryDreps godoc
+//
+//	@Summary		Query DReps
+//	@Description	Returns the registered DReps with their expiry epochs, anchors, and deposits in lovelace, ordered by type and then hash. DRep IDs can be CIP-129 or CIP-105 bech32, or hex, which is the CIP-129 header byte and the hash, or a key hash. The DReps are paged through by passing the next_cursor from the previous response as the cursor. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			drep_id	query		[]string	false	"only return this DRep, which can be repeated"	collectionFormat(multi)
+//	@Param			limit	query		integer		false	"maximum number of DReps to return"
+//	@Param			cursor	query		string		false	"cursor from the previous page"
+//	@Success		200		{object}	responseLocalStateQueryDreps
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/governance/dreps [get]
+func handleLocalStateQueryDreps(c *gin.Context) {
+	// Get parameters
+	req, dreps, cursor, ok := bindDrepsRequest(c, false)
+	if !ok {
+		return
+	}
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get DRep state. No DReps means all of them
+	states, ok := queryLedger[map[ledgerDrep]drepState](
+		c,
+		query,
+		"query drep-state",
+		queryTypeShelleyDRepState,
+		dreps,
+	)
+	if !ok {
+		return
+	}
+
+	// Page through the DReps before converting them. The cursor is the last
+	// DRep of the previous page, so the next page starts after it
+	keys := sortedKeys(states, compareLedgerDreps)
+	resp := responseLocalStateQueryDreps{
+		Total: len(keys),
+	}
+	keys, last := pageKeys(keys, cursor, req.Limit, compareLedgerDreps)
+	if last != nil {
+		nextCursor := encodeDrepCursor(*last)
+		resp.NextCursor = &nextCursor
+	}
+
+	// Create response
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"dreps",
+		len(keys),
+		func(idx int) (any, error) {
+			return newResponseGovernanceDrep(keys[idx], states[keys[idx]]), nil
+		},
+	)
+}
+
+// handleLocalStateQueryDrepStake godoc
+//
+//	@Summary		Query DRep Stake Distribution
+//	@Description	Returns the stake delegated to each DRep in lovelace, including the abstain and no confidence DReps, ordered by type and then hash. DRep IDs are given like for the DReps query, or as abstain or no_confidence. The DReps are paged through by passing the next_cursor from the previous response as the cursor. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			drep_id	query		[]string	false	"only return this DRep, which can be repeated"	collectionFormat(multi)
+//	@Param			limit	query		integer		false	"maximum number of DReps to return"
+//	@Param			cursor	query		string		false	"cursor from the previous page"
+//	@Success		200		{object}	responseLocalStateQueryDrepStake
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/governance/drep-stake [get]
+func handleLocalStateQueryDrepStake(c *gin.Context) {
+	// Get parameters
+	req, dreps, cursor, ok := bindDrepsRequest(c, true)
+	if !ok {
+		return
+	}
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get DRep stake distribution. No DReps means all of them
+	stake, ok := queryLedger[map[ledgerDrep]uint64](
+		c,
+		query,
+		"query drep-stake-distr",
+		queryTypeShelleyDRepStakeDistr,
+		dreps,
+	)
+	if !ok {
+		return
+	}
+
+	// Page through the DReps like for the DReps query
+	keys := sortedKeys(stake, compareLedgerDreps)
+	resp := responseLocalStateQueryDrepStake{
+		Total: len(keys),
+	}
+	keys, last := pageKeys(keys, cursor, req.Limit, compareLedgerDreps)
+	if last != nil {
+		nextCursor := encodeDrepCursor(*last)
+		resp.NextCursor = &nextCursor
+	}
+
+	// Create response
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"dreps",
+		len(keys),
+		func(idx int) (any, error) {
+			return responseGovernanceDrepStake{
+				Drep:  keys[idx].response(),
+				Stake: strconv.FormatUint(stake[keys[idx]], 10),
+			}, nil
+		},
+	)
+}
+
+// bindDrepsRequest gets the parameters for the DRep queries. An error response
+// has been sent if it returns false
+func bindDrepsRequest(
+	c *gin.Context,
+	allowSpecial bool,
+) (requestLocalStateQueryDreps, []ledgerDrep, *ledgerDrep, bool) {
+	var req requestLocalStateQueryDreps
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return req, nil, nil, false
+	}
+	dreps, err := parseDrepIds(req.DrepIds, allowSpecial)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return req, nil, nil, false
+	}
+	var cursor *ledgerDrep
+	if req.Cursor != "" {
+		tmpCursor, err := decodeDrepCursor(req.Cursor)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return req, nil, nil, false
+		}
+		cursor = &tmpCursor
+	}
+	return req, dreps, cursor, true
+}
+
+// handleLocalStateQueryCommittee godoc
+//
+//	@Summary		Query Constitutional Committee
+//	@Description	Returns the constitutional committee members with their cold and hot credentials, hot credential status, member status, and expiry epochs, ordered by cold credential, along with the quorum threshold. Credentials can be CIP-129 bech32 or hex, which is the CIP-129 header byte and the hash, or a key hash. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			cold_credential	query		[]string	false	"only return the member with this cold credential, which can be repeated"	collectionFormat(multi)
+//	@Param			hot_credential	query		[]string	false	"only return the member with this hot credential, which can be repeated"	collectionFormat(multi)
+//	@Param			status			query		[]string	false	"only return members with this status, which can be repeated"				collectionFormat(multi)	Enums(active, expired, unrecognized)
+//	@Success		200				{object}	responseLocalStateQueryCommittee
+//	@Failure		400				{object}	responseApiError
+//	@Failure		422				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/governance/committee [get]
+func handleLocalStateQueryCommittee(c *gin.Context) {
+	// Get parameters. No credentials or statuses means all of them
+	var req requestLocalStateQueryCommittee
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	coldCreds, err := parseCip129Ids(
+		req.ColdCredentials,
+		cip129PrefixCommitteeCold,
+		cip129HeaderCommitteeCold,
+	)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	hotCreds, err := parseCip129Ids(
+		req.HotCredentials,
+		cip129PrefixCommitteeHot,
+		cip129HeaderCommitteeHot,
+	)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	statuses := make([]uint, 0, len(req.Statuses))
+	for _, statusStr := range req.Statuses {
+		status, err := parseCommitteeMemberStatus(statusStr)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get committee members state
+	state, ok := queryLedger[committeeMembersStateResult](
+		c,
+		query,
+		"query committee-members-state",
+		queryTypeShelleyCommitteeMembersState,
+		coldCreds,
+		hotCreds,
+		statuses,
+	)
+	if !ok {
+		return
+	}
+
+	// Create response
+	respondJson(c, 200, newResponseCommittee(state))
+}
+
+// handleLocalStateQuerySpoStake godoc
+//
+//	@Summary		Query SPO Voting Stake Distribution
+//	@Description	Returns the voting stake of each stake pool in lovelace, ordered by pool ID, along with the total of the pools returned. Pool IDs can be bech32 or hex. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			pool_id	query		[]string	false	"only return this pool, which can be repeated"	collectionFormat(multi)
+//	@Success		200		{object}	responseLocalStateQuerySpoStake
+//	@Failure		400		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/governance/spo-stake [get]
+func handleLocalStateQuerySpoStake(c *gin.Context) {
+	// Get parameters. No pool IDs means all pools
+	poolIds := []ledger.PoolId{}
+	for _, poolIdStr := range c.QueryArray("pool_id") {
+		poolId, err := parsePoolId(strings.TrimSpace(poolIdStr))
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		if !slices.Contains(poolIds, poolId) {
+			poolIds = append(poolIds, poolId)
+		}
+	}
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get SPO stake distribution
+	stake, ok := queryLedger[map[ledger.PoolId]uint64](
+		c,
+		query,
+		"query spo-stake-distr",
+		queryTypeShelleySPOStakeDistr,
+		poolIds,
+	)
+	if !ok {
+		return
+	}
+
+	// Create response
+	keys := sortedKeys(stake, func(a, b ledger.PoolId) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	var total uint64
+	for _, poolStake := range stake {
+		total += poolStake
+	}
+	resp := responseLocalStateQuerySpoStake{
+		Total: strconv.FormatUint(total, 10),
+	}
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"pools",
+		len(keys),
+		func(idx int) (any, error) {
+			return responsePoolVotingStake{
+				PoolId: keys[idx].String(),
+				Stake:  strconv.FormatUint(stake[keys[idx]], 10),
+			}, nil
+		},
+	)
+}
+
+// handleLocalStateQueryProposals godoc
+//
+//	@Summary		Query Governance Proposals
+//	@Description	Returns the live governance actions in the order the node returns them, with their proposals and the votes cast so far. Action IDs are given as txHash#index, with the # URL-encoded as %23. The vote counts are the number of committee members, DReps, and stake pools that cast each vote. Use include_votes=false to leave out the individual votes. Needs the Conway era.
+//	@Tags			localstatequery
+//	@Produce		json
+//	@Param			action_id		query		[]string	false	"only return this action, which can be repeated"				collectionFormat(multi)
+//	@Param			type			query		[]string	false	"only return actions of this type, which can be repeated"	collectionFormat(multi)	Enums(parameter_change, hard_fork, treasury_withdrawals, no_confidence, update_committee, new_constitution, info)
+//	@Param			include_votes	query		bool		false	"include the individual votes (default true)"
+//	@Success		200				{object}	responseLocalStateQueryProposals
+//	@Failure		400				{object}	responseApiError
+//	@Failure		422				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Router			/localstatequery/governance/proposals [get]
+func handleLocalStateQueryProposals(c *gin.Context) {
+	// Get parameters. No action IDs means all actions
+	var req requestLocalStateQueryProposals
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	actionIds := make([]ledger.GovActionId, 0, len(req.ActionIds))
+	for _, actionIdStr := range req.ActionIds {
+		actionId, err := parseGovActionId(actionIdStr)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		if !slices.Contains(actionIds, actionId) {
+			actionIds = append(actionIds, actionId)
+		}
+	}
+	var actionTypes []uint
+	for _, actionTypeStr := range req.Types {
+		actionType, err := parseGovActionType(actionTypeStr)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+		actionTypes = append(actionTypes, actionType)
+	}
+	includeVotes := req.IncludeVotes == nil || *req.IncludeVotes
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return
+	}
+	defer query.Close()
+	if !requireConway(c, query) {
+		return
+	}
+
+	// Get proposals
+	states, ok := queryLedger[[]govActionState](
+		c,
+		query,
+		"query proposals",
+		queryTypeShelleyProposals,
+		actionIds,
+	)
+	if !ok {
+		return
+	}
+
+	// Filter by action type, which the node can't do
+	type proposal struct {
+		state      govActionState
+		actionType uint
+	}
+	proposals := make([]proposal, 0, len(states))
+	for _, state := range states {
+		actionType, err := cbor.DecodeIdFromList(state.Procedure.GovAction)
+		if err != nil {
+			respondError(
+				c,
+				500,
+				apiErrorCode(
+					errorCodeInternal,
+					fmt.Sprintf("failed to decode governance action: %s", err),
+					nil,
+				),
+			)
+			return
+		}
+		if actionTypes != nil &&
+			!slices.Contains(actionTypes, uint(actionType)) {
+			continue
+		}
+		proposals = append(proposals, proposal{state, uint(actionType)})
+	}
+
+	// Create response
+	resp := responseLocalStateQueryProposals{
+		Total: len(proposals),
+	}
+	respondJsonStream(
+		c,
+		200,
+		resp,
+		"proposals",
+		len(proposals),
+		func(idx int) (any, error) {
+			return newResponseGovernanceProposal(
+				proposals[idx].state,
+				proposals[idx].actionType,
+				includeVotes,
+			), nil
+		},
+	)
+}
+
+// handleLocalStateQueryRaw godoc
+//
+//	@Summary		Raw Query
+//	@Description	Sends a LocalStateQuery query given as hex CBOR to the node and returns the result as hex CBOR, along with a best-effort JSON rendering of it. The query is sent as is, so ledger queries must include the era wrapping, such as [0, [0, [era, query]]]. The ledger state is the volatile tip, the immutable tip, or a point given by its slot and block hash. A ledger query for a different era than the ledger state is reported as unprocessable. Only served when API_RAW_QUERY_ENABLED is set.
+//	@Tags			localstatequery
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		requestLocalStateQueryRaw	true	"query and ledger state"
+//	@Success		200		{object}	responseLocalStateQueryRaw
+//	@Failure		400		{object}	responseApiError
+//	@Failure		413		{object}	responseApiError
+//	@Failure		422		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Router			/localstatequery/raw [post]
+func handleLocalStateQueryRaw(c *gin.Context) {
+	// Get parameters
+	var req requestLocalStateQueryRaw
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondRequestTooLarge(c, maxBytesErr.Limit)
+			return
+		}
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	queryCbor, err := hex.DecodeString(req.Query)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidEncoding, "query must be hex", nil),
+		)
+		return
+	}
+	// The node drops the connection for a query it can't decode, so at least
+	// check that it's a single CBOR item
+	var tmpQuery cbor.RawMessage
+	if n, err := cbor.Decode(queryCbor, &tmpQuery); err != nil ||
+		n != len(queryCbor) {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidCbor,
+				"query must be a single CBOR item",
+				nil,
+			),
+		)
+		return
+	}
+	target, err := req.acquireTarget()
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+
+	query := openLedgerQuery(c, target)
+	if query == nil {
+		return
+	}
+	defer query.Close()
+
+	result, err := query.Query(
+		c.Request.Context(),
+		"query raw",
+		cbor.RawMessage(queryCbor),
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	// The result of a ledger query for the wrong era is the query and ledger
+	// eras instead
+	if isLedgerQuery(queryCbor) {
+		var mismatchErr *node.EraMismatchError
+		if _, err := node.UnwrapLedgerResult(result); errors.As(
+			err,
+			&mismatchErr,
+		) {
+			respondLedgerQueryError(c, err)
+			return
+		}
+	}
+
+	// Create response
+	resp := responseLocalStateQueryRaw{
+		Result: hex.EncodeToString(result),
+	}
+	if diagnostic, err := cborDiagnostic(result); err == nil {
+		resp.Diagnostic = diagnostic
+	}
+	respondJson(c, 200, resp)
 }
@@ -0,0 +1,207 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// Mainnet era summaries for the era history query, as hex. Each is a list with
+// the start and end bounds, which have the time in picoseconds, the slot, and
+// the epoch, and the era parameters. The current era has no end
+const (
+	testEraSummaryByronHex = "838300000083c24904df00a3ec298000001a00448e0018d0" +
+		"83195460194e2083001910e08100"
+	testEraSummaryShelleyHex = "8383c24904df00a3ec298000001a00448e0018d0" +
+		"83c2490586de43d5cca000001a00fd200018ec831a000697801903e883001a0001fa408100"
+	testEraSummaryAllegraHex = "8383c2490586de43d5cca000001a00fd200018ec" +
+		"83c24905e0cbd980ad2800001a0160008018fb831a000697801903e883001a0001fa408100"
+	testEraSummaryMaryHex = "8383c24905e0cbd980ad2800001a0160008018fb" +
+		"83c24906ca9bf83cf4f000001a02611500190122831a000697801903e883001a0001fa408100"
+	testEraSummaryAlonzoHex = "8383c24906ca9bf83cf4f000001a02611500190122" +
+		"83c249088c3fe493579800001a044f778019016d831a000697801903e883001a0001fa408100"
+	testEraSummaryBabbageHex = "8383c249088c3fe493579800001a044f778019016d" +
+		"83c2490bdf918f8fa52800001a07f780801901fb831a000697801903e883001a0001fa408100"
+	testEraSummaryConwayHex = "8383c2490bdf918f8fa52800001a07f780801901fb" +
+		"f6831a000697801903e883001a0001fa408100"
+	testEraSummariesBeforeConwayHex = testEraSummaryByronHex +
+		testEraSummaryShelleyHex +
+		testEraSummaryAllegraHex +
+		testEraSummaryMaryHex +
+		testEraSummaryAlonzoHex +
+		testEraSummaryBabbageHex
+)
+
+func TestHandleLocalStateQueryEra(t *testing.T) {
+	babbageStartSlot := uint64(72316800)
+	conwayStartSlot := uint64(133660800)
+	testDefs := []struct {
+		name string
+		// The current era and era history results, as hex
+		era        string
+		eraHistory string
+		wantId     uint8
+		wantName   string
+		// The start slot is left out if the era history doesn't have the era
+		wantStartSlot *uint64
+	}{
+		{
+			// The slot before the Conway hard fork at slot 133660800, when the
+			// end of Babbage is known
+			name:          "one slot before hard fork",
+			era:           "05",
+			eraHistory:    "86" + testEraSummariesBeforeConwayHex,
+			wantId:        5,
+			wantName:      "babbage",
+			wantStartSlot: &babbageStartSlot,
+		},
+		{
+			name:          "at hard fork",
+			era:           "06",
+			eraHistory:    "87" + testEraSummariesBeforeConwayHex + testEraSummaryConwayHex,
+			wantId:        6,
+			wantName:      "conway",
+			wantStartSlot: &conwayStartSlot,
+		},
+		{
+			name:       "era missing from history",
+			era:        "06",
+			eraHistory: "86" + testEraSummariesBeforeConwayHex,
+			wantId:     6,
+			wantName:   "conway",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			startLedgerQueryMockNode(
+				t,
+				[]string{
+					// [0, [2, [1]]]
+					"820082028101",
+					// [0, [2, [0]]]
+					"820082028100",
+				},
+				[]string{testDef.era, testDef.eraHistory},
+			)
+			w := serveTestRequest(
+				http.MethodGet,
+				"/localstatequery/era",
+				handleLocalStateQueryEra,
+				"/localstatequery/era",
+				nil,
+			)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var resp responseLocalStateQueryEra
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.Id != testDef.wantId || resp.Name != testDef.wantName {
+				t.Fatalf("unexpected era: %d (%s)", resp.Id, resp.Name)
+			}
+			switch {
+			case testDef.wantStartSlot == nil && resp.StartSlot != nil:
+				t.Fatalf("unexpected start slot: %d", *resp.StartSlot)
+			case testDef.wantStartSlot != nil && resp.StartSlot == nil:
+				t.Fatalf("no start slot")
+			case testDef.wantStartSlot != nil &&
+				*resp.StartSlot != *testDef.wantStartSlot:
+				t.Fatalf(
+					"unexpected start slot: got %d, wanted %d",
+					*resp.StartSlot,
+					*testDef.wantStartSlot,
+				)
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQuerySystemStart(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// The system start result, as hex
+		systemStart     string
+		wantTime        string
+		wantPicoseconds uint64
+	}{
+		{
+			name: "mainnet",
+			// [2017, 266, 78291000000000000]
+			systemStart:     "831907e119010a1b0116253fec1c3000",
+			wantTime:        "2017-09-23T21:44:51Z",
+			wantPicoseconds: 78291000000000000,
+		},
+		{
+			name: "fractional seconds",
+			// [2017, 266, 78291123456789000]
+			systemStart:     "831907e119010a1b0116255caab54a08",
+			wantTime:        "2017-09-23T21:44:51.123456789Z",
+			wantPicoseconds: 78291123456789000,
+		},
+		{
+			// The time only has nanoseconds, so the rest is dropped
+			name: "fractional nanoseconds",
+			// [2017, 266, 78291000000001500]
+			systemStart:     "831907e119010a1b0116253fec1c35dc",
+			wantTime:        "2017-09-23T21:44:51.000000001Z",
+			wantPicoseconds: 78291000000001500,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			t.Cleanup(discardPooledConnection)
+			startLedgerQueryMockNode(
+				t,
+				// [1]
+				[]string{"8101"},
+				[]string{testDef.systemStart},
+			)
+			w := serveTestRequest(
+				http.MethodGet,
+				"/localstatequery/system-start",
+				handleLocalStateQuerySystemStart,
+				"/localstatequery/system-start",
+				nil,
+			)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			// The time is checked as it's encoded
+			var resp struct {
+				Time        string `json:"time"`
+				Year        int    `json:"year"`
+				Day         int    `json:"day"`
+				Picoseconds uint64 `json:"picoseconds"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.Time != testDef.wantTime {
+				t.Fatalf(
+					"unexpected time: got %s, wanted %s",
+					resp.Time,
+					testDef.wantTime,
+				)
+			}
+			if resp.Year != 2017 || resp.Day != 266 ||
+				resp.Picoseconds != testDef.wantPicoseconds {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
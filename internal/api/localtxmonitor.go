@@ -15,201 +15,604 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/blinklabs-io/gouroboros/cbor"
 	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/blake2b"
 
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
 	"github.com/blinklabs-io/cardano-node-api/internal/node"
 )
 
-func configureLocalTxMonitorRoutes(apiGroup *gin.RouterGroup) {
+// Era of a mempool TX that we can't decode
+const mempoolTxEraUnknown = "unknown"
+
+func configureLocalTxMonitorRoutes(apiGroup *gin.RouterGroup, version int) {
 	group := apiGroup.Group("/localtxmonitor")
 	group.GET("/sizes", handleLocalTxMonitorSizes)
 	group.GET("/has_tx/:tx_hash", handleLocalTxMonitorHasTx)
 	group.GET("/txs", handleLocalTxMonitorTxs)
+	group.GET("/tx/:tx_hash", handleLocalTxMonitorTx)
+	group.GET("/stream", handleLocalTxMonitorStream)
+	group.GET("/ws", handleLocalTxMonitorWs)
 }
 
 type responseLocalTxMonitorSizes struct {
-	Capacity uint32 `json:"capacity"`
-	Size     uint32 `json:"size"`
-	TxCount  uint32 `json:"tx_count"`
+	Capacity    uint32  `json:"capacity"    example:"178176"`
+	Size        uint32  `json:"size"        example:"8920"`
+	TxCount     uint32  `json:"tx_count"    example:"12"`
+	Utilization float64 `json:"utilization" example:"5.01"`
+	Slot        uint64  `json:"slot"        example:"134217728"`
 }
 
 // handleLocalTxMonitorSizes godoc
 //
-//	@Summary	Get mempool capacity, size, and TX count
-//	@Tags		localtxmonitor
-//	@Accept		json
-//	@Produce	json
-//	@Success	200	{object}	responseLocalTxMonitorSizes
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localtxmonitor/sizes [get]
+//	@Summary		Get mempool capacity, size, and TX count
+//	@Description	Returns the capacity and size of a mempool snapshot in bytes, its number of transactions, and its size as a percentage of its capacity. The slot is the chain tip that the snapshot is for.
+//	@Tags			localtxmonitor
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	responseLocalTxMonitorSizes
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Failure		504	{object}	responseApiError
+//	@Router			/localtxmonitor/sizes [get]
 func handleLocalTxMonitorSizes(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalTxMonitor().Client.Start()
-	// Get sizes
-	capacity, size, txCount, err := oConn.LocalTxMonitor().Client.GetSizes()
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	// Acquire a mempool snapshot
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	slot, ok := queryMempoolSlot(c, oConn)
+	if !ok {
+		return
+	}
+	// Get sizes, which also updates the mempool metrics
+	sizes, err := node.GetMempoolSizes(ctx, oConn)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeError(c, err)
 		return
 	}
+	_ = oConn.ReleaseMempool(ctx)
 	// Create response
 	resp := responseLocalTxMonitorSizes{
-		Capacity: capacity,
-		Size:     size,
-		TxCount:  txCount,
+		Capacity:    sizes.Capacity,
+		Size:        sizes.Size,
+		TxCount:     sizes.TxCount,
+		Utilization: math.Round(sizes.Utilization()*100) / 100,
+		Slot:        slot,
 	}
-	c.JSON(200, resp)
+	respondJson(c, 200, resp)
 }
 
 type requestLocalTxMonitorHasTx struct {
 	TxHash string `uri:"tx_hash" binding:"required"`
 }
 
+type requestLocalTxMonitorHasTxWait struct {
+	Wait string `form:"wait"`
+}
+
 type responseLocalTxMonitorHasTx struct {
-	HasTx bool `json:"has_tx"`
+	Present bool   `json:"present" example:"true"`
+	Slot    uint64 `json:"slot"    example:"134217728"`
 }
 
 // handleLocalTxMonitorHasTx godoc
 //
-//	@Summary	Check if a particular TX exists in the mempool
-//	@Tags		localtxmonitor
-//	@Accept		json
-//	@Produce	json
-//	@Success	200	{object}	responseLocalTxMonitorHasTx
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localtxmonitor/has_tx/{tx_hash} [get]
+//	@Summary		Check if a particular TX exists in the mempool
+//	@Description	Returns whether a transaction is in a mempool snapshot. The slot is the chain tip that the snapshot is for. With a wait duration, such as 30s, the request waits for the transaction to reach the mempool, checking each new snapshot as the mempool changes. The response is sent as soon as the transaction is found, or with the last snapshot when the wait runs out. The wait must be less than the TX submission wait timeout.
+//	@Tags			localtxmonitor
+//	@Produce		json
+//	@Param			tx_hash	path		string	true	"Transaction ID"
+//	@Param			wait	query		string	false	"How long to wait for the transaction, as a Go duration"
+//	@Success		200		{object}	responseLocalTxMonitorHasTx
+//	@Failure		400		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Failure		504		{object}	responseApiError
+//	@Router			/localtxmonitor/has_tx/{tx_hash} [get]
 func handleLocalTxMonitorHasTx(c *gin.Context) {
 	// Get parameters
 	var req requestLocalTxMonitorHasTx
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, apiError(err.Error()))
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
 		return
 	}
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
-	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+	var waitReq requestLocalTxMonitorHasTxWait
+	if err := c.ShouldBindQuery(&waitReq); err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
+	txHash, err := hex.DecodeString(req.TxHash)
+	if err != nil || len(txHash) != blake2b.Size256 {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(
+				errorCodeBadRequest,
+				"invalid transaction ID, should be 32 bytes of hex",
+				nil,
+			),
+		)
+		return
+	}
+	// The request timeout doesn't apply to this route, so that it can't cut a
+	// wait short. We leave time after the wait for the final checks
+	timeout := time.Duration(config.GetConfig().Api.TxWaitTimeout) * time.Second
+	var wait time.Duration
+	if waitReq.Wait != "" {
+		wait, err = time.ParseDuration(waitReq.Wait)
+		if err != nil || wait < 0 || wait >= timeout {
+			respondError(
+				c,
+				http.StatusBadRequest,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf(
+						"invalid wait, should be a duration less than %s",
+						timeout,
+					),
+					nil,
+				),
+			)
 			return
 		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalTxMonitor().Client.Start()
-	// Make the call to the node
-	txHash, err := hex.DecodeString(req.TxHash)
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondNodeUnavailable(c, err)
 		return
 	}
-	hasTx, err := oConn.LocalTxMonitor().Client.HasTx(txHash)
-	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	// Acquire a mempool snapshot
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		respondNodeError(c, err)
 		return
 	}
-	// Create response
-	resp := responseLocalTxMonitorHasTx{
-		HasTx: hasTx,
+	// A client disconnect cancels the request context, which also ends the wait
+	waitCtx, waitCancel := context.WithTimeout(ctx, wait)
+	defer waitCancel()
+	var resp responseLocalTxMonitorHasTx
+	for {
+		hasTx, err := node.Call(
+			ctx,
+			oConn,
+			localtxmonitor.ProtocolName,
+			"has-tx",
+			func() (bool, error) {
+				return oConn.LocalTxMonitor().Client.HasTx(txHash)
+			},
+		)
+		if err != nil {
+			respondNodeError(c, err)
+			return
+		}
+		slot, ok := queryMempoolSlot(c, oConn)
+		if !ok {
+			return
+		}
+		resp = responseLocalTxMonitorHasTx{
+			Present: hasTx,
+			Slot:    slot,
+		}
+		if hasTx || wait == 0 {
+			break
+		}
+		// Wait for the next snapshot. The connection is abandoned if the wait
+		// runs out first, which also releases the snapshot
+		if err := oConn.AwaitMempool(waitCtx); err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				respondJson(c, 200, resp)
+				return
+			}
+			respondNodeError(c, err)
+			return
+		}
 	}
-	c.JSON(200, resp)
+	_ = oConn.ReleaseMempool(ctx)
+	respondJson(c, 200, resp)
+}
+
+type requestLocalTxMonitorTxs struct {
+	IncludeCbor bool     `form:"include_cbor"`
+	Limit       uint     `form:"limit"`
+	Addresses   []string `form:"address"`
 }
 
 type responseLocalTxMonitorTxs struct {
-	TxHash  string `json:"tx_hash"  swaggertype:"string" format:"base16" example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
-	TxBytes []byte `json:"tx_bytes" swaggertype:"string" format:"base64" example:"<base64 encoded transaction bytes>"`
+	Slot      uint64                     `json:"slot"      example:"134217728"`
+	TxCount   uint32                     `json:"tx_count"  example:"42"`
+	Truncated bool                       `json:"truncated" example:"false"`
+	Txs       []responseLocalTxMonitorTx `json:"txs"`
+}
+
+type responseLocalTxMonitorTx struct {
+	TxId           string         `json:"tx_id"                     example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Size           int            `json:"size"                      example:"512"`
+	Era            string         `json:"era"                       example:"conway"`
+	Cbor           string         `json:"cbor,omitempty"            example:"84a400..."`
+	MatchedOutputs []responseUtxo `json:"matched_outputs,omitempty"`
 }
 
 // handleLocalTxMonitorTxs godoc
 //
-//	@Summary	List all transactions in the mempool
-//	@Tags		localtxmonitor
-//	@Accept		json
-//	@Produce	json
-//	@Success	200	{object}	[]responseLocalTxMonitorTxs
-//	@Failure	500	{object}	responseApiError
-//	@Router		/localtxmonitor/txs [get]
+//	@Summary		List all transactions in the mempool
+//	@Description	Returns the transactions in a mempool snapshot, with their ID, size in bytes, and era, and their CBOR in hex if include_cbor is set. The slot is the chain tip that the snapshot is for, and tx_count is the number of transactions in the snapshot. The limit stops listing transactions early, and the response is marked as truncated if there were more. The era of a transaction that can't be decoded is unknown. With one or more addresses, only the transactions with an output to one of them are listed, along with the outputs that matched, and the limit applies to the matching transactions. Returns a CBOR array of the raw transactions for the cbor and hex formats.
+//	@Tags			localtxmonitor
+//	@Accept			json
+//	@Produce		json,application/cbor,plain
+//	@Param			format			query		string	false	"response format, which overrides the Accept header"	Enums(json, cbor, hex)
+//	@Param			include_cbor	query		bool	false	"Whether to include the transaction CBOR"
+//	@Param			limit			query		integer		false	"Max number of transactions to list, or 0 for all of them"
+//	@Param			address			query		[]string	false	"Only list transactions with an output to one of these addresses"	collectionFormat(multi)
+//	@Success		200				{object}	responseLocalTxMonitorTxs
+//	@Failure		400				{object}	responseApiError
+//	@Failure		500				{object}	responseApiError
+//	@Failure		503				{object}	responseApiError
+//	@Failure		504				{object}	responseApiError
+//	@Router			/localtxmonitor/txs [get]
 func handleLocalTxMonitorTxs(c *gin.Context) {
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	var req requestLocalTxMonitorTxs
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	addresses, err := parseMempoolAddresses(req.Addresses)
 	if err != nil {
-		c.JSON(500, apiError(err.Error()))
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
 		return
 	}
-	// Async error handler
-	go func() {
-		err, ok := <-oConn.ErrorChan()
-		if !ok {
-			return
-		}
-		c.JSON(500, apiError(err.Error()))
-	}()
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalTxMonitor().Client.Start()
-	// Collect TX hashes
-	resp := []responseLocalTxMonitorTxs{}
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	// Acquire a mempool snapshot
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	slot, ok := queryMempoolSlot(c, oConn)
+	if !ok {
+		return
+	}
+	sizes, err := node.GetMempoolSizes(ctx, oConn)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	// Collect TXs
+	resp := responseLocalTxMonitorTxs{
+		Slot:    slot,
+		TxCount: sizes.TxCount,
+		Txs:     []responseLocalTxMonitorTx{},
+	}
+	rawTxs := []cbor.RawMessage{}
 	for {
-		txRawBytes, err := oConn.LocalTxMonitor().Client.NextTx()
+		// The limit applies to the matching TXs when filtering by address, so we
+		// need all of them
+		if len(addresses) == 0 && req.Limit > 0 &&
+			uint(len(rawTxs)) >= req.Limit {
+			resp.Truncated = uint(len(rawTxs)) < uint(sizes.TxCount)
+			break
+		}
+		txRawBytes, err := node.Call(
+			ctx,
+			oConn,
+			localtxmonitor.ProtocolName,
+			"next-tx",
+			oConn.LocalTxMonitor().Client.NextTx,
+		)
 		if err != nil {
-			c.JSON(500, apiError(err.Error()))
+			respondNodeError(c, err)
 			return
 		}
 		if txRawBytes == nil {
 			break
 		}
-		// Determine transaction type (era)
-		txType, err := ledger.DetermineTransactionType(txRawBytes)
+		rawTxs = append(rawTxs, cbor.RawMessage(txRawBytes))
+	}
+	// Release the snapshot before building the response, so that the node isn't
+	// holding it for us any longer than needed
+	_ = oConn.ReleaseMempool(ctx)
+	// Filter by address
+	var matchedOutputs [][]responseUtxo
+	if len(addresses) > 0 {
+		var matchedTxs []cbor.RawMessage
+		for _, txRawBytes := range rawTxs {
+			txId, err := mempoolTxId(txRawBytes)
+			if err != nil {
+				continue
+			}
+			matched := matchMempoolTxOutputs(
+				mempoolTxOutputs.get(slot, txId, txRawBytes),
+				addresses,
+			)
+			if len(matched) == 0 {
+				continue
+			}
+			if req.Limit > 0 && uint(len(matchedTxs)) >= req.Limit {
+				resp.Truncated = true
+				break
+			}
+			matchedTxs = append(matchedTxs, txRawBytes)
+			matchedOutputs = append(matchedOutputs, matched)
+		}
+		rawTxs = matchedTxs
+	}
+	// Send raw transactions if requested
+	if responseFormat(c) != responseFormatJson {
+		cborData, err := cbor.Encode(rawTxs)
 		if err != nil {
-			c.JSON(500, apiError(err.Error()))
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
 			return
 		}
-		tx, err := ledger.NewTransactionFromCbor(txType, txRawBytes)
+		respondCbor(c, 200, cborData)
+		return
+	}
+	for idx, txRawBytes := range rawTxs {
+		tmpTx, err := newResponseLocalTxMonitorTx(txRawBytes, req.IncludeCbor)
 		if err != nil {
-			c.JSON(500, apiError(err.Error()))
+			respondError(
+				c,
+				500,
+				apiErrorCode(errorCodeInternal, err.Error(), nil),
+			)
 			return
 		}
-		// Add to response
-		resp = append(
-			resp,
-			responseLocalTxMonitorTxs{
-				TxHash:  tx.Hash(),
-				TxBytes: txRawBytes,
-			},
-		)
+		if matchedOutputs != nil {
+			tmpTx.MatchedOutputs = matchedOutputs[idx]
+		}
+		resp.Txs = append(resp.Txs, tmpTx)
 	}
 	// Send response
-	c.JSON(200, resp)
+	respondJson(c, 200, resp)
+}
+
+type requestLocalTxMonitorTx struct {
+	TxHash string `uri:"tx_hash" binding:"required"`
+}
+
+type responseLocalTxMonitorTxCbor struct {
+	TxId string `json:"tx_id" example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Size int    `json:"size"  example:"512"`
+	Slot uint64 `json:"slot"  example:"134217728"`
+	Cbor string `json:"cbor"  example:"84a400..."`
+}
+
+// handleLocalTxMonitorTx godoc
+//
+//	@Summary		Get a transaction from the mempool
+//	@Description	Returns a transaction from a mempool snapshot, with its size in bytes and its CBOR in hex. The slot is the chain tip that the snapshot is for. Returns the raw transaction for the cbor and hex formats.
+//	@Tags			localtxmonitor
+//	@Produce		json,application/cbor,plain
+//	@Param			tx_hash	path		string	true	"Transaction ID"
+//	@Param			format	query		string	false	"response format, which overrides the Accept header"	Enums(json, cbor, hex)
+//	@Success		200		{object}	responseLocalTxMonitorTxCbor
+//	@Failure		400		{object}	responseApiError
+//	@Failure		404		{object}	responseApiError
+//	@Failure		500		{object}	responseApiError
+//	@Failure		503		{object}	responseApiError
+//	@Failure		504		{object}	responseApiError
+//	@Router			/localtxmonitor/tx/{tx_hash} [get]
+func handleLocalTxMonitorTx(c *gin.Context) {
+	var req requestLocalTxMonitorTx
+	if err := c.ShouldBindUri(&req); err != nil {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	txHash, err := hex.DecodeString(req.TxHash)
+	if err != nil || len(txHash) != blake2b.Size256 {
+		respondError(
+			c,
+			http.StatusBadRequest,
+			apiErrorCode(
+				errorCodeBadRequest,
+				"invalid transaction ID, should be 32 bytes of hex",
+				nil,
+			),
+		)
+		return
+	}
+	txId := hex.EncodeToString(txHash)
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	// Acquire a mempool snapshot
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	// Check for the TX first, so that we only look through the mempool for TXs
+	// that are there
+	hasTx, err := node.Call(
+		ctx,
+		oConn,
+		localtxmonitor.ProtocolName,
+		"has-tx",
+		func() (bool, error) {
+			return oConn.LocalTxMonitor().Client.HasTx(txHash)
+		},
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return
+	}
+	var txCbor []byte
+	var slot uint64
+	if hasTx {
+		var ok bool
+		if slot, ok = queryMempoolSlot(c, oConn); !ok {
+			return
+		}
+		for {
+			txRawBytes, err := node.Call(
+				ctx,
+				oConn,
+				localtxmonitor.ProtocolName,
+				"next-tx",
+				oConn.LocalTxMonitor().Client.NextTx,
+			)
+			if err != nil {
+				respondNodeError(c, err)
+				return
+			}
+			if txRawBytes == nil {
+				break
+			}
+			if tmpTxId, err := mempoolTxId(txRawBytes); err == nil && tmpTxId == txId {
+				txCbor = txRawBytes
+				break
+			}
+		}
+	}
+	_ = oConn.ReleaseMempool(ctx)
+	if txCbor == nil {
+		respondError(
+			c,
+			http.StatusNotFound,
+			apiErrorCode(
+				errorCodeNotInMempool,
+				fmt.Sprintf("transaction %s is not in the mempool", txId),
+				nil,
+			),
+		)
+		return
+	}
+	c.Set(contextKeyTxHash, txId)
+	// Send the raw transaction if requested
+	if responseFormat(c) != responseFormatJson {
+		respondCbor(c, 200, txCbor)
+		return
+	}
+	respondJson(c, 200, responseLocalTxMonitorTxCbor{
+		TxId: txId,
+		Size: len(txCbor),
+		Slot: slot,
+		Cbor: hex.EncodeToString(txCbor),
+	})
+}
+
+// queryMempoolSlot returns the slot for the mempool snapshot that was just
+// acquired. gouroboros doesn't expose the slot from the acquire reply, so we use
+// the chain tip right after acquiring it. An error response has been sent if it
+// returns false
+func queryMempoolSlot(c *gin.Context, oConn *node.PooledConnection) (uint64, bool) {
+	ctx := c.Request.Context()
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		respondAcquireError(c, err)
+		return 0, false
+	}
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
+	if err != nil {
+		respondNodeError(c, err)
+		return 0, false
+	}
+	_ = oConn.ReleaseLocalState(ctx)
+	return point.Slot, true
+}
+
+// newResponseLocalTxMonitorTx returns a mempool TX. A TX that isn't in an era
+// that we can decode is still listed, with an unknown era
+func newResponseLocalTxMonitorTx(
+	txCbor []byte,
+	includeCbor bool,
+) (responseLocalTxMonitorTx, error) {
+	ret := responseLocalTxMonitorTx{
+		Size: len(txCbor),
+		Era:  mempoolTxEraUnknown,
+	}
+	if includeCbor {
+		ret.Cbor = hex.EncodeToString(txCbor)
+	}
+	if txType, err := ledger.DetermineTransactionType(txCbor); err == nil {
+		ret.Era = strings.ToLower(ledger.GetEraById(uint8(txType)).Name)
+	}
+	txId, err := mempoolTxId(txCbor)
+	if err != nil {
+		return ret, err
+	}
+	ret.TxId = txId
+	return ret, nil
+}
+
+// mempoolTxId returns the ID of a mempool TX from its raw body, without parsing
+// the rest of it
+func mempoolTxId(txCbor []byte) (string, error) {
+	var txItems []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txItems); err != nil || len(txItems) == 0 {
+		return "", fmt.Errorf("failed to decode mempool transaction: %v", err)
+	}
+	hash := blake2b.Sum256(txItems[0])
+	return hex.EncodeToString(hash[:]), nil
 }
@@ -15,53 +15,210 @@
 package api
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
 	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
-	"github.com/blinklabs-io/tx-submit-api/submit"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/blake2b"
 
 	"github.com/blinklabs-io/cardano-node-api/internal/config"
 	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
 )
 
-func configureLocalTxSubmissionRoutes(apiGroup *gin.RouterGroup) {
+// Statuses of a submitted TX that the node accepted into its mempool, or that
+// was already there
+const (
+	txStatusAccepted         = "accepted"
+	txStatusAlreadySubmitted = "already_submitted"
+)
+
+// responseTxTooLarge is the size of a TX that's over the max TX size, in bytes
+type responseTxTooLarge struct {
+	Size    uint64 `json:"size"     example:"16500"`
+	MaxSize uint64 `json:"max_size" example:"16384"`
+	OverBy  uint64 `json:"over_by"  example:"116"`
+}
+
+type responseLocalTxSubmission struct {
+	TxId   string `json:"tx_id"  example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Status string `json:"status" example:"accepted" enums:"accepted,already_submitted"`
+}
+
+// The idempotency cache is shared by the versioned and unversioned routes
+var (
+	txSubmitIdempotencyOnce  sync.Once
+	txSubmitIdempotencyCache *idempotencyCache
+)
+
+func configureLocalTxSubmissionRoutes(apiGroup *gin.RouterGroup, version int) {
 	group := apiGroup.Group("/localtxsubmission")
-	group.POST("/tx", handleLocalSubmitTx)
+	cfg := config.GetConfig()
+	if cfg.Api.Idempotency.CacheSize > 0 {
+		txSubmitIdempotencyOnce.Do(func() {
+			txSubmitIdempotencyCache = newIdempotencyCache(
+				int(cfg.Api.Idempotency.CacheSize),
+				time.Duration(cfg.Api.Idempotency.CacheTtl)*time.Second,
+			)
+		})
+	}
+	// Waits aren't covered by idempotency, since a retry should wait again rather
+	// than get the response to an earlier wait that timed out
+	group.POST(
+		"/tx",
+		txSubmitHandlers(cfg.Api.MaxTxSubmitBytes, true, handleLocalSubmitTx)...,
+	)
+	group.POST(
+		"/tx/wait",
+		txSubmitHandlers(cfg.Api.MaxTxSubmitBytes, false, handleLocalSubmitTxWait)...,
+	)
+	group.POST(
+		"/txs",
+		txSubmitHandlers(cfg.Api.MaxTxBatchBytes, true, handleLocalSubmitTxs)...,
+	)
+	// Evaluations can have additional UTxOs, so they get the batch size limit
+	group.POST(
+		"/evaluate",
+		txSubmitHandlers(cfg.Api.MaxTxBatchBytes, false, handleLocalEvaluateTx)...,
+	)
+}
+
+// txSubmitHandlers returns the handler chain for a TX submission route, with the
+// body size limit for the route and the optional debug logging and idempotency
+func txSubmitHandlers(
+	maxBodyBytes uint,
+	idempotent bool,
+	handler gin.HandlerFunc,
+) []gin.HandlerFunc {
+	cfg := config.GetConfig()
+	// This comes first so that the debug logging doesn't read too much
+	handlers := []gin.HandlerFunc{maxBodyBytesMiddleware(int64(maxBodyBytes))}
+	if cfg.Logging.TxSubmitDebug {
+		handlers = append(
+			handlers,
+			txSubmitDebugMiddleware(int(cfg.Logging.TxSubmitDebugMaxBytes)),
+		)
+	}
+	if idempotent && txSubmitIdempotencyCache != nil {
+		handlers = append(
+			handlers,
+			idempotencyMiddleware(txSubmitIdempotencyCache),
+		)
+	}
+	return append(handlers, handler)
 }
 
 // handleLocalSubmitTx godoc
 //
 //	@Summary		Submit Tx
-//	@Description	Submit an already serialized transaction to the network.
+//	@Description	Submit an already serialized transaction to the network. The body is the transaction CBOR, either raw or encoded as hex (optionally with a 0x prefix) or standard or URL-safe base64, which are detected in that order. Whitespace in encoded bodies is ignored. The detected encoding is returned in the X-Tx-Encoding header. If the body can't be decoded, the error details list the attempted encodings. If the node rejects the transaction, the error details have its era and the failed ledger rules, decoded where known, along with the rejection CBOR. Before the transaction is sent to the node, it is checked against the current protocol parameters and slot. It must be for a supported era, be within the max TX size unless that check is disabled, have witnesses, not be past its TTL, and pay at least the min fee for its size. Each failed check has its own error code. The skip_validation query parameter skips these checks. Retries with the same Idempotency-Key header and body get the response to the first request, unless it was a server error. If callbacks are enabled, the X-Callback-Url header sets a URL that is sent a POST once the transaction has the configured number of confirmations, or when it expires without being included. The callback body is JSON with the event (confirmed or expired), the transaction ID, and the block, and it is signed with an HMAC-SHA256 of the body in the X-Signature-256 header.
 //	@Produce		json
-//	@Param			Content-Type	header		string	true	"Content type"	Enums(application/cbor)
-//	@Success		202				{object}	string	"Ok"
-//	@Failure		400				{object}	string	"Bad Request"
-//	@Failure		415				{object}	string	"Unsupported Media Type"
-//	@Failure		500				{object}	string	"Server Error"
+//	@Param			Content-Type	header		string				true	"Content type"	Enums(application/cbor, application/octet-stream, text/plain)
+//	@Param			Idempotency-Key	header		string				false	"Key to identify retries of the same submission"
+//	@Param			X-Callback-Url	header		string				false	"URL to send a callback to when the transaction is confirmed"
+//	@Param			skip_validation	query		bool				false	"Whether to skip the checks before submitting"
+//	@Success		202				{object}	responseLocalTxSubmission	"Ok"
+//	@Failure		400				{object}	responseApiError	"Bad Request"
+//	@Failure		409				{object}	responseApiError	"Conflict"
+//	@Failure		413				{object}	responseApiError	"Request Entity Too Large"
+//	@Failure		415				{object}	responseApiError	"Unsupported Media Type"
+//	@Failure		500				{object}	responseApiError	"Server Error"
+//	@Failure		503				{object}	responseApiError	"Service Unavailable"
 //	@Router			/localtxsubmission/tx [post]
 func handleLocalSubmitTx(c *gin.Context) {
-	// First, initialize our configuration and loggers
-	cfg := config.GetConfig()
-	logger := logging.GetLogger()
-	// Check our headers for content-type
-	if c.ContentType() != "application/cbor" {
-		// Log the error, return an error to the user, and increment failed count
-		logger.Errorf("invalid request body, should be application/cbor")
-		c.JSON(415, "invalid request body, should be application/cbor")
-		// _ = ginmetrics.GetMonitor().GetMetric("tx_submit_fail_count").Inc(nil)
+	var prepare func(string, ledger.Transaction) bool
+	var callback *txCallback
+	if callbackUrl := c.GetHeader(txCallbackUrlHeader); callbackUrl != "" {
+		if callback = newTxCallback(c, callbackUrl); callback == nil {
+			return
+		}
+		prepare = func(txId string, tx ledger.Transaction) bool {
+			return callback.watch(c, txId, tx)
+		}
+	}
+	resp, ok := submitTx(c, prepare)
+	if callback != nil && callback.waiter != nil {
+		if !ok {
+			callback.stop()
+		} else {
+			go callback.run()
+		}
+	}
+	if !ok {
 		return
 	}
-	// Read raw transaction bytes from the request body and store in a byte array
-	txRawBytes, err := io.ReadAll(c.Request.Body)
+	respondJson(c, 202, resp)
+}
+
+// submitTx decodes the TX from the request body and sends it to the node. The
+// prepare func, if not nil, is called with the TX and its ID before it's sent, and
+// the submission is abandoned if it returns false. An error response has been
+// sent if it returns false
+func submitTx(
+	c *gin.Context,
+	prepare func(txId string, tx ledger.Transaction) bool,
+) (responseLocalTxSubmission, bool) {
+	// First, initialize our logger
+	logger := requestLogger(c, logging.ComponentApi)
+	var req requestTxValidation
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return responseLocalTxSubmission{}, false
+	}
+	// Check our headers for content-type. The encoding of the body is detected
+	// separately, since clients don't reliably label it
+	if c.ContentType() != mimeTypeCbor &&
+		c.ContentType() != mimeTypeOctetStream &&
+		c.ContentType() != mimeTypeText {
+		// Log the error and return an error to the user
+		logger.Errorf(
+			"invalid request body, should be application/cbor, application/octet-stream, or text/plain",
+		)
+		respondError(
+			c,
+			415,
+			apiErrorCode(
+				errorCodeUnsupportedMediaType,
+				"invalid request body, should be application/cbor, application/octet-stream, or text/plain",
+				nil,
+			),
+		)
+		return responseLocalTxSubmission{}, false
+	}
+	// Read the transaction from the request body and store in a byte array
+	reqBody, err := io.ReadAll(c.Request.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondRequestTooLarge(c, maxBytesErr.Limit)
+		return responseLocalTxSubmission{}, false
+	}
 	if err != nil {
-		// Log the error, return an error to the user, and increment failed count
+		// Log the error and return an error to the user
 		logger.Errorf("failed to read request body: %s", err)
-		c.JSON(500, "failed to read request body")
-		// _ = ginmetrics.GetMonitor().GetMetric("tx_submit_fail_count").Inc(nil)
-		return
+		respondError(
+			c,
+			500,
+			apiErrorCode(
+				errorCodeInternal,
+				"failed to read request body",
+				nil,
+			),
+		)
+		return responseLocalTxSubmission{}, false
 	}
 	// Close request body after read
 	if c.Request.Body != nil {
@@ -69,42 +226,228 @@ func handleLocalSubmitTx(c *gin.Context) {
 			logger.Errorf("failed to close request body: %s", err)
 		}
 	}
-	// Send TX
-	errorChan := make(chan error)
-	submitConfig := &submit.Config{
-		ErrorChan:    errorChan,
-		NetworkMagic: cfg.Node.NetworkMagic,
-		NodeAddress:  cfg.Node.Address,
-		NodePort:     cfg.Node.Port,
-		SocketPath:   cfg.Node.SocketPath,
-		Timeout:      cfg.Node.Timeout,
-	}
-	txHash, err := submit.SubmitTx(submitConfig, txRawBytes)
+	// Decode the TX from whichever encoding the client used
+	txRawBytes, txEncoding, attempts := decodeTxBody(reqBody)
+	if txRawBytes == nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidEncoding,
+				"could not decode transaction as raw CBOR, hex, or base64",
+				attempts,
+			),
+		)
+		return responseLocalTxSubmission{}, false
+	}
+	logger.Debugf("detected TX encoding: %s", txEncoding)
+	c.Header(txEncodingHeader, txEncoding)
+	// Parse the TX to determine its era and hash
+	txType, txId, tx, err := parseTx(txRawBytes)
 	if err != nil {
-		if c.GetHeader("Accept") == "application/cbor" {
-			txRejectErr := err.(localtxsubmission.TransactionRejectedError)
-			c.Data(400, "application/cbor", txRejectErr.ReasonCbor)
-		} else {
-			if err.Error() != "" {
-				c.JSON(400, err.Error())
-			} else {
-				c.JSON(400, fmt.Sprintf("%s", err))
-			}
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return responseLocalTxSubmission{}, false
+	}
+	// Record the TX ID for the access log, including for rejected submissions
+	c.Set(contextKeyTxHash, txId)
+	validation, ok := queryTxValidation(c, req.SkipValidation)
+	if !ok {
+		return responseLocalTxSubmission{}, false
+	}
+	if validation != nil {
+		if errResp := validation.check(txType, txRawBytes, tx); errResp != nil {
+			respondError(c, 400, *errResp)
+			return responseLocalTxSubmission{}, false
 		}
-		// _ = ginmetrics.GetMonitor().GetMetric("tx_submit_fail_count").Inc(nil)
-		return
 	}
-	// Start async error handler
-	go func() {
-		err, ok := <-errorChan
-		if ok {
+	if prepare != nil && !prepare(txId, tx) {
+		return responseLocalTxSubmission{}, false
+	}
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return responseLocalTxSubmission{}, false
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	if err := sendTx(ctx, oConn, txType, txRawBytes); err != nil {
+		var txRejectErr localtxsubmission.TransactionRejectedError
+		if !errors.As(err, &txRejectErr) {
 			logger.Errorf("failure communicating with node: %s", err)
-			c.JSON(500, "failure communicating with node")
-			// _ = ginmetrics.GetMonitor().GetMetric("tx_submit_fail_count").Inc(nil)
+			respondNodeError(c, err)
+		} else if config.GetConfig().Api.Idempotency.DuplicateSuccess &&
+			txInMempool(c, oConn, txId) {
+			// The TX was already submitted, probably by an earlier attempt
+			return responseLocalTxSubmission{
+				TxId:   txId,
+				Status: txStatusAlreadySubmitted,
+			}, true
+		} else if c.GetHeader("Accept") == "application/cbor" {
+			c.Data(400, "application/cbor", txRejectErr.ReasonCbor)
+		} else {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeTxRejected,
+					err.Error(),
+					newResponseTxRejection(txRejectErr.ReasonCbor),
+				),
+			)
 		}
-	}()
-	// Return transaction ID
-	c.JSON(202, txHash)
-	// Increment custom metric
-	// _ = ginmetrics.GetMonitor().GetMetric("tx_submit_count").Inc(nil)
+		return responseLocalTxSubmission{}, false
+	}
+	return responseLocalTxSubmission{TxId: txId, Status: txStatusAccepted}, true
+}
+
+// parseTx checks that the TX CBOR is a TX that we can parse, and returns its type,
+// ID, and the parsed TX
+func parseTx(txCbor []byte) (uint, string, ledger.Transaction, error) {
+	txType, err := ledger.DetermineTransactionType(txCbor)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf(
+			"could not parse transaction to determine type: %s",
+			err,
+		)
+	}
+	tx, err := ledger.NewTransactionFromCbor(txType, txCbor)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf(
+			"failed to parse transaction CBOR: %s",
+			err,
+		)
+	}
+	txId, err := transactionId(txType, txCbor)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to get transaction ID: %s", err)
+	}
+	return txType, txId, tx, nil
+}
+
+// sendTx submits a TX to the node and records the result in the metrics.
+// Rejections are returned as localtxsubmission.TransactionRejectedError
+func sendTx(
+	ctx context.Context,
+	oConn *node.PooledConnection,
+	txType uint,
+	txCbor []byte,
+) error {
+	start := time.Now()
+	err := node.Run(
+		ctx,
+		oConn,
+		localtxsubmission.ProtocolName,
+		"submit",
+		func() error {
+			return oConn.LocalTxSubmission().Client.SubmitTx(
+				uint16(txType),
+				txCbor,
+			)
+		},
+	)
+	duration := time.Since(start)
+	var txRejectErr localtxsubmission.TransactionRejectedError
+	switch {
+	case err == nil:
+		recordTxSubmit(len(txCbor), duration, txSubmitResultAccepted, nil)
+	case errors.As(err, &txRejectErr):
+		recordTxSubmit(
+			len(txCbor),
+			duration,
+			txSubmitResultRejected,
+			txRejectErr.ReasonCbor,
+		)
+	default:
+		recordTxSubmit(len(txCbor), duration, txSubmitResultError, nil)
+	}
+	return err
+}
+
+// transactionId returns the ID of a signed TX, which is the hash of its body as
+// it was encoded in the TX. The body is the first item in the TX array, which
+// has 3 items before Alonzo and 4 after
+func transactionId(txType uint, txCbor []byte) (string, error) {
+	var txItems []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txItems); err != nil {
+		return "", err
+	}
+	expectedItems := 4
+	switch txType {
+	case ledger.TxTypeShelley, ledger.TxTypeAllegra, ledger.TxTypeMary:
+		expectedItems = 3
+	}
+	if len(txItems) != expectedItems {
+		return "", fmt.Errorf(
+			"expected %d items in the transaction, got %d",
+			expectedItems,
+			len(txItems),
+		)
+	}
+	hash := blake2b.Sum256(txItems[0])
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// txTooLargeError returns the error for a TX that's over the max TX size, or nil
+// if it isn't or the max size is 0
+func txTooLargeError(txCbor []byte, maxSize uint64) *responseApiError {
+	size := uint64(len(txCbor))
+	if maxSize == 0 || size <= maxSize {
+		return nil
+	}
+	ret := apiErrorCode(
+		errorCodeTxTooLarge,
+		fmt.Sprintf(
+			"transaction is %d bytes, which is %d bytes over the max TX size of %d bytes",
+			size,
+			size-maxSize,
+			maxSize,
+		),
+		responseTxTooLarge{
+			Size:    size,
+			MaxSize: maxSize,
+			OverBy:  size - maxSize,
+		},
+	)
+	return &ret
+}
+
+// txInMempool returns whether the node's mempool has the TX. Failures are treated
+// as it not being there
+func txInMempool(
+	c *gin.Context,
+	oConn *node.PooledConnection,
+	txId string,
+) bool {
+	logger := requestLogger(c, logging.ComponentApi)
+	txHash, err := hex.DecodeString(txId)
+	if err != nil {
+		return false
+	}
+	ctx := c.Request.Context()
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		logger.Debugf("failed to acquire mempool: %s", err)
+		return false
+	}
+	hasTx, err := node.Call(
+		ctx,
+		oConn,
+		localtxmonitor.ProtocolName,
+		"has-tx",
+		func() (bool, error) {
+			return oConn.LocalTxMonitor().Client.HasTx(txHash)
+		},
+	)
+	if err != nil {
+		logger.Debugf("failed to check mempool for TX: %s", err)
+		return false
+	}
+	_ = oConn.ReleaseMempool(ctx)
+	return hasTx
 }
@@ -0,0 +1,152 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Witnesses and metadata to swap into the test TXs, as hex. The witness set has
+// a vkey witness with key 0x55 bytes, and the metadata is {674: {"msg": ["hello"]}}
+const (
+	testOtherWitnessesHex = "a10081825820555555555555555555555555555555555555555555555555555555555555555558406666666666666666" +
+		"666666666666666666666666666666666666666666666666666666666666666666666666666666666666666666666666" +
+		"6666666666666666"
+	testMetadataHex = "a11902a2a1636d7367816568656c6c6f"
+)
+
+// The expected IDs are the blake2b-256 hashes of the body bytes, which were
+// worked out separately from the code under test
+func TestTransactionId(t *testing.T) {
+	testDefs := []struct {
+		name    string
+		txType  uint
+		txHex   string
+		wantId  string
+		wantErr bool
+	}{
+		{
+			name:   "shelley",
+			txType: ledger.TxTypeShelley,
+			txHex:  testTxShelleyHex,
+			wantId: "2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+		},
+		{
+			name:   "allegra",
+			txType: ledger.TxTypeAllegra,
+			txHex:  testTxAllegraHex,
+			wantId: "4e2a5d34c7ed0966275c94653b534f0cbe5b74d953d6998b666d8c5293191f30",
+		},
+		{
+			name:   "mary",
+			txType: ledger.TxTypeMary,
+			txHex:  testTxMaryHex,
+			wantId: "90d240f40dc93b66fbeae49be2a3c20dcf3956f269e64dafd9c7d6cd84b56b89",
+		},
+		{
+			name:   "alonzo",
+			txType: ledger.TxTypeAlonzo,
+			txHex:  testTxAlonzoHex,
+			wantId: "917a798f21409aeeb59a1c5036ccceae73cd33b368b9c7ac361c84118bd51a46",
+		},
+		{
+			name:   "babbage",
+			txType: ledger.TxTypeBabbage,
+			txHex:  testTxBabbageHex,
+			wantId: "c7b25267409e2fd9c6df7e13d011f9a1352df325a3a20bc25958d0d18e259a09",
+		},
+		{
+			name:   "conway",
+			txType: ledger.TxTypeConway,
+			txHex:  testTxConwayHex,
+			wantId: "da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+		},
+		{
+			// [body, witnesses, metadata]
+			name:   "shelley witnesses and metadata not hashed",
+			txType: ledger.TxTypeShelley,
+			txHex:  "83" + testTxShelleyBodyHex + testOtherWitnessesHex + testMetadataHex,
+			wantId: "2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+		},
+		{
+			// [body, witnesses, is valid, auxiliary data]
+			name:   "conway witnesses and auxiliary data not hashed",
+			txType: ledger.TxTypeConway,
+			txHex:  "84" + testTxConwayBodyHex + testOtherWitnessesHex + "f4" + testMetadataHex,
+			wantId: "da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+		},
+		{
+			name:   "body hashed as encoded",
+			txType: ledger.TxTypeShelley,
+			// The TTL isn't in its shortest form, which changes the ID
+			txHex: "83" + strings.Replace(
+				testTxShelleyBodyHex,
+				"03191388",
+				"031a00001388",
+				1,
+			) + "a0f6",
+			wantId: "48d0aa73ceea122f0aaa9b0a5c1be844c6c5f0d81b50cfc862f820326b1c17c0",
+		},
+		{
+			name:    "4 items before alonzo",
+			txType:  ledger.TxTypeMary,
+			txHex:   "84a10001a0f5f6",
+			wantErr: true,
+		},
+		{
+			name:    "3 items after mary",
+			txType:  ledger.TxTypeBabbage,
+			txHex:   "83a10001a0f6",
+			wantErr: true,
+		},
+		{
+			name:    "not a list",
+			txType:  ledger.TxTypeConway,
+			txHex:   "a10001",
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			txType:  ledger.TxTypeConway,
+			txHex:   "84a10001a0",
+			wantErr: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			txCbor, err := hex.DecodeString(testDef.txHex)
+			if err != nil {
+				t.Fatalf("bad test TX hex: %s", err)
+			}
+			txId, err := transactionId(testDef.txType, txCbor)
+			if testDef.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got TX ID %s", txId)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if txId != testDef.wantId {
+				t.Fatalf("unexpected TX ID: %s", txId)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+func TestMain(m *testing.M) {
+	logging.Setup(&config.GetConfig().Logging)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// serveTestRequest sends a request to a handler registered for a route path and
+// returns the response
+func serveTestRequest(
+	method string,
+	routePath string,
+	handler gin.HandlerFunc,
+	url string,
+	body io.Reader,
+) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(method, routePath, handler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, url, body)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// startLedgerQueryMockNode starts a mock node that acquires the volatile tip and
+// then answers each of the queries with the matching result, both given as hex
+func startLedgerQueryMockNode(t *testing.T, queries []string, results []string) {
+	t.Helper()
+	conversation := []ouroboros_mock.ConversationEntry{
+		nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+		nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+	}
+	for idx, query := range queries {
+		conversation = append(
+			conversation,
+			nodetest.LsqQuery(query),
+			nodetest.LsqResult(t, results[idx]),
+		)
+	}
+	nodetest.StartMockNode(t, 16, conversation)
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// mempoolTxOutputCache holds the decoded outputs of mempool TXs by TX ID, so that
+// filtering the mempool by address doesn't decode every TX for every request. A
+// TX ID always has the same outputs, so the snapshot slot is only used to drop
+// entries: they're kept for the slot of the snapshot that they were last seen
+// in and the slot after it, since TXs leave the mempool as blocks are added
+type mempoolTxOutputCache struct {
+	mutex    sync.Mutex
+	slot     uint64
+	current  map[string][]responseUtxo
+	previous map[string][]responseUtxo
+}
+
+var mempoolTxOutputs = &mempoolTxOutputCache{}
+
+// get returns the outputs of a TX in a mempool snapshot for the slot, decoding
+// the TX if it isn't cached. A TX or output that can't be decoded is left out
+func (m *mempoolTxOutputCache) get(
+	slot uint64,
+	txId string,
+	txCbor []byte,
+) []responseUtxo {
+	m.mutex.Lock()
+	if m.current == nil || slot > m.slot {
+		m.slot = slot
+		m.previous = m.current
+		m.current = map[string][]responseUtxo{}
+	}
+	outputs, ok := m.current[txId]
+	if !ok {
+		if outputs, ok = m.previous[txId]; ok {
+			m.current[txId] = outputs
+		}
+	}
+	m.mutex.Unlock()
+	if ok {
+		return outputs
+	}
+	// Decode without holding the lock, since it's the slow part
+	outputs = decodeMempoolTxOutputs(txId, txCbor)
+	m.mutex.Lock()
+	// Snapshots from before the last slot change don't add entries, since they'd
+	// only be dropped by the next one
+	if slot == m.slot {
+		m.current[txId] = outputs
+	}
+	m.mutex.Unlock()
+	return outputs
+}
+
+// decodeMempoolTxOutputs returns the outputs of a mempool TX
+func decodeMempoolTxOutputs(txId string, txCbor []byte) []responseUtxo {
+	ret := []responseUtxo{}
+	txHash, err := hex.DecodeString(txId)
+	if err != nil {
+		return ret
+	}
+	txType, err := ledger.DetermineTransactionType(txCbor)
+	if err != nil {
+		return ret
+	}
+	tx, err := ledger.NewTransactionFromCbor(txType, txCbor)
+	if err != nil {
+		return ret
+	}
+	for idx, output := range tx.Outputs() {
+		utxo, err := newResponseTxOutput(txHash, idx, output)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, utxo)
+	}
+	return ret
+}
+
+// matchMempoolTxOutputs returns the outputs that pay to one of the addresses
+func matchMempoolTxOutputs(
+	outputs []responseUtxo,
+	addresses map[string]bool,
+) []responseUtxo {
+	var ret []responseUtxo
+	for _, output := range outputs {
+		if addresses[output.Address] {
+			ret = append(ret, output)
+		}
+	}
+	return ret
+}
+
+// parseMempoolAddresses returns the set of addresses to filter mempool TXs by, in
+// the form that the outputs use
+func parseMempoolAddresses(addrStrs []string) (map[string]bool, error) {
+	ret := make(map[string]bool, len(addrStrs))
+	for _, addrStr := range addrStrs {
+		addr, err := ledger.NewAddress(addrStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %s", addrStr, err)
+		}
+		ret[addr.String()] = true
+	}
+	return ret, nil
+}
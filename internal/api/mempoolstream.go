@@ -0,0 +1,228 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Mempool stream event types
+const (
+	mempoolEventSnapshot  = "snapshot"
+	mempoolEventTxAdded   = "tx_added"
+	mempoolEventTxRemoved = "tx_removed"
+	mempoolEventError     = "error"
+)
+
+// Number of mempool streams being served
+var mempoolStreams atomic.Int64
+
+type responseLocalTxMonitorStreamTx struct {
+	TxId string `json:"tx_id" example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Size int    `json:"size"  example:"435"`
+	Slot uint64 `json:"slot"  example:"134217728"`
+}
+
+type responseLocalTxMonitorStreamError struct {
+	Error string `json:"error" example:"lost connection to node"`
+}
+
+// mempoolEvent is an event on a mempool stream, with the data to send for it.
+// TX events also have the TX CBOR, so that websocket filters can match on it
+type mempoolEvent struct {
+	Type   string
+	Data   any
+	txCbor []byte
+}
+
+// handleLocalTxMonitorStream godoc
+//
+//	@Summary		Stream mempool changes
+//	@Description	Streams server-sent events for the mempool. A snapshot event with the slot, sizes, and TX count is sent each time the mempool changes, followed by tx_removed and tx_added events for the transactions that left or entered it since the last snapshot. The first snapshot lists every transaction in the mempool as added. Events aren't replayed, so a client that reconnects starts over from the current mempool, and Last-Event-ID is ignored. Heartbeat comments are sent while the mempool is idle. An error event is sent if the connection to the node is lost, and the stream ends. A shutdown event is sent before the stream ends when the server shuts down.
+//	@Tags			localtxmonitor
+//	@Produce		text/event-stream
+//	@Success		200
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localtxmonitor/stream [get]
+func handleLocalTxMonitorStream(c *gin.Context) {
+	// Track this handler so that shutdown waits for it
+	defer trackStream()()
+	// The request context is cancelled when the client goes away, which closes
+	// the node connection
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, ok := startMempoolStream(c, ctx)
+	if !ok {
+		return
+	}
+	defer mempoolStreams.Add(-1)
+	c.Header(nodeEndpointHeader, stream.Endpoint())
+	logger := requestLogger(c, logging.ComponentApi)
+	startSse(c, logger)
+	logger.Debugf("starting mempool stream")
+	defer logger.Debugf("mempool stream closed")
+	heartbeatInterval := time.Duration(
+		config.GetConfig().Api.MempoolStream.HeartbeatInterval,
+	) * time.Second
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	var diff mempoolDiff
+	for {
+		select {
+		case <-streamShutdownChan():
+			// Let the client know that we're going away
+			sendSseShutdown(c)
+			return
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if !sendSseHeartbeat(c) {
+				return
+			}
+		case snapshot, ok := <-stream.Snapshots():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					logger.Warnf("mempool stream failed: %s", err)
+					c.SSEvent(
+						mempoolEventError,
+						responseLocalTxMonitorStreamError{
+							Error: "lost connection to node",
+						},
+					)
+					c.Writer.Flush()
+				}
+				return
+			}
+			for _, evt := range diff.next(snapshot, logger) {
+				c.SSEvent(evt.Type, evt.Data)
+			}
+			c.Writer.Flush()
+			// Don't send a heartbeat right after the events
+			heartbeat.Reset(heartbeatInterval)
+		}
+	}
+}
+
+// startMempoolStream starts following the mempool for a stream request, if there
+// aren't already too many streams. The caller must decrement mempoolStreams when
+// the stream ends. An error response has been sent if it returns false
+func startMempoolStream(
+	c *gin.Context,
+	ctx context.Context,
+) (*node.MempoolStream, bool) {
+	maxStreams := config.GetConfig().Api.MempoolStream.MaxStreams
+	if mempoolStreams.Add(1) > int64(maxStreams) {
+		mempoolStreams.Add(-1)
+		respondError(
+			c,
+			http.StatusServiceUnavailable,
+			apiErrorCode(
+				errorCodeStreamLimit,
+				"too many mempool streams are open",
+				nil,
+			),
+		)
+		return nil, false
+	}
+	stream, err := node.StartMempoolStream(ctx)
+	if err != nil {
+		mempoolStreams.Add(-1)
+		respondNodeUnavailable(c, err)
+		return nil, false
+	}
+	return stream, true
+}
+
+// mempoolDiff finds the TXs that were added to and removed from the mempool
+// between consecutive snapshots, by TX ID
+type mempoolDiff struct {
+	txIds  []string
+	txCbor map[string][]byte
+}
+
+// next returns the events for a snapshot, which are the snapshot itself followed
+// by the TXs that were removed since the last snapshot and the TXs that were
+// added. Every TX in the first snapshot is added
+func (d *mempoolDiff) next(
+	snapshot node.MempoolSnapshot,
+	logger *logging.Logger,
+) []mempoolEvent {
+	events := []mempoolEvent{
+		{
+			Type: mempoolEventSnapshot,
+			Data: responseLocalTxMonitorSizes{
+				Capacity: snapshot.Sizes.Capacity,
+				Size:     snapshot.Sizes.Size,
+				TxCount:  snapshot.Sizes.TxCount,
+				Utilization: math.Round(
+					snapshot.Sizes.Utilization()*100,
+				) / 100,
+				Slot: snapshot.Slot,
+			},
+		},
+	}
+	txIds := make([]string, 0, len(snapshot.Txs))
+	txCbor := make(map[string][]byte, len(snapshot.Txs))
+	for _, tmpTxCbor := range snapshot.Txs {
+		txId, err := mempoolTxId(tmpTxCbor)
+		if err != nil {
+			logger.Debugf("skipping mempool TX: %s", err)
+			continue
+		}
+		txIds = append(txIds, txId)
+		txCbor[txId] = tmpTxCbor
+	}
+	txEvent := func(evtType string, txId string, cbor []byte) mempoolEvent {
+		return mempoolEvent{
+			Type: evtType,
+			Data: responseLocalTxMonitorStreamTx{
+				TxId: txId,
+				Size: len(cbor),
+				Slot: snapshot.Slot,
+			},
+			txCbor: cbor,
+		}
+	}
+	for _, txId := range d.txIds {
+		if _, ok := txCbor[txId]; !ok {
+			events = append(
+				events,
+				txEvent(mempoolEventTxRemoved, txId, d.txCbor[txId]),
+			)
+		}
+	}
+	for _, txId := range txIds {
+		if _, ok := d.txCbor[txId]; !ok {
+			events = append(
+				events,
+				txEvent(mempoolEventTxAdded, txId, txCbor[txId]),
+			)
+		}
+	}
+	d.txIds = txIds
+	d.txCbor = txCbor
+	return events
+}
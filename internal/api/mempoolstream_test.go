@@ -0,0 +1,175 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Mempool TXs for the tests, which only need a body to hash, with their IDs
+var testMempoolTxs = map[string]struct {
+	cborHex string
+	txId    string
+}{
+	"tx1": {
+		cborHex: "84a10001a0f5f6",
+		txId:    "74a96d312005ff5c1a43088e51f3f8981a4510eae6eb1dc8f389eb974dec07f2",
+	},
+	"tx2": {
+		cborHex: "84a10002a0f5f6",
+		txId:    "1b93e9460116fb033035a5718871e869e2f5507df2d24867fd3d7ec5c621c654",
+	},
+	"tx3": {
+		cborHex: "84a10003a0f5f6",
+		txId:    "fd020230cf31b077ab74beceb44f9a371a29e67a841eafd632ba9b25d2bb24bd",
+	},
+	// Not a TX, which is skipped
+	"invalid": {
+		cborHex: "01",
+	},
+}
+
+// testMempoolSnapshot returns a snapshot with the named TXs
+func testMempoolSnapshot(t *testing.T, slot uint64, txs []string) node.MempoolSnapshot {
+	t.Helper()
+	ret := node.MempoolSnapshot{
+		Slot: slot,
+		Sizes: node.MempoolSizes{
+			Capacity: 3000,
+			TxCount:  uint32(len(txs)),
+		},
+	}
+	for _, name := range txs {
+		txCbor, err := hex.DecodeString(testMempoolTxs[name].cborHex)
+		if err != nil {
+			t.Fatalf("bad test TX CBOR: %s", err)
+		}
+		ret.Txs = append(ret.Txs, txCbor)
+		ret.Sizes.Size += uint32(len(txCbor))
+	}
+	return ret
+}
+
+func TestMempoolDiffNext(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// TXs in the previous snapshot, or nil if this is the first
+		previous []string
+		current  []string
+		// Expected TX events after the snapshot event, as type and TX name
+		wantEvents [][2]string
+		// Expected utilization of the 3000 byte mempool, which is rounded
+		wantUtilization float64
+	}{
+		{
+			name:    "first snapshot adds all TXs",
+			current: []string{"tx1", "tx2"},
+			wantEvents: [][2]string{
+				{mempoolEventTxAdded, "tx1"},
+				{mempoolEventTxAdded, "tx2"},
+			},
+			wantUtilization: 0.47,
+		},
+		{
+			name:    "first snapshot empty",
+			current: []string{},
+		},
+		{
+			name:     "removed before added",
+			previous: []string{"tx1", "tx2"},
+			current:  []string{"tx2", "tx3"},
+			wantEvents: [][2]string{
+				{mempoolEventTxRemoved, "tx1"},
+				{mempoolEventTxAdded, "tx3"},
+			},
+			wantUtilization: 0.47,
+		},
+		{
+			name:            "unchanged",
+			previous:        []string{"tx1", "tx2"},
+			current:         []string{"tx2", "tx1"},
+			wantUtilization: 0.47,
+		},
+		{
+			name:     "emptied",
+			previous: []string{"tx1", "tx2"},
+			current:  []string{},
+			wantEvents: [][2]string{
+				{mempoolEventTxRemoved, "tx1"},
+				{mempoolEventTxRemoved, "tx2"},
+			},
+		},
+		{
+			name:     "skips invalid TX",
+			previous: []string{"tx1"},
+			current:  []string{"invalid", "tx1", "tx3"},
+			wantEvents: [][2]string{
+				{mempoolEventTxAdded, "tx3"},
+			},
+			wantUtilization: 0.5,
+		},
+	}
+	logger := logging.GetLogger(logging.ComponentApi)
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			var diff mempoolDiff
+			if testDef.previous != nil {
+				diff.next(testMempoolSnapshot(t, 100, testDef.previous), logger)
+			}
+			snapshot := testMempoolSnapshot(t, 200, testDef.current)
+			events := diff.next(snapshot, logger)
+			if len(events) != len(testDef.wantEvents)+1 {
+				t.Fatalf("got %d events, want %d", len(events), len(testDef.wantEvents)+1)
+			}
+			if events[0].Type != mempoolEventSnapshot {
+				t.Fatalf("first event is %s, want %s", events[0].Type, mempoolEventSnapshot)
+			}
+			sizes := events[0].Data.(responseLocalTxMonitorSizes)
+			if sizes.Slot != 200 || sizes.Size != snapshot.Sizes.Size ||
+				sizes.TxCount != snapshot.Sizes.TxCount {
+				t.Fatalf("unexpected snapshot event: %+v", sizes)
+			}
+			if sizes.Utilization != testDef.wantUtilization {
+				t.Fatalf("unexpected utilization: %v", sizes.Utilization)
+			}
+			for idx, wantEvent := range testDef.wantEvents {
+				evt := events[idx+1]
+				tx := testMempoolTxs[wantEvent[1]]
+				data := evt.Data.(responseLocalTxMonitorStreamTx)
+				if evt.Type != wantEvent[0] || data.TxId != tx.txId {
+					t.Fatalf(
+						"event %d is %s for %s, want %s for %s",
+						idx,
+						evt.Type,
+						data.TxId,
+						wantEvent[0],
+						wantEvent[1],
+					)
+				}
+				if data.Size != len(tx.cborHex)/2 || hex.EncodeToString(evt.txCbor) != tx.cborHex {
+					t.Fatalf("event %d has the wrong TX", idx)
+				}
+				// Removed TXs are reported at the slot they were found to be gone
+				if data.Slot != 200 {
+					t.Fatalf("event %d has slot %d", idx, data.Slot)
+				}
+			}
+		})
+	}
+}
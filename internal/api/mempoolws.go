@@ -0,0 +1,269 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+const (
+	// Time allowed to write a message or control frame to a websocket client
+	mempoolWsWriteTimeout = 10 * time.Second
+	// Largest filter message that we accept from a websocket client
+	mempoolWsMaxMessageBytes = 64 * 1024
+)
+
+type requestLocalTxMonitorWsFilter struct {
+	TxIds     []string `json:"tx_ids"`
+	Addresses []string `json:"addresses"`
+}
+
+type responseLocalTxMonitorWsMessage struct {
+	Type string `json:"type" example:"tx_added" enums:"snapshot,tx_added,tx_removed,error"`
+	Data any    `json:"data"`
+}
+
+// mempoolFilter limits the TX events sent to a websocket client to TXs with one
+// of the IDs, or with an output to one of the addresses
+type mempoolFilter struct {
+	txIds     map[string]bool
+	addresses map[string]bool
+}
+
+// mempoolFilterUpdate is a filter message from a websocket client, or the reason
+// that it's invalid
+type mempoolFilterUpdate struct {
+	filter *mempoolFilter
+	err    error
+}
+
+// handleLocalTxMonitorWs godoc
+//
+//	@Summary		Subscribe to mempool changes using a websocket
+//	@Description	Sends the same snapshot, tx_added, and tx_removed events as the /localtxmonitor/stream endpoint, as JSON messages with the event type and data. The client can send a filter message with tx_ids and addresses lists, after which TX events are only sent for TXs with one of the IDs or with an output to one of the addresses. Each filter message replaces the last one, and empty lists remove the filter. Snapshot events are always sent. Clients that don't answer pings, or that fall too far behind on messages, are disconnected.
+//	@Tags			localtxmonitor
+//	@Success		101
+//	@Failure		500	{object}	responseApiError
+//	@Failure		503	{object}	responseApiError
+//	@Router			/localtxmonitor/ws [get]
+func handleLocalTxMonitorWs(c *gin.Context) {
+	// Track this handler so that shutdown waits for it
+	defer trackStream()()
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	stream, ok := startMempoolStream(c, ctx)
+	if !ok {
+		return
+	}
+	defer mempoolStreams.Add(-1)
+	// Upgrade the connection
+	webConn, err := upgrader.Upgrade(
+		c.Writer,
+		c.Request,
+		http.Header{nodeEndpointHeader: []string{stream.Endpoint()}},
+	)
+	if err != nil {
+		return
+	}
+	defer webConn.Close()
+	cfg := config.GetConfig().Api.MempoolStream
+	heartbeatInterval := time.Duration(cfg.HeartbeatInterval) * time.Second
+	logger := requestLogger(c, logging.ComponentApi)
+	logger.Debugf("starting mempool websocket")
+	defer logger.Debugf("mempool websocket closed")
+	// The request context isn't cancelled when a websocket client goes away, so
+	// we read from the websocket to notice, which stops the stream right away.
+	// A client that doesn't answer our pings is treated as gone
+	pongWait := 2 * heartbeatInterval
+	webConn.SetReadLimit(mempoolWsMaxMessageBytes)
+	_ = webConn.SetReadDeadline(time.Now().Add(pongWait))
+	webConn.SetPongHandler(func(string) error {
+		return webConn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	filterChan := make(chan mempoolFilterUpdate)
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := webConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var update mempoolFilterUpdate
+			var req requestLocalTxMonitorWsFilter
+			if err := json.Unmarshal(data, &req); err != nil {
+				update.err = err
+			} else {
+				update.filter, update.err = newMempoolFilter(req)
+			}
+			select {
+			case filterChan <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// Messages are written in the background, so that a slow client can't hold
+	// up the stream. Control frames can be written alongside them
+	sendChan := make(chan []byte, cfg.SendBuffer)
+	defer close(sendChan)
+	go func() {
+		for msg := range sendChan {
+			_ = webConn.SetWriteDeadline(time.Now().Add(mempoolWsWriteTimeout))
+			if err := webConn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+	closeWebConn := func(code int, text string) {
+		_ = webConn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(code, text),
+			time.Now().Add(time.Second),
+		)
+	}
+	// send queues a message, and returns false if the client is too far behind
+	send := func(msgType string, data any) bool {
+		msg, err := json.Marshal(
+			responseLocalTxMonitorWsMessage{Type: msgType, Data: data},
+		)
+		if err != nil {
+			logger.Warnf("failed to encode mempool websocket message: %s", err)
+			return true
+		}
+		select {
+		case sendChan <- msg:
+			return true
+		default:
+			logger.Debugf("disconnecting slow mempool websocket client")
+			closeWebConn(websocket.CloseTryAgainLater, "client too slow")
+			return false
+		}
+	}
+	ping := time.NewTicker(heartbeatInterval)
+	defer ping.Stop()
+	var diff mempoolDiff
+	var filter *mempoolFilter
+	for {
+		select {
+		case <-streamShutdownChan():
+			// Let the client know that we're going away
+			closeWebConn(websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			if err := webConn.WriteControl(
+				websocket.PingMessage,
+				nil,
+				time.Now().Add(mempoolWsWriteTimeout),
+			); err != nil {
+				return
+			}
+		case update := <-filterChan:
+			if update.err != nil {
+				// Keep the last filter
+				if !send(
+					mempoolEventError,
+					responseLocalTxMonitorStreamError{
+						Error: "invalid filter: " + update.err.Error(),
+					},
+				) {
+					return
+				}
+				continue
+			}
+			filter = update.filter
+		case snapshot, ok := <-stream.Snapshots():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					logger.Warnf("mempool stream failed: %s", err)
+					closeWebConn(
+						websocket.CloseInternalServerErr,
+						"lost connection to node",
+					)
+				}
+				return
+			}
+			for _, evt := range diff.next(snapshot, logger) {
+				if !filter.match(evt) {
+					continue
+				}
+				if !send(evt.Type, evt.Data) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// newMempoolFilter returns the filter for a filter message, or nil if the message
+// has no TX IDs or addresses
+func newMempoolFilter(req requestLocalTxMonitorWsFilter) (*mempoolFilter, error) {
+	if len(req.TxIds) == 0 && len(req.Addresses) == 0 {
+		return nil, nil
+	}
+	filter := &mempoolFilter{
+		txIds: make(map[string]bool, len(req.TxIds)),
+	}
+	for _, txId := range req.TxIds {
+		txHash, err := hex.DecodeString(txId)
+		if err != nil || len(txHash) != blake2b.Size256 {
+			return nil, fmt.Errorf(
+				"invalid transaction ID %q, should be 32 bytes of hex",
+				txId,
+			)
+		}
+		filter.txIds[hex.EncodeToString(txHash)] = true
+	}
+	addresses, err := parseMempoolAddresses(req.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	filter.addresses = addresses
+	return filter, nil
+}
+
+// match reports whether an event should be sent. A nil filter matches everything
+func (f *mempoolFilter) match(evt mempoolEvent) bool {
+	if f == nil || evt.Type == mempoolEventSnapshot {
+		return true
+	}
+	if data, ok := evt.Data.(responseLocalTxMonitorStreamTx); ok &&
+		f.txIds[data.TxId] {
+		return true
+	}
+	if len(f.addresses) == 0 {
+		return false
+	}
+	data, ok := evt.Data.(responseLocalTxMonitorStreamTx)
+	if !ok {
+		return false
+	}
+	outputs := mempoolTxOutputs.get(data.Slot, data.TxId, evt.txCbor)
+	return len(matchMempoolTxOutputs(outputs, f.addresses)) > 0
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Custom metric names
+const (
+	metricAuthFailures      = "api_auth_failures_total"
+	metricRateLimited       = "api_rate_limited_total"
+	metricClientDisconnects = "api_client_disconnects_total"
+	metricBuildInfo         = "build_info"
+	metricRequestSize       = "api_request_size_bytes"
+	metricResponseSize      = "api_response_size_bytes"
+	metricConfigReload      = "config_reload_results_total"
+	metricTxSubmit          = "cardano_tx_submit_total"
+	metricTxSubmitDuration  = "cardano_tx_submit_duration_seconds"
+	metricTxSubmitSize      = "cardano_tx_submit_size_bytes"
+	metricTxRejections      = "cardano_tx_submit_rejections_total"
+)
+
+// Results of submitting a TX to the node
+const (
+	txSubmitResultAccepted = "accepted"
+	txSubmitResultRejected = "rejected"
+	txSubmitResultError    = "error"
+)
+
+// Label for TX rejection failures that we can't decode, which keeps the number
+// of label values bounded
+const txRejectionFailureOther = "other"
+
+var (
+	initMetricsOnce sync.Once
+	// Metrics middleware captured from the metrics monitor
+	metricsMiddleware gin.HandlersChain
+	// Names of the TX rejection failures that are used as metric labels
+	txRejectionFailureLabels map[string]bool
+)
+
+// InitMetrics sets up the metrics monitor and adds our custom metrics to it. The
+// metrics monitor isn't safe to set up concurrently with metrics being updated,
+// so this must be called before starting anything that updates metrics. It's safe
+// to call more than once
+func InitMetrics() {
+	initMetricsOnce.Do(func() {
+		cfg := config.GetConfig()
+		metrics := ginmetrics.GetMonitor()
+		// This must be set before the request duration histogram is created
+		metrics.SetDuration(cfg.Metrics.DurationBuckets)
+		// Capture the metrics middleware so that we can also use it for unmatched
+		// routes, which don't belong to any group
+		metricsGroup := &gin.RouterGroup{}
+		metrics.UseWithoutExposingEndpoint(metricsGroup)
+		metricsMiddleware = append(metricsGroup.Handlers, requestSizeMiddleware)
+		// Register custom metrics
+		node.RegisterMetrics()
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricAuthFailures,
+			Description: "API requests rejected due to failed authentication",
+			Labels:      []string{"group"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricRateLimited,
+			Description: "API requests rejected due to rate limiting",
+			Labels:      []string{"group"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricClientDisconnects,
+			Description: "API requests aborted because the client disconnected",
+			Labels:      []string{"group"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricBuildInfo,
+			Description: "Build information, with a constant value of 1",
+			Labels: []string{
+				"version",
+				"commit",
+				"build_date",
+				"go_version",
+				"gouroboros_version",
+			},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Histogram,
+			Name:        metricRequestSize,
+			Description: "Size of API request bodies in bytes",
+			Labels:      []string{"uri"},
+			Buckets:     cfg.Metrics.SizeBuckets,
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Histogram,
+			Name:        metricResponseSize,
+			Description: "Size of API response bodies in bytes",
+			Labels:      []string{"uri"},
+			Buckets:     cfg.Metrics.SizeBuckets,
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricConfigReload,
+			Description: "Results of reloading config keys",
+			Labels:      []string{"key", "result"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricTxSubmit,
+			Description: "TXs submitted to the node, by result",
+			Labels:      []string{"result"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Histogram,
+			Name:        metricTxSubmitDuration,
+			Description: "Time taken for the node to respond to a TX submission",
+			Labels:      []string{"result"},
+			Buckets: []float64{
+				0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+			},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Histogram,
+			Name:        metricTxSubmitSize,
+			Description: "Size of TXs submitted to the node in bytes",
+			Buckets: []float64{
+				256, 512, 1024, 2048, 4096, 8192, 16384, 32768,
+			},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricTxRejections,
+			Description: "Ledger rule failures in TXs rejected by the node, by failure",
+			Labels:      []string{"failure"},
+		})
+		txRejectionFailureLabels = txFailureNames()
+		setBuildInfoMetric()
+	})
+}
+
+// recordTxSubmit records the result of submitting a TX to the node. Each failure
+// in a rejection is counted, using "other" for failures that we can't decode
+func recordTxSubmit(
+	size int,
+	duration time.Duration,
+	result string,
+	reasonCbor []byte,
+) {
+	metrics := ginmetrics.GetMonitor()
+	labels := []string{result}
+	_ = metrics.GetMetric(metricTxSubmit).Inc(labels)
+	_ = metrics.GetMetric(metricTxSubmitDuration).
+		Observe(labels, duration.Seconds())
+	_ = metrics.GetMetric(metricTxSubmitSize).Observe(nil, float64(size))
+	if result != txSubmitResultRejected {
+		return
+	}
+	for _, failure := range newResponseTxRejection(reasonCbor).Failures {
+		failureLabel := failure.Name
+		if !txRejectionFailureLabels[failureLabel] {
+			failureLabel = txRejectionFailureOther
+		}
+		_ = metrics.GetMetric(metricTxRejections).Inc([]string{failureLabel})
+	}
+}
+
+// RecordConfigReload counts the results of a config reload
+func RecordConfigReload(results []config.ReloadResult) {
+	metric := ginmetrics.GetMonitor().GetMetric(metricConfigReload)
+	for _, result := range results {
+		_ = metric.Inc([]string{result.Key, result.Result})
+	}
+}
+
+// requestSizeMiddleware records the request and response body sizes. The
+// ginmetrics monitor only provides totals for these
+func requestSizeMiddleware(c *gin.Context) {
+	c.Next()
+	metrics := ginmetrics.GetMonitor()
+	labels := []string{c.FullPath()}
+	// The content length is negative when it's unknown
+	if c.Request.ContentLength >= 0 {
+		_ = metrics.GetMetric(metricRequestSize).
+			Observe(labels, float64(c.Request.ContentLength))
+	}
+	// The size is negative when no body has been written
+	_ = metrics.GetMetric(metricResponseSize).
+		Observe(labels, float64(max(c.Writer.Size(), 0)))
+}
+
+// metricsFilterMiddleware wraps the request metrics middleware to skip the
+// specified API routes, such as long-lived streams that would skew the request
+// duration histograms. Routes are relative to the API version, such as
+// "/chainsync/sync"
+func metricsFilterMiddleware(
+	handlers gin.HandlersChain,
+	excludePaths []string,
+) gin.HandlersChain {
+	excluded := make(map[string]bool, len(excludePaths))
+	for _, path := range excludePaths {
+		excluded["/"+strings.Trim(path, "/")] = true
+	}
+	ret := make(gin.HandlersChain, 0, len(handlers))
+	for _, handler := range handlers {
+		handler := handler
+		ret = append(ret, func(c *gin.Context) {
+			if excluded[apiRoutePath(c)] {
+				c.Next()
+				return
+			}
+			handler(c)
+		})
+	}
+	return ret
+}
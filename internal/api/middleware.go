@@ -0,0 +1,141 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Keys for values stored in the gin context by our middleware
+const (
+	contextKeyClientCN    = "client_cn"
+	contextKeyAuthSubject = "auth_subject"
+	contextKeyRequestId   = "request_id"
+	contextKeyTxHash      = "tx_hash"
+)
+
+const (
+	requestIdHeader = "X-Request-Id"
+	// Reports the node endpoint that served the request
+	nodeEndpointHeader = "X-Node-Endpoint"
+	// Longer client-provided request IDs are replaced with a generated one
+	requestIdMaxLength = 128
+)
+
+// requestIdMiddleware uses the request ID provided by the client, or generates a
+// new one, and returns it in the response headers
+func requestIdMiddleware(c *gin.Context) {
+	requestId := c.GetHeader(requestIdHeader)
+	if !validRequestId(requestId) {
+		requestId = newRequestId()
+	}
+	c.Set(contextKeyRequestId, requestId)
+	c.Header(requestIdHeader, requestId)
+	c.Next()
+}
+
+// validRequestId checks that a client-provided request ID is safe to log and echo
+// back to the client
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > requestIdMaxLength {
+		return false
+	}
+	for _, r := range requestId {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestId returns a random (version 4) UUID
+func newRequestId() string {
+	var buf [16]byte
+	// This never returns an error
+	_, _ = rand.Read(buf[:])
+	buf[6] = (buf[6] & 0x0f) | 0x40
+	buf[8] = (buf[8] & 0x3f) | 0x80
+	return fmt.Sprintf(
+		"%x-%x-%x-%x-%x",
+		buf[0:4],
+		buf[4:6],
+		buf[6:8],
+		buf[8:10],
+		buf[10:16],
+	)
+}
+
+// requestLogger returns the logger for a component with the request ID attached
+func requestLogger(c *gin.Context, component string) *logging.Logger {
+	logger := logging.GetLogger(component)
+	if requestId := c.GetString(contextKeyRequestId); requestId != "" {
+		logger = logger.With("request_id", requestId)
+	}
+	return logger
+}
+
+// clientCertMiddleware stores the common name of a verified client certificate in
+// the request context
+func clientCertMiddleware(c *gin.Context) {
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		c.Set(
+			contextKeyClientCN,
+			c.Request.TLS.PeerCertificates[0].Subject.CommonName,
+		)
+	}
+	c.Next()
+}
+
+// maxBodyBytesMiddleware rejects request bodies over maxBytes. Bodies that don't
+// declare their length fail when the handler reads past the limit
+func maxBodyBytesMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			respondRequestTooLarge(c, maxBytes)
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(
+				c.Writer,
+				c.Request.Body,
+				maxBytes,
+			)
+		}
+		c.Next()
+	}
+}
+
+// respondRequestTooLarge sends an error for a request body over maxBytes
+func respondRequestTooLarge(c *gin.Context, maxBytes int64) {
+	respondError(
+		c,
+		http.StatusRequestEntityTooLarge,
+		apiErrorCode(
+			errorCodeRequestTooLarge,
+			fmt.Sprintf("request body is larger than %d bytes", maxBytes),
+			nil,
+		),
+	)
+}
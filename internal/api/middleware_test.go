@@ -0,0 +1,126 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// Generated request IDs are version 4 UUIDs
+var testGeneratedRequestIdRegexp = regexp.MustCompile(
+	`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+	testDefs := []struct {
+		name      string
+		requestId string
+		// Whether the request ID is passed through rather than generated
+		wantPassed bool
+	}{
+		{
+			name:       "valid",
+			requestId:  "client-1_req.2:3",
+			wantPassed: true,
+		},
+		{
+			name:       "max length",
+			requestId:  strings.Repeat("a", requestIdMaxLength),
+			wantPassed: true,
+		},
+		{
+			name:      "over max length",
+			requestId: strings.Repeat("a", requestIdMaxLength+1),
+		},
+		{
+			name:      "space",
+			requestId: "client 1",
+		},
+		{
+			name:      "newline",
+			requestId: "client-1\ninjected",
+		},
+		{
+			name:      "non-ASCII",
+			requestId: "clïent-1",
+		},
+		{
+			name: "missing",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			router := gin.New()
+			router.Use(
+				requestIdMiddleware,
+				accessLogMiddleware(
+					zap.New(core),
+					newAccessLogFilter(config.GetConfig()),
+					[]string{config.AccessLogFieldRequestId},
+				),
+			)
+			// The handler sees the same request ID
+			var handlerRequestId string
+			router.GET("/test", func(c *gin.Context) {
+				handlerRequestId = c.GetString(contextKeyRequestId)
+				c.Status(http.StatusNoContent)
+			})
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if testDef.requestId != "" {
+				req.Header.Set(requestIdHeader, testDef.requestId)
+			}
+			router.ServeHTTP(w, req)
+			gotRequestId := w.Header().Get(requestIdHeader)
+			if testDef.wantPassed {
+				if gotRequestId != testDef.requestId {
+					t.Fatalf("request ID not passed through: got %q", gotRequestId)
+				}
+			} else if !testGeneratedRequestIdRegexp.MatchString(gotRequestId) {
+				t.Fatalf("request ID not generated: got %q", gotRequestId)
+			}
+			if handlerRequestId != gotRequestId {
+				t.Fatalf(
+					"handler got request ID %q, response has %q",
+					handlerRequestId,
+					gotRequestId,
+				)
+			}
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d access log entries, wanted 1", len(entries))
+			}
+			logged, ok := entries[0].ContextMap()["request_id"]
+			if !ok || logged != gotRequestId {
+				t.Fatalf(
+					"access log has request ID %v, response has %q",
+					logged,
+					gotRequestId,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+func configureNodeRoutes(apiGroup *gin.RouterGroup, version int) {
+	group := apiGroup.Group("/node")
+	group.GET("/connection", handleNodeConnection)
+}
+
+type responseNodeConnection struct {
+	ProtocolVersion uint16            `json:"protocol_version"`
+	OfferedVersions []uint16          `json:"offered_protocol_versions"`
+	NetworkMagic    uint32            `json:"network_magic"`
+	Endpoint        string            `json:"endpoint"`
+	ConnectedAt     time.Time         `json:"connected_at"`
+	UptimeSeconds   int64             `json:"uptime_seconds"`
+	Requests        map[string]uint64 `json:"requests"`
+}
+
+// handleNodeConnection godoc
+//
+//	@Summary		Node Connection
+//	@Description	Returns the details negotiated by the connection manager's current handshake with the node, the protocol versions that were offered, and the requests served per mini-protocol since it connected.
+//	@Tags			node
+//	@Produce		json
+//	@Success		200	{object}	responseNodeConnection
+//	@Failure		503	{object}	responseApiError
+//	@Router			/node/connection [get]
+func handleNodeConnection(c *gin.Context) {
+	conn := node.GetManagedConnection()
+	if conn == nil {
+		respondError(
+			c,
+			http.StatusServiceUnavailable,
+			apiErrorCode(
+				errorCodeNodeUnavailable,
+				"not connected to node",
+				nil,
+			),
+		)
+		return
+	}
+	resp := responseNodeConnection{
+		ProtocolVersion: conn.ProtocolVersion,
+		NetworkMagic:    conn.NetworkMagic,
+		Endpoint:        conn.Endpoint,
+		ConnectedAt:     conn.ConnectedAt.UTC(),
+		UptimeSeconds:   int64(time.Since(conn.ConnectedAt).Seconds()),
+		Requests:        conn.Requests,
+	}
+	resp.OfferedVersions = config.GetConfig().Node.OfferedProtocolVersions()
+	if resp.OfferedVersions == nil {
+		resp.OfferedVersions = config.SupportedProtocolVersions()
+	}
+	respondJson(c, http.StatusOK, resp)
+}
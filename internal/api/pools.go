@@ -0,0 +1,301 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Relay types in responses
+const (
+	poolRelayTypeAddress = "address"
+	poolRelayTypeHost    = "host"
+	poolRelayTypeDns     = "dns"
+)
+
+type responsePool struct {
+	PoolId    string `json:"pool_id"     example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	PoolIdHex string `json:"pool_id_hex" example:"0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735"`
+}
+
+type responsePoolParams struct {
+	PoolId        string              `json:"pool_id"                example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	PoolIdHex     string              `json:"pool_id_hex"            example:"0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735"`
+	VrfKeyHash    string              `json:"vrf_key_hash"           example:"b348e7ce3a7356e3b3ddbb2d8989de39247c4fe0e7d6e4abc47ac0ade1ae5d5d"`
+	Pledge        uint64              `json:"pledge"                 example:"100000000000"`
+	Cost          uint64              `json:"cost"                   example:"170000000"`
+	Margin        float64             `json:"margin"                 example:"0.01"`
+	RewardAccount string              `json:"reward_account"         example:"stake1uxpdrerp9wrxunfh6ukyv5267j70fzxgw0fr3z8zeac5vyqhf9jhy"`
+	Owners        []string            `json:"owners"`
+	Relays        []responsePoolRelay `json:"relays"`
+	MetadataUrl   string              `json:"metadata_url,omitempty"  example:"https://example.com/pool.json"`
+	MetadataHash  string              `json:"metadata_hash,omitempty" example:"b8c4b1fd4f7e74f1c5eead7d9b5de4cc4b8c2bd0c1a03e8b3fcaf6c9b8fa2f6e"`
+}
+
+// responsePoolRelay is a pool relay, where the type is one of address (an IPv4
+// and/or IPv6 address), host (a single host name), or dns (an SRV record name)
+type responsePoolRelay struct {
+	Type string  `json:"type"           example:"host" enums:"address,host,dns"`
+	Host string  `json:"host,omitempty" example:"relay.example.com"`
+	Ipv4 string  `json:"ipv4,omitempty" example:"192.0.2.1"`
+	Ipv6 string  `json:"ipv6,omitempty" example:"2001:db8::1"`
+	Port *uint32 `json:"port,omitempty" example:"3001"`
+}
+
+// parsePoolId parses a pool ID in either bech32 or hex
+func parsePoolId(poolId string) (ledger.PoolId, error) {
+	if strings.HasPrefix(poolId, "pool1") {
+		ret, err := ledger.NewPoolIdFromBech32(poolId)
+		if err != nil {
+			return ledger.PoolId{}, fmt.Errorf("invalid pool ID: %s", poolId)
+		}
+		return ret, nil
+	}
+	poolIdBytes, err := hex.DecodeString(poolId)
+	if err != nil || len(poolIdBytes) != len(ledger.PoolId{}) {
+		return ledger.PoolId{}, fmt.Errorf("invalid pool ID: %s", poolId)
+	}
+	return ledger.PoolId(poolIdBytes), nil
+}
+
+func newResponsePool(poolId ledger.PoolId) responsePool {
+	return responsePool{
+		PoolId:    poolId.String(),
+		PoolIdHex: hex.EncodeToString(poolId[:]),
+	}
+}
+
+// poolParams is the anonymous type of the stake pool params query results
+type poolParams = struct {
+	cbor.StructAsArray
+	Operator      ledger.Blake2b224
+	VrfKeyHash    ledger.Blake2b256
+	Pledge        uint
+	FixedCost     uint
+	Margin        *cbor.Rat
+	RewardAccount ledger.Address
+	PoolOwners    []ledger.Blake2b224
+	Relays        []ledger.PoolRelay
+	PoolMetadata  *struct {
+		cbor.StructAsArray
+		Url          string
+		MetadataHash ledger.Blake2b256
+	}
+}
+
+func newResponsePoolParams(
+	poolId ledger.PoolId,
+	params poolParams,
+) (responsePoolParams, error) {
+	pool := newResponsePool(poolId)
+	ret := responsePoolParams{
+		PoolId:        pool.PoolId,
+		PoolIdHex:     pool.PoolIdHex,
+		VrfKeyHash:    params.VrfKeyHash.String(),
+		Pledge:        uint64(params.Pledge),
+		Cost:          uint64(params.FixedCost),
+		RewardAccount: params.RewardAccount.String(),
+		Owners:        []string{},
+		Relays:        []responsePoolRelay{},
+	}
+	if params.Margin != nil && params.Margin.Rat != nil {
+		ret.Margin = ratFloat(params.Margin)
+	}
+	for _, owner := range params.PoolOwners {
+		ret.Owners = append(ret.Owners, owner.String())
+	}
+	for _, relay := range params.Relays {
+		tmpRelay, err := newResponsePoolRelay(relay)
+		if err != nil {
+			return ret, err
+		}
+		ret.Relays = append(ret.Relays, tmpRelay)
+	}
+	if params.PoolMetadata != nil {
+		ret.MetadataUrl = params.PoolMetadata.Url
+		ret.MetadataHash = params.PoolMetadata.MetadataHash.String()
+	}
+	return ret, nil
+}
+
+func newResponsePoolRelay(relay ledger.PoolRelay) (responsePoolRelay, error) {
+	ret := responsePoolRelay{Port: relay.Port}
+	switch relay.Type {
+	case ledger.PoolRelayTypeSingleHostAddress:
+		// A single host address has an IPv4 address, an IPv6 address, or both
+		if relay.Ipv4 == nil && relay.Ipv6 == nil {
+			return ret, fmt.Errorf("relay has no IPv4 or IPv6 address")
+		}
+		ret.Type = poolRelayTypeAddress
+		if relay.Ipv4 != nil {
+			if len(*relay.Ipv4) != net.IPv4len {
+				return ret, fmt.Errorf(
+					"invalid relay IPv4 address length: %d",
+					len(*relay.Ipv4),
+				)
+			}
+			ret.Ipv4 = relay.Ipv4.String()
+		}
+		if relay.Ipv6 != nil {
+			ipv6, err := poolRelayIpv6(*relay.Ipv6)
+			if err != nil {
+				return ret, err
+			}
+			ret.Ipv6 = ipv6
+		}
+	case ledger.PoolRelayTypeSingleHostName:
+		if relay.Hostname == nil {
+			return ret, fmt.Errorf("relay has no host name")
+		}
+		ret.Type = poolRelayTypeHost
+		ret.Host = *relay.Hostname
+	case ledger.PoolRelayTypeMultiHostName:
+		// The host name is an SRV record, so there's no port
+		if relay.Hostname == nil {
+			return ret, fmt.Errorf("relay has no DNS name")
+		}
+		ret.Type = poolRelayTypeDns
+		ret.Host = *relay.Hostname
+		ret.Port = nil
+	default:
+		return ret, fmt.Errorf("unknown relay type: %d", relay.Type)
+	}
+	return ret, nil
+}
+
+// poolRelayIpv6 formats a relay IPv6 address. The ledger encodes them as four
+// 32-bit words in little-endian order, so the bytes in each word are reversed
+func poolRelayIpv6(ip net.IP) (string, error) {
+	if len(ip) != net.IPv6len {
+		return "", fmt.Errorf("invalid relay IPv6 address length: %d", len(ip))
+	}
+	ret := make(net.IP, net.IPv6len)
+	for i := 0; i < net.IPv6len; i += 4 {
+		ret[i] = ip[i+3]
+		ret[i+1] = ip[i+2]
+		ret[i+2] = ip[i+1]
+		ret[i+3] = ip[i]
+	}
+	return ret.String(), nil
+}
+
+// Stake in the mark, set, and go snapshots, as lovelace strings
+type responsePoolSnapshotsStake struct {
+	Mark string `json:"mark" example:"64135748075088"`
+	Set  string `json:"set"  example:"64126196541243"`
+	Go   string `json:"go"   example:"64117030928998"`
+}
+
+type responsePoolSnapshots struct {
+	PoolId string                     `json:"pool_id" example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	Stake  responsePoolSnapshotsStake `json:"stake"`
+}
+
+// The total is the active stake in each snapshot. The pools are left out when
+// encoding this, since they're streamed after the other fields
+type responseLocalStateQueryStakeSnapshots struct {
+	EpochNo int                        `json:"epoch_no"        example:"507"`
+	Total   responsePoolSnapshotsStake `json:"total"`
+	Pools   []responsePoolSnapshots    `json:"pools,omitempty"`
+}
+
+// stakeSnapshotsResult is the result of the stake snapshots query
+type stakeSnapshotsResult struct {
+	cbor.StructAsArray
+	Pools     map[ledger.PoolId]stakeSnapshotsStake
+	MarkTotal uint64
+	SetTotal  uint64
+	GoTotal   uint64
+}
+
+func (r *stakeSnapshotsResult) total() stakeSnapshotsStake {
+	return stakeSnapshotsStake{
+		Mark: r.MarkTotal,
+		Set:  r.SetTotal,
+		Go:   r.GoTotal,
+	}
+}
+
+// Stake in the mark, set, and go snapshots, in lovelace
+type stakeSnapshotsStake struct {
+	cbor.StructAsArray
+	Mark uint64
+	Set  uint64
+	Go   uint64
+}
+
+func (s stakeSnapshotsStake) response() responsePoolSnapshotsStake {
+	return responsePoolSnapshotsStake{
+		Mark: strconv.FormatUint(s.Mark, 10),
+		Set:  strconv.FormatUint(s.Set, 10),
+		Go:   strconv.FormatUint(s.Go, 10),
+	}
+}
+
+// stakeSnapshotsCache holds the stake snapshots of all pools until the end of
+// the epoch that they were queried in, since they only change at epoch
+// boundaries
+type stakeSnapshotsCache struct {
+	mutex     sync.Mutex
+	epochNo   int
+	snapshots *stakeSnapshotsResult
+	expiresAt time.Time
+}
+
+var stakeSnapshots = &stakeSnapshotsCache{}
+
+// get returns the cached stake snapshots if they haven't expired
+func (s *stakeSnapshotsCache) get() (int, *stakeSnapshotsResult, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.snapshots == nil || !time.Now().Before(s.expiresAt) {
+		return 0, nil, false
+	}
+	return s.epochNo, s.snapshots, true
+}
+
+func (s *stakeSnapshotsCache) set(
+	epochNo int,
+	snapshots *stakeSnapshotsResult,
+	expiresAt time.Time,
+) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.epochNo = epochNo
+	s.snapshots = snapshots
+	s.expiresAt = expiresAt
+}
+
+// The stake is the pool's voting stake in lovelace, as a string
+type responsePoolVotingStake struct {
+	PoolId string `json:"pool_id" example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	Stake  string `json:"stake"   example:"64135748075088"`
+}
+
+// The total is the stake of the pools returned. The pools are left out when
+// encoding this, since they're streamed after the other fields
+type responseLocalStateQuerySpoStake struct {
+	Total string                    `json:"total"           example:"64135748075088"`
+	Pools []responsePoolVotingStake `json:"pools,omitempty"`
+}
@@ -0,0 +1,387 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+func TestHandleLocalStateQueryStakeSnapshots(t *testing.T) {
+	const testPoolId2 = "pool1xvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxjllzk8"
+	testPool := responsePoolSnapshots{
+		PoolId: testPoolId,
+		Stake:  responsePoolSnapshotsStake{Mark: "100", Set: "200", Go: "300"},
+	}
+	testTotal := responsePoolSnapshotsStake{
+		Mark: "1000",
+		Set:  "2000",
+		Go:   "3000",
+	}
+	testDefs := []struct {
+		name string
+		url  string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries []string
+		results []string
+		// Requests to send after the first, which must be served from the cache
+		cachedRequests int
+		wantStatus     int
+		wantResp       responseLocalStateQueryStakeSnapshots
+	}{
+		{
+			name: "pools in request order",
+			url:  "/pools/" + testPoolId + ",33333333333333333333333333333333333333333333333333333333," + testPoolId + "/snapshots",
+			queries: []string{
+				// [0, [2, [1]]]
+				"820082028101",
+				// [0, [0, [6, [1]]]]
+				"8200820082068101",
+				// [0, [0, [6, [20, [[pool, pool 2]]]]]]
+				"82008200820682148182581c0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735581c33333333333333333333333333333333333333333333333333333333",
+			},
+			results: []string{
+				"06",
+				"8119020d",
+				// [[{pool: [100, 200, 300]}, 1000, 2000, 3000]]
+				"8184a1581c0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef273583186418c819012c1903e81907d0190bb8",
+			},
+			wantStatus: http.StatusOK,
+			wantResp: responseLocalStateQueryStakeSnapshots{
+				EpochNo: 525,
+				Total:   testTotal,
+				Pools: []responsePoolSnapshots{
+					testPool,
+					{
+						PoolId: testPoolId2,
+						Stake: responsePoolSnapshotsStake{
+							Mark: "0",
+							Set:  "0",
+							Go:   "0",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "all pools cached",
+			url:  "/pools/all/snapshots?all=true",
+			queries: []string{
+				"820082028101",
+				"8200820082068101",
+				// [0, [0, [6, [20, []]]]]
+				"820082008206821480",
+				// [1]
+				"8101",
+				// [0, [2, [0]]]
+				"820082028100",
+			},
+			results: []string{
+				"06",
+				"8119020d",
+				// [[{pool 2: [4, 5, 6], pool: [100, 200, 300]}, 1000, 2000, 3000]]
+				"8184a2581c3333333333333333333333333333333333333333333333333333333383040506581c0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef273583186418c819012c1903e81907d0190bb8",
+				// [2100, 1, 0]
+				"831908340100",
+				// [[[0, 0, 0], null, [432000, 1000, [0, 129600, [0]]]]]
+				"818383000000f6831a000697801903e883001a0001fa408100",
+			},
+			cachedRequests: 1,
+			wantStatus:     http.StatusOK,
+			wantResp: responseLocalStateQueryStakeSnapshots{
+				EpochNo: 525,
+				Total:   testTotal,
+				Pools: []responsePoolSnapshots{
+					testPool,
+					{
+						PoolId: testPoolId2,
+						Stake: responsePoolSnapshotsStake{
+							Mark: "4",
+							Set:  "5",
+							Go:   "6",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "all pools not confirmed",
+			url:        "/pools/all/snapshots",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid pool ID",
+			url:        "/pools/" + testPoolId + ",pool1invalid/snapshots",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			stakeSnapshots.set(0, nil, time.Time{})
+			t.Cleanup(func() { stakeSnapshots.set(0, nil, time.Time{}) })
+			startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			for i := 0; i <= testDef.cachedRequests; i++ {
+				w := serveTestRequest(
+					http.MethodGet,
+					"/pools/:pool_id/snapshots",
+					handleLocalStateQueryStakeSnapshots,
+					testDef.url,
+					nil,
+				)
+				if w.Code != testDef.wantStatus {
+					t.Fatalf(
+						"unexpected status %d: %s",
+						w.Code,
+						w.Body.String(),
+					)
+				}
+				if testDef.wantStatus != http.StatusOK {
+					return
+				}
+				var resp responseLocalStateQueryStakeSnapshots
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %s", err)
+				}
+				if !reflect.DeepEqual(resp, testDef.wantResp) {
+					t.Fatalf("unexpected response: %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQuerySpoStake(t *testing.T) {
+	const (
+		testPoolIdA = "pool1424242424242424242424242424242424242424242425m6wpt0"
+		testPoolIdB = "pool1hwamhwamhwamhwamhwamhwamhwamhwamhwamhwamhwamk38rzzc"
+	)
+	testDefs := []struct {
+		name  string
+		query string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+		want       responseLocalStateQuerySpoStake
+	}{
+		{
+			name: "all",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [30, []]]]]
+				"82008200820682181e80",
+			},
+			results: []string{
+				"06",
+				"81a2581cbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb18c8" +
+					"581caaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1864",
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQuerySpoStake{
+				Total: "300",
+				Pools: []responsePoolVotingStake{
+					{PoolId: testPoolIdA, Stake: "100"},
+					{PoolId: testPoolIdB, Stake: "200"},
+				},
+			},
+		},
+		{
+			name:  "filtered",
+			query: "?pool_id=" + testPoolIdA + "&pool_id=aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [30, [pool ID]]]]]
+				"82008200820682181e81581caaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			},
+			results: []string{
+				"06",
+				"81a1581caaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1864",
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQuerySpoStake{
+				Total: "100",
+				Pools: []responsePoolVotingStake{
+					{PoolId: testPoolIdA, Stake: "100"},
+				},
+			},
+		},
+		{
+			name:       "invalid pool id",
+			query:      "?pool_id=pool1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "babbage",
+			queries:    []string{"820082028101"},
+			results:    []string{"05"},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if len(testDef.queries) > 0 {
+				startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			}
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/spo-stake",
+				handleLocalStateQuerySpoStake,
+				"/governance/spo-stake"+testDef.query,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQuerySpoStake
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.want) {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewResponsePoolRelay(t *testing.T) {
+	port := uint32(3001)
+	testDefs := []struct {
+		name string
+		// The relay as it's encoded in the pool parameters, as hex
+		relay   string
+		want    responsePoolRelay
+		wantErr string
+	}{
+		{
+			name: "ipv4",
+			// [0, 3001, h'c0000201', null]
+			relay: "8400190bb944c0000201f6",
+			want: responsePoolRelay{
+				Type: poolRelayTypeAddress,
+				Ipv4: "192.0.2.1",
+				Port: &port,
+			},
+		},
+		{
+			name: "ipv6",
+			// The words of 2001:db8:85a3::8a2e:370:7334, each little-endian
+			relay: "8400190bb9f650b80d01200000a3852e8a000034737003",
+			want: responsePoolRelay{
+				Type: poolRelayTypeAddress,
+				Ipv6: "2001:db8:85a3::8a2e:370:7334",
+				Port: &port,
+			},
+		},
+		{
+			name:  "ipv4 and ipv6",
+			relay: "8400190bb944c000020150b80d0120000000000000000001000000",
+			want: responsePoolRelay{
+				Type: poolRelayTypeAddress,
+				Ipv4: "192.0.2.1",
+				Ipv6: "2001:db8::1",
+				Port: &port,
+			},
+		},
+		{
+			name:  "address without port",
+			relay: "8400f644c0000201f6",
+			want: responsePoolRelay{
+				Type: poolRelayTypeAddress,
+				Ipv4: "192.0.2.1",
+			},
+		},
+		{
+			name: "host name",
+			// [1, 3001, "relay.example.com"]
+			relay: "8301190bb97172656c61792e6578616d706c652e636f6d",
+			want: responsePoolRelay{
+				Type: poolRelayTypeHost,
+				Host: "relay.example.com",
+				Port: &port,
+			},
+		},
+		{
+			name:  "host name without port",
+			relay: "8301f67172656c61792e6578616d706c652e636f6d",
+			want: responsePoolRelay{
+				Type: poolRelayTypeHost,
+				Host: "relay.example.com",
+			},
+		},
+		{
+			name: "multi host name",
+			// [2, "_cardano._tcp.example.com"]
+			relay: "820278195f63617264616e6f2e5f7463702e6578616d706c652e636f6d",
+			want: responsePoolRelay{
+				Type: poolRelayTypeDns,
+				Host: "_cardano._tcp.example.com",
+			},
+		},
+		{
+			name:    "malformed ipv6",
+			relay:   "8400190bb9f6480102030405060708",
+			wantErr: "invalid relay IPv6 address length: 8",
+		},
+		{
+			name:    "malformed ipv4",
+			relay:   "8400190bb943c00002f6",
+			wantErr: "invalid relay IPv4 address length: 3",
+		},
+		{
+			name:    "no address",
+			relay:   "8400190bb9f6f6",
+			wantErr: "relay has no IPv4 or IPv6 address",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			relayCbor, err := hex.DecodeString(testDef.relay)
+			if err != nil {
+				t.Fatalf("invalid test relay hex: %s", err)
+			}
+			var relay ledger.PoolRelay
+			if _, err := cbor.Decode(relayCbor, &relay); err != nil {
+				t.Fatalf("failed to decode test relay: %s", err)
+			}
+			got, err := newResponsePoolRelay(relay)
+			if testDef.wantErr != "" {
+				if err == nil || err.Error() != testDef.wantErr {
+					t.Fatalf(
+						"unexpected error: got %v, wanted %s",
+						err,
+						testDef.wantErr,
+					)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, testDef.want) {
+				t.Fatalf("unexpected relay:\n got: %+v\nwant: %+v", got, testDef.want)
+			}
+		})
+	}
+}
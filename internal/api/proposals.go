@@ -0,0 +1,248 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// Names of the governance action types, which are also used to filter the
+// proposals
+var govActionTypeNames = map[uint]string{
+	ledger.GovActionTypeParameterChange:    "parameter_change",
+	ledger.GovActionTypeHardForkInitiation: "hard_fork",
+	ledger.GovActionTypeTreasuryWithdrawal: "treasury_withdrawals",
+	ledger.GovActionTypeNoConfidence:       "no_confidence",
+	ledger.GovActionTypeUpdateCommittee:    "update_committee",
+	ledger.GovActionTypeNewConstitution:    "new_constitution",
+	ledger.GovActionTypeInfo:               "info",
+}
+
+// Votes in the proposals query result
+const (
+	voteNo      = 0
+	voteYes     = 1
+	voteAbstain = 2
+)
+
+var voteNames = map[uint]string{
+	voteNo:      "no",
+	voteYes:     "yes",
+	voteAbstain: "abstain",
+}
+
+// parseGovActionType parses a governance action type by name
+func parseGovActionType(actionType string) (uint, error) {
+	for id, name := range govActionTypeNames {
+		if name == actionType {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid governance action type: %s", actionType)
+}
+
+// parseGovActionId parses a governance action ID given as txHash#index
+func parseGovActionId(actionId string) (ledger.GovActionId, error) {
+	invalidErr := fmt.Errorf("invalid governance action ID: %s", actionId)
+	txHashHex, indexStr, ok := strings.Cut(actionId, "#")
+	if !ok {
+		return ledger.GovActionId{}, invalidErr
+	}
+	txHash, err := hex.DecodeString(txHashHex)
+	if err != nil || len(txHash) != len(ledger.Blake2b256{}) {
+		return ledger.GovActionId{}, invalidErr
+	}
+	index, err := strconv.ParseUint(indexStr, 10, 32)
+	if err != nil {
+		return ledger.GovActionId{}, invalidErr
+	}
+	ret := ledger.GovActionId{GovActionIdx: uint32(index)}
+	copy(ret.TransactionId[:], txHash)
+	return ret, nil
+}
+
+// govActionState is a live governance action in the proposals query result,
+// with the votes cast on it so far
+type govActionState struct {
+	cbor.StructAsArray
+	Id             ledger.GovActionId
+	CommitteeVotes map[ledgerCredential]uint
+	DrepVotes      map[ledgerCredential]uint
+	SpoVotes       map[ledger.PoolId]uint
+	Procedure      govProposalProcedure
+	ProposedIn     uint64
+	ExpiresAfter   uint64
+}
+
+// govProposalProcedure is the proposal of a governance action. The action is
+// only decoded as far as its type
+type govProposalProcedure struct {
+	cbor.StructAsArray
+	Deposit       uint64
+	RewardAccount ledger.Address
+	GovAction     cbor.RawMessage
+	Anchor        ledger.GovAnchor
+}
+
+type responseGovernanceVoteCounts struct {
+	Yes     int `json:"yes"     example:"3"`
+	No      int `json:"no"      example:"1"`
+	Abstain int `json:"abstain" example:"0"`
+}
+
+// The number of committee members, DReps, and stake pools that cast each vote
+type responseGovernanceVoteTallies struct {
+	Committee responseGovernanceVoteCounts `json:"committee"`
+	Drep      responseGovernanceVoteCounts `json:"drep"`
+	Spo       responseGovernanceVoteCounts `json:"spo"`
+}
+
+type responseGovernanceCredentialVote struct {
+	Credential responseGovernanceCredential `json:"credential"`
+	Vote       string                       `json:"vote"       example:"yes" enums:"yes,no,abstain"`
+}
+
+type responseGovernancePoolVote struct {
+	PoolId string `json:"pool_id" example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	Vote   string `json:"vote"    example:"yes" enums:"yes,no,abstain"`
+}
+
+// The committee members vote with their hot credentials
+type responseGovernanceVotes struct {
+	Committee []responseGovernanceCredentialVote `json:"committee"`
+	Drep      []responseGovernanceCredentialVote `json:"drep"`
+	Spo       []responseGovernancePoolVote       `json:"spo"`
+}
+
+// The deposit is in lovelace and is returned to the stake address. The action
+// expires after the expiry epoch. The votes are left out if they weren't asked
+// for
+type responseGovernanceProposal struct {
+	ActionId      responseGovActionId           `json:"action_id"`
+	Type          string                        `json:"type"            example:"info" enums:"parameter_change,hard_fork,treasury_withdrawals,no_confidence,update_committee,new_constitution,info"`
+	Deposit       uint64                        `json:"deposit"         example:"100000000000"`
+	ReturnAddress string                        `json:"return_address"  example:"stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"`
+	Anchor        responseGovernanceAnchor      `json:"anchor"`
+	ProposedEpoch uint64                        `json:"proposed_epoch"  example:"507"`
+	ExpiryEpoch   uint64                        `json:"expiry_epoch"    example:"513"`
+	VoteCounts    responseGovernanceVoteTallies `json:"vote_counts"`
+	Votes         *responseGovernanceVotes      `json:"votes,omitempty"`
+}
+
+func newResponseGovernanceProposal(
+	state govActionState,
+	actionType uint,
+	includeVotes bool,
+) responseGovernanceProposal {
+	ret := responseGovernanceProposal{
+		ActionId:      *newResponseGovActionId(state.Id),
+		Type:          govActionTypeNames[actionType],
+		Deposit:       state.Procedure.Deposit,
+		ReturnAddress: state.Procedure.RewardAccount.String(),
+		Anchor:        newResponseGovernanceAnchor(state.Procedure.Anchor),
+		ProposedEpoch: state.ProposedIn,
+		ExpiryEpoch:   state.ExpiresAfter,
+		VoteCounts: responseGovernanceVoteTallies{
+			Committee: countVotes(state.CommitteeVotes),
+			Drep:      countVotes(state.DrepVotes),
+			Spo:       countVotes(state.SpoVotes),
+		},
+	}
+	if !includeVotes {
+		return ret
+	}
+	ret.Votes = &responseGovernanceVotes{
+		Committee: newResponseCredentialVotes(
+			state.CommitteeVotes,
+			cip129PrefixCommitteeHot,
+			cip129HeaderCommitteeHot,
+		),
+		Drep: newResponseCredentialVotes(
+			state.DrepVotes,
+			cip129PrefixDrep,
+			cip129HeaderDrep,
+		),
+		Spo: make([]responseGovernancePoolVote, 0, len(state.SpoVotes)),
+	}
+	poolIds := sortedKeys(state.SpoVotes, func(a, b ledger.PoolId) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for _, poolId := range poolIds {
+		ret.Votes.Spo = append(
+			ret.Votes.Spo,
+			responseGovernancePoolVote{
+				PoolId: poolId.String(),
+				Vote:   voteNames[state.SpoVotes[poolId]],
+			},
+		)
+	}
+	return ret
+}
+
+// newResponseCredentialVotes maps the votes of committee members or DReps,
+// ordered by credential
+func newResponseCredentialVotes(
+	votes map[ledgerCredential]uint,
+	prefix string,
+	header byte,
+) []responseGovernanceCredentialVote {
+	ret := make([]responseGovernanceCredentialVote, 0, len(votes))
+	for _, cred := range sortedKeys(votes, compareLedgerCredentials) {
+		ret = append(
+			ret,
+			responseGovernanceCredentialVote{
+				Credential: newResponseGovernanceCredential(prefix, header, cred),
+				Vote:       voteNames[votes[cred]],
+			},
+		)
+	}
+	return ret
+}
+
+// countVotes counts the votes of each kind
+func countVotes[K comparable](votes map[K]uint) responseGovernanceVoteCounts {
+	var ret responseGovernanceVoteCounts
+	for _, vote := range votes {
+		switch vote {
+		case voteYes:
+			ret.Yes++
+		case voteNo:
+			ret.No++
+		case voteAbstain:
+			ret.Abstain++
+		}
+	}
+	return ret
+}
+
+type requestLocalStateQueryProposals struct {
+	ActionIds    []string `form:"action_id"`
+	Types        []string `form:"type"`
+	IncludeVotes *bool    `form:"include_votes"`
+}
+
+// The proposals are left out when encoding this, since they're streamed after
+// the other fields
+type responseLocalStateQueryProposals struct {
+	Total     int                          `json:"total"               example:"1"`
+	Proposals []responseGovernanceProposal `json:"proposals,omitempty"`
+}
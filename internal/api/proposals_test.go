@@ -0,0 +1,245 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// Governance action states from the proposals query, as hex. The info action
+// has votes from a committee member, two DReps, and a stake pool, while the no
+// confidence action has no votes. Both return their deposit to a stake key hash
+// of 0x99 bytes
+const (
+	testProposalInfoHex = "87825820abababababababababababababababababababababababababababababababab00" +
+		"a18200581c4444444444444444444444444444444444444444444444444444444401" +
+		"a28201581c2222222222222222222222222222222222222222222222222222222200" +
+		"8200581c1111111111111111111111111111111111111111111111111111111101" +
+		"a1581caaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa02" +
+		"841b000000174876e800581de199999999999999999999999999999999999999999999999999999999" +
+		"8106" +
+		"82782168747470733a2f2f6578616d706c652e636f6d2f70726f706f73616c2e6a736f6e" +
+		"58208888888888888888888888888888888888888888888888888888888888888888" +
+		"1901fb190201"
+	testProposalNoConfidenceHex = "87825820cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd01" +
+		"a0a0a0" +
+		"841b000000174876e800581de199999999999999999999999999999999999999999999999999999999" +
+		"8203f6" +
+		"82782168747470733a2f2f6578616d706c652e636f6d2f70726f706f73616c2e6a736f6e" +
+		"58208888888888888888888888888888888888888888888888888888888888888888" +
+		"1901fc190202"
+	// The # is URL-encoded
+	testProposalNoConfidenceId = "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd%231"
+)
+
+func TestHandleLocalStateQueryProposals(t *testing.T) {
+	anchor := responseGovernanceAnchor{
+		Url:      "https://example.com/proposal.json",
+		DataHash: "8888888888888888888888888888888888888888888888888888888888888888",
+	}
+	infoProposal := responseGovernanceProposal{
+		ActionId: responseGovActionId{
+			TxHash: "abababababababababababababababababababababababababababababababab",
+			Index:  0,
+		},
+		Type:          "info",
+		Deposit:       100000000000,
+		ReturnAddress: "stake1uxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxg3a2c5a",
+		Anchor:        anchor,
+		ProposedEpoch: 507,
+		ExpiryEpoch:   513,
+		VoteCounts: responseGovernanceVoteTallies{
+			Committee: responseGovernanceVoteCounts{Yes: 1},
+			Drep:      responseGovernanceVoteCounts{Yes: 1, No: 1},
+			Spo:       responseGovernanceVoteCounts{Abstain: 1},
+		},
+	}
+	infoProposalVotes := infoProposal
+	infoProposalVotes.Votes = &responseGovernanceVotes{
+		Committee: []responseGovernanceCredentialVote{
+			{
+				Credential: responseGovernanceCredential{
+					Type: governanceCredentialKeyHash,
+					Id:   "cc_hot1qfzyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3q4s9uvm",
+					Hash: "44444444444444444444444444444444444444444444444444444444",
+				},
+				Vote: "yes",
+			},
+		},
+		Drep: []responseGovernanceCredentialVote{
+			{
+				Credential: responseGovernanceCredential{
+					Type: governanceCredentialKeyHash,
+					Id:   testDrepKeyId,
+					Hash: testDrepKeyHashHex,
+				},
+				Vote: "yes",
+			},
+			{
+				Credential: responseGovernanceCredential{
+					Type: governanceCredentialScriptHash,
+					Id:   testDrepScriptId,
+					Hash: "22222222222222222222222222222222222222222222222222222222",
+				},
+				Vote: "no",
+			},
+		},
+		Spo: []responseGovernancePoolVote{
+			{
+				PoolId: "pool1424242424242424242424242424242424242424242425m6wpt0",
+				Vote:   "abstain",
+			},
+		},
+	}
+	noConfidenceProposal := responseGovernanceProposal{
+		ActionId: responseGovActionId{
+			TxHash: "cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd",
+			Index:  1,
+		},
+		Type:          "no_confidence",
+		Deposit:       100000000000,
+		ReturnAddress: "stake1uxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxvenxg3a2c5a",
+		Anchor:        anchor,
+		ProposedEpoch: 508,
+		ExpiryEpoch:   514,
+	}
+	noConfidenceProposalVotes := noConfidenceProposal
+	noConfidenceProposalVotes.Votes = &responseGovernanceVotes{
+		Committee: []responseGovernanceCredentialVote{},
+		Drep:      []responseGovernanceCredentialVote{},
+		Spo:       []responseGovernancePoolVote{},
+	}
+	allQueries := []string{
+		"820082028101",
+		// [0, [0, [6, [31, []]]]]
+		"82008200820682181f80",
+	}
+	allResults := []string{
+		"06",
+		"8182" + testProposalInfoHex + testProposalNoConfidenceHex,
+	}
+	testDefs := []struct {
+		name  string
+		query string
+		// Queries and their results after acquiring the ledger state, as hex
+		queries    []string
+		results    []string
+		wantStatus int
+		want       responseLocalStateQueryProposals
+	}{
+		{
+			name:       "all",
+			queries:    allQueries,
+			results:    allResults,
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryProposals{
+				Total: 2,
+				Proposals: []responseGovernanceProposal{
+					infoProposalVotes,
+					noConfidenceProposalVotes,
+				},
+			},
+		},
+		{
+			name:       "without votes",
+			query:      "?include_votes=false",
+			queries:    allQueries,
+			results:    allResults,
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryProposals{
+				Total: 2,
+				Proposals: []responseGovernanceProposal{
+					infoProposal,
+					noConfidenceProposal,
+				},
+			},
+		},
+		{
+			name:       "by type",
+			query:      "?type=no_confidence&type=treasury_withdrawals&include_votes=false",
+			queries:    allQueries,
+			results:    allResults,
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryProposals{
+				Total:     1,
+				Proposals: []responseGovernanceProposal{noConfidenceProposal},
+			},
+		},
+		{
+			name:  "by action id",
+			query: "?action_id=" + testProposalNoConfidenceId + "&include_votes=false",
+			queries: []string{
+				"820082028101",
+				// [0, [0, [6, [31, [[tx hash, 1]]]]]]
+				"82008200820682181f81825820cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd01",
+			},
+			results: []string{
+				"06",
+				"8181" + testProposalNoConfidenceHex,
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryProposals{
+				Total:     1,
+				Proposals: []responseGovernanceProposal{noConfidenceProposal},
+			},
+		},
+		{
+			name:       "invalid action id",
+			query:      "?action_id=cdcd%231",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid type",
+			query:      "?type=motion",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "babbage",
+			queries:    []string{"820082028101"},
+			results:    []string{"05"},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if len(testDef.queries) > 0 {
+				startLedgerQueryMockNode(t, testDef.queries, testDef.results)
+			}
+			w := serveTestRequest(
+				http.MethodGet,
+				"/governance/proposals",
+				handleLocalStateQueryProposals,
+				"/governance/proposals"+testDef.query,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryProposals
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.want) {
+				t.Fatalf("unexpected response: %s", w.Body.String())
+			}
+		})
+	}
+}
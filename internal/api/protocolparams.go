@@ -0,0 +1,614 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// protocolParamsCache holds the protocol parameters until the end of the epoch
+// that they were queried in, since updates only take effect at epoch boundaries
+type protocolParamsCache struct {
+	mutex     sync.Mutex
+	era       ledger.Era
+	params    localstatequery.CurrentProtocolParamsResult
+	expiresAt time.Time
+}
+
+var protocolParams = &protocolParamsCache{}
+
+// get returns the cached protocol parameters if they haven't expired
+func (p *protocolParamsCache) get() (
+	ledger.Era,
+	localstatequery.CurrentProtocolParamsResult,
+	bool,
+) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.params == nil || !time.Now().Before(p.expiresAt) {
+		return ledger.Era{}, nil, false
+	}
+	return p.era, p.params, true
+}
+
+func (p *protocolParamsCache) set(
+	era ledger.Era,
+	params localstatequery.CurrentProtocolParamsResult,
+	expiresAt time.Time,
+) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.era = era
+	p.params = params
+	p.expiresAt = expiresAt
+}
+
+// Names of the Plutus language versions used as cost model keys
+var costModelLanguages = map[uint64]string{
+	0: "PlutusV1",
+	1: "PlutusV2",
+	2: "PlutusV3",
+}
+
+// responseProtocolParameters maps the era-specific protocol parameters onto a
+// single schema. Fields that don't apply to the current era are null
+type responseProtocolParameters struct {
+	Era                        string                       `json:"era"                        example:"conway"`
+	MinFeeA                    *uint64                      `json:"minFeeA"                    example:"44"`
+	MinFeeB                    *uint64                      `json:"minFeeB"                    example:"155381"`
+	MaxBlockBodySize           *uint64                      `json:"maxBlockBodySize"           example:"90112"`
+	MaxTxSize                  *uint64                      `json:"maxTxSize"                  example:"16384"`
+	MaxBlockHeaderSize         *uint64                      `json:"maxBlockHeaderSize"         example:"1100"`
+	KeyDeposit                 *uint64                      `json:"keyDeposit"                 example:"2000000"`
+	PoolDeposit                *uint64                      `json:"poolDeposit"                example:"500000000"`
+	MaxEpoch                   *uint64                      `json:"maxEpoch"                   example:"18"`
+	NOpt                       *uint64                      `json:"nOpt"                       example:"500"`
+	A0                         *float64                     `json:"a0"                         example:"0.3"`
+	Rho                        *float64                     `json:"rho"                        example:"0.003"`
+	Tau                        *float64                     `json:"tau"                        example:"0.2"`
+	Decentralization           *float64                     `json:"decentralization"`
+	ProtocolVersion            *responseProtocolVersion     `json:"protocolVersion"`
+	MinUtxoValue               *uint64                      `json:"minUtxoValue"`
+	MinPoolCost                *uint64                      `json:"minPoolCost"                example:"170000000"`
+	UtxoCostPerWord            *uint64                      `json:"utxoCostPerWord"`
+	UtxoCostPerByte            *uint64                      `json:"utxoCostPerByte"            example:"4310"`
+	CostModels                 map[string][]int64           `json:"costModels"`
+	ExecutionUnitPrices        *responseExecutionUnitPrices `json:"executionUnitPrices"`
+	MaxTxExecutionUnits        *responseExecutionUnits      `json:"maxTxExecutionUnits"`
+	MaxBlockExecutionUnits     *responseExecutionUnits      `json:"maxBlockExecutionUnits"`
+	MaxValueSize               *uint64                      `json:"maxValueSize"               example:"5000"`
+	CollateralPercentage       *uint64                      `json:"collateralPercentage"       example:"150"`
+	MaxCollateralInputs        *uint64                      `json:"maxCollateralInputs"        example:"3"`
+	PoolVotingThresholds       []float64                    `json:"poolVotingThresholds"`
+	DRepVotingThresholds       []float64                    `json:"dRepVotingThresholds"`
+	CommitteeMinSize           *uint64                      `json:"committeeMinSize"           example:"7"`
+	CommitteeMaxTermLength     *uint64                      `json:"committeeMaxTermLength"     example:"146"`
+	GovActionLifetime          *uint64                      `json:"govActionLifetime"          example:"6"`
+	GovActionDeposit           *uint64                      `json:"govActionDeposit"           example:"100000000000"`
+	DRepDeposit                *uint64                      `json:"dRepDeposit"                example:"500000000"`
+	DRepActivity               *uint64                      `json:"dRepActivity"               example:"20"`
+	MinFeeRefScriptCostPerByte *float64                     `json:"minFeeRefScriptCostPerByte" example:"15"`
+}
+
+type responseProtocolVersion struct {
+	Major uint64 `json:"major" example:"9"`
+	Minor uint64 `json:"minor" example:"1"`
+}
+
+type responseExecutionUnitPrices struct {
+	PriceMemory float64 `json:"priceMemory" example:"0.0577"`
+	PriceSteps  float64 `json:"priceSteps"  example:"0.0000721"`
+}
+
+type responseExecutionUnits struct {
+	Memory uint64 `json:"memory" example:"14000000"`
+	Steps  uint64 `json:"steps"  example:"10000000000"`
+}
+
+// newResponseProtocolParameters maps the protocol parameters returned by the node
+// for the current era. gouroboros doesn't have a type for the Conway parameters
+// yet, so those are decoded generically
+func newResponseProtocolParameters(
+	era ledger.Era,
+	params localstatequery.CurrentProtocolParamsResult,
+) (responseProtocolParameters, error) {
+	resp := responseProtocolParameters{
+		Era: strings.ToLower(era.Name),
+	}
+	switch p := params.(type) {
+	case ledger.ShelleyProtocolParameters:
+		resp.setShelley(p)
+	case ledger.AllegraProtocolParameters:
+		resp.setShelley(p.ShelleyProtocolParameters)
+	case ledger.MaryProtocolParameters:
+		resp.setShelley(p.ShelleyProtocolParameters)
+	case ledger.AlonzoProtocolParameters:
+		resp.setShelley(p.ShelleyProtocolParameters)
+		// The min UTxO value was replaced by the cost per UTxO word
+		resp.MinUtxoValue = nil
+		resp.MinPoolCost = uintPtr(p.MinPoolCost)
+		resp.UtxoCostPerWord = uintPtr(p.AdaPerUtxoByte)
+		resp.MaxValueSize = uintPtr(p.MaxValueSize)
+		resp.CollateralPercentage = uintPtr(p.CollateralPercentage)
+		resp.MaxCollateralInputs = uintPtr(p.MaxCollateralInputs)
+	case ledger.BabbageProtocolParameters:
+		if err := resp.setBabbage(p); err != nil {
+			return resp, err
+		}
+	case []any:
+		if err := resp.setConway(p); err != nil {
+			return resp, err
+		}
+	default:
+		return resp, fmt.Errorf("unsupported protocol parameters type: %T", params)
+	}
+	return resp, nil
+}
+
+func (r *responseProtocolParameters) setShelley(
+	p ledger.ShelleyProtocolParameters,
+) {
+	r.MinFeeA = uintPtr(p.MinFeeA)
+	r.MinFeeB = uintPtr(p.MinFeeB)
+	r.MaxBlockBodySize = uintPtr(p.MaxBlockBodySize)
+	r.MaxTxSize = uintPtr(p.MaxTxSize)
+	r.MaxBlockHeaderSize = uintPtr(p.MaxBlockHeaderSize)
+	r.KeyDeposit = uintPtr(p.KeyDeposit)
+	r.PoolDeposit = uintPtr(p.PoolDeposit)
+	r.MaxEpoch = uintPtr(p.MaxEpoch)
+	r.NOpt = uintPtr(p.NOpt)
+	r.A0 = ratPtr(p.A0)
+	r.Rho = ratPtr(p.Rho)
+	r.Tau = ratPtr(p.Tau)
+	r.Decentralization = ratPtr(p.Decentralization)
+	r.ProtocolVersion = &responseProtocolVersion{
+		Major: uint64(p.ProtocolMajor),
+		Minor: uint64(p.ProtocolMinor),
+	}
+	r.MinUtxoValue = uintPtr(p.MinUtxoValue)
+}
+
+func (r *responseProtocolParameters) setBabbage(
+	p ledger.BabbageProtocolParameters,
+) error {
+	r.MinFeeA = uintPtr(p.MinFeeA)
+	r.MinFeeB = uintPtr(p.MinFeeB)
+	r.MaxBlockBodySize = uintPtr(p.MaxBlockBodySize)
+	r.MaxTxSize = uintPtr(p.MaxTxSize)
+	r.MaxBlockHeaderSize = uintPtr(p.MaxBlockHeaderSize)
+	r.KeyDeposit = uintPtr(p.KeyDeposit)
+	r.PoolDeposit = uintPtr(p.PoolDeposit)
+	r.MaxEpoch = uintPtr(p.MaxEpoch)
+	r.NOpt = uintPtr(p.NOpt)
+	r.A0 = ratPtr(p.A0)
+	r.Rho = ratPtr(p.Rho)
+	r.Tau = ratPtr(p.Tau)
+	r.ProtocolVersion = &responseProtocolVersion{
+		Major: uint64(p.ProtocolMajor),
+		Minor: uint64(p.ProtocolMinor),
+	}
+	r.MinPoolCost = uintPtr(p.MinPoolCost)
+	r.UtxoCostPerByte = uintPtr(p.AdaPerUtxoByte)
+	r.CostModels = make(map[string][]int64, len(p.CostModels))
+	for lang, costs := range p.CostModels {
+		tmpCosts := make([]int64, 0, len(costs))
+		for _, cost := range costs {
+			tmpCosts = append(tmpCosts, int64(cost))
+		}
+		r.CostModels[costModelLanguage(uint64(lang))] = tmpCosts
+	}
+	if len(p.ExecutionUnitPrices) != 2 ||
+		p.ExecutionUnitPrices[0] == nil ||
+		p.ExecutionUnitPrices[1] == nil {
+		return fmt.Errorf("invalid execution unit prices")
+	}
+	r.ExecutionUnitPrices = &responseExecutionUnitPrices{
+		PriceMemory: ratFloat(p.ExecutionUnitPrices[0]),
+		PriceSteps:  ratFloat(p.ExecutionUnitPrices[1]),
+	}
+	if len(p.MaxTxExecutionUnits) != 2 ||
+		len(p.MaxBlockExecutionUnits) != 2 {
+		return fmt.Errorf("invalid max execution units")
+	}
+	r.MaxTxExecutionUnits = &responseExecutionUnits{
+		Memory: uint64(p.MaxTxExecutionUnits[0]),
+		Steps:  uint64(p.MaxTxExecutionUnits[1]),
+	}
+	r.MaxBlockExecutionUnits = &responseExecutionUnits{
+		Memory: uint64(p.MaxBlockExecutionUnits[0]),
+		Steps:  uint64(p.MaxBlockExecutionUnits[1]),
+	}
+	r.MaxValueSize = uintPtr(p.MaxValueSize)
+	r.CollateralPercentage = uintPtr(p.CollateralPercentage)
+	r.MaxCollateralInputs = uintPtr(p.MaxCollateralInputs)
+	return nil
+}
+
+// setConway maps the generically decoded Conway parameters, which are a list in
+// the order defined by the ledger
+func (r *responseProtocolParameters) setConway(p []any) error {
+	d := &genericParamsDecoder{params: p}
+	r.MinFeeA = d.uint()
+	r.MinFeeB = d.uint()
+	r.MaxBlockBodySize = d.uint()
+	r.MaxTxSize = d.uint()
+	r.MaxBlockHeaderSize = d.uint()
+	r.KeyDeposit = d.uint()
+	r.PoolDeposit = d.uint()
+	r.MaxEpoch = d.uint()
+	r.NOpt = d.uint()
+	r.A0 = d.rat()
+	r.Rho = d.rat()
+	r.Tau = d.rat()
+	r.ProtocolVersion = d.protocolVersion()
+	r.MinPoolCost = d.uint()
+	r.UtxoCostPerByte = d.uint()
+	r.CostModels = d.costModels()
+	if prices := d.rats(); len(prices) == 2 {
+		r.ExecutionUnitPrices = &responseExecutionUnitPrices{
+			PriceMemory: prices[0],
+			PriceSteps:  prices[1],
+		}
+	}
+	r.MaxTxExecutionUnits = d.executionUnits()
+	r.MaxBlockExecutionUnits = d.executionUnits()
+	r.MaxValueSize = d.uint()
+	r.CollateralPercentage = d.uint()
+	r.MaxCollateralInputs = d.uint()
+	r.PoolVotingThresholds = d.rats()
+	r.DRepVotingThresholds = d.rats()
+	r.CommitteeMinSize = d.uint()
+	r.CommitteeMaxTermLength = d.uint()
+	r.GovActionLifetime = d.uint()
+	r.GovActionDeposit = d.uint()
+	r.DRepDeposit = d.uint()
+	r.DRepActivity = d.uint()
+	r.MinFeeRefScriptCostPerByte = d.rat()
+	return d.err
+}
+
+// genericParamsDecoder reads values in order from generically decoded protocol
+// parameters, keeping the first error
+type genericParamsDecoder struct {
+	params []any
+	idx    int
+	err    error
+}
+
+func (d *genericParamsDecoder) next() any {
+	if d.err != nil {
+		return nil
+	}
+	if d.idx >= len(d.params) {
+		d.err = fmt.Errorf(
+			"protocol parameters too short: %d items",
+			len(d.params),
+		)
+		return nil
+	}
+	ret := d.params[d.idx]
+	d.idx++
+	return ret
+}
+
+func (d *genericParamsDecoder) fail(name string, value any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(
+			"unexpected %s in protocol parameter %d: %T",
+			name,
+			d.idx-1,
+			value,
+		)
+	}
+}
+
+func (d *genericParamsDecoder) uint() *uint64 {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	ret, ok := value.(uint64)
+	if !ok {
+		d.fail("integer", value)
+		return nil
+	}
+	return &ret
+}
+
+func (d *genericParamsDecoder) rat() *float64 {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	ret, ok := genericRat(value)
+	if !ok {
+		d.fail("rational", value)
+		return nil
+	}
+	return &ret
+}
+
+func (d *genericParamsDecoder) rats() []float64 {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		d.fail("rational list", value)
+		return nil
+	}
+	ret := make([]float64, 0, len(items))
+	for _, item := range items {
+		tmpRat, ok := genericRat(item)
+		if !ok {
+			d.fail("rational", item)
+			return nil
+		}
+		ret = append(ret, tmpRat)
+	}
+	return ret
+}
+
+// protocolVersion reads the protocol version, which may be encoded as a nested
+// list or as two separate items
+func (d *genericParamsDecoder) protocolVersion() *responseProtocolVersion {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	if items, ok := value.([]any); ok && len(items) == 2 {
+		major, majorOk := items[0].(uint64)
+		minor, minorOk := items[1].(uint64)
+		if !majorOk || !minorOk {
+			d.fail("protocol version", value)
+			return nil
+		}
+		return &responseProtocolVersion{Major: major, Minor: minor}
+	}
+	major, ok := value.(uint64)
+	if !ok {
+		d.fail("protocol version", value)
+		return nil
+	}
+	minor := d.uint()
+	if minor == nil {
+		return nil
+	}
+	return &responseProtocolVersion{Major: major, Minor: *minor}
+}
+
+func (d *genericParamsDecoder) costModels() map[string][]int64 {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	models, ok := value.(map[any]any)
+	if !ok {
+		d.fail("cost models", value)
+		return nil
+	}
+	ret := make(map[string][]int64, len(models))
+	for lang, costs := range models {
+		langId, ok := lang.(uint64)
+		if !ok {
+			d.fail("cost model language", lang)
+			return nil
+		}
+		costItems, ok := costs.([]any)
+		if !ok {
+			d.fail("cost model", costs)
+			return nil
+		}
+		tmpCosts := make([]int64, 0, len(costItems))
+		for _, cost := range costItems {
+			switch v := cost.(type) {
+			case uint64:
+				tmpCosts = append(tmpCosts, int64(v))
+			case int64:
+				tmpCosts = append(tmpCosts, v)
+			default:
+				d.fail("cost", cost)
+				return nil
+			}
+		}
+		ret[costModelLanguage(langId)] = tmpCosts
+	}
+	return ret
+}
+
+func (d *genericParamsDecoder) executionUnits() *responseExecutionUnits {
+	value := d.next()
+	if d.err != nil {
+		return nil
+	}
+	items, ok := value.([]any)
+	if !ok || len(items) != 2 {
+		d.fail("execution units", value)
+		return nil
+	}
+	memory, memoryOk := items[0].(uint64)
+	steps, stepsOk := items[1].(uint64)
+	if !memoryOk || !stepsOk {
+		d.fail("execution units", value)
+		return nil
+	}
+	return &responseExecutionUnits{Memory: memory, Steps: steps}
+}
+
+func genericRat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case cbor.Rat:
+		if v.Rat == nil {
+			return 0, false
+		}
+		ret, _ := v.Float64()
+		return ret, true
+	case *cbor.Rat:
+		if v == nil || v.Rat == nil {
+			return 0, false
+		}
+		ret, _ := v.Float64()
+		return ret, true
+	}
+	return 0, false
+}
+
+func costModelLanguage(lang uint64) string {
+	if name, ok := costModelLanguages[lang]; ok {
+		return name
+	}
+	return fmt.Sprintf("PlutusV%d", lang+1)
+}
+
+func uintPtr(value uint) *uint64 {
+	ret := uint64(value)
+	return &ret
+}
+
+func ratPtr(value *cbor.Rat) *float64 {
+	if value == nil || value.Rat == nil {
+		return nil
+	}
+	ret := ratFloat(value)
+	return &ret
+}
+
+func ratFloat(value *cbor.Rat) float64 {
+	ret, _ := value.Float64()
+	return ret
+}
+
+// Number of decimal places in rationals formatted as strings
+const ratDecimalPlaces = 20
+
+// formatRat formats a rational as a decimal string without trailing zeros, for
+// values that lose precision as floats
+func formatRat(value *big.Rat) string {
+	ret := value.FloatString(ratDecimalPlaces)
+	if strings.Contains(ret, ".") {
+		ret = strings.TrimSuffix(strings.TrimRight(ret, "0"), ".")
+	}
+	return ret
+}
+
+// Names of the governance voting thresholds used by cardano-cli, in the order
+// that the ledger encodes them
+var (
+	cliPoolVotingThresholds = []string{
+		"motionNoConfidence",
+		"committeeNormal",
+		"committeeNoConfidence",
+		"hardForkInitiation",
+		"ppSecurityGroup",
+	}
+	cliDRepVotingThresholds = []string{
+		"motionNoConfidence",
+		"committeeNormal",
+		"committeeNoConfidence",
+		"updateToConstitution",
+		"hardForkInitiation",
+		"ppNetworkGroup",
+		"ppEconomicGroup",
+		"ppTechnicalGroup",
+		"ppGovGroup",
+		"treasuryWithdrawal",
+	}
+)
+
+// cliProtocolParameters renders the protocol parameters in the shape output by
+// `cardano-cli query protocol-parameters` for the era. Map keys are sorted
+// when encoded, which matches the cardano-cli output
+func cliProtocolParameters(
+	era ledger.Era,
+	p responseProtocolParameters,
+) map[string]any {
+	ret := map[string]any{
+		"maxBlockBodySize":    p.MaxBlockBodySize,
+		"maxBlockHeaderSize":  p.MaxBlockHeaderSize,
+		"maxTxSize":           p.MaxTxSize,
+		"monetaryExpansion":   p.Rho,
+		"poolPledgeInfluence": p.A0,
+		"poolRetireMaxEpoch":  p.MaxEpoch,
+		"protocolVersion":     p.ProtocolVersion,
+		"stakeAddressDeposit": p.KeyDeposit,
+		"stakePoolDeposit":    p.PoolDeposit,
+		"stakePoolTargetNum":  p.NOpt,
+		"treasuryCut":         p.Tau,
+		"txFeeFixed":          p.MinFeeB,
+		"txFeePerByte":        p.MinFeeA,
+	}
+	// The Conway output comes from the ledger types and drops the parameters
+	// that no longer exist, while earlier eras always include them
+	if era.Id < ledger.EraIdConway {
+		ret["decentralization"] = p.Decentralization
+		ret["extraPraosEntropy"] = nil
+		ret["minUTxOValue"] = p.MinUtxoValue
+	}
+	if era.Id < ledger.EraIdAlonzo {
+		return ret
+	}
+	ret["collateralPercentage"] = p.CollateralPercentage
+	ret["costModels"] = p.CostModels
+	ret["executionUnitPrices"] = p.ExecutionUnitPrices
+	ret["maxBlockExecutionUnits"] = p.MaxBlockExecutionUnits
+	ret["maxCollateralInputs"] = p.MaxCollateralInputs
+	ret["maxTxExecutionUnits"] = p.MaxTxExecutionUnits
+	ret["maxValueSize"] = p.MaxValueSize
+	ret["minPoolCost"] = p.MinPoolCost
+	if era.Id == ledger.EraIdAlonzo {
+		ret["utxoCostPerWord"] = p.UtxoCostPerWord
+		return ret
+	}
+	ret["utxoCostPerByte"] = p.UtxoCostPerByte
+	if era.Id < ledger.EraIdConway {
+		return ret
+	}
+	ret["committeeMaxTermLength"] = p.CommitteeMaxTermLength
+	ret["committeeMinSize"] = p.CommitteeMinSize
+	ret["dRepActivity"] = p.DRepActivity
+	ret["dRepDeposit"] = p.DRepDeposit
+	ret["dRepVotingThresholds"] = namedThresholds(
+		cliDRepVotingThresholds,
+		p.DRepVotingThresholds,
+	)
+	ret["govActionDeposit"] = p.GovActionDeposit
+	ret["govActionLifetime"] = p.GovActionLifetime
+	ret["minFeeRefScriptCostPerByte"] = p.MinFeeRefScriptCostPerByte
+	ret["poolVotingThresholds"] = namedThresholds(
+		cliPoolVotingThresholds,
+		p.PoolVotingThresholds,
+	)
+	return ret
+}
+
+// namedThresholds pairs the voting thresholds with their names
+func namedThresholds(names []string, thresholds []float64) map[string]float64 {
+	ret := make(map[string]float64, len(names))
+	for idx, threshold := range thresholds {
+		if idx < len(names) {
+			ret[names[idx]] = threshold
+		}
+	}
+	return ret
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// Conway protocol parameters as the node sends them, as hex. They're the
+// mainnet parameters with shortened cost models, except that each voting
+// threshold is different, so that a threshold with the wrong name shows up
+const testConwayProtocolParamsHex = "981f182c1a00025ef51a0001600019400019044c1a001e84801a1dcd6500121901f4d81e82030a" +
+	"d81e82031903e8d81e820105820a001a0a21fe801910d6a300831a000189b41901a40101841a000189b41901a401" +
+	"1903e802851a000189b41901a4011903e818ad82d81e82190241192710d81e821902d11a00989680821a00d59f80" +
+	"1b00000002540be400821a03b20b801b00000004a817c80019138818960385d81e8218331864d81e8218341864d8" +
+	"1e8218351864d81e8218361864d81e82183718648ad81e82183d1864d81e82183e1864d81e82183f1864d81e8218" +
+	"401864d81e8218411864d81e8218421864d81e8218431864d81e8218441864d81e8218451864d81e8218461864071892" +
+	"061b000000174876e8001a1dcd650014d81e820f01"
+
+// The golden files are in the layout of cardano-cli query protocol-parameters.
+// cardano-cli writes some numbers in exponent form, such as 5.77e-2, so the
+// values are compared rather than the bytes
+func TestCliProtocolParameters(t *testing.T) {
+	testDefs := []struct {
+		name       string
+		eraId      uint8
+		params     func(*testing.T) localstatequery.CurrentProtocolParamsResult
+		goldenFile string
+	}{
+		{
+			name:  "babbage",
+			eraId: ledger.EraIdBabbage,
+			params: func(t *testing.T) localstatequery.CurrentProtocolParamsResult {
+				return ledger.BabbageProtocolParameters{
+					MinFeeA:            44,
+					MinFeeB:            155381,
+					MaxBlockBodySize:   90112,
+					MaxTxSize:          16384,
+					MaxBlockHeaderSize: 1100,
+					KeyDeposit:         2000000,
+					PoolDeposit:        500000000,
+					MaxEpoch:           18,
+					NOpt:               500,
+					A0:                 testRat(3, 10),
+					Rho:                testRat(3, 1000),
+					Tau:                testRat(1, 5),
+					ProtocolMajor:      8,
+					MinPoolCost:        170000000,
+					AdaPerUtxoByte:     4310,
+					CostModels: map[uint][]int{
+						0: {100788, 420, 1},
+						1: {100788, 420, 1, 1000},
+					},
+					ExecutionUnitPrices: []*cbor.Rat{
+						testRat(577, 10000),
+						testRat(721, 10000000),
+					},
+					MaxTxExecutionUnits:    []uint{14000000, 10000000000},
+					MaxBlockExecutionUnits: []uint{62000000, 20000000000},
+					MaxValueSize:           5000,
+					CollateralPercentage:   150,
+					MaxCollateralInputs:    3,
+				}
+			},
+			goldenFile: "protocol-parameters-babbage.json",
+		},
+		{
+			name:  "conway",
+			eraId: ledger.EraIdConway,
+			params: func(t *testing.T) localstatequery.CurrentProtocolParamsResult {
+				paramsCbor, err := hex.DecodeString(testConwayProtocolParamsHex)
+				if err != nil {
+					t.Fatalf("invalid test params hex: %s", err)
+				}
+				var params []any
+				if _, err := cbor.Decode(paramsCbor, &params); err != nil {
+					t.Fatalf("failed to decode test params: %s", err)
+				}
+				return params
+			},
+			goldenFile: "protocol-parameters-conway.json",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			era := ledger.GetEraById(testDef.eraId)
+			params, err := newResponseProtocolParameters(era, testDef.params(t))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			gotJson, err := json.Marshal(cliProtocolParameters(era, params))
+			if err != nil {
+				t.Fatalf("failed to encode parameters: %s", err)
+			}
+			wantJson, err := os.ReadFile(
+				filepath.Join("testdata", testDef.goldenFile),
+			)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %s", err)
+			}
+			var got, want map[string]any
+			if err := json.Unmarshal(gotJson, &got); err != nil {
+				t.Fatalf("failed to decode parameters: %s", err)
+			}
+			if err := json.Unmarshal(wantJson, &want); err != nil {
+				t.Fatalf("invalid golden file: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected parameters:\n got: %s\nwant: %s", gotJson, wantJson)
+			}
+		})
+	}
+}
+
+func testRat(num int64, denom int64) *cbor.Rat {
+	return &cbor.Rat{Rat: big.NewRat(num, denom)}
+}
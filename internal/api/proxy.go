@@ -0,0 +1,93 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// Internal header holding the addresses from the RFC 7239 Forwarded header in
+// X-Forwarded-For format, which is the only format gin can resolve client IPs from
+const forwardedForHeader = "X-Cardano-Node-Api-Forwarded-For"
+
+// configureTrustedProxies sets the proxies that are trusted to report the client IP
+// and the header that it's read from. An empty list of trusted proxies means that
+// the address of the direct peer is always used
+func configureTrustedProxies(router *gin.Engine, apiCfg config.ApiConfig) error {
+	trustedProxies := apiCfg.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %s", err)
+	}
+	switch apiCfg.ClientIpHeader {
+	case config.ClientIpHeaderXForwardedFor:
+		router.RemoteIPHeaders = []string{"X-Forwarded-For"}
+	case config.ClientIpHeaderXRealIp:
+		router.RemoteIPHeaders = []string{"X-Real-IP"}
+	case config.ClientIpHeaderForwarded:
+		router.RemoteIPHeaders = []string{forwardedForHeader}
+		router.Use(forwardedHeaderMiddleware)
+	}
+	return nil
+}
+
+// forwardedHeaderMiddleware converts the RFC 7239 Forwarded header into our
+// internal X-Forwarded-For style header so that gin can resolve the client IP
+func forwardedHeaderMiddleware(c *gin.Context) {
+	// Never trust a client-provided copy of our internal header
+	c.Request.Header.Del(forwardedForHeader)
+	if forwarded := c.Request.Header.Values("Forwarded"); len(forwarded) > 0 {
+		if addrs := parseForwardedFor(forwarded); len(addrs) > 0 {
+			c.Request.Header.Set(forwardedForHeader, strings.Join(addrs, ", "))
+		}
+	}
+	c.Next()
+}
+
+// parseForwardedFor returns the addresses from the "for" parameters of Forwarded
+// header values, in order. Obfuscated and unknown identifiers are returned as-is
+// so that gin treats them as invalid and stops resolving at that hop
+func parseForwardedFor(values []string) []string {
+	var ret []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+				ret = append(ret, forwardedNodeAddr(strings.Trim(val, `"`)))
+			}
+		}
+	}
+	return ret
+}
+
+// forwardedNodeAddr strips the port and IPv6 brackets from a Forwarded node
+// identifier, such as "[2001:db8::17]:4711" or "192.0.2.60:8080"
+func forwardedNodeAddr(node string) string {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+}
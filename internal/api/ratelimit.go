@@ -0,0 +1,164 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+	"golang.org/x/time/rate"
+)
+
+const (
+	rateLimiterCleanupInterval = 1 * time.Minute
+	// Clients not seen for this long have their token bucket discarded
+	rateLimiterIdleTimeout = 5 * time.Minute
+)
+
+// rateLimiter maintains a token bucket per client IP
+type rateLimiter struct {
+	limit   rate.Limit
+	burst   int
+	mutex   sync.Mutex
+	clients map[string]*rateLimiterClient
+}
+
+type rateLimiterClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRateLimiter returns a rate limiter allowing the specified number of requests
+// per second for each client. A requestsPerSecond of 0 allows all requests
+func newRateLimiter(requestsPerSecond float64, burst int) *rateLimiter {
+	r := &rateLimiter{
+		clients: make(map[string]*rateLimiterClient),
+	}
+	r.SetLimit(requestsPerSecond, burst)
+	go r.cleanup()
+	return r
+}
+
+// Enabled returns whether the limiter is limiting requests
+func (r *rateLimiter) Enabled() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.limit > 0
+}
+
+// SetLimit changes the limit for new and existing clients
+func (r *rateLimiter) SetLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond < 0 {
+		requestsPerSecond = 0
+	}
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(requestsPerSecond)))
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.limit = rate.Limit(requestsPerSecond)
+	r.burst = burst
+	for _, client := range r.clients {
+		client.limiter.SetLimit(r.limit)
+		client.limiter.SetBurst(r.burst)
+	}
+}
+
+// Allow consumes a token for the client. If no token is available, it returns false
+// along with how long the client should wait before retrying
+func (r *rateLimiter) Allow(clientIp string) (bool, time.Duration) {
+	now := time.Now()
+	r.mutex.Lock()
+	if r.limit == 0 {
+		r.mutex.Unlock()
+		return true, 0
+	}
+	client, ok := r.clients[clientIp]
+	if !ok {
+		client = &rateLimiterClient{
+			limiter: rate.NewLimiter(r.limit, r.burst),
+		}
+		r.clients[clientIp] = client
+	}
+	client.lastSeen = now
+	r.mutex.Unlock()
+	reservation := client.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// Give the token back, since we're rejecting the request
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (r *rateLimiter) cleanup() {
+	for {
+		time.Sleep(rateLimiterCleanupInterval)
+		r.mutex.Lock()
+		for clientIp, client := range r.clients {
+			if time.Since(client.lastSeen) > rateLimiterIdleTimeout {
+				delete(r.clients, clientIp)
+			}
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// Cheap routes that clients poll, which get the light limiter if it's enabled
+var lightRateLimitRoutes = map[string]bool{
+	"/localtxmonitor/sizes": true,
+}
+
+// rateLimitMiddleware applies the submit limiter to the localtxsubmission and
+// submit route groups, the light limiter to cheap routes if it's enabled, and the
+// default limiter to all others
+func rateLimitMiddleware(
+	defaultLimiter *rateLimiter,
+	submitLimiter *rateLimiter,
+	lightLimiter *rateLimiter,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := routeGroup(c)
+		limiter := defaultLimiter
+		if group == "localtxsubmission" || group == submitApiGroup {
+			limiter = submitLimiter
+		} else if lightRateLimitRoutes[apiRoutePath(c)] && lightLimiter.Enabled() {
+			limiter = lightLimiter
+		}
+		if ok, retryAfter := limiter.Allow(c.ClientIP()); !ok {
+			_ = ginmetrics.GetMonitor().
+				GetMetric(metricRateLimited).
+				Inc([]string{group})
+			c.Header(
+				"Retry-After",
+				strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))),
+			)
+			c.Abort()
+			respondError(
+				c,
+				http.StatusTooManyRequests,
+				apiErrorCode(errorCodeRateLimited, "rate limit exceeded", nil),
+			)
+			return
+		}
+		c.Next()
+	}
+}
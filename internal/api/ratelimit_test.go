@@ -0,0 +1,202 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiter(t *testing.T) {
+	testDefs := []struct {
+		name              string
+		requestsPerSecond float64
+		burst             int
+		// Client IP of each request, in order
+		clients     []string
+		wantAllowed []bool
+	}{
+		{
+			name:              "disabled",
+			requestsPerSecond: 0,
+			clients:           []string{"a", "a", "a"},
+			wantAllowed:       []bool{true, true, true},
+		},
+		{
+			name:              "burst",
+			requestsPerSecond: 1,
+			burst:             2,
+			clients:           []string{"a", "a", "a"},
+			wantAllowed:       []bool{true, true, false},
+		},
+		{
+			name:              "default burst is rate",
+			requestsPerSecond: 2.5,
+			clients:           []string{"a", "a", "a", "a"},
+			wantAllowed:       []bool{true, true, true, false},
+		},
+		{
+			name:              "default burst is at least one",
+			requestsPerSecond: 0.1,
+			clients:           []string{"a", "a"},
+			wantAllowed:       []bool{true, false},
+		},
+		{
+			name:              "separate bucket per client",
+			requestsPerSecond: 1,
+			burst:             1,
+			clients:           []string{"a", "b", "a", "b"},
+			wantAllowed:       []bool{true, true, false, false},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			limiter := newRateLimiter(testDef.requestsPerSecond, testDef.burst)
+			if limiter.Enabled() != (testDef.requestsPerSecond > 0) {
+				t.Fatalf("unexpected enabled state: %v", limiter.Enabled())
+			}
+			for idx, client := range testDef.clients {
+				allowed, retryAfter := limiter.Allow(client)
+				if allowed != testDef.wantAllowed[idx] {
+					t.Fatalf("request %d: unexpected allowed: %v", idx, allowed)
+				}
+				if allowed && retryAfter != 0 {
+					t.Fatalf("request %d: retry after set when allowed", idx)
+				}
+				if !allowed && retryAfter <= 0 {
+					t.Fatalf("request %d: no retry after when rejected", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterSetLimit(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	if allowed, _ := limiter.Allow("a"); !allowed {
+		t.Fatalf("first request rejected")
+	}
+	if allowed, _ := limiter.Allow("a"); allowed {
+		t.Fatalf("request over the limit allowed")
+	}
+	// Existing clients get the new rate
+	limiter.SetLimit(1000, 1)
+	time.Sleep(10 * time.Millisecond)
+	if allowed, _ := limiter.Allow("a"); !allowed {
+		t.Fatalf("request rejected after raising the limit")
+	}
+	limiter.SetLimit(0, 0)
+	if limiter.Enabled() {
+		t.Fatalf("limiter enabled after removing the limit")
+	}
+	if allowed, _ := limiter.Allow("a"); !allowed {
+		t.Fatalf("request rejected after removing the limit")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// Paths of the requests, in order
+		paths []string
+		// Limits for each limiter, which allow one request per client when set
+		defaultLimit bool
+		submitLimit  bool
+		lightLimit   bool
+		wantStatus   []int
+	}{
+		{
+			name:         "default limiter",
+			paths:        []string{"/api/v1/localstatequery/tip", "/api/v1/localstatequery/era"},
+			defaultLimit: true,
+			wantStatus:   []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:         "submit routes use submit limiter",
+			paths:        []string{"/api/v1/localtxsubmission/tx", "/api/v1/localstatequery/tip"},
+			defaultLimit: true,
+			wantStatus:   []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:        "submit limiter",
+			paths:       []string{"/api/v1/localtxsubmission/tx", "/api/submit/tx", "/api/v1/localstatequery/tip"},
+			submitLimit: true,
+			wantStatus:  []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:         "light routes use light limiter",
+			paths:        []string{"/api/v1/localtxmonitor/sizes", "/api/v1/localtxmonitor/sizes", "/api/v1/localstatequery/tip"},
+			defaultLimit: true,
+			lightLimit:   true,
+			wantStatus:   []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:         "light routes use default limiter when light limiter is disabled",
+			paths:        []string{"/api/v1/localtxmonitor/sizes", "/api/v1/localstatequery/tip"},
+			defaultLimit: true,
+			wantStatus:   []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			limiters := []*rateLimiter{
+				newRateLimiter(0, 0),
+				newRateLimiter(0, 0),
+				newRateLimiter(0, 0),
+			}
+			for idx, enabled := range []bool{
+				testDef.defaultLimit,
+				testDef.submitLimit,
+				testDef.lightLimit,
+			} {
+				if enabled {
+					limiters[idx].SetLimit(0.01, 1)
+				}
+			}
+			router := gin.New()
+			router.Use(rateLimitMiddleware(limiters[0], limiters[1], limiters[2]))
+			for _, path := range []string{
+				"/api/v1/localstatequery/tip",
+				"/api/v1/localstatequery/era",
+				"/api/v1/localtxsubmission/tx",
+				"/api/submit/tx",
+				"/api/v1/localtxmonitor/sizes",
+			} {
+				router.GET(path, func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+			}
+			for idx, path := range testDef.paths {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				if w.Code != testDef.wantStatus[idx] {
+					t.Fatalf("request %d to %s: unexpected status %d", idx, path, w.Code)
+				}
+				if w.Code != http.StatusTooManyRequests {
+					continue
+				}
+				retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+				if err != nil || retryAfter <= 0 {
+					t.Fatalf("unexpected Retry-After: %q", w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
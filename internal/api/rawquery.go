@@ -0,0 +1,189 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Ledger states that a raw query can be run against
+const (
+	rawQueryAcquireVolatile  = "volatile"
+	rawQueryAcquireImmutable = "immutable"
+	rawQueryAcquirePoint     = "point"
+)
+
+// The query is a full LocalStateQuery query as hex CBOR, including the era
+// wrapping for ledger queries. The ledger state is the volatile tip unless
+// another is given. The slot and hash are only used for a point
+type requestLocalStateQueryRaw struct {
+	Query   string `json:"query"   binding:"required" example:"820082008206811a"`
+	Acquire string `json:"acquire"                    example:"volatile"        enums:"volatile,immutable,point"`
+	Slot    uint64 `json:"slot"                       example:"134217728"`
+	Hash    string `json:"hash"                       example:"b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"`
+}
+
+// acquireTarget returns the ledger state to acquire for the request
+func (r requestLocalStateQueryRaw) acquireTarget() (node.AcquireTarget, error) {
+	switch r.Acquire {
+	case "", rawQueryAcquireVolatile:
+		return node.AcquireTarget{}, nil
+	case rawQueryAcquireImmutable:
+		return node.AcquireTarget{Immutable: true}, nil
+	case rawQueryAcquirePoint:
+		hash, err := hex.DecodeString(r.Hash)
+		if err != nil || len(hash) != 32 {
+			return node.AcquireTarget{}, fmt.Errorf(
+				"a point needs a slot and a 32-byte block hash in hex",
+			)
+		}
+		point := ocommon.NewPoint(r.Slot, hash)
+		return node.AcquireTarget{Point: &point}, nil
+	}
+	return node.AcquireTarget{}, fmt.Errorf(
+		"unknown acquire target: %s",
+		r.Acquire,
+	)
+}
+
+// The result is the CBOR that the node returned, as hex. The diagnostic is a
+// best-effort JSON rendering of it, which is null if it can't be rendered
+type responseLocalStateQueryRaw struct {
+	Result     string `json:"result"     example:"8119020d"`
+	Diagnostic any    `json:"diagnostic" swaggertype:"object"`
+}
+
+// isLedgerQuery returns whether a query is a Shelley-based ledger query for the
+// current era, whose result the node wraps to report an era mismatch
+func isLedgerQuery(query []byte) bool {
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(query, &items); err != nil || len(items) != 2 {
+		return false
+	}
+	var queryType uint
+	if _, err := cbor.Decode(items[0], &queryType); err != nil ||
+		queryType != localstatequery.QueryTypeBlock {
+		return false
+	}
+	blockQueryType, err := cbor.DecodeIdFromList(items[1])
+	return err == nil && blockQueryType == localstatequery.QueryTypeShelley
+}
+
+// CBOR tags for positive and negative bignums
+const (
+	cborTagPositiveBignum = 2
+	cborTagNegativeBignum = 3
+)
+
+type cborDiagnosticPair struct {
+	Key   any `json:"key"`
+	Value any `json:"value"`
+}
+
+type cborDiagnosticTag struct {
+	Tag   uint64 `json:"tag"`
+	Value any    `json:"value"`
+}
+
+// cborDiagnostic renders a CBOR item as JSON. Byte strings are hex, bignums are
+// decimal strings, and other tags are an object with the tag number and value.
+// Maps with only text keys are objects, while other maps are lists of key and
+// value pairs, since their keys can be any item
+func cborDiagnostic(data cbor.RawMessage) (any, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty CBOR item")
+	}
+	switch cborMajorType(data) {
+	case cborMajorTypeBytes:
+		var ret []byte
+		if _, err := cbor.Decode(data, &ret); err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(ret), nil
+	case cborMajorTypeArray:
+		var items []cbor.RawMessage
+		if _, err := cbor.Decode(data, &items); err != nil {
+			return nil, err
+		}
+		ret := make([]any, 0, len(items))
+		for _, item := range items {
+			tmpItem, err := cborDiagnostic(item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, tmpItem)
+		}
+		return ret, nil
+	case cborMajorTypeMap:
+		pairs, err := cborMapPairs(data)
+		if err != nil {
+			return nil, err
+		}
+		textKeys := true
+		ret := make([]cborDiagnosticPair, 0, len(pairs))
+		for _, pair := range pairs {
+			if cborMajorType(pair[0]) != cborMajorTypeText {
+				textKeys = false
+			}
+			key, err := cborDiagnostic(pair[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := cborDiagnostic(pair[1])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, cborDiagnosticPair{Key: key, Value: value})
+		}
+		if !textKeys {
+			return ret, nil
+		}
+		obj := make(map[string]any, len(ret))
+		for _, pair := range ret {
+			obj[pair.Key.(string)] = pair.Value
+		}
+		return obj, nil
+	case cborMajorTypeTag:
+		var tag cbor.RawTag
+		if _, err := cbor.Decode(data, &tag); err != nil {
+			return nil, err
+		}
+		if tag.Number == cborTagPositiveBignum ||
+			tag.Number == cborTagNegativeBignum {
+			var ret big.Int
+			if _, err := cbor.Decode(data, &ret); err != nil {
+				return nil, err
+			}
+			return ret.String(), nil
+		}
+		value, err := cborDiagnostic(tag.Content)
+		if err != nil {
+			return nil, err
+		}
+		return cborDiagnosticTag{Tag: tag.Number, Value: value}, nil
+	}
+	var ret any
+	_, err := cbor.Decode(data, &ret)
+	return ret, err
+}
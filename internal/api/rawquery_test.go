@@ -0,0 +1,242 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+func TestCborDiagnostic(t *testing.T) {
+	testDefs := []struct {
+		name    string
+		cborHex string
+		// Expected diagnostic, as JSON
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "list",
+			cborHex: "831907e119010a00",
+			want:    `[2017,266,0]`,
+		},
+		{
+			name:    "indefinite list",
+			cborHex: "9f0102ff",
+			want:    `[1,2]`,
+		},
+		{
+			name:    "text keys",
+			cborHex: "a2616101616242abab",
+			want:    `{"a":1,"b":"abab"}`,
+		},
+		{
+			name:    "byte string keys",
+			cborHex: "a2581c111111111111111111111111111111111111111111111111111111110541028101",
+			want:    `[{"key":"11111111111111111111111111111111111111111111111111111111","value":5},{"key":"02","value":[1]}]`,
+		},
+		{
+			name:    "list keys",
+			cborHex: "a1820042111107",
+			want:    `[{"key":[0,"1111"],"value":7}]`,
+		},
+		{
+			name:    "rational",
+			cborHex: "d81e820102",
+			want:    `{"tag":30,"value":[1,2]}`,
+		},
+		{
+			name:    "bignum",
+			cborHex: "c249010000000000000000",
+			want:    `"18446744073709551616"`,
+		},
+		{
+			name:    "null",
+			cborHex: "f6",
+			want:    `null`,
+		},
+		{
+			name:    "truncated",
+			cborHex: "8301",
+			wantErr: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			data, err := hex.DecodeString(testDef.cborHex)
+			if err != nil {
+				t.Fatalf("bad test CBOR: %s", err)
+			}
+			diagnostic, err := cborDiagnostic(data)
+			if testDef.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", diagnostic)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := json.Marshal(diagnostic)
+			if err != nil {
+				t.Fatalf("failed to encode diagnostic: %s", err)
+			}
+			if string(got) != testDef.want {
+				t.Fatalf("unexpected diagnostic: %s", got)
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQueryRaw(t *testing.T) {
+	const testBlockHash = "b3b2b9f3bd8ab3cf1e5a7e8c8c1f3d8a1f3c4e5a6b7c8d9e0f1a2b3c4d5e6f70"
+	testDefs := []struct {
+		name string
+		body string
+		// Conversation after the handshake, or nil if the node isn't reached
+		conversation func(*testing.T) []ouroboros_mock.ConversationEntry
+		wantStatus   int
+		want         responseLocalStateQueryRaw
+	}{
+		{
+			name: "ledger query",
+			// [0, [0, [6, [1]]]]
+			body: `{"query": "8200820082068101"}`,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqQuery("8200820082068101"),
+					nodetest.LsqResult(t, "8119020d"),
+				}
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryRaw{
+				Result:     "8119020d",
+				Diagnostic: []any{float64(525)},
+			},
+		},
+		{
+			name: "system start at a point",
+			body: `{"query": "8101", "acquire": "point", "slot": 134217728, "hash": "` + testBlockHash + `"}`,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquire),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqQuery("8101"),
+					nodetest.LsqResult(t, "831907e119010a00"),
+				}
+			},
+			wantStatus: http.StatusOK,
+			want: responseLocalStateQueryRaw{
+				Result:     "831907e119010a00",
+				Diagnostic: []any{float64(2017), float64(266), float64(0)},
+			},
+		},
+		{
+			name: "point not on chain",
+			body: `{"query": "8101", "acquire": "point", "slot": 134217728, "hash": "` + testBlockHash + `"}`,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquire),
+					nodetest.LsqOutput(localstatequery.NewMsgFailure(
+						localstatequery.AcquireFailurePointNotOnChain,
+					)),
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "era mismatch",
+			// [0, [0, [5, [1]]]]
+			body: `{"query": "8200820082058101"}`,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqQuery("8200820082058101"),
+					// [[5, "Babbage"], [6, "Conway"]]
+					nodetest.LsqResult(t, "8282056742616262616765820666436f6e776179"),
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "missing query",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid hex",
+			body:       `{"query": "81zz"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "trailing bytes",
+			body:       `{"query": "810100"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown acquire target",
+			body:       `{"query": "8101", "acquire": "genesis"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "point without hash",
+			body:       `{"query": "8101", "acquire": "point", "slot": 134217728}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if testDef.conversation != nil {
+				nodetest.StartMockNode(t, 16, testDef.conversation(t))
+			}
+			w := serveTestRequest(
+				http.MethodPost,
+				"/raw",
+				handleLocalStateQueryRaw,
+				"/raw",
+				strings.NewReader(testDef.body),
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryRaw
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.Result != testDef.want.Result {
+				t.Fatalf("unexpected result: %s", resp.Result)
+			}
+			gotDiagnostic, _ := json.Marshal(resp.Diagnostic)
+			wantDiagnostic, _ := json.Marshal(testDef.want.Diagnostic)
+			if string(gotDiagnostic) != string(wantDiagnostic) {
+				t.Fatalf("unexpected diagnostic: %s", gotDiagnostic)
+			}
+		})
+	}
+}
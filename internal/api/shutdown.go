@@ -0,0 +1,85 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// Streaming handlers hijack their connections, so http.Server.Shutdown() does not
+// wait for them. We track them separately and notify them when shutdown starts.
+// The idle channel is closed while no handlers are running
+var (
+	streamMutex    sync.Mutex
+	streamCount    int
+	streamIdle     = closedChan()
+	streamShutdown = make(chan struct{})
+)
+
+func closedChan() chan struct{} {
+	ret := make(chan struct{})
+	close(ret)
+	return ret
+}
+
+// trackStream registers a streaming handler. The returned func must be called when
+// the handler exits
+func trackStream() func() {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	if streamCount == 0 {
+		streamIdle = make(chan struct{})
+	}
+	streamCount++
+	return func() {
+		streamMutex.Lock()
+		defer streamMutex.Unlock()
+		streamCount--
+		if streamCount == 0 {
+			close(streamIdle)
+		}
+	}
+}
+
+// streamShutdownChan returns a channel that is closed when shutdown starts
+func streamShutdownChan() <-chan struct{} {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	return streamShutdown
+}
+
+func notifyStreamsShutdown() {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	select {
+	case <-streamShutdown:
+	default:
+		close(streamShutdown)
+	}
+}
+
+// waitForStreams waits for all tracked streaming handlers to exit
+func waitForStreams(ctx context.Context) error {
+	streamMutex.Lock()
+	idleChan := streamIdle
+	streamMutex.Unlock()
+	select {
+	case <-idleChan:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
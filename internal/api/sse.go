@@ -0,0 +1,67 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Event sent on server-sent events streams when the server is shutting down
+const sseEventShutdown = "shutdown"
+
+type responseStreamShutdown struct {
+	Reason string `json:"reason" example:"server shutting down"`
+}
+
+// startSse sends the headers for a server-sent events response. The stream
+// outlives the server write timeout, so the deadline is removed. If that isn't
+// possible, the stream is cut off at the write timeout
+func startSse(c *gin.Context, logger *logging.Logger) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("failed to clear write deadline for stream: %s", err)
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Keep nginx from buffering the events
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// sendSseHeartbeat sends a comment on a server-sent events stream, which keeps
+// proxies from closing it while it's idle. It returns false if the client has
+// gone away
+func sendSseHeartbeat(c *gin.Context) bool {
+	if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
+
+// sendSseShutdown tells a server-sent events client that the stream is ending
+// because the server is shutting down
+func sendSseShutdown(c *gin.Context) {
+	c.SSEvent(
+		sseEventShutdown,
+		responseStreamShutdown{Reason: "server shutting down"},
+	)
+	c.Writer.Flush()
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// DRep delegation types in responses
+const (
+	drepTypeKeyHash      = "key_hash"
+	drepTypeScriptHash   = "script_hash"
+	drepTypeAbstain      = "abstain"
+	drepTypeNoConfidence = "no_confidence"
+)
+
+type responseLocalStateQueryStakeAddress struct {
+	StakeAddress string        `json:"stake_address"     example:"stake1uxpdrerp9wrxunfh6ukyv5267j70fzxgw0fr3z8zeac5vyqhf9jhy"`
+	Registered   bool          `json:"registered"        example:"true"`
+	PoolId       string        `json:"pool_id,omitempty" example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	Rewards      uint64        `json:"rewards"           example:"1500000"`
+	Drep         *responseDrep `json:"drep,omitempty"`
+}
+
+type responseDrep struct {
+	Type string `json:"type"         example:"key_hash" enums:"key_hash,script_hash,abstain,no_confidence"`
+	Id   string `json:"id,omitempty" example:"drep1yfxhc8568u4ccm27pgdjc02wtas8rq5n5j6ud4lglyqsyqc4zuzvc"`
+}
+
+// parseStakeAddress parses a bech32 stake address, rejecting other address
+// types
+func parseStakeAddress(stakeAddress string) (ledger.Address, error) {
+	if !strings.HasPrefix(stakeAddress, "stake1") &&
+		!strings.HasPrefix(stakeAddress, "stake_test1") {
+		return ledger.Address{}, fmt.Errorf(
+			"not a stake address, must start with stake1 or stake_test1: %s",
+			stakeAddress,
+		)
+	}
+	addr, err := ledger.NewAddress(stakeAddress)
+	if err != nil {
+		return ledger.Address{}, fmt.Errorf(
+			"invalid stake address: %s",
+			stakeAddress,
+		)
+	}
+	// The address type is in the top 4 bits of the header byte
+	addrType := addr.Bytes()[0] >> 4
+	if addrType != ledger.AddressTypeNoneKey &&
+		addrType != ledger.AddressTypeNoneScript {
+		return ledger.Address{}, fmt.Errorf(
+			"not a stake address: %s",
+			stakeAddress,
+		)
+	}
+	return addr, nil
+}
+
+// stakeAddressCredential returns the stake credential of a stake address
+func stakeAddressCredential(addr ledger.Address) ledgerCredential {
+	addrBytes := addr.Bytes()
+	ret := ledgerCredential{Type: credentialTypeKeyHash}
+	if addrBytes[0]>>4 == ledger.AddressTypeNoneScript {
+		ret.Type = credentialTypeScriptHash
+	}
+	copy(ret.Hash[:], addrBytes[1:])
+	return ret
+}
+
+// The result of the filtered delegations and reward accounts query. A stake
+// credential is registered if it has a reward account
+type stakeAccountsResult struct {
+	cbor.StructAsArray
+	Delegations    map[ledgerCredential]ledger.PoolId
+	RewardAccounts map[ledgerCredential]uint64
+}
+
+// queryStakeAccounts returns the stake address info for each of the stake
+// addresses, in the same order, from the current ledger state. The DRep
+// delegations are only available from the Conway era. An error response has
+// been sent if it returns false
+func queryStakeAccounts(
+	c *gin.Context,
+	stakeAddresses []ledger.Address,
+) ([]responseLocalStateQueryStakeAddress, bool) {
+	// The queries take a set of credentials, which can't have duplicates
+	creds := make([]ledgerCredential, 0, len(stakeAddresses))
+	for _, stakeAddress := range stakeAddresses {
+		cred := stakeAddressCredential(stakeAddress)
+		if !slices.Contains(creds, cred) {
+			creds = append(creds, cred)
+		}
+	}
+
+	query := openLedgerQuery(c, node.AcquireTarget{})
+	if query == nil {
+		return nil, false
+	}
+	defer query.Close()
+
+	// Get delegations and reward accounts
+	accounts, ok := queryLedger[stakeAccountsResult](
+		c,
+		query,
+		"query filtered-delegations-and-reward-accounts",
+		localstatequery.QueryTypeShelleyFilteredDelegationAndRewardAccounts,
+		creds,
+	)
+	if !ok {
+		return nil, false
+	}
+
+	// Get DRep delegations
+	era, ok := queryEra(c, query)
+	if !ok {
+		return nil, false
+	}
+	var voteDelegatees map[ledgerCredential]ledger.Drep
+	if era.Id >= ledger.EraIdConway {
+		voteDelegatees, ok = queryLedger[map[ledgerCredential]ledger.Drep](
+			c,
+			query,
+			"query filtered-vote-delegatees",
+			queryTypeShelleyFilteredVoteDelegatees,
+			creds,
+		)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	ret := make([]responseLocalStateQueryStakeAddress, 0, len(stakeAddresses))
+	for _, stakeAddress := range stakeAddresses {
+		cred := stakeAddressCredential(stakeAddress)
+		rewards, registered := accounts.RewardAccounts[cred]
+		tmpAccount := responseLocalStateQueryStakeAddress{
+			StakeAddress: stakeAddress.String(),
+			Registered:   registered,
+			Rewards:      rewards,
+		}
+		if poolId, ok := accounts.Delegations[cred]; ok {
+			tmpAccount.PoolId = poolId.String()
+		}
+		if drep, ok := voteDelegatees[cred]; ok {
+			tmpAccount.Drep = newResponseDrep(drep)
+		}
+		ret = append(ret, tmpAccount)
+	}
+	return ret, true
+}
+
+func newResponseDrep(drep ledger.Drep) *responseDrep {
+	switch drep.Type {
+	case ledger.DrepTypeAddrKeyHash:
+		return &responseDrep{
+			Type: drepTypeKeyHash,
+			Id: cip129Id(
+				cip129PrefixDrep,
+				cip129HeaderDrep,
+				false,
+				drep.Credential,
+			),
+		}
+	case ledger.DrepTypeScriptHash:
+		return &responseDrep{
+			Type: drepTypeScriptHash,
+			Id: cip129Id(
+				cip129PrefixDrep,
+				cip129HeaderDrep,
+				true,
+				drep.Credential,
+			),
+		}
+	case ledger.DrepTypeAbstain:
+		return &responseDrep{Type: drepTypeAbstain}
+	case ledger.DrepTypeNoConfidence:
+		return &responseDrep{Type: drepTypeNoConfidence}
+	}
+	return nil
+}
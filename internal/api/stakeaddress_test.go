@@ -0,0 +1,210 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+const (
+	testStakeAddress = "stake1uxpdrerp9wrxunfh6ukyv5267j70fzxgw0fr3z8zeac5vyqhf9jhy"
+	testPoolId       = "pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"
+)
+
+func TestHandleLocalStateQueryStakeAddress(t *testing.T) {
+	testDefs := []struct {
+		name         string
+		stakeAddress string
+		// Query results after acquiring the ledger state, as hex
+		results    []string
+		wantStatus int
+		wantResp   responseLocalStateQueryStakeAddress
+	}{
+		{
+			name:         "conway delegated",
+			stakeAddress: testStakeAddress,
+			results: []string{
+				"06",
+				// [[{[0, cred]: pool}, {[0, cred]: 1500000}]]
+				"8182a18200581c82d1e4612b866e4d37d72c46515af4bcf488c873d23888e2cf714610581c0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735a18200581c82d1e4612b866e4d37d72c46515af4bcf488c873d23888e2cf7146101a0016e360",
+				// [{[0, cred]: [0, drep key hash]}]
+				"81a18200581c82d1e4612b866e4d37d72c46515af4bcf488c873d23888e2cf7146108200581c11111111111111111111111111111111111111111111111111111111",
+			},
+			wantStatus: http.StatusOK,
+			wantResp: responseLocalStateQueryStakeAddress{
+				StakeAddress: testStakeAddress,
+				Registered:   true,
+				PoolId:       testPoolId,
+				Rewards:      1500000,
+				Drep: &responseDrep{
+					Type: drepTypeKeyHash,
+					Id:   "drep1ygg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg42v5vz",
+				},
+			},
+		},
+		{
+			name:         "babbage unregistered",
+			stakeAddress: testStakeAddress,
+			results: []string{
+				"05",
+				// [[{}, {}]]
+				"8182a0a0",
+			},
+			wantStatus: http.StatusOK,
+			wantResp: responseLocalStateQueryStakeAddress{
+				StakeAddress: testStakeAddress,
+			},
+		},
+		{
+			name:         "payment address",
+			stakeAddress: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			conversation := []ouroboros_mock.ConversationEntry{
+				nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+				nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+			}
+			for _, result := range testDef.results {
+				conversation = append(
+					conversation,
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, result),
+				)
+			}
+			nodetest.StartMockNode(t, 16, conversation)
+			w := serveTestRequest(
+				http.MethodGet,
+				"/stake/:stake_address",
+				handleLocalStateQueryStakeAddress,
+				"/stake/"+testDef.stakeAddress,
+				nil,
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp responseLocalStateQueryStakeAddress
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.wantResp) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestHandleLocalStateQueryStakeAccounts(t *testing.T) {
+	const scriptStakeAddress = "stake17y3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygsqhljyr"
+	testDefs := []struct {
+		name string
+		body string
+		// Query results after acquiring the ledger state, as hex
+		results    []string
+		wantStatus int
+		wantResp   []responseLocalStateQueryStakeAddress
+	}{
+		{
+			name: "request order",
+			body: `["` + scriptStakeAddress + `", "` + testStakeAddress + `", "` + scriptStakeAddress + `"]`,
+			results: []string{
+				"06",
+				// [[{[0, cred]: pool}, {[1, script]: 0, [0, cred]: 1500000}]]
+				"8182a18200581c82d1e4612b866e4d37d72c46515af4bcf488c873d23888e2cf714610581c0f292fcaa02b8b2f9b3c8f9fd8e0bb21abedb692a6d5058df3ef2735a28201581c22222222222222222222222222222222222222222222222222222222008200581c82d1e4612b866e4d37d72c46515af4bcf488c873d23888e2cf7146101a0016e360",
+				// [{[1, script]: [3]}]
+				"81a18201581c222222222222222222222222222222222222222222222222222222228103",
+			},
+			wantStatus: http.StatusOK,
+			wantResp: []responseLocalStateQueryStakeAddress{
+				{
+					StakeAddress: scriptStakeAddress,
+					Registered:   true,
+					Drep:         &responseDrep{Type: drepTypeNoConfidence},
+				},
+				{
+					StakeAddress: testStakeAddress,
+					Registered:   true,
+					PoolId:       testPoolId,
+					Rewards:      1500000,
+				},
+				{
+					StakeAddress: scriptStakeAddress,
+					Registered:   true,
+					Drep:         &responseDrep{Type: drepTypeNoConfidence},
+				},
+			},
+		},
+		{
+			name:       "empty",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid stake address",
+			body:       `["` + testStakeAddress + `", "stake1invalid"]`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			conversation := []ouroboros_mock.ConversationEntry{
+				nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+				nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+			}
+			for _, result := range testDef.results {
+				conversation = append(
+					conversation,
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, result),
+				)
+			}
+			nodetest.StartMockNode(t, 16, conversation)
+			w := serveTestRequest(
+				http.MethodPost,
+				"/stake/accounts",
+				handleLocalStateQueryStakeAccounts,
+				"/stake/accounts",
+				strings.NewReader(testDef.body),
+			)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if testDef.wantStatus != http.StatusOK {
+				return
+			}
+			var resp []responseLocalStateQueryStakeAddress
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if !reflect.DeepEqual(resp, testDef.wantResp) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+type responseStakeDistributionPool struct {
+	PoolId        string `json:"pool_id"        example:"pool1pu5jlj4q9w9jlxeu370a3c9myx47md5j5m2str0naunn2q3lkdy"`
+	StakeFraction string `json:"stake_fraction" example:"0.00287856386020331632"`
+	VrfKeyHash    string `json:"vrf_key_hash"   example:"b348e7ce3a7356e3b3ddbb2d8989de39247c4fe0e7d6e4abc47ac0ade1ae5d5d"`
+}
+
+// stakeDistributionPool is a pool from the stake distribution query
+type stakeDistributionPool struct {
+	poolId        ledger.PoolId
+	stakeFraction *big.Rat
+	vrfKeyHash    ledger.Blake2b256
+}
+
+func (p stakeDistributionPool) response() responseStakeDistributionPool {
+	return responseStakeDistributionPool{
+		PoolId:        p.poolId.String(),
+		StakeFraction: formatRat(p.stakeFraction),
+		VrfKeyHash:    p.vrfKeyHash.String(),
+	}
+}
+
+// newStakeDistributionPools returns the pools from a stake distribution query
+// result, ordered by descending stake and then pool ID
+func newStakeDistributionPools(
+	result *localstatequery.StakeDistributionResult,
+) []stakeDistributionPool {
+	ret := make([]stakeDistributionPool, 0, len(result.Results))
+	for poolId, pool := range result.Results {
+		stakeFraction := new(big.Rat)
+		if pool.StakeFraction != nil {
+			stakeFraction = pool.StakeFraction.ToBigRat()
+		}
+		ret = append(ret, stakeDistributionPool{
+			poolId:        poolId,
+			stakeFraction: stakeFraction,
+			vrfKeyHash:    pool.VrfHash,
+		})
+	}
+	slices.SortFunc(ret, func(a, b stakeDistributionPool) int {
+		if c := b.stakeFraction.Cmp(a.stakeFraction); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.poolId[:], b.poolId[:])
+	})
+	return ret
+}
+
+// stakeDistributionCache holds the stake distribution until the end of the epoch
+// that it was queried in, since it only changes at epoch boundaries
+type stakeDistributionCache struct {
+	mutex     sync.Mutex
+	epochNo   int
+	pools     []stakeDistributionPool
+	expiresAt time.Time
+}
+
+var stakeDistribution = &stakeDistributionCache{}
+
+// get returns the cached stake distribution if it hasn't expired
+func (s *stakeDistributionCache) get() (int, []stakeDistributionPool, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.pools == nil || !time.Now().Before(s.expiresAt) {
+		return 0, nil, false
+	}
+	return s.epochNo, s.pools, true
+}
+
+func (s *stakeDistributionCache) set(
+	epochNo int,
+	pools []stakeDistributionPool,
+	expiresAt time.Time,
+) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.epochNo = epochNo
+	s.pools = pools
+	s.expiresAt = expiresAt
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Route group for the cardano-submit-api compatible endpoint
+const submitApiGroup = "submit"
+
+// Error messages from cardano-submit-api, which clients may match on
+const (
+	submitApiErrorDecodeHex = "Provided data was hex encoded and this webapi expects raw binary"
+	submitApiErrorEmpty     = "Provided transaction has zero length"
+)
+
+// submitApiError is an error in the tagged format that cardano-submit-api's
+// Haskell types are encoded in. Contents is either a nested error or a value
+type submitApiError struct {
+	Contents any    `json:"contents"`
+	Tag      string `json:"tag"      example:"TxSubmitFail"`
+}
+
+// submitApiValidationError is a ledger rejection from cardano-submit-api, with
+// one rendered error per failed ledger rule
+type submitApiValidationError struct {
+	Era   string   `json:"era"`
+	Error []string `json:"error"`
+	Kind  string   `json:"kind"`
+}
+
+type submitApiEraMismatch struct {
+	LedgerEraName string `json:"ledgerEraName"`
+	OtherEraName  string `json:"otherEraName"`
+}
+
+// configureSubmitApiRoutes adds the cardano-submit-api compatible route, which
+// is always at /api/submit/tx since that's where its clients expect it
+func configureSubmitApiRoutes(apiGroup *gin.RouterGroup) {
+	group := apiGroup.Group("/" + submitApiGroup)
+	cfg := config.GetConfig()
+	group.Use(maxBodyBytesMiddleware(int64(cfg.Api.MaxTxSubmitBytes)))
+	if cfg.Logging.TxSubmitDebug {
+		group.Use(
+			txSubmitDebugMiddleware(int(cfg.Logging.TxSubmitDebugMaxBytes)),
+		)
+	}
+	group.POST("/tx", handleSubmitApiTx)
+}
+
+// handleSubmitApiTx godoc
+//
+//	@Summary		Submit Tx (cardano-submit-api compatible)
+//	@Description	Submits a transaction with the same request and responses as cardano-submit-api, for clients that expect its contract. It's only served when API_SUBMIT_API_COMPAT is enabled, and always at /api/submit/tx rather than under the versioned base path. The body is the raw transaction CBOR with the application/cbor content type, and the response is the transaction ID as a JSON string. Errors for bodies that can't be decoded are JSON strings, such as the message for hex-encoded bodies. Failures from the node are tagged objects in the format of cardano-submit-api, with the failed ledger rules rendered as text for rejections. The node endpoint that served the request is returned in the X-Node-Endpoint header.
+//	@Tags			localtxsubmission
+//	@Accept			application/cbor
+//	@Produce		json
+//	@Param			Content-Type	header		string			true	"Content type"	Enums(application/cbor)
+//	@Success		202				{string}	string			"Transaction ID"
+//	@Failure		400				{object}	submitApiError	"Bad Request"
+//	@Failure		413				{object}	responseApiError	"Request Entity Too Large"
+//	@Failure		415				"Unsupported Media Type"
+//	@Failure		500				{object}	submitApiError	"Server Error"
+//	@Router			/submit/tx [post]
+func handleSubmitApiTx(c *gin.Context) {
+	logger := requestLogger(c, logging.ComponentApi)
+	if c.ContentType() != mimeTypeCbor {
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return
+	}
+	reqBody, err := io.ReadAll(c.Request.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondRequestTooLarge(c, maxBytesErr.Limit)
+		return
+	}
+	if err != nil {
+		logger.Errorf("failed to read request body: %s", err)
+		c.JSON(400, submitApiCmdError("failed to read request body"))
+		return
+	}
+	if len(reqBody) == 0 {
+		c.JSON(400, submitApiErrorEmpty)
+		return
+	}
+	txType, txId, _, err := parseTx(reqBody)
+	if err != nil {
+		// Tell clients that send hex what they did wrong, like the original
+		if txBytes, hexErr := hexDecode(stripWhitespace(reqBody)); hexErr == nil &&
+			len(txBytes) > 0 {
+			c.JSON(400, submitApiErrorDecodeHex)
+			return
+		}
+		c.JSON(
+			400,
+			fmt.Sprintf("DecoderErrorDeserialiseFailure \"Tx\" (%s)", err),
+		)
+		return
+	}
+	c.Set(contextKeyTxHash, txId)
+	ctx := c.Request.Context()
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		if abortDisconnected(c) {
+			return
+		}
+		c.JSON(500, submitApiCmdError(err.Error()))
+		return
+	}
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	if err := sendTx(ctx, oConn, txType, reqBody); err != nil {
+		var txRejectErr localtxsubmission.TransactionRejectedError
+		if !errors.As(err, &txRejectErr) {
+			logger.Errorf("failure communicating with node: %s", err)
+			if abortDisconnected(c) {
+				return
+			}
+			c.JSON(500, submitApiCmdError(err.Error()))
+			return
+		}
+		c.JSON(400, newSubmitApiRejection(txRejectErr.ReasonCbor))
+		return
+	}
+	c.JSON(202, txId)
+}
+
+// submitApiCmdError is a failure to submit a TX for a reason other than the
+// ledger rejecting it
+func submitApiCmdError(msg string) submitApiError {
+	return submitApiError{
+		Tag: "TxSubmitFail",
+		Contents: submitApiError{
+			Tag:      "TxCmdTxSubmitError",
+			Contents: msg,
+		},
+	}
+}
+
+// newSubmitApiRejection returns a TX rejection from the node in the format of
+// cardano-submit-api. The failures are rendered from our decoding of them, since
+// we can't reproduce the Haskell representation of the ledger errors
+func newSubmitApiRejection(reasonCbor []byte) submitApiError {
+	var eraMismatch ledger.EraMismatch
+	if _, err := cbor.Decode(reasonCbor, &eraMismatch); err == nil {
+		return submitApiError{
+			Tag: "TxSubmitFail",
+			Contents: submitApiError{
+				Tag: "TxCmdTxSubmitErrorEraMismatch",
+				Contents: submitApiEraMismatch{
+					LedgerEraName: ledger.GetEraById(eraMismatch.LedgerEra).Name,
+					OtherEraName:  ledger.GetEraById(eraMismatch.OtherEra).Name,
+				},
+			},
+		}
+	}
+	rejection := newResponseTxRejection(reasonCbor)
+	validationErr := submitApiValidationError{
+		Error: make([]string, 0, len(rejection.Failures)),
+		Kind:  "ShelleyTxValidationError",
+	}
+	if rejection.Era != "" {
+		validationErr.Era = "ShelleyBasedEra" + rejection.Era
+	}
+	for _, failure := range rejection.Failures {
+		validationErr.Error = append(
+			validationErr.Error,
+			renderSubmitApiFailure(failure),
+		)
+	}
+	return submitApiError{
+		Tag: "TxSubmitFail",
+		Contents: submitApiError{
+			Tag: "TxCmdTxSubmitValidationError",
+			Contents: submitApiError{
+				Tag:      "TxValidationErrorInCardanoMode",
+				Contents: validationErr,
+			},
+		},
+	}
+}
+
+// renderSubmitApiFailure renders a ledger rule failure as text, with its details
+// if it was decoded and its CBOR otherwise
+func renderSubmitApiFailure(failure responseTxRejectionFailure) string {
+	parts := []string{failure.Name}
+	if failure.Rule != "" {
+		parts[0] = failure.Rule + " " + failure.Name
+	}
+	if len(failure.Details) > 0 {
+		details, err := json.Marshal(failure.Details)
+		if err == nil {
+			parts = append(parts, string(details))
+		}
+	}
+	if failure.Cbor != "" {
+		parts = append(parts, failure.Cbor)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+const (
+	// A TX with one input, one output, and a fee, and no witnesses
+	testSubmitTxHex = "84a30081825820111111111111111111111111111111111111111111111111111111111111111100018182581d60222222222222222222222222222222222222222222222222222222221a000f4240021a00030d40a0f5f6"
+	testSubmitTxId  = "dcae1357ecbef1c22ac343e2fe3e953dcc7f8897394acfe9ae25fd3a7bb7b5e0"
+)
+
+// discardPooledConnection stops the idle pooled connection, if any, from being
+// reused by the next test. The connection is treated as broken when its context
+// ends before it's returned
+func discardPooledConnection() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	pConn, err := node.GetPooledConnection(ctx)
+	cancel()
+	if err == nil {
+		pConn.Close()
+	}
+}
+
+func TestHandleSubmitApiTx(t *testing.T) {
+	txCbor, err := hex.DecodeString(testSubmitTxHex)
+	if err != nil {
+		t.Fatalf("bad test TX hex: %s", err)
+	}
+	// Failed connections would otherwise open the circuit breaker for later
+	// tests
+	cfg := config.GetConfig()
+	nodeCfg := cfg.Node
+	t.Cleanup(func() { cfg.Node = nodeCfg })
+	cfg.Node.BreakerFailures = 0
+	testDefs := []struct {
+		name        string
+		contentType string
+		body        []byte
+		// Conversation with the node, or nil if the node isn't reached
+		nodeResponse func(*testing.T) []ouroboros_mock.ConversationEntry
+		nodeDown     bool
+		wantStatus   int
+		wantBody     string
+		// Whether only the start of the body is known, for errors from elsewhere
+		wantBodyPrefix bool
+	}{
+		{
+			name:        "accepted",
+			contentType: "application/cbor",
+			body:        txCbor,
+			nodeResponse: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.TxSubmitInput(),
+					nodetest.TxSubmitOutput(localtxsubmission.NewMsgAcceptTx()),
+				}
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `"` + testSubmitTxId + `"`,
+		},
+		{
+			name:        "rejected",
+			contentType: "application/cbor",
+			body:        txCbor,
+			nodeResponse: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				// A Conway fee too small failure
+				reason, _ := hex.DecodeString("818206818201820083051a00030d401a000186a0")
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.TxSubmitInput(),
+					nodetest.TxSubmitOutput(localtxsubmission.NewMsgRejectTx(reason)),
+				}
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"contents":{"contents":{"contents":{"era":"ShelleyBasedEraConway","error":["UTXO FeeTooSmallUTxO {\"minimum_fee\":200000,\"supplied_fee\":100000}"],"kind":"ShelleyTxValidationError"},"tag":"TxValidationErrorInCardanoMode"},"tag":"TxCmdTxSubmitValidationError"},"tag":"TxSubmitFail"}`,
+		},
+		{
+			name:        "era mismatch",
+			contentType: "application/cbor",
+			body:        txCbor,
+			nodeResponse: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				reason, _ := hex.DecodeString("820506")
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.TxSubmitInput(),
+					nodetest.TxSubmitOutput(localtxsubmission.NewMsgRejectTx(reason)),
+				}
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"contents":{"contents":{"ledgerEraName":"Babbage","otherEraName":"Conway"},"tag":"TxCmdTxSubmitErrorEraMismatch"},"tag":"TxSubmitFail"}`,
+		},
+		{
+			name:           "node down",
+			contentType:    "application/cbor",
+			body:           txCbor,
+			nodeDown:       true,
+			wantStatus:     http.StatusInternalServerError,
+			wantBody:       `{"contents":{"contents":"`,
+			wantBodyPrefix: true,
+		},
+		{
+			name:        "wrong content type",
+			contentType: "application/json",
+			body:        txCbor,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "empty",
+			contentType: "application/cbor",
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `"Provided transaction has zero length"`,
+		},
+		{
+			name:        "hex",
+			contentType: "application/cbor",
+			body:        []byte(testSubmitTxHex + "\n"),
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `"Provided data was hex encoded and this webapi expects raw binary"`,
+		},
+		{
+			name:           "not a TX",
+			contentType:    "application/cbor",
+			body:           []byte{0x01},
+			wantStatus:     http.StatusBadRequest,
+			wantBody:       `"DecoderErrorDeserialiseFailure \"Tx\" (`,
+			wantBodyPrefix: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			// This runs after the mock node is stopped
+			t.Cleanup(discardPooledConnection)
+			switch {
+			case testDef.nodeResponse != nil:
+				nodetest.StartMockNode(t, 16, testDef.nodeResponse(t))
+			case testDef.nodeDown:
+				nodeCfg := cfg.Node
+				t.Cleanup(func() { cfg.Node = nodeCfg })
+				cfg.Node.Endpoints = []string{
+					filepath.Join(t.TempDir(), "missing.socket"),
+				}
+			}
+			router := gin.New()
+			router.POST("/api/submit/tx", handleSubmitApiTx)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/submit/tx",
+				bytes.NewReader(testDef.body),
+			)
+			req.Header.Set("Content-Type", testDef.contentType)
+			router.ServeHTTP(w, req)
+			if w.Code != testDef.wantStatus {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			body := w.Body.String()
+			if testDef.wantBodyPrefix {
+				if !strings.HasPrefix(body, testDef.wantBody) {
+					t.Fatalf("unexpected body: %s", body)
+				}
+			} else if body != testDef.wantBody {
+				t.Fatalf("unexpected body: %s", body)
+			}
+			if testDef.nodeResponse != nil &&
+				w.Header().Get(nodeEndpointHeader) == "" {
+				t.Fatalf("no %s header", nodeEndpointHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// The first file descriptor passed by systemd socket activation
+const systemdListenFdsStart = 3
+
+var (
+	systemdListenersOnce   sync.Once
+	systemdListenersByName map[string][]net.Listener
+	systemdListenersErr    error
+)
+
+// systemdListeners returns the listeners passed in by systemd socket activation,
+// keyed by the names from the FileDescriptorName= option of the socket units.
+// Unnamed sockets use the name "unknown", as systemd does
+func systemdListeners() (map[string][]net.Listener, error) {
+	systemdListenersOnce.Do(func() {
+		systemdListenersByName, systemdListenersErr = loadSystemdListeners()
+	})
+	return systemdListenersByName, systemdListenersErr
+}
+
+func loadSystemdListeners() (map[string][]net.Listener, error) {
+	// Make sure that the file descriptors are meant for us and not inherited by a
+	// child process
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	numFds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || numFds <= 0 {
+		return nil, nil
+	}
+	var names []string
+	if fdNames := os.Getenv("LISTEN_FDNAMES"); fdNames != "" {
+		names = strings.Split(fdNames, ":")
+	}
+	ret := make(map[string][]net.Listener)
+	for i := 0; i < numFds; i++ {
+		fd := systemdListenFdsStart + i
+		syscall.CloseOnExec(fd)
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		// FileListener dups the file descriptor, so we close the original
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to use systemd socket %q (fd %d): %s",
+				name,
+				fd,
+				err,
+			)
+		}
+		ret[name] = append(ret[name], listener)
+	}
+	return ret, nil
+}
+
+// systemdNotify sends a state update to the systemd service manager, if we were
+// started with Type=notify
+func systemdNotify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+	conn, err := net.DialUnix(
+		"unixgram",
+		nil,
+		&net.UnixAddr{Name: socketPath, Net: "unixgram"},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd notify socket: %s", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Set to the address of the inherited listener when running as the child
+// process in the socket activation test
+const testSystemdChildEnv = "TEST_SYSTEMD_LISTENER_ADDRESS"
+
+// The test runs again in a child process with a listener passed as fd 3, the
+// same way that systemd passes sockets to a service
+func TestOpenListenersSystemd(t *testing.T) {
+	if os.Getenv(testSystemdChildEnv) != "" {
+		testOpenListenersSystemdChild(t)
+		return
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on test port: %s", err)
+	}
+	defer listener.Close()
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("failed to get listener file: %s", err)
+	}
+	defer file.Close()
+	cmd := exec.Command(
+		os.Args[0],
+		"-test.run=^TestOpenListenersSystemd$",
+		"-test.v",
+	)
+	cmd.Env = append(
+		os.Environ(),
+		testSystemdChildEnv+"="+listener.Addr().String(),
+		"LISTEN_FDS=1",
+		"LISTEN_FDNAMES=api",
+	)
+	cmd.ExtraFiles = []*os.File{file}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("child process failed: %s\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "--- PASS: TestOpenListenersSystemd") {
+		t.Fatalf("child process didn't run the test:\n%s", output)
+	}
+}
+
+func testOpenListenersSystemdChild(t *testing.T) {
+	// systemd sets this after starting the process, so it's only known here
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	address := os.Getenv(testSystemdChildEnv)
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatalf("invalid listener address: %s", err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		t.Fatalf("invalid listener port: %s", err)
+	}
+	// Binding the configured port would fail, since the parent process is
+	// listening on it, and the socket file would exist
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	listeners, err := openListeners(listenerConfig{
+		name:        "API",
+		systemdName: "api",
+		address:     host,
+		port:        uint(port),
+		socketPath:  socketPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(listeners) != 1 || listeners[0].Addr().String() != address {
+		t.Fatalf("inherited listener not used: got %v", listeners)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Fatalf("UNIX socket listener started as well")
+	}
+	// The passed sockets are only for us and not our own child processes
+	if os.Getenv("LISTEN_FDS") != "" || os.Getenv("LISTEN_PID") != "" {
+		t.Fatalf("socket activation environment not cleared")
+	}
+	// Other listeners don't get the socket
+	otherListeners, err := openListeners(listenerConfig{
+		name:        "metrics",
+		systemdName: "metrics",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(otherListeners) != 0 {
+		t.Fatalf("unexpected metrics listeners: %v", otherListeners)
+	}
+	// The inherited listener accepts connections
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("failed to connect to inherited listener: %s", err)
+	}
+	defer conn.Close()
+	accepted, err := listeners[0].Accept()
+	if err != nil {
+		t.Fatalf("failed to accept on inherited listener: %s", err)
+	}
+	accepted.Close()
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+)
+
+// Route groups that serve long-lived streams and are exempt from request timeouts
+var timeoutExemptGroups = map[string]bool{
+	"chainsync": true,
+}
+
+// Routes outside of those groups that serve long-lived streams
+var streamRoutes = map[string]bool{
+	"/localtxmonitor/stream": true,
+	"/localtxmonitor/ws":     true,
+}
+
+// Routes that apply their own timeout, so the request timeout doesn't cut them
+// short
+var timeoutExemptRoutes = map[string]bool{
+	"/localtxmonitor/has_tx/:tx_hash": true,
+	"/localtxsubmission/tx/wait":      true,
+}
+
+// isStreamRoute reports whether the request is for a long-lived stream
+func isStreamRoute(c *gin.Context) bool {
+	return timeoutExemptGroups[routeGroup(c)] || streamRoutes[apiRoutePath(c)]
+}
+
+// timeoutMiddleware attaches a deadline to the request context, using the timeout
+// for the route group if one is configured. Handlers pass the request context to
+// the node connection so that in-progress protocol operations are aborted when
+// the deadline passes
+func timeoutMiddleware(
+	defaultTimeout uint,
+	groupTimeouts map[string]uint,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isStreamRoute(c) || timeoutExemptRoutes[apiRoutePath(c)] {
+			c.Next()
+			return
+		}
+		group := routeGroup(c)
+		timeout := defaultTimeout
+		if groupTimeout, ok := groupTimeouts[group]; ok {
+			timeout = groupTimeout
+		}
+		if timeout == 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(
+			c.Request.Context(),
+			time.Duration(timeout)*time.Second,
+		)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			respondError(
+				c,
+				http.StatusGatewayTimeout,
+				apiErrorCode(errorCodeTimeout, "request timed out", nil),
+			)
+		}
+	}
+}
+
+// clientDisconnectMiddleware counts requests that the client gave up on before
+// the handler finished. Handlers pass the request context to the node
+// connection, so the disconnect also aborts any in-progress protocol operations.
+// Streams are skipped, since they normally end with the client disconnecting
+func clientDisconnectMiddleware(c *gin.Context) {
+	c.Next()
+	if isStreamRoute(c) {
+		return
+	}
+	group := routeGroup(c)
+	if errors.Is(c.Request.Context().Err(), context.Canceled) {
+		_ = ginmetrics.GetMonitor().
+			GetMetric(metricClientDisconnects).
+			Inc([]string{group})
+	}
+}
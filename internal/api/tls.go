@@ -0,0 +1,148 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// How often to check the certificate and key files for changes
+const certReloaderCheckInterval = 1 * time.Minute
+
+// certReloader serves a TLS certificate loaded from disk, reloading it on config
+// reload or when the certificate or key file changes
+type certReloader struct {
+	certFile    string
+	keyFile     string
+	mutex       sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %s", err)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cert = &cert
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+	return nil
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"failed to stat TLS certificate file: %s",
+			err,
+		)
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"failed to stat TLS key file: %s",
+			err,
+		)
+	}
+	return certStat.ModTime(), keyStat.ModTime(), nil
+}
+
+// changed returns whether the certificate or key file has been modified since the
+// last successful load
+func (r *certReloader) changed() bool {
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return !certModTime.Equal(r.certModTime) ||
+		!keyModTime.Equal(r.keyModTime)
+}
+
+// GetCertificate is used as the tls.Config GetCertificate callback
+func (r *certReloader) GetCertificate(
+	*tls.ClientHelloInfo,
+) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the certificate on file change until the context is done. A failed
+// reload keeps serving the previously loaded certificate
+func (r *certReloader) Watch(ctx context.Context) {
+	logger := logging.GetLogger(logging.ComponentApi)
+	ticker := time.NewTicker(certReloaderCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+		}
+		if err := r.reload(); err != nil {
+			logger.Errorf("failed to reload TLS certificate: %s", err)
+			continue
+		}
+		logger.Infof("reloaded TLS certificate from %s", r.certFile)
+	}
+}
+
+// loadCertPool loads a pool of CA certificates from a PEM file
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	caData, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS client CA file: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf(
+			"no valid certificates found in TLS client CA file: %s",
+			caFile,
+		)
+	}
+	return pool, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
+)
+
+// tracingMiddleware creates a server span for each request. It's only used when
+// trace export is enabled
+func tracingMiddleware() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName)
+}
+
+// traceLogFields returns the trace and span IDs for the request, if any
+func traceLogFields(c *gin.Context) []zapcore.Field {
+	spanCtx := trace.SpanContextFromContext(c.Request.Context())
+	if !spanCtx.IsValid() {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.String("trace_id", spanCtx.TraceID().String()),
+		zap.String("span_id", spanCtx.SpanID().String()),
+	}
+}
+
+// respondJson writes a JSON response inside a span, so that encoding time can be
+// told apart from time spent talking to the node
+func respondJson(c *gin.Context, status int, obj any) {
+	_, span := tracing.StartSpan(c.Request.Context(), "response.encode")
+	c.JSON(status, obj)
+	span.End()
+}
+
+// respondJsonStream writes a JSON object response with a list field whose items
+// are encoded one at a time, so that large results don't need to be encoded in
+// memory first. The other fields come from obj, which must encode as an object.
+// The status has already been sent if an item fails, so the response is cut
+// short instead
+func respondJsonStream(
+	c *gin.Context,
+	status int,
+	obj any,
+	listKey string,
+	count int,
+	item func(int) (any, error),
+) {
+	_, span := tracing.StartSpan(c.Request.Context(), "response.encode")
+	defer span.End()
+	head, err := json.Marshal(obj)
+	if err == nil && (len(head) < 2 || head[0] != '{') {
+		err = fmt.Errorf("response is not an object: %s", head)
+	}
+	var keyJson []byte
+	if err == nil {
+		keyJson, err = json.Marshal(listKey)
+	}
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+	// Add the list to the end of the object
+	_, _ = c.Writer.Write(head[:len(head)-1])
+	if len(head) > 2 {
+		_, _ = c.Writer.WriteString(",")
+	}
+	_, _ = c.Writer.Write(keyJson)
+	_, _ = c.Writer.WriteString(":[")
+	enc := json.NewEncoder(c.Writer)
+	for idx := 0; idx < count; idx++ {
+		obj, err := item(idx)
+		if err == nil {
+			if idx > 0 {
+				_, _ = c.Writer.WriteString(",")
+			}
+			err = enc.Encode(obj)
+		}
+		if err != nil {
+			logging.GetLogger(logging.ComponentApi).
+				Errorf("failed to encode response: %s", err)
+			return
+		}
+	}
+	_, _ = c.Writer.WriteString("]}")
+}
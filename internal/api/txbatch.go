@@ -0,0 +1,227 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Statuses of TXs in a batch submission that weren't accepted
+const (
+	txStatusInvalid  = "invalid"
+	txStatusRejected = "rejected"
+	txStatusFailed   = "failed"
+	txStatusSkipped  = "skipped"
+)
+
+type requestLocalTxSubmissionBatch struct {
+	StopOnError    bool `form:"stop_on_error,default=true"`
+	SkipValidation bool `form:"skip_validation"`
+}
+
+// responseTxBatchItem is the result of submitting a single TX in a batch. The
+// error is set for invalid, rejected, and failed TXs
+type responseTxBatchItem struct {
+	TxId   string            `json:"tx_id,omitempty" example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Status string            `json:"status"          example:"accepted"                                                        enums:"accepted,already_submitted,invalid,rejected,failed,skipped"`
+	Error  *responseApiError `json:"error,omitempty"`
+}
+
+// batchTx is a TX from a batch submission that was decoded and parsed
+type batchTx struct {
+	txType uint
+	txCbor []byte
+}
+
+// handleLocalSubmitTxs godoc
+//
+//	@Summary		Submit Tx batch
+//	@Description	Submit a list of already serialized transactions to the network, in order over a single node connection, so that transactions can spend the outputs of earlier ones. Each transaction is its CBOR encoded as hex or standard or URL-safe base64. The results are in the same order as the request, with the transaction ID or the error for each one. Invalid transactions, including those that fail the same checks as the /localtxsubmission/tx endpoint, are found before anything is submitted. With stop_on_error (the default), nothing is submitted if any transaction is invalid, and the transactions after one that is rejected are skipped. Failures communicating with the node always skip the rest. The response status is 202 if every transaction was accepted, and 200 otherwise.
+//	@Accept			json
+//	@Produce		json
+//	@Param			txs				body		[]string				true	"Transactions"
+//	@Param			stop_on_error	query		bool					false	"Whether to skip the rest of the batch after a failure (default true)"
+//	@Param			skip_validation	query		bool					false	"Whether to skip the checks before submitting"
+//	@Param			Idempotency-Key	header		string					false	"Key to identify retries of the same submission"
+//	@Success		200				{array}		responseTxBatchItem		"Ok"
+//	@Success		202				{array}		responseTxBatchItem		"Accepted"
+//	@Failure		400				{object}	responseApiError		"Bad Request"
+//	@Failure		409				{object}	responseApiError		"Conflict"
+//	@Failure		413				{object}	responseApiError		"Request Entity Too Large"
+//	@Failure		500				{object}	responseApiError		"Server Error"
+//	@Router			/localtxsubmission/txs [post]
+func handleLocalSubmitTxs(c *gin.Context) {
+	logger := requestLogger(c, logging.ComponentApi)
+	var req requestLocalTxSubmissionBatch
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	var reqTxs []string
+	if err := c.ShouldBindJSON(&reqTxs); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondRequestTooLarge(c, maxBytesErr.Limit)
+			return
+		}
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	cfg := config.GetConfig()
+	maxItems := cfg.Api.MaxTxBatchItems
+	if len(reqTxs) == 0 || uint(len(reqTxs)) > maxItems {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf("between 1 and %d transactions must be specified", maxItems),
+				nil,
+			),
+		)
+		return
+	}
+	validation, ok := queryTxValidation(c, req.SkipValidation)
+	if !ok {
+		return
+	}
+	// Check the whole batch before submitting any of it
+	results := make([]responseTxBatchItem, len(reqTxs))
+	txs := make([]batchTx, len(reqTxs))
+	invalid := false
+	for idx, reqTx := range reqTxs {
+		tx, txId, errResp := parseBatchTx([]byte(reqTx), validation)
+		results[idx].TxId = txId
+		if errResp != nil {
+			results[idx].Status = txStatusInvalid
+			results[idx].Error = errResp
+			invalid = true
+			continue
+		}
+		txs[idx] = tx
+	}
+	if invalid && req.StopOnError {
+		for idx := range results {
+			if results[idx].Error == nil {
+				results[idx].Status = txStatusSkipped
+			}
+		}
+		respondJson(c, 200, results)
+		return
+	}
+	ctx := c.Request.Context()
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		respondNodeUnavailable(c, err)
+		return
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	c.Header(nodeEndpointHeader, oConn.Endpoint())
+	allAccepted := !invalid
+	stopped := false
+	for idx, tx := range txs {
+		result := &results[idx]
+		if result.Error != nil {
+			continue
+		}
+		if stopped {
+			result.Status = txStatusSkipped
+			continue
+		}
+		err := sendTx(ctx, oConn, tx.txType, tx.txCbor)
+		if err == nil {
+			result.Status = txStatusAccepted
+			continue
+		}
+		var txRejectErr localtxsubmission.TransactionRejectedError
+		if !errors.As(err, &txRejectErr) {
+			logger.Errorf("failure communicating with node: %s", err)
+			result.Status = txStatusFailed
+			errResp := apiErrorCode(errorCodeNodeError, err.Error(), nil)
+			result.Error = &errResp
+			allAccepted = false
+			// The connection may be unusable, and the node may or may not have the
+			// TX, so there's no point in sending the rest
+			stopped = true
+			continue
+		}
+		if cfg.Api.Idempotency.DuplicateSuccess &&
+			txInMempool(c, oConn, result.TxId) {
+			result.Status = txStatusAlreadySubmitted
+			continue
+		}
+		result.Status = txStatusRejected
+		errResp := apiErrorCode(
+			errorCodeTxRejected,
+			err.Error(),
+			newResponseTxRejection(txRejectErr.ReasonCbor),
+		)
+		result.Error = &errResp
+		allAccepted = false
+		stopped = req.StopOnError
+	}
+	if allAccepted {
+		respondJson(c, 202, results)
+		return
+	}
+	respondJson(c, 200, results)
+}
+
+// parseBatchTx decodes and parses a TX from a batch submission, and checks it if
+// the validation is not nil. The TX ID is returned if the TX could be parsed
+func parseBatchTx(
+	reqTx []byte,
+	validation *txValidation,
+) (batchTx, string, *responseApiError) {
+	txCbor, _, attempts := decodeTxBody(reqTx)
+	if txCbor == nil {
+		errResp := apiErrorCode(
+			errorCodeInvalidEncoding,
+			"could not decode transaction as hex or base64",
+			attempts,
+		)
+		return batchTx{}, "", &errResp
+	}
+	txType, txId, tx, err := parseTx(txCbor)
+	if err != nil {
+		errResp := apiErrorCode(errorCodeInvalidCbor, err.Error(), nil)
+		return batchTx{}, "", &errResp
+	}
+	if validation != nil {
+		if errResp := validation.check(txType, txCbor, tx); errResp != nil {
+			return batchTx{}, txId, errResp
+		}
+	}
+	return batchTx{txType: txType, txCbor: txCbor}, txId, nil
+}
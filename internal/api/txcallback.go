@@ -0,0 +1,321 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+const (
+	txCallbackUrlHeader = "X-Callback-Url"
+	// HMAC-SHA256 of the callback body with the configured secret, in hex with a
+	// "sha256=" prefix
+	txCallbackSignatureHeader = "X-Signature-256"
+)
+
+// Events sent to TX callback URLs
+const (
+	txCallbackEventConfirmed = "confirmed"
+	txCallbackEventExpired   = "expired"
+)
+
+const (
+	// Backoff for retrying failed callbacks
+	txCallbackRetryInterval    = time.Second
+	txCallbackMaxRetryInterval = time.Minute
+	// How long to wait before following the chain again when the chain-sync has
+	// given up reconnecting to the node
+	txCallbackResyncInterval = 5 * time.Second
+)
+
+// txCallbackPayload is the body of a TX callback. The block fields are only set
+// for the confirmed event
+type txCallbackPayload struct {
+	Event         string    `json:"event"`
+	TxId          string    `json:"tx_id"`
+	BlockHash     string    `json:"block_hash,omitempty"`
+	Slot          uint64    `json:"slot,omitempty"`
+	BlockHeight   uint64    `json:"block_height,omitempty"`
+	Confirmations uint64    `json:"confirmations,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Number of TXs being watched for callbacks
+var txCallbackWatches atomic.Int64
+
+// txCallback watches a submitted TX and sends a callback when it's confirmed or
+// expires
+type txCallback struct {
+	url       string
+	txId      string
+	ttl       uint64
+	expiresAt time.Time
+	waiter    *txBlockWaiter
+}
+
+// newTxCallback returns a callback to the URL, if callbacks are enabled and the
+// URL is allowed. An error response has been sent if it returns nil
+func newTxCallback(c *gin.Context, callbackUrl string) *txCallback {
+	cfg := config.GetConfig().Api.TxCallback
+	if !cfg.Enabled {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, "TX callbacks are not enabled", nil),
+		)
+		return nil
+	}
+	u, err := url.Parse(callbackUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+		u.Host == "" {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				"invalid callback URL, should be an absolute http or https URL",
+				nil,
+			),
+		)
+		return nil
+	}
+	hostAllowed := func(host string) bool {
+		return strings.EqualFold(host, u.Hostname())
+	}
+	if len(cfg.AllowedHosts) > 0 &&
+		!slices.ContainsFunc(cfg.AllowedHosts, hostAllowed) {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf("callback URL host %s is not allowed", u.Hostname()),
+				nil,
+			),
+		)
+		return nil
+	}
+	return &txCallback{url: u.String()}
+}
+
+// watch starts following the chain for the TX, which is done before it's
+// submitted so that we can't miss its block. An error response has been sent if
+// it returns false
+func (t *txCallback) watch(
+	c *gin.Context,
+	txId string,
+	tx ledger.Transaction,
+) bool {
+	cfg := config.GetConfig().Api.TxCallback
+	if txCallbackWatches.Add(1) > int64(cfg.MaxWatches) {
+		txCallbackWatches.Add(-1)
+		respondError(
+			c,
+			http.StatusServiceUnavailable,
+			apiErrorCode(
+				errorCodeCallbackLimit,
+				"too many TXs are being watched for callbacks",
+				nil,
+			),
+		)
+		return false
+	}
+	waiter, err := txWatcher.register(txId)
+	if err != nil {
+		txCallbackWatches.Add(-1)
+		respondNodeUnavailable(c, err)
+		return false
+	}
+	t.txId = txId
+	t.ttl = tx.TTL()
+	t.expiresAt = time.Now().Add(time.Duration(cfg.MaxWatchTime) * time.Second)
+	t.waiter = waiter
+	return true
+}
+
+// stop stops watching the TX
+func (t *txCallback) stop() {
+	if t.waiter != nil {
+		txWatcher.unregister(t.waiter)
+	}
+	txCallbackWatches.Add(-1)
+}
+
+// run waits for the TX to be confirmed or expire, and sends the callback
+func (t *txCallback) run() {
+	payload := t.wait()
+	t.stop()
+	t.send(payload)
+}
+
+// wait returns the callback payload once the TX has enough confirmations, or
+// its TTL or the max watch time has passed without that happening
+func (t *txCallback) wait() txCallbackPayload {
+	cfg := config.GetConfig().Api.TxCallback
+	logger := logging.GetLogger(logging.ComponentApi)
+	expired := txCallbackPayload{
+		Event: txCallbackEventExpired,
+		TxId:  t.txId,
+	}
+	expiryTimer := time.NewTimer(time.Until(t.expiresAt))
+	defer expiryTimer.Stop()
+	for {
+		select {
+		case <-expiryTimer.C:
+			return expired
+		case <-t.waiter.updates:
+		}
+		block, confirmations, err := txWatcher.state(t.waiter)
+		if block != nil && confirmations >= uint64(cfg.Confirmations) {
+			return txCallbackPayload{
+				Event:         txCallbackEventConfirmed,
+				TxId:          t.txId,
+				BlockHash:     block.Hash,
+				Slot:          block.Slot,
+				BlockHeight:   block.Number,
+				Confirmations: confirmations,
+			}
+		}
+		// The TX can't be included once the chain is past its TTL
+		if block == nil && t.ttl > 0 && txWatcher.currentSlot() >= t.ttl {
+			return expired
+		}
+		if err == nil {
+			continue
+		}
+		// The chain-sync gave up reconnecting to the node, so keep trying to
+		// follow the chain again
+		logger.Warnf(
+			"lost chain-sync for TX %s callback, retrying: %s",
+			t.txId,
+			err,
+		)
+		txWatcher.unregister(t.waiter)
+		t.waiter = nil
+		for t.waiter == nil {
+			select {
+			case <-expiryTimer.C:
+				return expired
+			case <-time.After(txCallbackResyncInterval):
+			}
+			waiter, err := txWatcher.register(t.txId)
+			if err != nil {
+				logger.Debugf("failed to follow the chain: %s", err)
+				continue
+			}
+			t.waiter = waiter
+		}
+	}
+}
+
+// send posts the callback payload, retrying with backoff if it fails
+func (t *txCallback) send(payload txCallbackPayload) {
+	cfg := config.GetConfig().Api.TxCallback
+	logger := logging.GetLogger(logging.ComponentApi)
+	payload.Timestamp = time.Now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Errorf("failed to encode TX callback: %s", err)
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.Secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	client := &http.Client{
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+		// Following redirects would get around the allowed hosts
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	delay := txCallbackRetryInterval
+	for attempt := uint(1); ; attempt++ {
+		err := postTxCallback(client, t.url, body, signature)
+		if err == nil {
+			logger.Debugf(
+				"sent %s callback for TX %s",
+				payload.Event,
+				payload.TxId,
+			)
+			return
+		}
+		if attempt > cfg.RetryAttempts {
+			logger.Warnf(
+				"giving up on %s callback for TX %s after %d attempts: %s",
+				payload.Event,
+				payload.TxId,
+				attempt,
+				err,
+			)
+			return
+		}
+		logger.Debugf(
+			"failed to send %s callback for TX %s, retrying in %s: %s",
+			payload.Event,
+			payload.TxId,
+			delay,
+			err,
+		)
+		time.Sleep(delay)
+		delay = min(delay*2, txCallbackMaxRetryInterval)
+	}
+}
+
+func postTxCallback(
+	client *http.Client,
+	callbackUrl string,
+	body []byte,
+	signature string,
+) error {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		callbackUrl,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(txCallbackSignatureHeader, signature)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Read the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
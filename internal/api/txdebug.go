@@ -0,0 +1,117 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// txSubmitDebugMiddleware logs the request and response bodies of TX
+// submissions at debug level. Bodies over maxBytes are truncated
+func txSubmitDebugMiddleware(maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := requestLogger(c, logging.ComponentApi)
+		var reqBody []byte
+		if c.Request.Body != nil {
+			var err error
+			reqBody, err = io.ReadAll(c.Request.Body)
+			// Put the body back for the handler, along with any read error
+			body := io.Reader(bytes.NewReader(reqBody))
+			if err != nil {
+				logger.Debugf("failed to read TX submission body: %s", err)
+				body = io.MultiReader(body, errorReader{err})
+			}
+			_ = c.Request.Body.Close()
+			c.Request.Body = io.NopCloser(body)
+		}
+		w := &bodyCaptureWriter{
+			ResponseWriter: c.Writer,
+			maxBytes:       maxBytes,
+		}
+		c.Writer = w
+		defer func() {
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+		reqHex, reqTruncated := truncateBody(reqBody, maxBytes)
+		logger.Debugw(
+			"TX submission",
+			"tx_hash", c.GetString(contextKeyTxHash),
+			"request_body", reqHex,
+			"request_body_size", len(reqBody),
+			"request_body_truncated", reqTruncated,
+			"response_status", w.Status(),
+			"response_body", string(w.body),
+			"response_body_size", w.size,
+			"response_body_truncated", w.size > len(w.body),
+		)
+	}
+}
+
+// truncateBody returns up to maxBytes of the body as hex and whether it was
+// truncated
+func truncateBody(body []byte, maxBytes int) (string, bool) {
+	if len(body) > maxBytes {
+		return hex.EncodeToString(body[:maxBytes]), true
+	}
+	return hex.EncodeToString(body), false
+}
+
+// bodyCaptureWriter keeps a copy of up to maxBytes of the response body
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	maxBytes int
+	body     []byte
+	size     int
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap returns the underlying writer, so that http.ResponseController can
+// reach the connection
+func (w *bodyCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bodyCaptureWriter) capture(data []byte) {
+	w.size += len(data)
+	if remaining := w.maxBytes - len(w.body); remaining > 0 {
+		w.body = append(w.body, data[:min(len(data), remaining)]...)
+	}
+}
+
+// errorReader returns an error for every read
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,446 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Names of the certificate types by their CBOR type ID
+var certificateTypeNames = map[int]string{
+	ledger.CertificateTypeStakeRegistration:               "stake_registration",
+	ledger.CertificateTypeStakeDeregistration:             "stake_deregistration",
+	ledger.CertificateTypeStakeDelegation:                 "stake_delegation",
+	ledger.CertificateTypePoolRegistration:                "pool_registration",
+	ledger.CertificateTypePoolRetirement:                  "pool_retirement",
+	ledger.CertificateTypeGenesisKeyDelegation:            "genesis_key_delegation",
+	ledger.CertificateTypeMoveInstantaneousRewards:        "move_instantaneous_rewards",
+	ledger.CertificateTypeRegistration:                    "registration",
+	ledger.CertificateTypeDeregistration:                  "deregistration",
+	ledger.CertificateTypeVoteDelegation:                  "vote_delegation",
+	ledger.CertificateTypeStakeVoteDelegation:             "stake_vote_delegation",
+	ledger.CertificateTypeStakeRegistrationDelegation:     "stake_registration_delegation",
+	ledger.CertificateTypeVoteRegistrationDelegation:      "vote_registration_delegation",
+	ledger.CertificateTypeStakeVoteRegistrationDelegation: "stake_vote_registration_delegation",
+	ledger.CertificateTypeAuthCommitteeHot:                "auth_committee_hot",
+	ledger.CertificateTypeResignCommitteeCold:             "resign_committee_cold",
+	ledger.CertificateTypeRegistrationDrep:                "drep_registration",
+	ledger.CertificateTypeDeregistrationDrep:              "drep_deregistration",
+	ledger.CertificateTypeUpdateDrep:                      "drep_update",
+}
+
+// Witness set keys
+const (
+	witnessSetVkeys         = 0
+	witnessSetNativeScripts = 1
+	witnessSetBootstrap     = 2
+	witnessSetPlutusV1      = 3
+	witnessSetPlutusData    = 4
+	witnessSetPlutusV2      = 6
+	witnessSetPlutusV3      = 7
+)
+
+func configureTxRoutes(apiGroup *gin.RouterGroup, version int) {
+	group := apiGroup.Group("/tx")
+	cfg := config.GetConfig()
+	group.POST(
+		"/decode",
+		txSubmitHandlers(cfg.Api.MaxTxSubmitBytes, false, handleTxDecode)...,
+	)
+	group.POST(
+		"/hash",
+		txSubmitHandlers(cfg.Api.MaxTxSubmitBytes, false, handleTxHash)...,
+	)
+	group.POST(
+		"/metadata",
+		txSubmitHandlers(cfg.Api.MaxTxSubmitBytes, false, handleTxMetadata)...,
+	)
+}
+
+type responseTxDecode struct {
+	Era                    string                  `json:"era"                               example:"conway"`
+	TxId                   string                  `json:"tx_id"                             example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Valid                  bool                    `json:"valid"                             example:"true"`
+	Inputs                 []responseTxIn          `json:"inputs"`
+	Outputs                []responseUtxo          `json:"outputs"`
+	Fee                    uint64                  `json:"fee"                               example:"168053"`
+	Ttl                    *uint64                 `json:"ttl"                               example:"134217728"`
+	ValidityIntervalStart  *uint64                 `json:"validity_interval_start"           example:"134210000"`
+	Certificates           []responseTxCertificate `json:"certificates"`
+	Withdrawals            []responseTxWithdrawal  `json:"withdrawals"`
+	Mint                   []responseTxMintAsset   `json:"mint"`
+	RequiredSigners        []string                `json:"required_signers"                  example:"e9c5bd6d1b4fb5ff9b4e5c7f4b5e1d1a8c2a6f3c5e7d9b1a3c5e7f91"`
+	Collateral             []responseTxIn          `json:"collateral"`
+	CollateralReturn       *responseUtxo           `json:"collateral_return"`
+	TotalCollateral        *uint64                 `json:"total_collateral"                  example:"5000000"`
+	ReferenceInputs        []responseTxIn          `json:"reference_inputs"`
+	Metadata               []responseTxMetadata    `json:"metadata"`
+	Witnesses              responseTxWitnesses     `json:"witnesses"`
+	AuxiliaryDataHash      string                  `json:"auxiliary_data_hash,omitempty"     example:"923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"`
+	ScriptDataHash         string                  `json:"script_data_hash,omitempty"        example:"923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"`
+	ProposalProcedureCount int                     `json:"proposal_procedure_count"          example:"0"`
+}
+
+type responseTxIn struct {
+	TxHash      string `json:"tx_hash"      example:"9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"`
+	OutputIndex uint32 `json:"output_index" example:"0"`
+}
+
+// responseTxCertificate is a certificate by its type, with its CBOR in hex for
+// the details
+type responseTxCertificate struct {
+	Type string `json:"type" example:"stake_delegation"`
+	Cbor string `json:"cbor" example:"83028200581c..."`
+}
+
+type responseTxWithdrawal struct {
+	Address  string `json:"address"  example:"stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"`
+	Lovelace uint64 `json:"lovelace" example:"1500000"`
+}
+
+// responseTxMintAsset is an asset minted by a TX, or burned if the quantity is
+// negative
+type responseTxMintAsset struct {
+	PolicyId string `json:"policy_id"      example:"29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"`
+	Name     string `json:"name,omitempty" example:"MIN"`
+	NameHex  string `json:"name_hex"       example:"4d494e"`
+	Quantity int64  `json:"quantity"       example:"1000"`
+}
+
+type responseTxWitnesses struct {
+	Vkeys           int `json:"vkeys"             example:"1"`
+	Bootstrap       int `json:"bootstrap"         example:"0"`
+	NativeScripts   int `json:"native_scripts"    example:"0"`
+	PlutusV1Scripts int `json:"plutus_v1_scripts" example:"0"`
+	PlutusV2Scripts int `json:"plutus_v2_scripts" example:"0"`
+	PlutusV3Scripts int `json:"plutus_v3_scripts" example:"0"`
+	PlutusData      int `json:"plutus_data"       example:"0"`
+	Redeemers       int `json:"redeemers"         example:"0"`
+}
+
+// handleTxDecode godoc
+//
+//	@Summary		Decode Tx
+//	@Description	Decode a transaction to JSON, without a connection to the node. The body is the transaction CBOR, either raw or encoded as hex or base64, like the /localtxsubmission/tx endpoint. The era is the earliest one whose format the transaction can be decoded as. Outputs have the transaction ID and their index, like UTxOs, and the collateral return output has the index after the last output. Certificates have their type and CBOR. Metadata is rendered as JSON like cardano-cli does without a schema, with the names of well-known CIP-10 labels, or as CBOR if it can't be rendered that way. The witnesses are counted by type.
+//	@Tags			tx
+//	@Produce		json
+//	@Param			Content-Type	header		string	true	"Content type"	Enums(application/cbor, application/octet-stream, text/plain)
+//	@Success		200				{object}	responseTxDecode
+//	@Failure		400				{object}	responseApiError
+//	@Failure		413				{object}	responseApiError
+//	@Failure		415				{object}	responseApiError
+//	@Router			/tx/decode [post]
+func handleTxDecode(c *gin.Context) {
+	reqBody, ok := readTxRequestBody(c)
+	if !ok {
+		return
+	}
+	txCbor, _, attempts := decodeTxBody(reqBody)
+	if txCbor == nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidEncoding,
+				"could not decode transaction as raw CBOR, hex, or base64",
+				attempts,
+			),
+		)
+		return
+	}
+	txType, txId, tx, err := parseTx(txCbor)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	c.Set(contextKeyTxHash, txId)
+	resp, err := newResponseTxDecode(txType, txId, txCbor, tx)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	respondJson(c, 200, resp)
+}
+
+// readTxRequestBody reads the body of a request with a TX that isn't being
+// submitted, after checking its content type. An error response has been sent if
+// it returns false
+func readTxRequestBody(c *gin.Context) ([]byte, bool) {
+	logger := requestLogger(c, logging.ComponentApi)
+	if c.ContentType() != mimeTypeCbor &&
+		c.ContentType() != mimeTypeOctetStream &&
+		c.ContentType() != mimeTypeText {
+		respondError(
+			c,
+			415,
+			apiErrorCode(
+				errorCodeUnsupportedMediaType,
+				"invalid request body, should be application/cbor, application/octet-stream, or text/plain",
+				nil,
+			),
+		)
+		return nil, false
+	}
+	reqBody, err := io.ReadAll(c.Request.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondRequestTooLarge(c, maxBytesErr.Limit)
+		return nil, false
+	}
+	if err != nil {
+		logger.Errorf("failed to read request body: %s", err)
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, "failed to read request body", nil),
+		)
+		return nil, false
+	}
+	return reqBody, true
+}
+
+func newResponseTxDecode(
+	txType uint,
+	txId string,
+	txCbor []byte,
+	tx ledger.Transaction,
+) (responseTxDecode, error) {
+	resp := responseTxDecode{
+		Era:                    strings.ToLower(ledger.GetEraById(uint8(txType)).Name),
+		TxId:                   txId,
+		Valid:                  tx.IsValid(),
+		Inputs:                 newResponseTxIns(tx.Inputs()),
+		Outputs:                []responseUtxo{},
+		Fee:                    tx.Fee(),
+		Certificates:           []responseTxCertificate{},
+		Withdrawals:            []responseTxWithdrawal{},
+		Mint:                   newResponseTxMint(tx.AssetMint()),
+		RequiredSigners:        []string{},
+		Collateral:             newResponseTxIns(tx.Collateral()),
+		ReferenceInputs:        newResponseTxIns(tx.ReferenceInputs()),
+		ProposalProcedureCount: len(tx.ProposalProcedures()),
+	}
+	txHash, err := hex.DecodeString(txId)
+	if err != nil {
+		return resp, err
+	}
+	for idx, output := range tx.Outputs() {
+		tmpOutput, err := newResponseTxOutput(txHash, idx, output)
+		if err != nil {
+			return resp, err
+		}
+		resp.Outputs = append(resp.Outputs, tmpOutput)
+	}
+	if output := tx.CollateralReturn(); output != nil {
+		tmpOutput, err := newResponseTxOutput(txHash, len(resp.Outputs), output)
+		if err != nil {
+			return resp, err
+		}
+		resp.CollateralReturn = &tmpOutput
+	}
+	if totalCollateral := tx.TotalCollateral(); totalCollateral > 0 {
+		resp.TotalCollateral = &totalCollateral
+	}
+	if ttl := tx.TTL(); ttl > 0 {
+		resp.Ttl = &ttl
+	}
+	if start := tx.ValidityIntervalStart(); start > 0 {
+		resp.ValidityIntervalStart = &start
+	}
+	for _, cert := range tx.Certificates() {
+		certType, err := cbor.DecodeIdFromList(cert.Cbor())
+		if err != nil {
+			return resp, fmt.Errorf("failed to decode certificate: %s", err)
+		}
+		name, ok := certificateTypeNames[certType]
+		if !ok {
+			name = strconv.Itoa(certType)
+		}
+		resp.Certificates = append(resp.Certificates, responseTxCertificate{
+			Type: name,
+			Cbor: hex.EncodeToString(cert.Cbor()),
+		})
+	}
+	for addr, amount := range tx.Withdrawals() {
+		resp.Withdrawals = append(resp.Withdrawals, responseTxWithdrawal{
+			Address:  addr.String(),
+			Lovelace: amount,
+		})
+	}
+	slices.SortFunc(resp.Withdrawals, func(a, b responseTxWithdrawal) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	for _, signer := range tx.RequiredSigners() {
+		resp.RequiredSigners = append(resp.RequiredSigners, signer.String())
+	}
+	if auxDataHash := tx.AuxDataHash(); auxDataHash != nil {
+		resp.AuxiliaryDataHash = auxDataHash.String()
+	}
+	if scriptDataHash := tx.ScriptDataHash(); scriptDataHash != nil {
+		resp.ScriptDataHash = scriptDataHash.String()
+	}
+	var txArray []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txArray); err != nil || len(txArray) < 2 {
+		return resp, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	witnesses, err := newResponseTxWitnesses(txCbor, txArray[1])
+	if err != nil {
+		return resp, err
+	}
+	resp.Witnesses = witnesses
+	// The auxiliary data is last, after the validity flag from Alonzo on
+	metadataCbor, _, err := parseTxAuxData(txArray[len(txArray)-1])
+	if err != nil {
+		return resp, err
+	}
+	resp.Metadata, err = newResponseTxMetadata(
+		metadataCbor,
+		txMetadataSchemaNoSchema,
+	)
+	if err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+func newResponseTxIns(inputs []ledger.TransactionInput) []responseTxIn {
+	ret := make([]responseTxIn, 0, len(inputs))
+	for _, input := range inputs {
+		ret = append(ret, responseTxIn{
+			TxHash:      input.Id().String(),
+			OutputIndex: input.Index(),
+		})
+	}
+	return ret
+}
+
+// newResponseTxOutput returns an output of a TX in the same form as the UTxO it
+// would create
+func newResponseTxOutput(
+	txHash []byte,
+	idx int,
+	output ledger.TransactionOutput,
+) (responseUtxo, error) {
+	// Outputs from before Alonzo don't keep their CBOR, so they're encoded
+	// again, which gives the same output even if not the same bytes
+	outputCbor := output.Cbor()
+	if len(outputCbor) == 0 {
+		var err error
+		if outputCbor, err = cbor.Encode(output); err != nil {
+			return responseUtxo{}, err
+		}
+	}
+	babbageOutput, err := ledger.NewBabbageTransactionOutputFromCbor(outputCbor)
+	if err != nil {
+		return responseUtxo{}, err
+	}
+	return newResponseUtxo(
+		localstatequery.UtxoId{Hash: ledger.NewBlake2b256(txHash), Idx: idx},
+		*babbageOutput,
+	)
+}
+
+// newResponseTxMint returns the minted and burned assets, sorted by policy ID and
+// asset name
+func newResponseTxMint(
+	assets *ledger.MultiAsset[ledger.MultiAssetTypeMint],
+) []responseTxMintAsset {
+	ret := []responseTxMintAsset{}
+	if assets == nil {
+		return ret
+	}
+	policyIds := assets.Policies()
+	slices.SortFunc(policyIds, func(a, b ledger.Blake2b224) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for _, policyId := range policyIds {
+		assetNames := assets.Assets(policyId)
+		slices.SortFunc(assetNames, bytes.Compare)
+		for _, assetName := range assetNames {
+			tmpAsset := responseTxMintAsset{
+				PolicyId: policyId.String(),
+				NameHex:  hex.EncodeToString(assetName),
+				Quantity: assets.Asset(policyId, assetName),
+			}
+			if utf8.Valid(assetName) {
+				tmpAsset.Name = string(assetName)
+			}
+			ret = append(ret, tmpAsset)
+		}
+	}
+	return ret
+}
+
+// newResponseTxWitnesses counts the witnesses in a witness set by their type
+func newResponseTxWitnesses(
+	txCbor []byte,
+	witnessSetCbor []byte,
+) (responseTxWitnesses, error) {
+	var ret responseTxWitnesses
+	var witnesses map[uint]cbor.RawMessage
+	if _, err := cbor.Decode(witnessSetCbor, &witnesses); err != nil {
+		return ret, fmt.Errorf("failed to decode witness set: %s", err)
+	}
+	for key, dest := range map[uint]*int{
+		witnessSetVkeys:         &ret.Vkeys,
+		witnessSetNativeScripts: &ret.NativeScripts,
+		witnessSetBootstrap:     &ret.Bootstrap,
+		witnessSetPlutusV1:      &ret.PlutusV1Scripts,
+		witnessSetPlutusData:    &ret.PlutusData,
+		witnessSetPlutusV2:      &ret.PlutusV2Scripts,
+		witnessSetPlutusV3:      &ret.PlutusV3Scripts,
+	} {
+		witnessList, ok := witnesses[key]
+		if !ok {
+			continue
+		}
+		var items []cbor.RawMessage
+		if _, err := cbor.Decode(witnessList, &items); err != nil {
+			return ret, fmt.Errorf("failed to decode witnesses: %s", err)
+		}
+		*dest = len(items)
+	}
+	redeemers, err := decodeTxRedeemers(txCbor)
+	if err != nil {
+		return ret, err
+	}
+	ret.Redeemers = len(redeemers)
+	return ret, nil
+}
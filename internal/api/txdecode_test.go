@@ -0,0 +1,187 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// One signed TX per era, built in the encoding the node uses for that era. The
+// inputs spend TX 0x11 bytes, the outputs pay to the enterprise address for key
+// hash 0x22 bytes, and each has a single vkey witness with key 0x33 bytes
+const (
+	testTxShelleyHex = "83a40081825820111111111111111111111111111111111111111111111111111111111111111100018182581d612222" +
+		"22222222222222222222222222222222222222222222222222221a000f4240021a0002981003191388a1008182582033" +
+		"333333333333333333333333333333333333333333333333333333333333335840444444444444444444444444444444" +
+		"444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444" +
+		"44f6"
+	testTxAllegraHex = "83a50081825820111111111111111111111111111111111111111111111111111111111111111101018182581d612222" +
+		"22222222222222222222222222222222222222222222222222221a001e8480021a0002ab9803191770081903e8a20081" +
+		"825820333333333333333333333333333333333333333333333333333333333333333358404444444444444444444444" +
+		"444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444444" +
+		"444444444401818200581c66666666666666666666666666666666666666666666666666666666f6"
+	testTxMaryHex = "83a40081825820111111111111111111111111111111111111111111111111111111111111111102018182581d612222" +
+		"2222222222222222222222222222222222222222222222222222821a0016e360a1581c55555555555555555555555555" +
+		"555555555555555555555555555555a143746f6b05021a0002bf2009a1581c5555555555555555555555555555555555" +
+		"5555555555555555555555a143746f6b05a1008182582033333333333333333333333333333333333333333333333333" +
+		"333333333333335840444444444444444444444444444444444444444444444444444444444444444444444444444444" +
+		"44444444444444444444444444444444444444444444444444f6"
+	testTxAlonzoHex = "84a60081825820111111111111111111111111111111111111111111111111111111111111111103018183581d612222" +
+		"22222222222222222222222222222222222222222222222222221a002dc6c05820777777777777777777777777777777" +
+		"7777777777777777777777777777777777021a0002e6300b582088888888888888888888888888888888888888888888" +
+		"888888888888888888880d81825820111111111111111111111111111111111111111111111111111111111111111104" +
+		"0e81581c99999999999999999999999999999999999999999999999999999999a1008182582033333333333333333333" +
+		"333333333333333333333333333333333333333333335840444444444444444444444444444444444444444444444444" +
+		"44444444444444444444444444444444444444444444444444444444444444444444444444444444f5f6"
+	testTxBabbageHex = "84a700818258201111111111111111111111111111111111111111111111111111111111111111050181a200581d6122" +
+		"222222222222222222222222222222222222222222222222222222011a003d0900021a00030d400d8182582011111111" +
+		"111111111111111111111111111111111111111111111111111111110610a200581d6122222222222222222222222222" +
+		"222222222222222222222222222222011a0044aa20111a000493e0128182582011111111111111111111111111111111" +
+		"1111111111111111111111111111111107a1008182582033333333333333333333333333333333333333333333333333" +
+		"333333333333335840444444444444444444444444444444444444444444444444444444444444444444444444444444" +
+		"44444444444444444444444444444444444444444444444444f5f6"
+	testTxConwayHex = "84a400d90102818258201111111111111111111111111111111111111111111111111111111111111111080181a20058" +
+		"1d6122222222222222222222222222222222222222222222222222222222011a004c4b40021a0003345014d901028184" +
+		"1b000000174876e800581de122222222222222222222222222222222222222222222222222222222810682781a687474" +
+		"70733a2f2f6578616d706c652e636f6d2f612e6a736f6e5820aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" +
+		"aaaaaaaaaaaaaaaaaaa100d9010281825820333333333333333333333333333333333333333333333333333333333333" +
+		"333358404444444444444444444444444444444444444444444444444444444444444444444444444444444444444444" +
+		"4444444444444444444444444444444444444444f5f6"
+)
+
+const testTxAddress = "addr1vy3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygs44r503"
+
+func TestHandleTxDecode(t *testing.T) {
+	testDefs := []struct {
+		name  string
+		txHex string
+		// The decoded fields to check, which are all compared at the top level
+		want string
+	}{
+		{
+			name:  "shelley",
+			txHex: testTxShelleyHex,
+			want: `{"era":"shelley","tx_id":"2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+				"valid":true,"fee":170000,"ttl":5000,"validity_interval_start":null,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":0}],
+				"outputs":[{"tx_hash":"2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":1000000,"assets":[]}],
+				"witnesses":{"vkeys":1,"bootstrap":0,"native_scripts":0,"plutus_v1_scripts":0,"plutus_v2_scripts":0,
+				"plutus_v3_scripts":0,"plutus_data":0,"redeemers":0}}`,
+		},
+		{
+			name:  "allegra",
+			txHex: testTxAllegraHex,
+			want: `{"era":"allegra","tx_id":"4e2a5d34c7ed0966275c94653b534f0cbe5b74d953d6998b666d8c5293191f30",
+				"fee":175000,"ttl":6000,"validity_interval_start":1000,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":1}],
+				"outputs":[{"tx_hash":"4e2a5d34c7ed0966275c94653b534f0cbe5b74d953d6998b666d8c5293191f30","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":2000000,"assets":[]}],
+				"witnesses":{"vkeys":1,"bootstrap":0,"native_scripts":1,"plutus_v1_scripts":0,"plutus_v2_scripts":0,
+				"plutus_v3_scripts":0,"plutus_data":0,"redeemers":0}}`,
+		},
+		{
+			name:  "mary",
+			txHex: testTxMaryHex,
+			want: `{"era":"mary","tx_id":"90d240f40dc93b66fbeae49be2a3c20dcf3956f269e64dafd9c7d6cd84b56b89",
+				"fee":180000,"ttl":null,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":2}],
+				"outputs":[{"tx_hash":"90d240f40dc93b66fbeae49be2a3c20dcf3956f269e64dafd9c7d6cd84b56b89","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":1500000,"assets":[{"policy_id":"55555555555555555555555555555555555555555555555555555555",
+				"name":"tok","name_hex":"746f6b","quantity":5}]}],
+				"mint":[{"policy_id":"55555555555555555555555555555555555555555555555555555555","name":"tok","name_hex":"746f6b","quantity":5}]}`,
+		},
+		{
+			name:  "alonzo",
+			txHex: testTxAlonzoHex,
+			want: `{"era":"alonzo","tx_id":"917a798f21409aeeb59a1c5036ccceae73cd33b368b9c7ac361c84118bd51a46",
+				"valid":true,"fee":190000,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":3}],
+				"outputs":[{"tx_hash":"917a798f21409aeeb59a1c5036ccceae73cd33b368b9c7ac361c84118bd51a46","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":3000000,"assets":[],
+				"datum_hash":"7777777777777777777777777777777777777777777777777777777777777777"}],
+				"collateral":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":4}],
+				"required_signers":["99999999999999999999999999999999999999999999999999999999"],
+				"script_data_hash":"8888888888888888888888888888888888888888888888888888888888888888"}`,
+		},
+		{
+			name:  "babbage",
+			txHex: testTxBabbageHex,
+			want: `{"era":"babbage","tx_id":"c7b25267409e2fd9c6df7e13d011f9a1352df325a3a20bc25958d0d18e259a09",
+				"fee":200000,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":5}],
+				"outputs":[{"tx_hash":"c7b25267409e2fd9c6df7e13d011f9a1352df325a3a20bc25958d0d18e259a09","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":4000000,"assets":[]}],
+				"collateral":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":6}],
+				"collateral_return":{"tx_hash":"c7b25267409e2fd9c6df7e13d011f9a1352df325a3a20bc25958d0d18e259a09","output_index":1,
+				"address":"` + testTxAddress + `","lovelace":4500000,"assets":[]},
+				"total_collateral":300000,
+				"reference_inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":7}]}`,
+		},
+		{
+			name:  "conway",
+			txHex: testTxConwayHex,
+			want: `{"era":"conway","tx_id":"da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+				"fee":210000,
+				"inputs":[{"tx_hash":"1111111111111111111111111111111111111111111111111111111111111111","output_index":8}],
+				"outputs":[{"tx_hash":"da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697","output_index":0,
+				"address":"` + testTxAddress + `","lovelace":5000000,"assets":[]}],
+				"proposal_procedure_count":1,
+				"witnesses":{"vkeys":1,"bootstrap":0,"native_scripts":0,"plutus_v1_scripts":0,"plutus_v2_scripts":0,
+				"plutus_v3_scripts":0,"plutus_data":0,"redeemers":0}}`,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/tx/decode", handleTxDecode)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/tx/decode",
+				strings.NewReader(testDef.txHex),
+			)
+			req.Header.Set("Content-Type", mimeTypeText)
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var got, want map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if err := json.Unmarshal([]byte(testDef.want), &want); err != nil {
+				t.Fatalf("invalid test JSON: %s", err)
+			}
+			for key, wantValue := range want {
+				if !reflect.DeepEqual(got[key], wantValue) {
+					t.Fatalf(
+						"unexpected %s:\n got: %v\nwant: %v",
+						key,
+						got[key],
+						wantValue,
+					)
+				}
+			}
+		})
+	}
+}
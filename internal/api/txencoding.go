@@ -0,0 +1,137 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// TX submission body encodings, in the order that they're tried
+const (
+	txEncodingCbor      = "cbor"
+	txEncodingHex       = "hex"
+	txEncodingBase64    = "base64"
+	txEncodingBase64Url = "base64url"
+)
+
+// Reports how the submitted TX body was encoded
+const txEncodingHeader = "X-Tx-Encoding"
+
+// responseTxEncodingAttempt is an encoding that was tried for a TX submission
+// body, and why it didn't work
+type responseTxEncodingAttempt struct {
+	Encoding string `json:"encoding" example:"hex"`
+	Error    string `json:"error"    example:"encoding/hex: invalid byte: U+0067 'g'"`
+}
+
+// txDecoder decodes a TX submission body in a single encoding
+type txDecoder struct {
+	encoding string
+	decode   func([]byte) ([]byte, error)
+}
+
+var txDecoders = []txDecoder{
+	{
+		encoding: txEncodingCbor,
+		decode: func(body []byte) ([]byte, error) {
+			return body, nil
+		},
+	},
+	{
+		encoding: txEncodingHex,
+		decode: func(body []byte) ([]byte, error) {
+			body = stripWhitespace(body)
+			if bytes.HasPrefix(body, []byte("0x")) ||
+				bytes.HasPrefix(body, []byte("0X")) {
+				body = body[2:]
+			}
+			return hexDecode(body)
+		},
+	},
+	{
+		encoding: txEncodingBase64,
+		decode: func(body []byte) ([]byte, error) {
+			return base64Decode(base64.StdEncoding, stripWhitespace(body))
+		},
+	},
+	{
+		encoding: txEncodingBase64Url,
+		decode: func(body []byte) ([]byte, error) {
+			return base64Decode(base64.URLEncoding, stripWhitespace(body))
+		},
+	},
+}
+
+// decodeTxBody returns the TX CBOR from a submission body, which may be raw CBOR
+// or text in hex or base64, along with the detected encoding. Each encoding is
+// tried in turn until one gives something that looks like a TX. Otherwise, the
+// attempted encodings are returned with their errors
+func decodeTxBody(
+	body []byte,
+) ([]byte, string, []responseTxEncodingAttempt) {
+	return decodeTxBodyCheck(body, func(txBytes []byte) error {
+		_, err := ledger.DetermineTransactionType(txBytes)
+		return err
+	})
+}
+
+// decodeTxBodyCheck is like decodeTxBody, with the check for whether the decoded
+// bytes look like what the body should have
+func decodeTxBodyCheck(
+	body []byte,
+	check func([]byte) error,
+) ([]byte, string, []responseTxEncodingAttempt) {
+	attempts := make([]responseTxEncodingAttempt, 0, len(txDecoders))
+	for _, decoder := range txDecoders {
+		txBytes, err := decoder.decode(body)
+		if err == nil {
+			err = check(txBytes)
+		}
+		if err == nil {
+			return txBytes, decoder.encoding, nil
+		}
+		attempts = append(attempts, responseTxEncodingAttempt{
+			Encoding: decoder.encoding,
+			Error:    err.Error(),
+		})
+	}
+	return nil, "", attempts
+}
+
+// stripWhitespace returns data without any spaces or line breaks, which text
+// encodings are often wrapped or padded with
+func stripWhitespace(data []byte) []byte {
+	return bytes.Join(bytes.Fields(data), nil)
+}
+
+func hexDecode(data []byte) ([]byte, error) {
+	ret := make([]byte, hex.DecodedLen(len(data)))
+	n, err := hex.Decode(ret, data)
+	return ret[:n], err
+}
+
+// base64Decode decodes base64 with or without padding
+func base64Decode(enc *base64.Encoding, data []byte) ([]byte, error) {
+	if !bytes.HasSuffix(data, []byte("=")) {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	ret := make([]byte, enc.DecodedLen(len(data)))
+	n, err := enc.Decode(ret, data)
+	return ret[:n], err
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The same TX in each encoding. The input TX ID is 0xfb bytes, so that the
+// standard and URL-safe base64 differ
+const (
+	testEncodingTxHex = "84a30081825820fbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfbfb" +
+		"00018182581d60222222222222222222222222222222222222222222222222222222221a000f4240021a00030d40a0f5f6"
+	testEncodingTxBase64 = "hKMAgYJYIPv7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7" +
+		"AAGBglgdYCIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIaAA9CQAIaAAMNQKD19g=="
+	testEncodingTxBase64Url = "hKMAgYJYIPv7-_v7-_v7-_v7-_v7-_v7-_v7-_v7-_v7-_v7-_v7" +
+		"AAGBglgdYCIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIiIaAA9CQAIaAAMNQKD19g"
+)
+
+func TestDecodeTxBody(t *testing.T) {
+	txCbor, err := hex.DecodeString(testEncodingTxHex)
+	if err != nil {
+		t.Fatalf("invalid test TX hex: %s", err)
+	}
+	testDefs := []struct {
+		name         string
+		body         []byte
+		wantEncoding string
+	}{
+		{
+			name:         "raw CBOR",
+			body:         txCbor,
+			wantEncoding: txEncodingCbor,
+		},
+		{
+			name:         "hex",
+			body:         []byte(testEncodingTxHex),
+			wantEncoding: txEncodingHex,
+		},
+		{
+			name: "prefixed hex with whitespace",
+			body: []byte(
+				" 0x" + testEncodingTxHex[:64] + "\n" + testEncodingTxHex[64:128] +
+					"\r\n\t" + testEncodingTxHex[128:] + "\n",
+			),
+			wantEncoding: txEncodingHex,
+		},
+		{
+			name:         "base64",
+			body:         []byte(testEncodingTxBase64),
+			wantEncoding: txEncodingBase64,
+		},
+		{
+			name:         "URL-safe base64",
+			body:         []byte(testEncodingTxBase64Url),
+			wantEncoding: txEncodingBase64Url,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			got, encoding, attempts := decodeTxBody(testDef.body)
+			if attempts != nil {
+				t.Fatalf("unexpected failed attempts: %+v", attempts)
+			}
+			if encoding != testDef.wantEncoding {
+				t.Fatalf(
+					"unexpected encoding: got %s, wanted %s",
+					encoding,
+					testDef.wantEncoding,
+				)
+			}
+			if !bytes.Equal(got, txCbor) {
+				t.Fatalf("unexpected TX: %x", got)
+			}
+		})
+	}
+}
+
+func TestHandleLocalSubmitTxInvalidEncoding(t *testing.T) {
+	wantEncodings := []string{
+		txEncodingCbor,
+		txEncodingHex,
+		txEncodingBase64,
+		txEncodingBase64Url,
+	}
+	testDefs := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "text",
+			body: "not a transaction",
+		},
+		{
+			name: "truncated hex",
+			body: testEncodingTxHex[:len(testEncodingTxHex)-2],
+		},
+		{
+			name: "hex that isn't a TX",
+			body: "0x8200",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/localtxsubmission/tx", handleLocalSubmitTx)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/localtxsubmission/tx",
+				strings.NewReader(testDef.body),
+			)
+			req.Header.Set("Content-Type", mimeTypeText)
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Code    string                      `json:"code"`
+				Details []responseTxEncodingAttempt `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.Code != errorCodeInvalidEncoding {
+				t.Fatalf("unexpected error code: %s", resp.Code)
+			}
+			gotEncodings := make([]string, 0, len(resp.Details))
+			for _, attempt := range resp.Details {
+				if attempt.Error == "" {
+					t.Fatalf("no error for %s attempt", attempt.Encoding)
+				}
+				gotEncodings = append(gotEncodings, attempt.Encoding)
+			}
+			if !reflect.DeepEqual(gotEncodings, wantEncodings) {
+				t.Fatalf("unexpected attempted encodings: %v", gotEncodings)
+			}
+		})
+	}
+}
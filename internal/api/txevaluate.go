@@ -0,0 +1,452 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"cmp"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// Redeemer purposes by their tag in the witness set, named like Ogmios names them
+// so that its clients can switch over easily
+var redeemerPurposes = map[uint64]string{
+	0: "spend",
+	1: "mint",
+	2: "publish",
+	3: "withdraw",
+	4: "vote",
+	5: "propose",
+}
+
+// Witness set key of the redeemers
+const witnessSetRedeemers = 5
+
+// requestTxEvaluate is a TX to evaluate, with its CBOR as hex or base64
+type requestTxEvaluate struct {
+	Tx              string                  `json:"tx"               binding:"required"`
+	AdditionalUtxos []requestTxEvaluateUtxo `json:"additional_utxos"`
+}
+
+// requestTxEvaluateUtxo is a UTxO that isn't on chain yet, such as an output of
+// a TX that hasn't been submitted. The output is its CBOR in hex
+type requestTxEvaluateUtxo struct {
+	TxHash      string `json:"tx_hash"      example:"9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"`
+	OutputIndex uint32 `json:"output_index" example:"0"`
+	Output      string `json:"output"`
+}
+
+type responseTxEvaluation struct {
+	TxId      string                         `json:"tx_id"     example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Redeemers []responseTxEvaluationRedeemer `json:"redeemers"`
+}
+
+// responseTxEvaluationRedeemer is the result of running the script for a
+// redeemer. The execution units are set if the script succeeded, and the error
+// and its trace if it failed
+type responseTxEvaluationRedeemer struct {
+	Purpose        string                  `json:"purpose"                   example:"spend" enums:"spend,mint,publish,withdraw,vote,propose"`
+	Index          uint32                  `json:"index"                     example:"0"`
+	ExecutionUnits *responseExecutionUnits `json:"execution_units,omitempty"`
+	Error          string                  `json:"error,omitempty"`
+	Trace          []string                `json:"trace,omitempty"`
+}
+
+// txRedeemer is a redeemer from the witness set of a TX, with the execution units
+// that the TX currently has for it
+type txRedeemer struct {
+	tag     uint64
+	index   uint32
+	data    cbor.RawMessage
+	memory  uint64
+	steps   uint64
+	purpose string
+}
+
+// txEvaluation is everything that a script evaluator needs for a TX. The UTxOs
+// have all of its inputs, collateral, and reference inputs
+type txEvaluation struct {
+	txCbor         []byte
+	tx             ledger.Transaction
+	redeemers      []txRedeemer
+	utxos          map[localstatequery.UtxoId]ledger.BabbageTransactionOutput
+	protocolParams responseProtocolParameters
+}
+
+// txEvaluator runs the scripts of a TX to find the execution units for each of
+// its redeemers. Failed scripts are returned as a txScriptFailureError
+type txEvaluator interface {
+	evaluate(evaluation txEvaluation) ([]responseTxEvaluationRedeemer, error)
+}
+
+// txScriptFailureError is the results of a TX evaluation where a script failed
+type txScriptFailureError struct {
+	redeemers []responseTxEvaluationRedeemer
+}
+
+func (e *txScriptFailureError) Error() string {
+	return "script evaluation failed"
+}
+
+// The script evaluator, which is nil until there's one to plug in. Without one,
+// evaluations fail after the inputs are resolved
+var txEvaluatorBackend txEvaluator
+
+// handleLocalEvaluateTx godoc
+//
+//	@Summary		Evaluate Tx
+//	@Description	Evaluate the Plutus scripts of a transaction to find the execution units for each redeemer, before it is submitted. The transaction can be signed or unsigned. The body is either the transaction CBOR like the /localtxsubmission/tx endpoint, or JSON with the transaction CBOR as hex or base64 and additional UTxOs. The additional UTxOs are used for inputs that aren't on chain yet, such as the outputs of transactions that haven't been submitted, and the other inputs, collateral, and reference inputs are looked up from the node. If a script fails, the error details have the error and trace for each redeemer. Script evaluation needs an evaluator backend, and the status is 501 if the server doesn't have one.
+//	@Accept			json
+//	@Produce		json
+//	@Param			Content-Type	header		string					true	"Content type"	Enums(application/json, application/cbor, application/octet-stream, text/plain)
+//	@Param			request			body		requestTxEvaluate		false	"Transaction and additional UTxOs, for JSON requests"
+//	@Success		200				{object}	responseTxEvaluation	"Ok"
+//	@Failure		400				{object}	responseApiError		"Bad Request"
+//	@Failure		413				{object}	responseApiError		"Request Entity Too Large"
+//	@Failure		415				{object}	responseApiError		"Unsupported Media Type"
+//	@Failure		500				{object}	responseApiError		"Server Error"
+//	@Failure		501				{object}	responseApiError		"Not Implemented"
+//	@Router			/localtxsubmission/evaluate [post]
+func handleLocalEvaluateTx(c *gin.Context) {
+	var req requestTxEvaluate
+	switch c.ContentType() {
+	case binding.MIMEJSON:
+		if err := c.ShouldBindJSON(&req); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				respondRequestTooLarge(c, maxBytesErr.Limit)
+				return
+			}
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return
+		}
+	case mimeTypeCbor, mimeTypeOctetStream, mimeTypeText:
+		reqBody, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondRequestTooLarge(c, maxBytesErr.Limit)
+			return
+		}
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, "failed to read request body", nil),
+			)
+			return
+		}
+		req.Tx = string(reqBody)
+	default:
+		respondError(
+			c,
+			415,
+			apiErrorCode(
+				errorCodeUnsupportedMediaType,
+				"invalid request body, should be application/json, application/cbor, application/octet-stream, or text/plain",
+				nil,
+			),
+		)
+		return
+	}
+	maxTxIns := config.GetConfig().Api.MaxUtxoTxIns
+	if uint(len(req.AdditionalUtxos)) > maxTxIns {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf("at most %d additional UTxOs can be specified", maxTxIns),
+				nil,
+			),
+		)
+		return
+	}
+	txCbor, _, attempts := decodeTxBody([]byte(req.Tx))
+	if txCbor == nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidEncoding,
+				"could not decode transaction as raw CBOR, hex, or base64",
+				attempts,
+			),
+		)
+		return
+	}
+	_, txId, tx, err := parseTx(txCbor)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	c.Set(contextKeyTxHash, txId)
+	redeemers, err := decodeTxRedeemers(txCbor)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	utxos, ok := resolveTxUtxos(c, tx, req.AdditionalUtxos)
+	if !ok {
+		return
+	}
+	resp := responseTxEvaluation{
+		TxId:      txId,
+		Redeemers: []responseTxEvaluationRedeemer{},
+	}
+	// There's nothing to run without scripts
+	if len(redeemers) == 0 {
+		respondJson(c, 200, resp)
+		return
+	}
+	if txEvaluatorBackend == nil {
+		respondError(
+			c,
+			http.StatusNotImplemented,
+			apiErrorCode(
+				errorCodeEvaluationUnavailable,
+				"script evaluation is not available on this server",
+				nil,
+			),
+		)
+		return
+	}
+	era, protoParams, ok := queryProtocolParameters(c)
+	if !ok {
+		return
+	}
+	params, err := newResponseProtocolParameters(era, protoParams)
+	if err != nil {
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	results, err := txEvaluatorBackend.evaluate(txEvaluation{
+		txCbor:         txCbor,
+		tx:             tx,
+		redeemers:      redeemers,
+		utxos:          utxos,
+		protocolParams: params,
+	})
+	if err != nil {
+		var scriptErr *txScriptFailureError
+		if errors.As(err, &scriptErr) {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeScriptFailure,
+					err.Error(),
+					scriptErr.redeemers,
+				),
+			)
+			return
+		}
+		respondError(c, 500, apiErrorCode(errorCodeInternal, err.Error(), nil))
+		return
+	}
+	resp.Redeemers = results
+	respondJson(c, 200, resp)
+}
+
+// resolveTxUtxos returns the UTxOs for the inputs, collateral, and reference
+// inputs of a TX, from the additional UTxOs or else the node. An error response
+// has been sent if it returns false
+func resolveTxUtxos(
+	c *gin.Context,
+	tx ledger.Transaction,
+	additionalUtxos []requestTxEvaluateUtxo,
+) (map[localstatequery.UtxoId]ledger.BabbageTransactionOutput, bool) {
+	utxos := make(map[localstatequery.UtxoId]ledger.BabbageTransactionOutput)
+	for _, reqUtxo := range additionalUtxos {
+		txIn, err := newTxIn(reqUtxo.TxHash, reqUtxo.OutputIndex)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+			)
+			return nil, false
+		}
+		outputCbor, err := hex.DecodeString(reqUtxo.Output)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(
+					errorCodeBadRequest,
+					fmt.Sprintf("invalid output hex for UTxO %s#%d", reqUtxo.TxHash, reqUtxo.OutputIndex),
+					nil,
+				),
+			)
+			return nil, false
+		}
+		output, err := ledger.NewBabbageTransactionOutputFromCbor(outputCbor)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+			)
+			return nil, false
+		}
+		utxos[localstatequery.UtxoId{Hash: txIn.TxId, Idx: int(txIn.OutputIndex)}] = *output
+	}
+	var txIns []ledger.ShelleyTransactionInput
+	var queryTxIns []ledger.ShelleyTransactionInput
+	for _, inputs := range [][]ledger.TransactionInput{
+		tx.Inputs(),
+		tx.Collateral(),
+		tx.ReferenceInputs(),
+	} {
+		for _, input := range inputs {
+			txIn := ledger.ShelleyTransactionInput{
+				TxId:        input.Id(),
+				OutputIndex: input.Index(),
+			}
+			txIns = append(txIns, txIn)
+			utxoId := localstatequery.UtxoId{Hash: txIn.TxId, Idx: int(txIn.OutputIndex)}
+			if _, ok := utxos[utxoId]; !ok {
+				queryTxIns = append(queryTxIns, txIn)
+			}
+		}
+	}
+	if len(queryTxIns) > 0 {
+		queryUtxos, ok := queryUtxosByTxIn(c, queryTxIns)
+		if !ok {
+			return nil, false
+		}
+		for utxoId, output := range queryUtxos {
+			utxos[utxoId] = output
+		}
+	}
+	var missing []requestLocalStateQueryUtxoTxIn
+	for _, txIn := range txIns {
+		utxoId := localstatequery.UtxoId{Hash: txIn.TxId, Idx: int(txIn.OutputIndex)}
+		if _, ok := utxos[utxoId]; ok {
+			continue
+		}
+		tmpMissing := requestLocalStateQueryUtxoTxIn{
+			TxHash:      txIn.TxId.String(),
+			OutputIndex: txIn.OutputIndex,
+		}
+		if !slices.Contains(missing, tmpMissing) {
+			missing = append(missing, tmpMissing)
+		}
+	}
+	if len(missing) > 0 {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeUtxoNotFound,
+				"transaction inputs are spent or unknown, and weren't in the additional UTxOs",
+				missing,
+			),
+		)
+		return nil, false
+	}
+	return utxos, true
+}
+
+// decodeTxRedeemers returns the redeemers from the witness set of a TX, ordered
+// by their purpose and index. They're a list before Conway, and may be a map of
+// the purpose and index to the rest from Conway on
+func decodeTxRedeemers(txCbor []byte) ([]txRedeemer, error) {
+	var txArray []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txArray); err != nil || len(txArray) < 2 {
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	var witnesses map[uint]cbor.RawMessage
+	if _, err := cbor.Decode(txArray[1], &witnesses); err != nil {
+		return nil, fmt.Errorf("failed to decode witness set: %s", err)
+	}
+	redeemersCbor, ok := witnesses[witnessSetRedeemers]
+	if !ok {
+		return nil, nil
+	}
+	var redeemers []txRedeemer
+	var redeemerList []struct {
+		cbor.StructAsArray
+		Tag     uint64
+		Index   uint32
+		Data    cbor.RawMessage
+		ExUnits [2]uint64
+	}
+	var redeemerMap map[[2]uint64]struct {
+		cbor.StructAsArray
+		Data    cbor.RawMessage
+		ExUnits [2]uint64
+	}
+	if _, err := cbor.Decode(redeemersCbor, &redeemerList); err == nil {
+		for _, redeemer := range redeemerList {
+			redeemers = append(redeemers, txRedeemer{
+				tag:    redeemer.Tag,
+				index:  redeemer.Index,
+				data:   redeemer.Data,
+				memory: redeemer.ExUnits[0],
+				steps:  redeemer.ExUnits[1],
+			})
+		}
+	} else if _, err := cbor.Decode(redeemersCbor, &redeemerMap); err == nil {
+		for key, redeemer := range redeemerMap {
+			redeemers = append(redeemers, txRedeemer{
+				tag:    key[0],
+				index:  uint32(key[1]),
+				data:   redeemer.Data,
+				memory: redeemer.ExUnits[0],
+				steps:  redeemer.ExUnits[1],
+			})
+		}
+	} else {
+		return nil, fmt.Errorf("failed to decode redeemers: %s", err)
+	}
+	for idx, redeemer := range redeemers {
+		purpose, ok := redeemerPurposes[redeemer.tag]
+		if !ok {
+			return nil, fmt.Errorf("unknown redeemer tag: %d", redeemer.tag)
+		}
+		redeemers[idx].purpose = purpose
+	}
+	slices.SortFunc(redeemers, func(a, b txRedeemer) int {
+		if a.tag != b.tag {
+			return cmp.Compare(a.tag, b.tag)
+		}
+		return cmp.Compare(a.index, b.index)
+	})
+	return redeemers, nil
+}
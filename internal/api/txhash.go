@@ -0,0 +1,130 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/blake2b"
+)
+
+// What a TX hash request body was
+const (
+	txHashInputTx     = "transaction"
+	txHashInputTxBody = "transaction_body"
+)
+
+// Fields that every TX body has, which are the inputs, outputs, and fee
+var txBodyRequiredKeys = []uint{0, 1, 2}
+
+type responseTxHash struct {
+	TxId  string `json:"tx_id" example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Input string `json:"input" example:"transaction" enums:"transaction,transaction_body"`
+}
+
+// handleTxHash godoc
+//
+//	@Summary		Hash Tx
+//	@Description	Returns the ID of a signed or unsigned transaction, which is the blake2b-256 hash of the transaction body. The body is hashed as it appears in the request CBOR, since encoding it again may not give the same bytes. The request body is either a transaction or a bare transaction body, as raw CBOR or encoded as hex or base64, like the /localtxsubmission/tx endpoint.
+//	@Tags			tx
+//	@Produce		json
+//	@Param			Content-Type	header		string	true	"Content type"	Enums(application/cbor, application/octet-stream, text/plain)
+//	@Success		200				{object}	responseTxHash
+//	@Failure		400				{object}	responseApiError
+//	@Failure		413				{object}	responseApiError
+//	@Failure		415				{object}	responseApiError
+//	@Router			/tx/hash [post]
+func handleTxHash(c *gin.Context) {
+	reqBody, ok := readTxRequestBody(c)
+	if !ok {
+		return
+	}
+	txBytes, _, attempts := decodeTxBodyCheck(reqBody, checkTxOrTxBody)
+	if txBytes == nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidEncoding,
+				"could not decode transaction or transaction body as raw CBOR, hex, or base64",
+				attempts,
+			),
+		)
+		return
+	}
+	resp := responseTxHash{Input: txHashInputTxBody}
+	if txType, err := ledger.DetermineTransactionType(txBytes); err == nil {
+		resp.Input = txHashInputTx
+		resp.TxId, err = transactionId(txType, txBytes)
+		if err != nil {
+			respondError(
+				c,
+				400,
+				apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+			)
+			return
+		}
+	} else {
+		hash := blake2b.Sum256(txBytes)
+		resp.TxId = hex.EncodeToString(hash[:])
+	}
+	c.Set(contextKeyTxHash, resp.TxId)
+	respondJson(c, 200, resp)
+}
+
+// checkTxOrTxBody returns an error if the CBOR is neither a TX nor a TX body
+func checkTxOrTxBody(txBytes []byte) error {
+	_, txErr := ledger.DetermineTransactionType(txBytes)
+	if txErr == nil {
+		return nil
+	}
+	if err := checkTxBody(txBytes); err != nil {
+		return fmt.Errorf(
+			"not a transaction (%s) or transaction body (%s)",
+			txErr,
+			err,
+		)
+	}
+	return nil
+}
+
+// checkTxBody returns an error if the CBOR isn't a TX body. Only the fields that
+// all eras have are checked, since the body is hashed without parsing it
+func checkTxBody(txBodyBytes []byte) error {
+	if len(txBodyBytes) == 0 ||
+		cborMajorType(txBodyBytes) != cborMajorTypeMap {
+		return errors.New("not a CBOR map")
+	}
+	var txBody map[uint]cbor.RawMessage
+	n, err := cbor.Decode(txBodyBytes, &txBody)
+	if err != nil {
+		return err
+	}
+	// Anything after the body wouldn't be hashed
+	if n != len(txBodyBytes) {
+		return fmt.Errorf("%d unexpected bytes after the body", len(txBodyBytes)-n)
+	}
+	for _, key := range txBodyRequiredKeys {
+		if _, ok := txBody[key]; !ok {
+			return fmt.Errorf("missing field %d", key)
+		}
+	}
+	return nil
+}
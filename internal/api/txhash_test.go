@@ -0,0 +1,211 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The bodies of the Shelley and Conway test TXs
+const (
+	testTxShelleyBodyHex = "a40081825820111111111111111111111111111111111111111111111111111111111111111100018182581d61" +
+		"222222222222222222222222222222222222222222222222222222221a000f4240021a0002981003191388"
+	testTxConwayBodyHex = "a400d90102818258201111111111111111111111111111111111111111111111111111111111111111080181a200" +
+		"581d6122222222222222222222222222222222222222222222222222222222011a004c4b40021a0003345014d9010281" +
+		"841b000000174876e800581de122222222222222222222222222222222222222222222222222222222810682781a6874" +
+		"7470733a2f2f6578616d706c652e636f6d2f612e6a736f6e5820aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" +
+		"aaaaaaaaaaaaaaaaaaaa"
+)
+
+// The expected IDs are the blake2b-256 hashes of the body bytes, which were
+// worked out separately from the code under test
+func TestHandleTxHash(t *testing.T) {
+	testDefs := []struct {
+		name      string
+		body      string
+		wantId    string
+		wantInput string
+	}{
+		{
+			name:      "shelley",
+			body:      testTxShelleyHex,
+			wantId:    "2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "allegra",
+			body:      testTxAllegraHex,
+			wantId:    "4e2a5d34c7ed0966275c94653b534f0cbe5b74d953d6998b666d8c5293191f30",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "mary",
+			body:      testTxMaryHex,
+			wantId:    "90d240f40dc93b66fbeae49be2a3c20dcf3956f269e64dafd9c7d6cd84b56b89",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "alonzo",
+			body:      testTxAlonzoHex,
+			wantId:    "917a798f21409aeeb59a1c5036ccceae73cd33b368b9c7ac361c84118bd51a46",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "babbage",
+			body:      testTxBabbageHex,
+			wantId:    "c7b25267409e2fd9c6df7e13d011f9a1352df325a3a20bc25958d0d18e259a09",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "conway",
+			body:      testTxConwayHex,
+			wantId:    "da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "shelley body",
+			body:      testTxShelleyBodyHex,
+			wantId:    "2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+			wantInput: txHashInputTxBody,
+		},
+		{
+			name:      "conway body",
+			body:      testTxConwayBodyHex,
+			wantId:    "da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+			wantInput: txHashInputTxBody,
+		},
+		{
+			name: "body hashed as encoded",
+			// The Shelley body with the TTL not in its shortest form
+			body: strings.Replace(
+				testTxShelleyBodyHex,
+				"03191388",
+				"031a00001388",
+				1,
+			),
+			wantId:    "48d0aa73ceea122f0aaa9b0a5c1be844c6c5f0d81b50cfc862f820326b1c17c0",
+			wantInput: txHashInputTxBody,
+		},
+		{
+			name:      "base64 TX",
+			body:      base64.StdEncoding.EncodeToString(mustDecodeHex(t, testTxConwayHex)),
+			wantId:    "da2b3e4db850aea74e1743a1c1e97d140e9a058a939029a6da1b715c217e3697",
+			wantInput: txHashInputTx,
+		},
+		{
+			name:      "raw CBOR body",
+			body:      string(mustDecodeHex(t, testTxShelleyBodyHex)),
+			wantId:    "2d666e6409fa7975a5371afc81f71fe058b4525372ef46de31bc259ef52b4008",
+			wantInput: txHashInputTxBody,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			w := serveTxHashRequest(testDef.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var resp responseTxHash
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.TxId != testDef.wantId {
+				t.Fatalf(
+					"unexpected TX ID: got %s, wanted %s",
+					resp.TxId,
+					testDef.wantId,
+				)
+			}
+			if resp.Input != testDef.wantInput {
+				t.Fatalf(
+					"unexpected input: got %s, wanted %s",
+					resp.Input,
+					testDef.wantInput,
+				)
+			}
+		})
+	}
+}
+
+func TestHandleTxHashInvalid(t *testing.T) {
+	testDefs := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "not CBOR",
+			body: "not a transaction",
+		},
+		{
+			name: "body without a fee",
+			// {0: [], 1: []}
+			body: "a200800180",
+		},
+		{
+			name: "bytes after the body",
+			body: testTxShelleyBodyHex + "00",
+		},
+		{
+			name: "list that isn't a TX",
+			body: "83010203",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			w := serveTxHashRequest(testDef.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var resp responseApiError
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %s", err)
+			}
+			if resp.Code != errorCodeInvalidEncoding {
+				t.Fatalf("unexpected error code: %s", resp.Code)
+			}
+		})
+	}
+}
+
+func serveTxHashRequest(body string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST("/tx/hash", handleTxHash)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/tx/hash",
+		strings.NewReader(body),
+	)
+	req.Header.Set("Content-Type", mimeTypeText)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func mustDecodeHex(t *testing.T, hexData string) []byte {
+	t.Helper()
+	ret, err := hex.DecodeString(hexData)
+	if err != nil {
+		t.Fatalf("invalid test hex: %s", err)
+	}
+	return ret
+}
@@ -0,0 +1,488 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/gin-gonic/gin"
+)
+
+// Metadata JSON schemas, which match the cardano-cli options of the same names
+const (
+	txMetadataSchemaNoSchema = "no_schema"
+	txMetadataSchemaDetailed = "detailed"
+)
+
+// Names of the metadata labels that are in the CIP-10 registry and widely used
+var metadataLabelNames = map[uint64]string{
+	674:   "CIP-20 message",
+	721:   "CIP-25 NFT metadata",
+	777:   "CIP-27 royalties",
+	61284: "CIP-15 Catalyst registration",
+	61285: "CIP-15 Catalyst witness",
+}
+
+// CBOR tag of Alonzo and later auxiliary data
+const auxiliaryDataTag = 259
+
+// Script types by their key in Alonzo and later auxiliary data. Allegra and Mary
+// auxiliary data can only have native scripts
+var auxiliaryDataScriptTypes = map[uint]string{
+	1: "native",
+	2: "plutus_v1",
+	3: "plutus_v2",
+	4: "plutus_v3",
+}
+
+type requestTxMetadata struct {
+	Schema string `form:"schema,default=no_schema"`
+}
+
+type responseTxAuxData struct {
+	TxId     string               `json:"tx_id"    example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Metadata []responseTxMetadata `json:"metadata"`
+	Scripts  []responseTxScript   `json:"scripts"`
+}
+
+// responseTxMetadata is the metadata for a label as JSON in the requested
+// schema, or as CBOR in hex if it can't be rendered in the schema
+type responseTxMetadata struct {
+	Label uint64 `json:"label"          example:"674"`
+	Name  string `json:"name,omitempty" example:"CIP-20 message"`
+	Json  any    `json:"json,omitempty"`
+	Cbor  string `json:"cbor,omitempty" example:"a1636d736781656869207468657265"`
+}
+
+type responseTxScript struct {
+	Type string `json:"type" example:"native" enums:"native,plutus_v1,plutus_v2,plutus_v3"`
+	Cbor string `json:"cbor" example:"8200581c29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"`
+}
+
+// handleTxMetadata godoc
+//
+//	@Summary		Tx Metadata
+//	@Description	Returns the auxiliary data of a transaction, without a connection to the node. The body is the transaction CBOR, either raw or encoded as hex or base64, like the /localtxsubmission/tx endpoint. Metadata is rendered as JSON in the same schemas as cardano-cli. With no_schema, byte strings are hex with a 0x prefix and map keys are strings. With detailed, each value is an object with its type. Metadata that can't be rendered in the schema, like a map with list keys without a schema, has its CBOR in hex instead. Scripts in the auxiliary data have their type and CBOR in hex.
+//	@Tags			tx
+//	@Produce		json
+//	@Param			Content-Type	header		string	true	"Content type"	Enums(application/cbor, application/octet-stream, text/plain)
+//	@Param			schema			query		string	false	"Metadata JSON schema"	Enums(no_schema, detailed)	default(no_schema)
+//	@Success		200				{object}	responseTxAuxData
+//	@Failure		400				{object}	responseApiError
+//	@Failure		413				{object}	responseApiError
+//	@Failure		415				{object}	responseApiError
+//	@Router			/tx/metadata [post]
+func handleTxMetadata(c *gin.Context) {
+	var req requestTxMetadata
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	if req.Schema != txMetadataSchemaNoSchema &&
+		req.Schema != txMetadataSchemaDetailed {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeBadRequest,
+				fmt.Sprintf(
+					"invalid schema %q, should be %s or %s",
+					req.Schema,
+					txMetadataSchemaNoSchema,
+					txMetadataSchemaDetailed,
+				),
+				nil,
+			),
+		)
+		return
+	}
+	reqBody, ok := readTxRequestBody(c)
+	if !ok {
+		return
+	}
+	txCbor, _, attempts := decodeTxBody(reqBody)
+	if txCbor == nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(
+				errorCodeInvalidEncoding,
+				"could not decode transaction as raw CBOR, hex, or base64",
+				attempts,
+			),
+		)
+		return
+	}
+	_, txId, _, err := parseTx(txCbor)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	c.Set(contextKeyTxHash, txId)
+	var txArray []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txArray); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	// The auxiliary data is last, after the validity flag from Alonzo on
+	metadataCbor, scripts, err := parseTxAuxData(txArray[len(txArray)-1])
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	metadata, err := newResponseTxMetadata(metadataCbor, req.Schema)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeInvalidCbor, err.Error(), nil),
+		)
+		return
+	}
+	respondJson(c, 200, responseTxAuxData{
+		TxId:     txId,
+		Metadata: metadata,
+		Scripts:  scripts,
+	})
+}
+
+// parseTxAuxData returns the metadata and scripts from the auxiliary data of a
+// TX. This is the metadata map in Shelley, a list of it and native scripts in
+// Allegra and Mary, and a tagged map with it and the scripts by type from Alonzo
+// on. The metadata is nil if there isn't any
+func parseTxAuxData(auxDataCbor []byte) ([]byte, []responseTxScript, error) {
+	scripts := []responseTxScript{}
+	if len(auxDataCbor) == 0 {
+		return nil, scripts, nil
+	}
+	switch cborMajorType(auxDataCbor) {
+	case cborMajorTypeSimple:
+		// No auxiliary data
+		return nil, scripts, nil
+	case cborMajorTypeMap:
+		return auxDataCbor, scripts, nil
+	case cborMajorTypeArray:
+		var auxData []cbor.RawMessage
+		if _, err := cbor.Decode(auxDataCbor, &auxData); err != nil ||
+			len(auxData) == 0 {
+			return nil, nil, fmt.Errorf("failed to decode auxiliary data: %v", err)
+		}
+		if len(auxData) > 1 {
+			var err error
+			scripts, err = appendTxScripts(scripts, "native", auxData[1])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return auxData[0], scripts, nil
+	case cborMajorTypeTag:
+		// The tag content is kept raw, since decoding it and encoding it again may
+		// not give the original metadata
+		var auxData cbor.RawTag
+		if _, err := cbor.Decode(auxDataCbor, &auxData); err != nil ||
+			auxData.Number != auxiliaryDataTag {
+			return nil, nil, fmt.Errorf("failed to decode auxiliary data: %v", err)
+		}
+		var auxDataMap map[uint]cbor.RawMessage
+		if _, err := cbor.Decode(auxData.Content, &auxDataMap); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode auxiliary data: %s", err)
+		}
+		for key := uint(1); key <= uint(len(auxiliaryDataScriptTypes)); key++ {
+			if scriptList, ok := auxDataMap[key]; ok {
+				var err error
+				scripts, err = appendTxScripts(
+					scripts,
+					auxiliaryDataScriptTypes[key],
+					scriptList,
+				)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		return auxDataMap[0], scripts, nil
+	}
+	return nil, nil, errors.New("failed to decode auxiliary data")
+}
+
+func appendTxScripts(
+	scripts []responseTxScript,
+	scriptType string,
+	scriptListCbor []byte,
+) ([]responseTxScript, error) {
+	var scriptList []cbor.RawMessage
+	if _, err := cbor.Decode(scriptListCbor, &scriptList); err != nil {
+		return nil, fmt.Errorf("failed to decode %s scripts: %s", scriptType, err)
+	}
+	for _, script := range scriptList {
+		scripts = append(scripts, responseTxScript{
+			Type: scriptType,
+			Cbor: hex.EncodeToString(script),
+		})
+	}
+	return scripts, nil
+}
+
+// newResponseTxMetadata returns the metadata labels, sorted, with their values
+// rendered in the schema
+func newResponseTxMetadata(
+	metadataCbor []byte,
+	schema string,
+) ([]responseTxMetadata, error) {
+	ret := []responseTxMetadata{}
+	if metadataCbor == nil {
+		return ret, nil
+	}
+	var metadata map[uint64]cbor.RawMessage
+	if _, err := cbor.Decode(metadataCbor, &metadata); err != nil {
+		return ret, fmt.Errorf("failed to decode metadata: %s", err)
+	}
+	render := metadatumJson
+	if schema == txMetadataSchemaDetailed {
+		render = metadatumDetailedJson
+	}
+	for label, value := range metadata {
+		tmpMetadata := responseTxMetadata{
+			Label: label,
+			Name:  metadataLabelNames[label],
+		}
+		tmpJson, err := render(value)
+		if err != nil {
+			tmpMetadata.Cbor = hex.EncodeToString(value)
+		} else {
+			tmpMetadata.Json = tmpJson
+		}
+		ret = append(ret, tmpMetadata)
+	}
+	slices.SortFunc(ret, func(a, b responseTxMetadata) int {
+		return cmp.Compare(a.Label, b.Label)
+	})
+	return ret, nil
+}
+
+// CBOR major types
+const (
+	cborMajorTypeUint    = 0
+	cborMajorTypeNegInt  = 1
+	cborMajorTypeBytes   = 2
+	cborMajorTypeText    = 3
+	cborMajorTypeArray   = 4
+	cborMajorTypeMap     = 5
+	cborMajorTypeTag     = 6
+	cborMajorTypeSimple  = 7
+	cborIndefiniteLength = 31
+	cborBreak            = 0xff
+)
+
+func cborMajorType(data []byte) byte {
+	return data[0] >> 5
+}
+
+// metadatumJson renders a metadata value as JSON like cardano-cli without a
+// schema. It fails for map keys that aren't ints, byte strings, or text, and for
+// keys that are the same once they're strings
+func metadatumJson(data cbor.RawMessage) (any, error) {
+	switch cborMajorType(data) {
+	case cborMajorTypeUint, cborMajorTypeNegInt:
+		var ret any
+		_, err := cbor.Decode(data, &ret)
+		return ret, err
+	case cborMajorTypeBytes:
+		var ret []byte
+		if _, err := cbor.Decode(data, &ret); err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(ret), nil
+	case cborMajorTypeText:
+		var ret string
+		_, err := cbor.Decode(data, &ret)
+		return ret, err
+	case cborMajorTypeArray:
+		var items []cbor.RawMessage
+		if _, err := cbor.Decode(data, &items); err != nil {
+			return nil, err
+		}
+		ret := make([]any, 0, len(items))
+		for _, item := range items {
+			tmpItem, err := metadatumJson(item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, tmpItem)
+		}
+		return ret, nil
+	case cborMajorTypeMap:
+		pairs, err := cborMapPairs(data)
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[string]any, len(pairs))
+		for _, pair := range pairs {
+			switch cborMajorType(pair[0]) {
+			case cborMajorTypeUint,
+				cborMajorTypeNegInt,
+				cborMajorTypeBytes,
+				cborMajorTypeText:
+			default:
+				return nil, errors.New("map key can't be a JSON string")
+			}
+			key, err := metadatumJson(pair[0])
+			if err != nil {
+				return nil, err
+			}
+			keyStr := fmt.Sprint(key)
+			if _, ok := ret[keyStr]; ok {
+				return nil, fmt.Errorf("duplicate map key %q", keyStr)
+			}
+			value, err := metadatumJson(pair[1])
+			if err != nil {
+				return nil, err
+			}
+			ret[keyStr] = value
+		}
+		return ret, nil
+	}
+	return nil, errors.New("invalid metadata value")
+}
+
+// metadatumDetailedJson renders a metadata value as JSON like cardano-cli with
+// the detailed schema, where each value is an object with its type as the key
+func metadatumDetailedJson(data cbor.RawMessage) (any, error) {
+	switch cborMajorType(data) {
+	case cborMajorTypeUint, cborMajorTypeNegInt:
+		var ret any
+		if _, err := cbor.Decode(data, &ret); err != nil {
+			return nil, err
+		}
+		return map[string]any{"int": ret}, nil
+	case cborMajorTypeBytes:
+		var ret []byte
+		if _, err := cbor.Decode(data, &ret); err != nil {
+			return nil, err
+		}
+		return map[string]any{"bytes": hex.EncodeToString(ret)}, nil
+	case cborMajorTypeText:
+		var ret string
+		if _, err := cbor.Decode(data, &ret); err != nil {
+			return nil, err
+		}
+		return map[string]any{"string": ret}, nil
+	case cborMajorTypeArray:
+		var items []cbor.RawMessage
+		if _, err := cbor.Decode(data, &items); err != nil {
+			return nil, err
+		}
+		ret := make([]any, 0, len(items))
+		for _, item := range items {
+			tmpItem, err := metadatumDetailedJson(item)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, tmpItem)
+		}
+		return map[string]any{"list": ret}, nil
+	case cborMajorTypeMap:
+		pairs, err := cborMapPairs(data)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]any, 0, len(pairs))
+		for _, pair := range pairs {
+			key, err := metadatumDetailedJson(pair[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := metadatumDetailedJson(pair[1])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, map[string]any{"k": key, "v": value})
+		}
+		return map[string]any{"map": ret}, nil
+	}
+	return nil, errors.New("invalid metadata value")
+}
+
+// cborMapPairs returns the keys and values of a CBOR map in the order that they
+// are encoded in. The keys can be anything, unlike when decoding to a Go map
+func cborMapPairs(data []byte) ([][2]cbor.RawMessage, error) {
+	if len(data) == 0 || cborMajorType(data) != cborMajorTypeMap {
+		return nil, errors.New("not a CBOR map")
+	}
+	info := data[0] & 0x1f
+	var count uint64
+	offset := 1
+	switch {
+	case info < 24:
+		count = uint64(info)
+	case info == 24 && len(data) >= 2:
+		count = uint64(data[1])
+		offset = 2
+	case info == 25 && len(data) >= 3:
+		count = uint64(binary.BigEndian.Uint16(data[1:]))
+		offset = 3
+	case info == 26 && len(data) >= 5:
+		count = uint64(binary.BigEndian.Uint32(data[1:]))
+		offset = 5
+	case info == 27 && len(data) >= 9:
+		count = binary.BigEndian.Uint64(data[1:])
+		offset = 9
+	case info == cborIndefiniteLength:
+	default:
+		return nil, errors.New("invalid CBOR map header")
+	}
+	var ret [][2]cbor.RawMessage
+	for idx := uint64(0); info == cborIndefiniteLength || idx < count; idx++ {
+		if info == cborIndefiniteLength && offset < len(data) &&
+			data[offset] == cborBreak {
+			break
+		}
+		var pair [2]cbor.RawMessage
+		for i := range pair {
+			if offset >= len(data) {
+				return nil, errors.New("truncated CBOR map")
+			}
+			n, err := cbor.Decode(data[offset:], &pair[i])
+			if err != nil {
+				return nil, err
+			}
+			offset += n
+		}
+		ret = append(ret, pair)
+	}
+	return ret, nil
+}
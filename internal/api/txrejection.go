@@ -0,0 +1,763 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+)
+
+// responseTxRejection is a TX rejection from the node, with the ledger rule
+// failures decoded where possible. The CBOR is the node's full rejection reason
+type responseTxRejection struct {
+	Era      string                       `json:"era,omitempty" example:"Conway"`
+	Failures []responseTxRejectionFailure `json:"failures"`
+	Cbor     string                       `json:"cbor"          example:"818206818201820083061b0000000129d8d7f81b000000012a05f200"`
+}
+
+// responseTxRejectionFailure is a single ledger rule failure. Failures that
+// aren't decoded include their CBOR instead of details
+type responseTxRejectionFailure struct {
+	Rule    string         `json:"rule,omitempty"    example:"UTXO"`
+	Name    string         `json:"name"              example:"ValueNotConservedUTxO"`
+	Details map[string]any `json:"details,omitempty"`
+	Cbor    string         `json:"cbor,omitempty"    example:"8200581c"`
+}
+
+// Names of failures that aren't from the ledger rule tables
+const (
+	// Failures that can't be matched to a known ledger rule failure
+	txFailureUnknown     = "Unknown"
+	txFailureEraMismatch = "EraMismatch"
+)
+
+// txFailureRule is the predicate failure type of a ledger rule, which is encoded
+// as a sum type with one case per failure
+type txFailureRule struct {
+	name  string
+	cases map[uint64]txFailureCase
+}
+
+// txFailureCase is a single failure of a ledger rule. It either wraps a failure
+// from another rule, or has fields that are decoded for the details. Opaque
+// failures are named but returned as CBOR
+type txFailureCase struct {
+	name   string
+	rule   func() *txFailureRule
+	fields []txFailureField
+	opaque bool
+}
+
+type txFailureField struct {
+	name   string
+	decode func([]byte) (any, error)
+}
+
+type responseTxValidityInterval struct {
+	InvalidBefore    *uint64 `json:"invalid_before"`
+	InvalidHereafter *uint64 `json:"invalid_hereafter"`
+}
+
+type responseTxValue struct {
+	Lovelace uint64              `json:"lovelace"`
+	Assets   []responseUtxoAsset `json:"assets"`
+}
+
+// Ledger rules by era. Alonzo and Babbage wrap the failures of the rules that
+// they extend, while Conway flattens them into its own rules
+var txFailureLedgerRules = map[uint8]func() *txFailureRule{
+	ledger.EraIdAlonzo: func() *txFailureRule {
+		return shelleyLedgerRule(alonzoUtxowRule(alonzoUtxoRule))
+	},
+	ledger.EraIdBabbage: func() *txFailureRule {
+		return shelleyLedgerRule(babbageUtxowRule)
+	},
+	ledger.EraIdConway: conwayLedgerRule,
+}
+
+// newResponseTxRejection decodes the rejection reason from the node. It's a
+// failure per ledger rule in the era of the TX, or an era mismatch
+func newResponseTxRejection(reasonCbor []byte) responseTxRejection {
+	ret := responseTxRejection{
+		Failures: []responseTxRejectionFailure{},
+		Cbor:     hex.EncodeToString(reasonCbor),
+	}
+	var eraMismatch ledger.EraMismatch
+	if _, err := cbor.Decode(reasonCbor, &eraMismatch); err == nil {
+		ret.Failures = append(ret.Failures, responseTxRejectionFailure{
+			Name: txFailureEraMismatch,
+			Details: map[string]any{
+				"ledger_era": ledger.GetEraById(eraMismatch.LedgerEra).Name,
+				"tx_era":     ledger.GetEraById(eraMismatch.OtherEra).Name,
+			},
+		})
+		return ret
+	}
+	var reason struct {
+		cbor.StructAsArray
+		Inner struct {
+			cbor.StructAsArray
+			Era      uint8
+			Failures []cbor.RawMessage
+		}
+	}
+	if _, err := cbor.Decode(reasonCbor, &reason); err != nil {
+		ret.Failures = append(ret.Failures, responseTxRejectionFailure{
+			Name: txFailureUnknown,
+			Cbor: ret.Cbor,
+		})
+		return ret
+	}
+	ret.Era = ledger.GetEraById(reason.Inner.Era).Name
+	var rule *txFailureRule
+	if ruleFunc, ok := txFailureLedgerRules[reason.Inner.Era]; ok {
+		rule = ruleFunc()
+	}
+	for _, failure := range reason.Inner.Failures {
+		ret.Failures = append(ret.Failures, decodeTxFailure(rule, failure))
+	}
+	return ret
+}
+
+// decodeTxFailure decodes a failure of a ledger rule, following wrapped failures
+// down to the rule that actually failed
+func decodeTxFailure(
+	rule *txFailureRule,
+	data []byte,
+) responseTxRejectionFailure {
+	unknown := responseTxRejectionFailure{
+		Name: txFailureUnknown,
+		Cbor: hex.EncodeToString(data),
+	}
+	if rule == nil {
+		return unknown
+	}
+	unknown.Rule = rule.name
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(data, &items); err != nil || len(items) == 0 {
+		return unknown
+	}
+	var tag uint64
+	if _, err := cbor.Decode(items[0], &tag); err != nil {
+		return unknown
+	}
+	failureCase, ok := rule.cases[tag]
+	if !ok {
+		return unknown
+	}
+	fields := items[1:]
+	if failureCase.rule != nil {
+		if len(fields) != 1 {
+			return unknown
+		}
+		return decodeTxFailure(failureCase.rule(), fields[0])
+	}
+	ret := responseTxRejectionFailure{
+		Rule: rule.name,
+		Name: failureCase.name,
+	}
+	if failureCase.opaque || len(fields) != len(failureCase.fields) {
+		ret.Cbor = unknown.Cbor
+		return ret
+	}
+	if len(fields) > 0 {
+		ret.Details = make(map[string]any, len(fields))
+	}
+	for idx, field := range failureCase.fields {
+		value, err := field.decode(fields[idx])
+		if err != nil {
+			ret.Details = nil
+			ret.Cbor = unknown.Cbor
+			return ret
+		}
+		ret.Details[field.name] = value
+	}
+	return ret
+}
+
+// txFailureNames returns the names of all the failures that can be decoded, in
+// every era
+func txFailureNames() map[string]bool {
+	ret := map[string]bool{txFailureEraMismatch: true}
+	var walk func(rule *txFailureRule)
+	walk = func(rule *txFailureRule) {
+		for _, failureCase := range rule.cases {
+			if failureCase.rule != nil {
+				walk(failureCase.rule())
+				continue
+			}
+			ret[failureCase.name] = true
+		}
+	}
+	for _, ruleFunc := range txFailureLedgerRules {
+		walk(ruleFunc())
+	}
+	return ret
+}
+
+// opaqueTxFailure is a named failure that isn't decoded
+func opaqueTxFailure(name string) txFailureCase {
+	return txFailureCase{name: name, opaque: true}
+}
+
+func shelleyLedgerRule(utxowRule func() *txFailureRule) *txFailureRule {
+	return &txFailureRule{
+		name: "LEDGER",
+		cases: map[uint64]txFailureCase{
+			0: {name: "UtxowFailure", rule: utxowRule},
+			1: opaqueTxFailure("DelegsFailure"),
+		},
+	}
+}
+
+func shelleyUtxowRule(utxoRule func() *txFailureRule) func() *txFailureRule {
+	return func() *txFailureRule {
+		return &txFailureRule{
+			name: "UTXOW",
+			cases: map[uint64]txFailureCase{
+				0: invalidWitnessesTxFailure,
+				1: missingVKeyWitnessesTxFailure,
+				2: missingScriptWitnessesTxFailure,
+				3: scriptWitnessNotValidatingTxFailure,
+				4: {name: "UtxoFailure", rule: utxoRule},
+				5: {
+					name: "MIRInsufficientGenesisSigsUTXOW",
+					fields: []txFailureField{
+						{"genesis_key_hashes", decodeTxFailureHashes},
+					},
+				},
+				6:  missingTxBodyMetadataHashTxFailure,
+				7:  missingTxMetadataTxFailure,
+				8:  conflictingMetadataHashTxFailure,
+				9:  {name: "InvalidMetadata"},
+				10: extraneousScriptWitnessesTxFailure,
+			},
+		}
+	}
+}
+
+func alonzoUtxowRule(utxoRule func() *txFailureRule) func() *txFailureRule {
+	return func() *txFailureRule {
+		return &txFailureRule{
+			name: "UTXOW",
+			cases: map[uint64]txFailureCase{
+				0: {
+					name: "ShelleyInAlonzoUtxowPredFailure",
+					rule: shelleyUtxowRule(utxoRule),
+				},
+				1: opaqueTxFailure("MissingRedeemers"),
+				2: missingRequiredDatumsTxFailure,
+				3: notAllowedSupplementalDatumsTxFailure,
+				4: ppViewHashesDontMatchTxFailure,
+				5: {
+					name: "MissingRequiredSigners",
+					fields: []txFailureField{
+						{"missing_key_hashes", decodeTxFailureHashes},
+					},
+				},
+				6: unspendableUtxoNoDatumHashTxFailure,
+				7: opaqueTxFailure("ExtraRedeemers"),
+			},
+		}
+	}
+}
+
+func alonzoUtxoRule() *txFailureRule {
+	return &txFailureRule{
+		name: "UTXO",
+		cases: map[uint64]txFailureCase{
+			0:  badInputsTxFailure,
+			1:  outsideValidityIntervalTxFailure,
+			2:  maxTxSizeTxFailure,
+			3:  {name: "InputSetEmptyUTxO"},
+			4:  feeTooSmallTxFailure,
+			5:  valueNotConservedTxFailure,
+			6:  opaqueTxFailure("OutputTooSmallUTxO"),
+			7:  opaqueTxFailure("UtxosFailure"),
+			8:  wrongNetworkTxFailure,
+			9:  wrongNetworkWithdrawalTxFailure,
+			10: opaqueTxFailure("OutputBootAddrAttrsTooBig"),
+			11: {name: "TriesToForgeADA"},
+			12: opaqueTxFailure("OutputTooBigUTxO"),
+			13: insufficientCollateralTxFailure,
+			14: opaqueTxFailure("ScriptsNotPaidUTxO"),
+			15: exUnitsTooBigTxFailure,
+			16: collateralContainsNonAdaTxFailure,
+			17: wrongNetworkInTxBodyTxFailure,
+			18: outsideForecastTxFailure,
+			19: tooManyCollateralInputsTxFailure,
+			20: {name: "NoCollateralInputs"},
+		},
+	}
+}
+
+func babbageUtxowRule() *txFailureRule {
+	return &txFailureRule{
+		name: "UTXOW",
+		cases: map[uint64]txFailureCase{
+			1: {
+				name: "AlonzoInBabbageUtxowPredFailure",
+				rule: alonzoUtxowRule(babbageUtxoRule),
+			},
+			2: {name: "UtxoFailure", rule: babbageUtxoRule},
+			3: malformedScriptWitnessesTxFailure,
+			4: malformedReferenceScriptsTxFailure,
+		},
+	}
+}
+
+func babbageUtxoRule() *txFailureRule {
+	return &txFailureRule{
+		name: "UTXO",
+		cases: map[uint64]txFailureCase{
+			1: {name: "AlonzoInBabbageUtxoPredFailure", rule: alonzoUtxoRule},
+			2: incorrectTotalCollateralTxFailure,
+			3: opaqueTxFailure("BabbageOutputTooSmallUTxO"),
+			4: nonDisjointRefInputsTxFailure,
+		},
+	}
+}
+
+func conwayLedgerRule() *txFailureRule {
+	return &txFailureRule{
+		name: "LEDGER",
+		cases: map[uint64]txFailureCase{
+			1: {name: "ConwayUtxowFailure", rule: conwayUtxowRule},
+			2: opaqueTxFailure("ConwayCertsFailure"),
+			3: opaqueTxFailure("ConwayGovFailure"),
+			4: opaqueTxFailure("ConwayWdrlNotDelegatedToDRep"),
+			5: {
+				name: "ConwayTreasuryValueMismatch",
+				fields: []txFailureField{
+					{"actual", decodeTxFailureUint},
+					{"submitted", decodeTxFailureUint},
+				},
+			},
+			6: {
+				name: "ConwayTxRefScriptsSizeTooBig",
+				fields: []txFailureField{
+					{"actual_size", decodeTxFailureUint},
+					{"max_size", decodeTxFailureUint},
+				},
+			},
+			7: {
+				name: "ConwayMempoolFailure",
+				fields: []txFailureField{
+					{"message", decodeTxFailureText},
+				},
+			},
+		},
+	}
+}
+
+// conwayUtxowRule has the witness failures of the earlier eras in a single rule,
+// without the ones that Conway removed
+func conwayUtxowRule() *txFailureRule {
+	return &txFailureRule{
+		name: "UTXOW",
+		cases: map[uint64]txFailureCase{
+			0:  {name: "UtxoFailure", rule: conwayUtxoRule},
+			1:  invalidWitnessesTxFailure,
+			2:  missingVKeyWitnessesTxFailure,
+			3:  missingScriptWitnessesTxFailure,
+			4:  scriptWitnessNotValidatingTxFailure,
+			5:  missingTxBodyMetadataHashTxFailure,
+			6:  missingTxMetadataTxFailure,
+			7:  conflictingMetadataHashTxFailure,
+			8:  {name: "InvalidMetadata"},
+			9:  extraneousScriptWitnessesTxFailure,
+			10: opaqueTxFailure("MissingRedeemers"),
+			11: missingRequiredDatumsTxFailure,
+			12: notAllowedSupplementalDatumsTxFailure,
+			13: ppViewHashesDontMatchTxFailure,
+			14: unspendableUtxoNoDatumHashTxFailure,
+			15: opaqueTxFailure("ExtraRedeemers"),
+			16: malformedScriptWitnessesTxFailure,
+			17: malformedReferenceScriptsTxFailure,
+		},
+	}
+}
+
+func conwayUtxoRule() *txFailureRule {
+	return &txFailureRule{
+		name: "UTXO",
+		cases: map[uint64]txFailureCase{
+			0:  opaqueTxFailure("UtxosFailure"),
+			1:  badInputsTxFailure,
+			2:  outsideValidityIntervalTxFailure,
+			3:  maxTxSizeTxFailure,
+			4:  {name: "InputSetEmptyUTxO"},
+			5:  feeTooSmallTxFailure,
+			6:  valueNotConservedTxFailure,
+			7:  wrongNetworkTxFailure,
+			8:  wrongNetworkWithdrawalTxFailure,
+			9:  opaqueTxFailure("OutputTooSmallUTxO"),
+			10: opaqueTxFailure("OutputBootAddrAttrsTooBig"),
+			11: opaqueTxFailure("OutputTooBigUTxO"),
+			12: insufficientCollateralTxFailure,
+			13: opaqueTxFailure("ScriptsNotPaidUTxO"),
+			14: exUnitsTooBigTxFailure,
+			15: collateralContainsNonAdaTxFailure,
+			16: wrongNetworkInTxBodyTxFailure,
+			17: outsideForecastTxFailure,
+			18: tooManyCollateralInputsTxFailure,
+			19: {name: "NoCollateralInputs"},
+			20: incorrectTotalCollateralTxFailure,
+			21: opaqueTxFailure("BabbageOutputTooSmallUTxO"),
+			22: nonDisjointRefInputsTxFailure,
+		},
+	}
+}
+
+// Witness failures that are encoded the same way in every era, under different
+// tags
+var (
+	invalidWitnessesTxFailure = txFailureCase{
+		name: "InvalidWitnessesUTXOW",
+		fields: []txFailureField{
+			{"invalid_vkeys", decodeTxFailureHashes},
+		},
+	}
+	missingVKeyWitnessesTxFailure = txFailureCase{
+		name: "MissingVKeyWitnessesUTXOW",
+		fields: []txFailureField{
+			{"missing_key_hashes", decodeTxFailureHashes},
+		},
+	}
+	missingScriptWitnessesTxFailure = txFailureCase{
+		name: "MissingScriptWitnessesUTXOW",
+		fields: []txFailureField{
+			{"missing_script_hashes", decodeTxFailureHashes},
+		},
+	}
+	scriptWitnessNotValidatingTxFailure = txFailureCase{
+		name: "ScriptWitnessNotValidatingUTXOW",
+		fields: []txFailureField{
+			{"script_hashes", decodeTxFailureHashes},
+		},
+	}
+	missingTxBodyMetadataHashTxFailure = txFailureCase{
+		name: "MissingTxBodyMetadataHash",
+		fields: []txFailureField{
+			{"metadata_hash", decodeTxFailureHash},
+		},
+	}
+	missingTxMetadataTxFailure = txFailureCase{
+		name: "MissingTxMetadata",
+		fields: []txFailureField{
+			{"metadata_hash", decodeTxFailureHash},
+		},
+	}
+	conflictingMetadataHashTxFailure = txFailureCase{
+		name: "ConflictingMetadataHash",
+		fields: []txFailureField{
+			{"body_metadata_hash", decodeTxFailureHash},
+			{"metadata_hash", decodeTxFailureHash},
+		},
+	}
+	extraneousScriptWitnessesTxFailure = txFailureCase{
+		name: "ExtraneousScriptWitnessesUTXOW",
+		fields: []txFailureField{
+			{"script_hashes", decodeTxFailureHashes},
+		},
+	}
+	missingRequiredDatumsTxFailure = txFailureCase{
+		name: "MissingRequiredDatums",
+		fields: []txFailureField{
+			{"missing_datum_hashes", decodeTxFailureHashes},
+			{"received_datum_hashes", decodeTxFailureHashes},
+		},
+	}
+	notAllowedSupplementalDatumsTxFailure = txFailureCase{
+		name: "NotAllowedSupplementalDatums",
+		fields: []txFailureField{
+			{"unallowed_datum_hashes", decodeTxFailureHashes},
+			{"acceptable_datum_hashes", decodeTxFailureHashes},
+		},
+	}
+	ppViewHashesDontMatchTxFailure = txFailureCase{
+		name: "PPViewHashesDontMatch",
+		fields: []txFailureField{
+			{"supplied_hash", decodeTxFailureMaybeHash},
+			{"expected_hash", decodeTxFailureMaybeHash},
+		},
+	}
+	unspendableUtxoNoDatumHashTxFailure = txFailureCase{
+		name: "UnspendableUTxONoDatumHash",
+		fields: []txFailureField{
+			{"inputs", decodeTxFailureTxIns},
+		},
+	}
+	malformedScriptWitnessesTxFailure = txFailureCase{
+		name: "MalformedScriptWitnesses",
+		fields: []txFailureField{
+			{"script_hashes", decodeTxFailureHashes},
+		},
+	}
+	malformedReferenceScriptsTxFailure = txFailureCase{
+		name: "MalformedReferenceScripts",
+		fields: []txFailureField{
+			{"script_hashes", decodeTxFailureHashes},
+		},
+	}
+)
+
+// UTxO failures that are encoded the same way in every era, under different tags
+var (
+	badInputsTxFailure = txFailureCase{
+		name: "BadInputsUTxO",
+		fields: []txFailureField{
+			{"bad_inputs", decodeTxFailureTxIns},
+		},
+	}
+	outsideValidityIntervalTxFailure = txFailureCase{
+		name: "OutsideValidityIntervalUTxO",
+		fields: []txFailureField{
+			{"validity_interval", decodeTxFailureValidityInterval},
+			{"slot", decodeTxFailureUint},
+		},
+	}
+	maxTxSizeTxFailure = txFailureCase{
+		name: "MaxTxSizeUTxO",
+		fields: []txFailureField{
+			{"actual_size", decodeTxFailureUint},
+			{"max_size", decodeTxFailureUint},
+		},
+	}
+	feeTooSmallTxFailure = txFailureCase{
+		name: "FeeTooSmallUTxO",
+		fields: []txFailureField{
+			{"minimum_fee", decodeTxFailureUint},
+			{"supplied_fee", decodeTxFailureUint},
+		},
+	}
+	valueNotConservedTxFailure = txFailureCase{
+		name: "ValueNotConservedUTxO",
+		fields: []txFailureField{
+			{"consumed", decodeTxFailureValue},
+			{"produced", decodeTxFailureValue},
+		},
+	}
+	wrongNetworkTxFailure = txFailureCase{
+		name: "WrongNetwork",
+		fields: []txFailureField{
+			{"expected_network_id", decodeTxFailureUint},
+			{"addresses", decodeTxFailureAddresses},
+		},
+	}
+	wrongNetworkWithdrawalTxFailure = txFailureCase{
+		name: "WrongNetworkWithdrawal",
+		fields: []txFailureField{
+			{"expected_network_id", decodeTxFailureUint},
+			{"reward_accounts", decodeTxFailureAddresses},
+		},
+	}
+	insufficientCollateralTxFailure = txFailureCase{
+		name: "InsufficientCollateral",
+		fields: []txFailureField{
+			{"balance", decodeTxFailureInt},
+			{"required_collateral", decodeTxFailureUint},
+		},
+	}
+	exUnitsTooBigTxFailure = txFailureCase{
+		name: "ExUnitsTooBigUTxO",
+		fields: []txFailureField{
+			{"max_ex_units", decodeTxFailureExUnits},
+			{"supplied_ex_units", decodeTxFailureExUnits},
+		},
+	}
+	collateralContainsNonAdaTxFailure = txFailureCase{
+		name: "CollateralContainsNonADA",
+		fields: []txFailureField{
+			{"value", decodeTxFailureValue},
+		},
+	}
+	wrongNetworkInTxBodyTxFailure = txFailureCase{
+		name: "WrongNetworkInTxBody",
+		fields: []txFailureField{
+			{"expected_network_id", decodeTxFailureUint},
+			{"tx_network_id", decodeTxFailureUint},
+		},
+	}
+	outsideForecastTxFailure = txFailureCase{
+		name: "OutsideForecast",
+		fields: []txFailureField{
+			{"slot", decodeTxFailureUint},
+		},
+	}
+	tooManyCollateralInputsTxFailure = txFailureCase{
+		name: "TooManyCollateralInputs",
+		fields: []txFailureField{
+			{"max_collateral_inputs", decodeTxFailureUint},
+			{"supplied_collateral_inputs", decodeTxFailureUint},
+		},
+	}
+	incorrectTotalCollateralTxFailure = txFailureCase{
+		name: "IncorrectTotalCollateralField",
+		fields: []txFailureField{
+			{"balance", decodeTxFailureInt},
+			{"total_collateral", decodeTxFailureUint},
+		},
+	}
+	nonDisjointRefInputsTxFailure = txFailureCase{
+		name: "BabbageNonDisjointRefInputs",
+		fields: []txFailureField{
+			{"inputs", decodeTxFailureTxIns},
+		},
+	}
+)
+
+func decodeTxFailureUint(data []byte) (any, error) {
+	var ret uint64
+	_, err := cbor.Decode(data, &ret)
+	return ret, err
+}
+
+func decodeTxFailureInt(data []byte) (any, error) {
+	var ret int64
+	_, err := cbor.Decode(data, &ret)
+	return ret, err
+}
+
+func decodeTxFailureText(data []byte) (any, error) {
+	var ret string
+	_, err := cbor.Decode(data, &ret)
+	return ret, err
+}
+
+func decodeTxFailureHash(data []byte) (any, error) {
+	var ret []byte
+	if _, err := cbor.Decode(data, &ret); err != nil {
+		return nil, err
+	}
+	return hex.EncodeToString(ret), nil
+}
+
+// decodeTxFailureMaybeHash decodes an optional hash, which is encoded as an
+// empty list or a list with the hash
+func decodeTxFailureMaybeHash(data []byte) (any, error) {
+	var ret [][]byte
+	if _, err := cbor.Decode(data, &ret); err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return hex.EncodeToString(ret[0]), nil
+}
+
+func decodeTxFailureHashes(data []byte) (any, error) {
+	var hashes [][]byte
+	if _, err := cbor.Decode(data, &hashes); err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		ret = append(ret, hex.EncodeToString(hash))
+	}
+	return ret, nil
+}
+
+// decodeTxFailureTxIns decodes TX inputs, which are returned as txHash#index
+func decodeTxFailureTxIns(data []byte) (any, error) {
+	var txIns []struct {
+		cbor.StructAsArray
+		TxId  ledger.Blake2b256
+		Index uint32
+	}
+	if _, err := cbor.Decode(data, &txIns); err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(txIns))
+	for _, txIn := range txIns {
+		ret = append(ret, fmt.Sprintf("%s#%d", txIn.TxId.String(), txIn.Index))
+	}
+	return ret, nil
+}
+
+func decodeTxFailureAddresses(data []byte) (any, error) {
+	var addrs []ledger.Address
+	if _, err := cbor.Decode(data, &addrs); err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ret = append(ret, addr.String())
+	}
+	return ret, nil
+}
+
+// decodeTxFailureValidityInterval decodes a validity interval, where each bound
+// is optional
+func decodeTxFailureValidityInterval(data []byte) (any, error) {
+	var interval struct {
+		cbor.StructAsArray
+		InvalidBefore    []uint64
+		InvalidHereafter []uint64
+	}
+	if _, err := cbor.Decode(data, &interval); err != nil {
+		return nil, err
+	}
+	ret := responseTxValidityInterval{}
+	if len(interval.InvalidBefore) > 0 {
+		ret.InvalidBefore = &interval.InvalidBefore[0]
+	}
+	if len(interval.InvalidHereafter) > 0 {
+		ret.InvalidHereafter = &interval.InvalidHereafter[0]
+	}
+	return ret, nil
+}
+
+func decodeTxFailureExUnits(data []byte) (any, error) {
+	var exUnits struct {
+		cbor.StructAsArray
+		Memory uint64
+		Steps  uint64
+	}
+	if _, err := cbor.Decode(data, &exUnits); err != nil {
+		return nil, err
+	}
+	return responseExecutionUnits{
+		Memory: exUnits.Memory,
+		Steps:  exUnits.Steps,
+	}, nil
+}
+
+// decodeTxFailureValue decodes a value, which is either lovelace or a list of
+// lovelace and assets
+func decodeTxFailureValue(data []byte) (any, error) {
+	var lovelace uint64
+	if _, err := cbor.Decode(data, &lovelace); err == nil {
+		return responseTxValue{
+			Lovelace: lovelace,
+			Assets:   []responseUtxoAsset{},
+		}, nil
+	}
+	var value struct {
+		cbor.StructAsArray
+		Lovelace uint64
+		Assets   ledger.MultiAsset[ledger.MultiAssetTypeOutput]
+	}
+	if _, err := cbor.Decode(data, &value); err != nil {
+		return nil, err
+	}
+	return responseTxValue{
+		Lovelace: value.Lovelace,
+		Assets:   newResponseUtxoAssets(&value.Assets),
+	}, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Rejection reasons as the node sends them, as hex. Each is a list with the era
+// and its ledger rule failures, which wrap the failures of the rules below them.
+// Key hashes are 0x33 and 0x44 bytes, script hashes are 0x55 bytes, policy IDs
+// are 0x66 bytes, and TX IDs are 0x11 and 0x22 bytes
+func TestNewResponseTxRejection(t *testing.T) {
+	testDefs := []struct {
+		name   string
+		reason string
+		want   string
+	}{
+		{
+			name: "alonzo utxo",
+			// [[4, [[0, [0, [4, [4, 200000, 170000]]]]]]]
+			reason: "8182048182008200820483041a00030d401a00029810",
+			want: `{"era":"Alonzo","failures":[{"rule":"UTXO","name":"FeeTooSmallUTxO",
+				"details":{"minimum_fee":200000,"supplied_fee":170000}}]}`,
+		},
+		{
+			name: "alonzo utxow",
+			// [[4, [[0, [0, [1, [h'33..', h'44..']]]]]]]
+			reason: "8182048182008200820182581c33333333333333333333333333333333333333333333333333333333" +
+				"581c44444444444444444444444444444444444444444444444444444444",
+			want: `{"era":"Alonzo","failures":[{"rule":"UTXOW","name":"MissingVKeyWitnessesUTXOW",
+				"details":{"missing_key_hashes":["33333333333333333333333333333333333333333333333333333333",
+				"44444444444444444444444444444444444444444444444444444444"]}}]}`,
+		},
+		{
+			name: "alonzo ledger",
+			// [[4, [[1, [0, [5, [h'33..']]]]]]]
+			reason: "8182048182018200820581581c33333333333333333333333333333333333333333333333333333333",
+			want: `{"era":"Alonzo","failures":[{"rule":"LEDGER","name":"DelegsFailure",
+				"cbor":"82018200820581581c33333333333333333333333333333333333333333333333333333333"}]}`,
+		},
+		{
+			name: "babbage utxo",
+			// [[5, [[0, [2, [1, [0, [[h'11..', 0], [h'22..', 3]]]]]]]]]
+			reason: "81820581820082028201820082825820111111111111111111111111111111111111111111111111111111111111111100" +
+				"825820222222222222222222222222222222222222222222222222222222222222222203",
+			want: `{"era":"Babbage","failures":[{"rule":"UTXO","name":"BadInputsUTxO",
+				"details":{"bad_inputs":["1111111111111111111111111111111111111111111111111111111111111111#0",
+				"2222222222222222222222222222222222222222222222222222222222222222#3"]}}]}`,
+		},
+		{
+			name: "babbage utxo collateral",
+			// [[5, [[0, [2, [2, -500000, 5000000]]]]]]
+			reason: "818205818200820283023a0007a11f1a004c4b40",
+			want: `{"era":"Babbage","failures":[{"rule":"UTXO","name":"IncorrectTotalCollateralField",
+				"details":{"balance":-500000,"total_collateral":5000000}}]}`,
+		},
+		{
+			name: "babbage utxow",
+			// [[5, [[0, [1, [5, [h'33..']]]]]]]
+			reason: "8182058182008201820581581c33333333333333333333333333333333333333333333333333333333",
+			want: `{"era":"Babbage","failures":[{"rule":"UTXOW","name":"MissingRequiredSigners",
+				"details":{"missing_key_hashes":["33333333333333333333333333333333333333333333333333333333"]}}]}`,
+		},
+		{
+			name: "babbage utxow malformed scripts",
+			// [[5, [[0, [3, [h'55..']]]]]]
+			reason: "818205818200820381581c55555555555555555555555555555555555555555555555555555555",
+			want: `{"era":"Babbage","failures":[{"rule":"UTXOW","name":"MalformedScriptWitnesses",
+				"details":{"script_hashes":["55555555555555555555555555555555555555555555555555555555"]}}]}`,
+		},
+		{
+			name: "conway utxo value",
+			// [[6, [[1, [0, [6, [3000000, {h'66..': {'tok': 5}}], 2000000]]]]]]
+			reason: "81820681820182008306821a002dc6c0a1581c66666666666666666666666666666666666666666666666666666666" +
+				"a143746f6b051a001e8480",
+			want: `{"era":"Conway","failures":[{"rule":"UTXO","name":"ValueNotConservedUTxO",
+				"details":{"consumed":{"lovelace":3000000,"assets":[{"policy_id":"66666666666666666666666666666666666666666666666666666666",
+				"name":"tok","name_hex":"746f6b","quantity":5}]},"produced":{"lovelace":2000000,"assets":[]}}}]}`,
+		},
+		{
+			name: "conway utxo validity interval",
+			// [[6, [[1, [0, [2, [[100], []], 250]]]]]]
+			reason: "81820681820182008302828118648018fa",
+			want: `{"era":"Conway","failures":[{"rule":"UTXO","name":"OutsideValidityIntervalUTxO",
+				"details":{"slot":250,"validity_interval":{"invalid_before":100,"invalid_hereafter":null}}}]}`,
+		},
+		{
+			name: "conway utxo tagged set",
+			// [[6, [[1, [0, [1, 258([[h'11..', 1]])]]]]]]
+			reason: "81820681820182008201d9010281825820111111111111111111111111111111111111111111111111111111111111111101",
+			want: `{"era":"Conway","failures":[{"rule":"UTXO","name":"BadInputsUTxO",
+				"details":{"bad_inputs":["1111111111111111111111111111111111111111111111111111111111111111#1"]}}]}`,
+		},
+		{
+			name: "conway utxow",
+			// [[6, [[1, [2, [h'33..']]]]]]
+			reason: "818206818201820281581c33333333333333333333333333333333333333333333333333333333",
+			want: `{"era":"Conway","failures":[{"rule":"UTXOW","name":"MissingVKeyWitnessesUTXOW",
+				"details":{"missing_key_hashes":["33333333333333333333333333333333333333333333333333333333"]}}]}`,
+		},
+		{
+			name: "conway ledger",
+			// [[6, [[5, 1000, 2000]]]]
+			reason: "8182068183051903e81907d0",
+			want: `{"era":"Conway","failures":[{"rule":"LEDGER","name":"ConwayTreasuryValueMismatch",
+				"details":{"actual":1000,"submitted":2000}}]}`,
+		},
+		{
+			name: "multiple failures",
+			// [[6, [[1, [0, [5, 200000, 170000]]], [1, [2, [h'33..']]]]]]
+			reason: "818206828201820083051a00030d401a000298108201820281581c33333333333333333333333333333333333333333333333333333333",
+			want: `{"era":"Conway","failures":[{"rule":"UTXO","name":"FeeTooSmallUTxO",
+				"details":{"minimum_fee":200000,"supplied_fee":170000}},{"rule":"UTXOW","name":"MissingVKeyWitnessesUTXOW",
+				"details":{"missing_key_hashes":["33333333333333333333333333333333333333333333333333333333"]}}]}`,
+		},
+		{
+			name: "unknown tag",
+			// [[6, [[1, [0, [99, 7]]]]]]
+			reason: "818206818201820082186307",
+			want:   `{"era":"Conway","failures":[{"rule":"UTXO","name":"Unknown","cbor":"82186307"}]}`,
+		},
+		{
+			name: "undecodable fields",
+			// [[6, [[1, [0, [5, "x", 1]]]]]]
+			reason: "81820681820182008305617801",
+			want:   `{"era":"Conway","failures":[{"rule":"UTXO","name":"FeeTooSmallUTxO","cbor":"8305617801"}]}`,
+		},
+		{
+			name: "era without rules",
+			// [[1, [[0, [1]]]]]
+			reason: "8182018182008101",
+			want:   `{"era":"Shelley","failures":[{"name":"Unknown","cbor":"82008101"}]}`,
+		},
+		{
+			name:   "era mismatch",
+			reason: "820506",
+			want: `{"failures":[{"name":"EraMismatch",
+				"details":{"ledger_era":"Babbage","tx_era":"Conway"}}]}`,
+		},
+		{
+			name:   "not a rejection",
+			reason: "63626164",
+			want:   `{"failures":[{"name":"Unknown","cbor":"63626164"}]}`,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			reason, err := hex.DecodeString(testDef.reason)
+			if err != nil {
+				t.Fatalf("invalid test reason: %s", err)
+			}
+			rejection := newResponseTxRejection(reason)
+			if rejection.Cbor != testDef.reason {
+				t.Fatalf(
+					"unexpected CBOR: got %s, wanted %s",
+					rejection.Cbor,
+					testDef.reason,
+				)
+			}
+			// The full CBOR is checked above, so it's left out of the comparison
+			gotJson, err := json.Marshal(rejection)
+			if err != nil {
+				t.Fatalf("failed to encode rejection: %s", err)
+			}
+			var got, want map[string]any
+			if err := json.Unmarshal(gotJson, &got); err != nil {
+				t.Fatalf("failed to decode rejection: %s", err)
+			}
+			delete(got, "cbor")
+			if err := json.Unmarshal([]byte(testDef.want), &want); err != nil {
+				t.Fatalf("invalid test JSON: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected rejection:\n got: %s\nwant: %s", gotJson, testDef.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Encodings of an empty witness list, which may be a tagged set from Conway on
+var emptyWitnessLists = [][]byte{
+	{0x80},
+	{0xa0},
+	{0xd9, 0x01, 0x02, 0x80},
+}
+
+type requestTxValidation struct {
+	SkipValidation bool `form:"skip_validation"`
+}
+
+// responseTxExpired is the TTL of a TX that has already passed
+type responseTxExpired struct {
+	Ttl  uint64 `json:"ttl"  example:"134217728"`
+	Slot uint64 `json:"slot" example:"134217800"`
+}
+
+// responseTxFeeTooLow is the fee of a TX under the min fee for its size
+type responseTxFeeTooLow struct {
+	Fee    uint64 `json:"fee"     example:"150000"`
+	MinFee uint64 `json:"min_fee" example:"168053"`
+}
+
+// txValidation has what's needed to check TXs before they're sent to the node,
+// so that we can reject them without waiting for the node. Checks are skipped
+// when the values for them aren't known
+type txValidation struct {
+	era       ledger.Era
+	maxTxSize uint64
+	minFeeA   *uint64
+	minFeeB   *uint64
+	slot      uint64
+}
+
+// queryTxValidation returns the protocol parameters and ledger tip slot to check
+// TXs against, or nil if the request skips validation. The TTL check is skipped
+// if the tip can't be queried. An error response has been sent if it returns
+// false
+func queryTxValidation(c *gin.Context, skip bool) (*txValidation, bool) {
+	if skip {
+		return nil, true
+	}
+	era, protoParams, ok := queryProtocolParameters(c)
+	if !ok {
+		return nil, false
+	}
+	v := &txValidation{era: era}
+	params, err := newResponseProtocolParameters(era, protoParams)
+	if err == nil {
+		if config.GetConfig().Api.TxSizeCheck && params.MaxTxSize != nil {
+			v.maxTxSize = *params.MaxTxSize
+		}
+		v.minFeeA = params.MinFeeA
+		v.minFeeB = params.MinFeeB
+	}
+	slot, err := queryLedgerTipSlot(c.Request.Context())
+	if err != nil {
+		requestLogger(c, logging.ComponentApi).Warnf(
+			"skipping TTL check, failed to query the ledger tip: %s",
+			err,
+		)
+	}
+	v.slot = slot
+	return v, true
+}
+
+// queryLedgerTipSlot returns the slot of the ledger tip from the node
+func queryLedgerTipSlot(ctx context.Context) (uint64, error) {
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Return the connection to the pool
+	defer oConn.Close()
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		return 0, err
+	}
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
+	if err != nil {
+		return 0, err
+	}
+	_ = oConn.ReleaseLocalState(ctx)
+	return point.Slot, nil
+}
+
+// check returns the error for the first check that the TX fails, or nil if it
+// passes them all
+func (v *txValidation) check(
+	txType uint,
+	txCbor []byte,
+	tx ledger.Transaction,
+) *responseApiError {
+	if txType < ledger.TxTypeShelley || txType > ledger.TxTypeConway {
+		errResp := apiErrorCode(
+			errorCodeUnsupportedEra,
+			fmt.Sprintf("transaction type %d is not supported", txType),
+			nil,
+		)
+		return &errResp
+	}
+	if txType > uint(v.era.Id) {
+		errResp := apiErrorCode(
+			errorCodeUnsupportedEra,
+			fmt.Sprintf(
+				"transaction is for the %s era, which is after the current %s era",
+				ledger.GetEraById(uint8(txType)).Name,
+				v.era.Name,
+			),
+			nil,
+		)
+		return &errResp
+	}
+	if errResp := txTooLargeError(txCbor, v.maxTxSize); errResp != nil {
+		return errResp
+	}
+	if !hasWitnesses(txCbor) {
+		errResp := apiErrorCode(
+			errorCodeMissingWitnesses,
+			"transaction has an empty witness set",
+			nil,
+		)
+		return &errResp
+	}
+	if ttl := tx.TTL(); ttl > 0 && v.slot >= ttl {
+		errResp := apiErrorCode(
+			errorCodeTxExpired,
+			fmt.Sprintf(
+				"transaction TTL of slot %d has passed, the ledger tip is at slot %d",
+				ttl,
+				v.slot,
+			),
+			responseTxExpired{Ttl: ttl, Slot: v.slot},
+		)
+		return &errResp
+	}
+	if v.minFeeA != nil && v.minFeeB != nil {
+		// This leaves out script execution and reference script costs, so the
+		// node may still reject a TX that passes
+		minFee := *v.minFeeA*uint64(len(txCbor)) + *v.minFeeB
+		if fee := tx.Fee(); fee < minFee {
+			errResp := apiErrorCode(
+				errorCodeFeeTooLow,
+				fmt.Sprintf(
+					"transaction fee of %d is less than the min fee of %d for its size",
+					fee,
+					minFee,
+				),
+				responseTxFeeTooLow{Fee: fee, MinFee: minFee},
+			)
+			return &errResp
+		}
+	}
+	return nil
+}
+
+// hasWitnesses returns whether the witness set of the TX has any witnesses
+func hasWitnesses(txCbor []byte) bool {
+	var txArray []cbor.RawMessage
+	if _, err := cbor.Decode(txCbor, &txArray); err != nil || len(txArray) < 2 {
+		return false
+	}
+	var witnesses map[uint]cbor.RawMessage
+	if _, err := cbor.Decode(txArray[1], &witnesses); err != nil {
+		return false
+	}
+	for _, witnessList := range witnesses {
+		empty := false
+		for _, emptyList := range emptyWitnessLists {
+			if bytes.Equal(witnessList, emptyList) {
+				empty = true
+				break
+			}
+		}
+		if !empty {
+			return true
+		}
+	}
+	return false
+}
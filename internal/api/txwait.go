@@ -0,0 +1,445 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/gin-gonic/gin"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+// Stages that a submitted TX can reach while waiting for it
+const (
+	txStageSubmitted = "submitted"
+	txStageMempool   = "mempool"
+	txStageBlock     = "block"
+)
+
+// Wait modes for TX submissions
+const (
+	txWaitModeMempool             = "mempool"
+	txWaitModeBlock               = "block"
+	txWaitModeConfirmationsPrefix = "confirmations:"
+)
+
+// How often to check the mempool for a TX while waiting for it to show up
+const txWaitMempoolPollInterval = time.Second
+
+type requestLocalTxSubmissionWait struct {
+	Wait string `form:"wait"`
+}
+
+// responseLocalTxSubmissionWait is the furthest stage that a submitted TX reached,
+// with the block that it was included in for the block stage. Error is why the
+// wait ended early, if it did
+type responseLocalTxSubmissionWait struct {
+	TxId          string           `json:"tx_id"                   example:"96649a8b827a5a4d508cd4e98cd88832482f7b884d507a49466d1fb8c4b14978"`
+	Status        string           `json:"status"                  example:"accepted"             enums:"accepted,already_submitted"`
+	Stage         string           `json:"stage"                   example:"block"                enums:"submitted,mempool,block"`
+	TimedOut      bool             `json:"timed_out"               example:"false"`
+	Block         *responseTxBlock `json:"block,omitempty"`
+	Confirmations uint64           `json:"confirmations,omitempty" example:"1"`
+	Error         string           `json:"error,omitempty"         example:"server shutting down"`
+}
+
+type responseTxBlock struct {
+	Hash   string `json:"hash"   example:"4ac0a0a3a1f0a7ad3e1ed3a1c2b2bcbf143a6f1e00c4e1e5f5b4936a4f4aab62"`
+	Slot   uint64 `json:"slot"   example:"130048962"`
+	Number uint64 `json:"number" example:"10839424"`
+}
+
+// parseTxWaitMode returns the number of block confirmations to wait for, or 0 to
+// wait for the mempool
+func parseTxWaitMode(mode string) (uint64, error) {
+	switch mode {
+	case "", txWaitModeMempool:
+		return 0, nil
+	case txWaitModeBlock:
+		return 1, nil
+	}
+	countStr, ok := strings.CutPrefix(mode, txWaitModeConfirmationsPrefix)
+	if !ok {
+		return 0, fmt.Errorf(
+			"unknown wait mode %q, should be mempool, block, or confirmations:N",
+			mode,
+		)
+	}
+	count, err := strconv.ParseUint(countStr, 10, 64)
+	if err != nil || count == 0 {
+		return 0, fmt.Errorf(
+			"invalid confirmations %q, should be a positive number",
+			countStr,
+		)
+	}
+	return count, nil
+}
+
+// txBlockWatcher follows the chain to find the blocks that waited for TXs are
+// included in. All waiters share a single chain-sync, which starts from the tip
+// when the first one registers and stops when the last one leaves
+type txBlockWatcher struct {
+	mutex   sync.Mutex
+	stream  *node.ChainSyncStream
+	cancel  context.CancelFunc
+	waiters map[string]map[*txBlockWaiter]struct{}
+	// Number of the most recent block, or 0 if there hasn't been one since the
+	// last rollback
+	tipBlockNumber uint64
+	tipSlot        uint64
+}
+
+// txBlockWaiter waits for a single TX. Updates receives a value whenever its
+// state changes or the chain moves on, and the state is read with state()
+type txBlockWaiter struct {
+	txId      string
+	updates   chan struct{}
+	inclusion *responseTxBlock
+	err       error
+}
+
+var txWatcher = &txBlockWatcher{
+	waiters: make(map[string]map[*txBlockWaiter]struct{}),
+}
+
+// register adds a waiter for a TX, starting the chain-sync if it isn't running
+func (w *txBlockWatcher) register(txId string) (*txBlockWaiter, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.stream == nil {
+		// The chain-sync outlives any single request, so it isn't tied to one
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := node.StartChainSyncStream(ctx, nil)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		logging.GetLogger(logging.ComponentChainsync).Debugf(
+			"started chain-sync for TX waits at slot %d",
+			stream.IntersectPoint().Slot,
+		)
+		w.stream = stream
+		w.cancel = cancel
+		w.tipBlockNumber = 0
+		w.tipSlot = stream.IntersectPoint().Slot
+		go w.run(stream)
+	}
+	waiter := &txBlockWaiter{
+		txId:    txId,
+		updates: make(chan struct{}, 1),
+	}
+	if w.waiters[txId] == nil {
+		w.waiters[txId] = make(map[*txBlockWaiter]struct{})
+	}
+	w.waiters[txId][waiter] = struct{}{}
+	return waiter, nil
+}
+
+// unregister removes a waiter, stopping the chain-sync if it was the last one
+func (w *txBlockWatcher) unregister(waiter *txBlockWaiter) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.waiters[waiter.txId], waiter)
+	if len(w.waiters[waiter.txId]) == 0 {
+		delete(w.waiters, waiter.txId)
+	}
+	if len(w.waiters) == 0 && w.stream != nil {
+		w.stop()
+	}
+}
+
+func (w *txBlockWatcher) stop() {
+	w.cancel()
+	w.stream = nil
+	w.cancel = nil
+}
+
+// state returns the block that the waiter's TX was included in and its number of
+// confirmations, or the error that stopped the chain-sync
+func (w *txBlockWatcher) state(
+	waiter *txBlockWaiter,
+) (*responseTxBlock, uint64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if waiter.inclusion == nil {
+		return nil, 0, waiter.err
+	}
+	var confirmations uint64
+	if w.tipBlockNumber >= waiter.inclusion.Number {
+		confirmations = w.tipBlockNumber - waiter.inclusion.Number + 1
+	}
+	return waiter.inclusion, confirmations, waiter.err
+}
+
+// currentSlot returns the slot of the most recent block, or of the rollback point
+// if there's been a rollback since
+func (w *txBlockWatcher) currentSlot() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.tipSlot
+}
+
+func (w *txBlockWatcher) run(stream *node.ChainSyncStream) {
+	for evt := range stream.Events() {
+		switch payload := evt.Payload.(type) {
+		case input_chainsync.BlockEvent:
+			blockCtx, ok := evt.Context.(input_chainsync.BlockContext)
+			if !ok {
+				continue
+			}
+			w.handleBlock(stream, payload, blockCtx)
+		case input_chainsync.RollbackEvent:
+			w.handleRollback(stream, payload.SlotNumber)
+		}
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// The stream was replaced if the waiters all left in the meantime
+	if w.stream != stream {
+		return
+	}
+	err := stream.Err()
+	if err == nil {
+		err = errors.New("chain-sync stopped")
+	}
+	logging.GetLogger(logging.ComponentChainsync).Warnf(
+		"chain-sync for TX waits failed: %s",
+		err,
+	)
+	for _, waiters := range w.waiters {
+		for waiter := range waiters {
+			waiter.err = err
+			waiter.notify()
+		}
+	}
+	// The next waiter starts a new chain-sync
+	w.stop()
+}
+
+func (w *txBlockWatcher) handleBlock(
+	stream *node.ChainSyncStream,
+	blockEvt input_chainsync.BlockEvent,
+	blockCtx input_chainsync.BlockContext,
+) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// Ignore events from a stopped stream that were already on their way
+	if w.stream != stream {
+		return
+	}
+	w.tipBlockNumber = blockCtx.BlockNumber
+	w.tipSlot = blockCtx.SlotNumber
+	for _, tx := range blockEvt.Block.Transactions() {
+		for waiter := range w.waiters[tx.Hash()] {
+			waiter.inclusion = &responseTxBlock{
+				Hash:   blockEvt.BlockHash,
+				Slot:   blockCtx.SlotNumber,
+				Number: blockCtx.BlockNumber,
+			}
+		}
+	}
+	// Waiters for confirmations need to know about every block after theirs,
+	// and the others may be waiting for the TX to expire
+	for _, waiters := range w.waiters {
+		for waiter := range waiters {
+			waiter.notify()
+		}
+	}
+}
+
+// handleRollback forgets the blocks after the rollback point, so TXs in those
+// blocks need to be included again
+func (w *txBlockWatcher) handleRollback(
+	stream *node.ChainSyncStream,
+	slot uint64,
+) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.stream != stream {
+		return
+	}
+	w.tipBlockNumber = 0
+	w.tipSlot = slot
+	for _, waiters := range w.waiters {
+		for waiter := range waiters {
+			if waiter.inclusion != nil && waiter.inclusion.Slot > slot {
+				waiter.inclusion = nil
+				waiter.notify()
+			}
+		}
+	}
+}
+
+func (t *txBlockWaiter) notify() {
+	select {
+	case t.updates <- struct{}{}:
+	default:
+	}
+}
+
+// handleLocalSubmitTxWait godoc
+//
+//	@Summary		Submit Tx and wait
+//	@Description	Submit an already serialized transaction to the network, like the /localtxsubmission/tx endpoint, and then wait until it reaches the mempool or is included in a block. The wait mode is mempool (the default), block, or confirmations:N to wait for the block with the transaction and N-1 blocks after it. The response has the furthest stage that the transaction reached, and the block it was included in for the block stage. If the wait times out, the response is sent with a 202 status and the stage that was reached. Idempotency-Key headers aren't supported, since retries of a wait should wait again.
+//	@Produce		json
+//	@Param			Content-Type	header		string							true	"Content type"	Enums(application/cbor, application/octet-stream, text/plain)
+//	@Param			wait			query		string							false	"Stage to wait for: mempool, block, or confirmations:N"
+//	@Param			skip_validation	query		bool							false	"Whether to skip the checks before submitting"
+//	@Success		200				{object}	responseLocalTxSubmissionWait	"Ok"
+//	@Success		202				{object}	responseLocalTxSubmissionWait	"Accepted"
+//	@Failure		400				{object}	responseApiError				"Bad Request"
+//	@Failure		413				{object}	responseApiError				"Request Entity Too Large"
+//	@Failure		415				{object}	responseApiError				"Unsupported Media Type"
+//	@Failure		500				{object}	responseApiError				"Server Error"
+//	@Router			/localtxsubmission/tx/wait [post]
+func handleLocalSubmitTxWait(c *gin.Context) {
+	var req requestLocalTxSubmissionWait
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	confirmations, err := parseTxWaitMode(req.Wait)
+	if err != nil {
+		respondError(
+			c,
+			400,
+			apiErrorCode(errorCodeBadRequest, err.Error(), nil),
+		)
+		return
+	}
+	// The timeout covers the submission too. A client disconnect cancels the
+	// request context, which stops the wait
+	ctx, cancel := context.WithTimeout(
+		c.Request.Context(),
+		time.Duration(config.GetConfig().Api.TxWaitTimeout)*time.Second,
+	)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	// Watch for blocks before submitting, so that we can't miss the block with
+	// the TX
+	var waiter *txBlockWaiter
+	defer func() {
+		if waiter != nil {
+			txWatcher.unregister(waiter)
+		}
+	}()
+	prepare := func(txId string, _ ledger.Transaction) bool {
+		if confirmations == 0 {
+			return true
+		}
+		waiter, err = txWatcher.register(txId)
+		if err != nil {
+			respondNodeUnavailable(c, err)
+			return false
+		}
+		return true
+	}
+	submitResp, ok := submitTx(c, prepare)
+	if !ok {
+		return
+	}
+	resp := responseLocalTxSubmissionWait{
+		TxId:   submitResp.TxId,
+		Status: submitResp.Status,
+		Stage:  txStageSubmitted,
+	}
+	if waitForTx(c, &resp, waiter, confirmations) {
+		respondJson(c, 200, resp)
+		return
+	}
+	if abortDisconnected(c) {
+		return
+	}
+	resp.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	respondJson(c, 202, resp)
+}
+
+// waitForTx updates the response as the TX reaches each stage, and returns
+// whether it reached the requested one. It checks the mempool until the TX shows
+// up there, and then waits for the block confirmations if there are any
+func waitForTx(
+	c *gin.Context,
+	resp *responseLocalTxSubmissionWait,
+	waiter *txBlockWaiter,
+	confirmations uint64,
+) bool {
+	ctx := c.Request.Context()
+	oConn, err := node.GetPooledConnection(ctx)
+	if err != nil {
+		resp.Error = err.Error()
+		return false
+	}
+	defer oConn.Close()
+	var updates <-chan struct{}
+	if waiter != nil {
+		updates = waiter.updates
+	}
+	pollTimer := time.NewTimer(0)
+	defer pollTimer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-streamShutdownChan():
+			resp.Error = "server shutting down"
+			return false
+		case <-pollTimer.C:
+			if txInMempool(c, oConn, resp.TxId) {
+				if resp.Stage == txStageSubmitted {
+					resp.Stage = txStageMempool
+				}
+				if confirmations == 0 {
+					return true
+				}
+			} else if resp.Stage == txStageSubmitted {
+				pollTimer.Reset(txWaitMempoolPollInterval)
+			}
+		case <-updates:
+			block, blockConfirmations, err := txWatcher.state(waiter)
+			if block != nil {
+				resp.Stage = txStageBlock
+				resp.Block = block
+				resp.Confirmations = blockConfirmations
+				if blockConfirmations >= confirmations {
+					return true
+				}
+			} else if resp.Stage == txStageBlock {
+				// The block was rolled back, so the TX is back in the mempool
+				resp.Stage = txStageMempool
+				resp.Block = nil
+				resp.Confirmations = 0
+			}
+			if err != nil {
+				resp.Error = err.Error()
+				return false
+			}
+		}
+	}
+}
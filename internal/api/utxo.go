@@ -0,0 +1,281 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"golang.org/x/crypto/blake2b"
+)
+
+type responseUtxo struct {
+	TxHash              string              `json:"tx_hash"                         example:"9a5b7b4a3a89e0a1f0fc1d1da1dbd58e3894c7a14ea2cbcc89cd6e3a4f9f1f5f"`
+	OutputIndex         uint32              `json:"output_index"                    example:"0"`
+	Address             string              `json:"address"                         example:"addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"`
+	Lovelace            uint64              `json:"lovelace"                        example:"1500000"`
+	Assets              []responseUtxoAsset `json:"assets"`
+	DatumHash           string              `json:"datum_hash,omitempty"            example:"923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"`
+	InlineDatum         string              `json:"inline_datum,omitempty"          example:"d87980"`
+	ReferenceScriptHash string              `json:"reference_script_hash,omitempty" example:"e9c5bd6d1b4fb5ff9b4e5c7f4b5e1d1a8c2a6f3c5e7d9b1a3c5e7f91"`
+}
+
+type responseUtxoAsset struct {
+	PolicyId string `json:"policy_id"      example:"29d222ce763455e3d7a09a665ce554f00ac89d2e99a1a83d267170c6"`
+	Name     string `json:"name,omitempty" example:"MIN"`
+	NameHex  string `json:"name_hex"       example:"4d494e"`
+	Quantity uint64 `json:"quantity"       example:"1000"`
+}
+
+// Script type prefixes for script hashes
+const (
+	scriptTypeNative   = 0
+	scriptTypePlutusV1 = 1
+	scriptTypePlutusV2 = 2
+	scriptTypePlutusV3 = 3
+)
+
+// filteredUtxoIds returns the IDs of the UTxOs from a query result that pass the
+// filter, in a stable order
+func filteredUtxoIds(
+	utxos map[localstatequery.UtxoId]ledger.BabbageTransactionOutput,
+	filter *utxoFilter,
+) []localstatequery.UtxoId {
+	ret := make([]localstatequery.UtxoId, 0, len(utxos))
+	for utxoId, output := range utxos {
+		if filter.match(output) {
+			ret = append(ret, utxoId)
+		}
+	}
+	slices.SortFunc(ret, compareUtxoIds)
+	return ret
+}
+
+func newResponseUtxo(
+	utxoId localstatequery.UtxoId,
+	output ledger.BabbageTransactionOutput,
+) (responseUtxo, error) {
+	ret := responseUtxo{
+		TxHash:      utxoId.Hash.String(),
+		OutputIndex: uint32(utxoId.Idx),
+		Address:     output.Address().String(),
+		Lovelace:    output.Amount(),
+		Assets:      newResponseUtxoAssets(output.Assets()),
+	}
+	if output.DatumOption != nil {
+		if datumHash := output.DatumHash(); datumHash != nil {
+			ret.DatumHash = datumHash.String()
+		}
+		if datum := output.Datum(); datum != nil {
+			ret.InlineDatum = hex.EncodeToString(datum.Cbor())
+		}
+	} else if outputCbor := output.Cbor(); len(outputCbor) > 0 &&
+		outputCbor[0]&0xe0 == 0x80 {
+		// gouroboros drops the datum hash from legacy (array) outputs when
+		// decoding them as Babbage outputs
+		var legacyOutput ledger.AlonzoTransactionOutput
+		if _, err := cbor.Decode(outputCbor, &legacyOutput); err != nil {
+			return ret, fmt.Errorf("failure decoding legacy output: %s", err)
+		}
+		if legacyOutput.TxOutputDatumHash != nil {
+			ret.DatumHash = legacyOutput.TxOutputDatumHash.String()
+		}
+	}
+	if output.ScriptRef != nil {
+		scriptHash, err := referenceScriptHash(output.ScriptRef)
+		if err != nil {
+			return ret, err
+		}
+		ret.ReferenceScriptHash = scriptHash
+	}
+	return ret, nil
+}
+
+// newResponseUtxoAssets returns the assets in an output, sorted by policy ID and
+// asset name
+func newResponseUtxoAssets(
+	assets *ledger.MultiAsset[ledger.MultiAssetTypeOutput],
+) []responseUtxoAsset {
+	ret := []responseUtxoAsset{}
+	if assets == nil {
+		return ret
+	}
+	policyIds := assets.Policies()
+	slices.SortFunc(policyIds, func(a, b ledger.Blake2b224) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for _, policyId := range policyIds {
+		assetNames := assets.Assets(policyId)
+		slices.SortFunc(assetNames, bytes.Compare)
+		for _, assetName := range assetNames {
+			tmpAsset := responseUtxoAsset{
+				PolicyId: policyId.String(),
+				NameHex:  hex.EncodeToString(assetName),
+				Quantity: assets.Asset(policyId, assetName),
+			}
+			// Asset names are arbitrary bytes, so we only decode them when
+			// they're valid text
+			if utf8.Valid(assetName) {
+				tmpAsset.Name = string(assetName)
+			}
+			ret = append(ret, tmpAsset)
+		}
+	}
+	return ret
+}
+
+// referenceScriptHash returns the hash of an output's reference script, which is
+// a CBOR-in-CBOR script with its type
+func referenceScriptHash(scriptRef *cbor.Tag) (string, error) {
+	scriptRefCbor, ok := scriptRef.Content.([]byte)
+	if !ok {
+		return "", fmt.Errorf(
+			"unexpected reference script type: %T",
+			scriptRef.Content,
+		)
+	}
+	var script struct {
+		cbor.StructAsArray
+		Type   uint
+		Script cbor.RawMessage
+	}
+	if _, err := cbor.Decode(scriptRefCbor, &script); err != nil {
+		return "", fmt.Errorf("failure decoding reference script: %s", err)
+	}
+	// Native scripts are hashed as CBOR, and Plutus scripts as the bytes in
+	// their CBOR bytestring
+	scriptBytes := []byte(script.Script)
+	switch script.Type {
+	case scriptTypeNative:
+	case scriptTypePlutusV1, scriptTypePlutusV2, scriptTypePlutusV3:
+		if _, err := cbor.Decode(script.Script, &scriptBytes); err != nil {
+			return "", fmt.Errorf("failure decoding reference script: %s", err)
+		}
+	default:
+		return "", fmt.Errorf("unknown reference script type: %d", script.Type)
+	}
+	hash, err := blake2b.New(len(ledger.Blake2b224{}), nil)
+	if err != nil {
+		return "", err
+	}
+	hash.Write([]byte{byte(script.Type)})
+	hash.Write(scriptBytes)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// utxoFilter selects UTxOs by the assets and lovelace that they hold
+type utxoFilter struct {
+	policyId    *ledger.Blake2b224
+	assetName   []byte
+	minLovelace uint64
+}
+
+// newUtxoFilter returns a filter from a hex policy ID, an asset in the form
+// policyId.assetNameHex, and a minimum lovelace amount. The policy ID and asset
+// are optional
+func newUtxoFilter(
+	policyId string,
+	asset string,
+	minLovelace uint64,
+) (*utxoFilter, error) {
+	ret := &utxoFilter{minLovelace: minLovelace}
+	if policyId != "" && asset != "" {
+		return nil, fmt.Errorf("only one of policy_id and asset can be specified")
+	}
+	if asset != "" {
+		var assetNameHex string
+		var ok bool
+		policyId, assetNameHex, ok = strings.Cut(asset, ".")
+		if !ok {
+			return nil, fmt.Errorf(
+				"invalid asset, must be policyId.assetNameHex: %s",
+				asset,
+			)
+		}
+		assetName, err := hex.DecodeString(assetNameHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset name: %s", assetNameHex)
+		}
+		ret.assetName = assetName
+	}
+	if policyId != "" {
+		policyIdBytes, err := hex.DecodeString(policyId)
+		if err != nil || len(policyIdBytes) != len(ledger.Blake2b224{}) {
+			return nil, fmt.Errorf("invalid policy ID: %s", policyId)
+		}
+		tmpPolicyId := ledger.NewBlake2b224(policyIdBytes)
+		ret.policyId = &tmpPolicyId
+	}
+	return ret, nil
+}
+
+// match returns whether an output passes the filter
+func (f *utxoFilter) match(output ledger.BabbageTransactionOutput) bool {
+	if output.Amount() < f.minLovelace {
+		return false
+	}
+	if f.policyId == nil {
+		return true
+	}
+	assets := output.Assets()
+	if assets == nil {
+		return false
+	}
+	if f.assetName != nil {
+		return assets.Asset(*f.policyId, f.assetName) > 0
+	}
+	return assets.Assets(*f.policyId) != nil
+}
+
+// encodeUtxoCursor returns an opaque pagination cursor for a UTxO ID, which
+// encodes the TX input
+func encodeUtxoCursor(utxoId localstatequery.UtxoId) string {
+	data := binary.BigEndian.AppendUint32(
+		slices.Clone(utxoId.Hash[:]),
+		uint32(utxoId.Idx),
+	)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUtxoCursor returns the UTxO ID encoded in a pagination cursor
+func decodeUtxoCursor(cursor string) (localstatequery.UtxoId, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	hashSize := len(ledger.Blake2b256{})
+	if err != nil || len(data) != hashSize+4 {
+		return localstatequery.UtxoId{}, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return localstatequery.UtxoId{
+		Hash: ledger.NewBlake2b256(data[:hashSize]),
+		Idx:  int(binary.BigEndian.Uint32(data[hashSize:])),
+	}, nil
+}
+
+// compareUtxoIds orders UTxO IDs by TX hash and output index
+func compareUtxoIds(a, b localstatequery.UtxoId) int {
+	if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Idx, b.Idx)
+}
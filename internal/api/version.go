@@ -0,0 +1,77 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+	"github.com/blinklabs-io/cardano-node-api/internal/version"
+)
+
+const gouroborosModulePath = "github.com/blinklabs-io/gouroboros"
+
+type responseVersion struct {
+	Version           string  `json:"version"`
+	CommitHash        string  `json:"commit_hash"`
+	BuildDate         string  `json:"build_date"`
+	GoVersion         string  `json:"go_version"`
+	GouroborosVersion string  `json:"gouroboros_version"`
+	ProtocolVersion   *uint16 `json:"protocol_version"`
+	NetworkMagic      *uint32 `json:"network_magic"`
+}
+
+// handleVersion returns version and build info. The node protocol version and
+// network magic are null until a node connection has been established
+func handleVersion(c *gin.Context) {
+	resp := responseVersion{
+		Version:           version.Version,
+		CommitHash:        version.CommitHash,
+		BuildDate:         version.BuildDate,
+		GoVersion:         runtime.Version(),
+		GouroborosVersion: version.GetDependencyVersion(gouroborosModulePath),
+	}
+	if resp.Version == "" {
+		resp.Version = "devel"
+	}
+	if connInfo := node.GetConnectionInfo(); connInfo != nil {
+		resp.ProtocolVersion = &connInfo.ProtocolVersion
+		resp.NetworkMagic = &connInfo.NetworkMagic
+	}
+	c.JSON(200, resp)
+}
+
+// setBuildInfoMetric sets the build info gauge, which always has the value 1
+func setBuildInfoMetric() {
+	versionString := version.Version
+	if versionString == "" {
+		versionString = "devel"
+	}
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricBuildInfo).
+		SetGaugeValue(
+			[]string{
+				versionString,
+				version.CommitHash,
+				version.BuildDate,
+				runtime.Version(),
+				version.GetDependencyVersion(gouroborosModulePath),
+			},
+			1,
+		)
+}
@@ -15,8 +15,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"strings"
 
 	"github.com/blinklabs-io/gouroboros"
 	"github.com/kelseyhightower/envconfig"
@@ -33,13 +35,267 @@ type Config struct {
 }
 
 type LoggingConfig struct {
-	Healthchecks bool   `yaml:"healthchecks" envconfig:"LOGGING_HEALTHCHECKS"`
-	Level        string `yaml:"level"        envconfig:"LOGGING_LEVEL"`
+	Healthchecks          bool              `yaml:"healthchecks"          envconfig:"LOGGING_HEALTHCHECKS"`
+	Level                 string            `yaml:"level"                 envconfig:"LOGGING_LEVEL"`
+	SkipPaths             []string          `yaml:"skipPaths"             envconfig:"LOGGING_SKIP_PATHS"`
+	SampleRates           map[string]uint   `yaml:"sampleRates"           envconfig:"LOGGING_SAMPLE_RATES"`
+	AccessLogFields       []string          `yaml:"accessLogFields"       envconfig:"LOGGING_ACCESS_LOG_FIELDS"`
+	Levels                map[string]string `yaml:"levels"                envconfig:"LOGGING_LEVELS"`
+	Format                string            `yaml:"format"                envconfig:"LOGGING_FORMAT"`
+	AccessFormat          string            `yaml:"accessFormat"          envconfig:"LOGGING_ACCESS_FORMAT"`
+	OutputPath            string            `yaml:"outputPath"            envconfig:"LOGGING_OUTPUT_PATH"`
+	AccessOutputPath      string            `yaml:"accessOutputPath"      envconfig:"LOGGING_ACCESS_OUTPUT_PATH"`
+	MaxSize               uint              `yaml:"maxSize"               envconfig:"LOGGING_MAX_SIZE"`
+	MaxAge                uint              `yaml:"maxAge"                envconfig:"LOGGING_MAX_AGE"`
+	MaxBackups            uint              `yaml:"maxBackups"            envconfig:"LOGGING_MAX_BACKUPS"`
+	TxSubmitDebug         bool              `yaml:"txSubmitDebug"         envconfig:"LOGGING_TX_SUBMIT_DEBUG"`
+	TxSubmitDebugMaxBytes uint              `yaml:"txSubmitDebugMaxBytes" envconfig:"LOGGING_TX_SUBMIT_DEBUG_MAX_BYTES"`
+}
+
+// Supported log encodings
+const (
+	LogFormatJson    = "json"
+	LogFormatConsole = "console"
+)
+
+// Optional fields for the access log
+const (
+	AccessLogFieldStatus       = "status"
+	AccessLogFieldMethod       = "method"
+	AccessLogFieldPath         = "path"
+	AccessLogFieldQuery        = "query"
+	AccessLogFieldIp           = "ip"
+	AccessLogFieldUserAgent    = "user-agent"
+	AccessLogFieldLatency      = "latency"
+	AccessLogFieldTime         = "time"
+	AccessLogFieldResponseSize = "response_size"
+	AccessLogFieldRequestId    = "request_id"
+	AccessLogFieldClientCN     = "client_cn"
+	AccessLogFieldAuthSubject  = "auth_subject"
+	AccessLogFieldTrace        = "trace"
+	AccessLogFieldTxHash       = "tx_hash"
+)
+
+// AccessLogFields lists all of the available access log fields
+var AccessLogFields = []string{
+	AccessLogFieldStatus,
+	AccessLogFieldMethod,
+	AccessLogFieldPath,
+	AccessLogFieldQuery,
+	AccessLogFieldIp,
+	AccessLogFieldUserAgent,
+	AccessLogFieldLatency,
+	AccessLogFieldTime,
+	AccessLogFieldResponseSize,
+	AccessLogFieldRequestId,
+	AccessLogFieldClientCN,
+	AccessLogFieldAuthSubject,
+	AccessLogFieldTrace,
+	AccessLogFieldTxHash,
 }
 
 type ApiConfig struct {
-	ListenAddress string `yaml:"address" envconfig:"API_LISTEN_ADDRESS"`
-	ListenPort    uint   `yaml:"port"    envconfig:"API_LISTEN_PORT"`
+	ListenAddress          string                `yaml:"address"                envconfig:"API_LISTEN_ADDRESS"`
+	ListenPort             uint                  `yaml:"port"                   envconfig:"API_LISTEN_PORT"`
+	ListenSocket           string                `yaml:"socket"                 envconfig:"API_LISTEN_SOCKET"`
+	ListenSocketMode       string                `yaml:"socketMode"             envconfig:"API_LISTEN_SOCKET_MODE"`
+	ListenSocketOwner      string                `yaml:"socketOwner"            envconfig:"API_LISTEN_SOCKET_OWNER"`
+	BasePath               string                `yaml:"basePath"               envconfig:"API_BASE_PATH"`
+	HealthcheckTimeout     uint                  `yaml:"healthcheckTimeout"     envconfig:"HEALTHCHECK_TIMEOUT"`
+	ReadyzMaxSlotLag       uint                  `yaml:"readyzMaxSlotLag"       envconfig:"API_READYZ_MAX_SLOT_LAG"`
+	ShutdownTimeout        uint                  `yaml:"shutdownTimeout"        envconfig:"API_SHUTDOWN_TIMEOUT"`
+	RequestTimeout         uint                  `yaml:"requestTimeout"         envconfig:"API_REQUEST_TIMEOUT"`
+	RequestTimeouts        map[string]uint       `yaml:"requestTimeouts"        envconfig:"API_REQUEST_TIMEOUTS"`
+	ErrorRequestId         bool                  `yaml:"errorRequestId"         envconfig:"API_ERROR_REQUEST_ID"`
+	TrustedProxies         []string              `yaml:"trustedProxies"         envconfig:"API_TRUSTED_PROXIES"`
+	ClientIpHeader         string                `yaml:"clientIpHeader"         envconfig:"API_CLIENT_IP_HEADER"`
+	UnversionedRoutes      bool                  `yaml:"unversionedRoutes"      envconfig:"API_UNVERSIONED_ROUTES"`
+	UnversionedDeprecation bool                  `yaml:"unversionedDeprecation" envconfig:"API_UNVERSIONED_DEPRECATION"`
+	MaxUtxoTxIns           uint                  `yaml:"maxUtxoTxIns"           envconfig:"API_MAX_UTXO_TX_INS"`
+	MaxStakeAccounts       uint                  `yaml:"maxStakeAccounts"       envconfig:"API_MAX_STAKE_ACCOUNTS"`
+	MaxTxSubmitBytes       uint                  `yaml:"maxTxSubmitBytes"       envconfig:"API_MAX_TX_SUBMIT_BYTES"`
+	MaxTxBatchItems        uint                  `yaml:"maxTxBatchItems"        envconfig:"API_MAX_TX_BATCH_ITEMS"`
+	MaxTxBatchBytes        uint                  `yaml:"maxTxBatchBytes"        envconfig:"API_MAX_TX_BATCH_BYTES"`
+	TxSizeCheck            bool                  `yaml:"txSizeCheck"            envconfig:"API_TX_SIZE_CHECK"`
+	TxWaitTimeout          uint                  `yaml:"txWaitTimeout"          envconfig:"API_TX_WAIT_TIMEOUT"`
+	SubmitApiCompat        bool                  `yaml:"submitApiCompat"        envconfig:"API_SUBMIT_API_COMPAT"`
+	Server                 ServerConfig          `yaml:"server"`
+	Tls                    TlsConfig             `yaml:"tls"`
+	Auth                   AuthConfig            `yaml:"auth"`
+	RateLimit              RateLimitConfig       `yaml:"rateLimit"`
+	Cors                   CorsConfig            `yaml:"cors"`
+	Compression            CompressionConfig     `yaml:"compression"`
+	Convert                ConvertConfig         `yaml:"convert"`
+	Idempotency            IdempotencyConfig     `yaml:"idempotency"`
+	TxCallback             TxCallbackConfig      `yaml:"txCallback"`
+	MempoolStream          MempoolStreamConfig   `yaml:"mempoolStream"`
+	ChainSyncStream        ChainSyncStreamConfig `yaml:"chainSyncStream"`
+	RawQuery               RawQueryConfig        `yaml:"rawQuery"`
+}
+
+// ServerConfig holds the HTTP server limits for the API and metrics listeners. The
+// timeouts are in seconds, and 0 disables the timeout
+type ServerConfig struct {
+	ReadTimeout       uint `yaml:"readTimeout"       envconfig:"API_READ_TIMEOUT"`
+	ReadHeaderTimeout uint `yaml:"readHeaderTimeout" envconfig:"API_READ_HEADER_TIMEOUT"`
+	WriteTimeout      uint `yaml:"writeTimeout"      envconfig:"API_WRITE_TIMEOUT"`
+	IdleTimeout       uint `yaml:"idleTimeout"       envconfig:"API_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int  `yaml:"maxHeaderBytes"    envconfig:"API_MAX_HEADER_BYTES"`
+}
+
+// CompressionConfig controls gzip compression of API responses. Responses smaller
+// than MinSize bytes are sent uncompressed
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" envconfig:"API_COMPRESSION_ENABLED"`
+	MinSize uint `yaml:"minSize" envconfig:"API_COMPRESSION_MIN_SIZE"`
+}
+
+// ConvertConfig controls the slot and time conversion endpoints. The era history
+// from the node is cached for CacheTtl seconds. Conversions past the end of the
+// era history are marked as projected, or rejected if AllowProjected is false
+type ConvertConfig struct {
+	CacheTtl       uint `yaml:"cacheTtl"       envconfig:"API_CONVERT_CACHE_TTL"`
+	AllowProjected bool `yaml:"allowProjected" envconfig:"API_CONVERT_ALLOW_PROJECTED"`
+}
+
+// IdempotencyConfig controls the Idempotency-Key header support for TX submission.
+// The responses for up to CacheSize keys are remembered for CacheTtl seconds, and a
+// CacheSize of 0 disables it. If DuplicateSuccess is true, a rejected TX that's
+// already in the mempool is returned as a success
+type IdempotencyConfig struct {
+	CacheSize        uint `yaml:"cacheSize"        envconfig:"API_IDEMPOTENCY_CACHE_SIZE"`
+	CacheTtl         uint `yaml:"cacheTtl"         envconfig:"API_IDEMPOTENCY_CACHE_TTL"`
+	DuplicateSuccess bool `yaml:"duplicateSuccess" envconfig:"API_IDEMPOTENCY_DUPLICATE_SUCCESS"`
+}
+
+// TxCallbackConfig controls the webhooks for TX submissions with a callback URL,
+// which are sent when the TX is included in a block with enough confirmations or
+// expires. Callbacks are signed with an HMAC of the body using the secret, which
+// can also be read from a file. Up to MaxWatches TXs are watched at a time, for
+// no longer than MaxWatchTime seconds. An empty list of allowed hosts allows
+// callbacks to any host
+type TxCallbackConfig struct {
+	Enabled       bool     `yaml:"enabled"       envconfig:"API_TX_CALLBACK_ENABLED"`
+	Secret        string   `yaml:"secret"        envconfig:"API_TX_CALLBACK_SECRET"`
+	SecretFile    string   `yaml:"secretFile"    envconfig:"API_TX_CALLBACK_SECRET_FILE"`
+	AllowedHosts  []string `yaml:"allowedHosts"  envconfig:"API_TX_CALLBACK_ALLOWED_HOSTS"`
+	MaxWatches    uint     `yaml:"maxWatches"    envconfig:"API_TX_CALLBACK_MAX_WATCHES"`
+	MaxWatchTime  uint     `yaml:"maxWatchTime"  envconfig:"API_TX_CALLBACK_MAX_WATCH_TIME"`
+	Confirmations uint     `yaml:"confirmations" envconfig:"API_TX_CALLBACK_CONFIRMATIONS"`
+	RetryAttempts uint     `yaml:"retryAttempts" envconfig:"API_TX_CALLBACK_RETRY_ATTEMPTS"`
+	Timeout       uint     `yaml:"timeout"       envconfig:"API_TX_CALLBACK_TIMEOUT"`
+}
+
+// MempoolStreamConfig controls the mempool event streams, over server-sent events
+// and websockets. Up to MaxStreams streams of either kind are served at a time,
+// each on its own node connection, and a MaxStreams of 0 disables them. A
+// heartbeat comment or websocket ping is sent every HeartbeatInterval seconds.
+// Websocket clients that fall SendBuffer messages behind are disconnected
+type MempoolStreamConfig struct {
+	MaxStreams        uint `yaml:"maxStreams"        envconfig:"API_MEMPOOL_STREAM_MAX_STREAMS"`
+	HeartbeatInterval uint `yaml:"heartbeatInterval" envconfig:"API_MEMPOOL_STREAM_HEARTBEAT_INTERVAL"`
+	SendBuffer        uint `yaml:"sendBuffer"        envconfig:"API_MEMPOOL_STREAM_SEND_BUFFER"`
+}
+
+// RawQueryConfig controls the raw LocalStateQuery endpoint, which sends any query
+// given as CBOR to the node, so it's disabled unless Enabled is set. Query
+// bodies over MaxBytes are rejected
+type RawQueryConfig struct {
+	Enabled  bool `yaml:"enabled"  envconfig:"API_RAW_QUERY_ENABLED"`
+	MaxBytes uint `yaml:"maxBytes" envconfig:"API_RAW_QUERY_MAX_BYTES"`
+}
+
+// ChainSyncStreamConfig controls the chain-sync event stream. Up to MaxStreams
+// streams are served at a time, each following the chain on its own node
+// connection, and a MaxStreams of 0 disables it. A heartbeat comment is sent
+// every HeartbeatInterval seconds
+type ChainSyncStreamConfig struct {
+	MaxStreams        uint `yaml:"maxStreams"        envconfig:"API_CHAINSYNC_STREAM_MAX_STREAMS"`
+	HeartbeatInterval uint `yaml:"heartbeatInterval" envconfig:"API_CHAINSYNC_STREAM_HEARTBEAT_INTERVAL"`
+}
+
+// CorsConfig controls the CORS headers sent for the API endpoints. No CORS headers
+// are sent unless allowed origins are configured
+type CorsConfig struct {
+	AllowedOrigins   []string `yaml:"allowedOrigins"   envconfig:"API_CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowedMethods"   envconfig:"API_CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowedHeaders"   envconfig:"API_CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool     `yaml:"allowCredentials" envconfig:"API_CORS_ALLOW_CREDENTIALS"`
+	MaxAge           uint     `yaml:"maxAge"           envconfig:"API_CORS_MAX_AGE"`
+}
+
+// RateLimitConfig controls per-client request rate limits. The submit limits apply
+// to the localtxsubmission endpoints, the light limits apply to cheap endpoints
+// that clients poll, and the others apply to the rest of the API. A rate of 0
+// disables limiting, except that cheap endpoints get the default limits when the
+// light rate is 0
+type RateLimitConfig struct {
+	RequestsPerSecond       float64 `yaml:"requestsPerSecond"       envconfig:"API_RATE_LIMIT_RPS"`
+	Burst                   int     `yaml:"burst"                   envconfig:"API_RATE_LIMIT_BURST"`
+	SubmitRequestsPerSecond float64 `yaml:"submitRequestsPerSecond" envconfig:"API_RATE_LIMIT_SUBMIT_RPS"`
+	SubmitBurst             int     `yaml:"submitBurst"             envconfig:"API_RATE_LIMIT_SUBMIT_BURST"`
+	LightRequestsPerSecond  float64 `yaml:"lightRequestsPerSecond"  envconfig:"API_RATE_LIMIT_LIGHT_RPS"`
+	LightBurst              int     `yaml:"lightBurst"              envconfig:"API_RATE_LIMIT_LIGHT_BURST"`
+}
+
+type TlsConfig struct {
+	CertFilePath      string `yaml:"certFile"          envconfig:"API_TLS_CERT_FILE"`
+	KeyFilePath       string `yaml:"keyFile"           envconfig:"API_TLS_KEY_FILE"`
+	ClientCaFilePath  string `yaml:"clientCaFile"      envconfig:"API_TLS_CLIENT_CA_FILE"`
+	ExemptHealthcheck bool   `yaml:"exemptHealthcheck" envconfig:"API_TLS_EXEMPT_HEALTHCHECK"`
+}
+
+// Supported headers for reading the client IP from trusted proxies
+const (
+	ClientIpHeaderXForwardedFor = "x-forwarded-for"
+	ClientIpHeaderXRealIp       = "x-real-ip"
+	ClientIpHeaderForwarded     = "forwarded"
+)
+
+// Supported API authentication modes
+const (
+	AuthModeNone   = "none"
+	AuthModeApiKey = "apikey"
+	AuthModeJwt    = "jwt"
+	AuthModeBoth   = "both"
+)
+
+type AuthConfig struct {
+	// Mode defaults to "apikey" when API keys are configured and "none" otherwise
+	Mode        string         `yaml:"mode"        envconfig:"API_AUTH_MODE"`
+	ApiKeys     []ApiKeyConfig `yaml:"apiKeys"     envconfig:"API_KEYS"`
+	ApiKeysFile string         `yaml:"apiKeysFile" envconfig:"API_KEYS_FILE"`
+	Jwt         JwtConfig      `yaml:"jwt"`
+}
+
+type JwtConfig struct {
+	Issuer   string `yaml:"issuer"   envconfig:"API_AUTH_JWT_ISSUER"`
+	Audience string `yaml:"audience" envconfig:"API_AUTH_JWT_AUDIENCE"`
+	// JwksUrl is discovered from the issuer's OpenID configuration if not set
+	JwksUrl         string `yaml:"jwksUrl"         envconfig:"API_AUTH_JWT_JWKS_URL"`
+	RefreshInterval uint   `yaml:"refreshInterval" envconfig:"API_AUTH_JWT_REFRESH_INTERVAL"`
+}
+
+// ApiKeyConfig is an API key and the API route groups that it can access. An
+// empty list of groups allows access to all route groups
+type ApiKeyConfig struct {
+	Key    string   `yaml:"key"`
+	Groups []string `yaml:"groups"`
+}
+
+// Decode parses an API key from an environment variable value of the form
+// KEY or KEY:GROUP1|GROUP2
+func (a *ApiKeyConfig) Decode(value string) error {
+	key, groups, _ := strings.Cut(value, ":")
+	if key == "" {
+		return fmt.Errorf("empty API key")
+	}
+	a.Key = key
+	a.Groups = nil
+	if groups != "" {
+		a.Groups = strings.Split(groups, "|")
+	}
+	return nil
 }
 
 type DebugConfig struct {
@@ -48,18 +304,45 @@ type DebugConfig struct {
 }
 
 type MetricsConfig struct {
-	ListenAddress string `yaml:"address" envconfig:"METRICS_LISTEN_ADDRESS"`
-	ListenPort    uint   `yaml:"port"    envconfig:"METRICS_LISTEN_PORT"`
+	ListenAddress       string    `yaml:"address"             envconfig:"METRICS_LISTEN_ADDRESS"`
+	ListenPort          uint      `yaml:"port"                envconfig:"METRICS_LISTEN_PORT"`
+	ListenSocket        string    `yaml:"socket"              envconfig:"METRICS_LISTEN_SOCKET"`
+	ListenSocketMode    string    `yaml:"socketMode"          envconfig:"METRICS_LISTEN_SOCKET_MODE"`
+	ListenSocketOwner   string    `yaml:"socketOwner"         envconfig:"METRICS_LISTEN_SOCKET_OWNER"`
+	Path                string    `yaml:"path"                envconfig:"METRICS_PATH"`
+	ApiPath             string    `yaml:"apiPath"             envconfig:"METRICS_API_PATH"`
+	ExcludePaths        []string  `yaml:"excludePaths"        envconfig:"METRICS_EXCLUDE_PATHS"`
+	DurationBuckets     []float64 `yaml:"durationBuckets"     envconfig:"METRICS_DURATION_BUCKETS"`
+	SizeBuckets         []float64 `yaml:"sizeBuckets"         envconfig:"METRICS_SIZE_BUCKETS"`
+	MempoolPoll         bool      `yaml:"mempoolPoll"         envconfig:"METRICS_MEMPOOL_POLL"`
+	MempoolPollInterval uint      `yaml:"mempoolPollInterval" envconfig:"METRICS_MEMPOOL_POLL_INTERVAL"`
+	TipPoll             bool      `yaml:"tipPoll"             envconfig:"METRICS_TIP_POLL"`
+	TipPollInterval     uint      `yaml:"tipPollInterval"     envconfig:"METRICS_TIP_POLL_INTERVAL"`
 }
 
 type NodeConfig struct {
-	Network      string `yaml:"network"      envconfig:"CARDANO_NETWORK"`
-	NetworkMagic uint32 `yaml:"networkMagic" envconfig:"CARDANO_NODE_NETWORK_MAGIC"`
-	Address      string `yaml:"address"      envconfig:"CARDANO_NODE_SOCKET_TCP_HOST"`
-	Port         uint   `yaml:"port"         envconfig:"CARDANO_NODE_SOCKET_TCP_PORT"`
-	QueryTimeout uint   `yaml:"queryTimeout" envconfig:"CARDANO_NODE_SOCKET_QUERY_TIMEOUT"`
-	SocketPath   string `yaml:"socketPath"   envconfig:"CARDANO_NODE_SOCKET_PATH"`
-	Timeout      uint   `yaml:"timeout"      envconfig:"CARDANO_NODE_SOCKET_TIMEOUT"`
+	Network                string   `yaml:"network"                envconfig:"CARDANO_NETWORK"`
+	NetworkMagic           uint32   `yaml:"networkMagic"           envconfig:"CARDANO_NODE_NETWORK_MAGIC"`
+	Address                string   `yaml:"address"                envconfig:"CARDANO_NODE_SOCKET_TCP_HOST"`
+	Port                   uint     `yaml:"port"                   envconfig:"CARDANO_NODE_SOCKET_TCP_PORT"`
+	QueryTimeout           uint     `yaml:"queryTimeout"           envconfig:"CARDANO_NODE_SOCKET_QUERY_TIMEOUT"`
+	SocketPath             string   `yaml:"socketPath"             envconfig:"CARDANO_NODE_SOCKET_PATH"`
+	Timeout                uint     `yaml:"timeout"                envconfig:"CARDANO_NODE_SOCKET_TIMEOUT"`
+	RetryMaxInterval       uint     `yaml:"retryMaxInterval"       envconfig:"CARDANO_NODE_RETRY_MAX_INTERVAL"`
+	RetryMaxAttempts       uint     `yaml:"retryMaxAttempts"       envconfig:"CARDANO_NODE_RETRY_MAX_ATTEMPTS"`
+	PoolMinSize            uint     `yaml:"poolMinSize"            envconfig:"CARDANO_NODE_POOL_MIN_SIZE"`
+	PoolMaxSize            uint     `yaml:"poolMaxSize"            envconfig:"CARDANO_NODE_POOL_MAX_SIZE"`
+	PoolIdleTimeout        uint     `yaml:"poolIdleTimeout"        envconfig:"CARDANO_NODE_POOL_IDLE_TIMEOUT"`
+	Endpoints              []string `yaml:"endpoints"              envconfig:"CARDANO_NODE_ENDPOINTS"`
+	FailbackInterval       uint     `yaml:"failbackInterval"       envconfig:"CARDANO_NODE_FAILBACK_INTERVAL"`
+	LocalStateQueryTimeout uint     `yaml:"localStateQueryTimeout" envconfig:"CARDANO_NODE_LOCAL_STATE_QUERY_TIMEOUT"`
+	LocalTxMonitorTimeout  uint     `yaml:"localTxMonitorTimeout"  envconfig:"CARDANO_NODE_LOCAL_TX_MONITOR_TIMEOUT"`
+	TxSubmissionTimeout    uint     `yaml:"txSubmissionTimeout"    envconfig:"CARDANO_NODE_TX_SUBMISSION_TIMEOUT"`
+	BreakerFailures        uint     `yaml:"breakerFailures"        envconfig:"CARDANO_NODE_BREAKER_FAILURES"`
+	BreakerWindow          uint     `yaml:"breakerWindow"          envconfig:"CARDANO_NODE_BREAKER_WINDOW"`
+	BreakerProbeInterval   uint     `yaml:"breakerProbeInterval"   envconfig:"CARDANO_NODE_BREAKER_PROBE_INTERVAL"`
+	ProtocolVersion        uint     `yaml:"protocolVersion"        envconfig:"CARDANO_NODE_PROTOCOL_VERSION"`
+	MaxProtocolVersion     uint     `yaml:"maxProtocolVersion"     envconfig:"CARDANO_NODE_MAX_PROTOCOL_VERSION"`
 }
 
 type UtxorpcConfig struct {
@@ -67,67 +350,286 @@ type UtxorpcConfig struct {
 	ListenPort    uint   `yaml:"port"    envconfig:"GRPC_LISTEN_PORT"`
 }
 
+// Used when neither a socket path nor TCP address is provided for the node
+const defaultNodeSocketPath = "/node-ipc/node.socket"
+
 // Singleton config instance with default values
-var globalConfig = &Config{
-	Logging: LoggingConfig{
-		Level:        "info",
-		Healthchecks: false,
-	},
-	Api: ApiConfig{
-		ListenAddress: "",
-		ListenPort:    8080,
-	},
-	Debug: DebugConfig{
-		ListenAddress: "localhost",
-		ListenPort:    0,
-	},
-	Metrics: MetricsConfig{
-		ListenAddress: "",
-		ListenPort:    8081,
-	},
-	Node: NodeConfig{
-		Network:      "mainnet",
-		SocketPath:   "/node-ipc/node.socket",
-		QueryTimeout: 180,
-		Timeout:      5,
-	},
-	Utxorpc: UtxorpcConfig{
-		ListenAddress: "",
-		ListenPort:    9090,
-	},
-}
-
-func Load(configFile string) (*Config, error) {
-	// Load config file as YAML if provided
+var globalConfig = defaultConfig()
+
+// defaultConfig returns a new config instance with default values
+func defaultConfig() *Config {
+	return &Config{
+		Logging: LoggingConfig{
+			Level:           "info",
+			Healthchecks:    false,
+			AccessLogFields: AccessLogFields,
+			Format:          LogFormatJson,
+			AccessFormat:    LogFormatJson,
+			MaxSize:         100,
+			// Larger than the maximum TX size
+			TxSubmitDebugMaxBytes: 32768,
+		},
+		Api: ApiConfig{
+			ListenAddress:      "",
+			ListenPort:         8080,
+			ListenSocketMode:   "0660",
+			HealthcheckTimeout: 5,
+			ShutdownTimeout:    10,
+			RequestTimeout:     30,
+			ClientIpHeader:     ClientIpHeaderXForwardedFor,
+			UnversionedRoutes:  true,
+			MaxUtxoTxIns:       100,
+			MaxStakeAccounts:   500,
+			// The mainnet max TX size plus some room to spare
+			MaxTxSubmitBytes: 16384 + 4096,
+			MaxTxBatchItems:  20,
+			// Room for the max number of TXs at the mainnet max TX size in hex
+			MaxTxBatchBytes: 1 << 20,
+			TxSizeCheck:     true,
+			// Long enough for a block on mainnet, but within the write timeout
+			TxWaitTimeout: 45,
+			Server: ServerConfig{
+				ReadTimeout:       30,
+				ReadHeaderTimeout: 10,
+				WriteTimeout:      60,
+				IdleTimeout:       120,
+				MaxHeaderBytes:    1 << 20,
+			},
+			Auth: AuthConfig{
+				Jwt: JwtConfig{
+					RefreshInterval: 3600,
+				},
+			},
+			Compression: CompressionConfig{
+				MinSize: 1024,
+			},
+			Convert: ConvertConfig{
+				CacheTtl:       60,
+				AllowProjected: true,
+			},
+			Cors: CorsConfig{
+				AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+				AllowedHeaders: []string{
+					"Authorization",
+					"Content-Type",
+					"Idempotency-Key",
+					"X-Api-Key",
+					"X-Callback-Url",
+				},
+				MaxAge: 600,
+			},
+			Idempotency: IdempotencyConfig{
+				CacheSize: 10000,
+				CacheTtl:  3600,
+			},
+			TxCallback: TxCallbackConfig{
+				MaxWatches: 1000,
+				// Long enough for the TTL of most TXs
+				MaxWatchTime:  7200,
+				Confirmations: 1,
+				RetryAttempts: 5,
+				Timeout:       10,
+			},
+			MempoolStream: MempoolStreamConfig{
+				MaxStreams:        10,
+				HeartbeatInterval: 15,
+				SendBuffer:        256,
+			},
+			ChainSyncStream: ChainSyncStreamConfig{
+				MaxStreams:        10,
+				HeartbeatInterval: 15,
+			},
+			RawQuery: RawQueryConfig{
+				MaxBytes: 65536,
+			},
+		},
+		Debug: DebugConfig{
+			ListenAddress: "localhost",
+			ListenPort:    0,
+		},
+		Metrics: MetricsConfig{
+			ListenAddress:       "",
+			ListenPort:          8081,
+			ListenSocketMode:    "0660",
+			Path:                "/",
+			DurationBuckets:     []float64{0.1, 0.3, 1.2, 5, 10},
+			SizeBuckets:         []float64{100, 1e3, 1e4, 1e5, 1e6, 1e7},
+			MempoolPoll:         true,
+			MempoolPollInterval: 30,
+			TipPoll:             true,
+			TipPollInterval:     10,
+		},
+		Node: NodeConfig{
+			Network:                "mainnet",
+			QueryTimeout:           180,
+			Timeout:                5,
+			RetryMaxInterval:       30,
+			PoolMinSize:            1,
+			PoolMaxSize:            16,
+			PoolIdleTimeout:        60,
+			FailbackInterval:       30,
+			LocalStateQueryTimeout: 15,
+			LocalTxMonitorTimeout:  15,
+			TxSubmissionTimeout:    60,
+			BreakerFailures:        5,
+			BreakerWindow:          30,
+			BreakerProbeInterval:   10,
+		},
+		Utxorpc: UtxorpcConfig{
+			ListenAddress: "",
+			ListenPort:    9090,
+		},
+	}
+}
+
+// Overrides holds values from command line flags, which take precedence over
+// all other config sources. Nil values are left alone
+type Overrides struct {
+	ApiAddress     *string
+	ApiPort        *uint
+	NodeSocketPath *string
+	NetworkMagic   *uint32
+	LoggingLevel   *string
+}
+
+func (o *Overrides) apply(cfg *Config) {
+	if o.ApiAddress != nil {
+		cfg.Api.ListenAddress = *o.ApiAddress
+	}
+	if o.ApiPort != nil {
+		cfg.Api.ListenPort = *o.ApiPort
+	}
+	if o.NodeSocketPath != nil {
+		cfg.Node.SocketPath = *o.NodeSocketPath
+	}
+	if o.NetworkMagic != nil {
+		cfg.Node.NetworkMagic = *o.NetworkMagic
+		// The network name would otherwise replace the network magic
+		cfg.Node.Network = ""
+	}
+	if o.LoggingLevel != nil {
+		cfg.Logging.Level = *o.LoggingLevel
+	}
+}
+
+func Load(
+	configFile string,
+	strict bool,
+	overrides *Overrides,
+) (*Config, error) {
+	if err := load(globalConfig, configFile, strict, overrides); err != nil {
+		return nil, err
+	}
+	// Keep the sources around for reloading
+	reloadSources = loadSources{
+		configFile: configFile,
+		strict:     strict,
+		overrides:  overrides,
+	}
+	return globalConfig, nil
+}
+
+// load populates a config from the config file, environment, and command line
+// flags, in that order
+func load(
+	cfg *Config,
+	configFile string,
+	strict bool,
+	overrides *Overrides,
+) error {
+	// Load config file if provided
 	if configFile != "" {
-		buf, err := os.ReadFile(configFile)
-		if err != nil {
-			return nil, fmt.Errorf("error reading config file: %s", err)
-		}
-		err = yaml.Unmarshal(buf, globalConfig)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing config file: %s", err)
+		if err := loadFile(cfg, configFile, strict); err != nil {
+			return err
 		}
 	}
 	// Load config values from environment variables
 	// We use "dummy" as the app name here to (mostly) prevent picking up env
 	// vars that we hadn't explicitly specified in annotations above
-	err := envconfig.Process("dummy", globalConfig)
+	err := envconfig.Process("dummy", cfg)
 	if err != nil {
-		return nil, fmt.Errorf("error processing environment: %s", err)
-	}
-	// Populate network magic value from network name
-	if globalConfig.Node.Network != "" {
-		network := ouroboros.NetworkByName(globalConfig.Node.Network)
-		if network == ouroboros.NetworkInvalid {
-			return nil, fmt.Errorf(
-				"unknown network: %s",
-				globalConfig.Node.Network,
+		return fmt.Errorf("error processing environment: %s", err)
+	}
+	// Apply command line flags
+	if overrides != nil {
+		overrides.apply(cfg)
+	}
+	// Populate network magic value from network name. An unknown network is
+	// reported by Validate
+	if cfg.Node.Network != "" {
+		network := ouroboros.NetworkByName(cfg.Node.Network)
+		if network != ouroboros.NetworkInvalid &&
+			cfg.Node.NetworkMagic == 0 {
+			cfg.Node.NetworkMagic = network.NetworkMagic
+		}
+	}
+	// Use the default node socket path unless connecting over TCP or to a list
+	// of endpoints
+	if cfg.Node.SocketPath == "" && cfg.Node.Address == "" &&
+		len(cfg.Node.Endpoints) == 0 {
+		cfg.Node.SocketPath = defaultNodeSocketPath
+	}
+	// Normalize metrics paths to have a leading slash
+	if !strings.HasPrefix(cfg.Metrics.Path, "/") {
+		cfg.Metrics.Path = "/" + cfg.Metrics.Path
+	}
+	if cfg.Metrics.ApiPath != "" {
+		cfg.Metrics.ApiPath = "/" + strings.Trim(
+			cfg.Metrics.ApiPath,
+			"/",
+		)
+	}
+	// Normalize base path to have a leading slash and no trailing slash
+	if basePath := strings.Trim(cfg.Api.BasePath, "/"); basePath != "" {
+		cfg.Api.BasePath = "/" + basePath
+	} else {
+		cfg.Api.BasePath = ""
+	}
+	// Load the API keys from file
+	if cfg.Api.Auth.ApiKeysFile != "" {
+		if len(cfg.Api.Auth.ApiKeys) > 0 {
+			return errors.New(
+				"only one of api.auth.apiKeys (API_KEYS) and api.auth.apiKeysFile (API_KEYS_FILE) can be set",
 			)
 		}
-		globalConfig.Node.NetworkMagic = network.NetworkMagic
+		buf, err := readSecretFile(cfg.Api.Auth.ApiKeysFile)
+		if err != nil {
+			return fmt.Errorf("error reading API keys file: %s", err)
+		}
+		var apiKeys []ApiKeyConfig
+		if err := yaml.Unmarshal(buf, &apiKeys); err != nil {
+			return fmt.Errorf("error parsing API keys file: %s", err)
+		}
+		cfg.Api.Auth.ApiKeys = apiKeys
 	}
-	return globalConfig, nil
+	// Load the TX callback secret from file
+	if cfg.Api.TxCallback.SecretFile != "" {
+		if cfg.Api.TxCallback.Secret != "" {
+			return errors.New(
+				"only one of api.txCallback.secret (API_TX_CALLBACK_SECRET) and api.txCallback.secretFile (API_TX_CALLBACK_SECRET_FILE) can be set",
+			)
+		}
+		buf, err := readSecretFile(cfg.Api.TxCallback.SecretFile)
+		if err != nil {
+			return fmt.Errorf("error reading TX callback secret file: %s", err)
+		}
+		cfg.Api.TxCallback.Secret = strings.TrimSpace(string(buf))
+	}
+	// The TLS key is loaded later, but check that it's protected up front
+	if cfg.Api.Tls.KeyFilePath != "" {
+		err := checkSecretFile(cfg.Api.Tls.KeyFilePath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking TLS key file: %s", err)
+		}
+	}
+	// Pick the auth mode based on the provided API keys if not set
+	if cfg.Api.Auth.Mode == "" {
+		cfg.Api.Auth.Mode = AuthModeNone
+		if len(cfg.Api.Auth.ApiKeys) > 0 {
+			cfg.Api.Auth.Mode = AuthModeApiKey
+		}
+	}
+	return nil
 }
 
 // Config returns the global config instance
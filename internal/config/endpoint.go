@@ -0,0 +1,83 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// NodeEndpoint is an address that the node can be reached at, either over a UNIX
+// socket or TCP
+type NodeEndpoint struct {
+	// Network is "unix" or "tcp"
+	Network string
+	Address string
+}
+
+// String returns the endpoint as it's configured, which is used in logs,
+// metrics, and the response header
+func (e NodeEndpoint) String() string {
+	return e.Address
+}
+
+// GetEndpoints returns the node endpoints in order of preference. These come from
+// the endpoints list if provided, otherwise the single socket path or TCP
+// address is used
+func (n *NodeConfig) GetEndpoints() []NodeEndpoint {
+	if len(n.Endpoints) == 0 {
+		if n.Address != "" && n.Port > 0 {
+			return []NodeEndpoint{
+				{
+					Network: "tcp",
+					Address: net.JoinHostPort(
+						n.Address,
+						strconv.Itoa(int(n.Port)),
+					),
+				},
+			}
+		}
+		return []NodeEndpoint{{Network: "unix", Address: n.SocketPath}}
+	}
+	ret := make([]NodeEndpoint, 0, len(n.Endpoints))
+	for _, value := range n.Endpoints {
+		// Invalid endpoints are reported by Validate
+		if endpoint, err := parseNodeEndpoint(value); err == nil {
+			ret = append(ret, endpoint)
+		}
+	}
+	return ret
+}
+
+// parseNodeEndpoint parses an absolute UNIX socket path or a TCP host:port
+func parseNodeEndpoint(value string) (NodeEndpoint, error) {
+	if strings.HasPrefix(value, "/") {
+		return NodeEndpoint{Network: "unix", Address: value}, nil
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return NodeEndpoint{}, fmt.Errorf(
+			"node endpoint must be a socket path or host:port: %s",
+			value,
+		)
+	}
+	if portNum, err := strconv.ParseUint(port, 10, 16); err != nil ||
+		portNum == 0 || host == "" {
+		return NodeEndpoint{}, fmt.Errorf("invalid node endpoint: %s", value)
+	}
+	return NodeEndpoint{Network: "tcp", Address: value}, nil
+}
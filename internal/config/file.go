@@ -0,0 +1,96 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
+
+const exampleHeader = `# Example cardano-node-api config file, showing the default values
+#
+# Values are applied in this order, with later sources taking precedence:
+# defaults, this file, environment variables, command line flags
+`
+
+var yamlLineRegexp = regexp.MustCompile(`^line \d+: `)
+
+// Problems found in the config file which weren't fatal
+var loadWarnings []string
+
+// Warnings returns any problems found while loading the config file, such as
+// unknown keys, which didn't prevent it from loading
+func Warnings() []string {
+	return loadWarnings
+}
+
+// Example returns an example config file containing the default values
+func Example() ([]byte, error) {
+	buf, err := yaml.Marshal(defaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(exampleHeader), buf...), nil
+}
+
+// loadFile loads a YAML or TOML config file over the provided config. Unknown
+// keys are returned as warnings, or as an error if strict is set
+func loadFile(cfg *Config, configFile string, strict bool) error {
+	buf, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %s", err)
+	}
+	// TOML is converted to YAML so that the same struct tags apply to both
+	isToml := strings.ToLower(filepath.Ext(configFile)) == ".toml"
+	if isToml {
+		var tmpData map[string]any
+		if err := toml.Unmarshal(buf, &tmpData); err != nil {
+			return fmt.Errorf("error parsing config file: %s", err)
+		}
+		buf, err = yaml.Marshal(tmpData)
+		if err != nil {
+			return fmt.Errorf("error parsing config file: %s", err)
+		}
+	}
+	if err := yaml.Unmarshal(buf, cfg); err != nil {
+		return fmt.Errorf("error parsing config file: %s", err)
+	}
+	// Parse the file again in strict mode to find any unknown keys. Any other
+	// problems would have been caught above
+	var typeErr *yaml.TypeError
+	if err := yaml.UnmarshalStrict(buf, &Config{}); errors.As(err, &typeErr) {
+		if isToml {
+			// Line numbers refer to the converted YAML, so they aren't useful
+			for idx, msg := range typeErr.Errors {
+				typeErr.Errors[idx] = yamlLineRegexp.ReplaceAllString(msg, "")
+			}
+		}
+		if strict {
+			return fmt.Errorf(
+				"unknown keys in config file: %s",
+				strings.Join(typeErr.Errors, ", "),
+			)
+		}
+		loadWarnings = append(loadWarnings, typeErr.Errors...)
+	}
+	return nil
+}
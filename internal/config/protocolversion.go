@@ -0,0 +1,58 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+)
+
+// SupportedProtocolVersions returns the node-to-client protocol versions that
+// gouroboros supports, in ascending order
+func SupportedProtocolVersions() []uint16 {
+	versionMap := protocol.GetProtocolVersionMap(
+		protocol.ProtocolModeNodeToClient,
+		0,
+		false,
+		false,
+		false,
+	)
+	ret := make([]uint16, 0, len(versionMap))
+	for version := range versionMap {
+		ret = append(ret, version-protocol.ProtocolVersionNtCOffset)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// OfferedProtocolVersions returns the node-to-client protocol versions to offer
+// in the handshake, or nil to offer all of the supported versions
+func (n *NodeConfig) OfferedProtocolVersions() []uint16 {
+	if n.ProtocolVersion == 0 && n.MaxProtocolVersion == 0 {
+		return nil
+	}
+	var ret []uint16
+	for _, version := range SupportedProtocolVersions() {
+		if n.ProtocolVersion > 0 && uint(version) != n.ProtocolVersion {
+			continue
+		}
+		if n.MaxProtocolVersion > 0 && uint(version) > n.MaxProtocolVersion {
+			continue
+		}
+		ret = append(ret, version)
+	}
+	return ret
+}
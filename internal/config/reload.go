@@ -0,0 +1,215 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Outcomes of reloading a config key
+const (
+	ReloadResultApplied = "applied"
+	ReloadResultSkipped = "skipped"
+	ReloadResultFailed  = "failed"
+)
+
+// ReloadResult is the outcome of reloading a single config key. Keys use the
+// same dotted paths as the config file
+type ReloadResult struct {
+	Key    string
+	Result string
+	Err    error
+}
+
+type loadSources struct {
+	configFile string
+	strict     bool
+	overrides  *Overrides
+}
+
+type reloadHandler struct {
+	name  string
+	keys  []string
+	apply func(*Config) error
+}
+
+var (
+	reloadSources  loadSources
+	reloadHandlers []reloadHandler
+	// Flattened values of the currently applied config
+	reloadState map[string]any
+	reloadMutex sync.Mutex
+)
+
+// OnReload registers a function that applies the hot-reloadable settings under
+// the given keys from a reloaded config. It's called when any of them change.
+// With no keys, it's called on every reload and its result is reported under
+// the name instead
+func OnReload(name string, keys []string, apply func(*Config) error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	reloadHandlers = append(
+		reloadHandlers,
+		reloadHandler{name: name, keys: keys, apply: apply},
+	)
+}
+
+// Reload re-reads the config from the sources originally passed to Load and
+// applies any changed hot-reloadable settings. Changes to other settings are
+// reported as skipped, since they require a restart. An error is returned if
+// the new config can't be loaded or is invalid, in which case nothing is
+// applied
+func Reload() ([]ReloadResult, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	if reloadState == nil {
+		state, err := flattenConfig(globalConfig)
+		if err != nil {
+			return nil, err
+		}
+		reloadState = state
+	}
+	loadWarnings = nil
+	newCfg := defaultConfig()
+	if err := load(
+		newCfg,
+		reloadSources.configFile,
+		reloadSources.strict,
+		reloadSources.overrides,
+	); err != nil {
+		return nil, err
+	}
+	if err := newCfg.Validate(); err != nil {
+		return nil, err
+	}
+	newState, err := flattenConfig(newCfg)
+	if err != nil {
+		return nil, err
+	}
+	// Find the changed keys
+	var changed []string
+	for key, value := range newState {
+		if oldValue, ok := reloadState[key]; !ok ||
+			!reflect.DeepEqual(oldValue, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range reloadState {
+		if _, ok := newState[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	var results []ReloadResult
+	handled := make(map[string]bool)
+	for _, handler := range reloadHandlers {
+		if len(handler.keys) == 0 {
+			result := ReloadResult{Key: handler.name, Result: ReloadResultApplied}
+			if err := handler.apply(newCfg); err != nil {
+				result.Result = ReloadResultFailed
+				result.Err = err
+			}
+			results = append(results, result)
+			continue
+		}
+		var handlerKeys []string
+		for _, key := range changed {
+			if matchesReloadKey(key, handler.keys) {
+				handlerKeys = append(handlerKeys, key)
+				handled[key] = true
+			}
+		}
+		if len(handlerKeys) == 0 {
+			continue
+		}
+		err := handler.apply(newCfg)
+		for _, key := range handlerKeys {
+			result := ReloadResult{Key: key, Result: ReloadResultApplied}
+			if err != nil {
+				result.Result = ReloadResultFailed
+				result.Err = err
+			} else {
+				updateReloadState(key, newState)
+			}
+			results = append(results, result)
+		}
+	}
+	// Anything else can only be changed with a restart
+	for _, key := range changed {
+		if !handled[key] {
+			results = append(
+				results,
+				ReloadResult{
+					Key:    key,
+					Result: ReloadResultSkipped,
+					Err:    fmt.Errorf("changing %s requires a restart", key),
+				},
+			)
+		}
+	}
+	return results, nil
+}
+
+// matchesReloadKey returns whether a key is one of the given keys or nested
+// under one of them
+func matchesReloadKey(key string, keys []string) bool {
+	for _, tmpKey := range keys {
+		if key == tmpKey || strings.HasPrefix(key, tmpKey+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func updateReloadState(key string, newState map[string]any) {
+	if value, ok := newState[key]; ok {
+		reloadState[key] = value
+	} else {
+		delete(reloadState, key)
+	}
+}
+
+// flattenConfig returns the config values keyed by their dotted config file
+// paths
+func flattenConfig(cfg *Config) (map[string]any, error) {
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var tmpData map[any]any
+	if err := yaml.Unmarshal(buf, &tmpData); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]any)
+	flattenMap("", tmpData, ret)
+	return ret, nil
+}
+
+func flattenMap(prefix string, data map[any]any, dest map[string]any) {
+	for k, v := range data {
+		key := fmt.Sprintf("%s%v", prefix, k)
+		if tmpMap, ok := v.(map[any]any); ok {
+			flattenMap(key+".", tmpMap, dest)
+			continue
+		}
+		dest[key] = v
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// readSecretFile reads a file containing secrets after checking its
+// permissions, with surrounding whitespace trimmed
+func readSecretFile(path string) ([]byte, error) {
+	if err := checkSecretFile(path); err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf), nil
+}
+
+// checkSecretFile makes sure that a file containing secrets can't be modified
+// by other users. Files that other users can read are allowed, since that's
+// the default for mounted Kubernetes secrets, but produce a warning
+func checkSecretFile(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// Windows doesn't have Unix permission bits
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	mode := stat.Mode().Perm()
+	if mode&0o022 != 0 {
+		return fmt.Errorf(
+			"secret file %s must not be writable by other users (mode %04o)",
+			path,
+			mode,
+		)
+	}
+	if mode&0o004 != 0 {
+		loadWarnings = append(
+			loadWarnings,
+			fmt.Sprintf(
+				"secret file %s is readable by all users (mode %04o)",
+				path,
+				mode,
+			),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadSecretFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't checked on Windows")
+	}
+	const apiKeysFile = "- key: filekey\n  groups: [chainsync]\n"
+	testDefs := []struct {
+		name  string
+		env   map[string]string
+		file  string
+		mode  os.FileMode
+		check func(*testing.T, *Config)
+		// Substring of the expected error, if any
+		wantErr     string
+		wantWarning bool
+	}{
+		{
+			name: "API keys file",
+			env:  map[string]string{"API_KEYS_FILE": "{file}"},
+			file: apiKeysFile,
+			mode: 0o600,
+			check: func(t *testing.T, cfg *Config) {
+				keys := cfg.Api.Auth.ApiKeys
+				if len(keys) != 1 || keys[0].Key != "filekey" ||
+					len(keys[0].Groups) != 1 || keys[0].Groups[0] != "chainsync" {
+					t.Fatalf("unexpected API keys: %+v", keys)
+				}
+				if cfg.Api.Auth.Mode != AuthModeApiKey {
+					t.Fatalf("unexpected auth mode: %s", cfg.Api.Auth.Mode)
+				}
+			},
+		},
+		{
+			name: "API keys and API keys file",
+			env: map[string]string{
+				"API_KEYS":      "envkey",
+				"API_KEYS_FILE": "{file}",
+			},
+			file:    apiKeysFile,
+			mode:    0o600,
+			wantErr: "only one of api.auth.apiKeys (API_KEYS) and api.auth.apiKeysFile (API_KEYS_FILE)",
+		},
+		{
+			name:        "API keys file readable by all users",
+			env:         map[string]string{"API_KEYS_FILE": "{file}"},
+			file:        apiKeysFile,
+			mode:        0o644,
+			wantWarning: true,
+		},
+		{
+			name:    "API keys file writable by other users",
+			env:     map[string]string{"API_KEYS_FILE": "{file}"},
+			file:    apiKeysFile,
+			mode:    0o666,
+			wantErr: "must not be writable by other users",
+		},
+		{
+			name:    "missing API keys file",
+			env:     map[string]string{"API_KEYS_FILE": "{missing}"},
+			wantErr: "error reading API keys file",
+		},
+		{
+			name:    "invalid API keys file",
+			env:     map[string]string{"API_KEYS_FILE": "{file}"},
+			file:    "key: [",
+			mode:    0o600,
+			wantErr: "error parsing API keys file",
+		},
+		{
+			name: "TX callback secret file",
+			env:  map[string]string{"API_TX_CALLBACK_SECRET_FILE": "{file}"},
+			file: " filesecret\n",
+			mode: 0o400,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Api.TxCallback.Secret != "filesecret" {
+					t.Fatalf(
+						"unexpected TX callback secret: %q",
+						cfg.Api.TxCallback.Secret,
+					)
+				}
+			},
+		},
+		{
+			name: "TX callback secret and secret file",
+			env: map[string]string{
+				"API_TX_CALLBACK_SECRET":      "envsecret",
+				"API_TX_CALLBACK_SECRET_FILE": "{file}",
+			},
+			file:    "filesecret",
+			mode:    0o600,
+			wantErr: "only one of api.txCallback.secret (API_TX_CALLBACK_SECRET) and api.txCallback.secretFile (API_TX_CALLBACK_SECRET_FILE)",
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			secretPath := filepath.Join(tmpDir, "secret")
+			if testDef.file != "" {
+				if err := os.WriteFile(secretPath, []byte(testDef.file), 0o600); err != nil {
+					t.Fatalf("failed to write secret file: %s", err)
+				}
+				// Set the mode explicitly, since WriteFile applies the umask
+				if err := os.Chmod(secretPath, testDef.mode); err != nil {
+					t.Fatalf("failed to set secret file mode: %s", err)
+				}
+			}
+			for key, value := range testDef.env {
+				value = strings.ReplaceAll(value, "{file}", secretPath)
+				value = strings.ReplaceAll(
+					value,
+					"{missing}",
+					filepath.Join(tmpDir, "missing"),
+				)
+				t.Setenv(key, value)
+			}
+			loadWarnings = nil
+			cfg := defaultConfig()
+			err := load(cfg, "", false, nil)
+			if testDef.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testDef.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", testDef.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if gotWarning := len(loadWarnings) > 0; gotWarning != testDef.wantWarning {
+				t.Fatalf("unexpected warnings: %v", loadWarnings)
+			}
+			if testDef.check != nil {
+				testDef.check(t, cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,594 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"go.uber.org/zap/zapcore"
+)
+
+const maxPort = 65535
+
+// Validate checks the config for problems, returning all of them at once
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.Logging.validate()...)
+	errs = append(errs, c.validateListeners()...)
+	errs = append(errs, c.Metrics.validate()...)
+	errs = append(errs, c.Api.validate()...)
+	errs = append(errs, c.Node.validate()...)
+	return errors.Join(errs...)
+}
+
+func (l *LoggingConfig) validate() []error {
+	var errs []error
+	if _, err := zapcore.ParseLevel(l.Level); err != nil {
+		errs = append(
+			errs,
+			fmt.Errorf("invalid log level for logging.level: %s", l.Level),
+		)
+	}
+	for component, level := range l.Levels {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"invalid log level for logging.levels.%s: %s",
+					component,
+					level,
+				),
+			)
+		}
+	}
+	for _, format := range []struct {
+		key   string
+		value string
+	}{
+		{"logging.format", l.Format},
+		{"logging.accessFormat", l.AccessFormat},
+	} {
+		switch format.value {
+		case LogFormatJson, LogFormatConsole:
+		default:
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"unknown log format for %s: %s",
+					format.key,
+					format.value,
+				),
+			)
+		}
+	}
+	for _, path := range l.SkipPaths {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(
+				errs,
+				fmt.Errorf("access log skip path must start with /: %s", path),
+			)
+		}
+	}
+	for path, rate := range l.SampleRates {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(
+				errs,
+				fmt.Errorf("access log sample path must start with /: %s", path),
+			)
+		}
+		if rate == 0 {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"access log sample rate for %s must be at least 1",
+					path,
+				),
+			)
+		}
+	}
+	for _, field := range l.AccessLogFields {
+		if !slices.Contains(AccessLogFields, field) {
+			errs = append(errs, fmt.Errorf("unknown access log field: %s", field))
+		}
+	}
+	return errs
+}
+
+func (c *Config) validateListeners() []error {
+	var errs []error
+	listeners := []struct {
+		name    string
+		address string
+		port    uint
+	}{
+		{"API", c.Api.ListenAddress, c.Api.ListenPort},
+		{"metrics", c.Metrics.ListenAddress, c.Metrics.ListenPort},
+		{"debug", c.Debug.ListenAddress, c.Debug.ListenPort},
+		{"gRPC", c.Utxorpc.ListenAddress, c.Utxorpc.ListenPort},
+	}
+	for idx, listener := range listeners {
+		if listener.port > maxPort {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"the %s listen port is out of range: %d",
+					listener.name,
+					listener.port,
+				),
+			)
+		}
+		if listener.port == 0 {
+			continue
+		}
+		// An empty address listens on all interfaces, so it conflicts with
+		// any other address on the same port
+		for _, other := range listeners[idx+1:] {
+			if other.port != listener.port {
+				continue
+			}
+			if listener.address == other.address || listener.address == "" ||
+				other.address == "" {
+				errs = append(
+					errs,
+					fmt.Errorf(
+						"the %s and %s listeners cannot both use port %d",
+						listener.name,
+						other.name,
+						listener.port,
+					),
+				)
+			}
+		}
+	}
+	if c.Api.ListenPort == 0 && c.Api.ListenSocket == "" {
+		errs = append(
+			errs,
+			errors.New(
+				"either the API listen port or socket path must be provided",
+			),
+		)
+	}
+	// The metrics listener can be disabled when metrics are served on the API
+	// listener instead
+	metricsListener := c.Metrics.ListenPort > 0 || c.Metrics.ListenSocket != ""
+	if !metricsListener && c.Metrics.ApiPath == "" {
+		errs = append(
+			errs,
+			errors.New(
+				"either the metrics listen port, socket path, or API path must be provided",
+			),
+		)
+	}
+	if !metricsListener && c.Api.Tls.ExemptHealthcheck {
+		errs = append(
+			errs,
+			errors.New(
+				"exempting the healthcheck from TLS client auth requires the metrics listener",
+			),
+		)
+	}
+	return errs
+}
+
+func (m *MetricsConfig) validate() []error {
+	var errs []error
+	if err := validateBuckets(m.DurationBuckets); err != nil {
+		errs = append(
+			errs,
+			fmt.Errorf("invalid metrics duration buckets: %s", err),
+		)
+	}
+	if err := validateBuckets(m.SizeBuckets); err != nil {
+		errs = append(errs, fmt.Errorf("invalid metrics size buckets: %s", err))
+	}
+	if m.ApiPath == "/" {
+		errs = append(errs, errors.New("the metrics API path cannot be /"))
+	}
+	if m.MempoolPoll && m.MempoolPollInterval == 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the mempool poll interval must be greater than 0 when the mempool poller is enabled",
+			),
+		)
+	}
+	if m.TipPoll && m.TipPollInterval == 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the tip poll interval must be greater than 0 when the tip poller is enabled",
+			),
+		)
+	}
+	return errs
+}
+
+func (a *ApiConfig) validate() []error {
+	var errs []error
+	switch a.ClientIpHeader {
+	case ClientIpHeaderXForwardedFor, ClientIpHeaderXRealIp, ClientIpHeaderForwarded:
+	default:
+		errs = append(
+			errs,
+			fmt.Errorf("unknown client IP header: %s", a.ClientIpHeader),
+		)
+	}
+	// Check TLS config
+	if (a.Tls.CertFilePath == "") != (a.Tls.KeyFilePath == "") {
+		errs = append(
+			errs,
+			errors.New(
+				"both the TLS certificate and key files must be provided to enable TLS",
+			),
+		)
+	}
+	if a.Tls.ClientCaFilePath != "" && a.Tls.CertFilePath == "" {
+		errs = append(
+			errs,
+			errors.New(
+				"the TLS certificate and key files must be provided to enable client certificate authentication",
+			),
+		)
+	}
+	for _, file := range []struct {
+		name string
+		path string
+	}{
+		{"TLS certificate file", a.Tls.CertFilePath},
+		{"TLS key file", a.Tls.KeyFilePath},
+		{"TLS client CA file", a.Tls.ClientCaFilePath},
+	} {
+		if err := validateFileExists(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %s", file.name, err))
+		}
+	}
+	if a.MaxUtxoTxIns == 0 {
+		errs = append(
+			errs,
+			errors.New("the max UTxO TX inputs per request must be at least 1"),
+		)
+	}
+	if a.MaxStakeAccounts == 0 {
+		errs = append(
+			errs,
+			errors.New("the max stake accounts per request must be at least 1"),
+		)
+	}
+	if a.MaxTxSubmitBytes == 0 {
+		errs = append(
+			errs,
+			errors.New("the max TX submission body size must be at least 1"),
+		)
+	}
+	if a.MaxTxBatchItems == 0 {
+		errs = append(
+			errs,
+			errors.New("the max TX batch submission items must be at least 1"),
+		)
+	}
+	if a.MaxTxBatchBytes == 0 {
+		errs = append(
+			errs,
+			errors.New("the max TX batch submission body size must be at least 1"),
+		)
+	}
+	if a.TxWaitTimeout == 0 {
+		errs = append(
+			errs,
+			errors.New("the TX submission wait timeout must be at least 1 second"),
+		)
+	} else if a.Server.WriteTimeout > 0 &&
+		a.TxWaitTimeout >= a.Server.WriteTimeout {
+		errs = append(
+			errs,
+			errors.New(
+				"the TX submission wait timeout must be less than the write timeout",
+			),
+		)
+	}
+	if a.TxCallback.Enabled {
+		if a.TxCallback.Secret == "" {
+			errs = append(
+				errs,
+				errors.New("a TX callback secret is required when callbacks are enabled"),
+			)
+		}
+		if a.TxCallback.MaxWatches == 0 {
+			errs = append(
+				errs,
+				errors.New("the max TX callback watches must be at least 1"),
+			)
+		}
+		if a.TxCallback.MaxWatchTime == 0 {
+			errs = append(
+				errs,
+				errors.New("the max TX callback watch time must be at least 1 second"),
+			)
+		}
+		if a.TxCallback.Confirmations == 0 {
+			errs = append(
+				errs,
+				errors.New("the TX callback confirmations must be at least 1"),
+			)
+		}
+		if a.TxCallback.Timeout == 0 {
+			errs = append(
+				errs,
+				errors.New("the TX callback timeout must be at least 1 second"),
+			)
+		}
+	}
+	if a.MempoolStream.MaxStreams > 0 {
+		if a.MempoolStream.HeartbeatInterval == 0 {
+			errs = append(
+				errs,
+				errors.New(
+					"the mempool stream heartbeat interval must be at least 1 second when streams are enabled",
+				),
+			)
+		}
+		if a.MempoolStream.SendBuffer == 0 {
+			errs = append(
+				errs,
+				errors.New(
+					"the mempool stream send buffer must be at least 1 message when streams are enabled",
+				),
+			)
+		}
+	}
+	if a.ChainSyncStream.MaxStreams > 0 && a.ChainSyncStream.HeartbeatInterval == 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the chain-sync stream heartbeat interval must be at least 1 second when streams are enabled",
+			),
+		)
+	}
+	if a.RawQuery.Enabled && a.RawQuery.MaxBytes == 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the raw query max body size must be at least 1 when the raw query endpoint is enabled",
+			),
+		)
+	}
+	if a.Idempotency.CacheSize > 0 && a.Idempotency.CacheTtl == 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the idempotency cache TTL must be at least 1 second when the cache is enabled",
+			),
+		)
+	}
+	// Check auth config
+	switch a.Auth.Mode {
+	case AuthModeNone:
+	case AuthModeApiKey, AuthModeJwt, AuthModeBoth:
+		if a.Auth.Mode != AuthModeJwt && len(a.Auth.ApiKeys) == 0 {
+			errs = append(
+				errs,
+				fmt.Errorf("auth mode %q requires API keys", a.Auth.Mode),
+			)
+		}
+		if a.Auth.Mode != AuthModeApiKey &&
+			(a.Auth.Jwt.Issuer == "" || a.Auth.Jwt.Audience == "") {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"auth mode %q requires a JWT issuer and audience",
+					a.Auth.Mode,
+				),
+			)
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown auth mode: %s", a.Auth.Mode))
+	}
+	if a.Auth.Jwt.JwksUrl != "" {
+		jwksUrl, err := url.Parse(a.Auth.Jwt.JwksUrl)
+		if err != nil || (jwksUrl.Scheme != "http" && jwksUrl.Scheme != "https") ||
+			jwksUrl.Host == "" {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"the JWKS URL must be an http or https URL: %s",
+					a.Auth.Jwt.JwksUrl,
+				),
+			)
+		}
+	}
+	// Check CORS config
+	if slices.Contains(a.Cors.AllowedOrigins, "*") && a.Cors.AllowCredentials {
+		errs = append(
+			errs,
+			errors.New(
+				"the CORS allowed origin \"*\" cannot be combined with allowing credentials",
+			),
+		)
+	}
+	return errs
+}
+
+func (n *NodeConfig) validate() []error {
+	var errs []error
+	// Check the connection method
+	if (n.Address == "") != (n.Port == 0) {
+		errs = append(
+			errs,
+			errors.New(
+				"both the node TCP address and port must be provided to connect over TCP",
+			),
+		)
+	}
+	if n.Port > maxPort {
+		errs = append(errs, fmt.Errorf("the node port is out of range: %d", n.Port))
+	}
+	if n.Address != "" && n.SocketPath != "" {
+		errs = append(
+			errs,
+			errors.New(
+				"the node socket path and TCP address cannot both be provided",
+			),
+		)
+	}
+	if len(n.Endpoints) > 0 {
+		if n.Address != "" || n.SocketPath != "" {
+			errs = append(
+				errs,
+				errors.New(
+					"the node endpoints cannot be combined with the node socket path or TCP address",
+				),
+			)
+		}
+		for _, value := range n.Endpoints {
+			if _, err := parseNodeEndpoint(value); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	// The node socket isn't checked, since it may not exist until the node has
+	// started
+	if n.RetryMaxInterval == 0 {
+		errs = append(
+			errs,
+			errors.New("the node retry max interval must be greater than 0"),
+		)
+	}
+	if n.PoolMaxSize == 0 {
+		errs = append(
+			errs,
+			errors.New("the node connection pool max size must be at least 1"),
+		)
+	}
+	if n.PoolMinSize > n.PoolMaxSize {
+		errs = append(
+			errs,
+			fmt.Errorf(
+				"the node connection pool min size (%d) cannot be larger than the max size (%d)",
+				n.PoolMinSize,
+				n.PoolMaxSize,
+			),
+		)
+	}
+	if n.BreakerFailures > 0 && (n.BreakerWindow == 0 || n.BreakerProbeInterval == 0) {
+		errs = append(
+			errs,
+			errors.New(
+				"the node circuit breaker window and probe interval must be greater than 0 when the breaker is enabled",
+			),
+		)
+	}
+	errs = append(errs, n.validateProtocolVersion()...)
+	// Check the network
+	if n.Network != "" {
+		network := ouroboros.NetworkByName(n.Network)
+		if network == ouroboros.NetworkInvalid {
+			errs = append(errs, fmt.Errorf("unknown network: %s", n.Network))
+		} else if n.NetworkMagic != network.NetworkMagic {
+			errs = append(
+				errs,
+				fmt.Errorf(
+					"the network magic %d does not match the magic for network %s (%d), unset the network name to use a custom network magic",
+					n.NetworkMagic,
+					n.Network,
+					network.NetworkMagic,
+				),
+			)
+		}
+	} else if n.NetworkMagic == 0 {
+		errs = append(
+			errs,
+			errors.New("either the network name or network magic must be provided"),
+		)
+	}
+	return errs
+}
+
+// validateBuckets checks that histogram buckets are non-empty and ascending
+func validateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("at least one bucket must be provided")
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("buckets must be in ascending order")
+		}
+	}
+	return nil
+}
+
+// validateFileExists checks that a path, if provided, exists
+func validateFileExists(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateProtocolVersion checks that the pinned protocol versions are ones that
+// we support
+func (n *NodeConfig) validateProtocolVersion() []error {
+	var errs []error
+	supported := SupportedProtocolVersions()
+	if n.ProtocolVersion > 0 && n.MaxProtocolVersion > 0 {
+		errs = append(
+			errs,
+			errors.New(
+				"the node protocol version and max protocol version cannot both be provided",
+			),
+		)
+	}
+	isSupported := slices.ContainsFunc(supported, func(version uint16) bool {
+		return uint(version) == n.ProtocolVersion
+	})
+	if n.ProtocolVersion > 0 && !isSupported {
+		errs = append(
+			errs,
+			fmt.Errorf(
+				"unsupported node protocol version %d, must be one of: %s",
+				n.ProtocolVersion,
+				formatProtocolVersions(supported),
+			),
+		)
+	}
+	if n.MaxProtocolVersion > 0 && n.MaxProtocolVersion < uint(supported[0]) {
+		errs = append(
+			errs,
+			fmt.Errorf(
+				"node max protocol version %d is below the lowest supported version %d",
+				n.MaxProtocolVersion,
+				supported[0],
+			),
+		)
+	}
+	return errs
+}
+
+func formatProtocolVersions(versions []uint16) string {
+	tmpVersions := make([]string, 0, len(versions))
+	for _, version := range versions {
+		tmpVersions = append(tmpVersions, strconv.Itoa(int(version)))
+	}
+	return strings.Join(tmpVersions, ", ")
+}
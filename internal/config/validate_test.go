@@ -0,0 +1,255 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	supported := SupportedProtocolVersions()
+	testDefs := []struct {
+		name   string
+		modify func(*testing.T, *Config)
+		// Substrings of the expected errors, which are all reported at once
+		wantErrs []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "valid with TLS",
+			modify: func(t *testing.T, cfg *Config) {
+				tmpDir := t.TempDir()
+				for _, path := range []*string{
+					&cfg.Api.Tls.CertFilePath,
+					&cfg.Api.Tls.KeyFilePath,
+					&cfg.Api.Tls.ClientCaFilePath,
+				} {
+					*path = filepath.Join(tmpDir, "tls.pem")
+				}
+				if err := os.WriteFile(cfg.Api.Tls.CertFilePath, nil, 0o600); err != nil {
+					t.Fatalf("failed to write TLS file: %s", err)
+				}
+			},
+		},
+		{
+			name: "bad log level",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Logging.Level = "loud"
+			},
+			wantErrs: []string{"invalid log level for logging.level: loud"},
+		},
+		{
+			name: "bad component log level",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Logging.Levels = map[string]string{"node": "loud"}
+			},
+			wantErrs: []string{"invalid log level for logging.levels.node: loud"},
+		},
+		{
+			name: "bad log format",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Logging.Format = "xml"
+			},
+			wantErrs: []string{"unknown log format for logging.format: xml"},
+		},
+		{
+			name: "bad access log format",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Logging.AccessFormat = "text"
+			},
+			wantErrs: []string{"unknown log format for logging.accessFormat: text"},
+		},
+		{
+			name: "port out of range",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.ListenPort = 70000
+			},
+			wantErrs: []string{"the API listen port is out of range: 70000"},
+		},
+		{
+			name: "port conflict",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Metrics.ListenPort = cfg.Api.ListenPort
+			},
+			wantErrs: []string{"the API and metrics listeners cannot both use port 8080"},
+		},
+		{
+			name: "port conflict with all interfaces",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Debug.ListenPort = cfg.Utxorpc.ListenPort
+			},
+			wantErrs: []string{"the debug and gRPC listeners cannot both use port 9090"},
+		},
+		{
+			name: "same port on different addresses",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.ListenAddress = "127.0.0.1"
+				cfg.Metrics.ListenAddress = "127.0.0.2"
+				cfg.Metrics.ListenPort = cfg.Api.ListenPort
+			},
+		},
+		{
+			name: "no API listener",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.ListenPort = 0
+			},
+			wantErrs: []string{"either the API listen port or socket path must be provided"},
+		},
+		{
+			name: "empty metrics buckets",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Metrics.DurationBuckets = nil
+			},
+			wantErrs: []string{"invalid metrics duration buckets: at least one bucket must be provided"},
+		},
+		{
+			name: "unordered metrics buckets",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Metrics.SizeBuckets = []float64{100, 10}
+			},
+			wantErrs: []string{"invalid metrics size buckets: buckets must be in ascending order"},
+		},
+		{
+			name: "TLS key without certificate",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.Tls.KeyFilePath = filepath.Join(t.TempDir(), "key.pem")
+			},
+			wantErrs: []string{
+				"both the TLS certificate and key files must be provided",
+				"invalid TLS key file",
+			},
+		},
+		{
+			name: "missing TLS files",
+			modify: func(t *testing.T, cfg *Config) {
+				tmpDir := t.TempDir()
+				cfg.Api.Tls.CertFilePath = filepath.Join(tmpDir, "cert.pem")
+				cfg.Api.Tls.KeyFilePath = filepath.Join(tmpDir, "key.pem")
+				cfg.Api.Tls.ClientCaFilePath = filepath.Join(tmpDir, "ca.pem")
+			},
+			wantErrs: []string{
+				"invalid TLS certificate file",
+				"invalid TLS key file",
+				"invalid TLS client CA file",
+			},
+		},
+		{
+			name: "bad JWKS URL",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.Auth.Jwt.JwksUrl = "/etc/jwks.json"
+			},
+			wantErrs: []string{"the JWKS URL must be an http or https URL: /etc/jwks.json"},
+		},
+		{
+			name: "JWT mode without issuer",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Api.Auth.Mode = AuthModeJwt
+			},
+			wantErrs: []string{`auth mode "jwt" requires a JWT issuer and audience`},
+		},
+		{
+			name: "node socket and TCP address",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Node.SocketPath = "/node-ipc/node.socket"
+				cfg.Node.Address = "localhost"
+				cfg.Node.Port = 3001
+			},
+			wantErrs: []string{"the node socket path and TCP address cannot both be provided"},
+		},
+		{
+			name: "network magic mismatch",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Node.NetworkMagic = 2
+			},
+			wantErrs: []string{"the network magic 2 does not match the magic for network mainnet"},
+		},
+		{
+			name: "unsupported protocol version",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Node.ProtocolVersion = uint(supported[len(supported)-1]) + 1
+			},
+			wantErrs: []string{"unsupported node protocol version"},
+		},
+		{
+			name: "max protocol version below supported",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Node.MaxProtocolVersion = uint(supported[0]) - 1
+			},
+			wantErrs: []string{"is below the lowest supported version"},
+		},
+		{
+			name: "protocol version and max protocol version",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Node.ProtocolVersion = uint(supported[0])
+				cfg.Node.MaxProtocolVersion = uint(supported[0])
+			},
+			wantErrs: []string{"the node protocol version and max protocol version cannot both be provided"},
+		},
+		{
+			name: "multiple problems",
+			modify: func(t *testing.T, cfg *Config) {
+				cfg.Logging.Level = "loud"
+				cfg.Metrics.DurationBuckets = nil
+				cfg.Api.MaxUtxoTxIns = 0
+			},
+			wantErrs: []string{
+				"invalid log level for logging.level: loud",
+				"invalid metrics duration buckets",
+				"the max UTxO TX inputs per request must be at least 1",
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			// Fill in the values that Load derives from the others
+			cfg := defaultConfig()
+			cfg.Node.NetworkMagic = 764824073
+			cfg.Api.Auth.Mode = AuthModeNone
+			if testDef.modify != nil {
+				testDef.modify(t, cfg)
+			}
+			err := cfg.Validate()
+			if len(testDef.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("did not get expected error")
+			}
+			// Each problem is on its own line
+			gotErrs := strings.Split(err.Error(), "\n")
+			if len(gotErrs) != len(testDef.wantErrs) {
+				t.Fatalf(
+					"got %d errors, wanted %d: %s",
+					len(gotErrs),
+					len(testDef.wantErrs),
+					err,
+				)
+			}
+			for idx, wantErr := range testDef.wantErrs {
+				if !strings.Contains(gotErrs[idx], wantErr) {
+					t.Fatalf("unexpected error: got %q, wanted %q", gotErrs[idx], wantErr)
+				}
+			}
+		})
+	}
+}
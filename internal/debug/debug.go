@@ -0,0 +1,112 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/node"
+)
+
+type logLevels struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels"`
+}
+
+type responseConnections struct {
+	Goroutines  int                   `json:"goroutines"`
+	Connections []node.OpenConnection `json:"connections"`
+}
+
+// Start runs the debug listener. It uses its own mux rather than
+// http.DefaultServeMux so that the debug handlers are only ever reachable on
+// this listener
+func Start(cfg *config.Config) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/connections", handleConnections)
+	mux.HandleFunc("/debug/loglevel", handleLogLevel)
+	return http.ListenAndServe(
+		fmt.Sprintf(
+			"%s:%d",
+			cfg.Debug.ListenAddress,
+			cfg.Debug.ListenPort,
+		),
+		mux,
+	)
+}
+
+// handleConnections dumps the currently open node connections along with the
+// current goroutine count. A full goroutine dump is available from
+// /debug/pprof/goroutine?debug=2
+func handleConnections(w http.ResponseWriter, r *http.Request) {
+	resp := responseConnections{
+		Goroutines:  runtime.NumGoroutine(),
+		Connections: node.GetOpenConnections(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(resp)
+}
+
+// handleLogLevel returns the current log levels, or replaces them on PUT. The
+// request body uses the same shape as the response, and the per-component
+// overrides in it replace any existing ones
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req logLevels
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(
+				w,
+				fmt.Sprintf("invalid request body: %s", err),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		if err := logging.SetLevels(req.Level, req.Levels); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logging.GetLogger(logging.ComponentMain).
+			Infof("log levels updated from debug listener")
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	level, levels := logging.GetLevels()
+	if levels == nil {
+		levels = map[string]string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(logLevels{Level: level, Levels: levels})
+}
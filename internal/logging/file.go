@@ -0,0 +1,97 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sync"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log files are opened by zap through a custom sink scheme so that they get
+// the same encoder, sampling, and error output handling as stderr
+const fileSinkScheme = "lumberjack"
+
+var (
+	fileWriters      = map[string]*fileWriter{}
+	fileWritersMutex sync.Mutex
+)
+
+func init() {
+	if err := zap.RegisterSink(fileSinkScheme, openFileSink); err != nil {
+		panic(err)
+	}
+}
+
+// fileWriter is a rotating log file
+type fileWriter struct {
+	*lumberjack.Logger
+}
+
+// Sync is a no-op, since lumberjack doesn't buffer writes
+func (w *fileWriter) Sync() error {
+	return nil
+}
+
+// fileSinkUrl registers a rotating log file for the path and returns the URL
+// that zap should use to open it
+func fileSinkUrl(path string, cfg *config.LoggingConfig) (string, error) {
+	// Relative paths can't be represented in the URL path
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid log file path: %s", err)
+	}
+	fileWritersMutex.Lock()
+	defer fileWritersMutex.Unlock()
+	if _, ok := fileWriters[path]; !ok {
+		fileWriters[path] = &fileWriter{
+			Logger: &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    int(cfg.MaxSize),
+				MaxAge:     int(cfg.MaxAge),
+				MaxBackups: int(cfg.MaxBackups),
+			},
+		}
+	}
+	return (&url.URL{Scheme: fileSinkScheme, Path: path}).String(), nil
+}
+
+func openFileSink(u *url.URL) (zap.Sink, error) {
+	fileWritersMutex.Lock()
+	defer fileWritersMutex.Unlock()
+	w, ok := fileWriters[u.Path]
+	if !ok {
+		return nil, fmt.Errorf("no log file configured for %s", u.Path)
+	}
+	return w, nil
+}
+
+// Reopen closes any log files so that they're reopened on the next write. This
+// allows external tools to move log files out of the way without truncating them
+func Reopen() error {
+	fileWritersMutex.Lock()
+	defer fileWritersMutex.Unlock()
+	for path, w := range fileWriters {
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to close log file %s: %s", path, err)
+		}
+	}
+	return nil
+}
@@ -15,48 +15,192 @@
 package logging
 
 import (
+	"fmt"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
 	"github.com/blinklabs-io/cardano-node-api/internal/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"log"
-	"time"
 )
 
 type Logger = zap.SugaredLogger
 
-var globalLogger *Logger
+// Components with their own log level, which can be overridden in the
+// logging.levels config
+const (
+	ComponentMain      = "main"
+	ComponentApi       = "api"
+	ComponentAccess    = "access"
+	ComponentNode      = "node"
+	ComponentChainsync = "chainsync"
+	ComponentUtxorpc   = "utxorpc"
+)
+
+var components = []string{
+	ComponentMain,
+	ComponentApi,
+	ComponentAccess,
+	ComponentNode,
+	ComponentChainsync,
+	ComponentUtxorpc,
+}
+
+var (
+	globalLogger     *Logger
+	globalLevel      = zap.NewAtomicLevel()
+	componentLevels  = map[string]zap.AtomicLevel{}
+	componentLoggers = map[string]*Logger{}
+	levelOverrides   = map[string]string{}
+	levelsMutex      sync.Mutex
+)
+
+func init() {
+	for _, component := range components {
+		componentLevels[component] = zap.NewAtomicLevel()
+	}
+}
 
 func Setup(cfg *config.LoggingConfig) {
+	// Set levels
+	if err := SetLevels(cfg.Level, cfg.Levels); err != nil {
+		log.Fatalf("error configuring logger: %s", err)
+	}
+
+	// Create the loggers
+	l, err := buildLogger(cfg.Format, cfg.OutputPath, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	accessLogger, err := buildLogger(
+		cfg.AccessFormat,
+		cfg.AccessOutputPath,
+		cfg,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Apply changed levels when the config is reloaded
+	config.OnReload(
+		"log levels",
+		[]string{"logging.level", "logging.levels"},
+		func(cfg *config.Config) error {
+			return SetLevels(cfg.Logging.Level, cfg.Logging.Levels)
+		},
+	)
+
+	// Store the "sugared" version of the logger along with a named child
+	// logger for each component
+	globalLogger = withLevel(l, globalLevel).Sugar()
+	for component, level := range componentLevels {
+		base := l
+		if component == ComponentAccess {
+			base = accessLogger
+		}
+		componentLoggers[component] = withLevel(
+			base.Named(component),
+			level,
+		).Sugar()
+	}
+}
+
+// buildLogger creates a base logger using the given encoding, which writes to
+// a rotating log file if a path is provided
+func buildLogger(
+	format string,
+	path string,
+	cfg *config.LoggingConfig,
+) (*zap.Logger, error) {
 	// Build our custom logging config
 	loggerConfig := zap.NewProductionConfig()
+	loggerConfig.Encoding = format
+	if path != "" {
+		sinkUrl, err := fileSinkUrl(path, cfg)
+		if err != nil {
+			return nil, err
+		}
+		loggerConfig.OutputPaths = []string{sinkUrl}
+	}
 	// Change timestamp key name
 	loggerConfig.EncoderConfig.TimeKey = "timestamp"
 	// Use a human readable time format
 	loggerConfig.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(
 		time.RFC3339,
 	)
+	if format == config.LogFormatConsole {
+		// Make the level stand out when reading a terminal
+		loggerConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
 
-	// Set level
-	if cfg.Level != "" {
-		level, err := zapcore.ParseLevel(cfg.Level)
+	// Let everything through the base logger and filter by component level
+	// in the child loggers instead
+	loggerConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	return loggerConfig.Build()
+}
+
+// SetLevels updates the default log level and the per-component overrides.
+// Components without an override use the default level. An empty default
+// level leaves the current default unchanged
+func SetLevels(level string, levels map[string]string) error {
+	newLevel := globalLevel.Level()
+	if level != "" {
+		tmpLevel, err := zapcore.ParseLevel(level)
 		if err != nil {
-			log.Fatalf("error configuring logger: %s", err)
+			return fmt.Errorf("invalid log level for logging.level: %s", level)
 		}
-		loggerConfig.Level.SetLevel(level)
+		newLevel = tmpLevel
 	}
-
-	// Create the logger
-	l, err := loggerConfig.Build()
-	if err != nil {
-		log.Fatal(err)
+	newLevels := make(map[string]zapcore.Level, len(levels))
+	for component, componentLevel := range levels {
+		if _, ok := componentLevels[component]; !ok {
+			return fmt.Errorf(
+				"unknown log component: logging.levels.%s",
+				component,
+			)
+		}
+		tmpLevel, err := zapcore.ParseLevel(componentLevel)
+		if err != nil {
+			return fmt.Errorf(
+				"invalid log level for logging.levels.%s: %s",
+				component,
+				componentLevel,
+			)
+		}
+		newLevels[component] = tmpLevel
+	}
+	levelsMutex.Lock()
+	defer levelsMutex.Unlock()
+	levelOverrides = maps.Clone(levels)
+	globalLevel.SetLevel(newLevel)
+	for component, atomicLevel := range componentLevels {
+		if componentLevel, ok := newLevels[component]; ok {
+			atomicLevel.SetLevel(componentLevel)
+		} else {
+			atomicLevel.SetLevel(newLevel)
+		}
 	}
+	return nil
+}
 
-	// Store the "sugared" version of the logger
-	globalLogger = l.Sugar()
+// GetLevels returns the current default log level and the per-component
+// overrides
+func GetLevels() (string, map[string]string) {
+	levelsMutex.Lock()
+	defer levelsMutex.Unlock()
+	return globalLevel.String(), maps.Clone(levelOverrides)
 }
 
-func GetLogger() *zap.SugaredLogger {
-	return globalLogger
+// GetLogger returns the named child logger for a component. Unknown
+// components get a named logger using the default level
+func GetLogger(component string) *zap.SugaredLogger {
+	if logger, ok := componentLoggers[component]; ok {
+		return logger
+	}
+	return globalLogger.Named(component)
 }
 
 func GetDesugaredLogger() *zap.Logger {
@@ -64,7 +208,41 @@ func GetDesugaredLogger() *zap.Logger {
 }
 
 func GetAccessLogger() *zap.Logger {
-	return globalLogger.Desugar().
+	return GetLogger(ComponentAccess).Desugar().
 		With(zap.String("type", "access")).
 		WithOptions(zap.WithCaller(false))
 }
+
+// withLevel returns a logger that only writes entries enabled by the given
+// level
+func withLevel(l *zap.Logger, level zap.AtomicLevel) *zap.Logger {
+	return l.WithOptions(
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &levelCore{Core: core, level: level}
+		}),
+	)
+}
+
+// levelCore filters the entries passed to the wrapped core by a separate level
+type levelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(
+	entry zapcore.Entry,
+	ce *zapcore.CheckedEntry,
+) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
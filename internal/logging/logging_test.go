@@ -0,0 +1,107 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"go.uber.org/zap"
+)
+
+// The timestamp and caller change from run to run, so they're replaced before
+// the entries are compared
+var (
+	testTimestampRegexp = regexp.MustCompile(
+		`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})`,
+	)
+	testCallerRegexp = regexp.MustCompile(`logging/logging_test\.go:\d+`)
+)
+
+func TestBuildLogger(t *testing.T) {
+	testDefs := []struct {
+		format    string
+		wantLines []string
+	}{
+		{
+			format: config.LogFormatJson,
+			wantLines: []string{
+				`{"level":"info","timestamp":"<timestamp>","logger":"api","caller":"<caller>","msg":"info message","key":"value"}`,
+				`{"level":"warn","timestamp":"<timestamp>","logger":"api","caller":"<caller>","msg":"warn message","count":3}`,
+			},
+		},
+		{
+			format: config.LogFormatConsole,
+			wantLines: []string{
+				"<timestamp>\tINFO\tapi\t<caller>\tinfo message\t{\"key\": \"value\"}",
+				"<timestamp>\tWARN\tapi\t<caller>\twarn message\t{\"count\": 3}",
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.log")
+			l, err := buildLogger(
+				testDef.format,
+				path,
+				&config.LoggingConfig{MaxSize: 1},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			t.Cleanup(func() {
+				fileWritersMutex.Lock()
+				defer fileWritersMutex.Unlock()
+				_ = fileWriters[path].Close()
+				delete(fileWriters, path)
+			})
+			logger := withLevel(l, zap.NewAtomicLevelAt(zap.InfoLevel)).
+				Named("api").
+				Sugar()
+			logger.Infow("info message", "key", "value")
+			// This is below the level, so it's left out
+			logger.Debugw("debug message", "key", "value")
+			logger.Warnw("warn message", "count", 3)
+			logData, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read log file: %s", err)
+			}
+			gotLines := strings.Split(strings.TrimSuffix(string(logData), "\n"), "\n")
+			if len(gotLines) != len(testDef.wantLines) {
+				t.Fatalf("unexpected log entries:\n%s", logData)
+			}
+			for idx, gotLine := range gotLines {
+				timestamp := testTimestampRegexp.FindString(gotLine)
+				if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+					t.Fatalf("no RFC3339 timestamp in log entry: %s", gotLine)
+				}
+				gotLine = strings.Replace(gotLine, timestamp, "<timestamp>", 1)
+				gotLine = testCallerRegexp.ReplaceAllString(gotLine, "<caller>")
+				if gotLine != testDef.wantLines[idx] {
+					t.Fatalf(
+						"unexpected log entry:\n got: %s\nwant: %s",
+						gotLine,
+						testDef.wantLines[idx],
+					)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Circuit breaker states
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateHalfOpen = "half-open"
+	BreakerStateOpen     = "open"
+)
+
+// Values of the circuit breaker state gauge
+var breakerStateValues = map[string]float64{
+	BreakerStateClosed:   0,
+	BreakerStateHalfOpen: 1,
+	BreakerStateOpen:     2,
+}
+
+// BreakerOpenError is returned instead of connecting to the node while the
+// circuit breaker is open
+type BreakerOpenError struct {
+	// Time until the breaker next probes the node
+	RetryAfter time.Duration
+}
+
+func (e *BreakerOpenError) Error() string {
+	return "node connection circuit breaker is open after repeated connection failures"
+}
+
+// circuitBreaker stops us connecting to the node after repeated failures, so
+// that requests fail fast rather than each waiting for the connection to time
+// out. While open, it probes the node periodically and closes once a probe
+// succeeds
+type circuitBreaker struct {
+	mutex        sync.Mutex
+	state        string
+	failures     uint
+	firstFailure time.Time
+	nextProbe    time.Time
+}
+
+var breaker = &circuitBreaker{state: BreakerStateClosed}
+
+// BreakerState returns the current state of the node connection circuit breaker
+func BreakerState() string {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	return breaker.state
+}
+
+// allow returns an error if connections to the node are currently blocked
+func (b *circuitBreaker) allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == BreakerStateClosed {
+		return nil
+	}
+	return &BreakerOpenError{RetryAfter: max(time.Until(b.nextProbe), 0)}
+}
+
+// record updates the breaker with the result of a connection attempt
+func (b *circuitBreaker) record(err error) {
+	cfg := config.GetConfig()
+	if cfg.Node.BreakerFailures == 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err == nil {
+		b.failures = 0
+		if b.state != BreakerStateClosed {
+			logging.GetLogger(logging.ComponentNode).
+				Infof("closing node connection circuit breaker")
+			b.setState(BreakerStateClosed)
+		}
+		return
+	}
+	if b.state != BreakerStateClosed {
+		return
+	}
+	window := time.Duration(cfg.Node.BreakerWindow) * time.Second
+	if b.failures == 0 || time.Since(b.firstFailure) > window {
+		b.failures = 0
+		b.firstFailure = time.Now()
+	}
+	b.failures++
+	if b.failures >= cfg.Node.BreakerFailures {
+		logging.GetLogger(logging.ComponentNode).Warnf(
+			"opening node connection circuit breaker after %d consecutive failures: %s",
+			b.failures,
+			err,
+		)
+		b.open()
+	}
+}
+
+// open blocks connections and schedules the next probe. The mutex must be held
+func (b *circuitBreaker) open() {
+	interval := time.Duration(config.GetConfig().Node.BreakerProbeInterval) *
+		time.Second
+	b.setState(BreakerStateOpen)
+	b.nextProbe = time.Now().Add(interval)
+	time.AfterFunc(interval, b.probe)
+}
+
+// probe tries to connect to the node while half-open, closing the breaker on
+// success and opening it again on failure
+func (b *circuitBreaker) probe() {
+	b.mutex.Lock()
+	if b.state != BreakerStateOpen {
+		b.mutex.Unlock()
+		return
+	}
+	b.setState(BreakerStateHalfOpen)
+	b.mutex.Unlock()
+	oConn, _, err := openConnection(&ConnectionConfig{skipBreaker: true}, -1)
+	if err == nil {
+		oConn.Close()
+		b.record(nil)
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	// A connection from elsewhere may have closed the breaker in the meantime
+	if b.state == BreakerStateHalfOpen {
+		logging.GetLogger(logging.ComponentNode).Debugf(
+			"node connection circuit breaker probe failed: %s",
+			err,
+		)
+		b.open()
+	}
+}
+
+// setState updates the state and its gauge. The mutex must be held
+func (b *circuitBreaker) setState(state string) {
+	b.state = state
+	updateBreakerMetric(state)
+}
+
+func updateBreakerMetric(state string) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricBreakerState).
+		SetGaugeValue(nil, breakerStateValues[state])
+}
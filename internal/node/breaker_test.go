@@ -0,0 +1,213 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+// Steps applied to a circuit breaker in the tests
+var (
+	breakerFail = func(t *testing.T, b *circuitBreaker) {
+		b.record(errors.New("connection refused"))
+	}
+	breakerSucceed = func(t *testing.T, b *circuitBreaker) {
+		b.record(nil)
+	}
+	// Move the first failure out of the failure window
+	breakerExpireWindow = func(t *testing.T, b *circuitBreaker) {
+		window := time.Duration(config.GetConfig().Node.BreakerWindow) *
+			time.Second
+		b.firstFailure = time.Now().Add(-2 * window)
+	}
+	breakerProbeUp = func(t *testing.T, b *circuitBreaker) {
+		nodetest.StartMockNode(t, 16, []ouroboros_mock.ConversationEntry{})
+		b.probe()
+	}
+	breakerProbeDown = func(t *testing.T, b *circuitBreaker) {
+		cfg := config.GetConfig()
+		nodeCfg := cfg.Node
+		t.Cleanup(func() { cfg.Node = nodeCfg })
+		cfg.Node.Endpoints = []string{
+			filepath.Join(t.TempDir(), "missing.socket"),
+		}
+		b.probe()
+	}
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	testDefs := []struct {
+		name  string
+		steps []func(*testing.T, *circuitBreaker)
+		// Whether the breaker is disabled, otherwise it opens after 3 failures
+		// in 60s
+		disabled     bool
+		wantState    string
+		wantFailures uint
+	}{
+		{
+			name:         "stays closed below threshold",
+			steps:        []func(*testing.T, *circuitBreaker){breakerFail, breakerFail},
+			wantState:    BreakerStateClosed,
+			wantFailures: 2,
+		},
+		{
+			name: "opens at threshold",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+			},
+			wantState:    BreakerStateOpen,
+			wantFailures: 3,
+		},
+		{
+			name: "success resets failures",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerSucceed,
+				breakerFail,
+				breakerFail,
+			},
+			wantState:    BreakerStateClosed,
+			wantFailures: 2,
+		},
+		{
+			name: "failures outside window are forgotten",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerExpireWindow,
+				breakerFail,
+			},
+			wantState:    BreakerStateClosed,
+			wantFailures: 1,
+		},
+		{
+			name: "failures while open are ignored",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+				breakerFail,
+			},
+			wantState:    BreakerStateOpen,
+			wantFailures: 3,
+		},
+		{
+			name: "success while open closes",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+				breakerSucceed,
+			},
+			wantState:    BreakerStateClosed,
+			wantFailures: 0,
+		},
+		{
+			name: "probe success closes",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+				breakerProbeUp,
+			},
+			wantState:    BreakerStateClosed,
+			wantFailures: 0,
+		},
+		{
+			name: "probe failure reopens",
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+				breakerProbeDown,
+			},
+			wantState:    BreakerStateOpen,
+			wantFailures: 3,
+		},
+		{
+			name:         "probe while closed does nothing",
+			steps:        []func(*testing.T, *circuitBreaker){breakerFail, breakerProbeDown},
+			wantState:    BreakerStateClosed,
+			wantFailures: 1,
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			steps: []func(*testing.T, *circuitBreaker){
+				breakerFail,
+				breakerFail,
+				breakerFail,
+			},
+			wantState: BreakerStateClosed,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			cfg := config.GetConfig()
+			nodeCfg := cfg.Node
+			t.Cleanup(func() { cfg.Node = nodeCfg })
+			cfg.Node.BreakerFailures = 3
+			if testDef.disabled {
+				cfg.Node.BreakerFailures = 0
+			}
+			cfg.Node.BreakerWindow = 60
+			// Long enough that the scheduled probe never runs during the test
+			cfg.Node.BreakerProbeInterval = 3600
+			// Probes connect through the shared breaker, so leave it closed
+			t.Cleanup(func() {
+				breaker.mutex.Lock()
+				defer breaker.mutex.Unlock()
+				breaker.state = BreakerStateClosed
+				breaker.failures = 0
+			})
+			b := &circuitBreaker{state: BreakerStateClosed}
+			for _, step := range testDef.steps {
+				step(t, b)
+			}
+			if b.state != testDef.wantState {
+				t.Fatalf("unexpected state: %s", b.state)
+			}
+			if b.failures != testDef.wantFailures {
+				t.Fatalf("unexpected failure count: %d", b.failures)
+			}
+			err := b.allow()
+			if testDef.wantState == BreakerStateClosed {
+				if err != nil {
+					t.Fatalf("unexpected error from closed breaker: %s", err)
+				}
+				return
+			}
+			var openErr *BreakerOpenError
+			if !errors.As(err, &openErr) {
+				t.Fatalf("expected BreakerOpenError, got %v", err)
+			}
+			if openErr.RetryAfter <= 0 || openErr.RetryAfter > time.Hour {
+				t.Fatalf("unexpected retry after: %s", openErr.RetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
+)
+
+// TimeoutError is returned when a call to the node doesn't complete within the
+// configured timeout for its mini-protocol, or before the context deadline
+type TimeoutError struct {
+	Protocol  string
+	Operation string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf(
+		"%s: timed out waiting for %s response from node",
+		e.Protocol,
+		e.Operation,
+	)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Run calls a node mini-protocol function on a pooled connection inside a trace
+// span, and counts any error against the mini-protocol. The call is abandoned if
+// it doesn't complete within the mini-protocol's timeout or before the context
+// is done. The connection is then closed rather than returned to the pool, since
+// it's left in an unknown state
+func Run(
+	ctx context.Context,
+	conn *PooledConnection,
+	protocol string,
+	op string,
+	fn func() error,
+) error {
+	_, err := Call(
+		ctx,
+		conn,
+		protocol,
+		op,
+		func() (struct{}, error) {
+			return struct{}{}, fn()
+		},
+	)
+	return err
+}
+
+// Call is like Run for functions that also return a result
+func Call[T any](
+	ctx context.Context,
+	conn *PooledConnection,
+	protocol string,
+	op string,
+	fn func() (T, error),
+) (T, error) {
+	return call(ctx, conn.abandon, protocol, op, fn)
+}
+
+// call is like Call for any node connection. The abandon function closes the
+// connection if the call is abandoned
+func call[T any](
+	ctx context.Context,
+	abandon func(),
+	protocol string,
+	op string,
+	fn func() (T, error),
+) (T, error) {
+	recordProtocolRequest(protocol)
+	ret, err := tracing.Call(
+		ctx,
+		protocol+"."+op,
+		func() (T, error) {
+			return callWithTimeout(ctx, abandon, protocol, op, fn)
+		},
+	)
+	// A rejected TX is a normal response from the node
+	var txRejectErr localtxsubmission.TransactionRejectedError
+	if err != nil && !errors.As(err, &txRejectErr) {
+		RecordProtocolError(protocol)
+	}
+	return ret, err
+}
+
+// Await is like Run for calls that wait on the node, such as acquiring the next
+// mempool snapshot. The mini-protocol timeout doesn't apply, so only the context
+// bounds the call. The context ending isn't counted as a protocol error, since
+// it's how the caller stops waiting
+func Await(
+	ctx context.Context,
+	conn *PooledConnection,
+	protocol string,
+	op string,
+	fn func() error,
+) error {
+	recordProtocolRequest(protocol)
+	_, err := tracing.Call(
+		ctx,
+		protocol+"."+op,
+		func() (struct{}, error) {
+			resultChan := make(chan error, 1)
+			go func() {
+				resultChan <- fn()
+			}()
+			select {
+			case err := <-resultChan:
+				return struct{}{}, err
+			case <-ctx.Done():
+				conn.abandon()
+				return struct{}{}, ctx.Err()
+			}
+		},
+	)
+	if err != nil && ctx.Err() == nil {
+		RecordProtocolError(protocol)
+	}
+	return err
+}
+
+func callWithTimeout[T any](
+	ctx context.Context,
+	abandon func(),
+	protocol string,
+	op string,
+	fn func() (T, error),
+) (T, error) {
+	callCtx := ctx
+	if timeout := protocolTimeout(protocol); timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	type result struct {
+		ret T
+		err error
+	}
+	// The gouroboros clients don't take a context, so we wait for the result
+	// in the background. Closing the connection makes the call return
+	resultChan := make(chan result, 1)
+	go func() {
+		ret, err := fn()
+		resultChan <- result{ret: ret, err: err}
+	}()
+	select {
+	case res := <-resultChan:
+		return res.ret, res.err
+	case <-callCtx.Done():
+		abandon()
+		var zero T
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return zero, ctx.Err()
+		}
+		_ = ginmetrics.GetMonitor().
+			GetMetric(metricProtocolTimeouts).
+			Inc([]string{protocol})
+		return zero, &TimeoutError{Protocol: protocol, Operation: op}
+	}
+}
+
+// protocolTimeout returns the configured timeout for calls on a mini-protocol
+func protocolTimeout(protocol string) time.Duration {
+	cfg := config.GetConfig()
+	var timeout uint
+	switch protocol {
+	case localstatequery.ProtocolName:
+		timeout = cfg.Node.LocalStateQueryTimeout
+	case localtxmonitor.ProtocolName:
+		timeout = cfg.Node.LocalTxMonitorTimeout
+	case localtxsubmission.ProtocolName:
+		timeout = cfg.Node.TxSubmissionTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
@@ -68,7 +68,7 @@ func chainSyncRollBackwardHandler(
 			nil,
 			input_chainsync.NewRollbackEvent(point),
 		)
-		connCfg.ChainSyncEventChan <- evt
+		sendChainSyncEvent(connCfg, evt)
 	}
 	return nil
 }
@@ -108,7 +108,21 @@ func chainSyncRollForwardHandler(
 			input_chainsync.NewBlockContext(block, cfg.Node.NetworkMagic),
 			input_chainsync.NewBlockEvent(block, true),
 		)
-		connCfg.ChainSyncEventChan <- evt
+		sendChainSyncEvent(connCfg, evt)
 	}
 	return nil
 }
+
+// sendChainSyncEvent sends an event to the chain-sync event channel, giving up
+// if the connection context is done so that a closed stream doesn't block the
+// protocol handler forever
+func sendChainSyncEvent(connCfg ConnectionConfig, evt event.Event) {
+	if connCfg.Context == nil {
+		connCfg.ChainSyncEventChan <- evt
+		return
+	}
+	select {
+	case connCfg.ChainSyncEventChan <- evt:
+	case <-connCfg.Context.Done():
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/blinklabs-io/adder/event"
+	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Event type sent on chain-sync streams after reconnecting to the node
+const EventTypeChainSyncReconnect = "chainsync.reconnect"
+
+// Number of recent points offered as intersect points when resuming a
+// chain-sync, in case the node has switched to a different fork
+const chainSyncResumePoints = 10
+
+// ReconnectEvent is sent on a chain-sync stream after the connection to the node
+// was lost and re-established. The stream resumes from the most recent point
+// that's still on the chain, which the node reports with a rollback event, so
+// clients may want to check for anything they missed in the meantime. Failover
+// is set when the stream resumed on a different node endpoint, which may have a
+// different view of the chain, so there's a possible gap in the events
+type ReconnectEvent struct {
+	SlotNumber uint64 `json:"slotNumber"`
+	BlockHash  string `json:"blockHash"`
+	Error      string `json:"error"`
+	Endpoint   string `json:"endpoint"`
+	Failover   bool   `json:"failover"`
+}
+
+// ChainSyncStream follows the chain on a dedicated node connection, which is
+// replaced if it fails
+type ChainSyncStream struct {
+	ctx          context.Context
+	events       chan event.Event
+	err          error
+	startPoint   common.Point
+	recentPoints []common.Point
+	oConn        *ouroboros.Connection
+	endpoint     int
+	connEvents   chan event.Event
+	connCancel   context.CancelFunc
+}
+
+// StartChainSyncStream connects to the node and starts a chain-sync from the
+// given intersect points, or from the current tip when none are provided.
+// Errors connecting or finding the intersection are returned directly. After
+// that, the stream reconnects and resumes if the connection to the node is lost
+func StartChainSyncStream(
+	ctx context.Context,
+	intersectPoints []common.Point,
+) (*ChainSyncStream, error) {
+	s := &ChainSyncStream{
+		ctx:    ctx,
+		events: make(chan event.Event, 10),
+	}
+	if err := s.connect(intersectPoints); err != nil {
+		return nil, err
+	}
+	recordEndpointRequest(s.Endpoint())
+	recordProtocolRequest(chainsync.ProtocolName)
+	go s.run()
+	return s, nil
+}
+
+// Events returns the chain-sync events. The channel is closed when the context
+// is done or the stream can't be resumed, in which case Err returns the reason
+func (s *ChainSyncStream) Events() <-chan event.Event {
+	return s.events
+}
+
+// Err returns the error that ended the stream, if any. It's only valid after the
+// events channel is closed
+func (s *ChainSyncStream) Err() error {
+	return s.err
+}
+
+// Endpoint returns the node endpoint that the stream is currently following
+func (s *ChainSyncStream) Endpoint() string {
+	return config.GetConfig().Node.GetEndpoints()[s.endpoint].String()
+}
+
+// IntersectPoint returns the point that the stream started from
+func (s *ChainSyncStream) IntersectPoint() common.Point {
+	return s.startPoint
+}
+
+func (s *ChainSyncStream) connect(intersectPoints []common.Point) error {
+	connCtx, connCancel := context.WithCancel(s.ctx)
+	connEvents := make(chan event.Event, 10)
+	oConn, endpointIdx, err := openConnection(
+		&ConnectionConfig{
+			ChainSyncEventChan: connEvents,
+			Context:            connCtx,
+		},
+		-1,
+	)
+	if err != nil {
+		connCancel()
+		return err
+	}
+	if len(intersectPoints) == 0 {
+		tip, err := oConn.ChainSync().Client.GetCurrentTip()
+		if err != nil {
+			connCancel()
+			return err
+		}
+		intersectPoints = []common.Point{tip.Point}
+	}
+	if err := oConn.ChainSync().Client.Sync(intersectPoints); err != nil {
+		connCancel()
+		return err
+	}
+	if len(s.recentPoints) == 0 {
+		s.startPoint = intersectPoints[0]
+		s.recentPoints = intersectPoints[:1]
+	}
+	s.oConn = oConn
+	s.endpoint = endpointIdx
+	s.connEvents = connEvents
+	s.connCancel = connCancel
+	return nil
+}
+
+func (s *ChainSyncStream) run() {
+	defer close(s.events)
+	defer func() {
+		s.connCancel()
+	}()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case evt := <-s.connEvents:
+			s.trackPoint(evt)
+			select {
+			case s.events <- evt:
+			case <-s.ctx.Done():
+				return
+			}
+		case err, ok := <-s.oConn.ErrorChan():
+			if s.ctx.Err() != nil {
+				return
+			}
+			if !ok {
+				err = fmt.Errorf("connection closed")
+			}
+			RecordConnectionError(err)
+			s.connCancel()
+			if err := s.reconnect(err); err != nil {
+				s.err = err
+				return
+			}
+		}
+	}
+}
+
+// trackPoint records the chain point of a block or rollback event, so that we
+// know where to resume from
+func (s *ChainSyncStream) trackPoint(evt event.Event) {
+	var point common.Point
+	switch payload := evt.Payload.(type) {
+	case input_chainsync.BlockEvent:
+		blockCtx, ok := evt.Context.(input_chainsync.BlockContext)
+		if !ok {
+			return
+		}
+		hash, err := hex.DecodeString(payload.BlockHash)
+		if err != nil {
+			return
+		}
+		point = common.NewPoint(blockCtx.SlotNumber, hash)
+	case input_chainsync.RollbackEvent:
+		hash, err := hex.DecodeString(payload.BlockHash)
+		if err != nil {
+			return
+		}
+		point = common.NewPoint(payload.SlotNumber, hash)
+		// Forget the points that were rolled back
+		for len(s.recentPoints) > 0 &&
+			s.recentPoints[len(s.recentPoints)-1].Slot >= point.Slot {
+			s.recentPoints = s.recentPoints[:len(s.recentPoints)-1]
+		}
+	default:
+		return
+	}
+	s.recentPoints = append(s.recentPoints, point)
+	if len(s.recentPoints) > chainSyncResumePoints {
+		s.recentPoints = s.recentPoints[1:]
+	}
+}
+
+// reconnect retries the connection with backoff until the chain-sync resumes
+func (s *ChainSyncStream) reconnect(lostErr error) error {
+	cfg := config.GetConfig()
+	logger := logging.GetLogger(logging.ComponentChainsync)
+	lastPoint := s.recentPoints[len(s.recentPoints)-1]
+	// Offer the most recent points first
+	resumePoints := make([]common.Point, 0, len(s.recentPoints))
+	for i := len(s.recentPoints) - 1; i >= 0; i-- {
+		resumePoints = append(resumePoints, s.recentPoints[i])
+	}
+	maxInterval := time.Duration(cfg.Node.RetryMaxInterval) * time.Second
+	previousEndpoint := s.endpoint
+	var attempts uint
+	connErr := lostErr
+	for {
+		attempts++
+		delay := retryDelay(attempts, maxInterval)
+		logger.Warnf(
+			"lost chain-sync connection to node at slot %d, reconnecting in %s: %s",
+			lastPoint.Slot,
+			delay.Round(time.Millisecond),
+			connErr,
+		)
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		err := s.connect(resumePoints)
+		if err == nil {
+			break
+		}
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		if cfg.Node.RetryMaxAttempts > 0 && attempts >= cfg.Node.RetryMaxAttempts {
+			return fmt.Errorf(
+				"failed to resume chain-sync after %d attempts: %s",
+				attempts,
+				err,
+			)
+		}
+		connErr = err
+	}
+	RecordReconnect(reconnectConsumerChainsync)
+	failover := s.endpoint != previousEndpoint
+	if failover {
+		logger.Warnf(
+			"resumed chain-sync on node endpoint %s after failing over, there may be a gap in the stream",
+			s.Endpoint(),
+		)
+	} else {
+		logger.Infof("resumed chain-sync after reconnecting to node")
+	}
+	evt := event.New(
+		EventTypeChainSyncReconnect,
+		time.Now(),
+		nil,
+		ReconnectEvent{
+			SlotNumber: lastPoint.Slot,
+			BlockHash:  hex.EncodeToString(lastPoint.Hash),
+			Error:      lostErr.Error(),
+			Endpoint:   s.Endpoint(),
+			Failover:   failover,
+		},
+	)
+	select {
+	case s.events <- evt:
+	case <-s.ctx.Done():
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Index of the node endpoint that new connections try first. This only changes
+// when a connection to it fails and another endpoint works, or when the
+// connection manager fails back to a more preferred endpoint
+var activeEndpoint atomic.Int32
+
+// ActiveEndpoint returns the node endpoint that new connections are made to
+func ActiveEndpoint() string {
+	endpoints := config.GetConfig().Node.GetEndpoints()
+	return endpoints[activeEndpoint.Load()].String()
+}
+
+// endpointOrder returns the order to try the endpoints in, which is the active
+// endpoint followed by the rest in order of preference
+func endpointOrder(count int) []int {
+	active := int(activeEndpoint.Load())
+	ret := make([]int, 0, count)
+	ret = append(ret, active)
+	for idx := 0; idx < count; idx++ {
+		if idx != active {
+			ret = append(ret, idx)
+		}
+	}
+	return ret
+}
+
+// setActiveEndpoint switches new connections to the specified endpoint
+func setActiveEndpoint(idx int) {
+	previous := int(activeEndpoint.Swap(int32(idx)))
+	if previous == idx {
+		return
+	}
+	endpoints := config.GetConfig().Node.GetEndpoints()
+	logger := logging.GetLogger(logging.ComponentNode)
+	if idx < previous {
+		logger.Infof(
+			"failing back to node endpoint %s from %s",
+			endpoints[idx],
+			endpoints[previous],
+		)
+	} else {
+		logger.Warnf(
+			"failing over to node endpoint %s from %s",
+			endpoints[idx],
+			endpoints[previous],
+		)
+	}
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricFailovers).
+		Inc([]string{endpoints[idx].String()})
+	updateActiveEndpointMetric()
+}
+
+func updateActiveEndpointMetric() {
+	active := int(activeEndpoint.Load())
+	metric := ginmetrics.GetMonitor().GetMetric(metricActiveEndpoint)
+	for idx, endpoint := range config.GetConfig().Node.GetEndpoints() {
+		value := float64(0)
+		if idx == active {
+			value = 1
+		}
+		_ = metric.SetGaugeValue([]string{endpoint.String()}, value)
+	}
+}
+
+// recordEndpointRequest counts a request being served by the specified endpoint
+func recordEndpointRequest(endpoint string) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricEndpointRequests).
+		Inc([]string{endpoint})
+}
+
+// dial opens the underlying connection to a cardano-node endpoint
+func dial(endpoint config.NodeEndpoint) (net.Conn, error) {
+	switch endpoint.Network {
+	case "tcp":
+		// Connect to TCP port. The address is resolved again for each
+		// connection, so that we follow DNS changes such as a node that moved
+		// behind a service name
+		conn, err := net.DialTimeout(
+			"tcp",
+			endpoint.Address,
+			ouroboros.DefaultConnectTimeout,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failure connecting to node via TCP: %s",
+				err,
+			)
+		}
+		return conn, nil
+	case "unix":
+		if endpoint.Address == "" {
+			break
+		}
+		if err := CheckSocketPath(endpoint.Address); err != nil {
+			return nil, err
+		}
+		conn, err := net.DialTimeout(
+			"unix",
+			endpoint.Address,
+			ouroboros.DefaultConnectTimeout,
+		)
+		if err != nil {
+			// Connecting needs write access to the socket, which stat doesn't
+			// tell us about
+			if errors.Is(err, syscall.EACCES) {
+				return nil, newSocketPathError(
+					endpoint.Address,
+					socketPathPermissionDenied,
+				)
+			}
+			return nil, fmt.Errorf("failure connecting to node via UNIX socket: %s", err)
+		}
+		return conn, nil
+	}
+	return nil, fmt.Errorf("you must specify either the UNIX socket path or the address/port for your cardano-node")
+}
+
+// Reasons that the node socket path can't be used
+const (
+	socketPathNotExist         = "does not exist"
+	socketPathNotSocket        = "exists but is not a socket"
+	socketPathPermissionDenied = "is not accessible (permission denied)"
+)
+
+// SocketPathError describes why the node socket path can't be used, which is
+// usually a misconfiguration rather than the node being down
+type SocketPathError struct {
+	// Absolute path to the socket
+	Path   string
+	Reason string
+	// Effective user and group that we're running as
+	Uid int
+	Gid int
+}
+
+func (e *SocketPathError) Error() string {
+	return fmt.Sprintf(
+		"node socket path %s %s, running as uid %d gid %d",
+		e.Path,
+		e.Reason,
+		e.Uid,
+		e.Gid,
+	)
+}
+
+func newSocketPathError(path string, reason string) *SocketPathError {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	return &SocketPathError{
+		Path:   path,
+		Reason: reason,
+		Uid:    os.Geteuid(),
+		Gid:    os.Getegid(),
+	}
+}
+
+// CheckSocketPath returns a SocketPathError if the node socket path doesn't
+// exist, isn't a socket, or can't be accessed
+func CheckSocketPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newSocketPathError(path, socketPathNotExist)
+		}
+		if os.IsPermission(err) {
+			return newSocketPathError(path, socketPathPermissionDenied)
+		}
+		return fmt.Errorf("unknown error checking if node socket path exists: %s", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return newSocketPathError(path, socketPathNotSocket)
+	}
+	return nil
+}
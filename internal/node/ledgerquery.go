@@ -0,0 +1,385 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/ledger"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// LocalStateQuery messages for acquiring the immutable tip, which gouroboros
+// doesn't have
+const (
+	msgTypeAcquireImmutableTip   = 10
+	msgTypeReacquireImmutableTip = 11
+)
+
+// Node-to-client protocol version that added acquiring the immutable tip
+const protocolVersionImmutableTip = 16
+
+var (
+	lsqStateIdle      = protocol.NewState(1, "Idle")
+	lsqStateAcquiring = protocol.NewState(2, "Acquiring")
+	lsqStateAcquired  = protocol.NewState(3, "Acquired")
+)
+
+// ledgerQueryStateMap is the gouroboros LocalStateQuery state machine with the
+// immutable tip messages added
+var ledgerQueryStateMap = func() protocol.StateMap {
+	ret := localstatequery.StateMap.Copy()
+	addTransition := func(state protocol.State, msgType uint8) {
+		entry := ret[state]
+		entry.Transitions = append(
+			slices.Clone(entry.Transitions),
+			protocol.StateTransition{
+				MsgType:  msgType,
+				NewState: lsqStateAcquiring,
+			},
+		)
+		ret[state] = entry
+	}
+	addTransition(lsqStateIdle, msgTypeAcquireImmutableTip)
+	addTransition(lsqStateAcquired, msgTypeReacquireImmutableTip)
+	return ret
+}()
+
+type msgAcquireImmutableTip struct {
+	protocol.MessageBase
+}
+
+// AcquireTarget selects the ledger state to acquire for queries. The zero value
+// is the volatile tip
+type AcquireTarget struct {
+	// Point is a specific point on the chain to acquire
+	Point *common.Point
+	// Immutable acquires the immutable tip, which needs node-to-client protocol
+	// version 16 or later
+	Immutable bool
+}
+
+// EraMismatchError is returned for a ledger query that's for a different era
+// than the acquired ledger state
+type EraMismatchError struct {
+	QueryEra  string
+	LedgerEra string
+}
+
+func (e *EraMismatchError) Error() string {
+	return fmt.Sprintf(
+		"query is for the %s era, but the ledger state is in the %s era",
+		e.QueryEra,
+		e.LedgerEra,
+	)
+}
+
+// LedgerQuery sends LocalStateQuery queries given as CBOR and returns the raw
+// results. gouroboros only sends the queries that it knows about, so this runs
+// its own client for the mini-protocol on a dedicated node connection, where
+// the gouroboros client is never started. A LedgerQuery is used by one request
+// at a time
+type LedgerQuery struct {
+	oConn           *ouroboros.Connection
+	endpoint        int
+	protocolVersion uint16
+	proto           *protocol.Protocol
+	msgChan         chan protocol.Message
+	errChan         chan error
+	acquired        bool
+	// The era of the acquired ledger state, or -1 if it hasn't been queried
+	era int
+}
+
+// OpenLedgerQuery connects to the node for ledger queries. The connection is
+// closed when the context is done or Close is called
+func OpenLedgerQuery(ctx context.Context) (*LedgerQuery, error) {
+	oConn, endpointIdx, err := openConnection(
+		&ConnectionConfig{Context: ctx},
+		-1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	protocolVersion, _ := oConn.ProtocolVersion()
+	q := &LedgerQuery{
+		oConn:           oConn,
+		endpoint:        endpointIdx,
+		protocolVersion: protocolVersion - protocol.ProtocolVersionNtCOffset,
+		// Only one reply is outstanding at a time
+		msgChan: make(chan protocol.Message, 1),
+		errChan: make(chan error, 1),
+		era:     -1,
+	}
+	q.proto = protocol.New(protocol.ProtocolConfig{
+		Name:                localstatequery.ProtocolName,
+		ProtocolId:          localstatequery.ProtocolId,
+		ErrorChan:           q.errChan,
+		Muxer:               oConn.Muxer(),
+		Mode:                protocol.ProtocolModeNodeToClient,
+		Role:                protocol.ProtocolRoleClient,
+		MessageHandlerFunc:  q.handleMessage,
+		MessageFromCborFunc: localstatequery.NewMsgFromCbor,
+		StateMap:            ledgerQueryStateMap,
+		InitialState:        lsqStateIdle,
+	})
+	q.proto.Start()
+	recordEndpointRequest(q.Endpoint())
+	return q, nil
+}
+
+// Endpoint returns the node endpoint that the queries are sent to
+func (q *LedgerQuery) Endpoint() string {
+	return config.GetConfig().Node.GetEndpoints()[q.endpoint].String()
+}
+
+// Close closes the node connection
+func (q *LedgerQuery) Close() {
+	q.oConn.Close()
+}
+
+func (q *LedgerQuery) abandon() {
+	q.oConn.Close()
+}
+
+// Acquire acquires a ledger state for the queries that follow, replacing any
+// ledger state that's already acquired
+func (q *LedgerQuery) Acquire(ctx context.Context, target AcquireTarget) error {
+	var msg protocol.Message
+	switch {
+	case target.Immutable:
+		if q.protocolVersion < protocolVersionImmutableTip {
+			return fmt.Errorf(
+				"acquiring the immutable tip needs node-to-client protocol version %d or later, but the node is using version %d",
+				protocolVersionImmutableTip,
+				q.protocolVersion,
+			)
+		}
+		msgType := uint8(msgTypeAcquireImmutableTip)
+		if q.acquired {
+			msgType = msgTypeReacquireImmutableTip
+		}
+		msg = &msgAcquireImmutableTip{
+			MessageBase: protocol.MessageBase{MessageType: msgType},
+		}
+	case target.Point != nil && q.acquired:
+		msg = localstatequery.NewMsgReAcquire(*target.Point)
+	case target.Point != nil:
+		msg = localstatequery.NewMsgAcquire(*target.Point)
+	case q.acquired:
+		msg = localstatequery.NewMsgReAcquireNoPoint()
+	default:
+		msg = localstatequery.NewMsgAcquireNoPoint()
+	}
+	_, err := call(
+		ctx,
+		q.abandon,
+		localstatequery.ProtocolName,
+		"acquire",
+		func() (struct{}, error) {
+			reply, err := q.send(msg)
+			if err != nil {
+				return struct{}{}, err
+			}
+			switch reply := reply.(type) {
+			case *localstatequery.MsgAcquired:
+				return struct{}{}, nil
+			case *localstatequery.MsgFailure:
+				switch reply.Failure {
+				case localstatequery.AcquireFailurePointTooOld:
+					return struct{}{}, localstatequery.AcquireFailurePointTooOldError{}
+				case localstatequery.AcquireFailurePointNotOnChain:
+					return struct{}{}, localstatequery.AcquireFailurePointNotOnChainError{}
+				}
+				return struct{}{}, fmt.Errorf(
+					"acquire failure: unknown reason %d",
+					reply.Failure,
+				)
+			}
+			return struct{}{}, unexpectedMessageError(reply)
+		},
+	)
+	// A failed acquire leaves no ledger state acquired
+	q.acquired = err == nil
+	q.era = -1
+	return err
+}
+
+// Query sends a query and returns the raw result. The query is encoded as CBOR,
+// unless it's a cbor.RawMessage. A ledger state must be acquired first
+func (q *LedgerQuery) Query(
+	ctx context.Context,
+	op string,
+	query any,
+) ([]byte, error) {
+	if !q.acquired {
+		return nil, fmt.Errorf("no ledger state is acquired")
+	}
+	return call(
+		ctx,
+		q.abandon,
+		localstatequery.ProtocolName,
+		op,
+		func() ([]byte, error) {
+			reply, err := q.send(localstatequery.NewMsgQuery(query))
+			if err != nil {
+				return nil, err
+			}
+			result, ok := reply.(*localstatequery.MsgResult)
+			if !ok {
+				return nil, unexpectedMessageError(reply)
+			}
+			return result.Result, nil
+		},
+	)
+}
+
+// CurrentEra returns the era of the acquired ledger state
+func (q *LedgerQuery) CurrentEra(ctx context.Context) (uint, error) {
+	if q.era >= 0 {
+		return uint(q.era), nil
+	}
+	result, err := q.Query(
+		ctx,
+		"query current-era",
+		[]any{
+			localstatequery.QueryTypeBlock,
+			[]any{
+				localstatequery.QueryTypeHardFork,
+				[]any{localstatequery.QueryTypeHardForkCurrentEra},
+			},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	var era uint
+	if _, err := cbor.Decode(result, &era); err != nil {
+		return 0, fmt.Errorf("failed to decode current era: %s", err)
+	}
+	q.era = int(era)
+	return era, nil
+}
+
+// QueryLedger sends a Shelley-based ledger query for the era of the acquired
+// ledger state and returns the result. The query is the query type followed by
+// its parameters
+func (q *LedgerQuery) QueryLedger(
+	ctx context.Context,
+	op string,
+	query ...any,
+) ([]byte, error) {
+	era, err := q.CurrentEra(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := q.Query(
+		ctx,
+		op,
+		[]any{
+			localstatequery.QueryTypeBlock,
+			[]any{
+				localstatequery.QueryTypeShelley,
+				[]any{era, query},
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return UnwrapLedgerResult(result)
+}
+
+// UnwrapLedgerResult returns the result of a Shelley-based ledger query, which
+// the node wraps in a list. An EraMismatchError is returned if the query was for
+// a different era than the ledger state
+func UnwrapLedgerResult(result []byte) ([]byte, error) {
+	var items []cbor.RawMessage
+	if _, err := cbor.Decode(result, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode query result: %s", err)
+	}
+	switch len(items) {
+	case 1:
+		return items[0], nil
+	case 2:
+		// The node sends the eras of the query and of the ledger state, each as
+		// the era index and name
+		var eras [2]struct {
+			cbor.StructAsArray
+			Id   uint8
+			Name string
+		}
+		for idx, item := range items {
+			if _, err := cbor.Decode(item, &eras[idx]); err != nil {
+				return nil, fmt.Errorf(
+					"failed to decode query era mismatch: %s",
+					err,
+				)
+			}
+		}
+		return nil, &EraMismatchError{
+			QueryEra:  ledger.GetEraById(eras[0].Id).Name,
+			LedgerEra: ledger.GetEraById(eras[1].Id).Name,
+		}
+	}
+	return nil, fmt.Errorf(
+		"failed to decode query result: expected 1 or 2 items, got %d",
+		len(items),
+	)
+}
+
+// send sends a message and waits for the reply
+func (q *LedgerQuery) send(msg protocol.Message) (protocol.Message, error) {
+	if err := q.proto.SendMessage(msg); err != nil {
+		return nil, err
+	}
+	select {
+	case reply := <-q.msgChan:
+		return reply, nil
+	case err := <-q.errChan:
+		// The mini-protocol can't continue after an error
+		q.oConn.Close()
+		return nil, err
+	case <-q.proto.DoneChan():
+		return nil, protocol.ProtocolShuttingDownError
+	}
+}
+
+func (q *LedgerQuery) handleMessage(msg protocol.Message) error {
+	switch msg.(type) {
+	case *localstatequery.MsgAcquired,
+		*localstatequery.MsgFailure,
+		*localstatequery.MsgResult:
+		q.msgChan <- msg
+		return nil
+	}
+	return unexpectedMessageError(msg)
+}
+
+func unexpectedMessageError(msg protocol.Message) error {
+	return fmt.Errorf(
+		"%s: received unexpected message type %d",
+		localstatequery.ProtocolName,
+		msg.Type(),
+	)
+}
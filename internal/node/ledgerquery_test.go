@@ -0,0 +1,163 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+func TestLedgerQuery(t *testing.T) {
+	testDefs := []struct {
+		name    string
+		version uint16
+		target  AcquireTarget
+		// Conversation after the handshake
+		conversation func(*testing.T) []ouroboros_mock.ConversationEntry
+		// Expected result of the Conway epoch number query, as hex
+		wantResult string
+		wantErr    func(*testing.T, error)
+	}{
+		{
+			name:    "volatile tip",
+			version: 16,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, "06"),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, "8119020d"),
+				}
+			},
+			wantResult: "19020d",
+		},
+		{
+			name:    "immutable tip",
+			version: 16,
+			target:  AcquireTarget{Immutable: true},
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(msgTypeAcquireImmutableTip),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, "06"),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, "8119020d"),
+				}
+			},
+			wantResult: "19020d",
+		},
+		{
+			name:    "immutable tip unsupported",
+			version: 15,
+			target:  AcquireTarget{Immutable: true},
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return nil
+			},
+			wantErr: func(t *testing.T, err error) {
+				if err == nil || !strings.Contains(err.Error(), "version 16") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name:    "acquire failure",
+			version: 16,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+					nodetest.LsqOutput(localstatequery.NewMsgFailure(
+						localstatequery.AcquireFailurePointTooOld,
+					)),
+				}
+			},
+			wantErr: func(t *testing.T, err error) {
+				var tooOldErr localstatequery.AcquireFailurePointTooOldError
+				if !errors.As(err, &tooOldErr) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name:    "era mismatch",
+			version: 16,
+			conversation: func(t *testing.T) []ouroboros_mock.ConversationEntry {
+				return []ouroboros_mock.ConversationEntry{
+					nodetest.LsqInput(localstatequery.MessageTypeAcquireNoPoint),
+					nodetest.LsqOutput(localstatequery.NewMsgAcquired()),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					nodetest.LsqResult(t, "06"),
+					nodetest.LsqInput(localstatequery.MessageTypeQuery),
+					// [[5, "Babbage"], [6, "Conway"]]
+					nodetest.LsqResult(t, "8282056742616262616765820666436f6e776179"),
+				}
+			},
+			wantErr: func(t *testing.T, err error) {
+				var mismatchErr *EraMismatchError
+				if !errors.As(err, &mismatchErr) ||
+					mismatchErr.QueryEra != "Babbage" ||
+					mismatchErr.LedgerEra != "Conway" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			nodetest.StartMockNode(t, testDef.version, testDef.conversation(t))
+			ctx, cancel := context.WithTimeout(
+				context.Background(),
+				5*time.Second,
+			)
+			defer cancel()
+			query, err := OpenLedgerQuery(ctx)
+			if err != nil {
+				t.Fatalf("failed to open ledger query: %s", err)
+			}
+			defer query.Close()
+			result, err := func() ([]byte, error) {
+				if err := query.Acquire(ctx, testDef.target); err != nil {
+					return nil, err
+				}
+				return query.QueryLedger(
+					ctx,
+					"query epoch-no",
+					localstatequery.QueryTypeShelleyEpochNo,
+				)
+			}()
+			if testDef.wantErr != nil {
+				testDef.wantErr(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if hex.EncodeToString(result) != testDef.wantResult {
+				t.Fatalf("unexpected result: %x", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Delay before the first retry after a failed connection attempt, which is
+// doubled on each further attempt up to the configured maximum
+const retryInitialInterval = 500 * time.Millisecond
+
+var (
+	// Whether the connection manager currently has a connection to the node
+	connected atomic.Bool
+	// Whether the connection manager has connected to the node at least once
+	hasConnected atomic.Bool
+)
+
+// ManagedConnection describes the connection manager's current connection to the
+// node
+type ManagedConnection struct {
+	ProtocolVersion uint16
+	NetworkMagic    uint32
+	Endpoint        string
+	ConnectedAt     time.Time
+	// Requests served per mini-protocol since this connection was established
+	Requests map[string]uint64
+}
+
+var managedConn struct {
+	sync.Mutex
+	conn *ManagedConnection
+}
+
+// GetManagedConnection returns a copy of the connection manager's current
+// connection details, or nil while it isn't connected
+func GetManagedConnection() *ManagedConnection {
+	managedConn.Lock()
+	defer managedConn.Unlock()
+	if managedConn.conn == nil {
+		return nil
+	}
+	ret := *managedConn.conn
+	ret.Requests = maps.Clone(managedConn.conn.Requests)
+	return &ret
+}
+
+// recordProtocolRequest counts a request served by the specified mini-protocol
+// against the connection manager's current connection
+func recordProtocolRequest(protocol string) {
+	managedConn.Lock()
+	defer managedConn.Unlock()
+	if managedConn.conn != nil {
+		managedConn.conn.Requests[protocol]++
+	}
+}
+
+func setManagedConnection(conn *ManagedConnection) {
+	managedConn.Lock()
+	defer managedConn.Unlock()
+	managedConn.conn = conn
+}
+
+// Connected returns whether the connection manager currently has a working
+// connection to the node. It's false until the first successful handshake
+func Connected() bool {
+	return connected.Load()
+}
+
+// HasConnected returns whether the connection manager has connected to the node
+// since startup. Once it has, requests don't wait for the manager to notice that
+// the node is back after a restart, since each one connects again if needed
+func HasConnected() bool {
+	return hasConnected.Load()
+}
+
+// ManageConnection keeps a connection open to the node until the context is
+// done, so that we notice when the node becomes available or goes away. Failed
+// connection attempts are retried with exponential backoff, both at startup
+// and after a disconnect. An error is returned if the configured number of
+// consecutive attempts fail. When multiple endpoints are configured, it also
+// fails back to a more preferred endpoint once it's available again
+func ManageConnection(ctx context.Context, cfg *config.Config) error {
+	logger := logging.GetLogger(logging.ComponentNode)
+	maxInterval := time.Duration(cfg.Node.RetryMaxInterval) * time.Second
+	var attempts uint
+	var lost bool
+	// Socket path problem that was last logged, so that we only log it again
+	// when it changes rather than on every attempt
+	var socketPathReason string
+	if versions := cfg.Node.OfferedProtocolVersions(); versions != nil {
+		logger.Infof("offering only node protocol versions %v", versions)
+	}
+	setConnected(false)
+	updateActiveEndpointMetric()
+	updateBreakerMetric(BreakerState())
+	for {
+		oConn, endpointIdx, err := openConnection(
+			&ConnectionConfig{Context: ctx, skipBreaker: true},
+			-1,
+		)
+		if err != nil {
+			setConnected(false)
+			if ctx.Err() != nil {
+				return nil
+			}
+			attempts++
+			if cfg.Node.RetryMaxAttempts > 0 &&
+				attempts >= cfg.Node.RetryMaxAttempts {
+				return fmt.Errorf(
+					"giving up after %d attempts: %s",
+					attempts,
+					err,
+				)
+			}
+			delay := retryDelay(attempts, maxInterval)
+			var pathErr *SocketPathError
+			if errors.As(err, &pathErr) {
+				if pathErr.Reason != socketPathReason {
+					logger.Errorf(
+						"cannot connect to node, retrying until this is fixed: %s",
+						err,
+					)
+					socketPathReason = pathErr.Reason
+				} else {
+					logger.Debugf(
+						"failed to connect to node (attempt %d), retrying in %s: %s",
+						attempts,
+						delay.Round(time.Millisecond),
+						err,
+					)
+				}
+			} else {
+				socketPathReason = ""
+				logger.Warnf(
+					"failed to connect to node (attempt %d), retrying in %s: %s",
+					attempts,
+					delay.Round(time.Millisecond),
+					err,
+				)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			continue
+		}
+		socketPathReason = ""
+		endpoint := cfg.Node.GetEndpoints()[endpointIdx]
+		protocolVersion, versionData := oConn.ProtocolVersion()
+		conn := &ManagedConnection{
+			ProtocolVersion: protocolVersion - protocol.ProtocolVersionNtCOffset,
+			Endpoint:        endpoint.String(),
+			ConnectedAt:     time.Now(),
+			Requests:        make(map[string]uint64),
+		}
+		if versionData != nil {
+			conn.NetworkMagic = versionData.NetworkMagic()
+		}
+		if !HasConnected() {
+			logger.Infof(
+				"completed first handshake with node at %s: protocol version %d, network magic %d",
+				endpoint,
+				conn.ProtocolVersion,
+				conn.NetworkMagic,
+			)
+		}
+		if attempts > 0 {
+			logger.Infof(
+				"connected to node at %s after %d failed attempts",
+				endpoint,
+				attempts,
+			)
+		} else {
+			logger.Infof("connected to node at %s", endpoint)
+		}
+		if lost {
+			RecordReconnect(reconnectConsumerManager)
+		}
+		attempts = 0
+		setManagedConnection(conn)
+		setConnected(true)
+		failback, err := waitConnection(ctx, cfg, oConn, endpointIdx)
+		oConn.Close()
+		if failback && ctx.Err() == nil {
+			// Stay connected while reconnecting to the preferred endpoint
+			continue
+		}
+		setManagedConnection(nil)
+		setConnected(false)
+		if ctx.Err() != nil {
+			return nil
+		}
+		lost = true
+		if err != nil {
+			RecordConnectionError(err)
+			logger.Warnf("lost connection to node, reconnecting: %s", err)
+		} else {
+			logger.Warnf("lost connection to node, reconnecting")
+		}
+	}
+}
+
+// waitConnection waits for the connection to fail, returning its error if it
+// reported one. While new connections are made to an endpoint other than the most
+// preferred, the more preferred endpoints are probed periodically. If one is
+// available again, new connections switch back to it, and failback is true if
+// this connection needs to be replaced
+func waitConnection(
+	ctx context.Context,
+	cfg *config.Config,
+	oConn *ouroboros.Connection,
+	endpointIdx int,
+) (bool, error) {
+	var probeChan <-chan time.Time
+	if cfg.Node.FailbackInterval > 0 && len(cfg.Node.GetEndpoints()) > 1 {
+		ticker := time.NewTicker(
+			time.Duration(cfg.Node.FailbackInterval) * time.Second,
+		)
+		defer ticker.Stop()
+		probeChan = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		// The error channel is closed when the connection is shut down
+		case err := <-oConn.ErrorChan():
+			return false, err
+		case <-probeChan:
+			active := int(activeEndpoint.Load())
+			for idx := 0; idx < active; idx++ {
+				probeConn, _, err := openConnection(
+					&ConnectionConfig{Context: ctx},
+					idx,
+				)
+				if err != nil {
+					continue
+				}
+				probeConn.Close()
+				setActiveEndpoint(idx)
+				break
+			}
+			if int(activeEndpoint.Load()) != endpointIdx {
+				return true, nil
+			}
+		}
+	}
+}
+
+// retryDelay returns the delay before the specified retry attempt, with random
+// jitter so that instances started together don't all retry at once
+func retryDelay(attempt uint, maxInterval time.Duration) time.Duration {
+	delay := retryInitialInterval
+	for i := uint(1); i < attempt && delay < maxInterval; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxInterval)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func setConnected(value bool) {
+	connected.Store(value)
+	if value {
+		hasConnected.Store(true)
+	}
+	gaugeValue := float64(0)
+	if value {
+		gaugeValue = 1
+	}
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricConnected).
+		SetGaugeValue(nil, gaugeValue)
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+func TestRetryDelay(t *testing.T) {
+	testDefs := []struct {
+		attempt     uint
+		maxInterval time.Duration
+		// The delay before jitter, which is between half of this and all of it
+		wantDelay time.Duration
+	}{
+		{attempt: 1, maxInterval: 30 * time.Second, wantDelay: 500 * time.Millisecond},
+		{attempt: 2, maxInterval: 30 * time.Second, wantDelay: time.Second},
+		{attempt: 4, maxInterval: 30 * time.Second, wantDelay: 4 * time.Second},
+		{attempt: 7, maxInterval: 30 * time.Second, wantDelay: 30 * time.Second},
+		{attempt: 100, maxInterval: 30 * time.Second, wantDelay: 30 * time.Second},
+		{attempt: 1, maxInterval: 200 * time.Millisecond, wantDelay: 200 * time.Millisecond},
+	}
+	for _, testDef := range testDefs {
+		for i := 0; i < 20; i++ {
+			delay := retryDelay(testDef.attempt, testDef.maxInterval)
+			if delay < testDef.wantDelay/2 || delay > testDef.wantDelay {
+				t.Fatalf(
+					"delay %s for attempt %d with max %s is outside %s to %s",
+					delay,
+					testDef.attempt,
+					testDef.maxInterval,
+					testDef.wantDelay/2,
+					testDef.wantDelay,
+				)
+			}
+		}
+	}
+}
+
+func TestManageConnection(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// Whether the mock node is up
+		nodeUp      bool
+		maxAttempts uint
+		wantErr     string
+	}{
+		{
+			name:        "gives up after max attempts",
+			maxAttempts: 2,
+			wantErr:     "giving up after 2 attempts",
+		},
+		{
+			name:   "stops when done",
+			nodeUp: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			if testDef.nodeUp {
+				nodetest.StartMockNode(t, 16, []ouroboros_mock.ConversationEntry{})
+			} else {
+				setTestEndpoints(t, filepath.Join(t.TempDir(), "missing.socket"))
+			}
+			cfg := *config.GetConfig()
+			cfg.Node.RetryMaxAttempts = testDef.maxAttempts
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- ManageConnection(ctx, &cfg)
+			}()
+			if testDef.nodeUp {
+				waitFor(t, Connected)
+				if GetConnectionInfo() == nil {
+					t.Fatalf("no connection info while connected")
+				}
+				cancel()
+			}
+			var err error
+			select {
+			case err = <-errChan:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for ManageConnection to return")
+			}
+			if testDef.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testDef.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", testDef.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if Connected() {
+				t.Fatalf("still connected after ManageConnection returned")
+			}
+		})
+	}
+}
+
+func TestWaitConnectionFailback(t *testing.T) {
+	testDefs := []struct {
+		name string
+		// Whether the preferred endpoint is up
+		preferredUp      bool
+		failbackInterval uint
+		wantFailback     bool
+	}{
+		{
+			name:             "fails back to preferred endpoint",
+			preferredUp:      true,
+			failbackInterval: 1,
+			wantFailback:     true,
+		},
+		{
+			name:             "stays while preferred endpoint is down",
+			failbackInterval: 1,
+		},
+		{
+			name:        "failback disabled",
+			preferredUp: true,
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			// The preferred endpoint only needs to accept the probe
+			preferred := filepath.Join(t.TempDir(), "missing.socket")
+			if testDef.preferredUp {
+				nodetest.StartMockNode(t, 16, []ouroboros_mock.ConversationEntry{})
+				preferred = config.GetConfig().Node.Endpoints[0]
+			}
+			nodetest.StartMockNode(t, 16, []ouroboros_mock.ConversationEntry{})
+			fallback := config.GetConfig().Node.Endpoints[0]
+			setTestEndpoints(t, preferred, fallback)
+			origActive := activeEndpoint.Load()
+			t.Cleanup(func() { activeEndpoint.Store(origActive) })
+			oConn, _, err := openConnection(nil, 1)
+			if err != nil {
+				t.Fatalf("failed to connect to fallback endpoint: %s", err)
+			}
+			defer oConn.Close()
+			setActiveEndpoint(1)
+			cfg := *config.GetConfig()
+			cfg.Node.FailbackInterval = testDef.failbackInterval
+			// Long enough for one probe
+			ctx, cancel := context.WithTimeout(
+				context.Background(),
+				1500*time.Millisecond,
+			)
+			defer cancel()
+			failback, err := waitConnection(ctx, &cfg, oConn, 1)
+			if err != nil {
+				t.Fatalf("unexpected connection error: %s", err)
+			}
+			if failback != testDef.wantFailback {
+				t.Fatalf("unexpected failback: %v", failback)
+			}
+			wantActive := int32(1)
+			if testDef.wantFailback {
+				wantActive = 0
+			}
+			if active := activeEndpoint.Load(); active != wantActive {
+				t.Fatalf("unexpected active endpoint: %d", active)
+			}
+		})
+	}
+}
+
+// setTestEndpoints points the node config at the endpoints for a test
+func setTestEndpoints(t *testing.T, endpoints ...string) {
+	cfg := config.GetConfig()
+	nodeCfg := cfg.Node
+	t.Cleanup(func() { cfg.Node = nodeCfg })
+	cfg.Node.Endpoints = endpoints
+}
+
+// waitFor waits for a condition to become true, failing the test if it doesn't
+// within a few seconds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// StartMempoolPoller periodically exports the mempool size, capacity, and TX
+// count from a LocalTxMonitor snapshot until the context is done
+func StartMempoolPoller(ctx context.Context, cfg *config.Config) {
+	p := &poller{
+		name:     "mempool",
+		interval: time.Duration(cfg.Metrics.MempoolPollInterval) * time.Second,
+		startFunc: func(oConn *ouroboros.Connection) {
+			oConn.LocalTxMonitor().Client.Start()
+		},
+		pollFunc: pollMempool,
+	}
+	go p.run(ctx)
+}
+
+func pollMempool(oConn *ouroboros.Connection) error {
+	client := oConn.LocalTxMonitor().Client
+	// Acquire a fresh mempool snapshot
+	if err := client.Acquire(); err != nil {
+		RecordProtocolError(localtxmonitor.ProtocolName)
+		return err
+	}
+	if _, err := getMempoolSizes(client); err != nil {
+		RecordProtocolError(localtxmonitor.ProtocolName)
+		return err
+	}
+	if err := client.Release(); err != nil {
+		RecordProtocolError(localtxmonitor.ProtocolName)
+		return err
+	}
+	return nil
+}
+
+// MempoolSizes is the usage of the mempool in a LocalTxMonitor snapshot
+type MempoolSizes struct {
+	// Capacity and Size are in bytes
+	Capacity uint32
+	Size     uint32
+	TxCount  uint32
+}
+
+// Utilization returns the size as a percentage of the capacity
+func (s MempoolSizes) Utilization() float64 {
+	if s.Capacity == 0 {
+		return 0
+	}
+	return float64(s.Size) / float64(s.Capacity) * 100
+}
+
+// GetMempoolSizes returns the sizes from the mempool snapshot acquired on the
+// connection, and updates the mempool metrics with them
+func GetMempoolSizes(
+	ctx context.Context,
+	conn *PooledConnection,
+) (MempoolSizes, error) {
+	return Call(
+		ctx,
+		conn,
+		localtxmonitor.ProtocolName,
+		"get-sizes",
+		func() (MempoolSizes, error) {
+			return getMempoolSizes(conn.LocalTxMonitor().Client)
+		},
+	)
+}
+
+// getMempoolSizes gets the sizes from an acquired mempool snapshot and updates the
+// mempool metrics, which is shared by the poller and the API
+func getMempoolSizes(client *localtxmonitor.Client) (MempoolSizes, error) {
+	capacity, size, txCount, err := client.GetSizes()
+	if err != nil {
+		return MempoolSizes{}, err
+	}
+	metrics := ginmetrics.GetMonitor()
+	_ = metrics.GetMetric(metricMempoolSize).SetGaugeValue(nil, float64(size))
+	_ = metrics.GetMetric(metricMempoolCapacity).
+		SetGaugeValue(nil, float64(capacity))
+	_ = metrics.GetMetric(metricMempoolTxCount).
+		SetGaugeValue(nil, float64(txCount))
+	return MempoolSizes{
+		Capacity: capacity,
+		Size:     size,
+		TxCount:  txCount,
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// MempoolSnapshot is the contents of a LocalTxMonitor snapshot. The slot is the
+// chain tip right after the snapshot was acquired, since gouroboros doesn't
+// expose the slot from the acquire reply
+type MempoolSnapshot struct {
+	Slot  uint64
+	Sizes MempoolSizes
+	Txs   [][]byte
+}
+
+// MempoolStream follows the mempool on a dedicated node connection, sending a
+// snapshot each time the mempool changes. Unlike a chain-sync stream, it isn't
+// resumed if the connection fails
+type MempoolStream struct {
+	ctx       context.Context
+	snapshots chan MempoolSnapshot
+	err       error
+	oConn     *ouroboros.Connection
+	endpoint  int
+}
+
+// StartMempoolStream connects to the node and starts following the mempool. The
+// connection is closed when the context is done, which stops any call waiting on
+// the mempool
+func StartMempoolStream(ctx context.Context) (*MempoolStream, error) {
+	oConn, endpointIdx, err := openConnection(
+		&ConnectionConfig{Context: ctx},
+		-1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	oConn.LocalStateQuery().Client.Start()
+	oConn.LocalTxMonitor().Client.Start()
+	s := &MempoolStream{
+		ctx:       ctx,
+		snapshots: make(chan MempoolSnapshot, 1),
+		oConn:     oConn,
+		endpoint:  endpointIdx,
+	}
+	recordEndpointRequest(s.Endpoint())
+	go s.run()
+	return s, nil
+}
+
+// Snapshots returns the mempool snapshots, starting with the current one. The
+// channel is closed when the context is done or the connection fails, in which
+// case Err returns the reason
+func (s *MempoolStream) Snapshots() <-chan MempoolSnapshot {
+	return s.snapshots
+}
+
+// Err returns the error that ended the stream, if any. It's only valid after the
+// snapshots channel is closed
+func (s *MempoolStream) Err() error {
+	return s.err
+}
+
+// Endpoint returns the node endpoint that the stream is following
+func (s *MempoolStream) Endpoint() string {
+	return config.GetConfig().Node.GetEndpoints()[s.endpoint].String()
+}
+
+func (s *MempoolStream) run() {
+	defer close(s.snapshots)
+	defer s.oConn.Close()
+	for {
+		// Acquiring while a snapshot is still acquired waits for the mempool to
+		// change, so we never release it
+		recordProtocolRequest(localtxmonitor.ProtocolName)
+		if err := s.oConn.LocalTxMonitor().Client.Acquire(); err != nil {
+			s.fail(localtxmonitor.ProtocolName, err)
+			return
+		}
+		snapshot, protocol, err := s.snapshot()
+		if err != nil {
+			s.fail(protocol, err)
+			return
+		}
+		select {
+		case s.snapshots <- snapshot:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// snapshot reads the acquired mempool snapshot. The mini-protocol that failed is
+// returned with any error
+func (s *MempoolStream) snapshot() (MempoolSnapshot, string, error) {
+	var snapshot MempoolSnapshot
+	lsqClient := s.oConn.LocalStateQuery().Client
+	recordProtocolRequest(localstatequery.ProtocolName)
+	if err := lsqClient.Acquire(nil); err != nil {
+		return snapshot, localstatequery.ProtocolName, err
+	}
+	point, err := lsqClient.GetChainPoint()
+	if err != nil {
+		return snapshot, localstatequery.ProtocolName, err
+	}
+	if err := lsqClient.Release(); err != nil {
+		return snapshot, localstatequery.ProtocolName, err
+	}
+	snapshot.Slot = point.Slot
+	client := s.oConn.LocalTxMonitor().Client
+	if snapshot.Sizes, err = getMempoolSizes(client); err != nil {
+		return snapshot, localtxmonitor.ProtocolName, err
+	}
+	for {
+		txRawBytes, err := client.NextTx()
+		if err != nil {
+			return snapshot, localtxmonitor.ProtocolName, err
+		}
+		if txRawBytes == nil {
+			break
+		}
+		snapshot.Txs = append(snapshot.Txs, txRawBytes)
+	}
+	return snapshot, "", nil
+}
+
+// fail records an error that ended the stream. Errors from the connection being
+// closed after the context is done are expected
+func (s *MempoolStream) fail(protocol string, err error) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	RecordProtocolError(protocol)
+	s.err = err
+}
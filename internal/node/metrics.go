@@ -0,0 +1,236 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/blinklabs-io/gouroboros/protocol/chainsync"
+	"github.com/blinklabs-io/gouroboros/protocol/handshake"
+	"github.com/blinklabs-io/gouroboros/protocol/keepalive"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+)
+
+// Node connection metric names
+const (
+	metricConnectionsOpen   = "cardano_node_connections_open"
+	metricConnected         = "cardano_node_connected"
+	metricPoolConnections   = "cardano_node_pool_connections"
+	metricPoolMaxSize       = "cardano_node_pool_max_size"
+	metricHandshakeFailures = "cardano_node_handshake_failures_total"
+	metricHandshakeDuration = "cardano_node_handshake_duration_seconds"
+	metricProtocolErrors    = "cardano_node_protocol_errors_total"
+	metricProtocolTimeouts  = "cardano_node_protocol_timeouts_total"
+	metricReconnectAttempts = "cardano_node_reconnect_attempts_total"
+	metricReconnects        = "cardano_node_reconnects_total"
+	metricActiveEndpoint    = "cardano_node_active_endpoint"
+	metricFailovers         = "cardano_node_failovers_total"
+	metricEndpointRequests  = "cardano_node_endpoint_requests_total"
+	metricBreakerState      = "cardano_node_circuit_breaker_state"
+	metricMempoolSize       = "cardano_mempool_size_bytes"
+	metricMempoolCapacity   = "cardano_mempool_capacity_bytes"
+	metricMempoolTxCount    = "cardano_mempool_tx_count"
+	metricTipSlot           = "cardano_tip_slot"
+	metricTipBlockHeight    = "cardano_tip_block_height"
+	metricTipAge            = "cardano_tip_age_seconds"
+)
+
+// Labels for the consumers of long-lived connections that reconnect to the node
+const (
+	reconnectConsumerManager   = "manager"
+	reconnectConsumerChainsync = "chainsync"
+	reconnectConsumerPoller    = "poller"
+)
+
+// Label used for errors that can't be attributed to a mini-protocol
+const protocolUnknown = "unknown"
+
+// Mini-protocols that errors can be attributed to from their message prefix
+var errorProtocolNames = []string{
+	chainsync.ProtocolName,
+	handshake.ProtocolName,
+	keepalive.ProtocolName,
+	localstatequery.ProtocolName,
+	localtxmonitor.ProtocolName,
+	localtxsubmission.ProtocolName,
+	"muxer",
+}
+
+var registerMetricsOnce sync.Once
+
+// RegisterMetrics adds the node connection, mempool, and chain tip metrics to the
+// metrics monitor. It's safe to call more than once
+func RegisterMetrics() {
+	registerMetricsOnce.Do(func() {
+		metrics := ginmetrics.GetMonitor()
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricConnectionsOpen,
+			Description: "Currently open connections to the node",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricConnected,
+			Description: "Whether the connection manager is connected to the node",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricPoolConnections,
+			Description: "Pooled connections to the node, by state",
+			Labels:      []string{"state"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricPoolMaxSize,
+			Description: "Maximum number of pooled connections to the node",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricHandshakeFailures,
+			Description: "Failed handshakes with the node",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Histogram,
+			Name:        metricHandshakeDuration,
+			Description: "Time taken to negotiate the handshake with the node",
+			Buckets: []float64{
+				0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+			},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricProtocolErrors,
+			Description: "Errors communicating with the node, by mini-protocol",
+			Labels:      []string{"protocol"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricProtocolTimeouts,
+			Description: "Calls to the node that timed out, by mini-protocol",
+			Labels:      []string{"protocol"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricReconnectAttempts,
+			Description: "Node connection attempts made after the previous attempt failed",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricReconnects,
+			Description: "Long-lived node connections re-established after being lost, by consumer",
+			Labels:      []string{"consumer"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricActiveEndpoint,
+			Description: "Whether new node connections are made to the endpoint",
+			Labels:      []string{"endpoint"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricFailovers,
+			Description: "Switches of new node connections to the endpoint",
+			Labels:      []string{"endpoint"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Counter,
+			Name:        metricEndpointRequests,
+			Description: "Requests served by the node endpoint",
+			Labels:      []string{"endpoint"},
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricBreakerState,
+			Description: "State of the node connection circuit breaker: 0 closed, 1 half-open, 2 open",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricMempoolSize,
+			Description: "Size of the node mempool in bytes",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricMempoolCapacity,
+			Description: "Capacity of the node mempool in bytes",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricMempoolTxCount,
+			Description: "Number of transactions in the node mempool",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricTipSlot,
+			Description: "Slot number of the node tip",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricTipBlockHeight,
+			Description: "Block height of the node tip",
+		})
+		_ = metrics.AddMetric(&ginmetrics.Metric{
+			Type:        ginmetrics.Gauge,
+			Name:        metricTipAge,
+			Description: "Seconds since the wall-clock time of the node tip slot",
+		})
+	})
+}
+
+// RecordProtocolError counts an error for the specified mini-protocol
+func RecordProtocolError(protocol string) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricProtocolErrors).
+		Inc([]string{protocol})
+}
+
+// RecordReconnect counts a long-lived connection being re-established after it
+// was lost
+func RecordReconnect(consumer string) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricReconnects).
+		Inc([]string{consumer})
+}
+
+// RecordConnectionError counts an asynchronous error from a node connection,
+// using the mini-protocol name that gouroboros prefixes most errors with
+func RecordConnectionError(err error) {
+	RecordProtocolError(protocolFromError(err))
+}
+
+func protocolFromError(err error) string {
+	if errors.Is(err, io.EOF) {
+		return "muxer"
+	}
+	msg := err.Error()
+	for _, name := range errorProtocolNames {
+		if strings.HasPrefix(msg, name+":") ||
+			strings.HasPrefix(msg, name+" ") {
+			return name
+		}
+	}
+	return protocolUnknown
+}
+
+func updateOpenConnectionsMetric(count int) {
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricConnectionsOpen).
+		SetGaugeValue(nil, float64(count))
+}
@@ -15,27 +15,144 @@
 package node
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
 
 	"github.com/blinklabs-io/adder/event"
 	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/penglongli/gin-metrics/ginmetrics"
 )
 
 type ConnectionConfig struct {
 	ChainSyncEventChan chan event.Event
+	// Context closes the connection when it is done, which aborts any in-progress
+	// protocol operations
+	Context context.Context
+	// Pooled marks connections that are owned by the connection pool
+	Pooled bool
+	// skipBreaker connects even while the circuit breaker is open, for the
+	// connection manager and breaker probes
+	skipBreaker bool
+}
+
+// ConnectionInfo holds the details negotiated during the handshake with the node
+type ConnectionInfo struct {
+	ProtocolVersion uint16
+	NetworkMagic    uint32
+}
+
+// Details from the most recent successful node connection
+var lastConnectionInfo atomic.Pointer[ConnectionInfo]
+
+// Whether the most recent connection attempt failed, so that we can count
+// reconnect attempts
+var lastConnectFailed atomic.Bool
+
+// GetConnectionInfo returns the details negotiated with the node on the most recent
+// successful connection, or nil if no connection has been established yet
+func GetConnectionInfo() *ConnectionInfo {
+	return lastConnectionInfo.Load()
 }
 
 func GetConnection(connCfg *ConnectionConfig) (*ouroboros.Connection, error) {
+	oConn, _, err := openConnection(connCfg, -1)
+	return oConn, err
+}
+
+// openConnection connects to the specified endpoint, or to the first available
+// endpoint if the index is negative. The index of the endpoint is returned with
+// the connection
+func openConnection(
+	connCfg *ConnectionConfig,
+	endpointIdx int,
+) (*ouroboros.Connection, int, error) {
 	// Make sure we always have a ConnectionConfig object
 	if connCfg == nil {
 		connCfg = &ConnectionConfig{}
 	}
+	if endpointIdx >= 0 {
+		oConn, err := connectEndpoint(connCfg, endpointIdx)
+		return oConn, endpointIdx, err
+	}
+	if !connCfg.skipBreaker {
+		if err := breaker.allow(); err != nil {
+			return nil, 0, err
+		}
+	}
+	oConn, endpointIdx, err := connectFirstEndpoint(connCfg)
+	breaker.record(err)
+	return oConn, endpointIdx, err
+}
+
+// connectFirstEndpoint connects to the first available endpoint, starting with
+// the active endpoint
+func connectFirstEndpoint(
+	connCfg *ConnectionConfig,
+) (*ouroboros.Connection, int, error) {
+	endpoints := config.GetConfig().Node.GetEndpoints()
+	var errs []string
+	var lastErr error
+	for _, idx := range endpointOrder(len(endpoints)) {
+		oConn, err := connectEndpoint(connCfg, idx)
+		if err == nil {
+			setActiveEndpoint(idx)
+			return oConn, idx, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", endpoints[idx], err))
+		lastErr = err
+	}
+	if len(errs) == 1 {
+		return nil, 0, lastErr
+	}
+	return nil, 0, fmt.Errorf(
+		"failure connecting to all node endpoints: %s",
+		strings.Join(errs, "; "),
+	)
+}
+
+// connectEndpoint opens a connection to the specified endpoint and performs the
+// handshake
+func connectEndpoint(
+	connCfg *ConnectionConfig,
+	endpointIdx int,
+) (*ouroboros.Connection, error) {
 	cfg := config.GetConfig()
+	endpoint := cfg.Node.GetEndpoints()[endpointIdx]
+	logger := logging.GetLogger(logging.ComponentNode)
+	ctx := connCfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	// Connect to cardano-node
+	if lastConnectFailed.Load() {
+		_ = ginmetrics.GetMonitor().GetMetric(metricReconnectAttempts).Inc(nil)
+	}
+	_, dialSpan := tracing.StartSpan(ctx, "node.dial")
+	conn, err := dial(endpoint)
+	tracing.EndSpan(dialSpan, err)
+	if err != nil {
+		lastConnectFailed.Store(true)
+		logger.Debugf("failed to dial node at %s: %s", endpoint, err)
+		return nil, err
+	}
+	if versions := cfg.Node.OfferedProtocolVersions(); versions != nil {
+		conn = filterProtocolVersions(conn, versions)
+	}
+	// Wrap the connection so that we can keep track of it until it's closed
+	tConn := trackConnection(conn, endpoint, *connCfg)
+	// Creating the connection performs the handshake
+	_, handshakeSpan := tracing.StartSpan(ctx, "node.handshake")
+	handshakeStart := time.Now()
 	oConn, err := ouroboros.NewConnection(
+		ouroboros.WithConnection(tConn),
 		ouroboros.WithNetworkMagic(uint32(cfg.Node.NetworkMagic)),
 		ouroboros.WithNodeToNode(false),
 		ouroboros.WithKeepAlive(true),
@@ -44,32 +161,38 @@ func GetConnection(connCfg *ConnectionConfig) (*ouroboros.Connection, error) {
 		ouroboros.WithLocalStateQueryConfig(buildLocalStateQueryConfig()),
 		ouroboros.WithLocalTxSubmissionConfig(buildLocalTxSubmissionConfig()),
 	)
+	tracing.EndSpan(handshakeSpan, err)
+	_ = ginmetrics.GetMonitor().
+		GetMetric(metricHandshakeDuration).
+		Observe(nil, time.Since(handshakeStart).Seconds())
 	if err != nil {
+		lastConnectFailed.Store(true)
+		_ = ginmetrics.GetMonitor().GetMetric(metricHandshakeFailures).Inc(nil)
+		_ = tConn.Close()
+		logger.Debugf("handshake with node at %s failed: %s", endpoint, err)
 		return nil, fmt.Errorf("failure creating Ouroboros connection: %s", err)
 	}
-
-	if cfg.Node.Address != "" && cfg.Node.Port > 0 {
-		// Connect to TCP port
-		if err := oConn.Dial("tcp", fmt.Sprintf("%s:%d", cfg.Node.Address, cfg.Node.Port)); err != nil {
-			return nil, fmt.Errorf(
-				"failure connecting to node via TCP: %s",
-				err,
-			)
-		}
-	} else if cfg.Node.SocketPath != "" {
-		// Check that node socket path exists
-		if _, err := os.Stat(cfg.Node.SocketPath); err != nil {
-			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("node socket path does not exist: %s", cfg.Node.SocketPath)
-			} else {
-				return nil, fmt.Errorf("unknown error checking if node socket path exists: %s", err)
-			}
-		}
-		if err := oConn.Dial("unix", cfg.Node.SocketPath); err != nil {
-			return nil, fmt.Errorf("failure connecting to node via UNIX socket: %s", err)
-		}
-	} else {
-		return nil, fmt.Errorf("you must specify either the UNIX socket path or the address/port for your cardano-node")
+	lastConnectFailed.Store(false)
+	// Record the negotiated handshake details
+	protocolVersion, versionData := oConn.ProtocolVersion()
+	connInfo := &ConnectionInfo{
+		ProtocolVersion: protocolVersion - protocol.ProtocolVersionNtCOffset,
+	}
+	if versionData != nil {
+		connInfo.NetworkMagic = versionData.NetworkMagic()
+	}
+	lastConnectionInfo.Store(connInfo)
+	tConn.setProtocolVersion(connInfo.ProtocolVersion)
+	logger.Debugf(
+		"connected to node at %s (protocol version %d, network magic %d)",
+		endpoint,
+		connInfo.ProtocolVersion,
+		connInfo.NetworkMagic,
+	)
+	if connCfg.Context != nil {
+		context.AfterFunc(connCfg.Context, func() {
+			oConn.Close()
+		})
 	}
 	return oConn, nil
 }
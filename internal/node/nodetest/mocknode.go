@@ -0,0 +1,175 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodetest runs a mock node for tests
+package nodetest
+
+import (
+	"encoding/hex"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/handshake"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// HandshakeNtCResponse returns a handshake response accepting a node-to-client
+// protocol version
+func HandshakeNtCResponse(version uint16) ouroboros_mock.ConversationEntry {
+	var versionData protocol.VersionData = protocol.VersionDataNtC9to14(
+		ouroboros_mock.MockNetworkMagic,
+	)
+	if version >= 15 {
+		versionData = protocol.VersionDataNtC15andUp{
+			CborNetworkMagic: ouroboros_mock.MockNetworkMagic,
+		}
+	}
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: handshake.ProtocolId,
+		IsResponse: true,
+		Messages: []protocol.Message{
+			handshake.NewMsgAcceptVersion(
+				version+protocol.ProtocolVersionNtCOffset,
+				versionData,
+			),
+		},
+	}
+}
+
+// StartMockNode points the node config at a UNIX socket that serves each
+// connection with the next conversation. The conversations don't include the
+// handshake, which accepts the node-to-client protocol version
+func StartMockNode(
+	t *testing.T,
+	version uint16,
+	conversations ...[]ouroboros_mock.ConversationEntry,
+) {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "node.socket")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on mock node socket: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for _, conversation := range conversations {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mockConn := ouroboros_mock.NewConnection(
+				ouroboros_mock.ProtocolRoleClient,
+				append(
+					[]ouroboros_mock.ConversationEntry{
+						ouroboros_mock.ConversationEntryHandshakeRequestGeneric,
+						HandshakeNtCResponse(version),
+					},
+					conversation...,
+				),
+			)
+			go func() {
+				_, _ = io.Copy(mockConn, conn)
+				mockConn.Close()
+			}()
+			go func() {
+				_, _ = io.Copy(conn, mockConn)
+				conn.Close()
+			}()
+		}
+	}()
+	cfg := config.GetConfig()
+	nodeCfg := cfg.Node
+	t.Cleanup(func() { cfg.Node = nodeCfg })
+	cfg.Node.Endpoints = []string{socketPath}
+	cfg.Node.NetworkMagic = ouroboros_mock.MockNetworkMagic
+}
+
+// LsqInput matches a LocalStateQuery message from the client by type
+func LsqInput(msgType uint) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId:  localstatequery.ProtocolId,
+		MessageType: msgType,
+	}
+}
+
+// LsqOutput sends a LocalStateQuery message to the client
+func LsqOutput(msg protocol.Message) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: localstatequery.ProtocolId,
+		IsResponse: true,
+		Messages:   []protocol.Message{msg},
+	}
+}
+
+// LsqResult sends a query result to the client
+func LsqResult(t *testing.T, resultHex string) ouroboros_mock.ConversationEntry {
+	t.Helper()
+	result, err := hex.DecodeString(resultHex)
+	if err != nil {
+		t.Fatalf("invalid result hex: %s", err)
+	}
+	return LsqOutput(localstatequery.NewMsgResult(result))
+}
+
+// lsqQueryMsg is a LocalStateQuery query message with its payload as hex, for
+// comparing queries exactly
+type lsqQueryMsg struct {
+	protocol.MessageBase
+	Payload string
+}
+
+// LsqQuery matches a LocalStateQuery query from the client by its CBOR, given
+// as hex
+func LsqQuery(queryHex string) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId: localstatequery.ProtocolId,
+		Message: &lsqQueryMsg{
+			MessageBase: protocol.MessageBase{
+				MessageType: localstatequery.MessageTypeQuery,
+			},
+			// The message is the message type and the query
+			Payload: "8203" + queryHex,
+		},
+		MsgFromCborFunc: func(msgType uint, data []byte) (protocol.Message, error) {
+			return &lsqQueryMsg{
+				MessageBase: protocol.MessageBase{MessageType: uint8(msgType)},
+				Payload:     hex.EncodeToString(data),
+			}, nil
+		},
+	}
+}
+
+// TxSubmitInput matches a TX submission from the client
+func TxSubmitInput() ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryInput{
+		ProtocolId:  localtxsubmission.ProtocolId,
+		MessageType: localtxsubmission.MessageTypeSubmitTx,
+	}
+}
+
+// TxSubmitOutput sends a LocalTxSubmission message to the client
+func TxSubmitOutput(msg protocol.Message) ouroboros_mock.ConversationEntry {
+	return ouroboros_mock.ConversationEntryOutput{
+		ProtocolId: localtxsubmission.ProtocolId,
+		IsResponse: true,
+		Messages:   []protocol.Message{msg},
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+)
+
+// Maximum delay between polls while the node is unavailable
+const pollerMaxBackoff = 5 * time.Minute
+
+// poller periodically calls a function with a node connection, which is kept
+// open between polls and replaced after any failure
+type poller struct {
+	name     string
+	interval time.Duration
+	// startFunc starts the mini-protocol clients on a new connection
+	startFunc func(*ouroboros.Connection)
+	pollFunc  func(*ouroboros.Connection) error
+	oConn     *ouroboros.Connection
+	// Whether the previous poll failed
+	failed bool
+}
+
+func (p *poller) run(ctx context.Context) {
+	logger := logging.GetLogger(logging.ComponentNode)
+	defer p.closeConnection()
+	delay := time.Duration(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if err := p.poll(); err != nil {
+			p.closeConnection()
+			// Back off while the node is unavailable
+			if delay < p.interval {
+				delay = p.interval
+			} else {
+				delay = min(delay*2, pollerMaxBackoff)
+			}
+			logger.Warnf(
+				"failed to poll %s, retrying in %s: %s",
+				p.name,
+				delay,
+				err,
+			)
+			p.failed = true
+			continue
+		}
+		if p.failed {
+			// The mini-protocols were started again on a new connection
+			logger.Infof("reconnected to node for %s polling", p.name)
+			RecordReconnect(reconnectConsumerPoller)
+			p.failed = false
+		}
+		delay = p.interval
+	}
+}
+
+func (p *poller) poll() error {
+	if p.oConn == nil {
+		oConn, err := GetConnection(nil)
+		if err != nil {
+			return err
+		}
+		// Drain async errors. We notice a broken connection from the next call
+		go func() {
+			for err := range oConn.ErrorChan() {
+				RecordConnectionError(err)
+			}
+		}()
+		p.startFunc(oConn)
+		p.oConn = oConn
+	}
+	return p.pollFunc(p.oConn)
+}
+
+func (p *poller) closeConnection() {
+	if p.oConn != nil {
+		p.oConn.Close()
+		p.oConn = nil
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+	"github.com/blinklabs-io/cardano-node-api/internal/logging"
+	"github.com/blinklabs-io/cardano-node-api/internal/tracing"
+)
+
+// Labels for the pool connections metric
+const (
+	poolStateIdle  = "idle"
+	poolStateInUse = "in_use"
+)
+
+// PooledConnection is a node connection borrowed from the connection pool. The
+// LocalStateQuery, LocalTxMonitor, and LocalTxSubmission clients are already
+// started. Close returns the connection to the pool rather than closing it
+type PooledConnection struct {
+	*ouroboros.Connection
+	entry     *poolEntry
+	pool      *connectionPool
+	stopCtx   func() bool
+	closeOnce sync.Once
+}
+
+// poolEntry is a connection owned by the pool, which outlives each use of it
+type poolEntry struct {
+	oConn    *ouroboros.Connection
+	endpoint int
+	broken   atomic.Bool
+	lastUsed time.Time
+	// Whether the ledger state or mempool snapshot is currently acquired
+	stateAcquired   bool
+	mempoolAcquired bool
+}
+
+type connectionPool struct {
+	minSize     int
+	idleTimeout time.Duration
+	// Holds a token for each connection that's in use
+	inUseSlots chan struct{}
+	mutex      sync.Mutex
+	idle       []*poolEntry
+	closed     bool
+}
+
+var (
+	connPool     *connectionPool
+	connPoolOnce sync.Once
+)
+
+func getConnectionPool() *connectionPool {
+	connPoolOnce.Do(func() {
+		cfg := config.GetConfig()
+		connPool = &connectionPool{
+			minSize:     int(cfg.Node.PoolMinSize),
+			idleTimeout: time.Duration(cfg.Node.PoolIdleTimeout) * time.Second,
+			inUseSlots:  make(chan struct{}, cfg.Node.PoolMaxSize),
+		}
+		_ = ginmetrics.GetMonitor().
+			GetMetric(metricPoolMaxSize).
+			SetGaugeValue(nil, float64(cfg.Node.PoolMaxSize))
+	})
+	return connPool
+}
+
+// StartConnectionPool opens the minimum number of pooled connections and closes
+// connections that have been idle for too long, until the context is done. All
+// pooled connections are closed after that
+func StartConnectionPool(ctx context.Context) {
+	p := getConnectionPool()
+	go p.run(ctx)
+}
+
+// GetPooledConnection borrows an idle connection from the pool, or opens a new
+// one if there are none. It waits for a connection to be returned when the
+// maximum number of connections are already in use. The connection is closed
+// if the context is done before it's returned to the pool, which aborts any
+// in-progress protocol operations
+func GetPooledConnection(ctx context.Context) (*PooledConnection, error) {
+	// Fail fast rather than waiting for a connection while the node is down
+	if err := breaker.allow(); err != nil {
+		return nil, err
+	}
+	p := getConnectionPool()
+	_, span := tracing.StartSpan(ctx, "node.pool.get")
+	entry, err := p.get(ctx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	pConn := &PooledConnection{
+		Connection: entry.oConn,
+		entry:      entry,
+		pool:       p,
+	}
+	recordEndpointRequest(pConn.Endpoint())
+	pConn.stopCtx = context.AfterFunc(ctx, func() {
+		entry.broken.Store(true)
+		entry.oConn.Close()
+	})
+	return pConn, nil
+}
+
+func (p *connectionPool) get(ctx context.Context) (*poolEntry, error) {
+	select {
+	case p.inUseSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry := p.popIdle(); entry != nil {
+		p.updateMetrics()
+		return entry, nil
+	}
+	entry, err := p.open()
+	if err != nil {
+		<-p.inUseSlots
+		return nil, err
+	}
+	p.updateMetrics()
+	return entry, nil
+}
+
+// popIdle returns the most recently used idle connection, discarding any that
+// have failed since they were returned or are to an endpoint we've switched away
+// from
+func (p *connectionPool) popIdle() *poolEntry {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for len(p.idle) > 0 {
+		entry := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !entry.usable() {
+			entry.oConn.Close()
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+// open creates a new connection for the pool
+func (p *connectionPool) open() (*poolEntry, error) {
+	oConn, endpointIdx, err := openConnection(
+		&ConnectionConfig{Pooled: true},
+		-1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry := &poolEntry{oConn: oConn, endpoint: endpointIdx}
+	// A connection that reports an error can't be reused
+	go func() {
+		err, ok := <-oConn.ErrorChan()
+		if ok {
+			RecordConnectionError(err)
+		}
+		entry.broken.Store(true)
+		oConn.Close()
+	}()
+	oConn.LocalStateQuery().Client.Start()
+	oConn.LocalTxMonitor().Client.Start()
+	oConn.LocalTxSubmission().Client.Start()
+	return entry, nil
+}
+
+// usable returns whether the connection can be handed out again
+func (e *poolEntry) usable() bool {
+	return !e.broken.Load() && e.endpoint == int(activeEndpoint.Load())
+}
+
+// abandon closes a connection that's in an unknown state, such as after a call
+// timed out, so that it isn't reused
+func (c *PooledConnection) abandon() {
+	c.entry.broken.Store(true)
+	c.entry.oConn.Close()
+}
+
+// Endpoint returns the node endpoint that the connection is to
+func (c *PooledConnection) Endpoint() string {
+	return config.GetConfig().Node.GetEndpoints()[c.entry.endpoint].String()
+}
+
+// Close releases any ledger state or mempool snapshot that's still acquired and
+// returns the connection to the pool. Broken connections are closed instead
+func (c *PooledConnection) Close() error {
+	c.closeOnce.Do(func() {
+		if !c.stopCtx() {
+			c.entry.broken.Store(true)
+		}
+		if !c.entry.broken.Load() {
+			// The next user would otherwise see a stale snapshot, and acquiring
+			// the mempool again would wait for it to change
+			if c.entry.stateAcquired {
+				_ = c.ReleaseLocalState(context.Background())
+			}
+			if c.entry.mempoolAcquired {
+				_ = c.ReleaseMempool(context.Background())
+			}
+		}
+		c.pool.put(c.entry)
+	})
+	return nil
+}
+
+func (p *connectionPool) put(entry *poolEntry) {
+	p.mutex.Lock()
+	if !entry.usable() || p.closed {
+		entry.oConn.Close()
+	} else {
+		entry.lastUsed = time.Now()
+		p.idle = append(p.idle, entry)
+	}
+	p.mutex.Unlock()
+	<-p.inUseSlots
+	p.updateMetrics()
+}
+
+// AcquireLocalState acquires the current ledger state for LocalStateQuery. This
+// is done explicitly, rather than letting the first query do it, so that it gets
+// its own trace span
+func (c *PooledConnection) AcquireLocalState(ctx context.Context) error {
+	err := Run(
+		ctx,
+		c,
+		localstatequery.ProtocolName,
+		"acquire",
+		func() error {
+			return c.LocalStateQuery().Client.Acquire(nil)
+		},
+	)
+	if err == nil {
+		c.entry.stateAcquired = true
+	}
+	return err
+}
+
+// ReleaseLocalState releases the acquired ledger state. The connection isn't
+// reused if this fails
+func (c *PooledConnection) ReleaseLocalState(ctx context.Context) error {
+	err := Run(
+		ctx,
+		c,
+		localstatequery.ProtocolName,
+		"release",
+		c.LocalStateQuery().Client.Release,
+	)
+	c.entry.stateAcquired = false
+	if err != nil {
+		c.entry.broken.Store(true)
+	}
+	return err
+}
+
+// AcquireMempool acquires a mempool snapshot for LocalTxMonitor
+func (c *PooledConnection) AcquireMempool(ctx context.Context) error {
+	err := Run(
+		ctx,
+		c,
+		localtxmonitor.ProtocolName,
+		"acquire",
+		c.LocalTxMonitor().Client.Acquire,
+	)
+	if err == nil {
+		c.entry.mempoolAcquired = true
+	}
+	return err
+}
+
+// AwaitMempool waits for the mempool to change and then acquires a snapshot of
+// it, which requires a snapshot to already be acquired. The node has no way to
+// cancel the wait, so the connection is abandoned if the context ends first
+func (c *PooledConnection) AwaitMempool(ctx context.Context) error {
+	err := Await(
+		ctx,
+		c,
+		localtxmonitor.ProtocolName,
+		"await-acquire",
+		c.LocalTxMonitor().Client.Acquire,
+	)
+	if err == nil {
+		c.entry.mempoolAcquired = true
+	}
+	return err
+}
+
+// ReleaseMempool releases the acquired mempool snapshot. The connection isn't
+// reused if this fails
+func (c *PooledConnection) ReleaseMempool(ctx context.Context) error {
+	err := Run(
+		ctx,
+		c,
+		localtxmonitor.ProtocolName,
+		"release",
+		c.LocalTxMonitor().Client.Release,
+	)
+	c.entry.mempoolAcquired = false
+	if err != nil {
+		c.entry.broken.Store(true)
+	}
+	return err
+}
+
+func (p *connectionPool) run(ctx context.Context) {
+	logger := logging.GetLogger(logging.ComponentNode)
+	interval := min(max(p.idleTimeout/2, time.Second), 10*time.Second)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.closeIdle()
+		if err := p.fill(); err != nil {
+			logger.Debugf("failed to open pooled node connection: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			p.close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeIdle closes broken idle connections and those to an endpoint we've
+// switched away from, and any others that have been idle for longer than the
+// idle timeout beyond the minimum pool size
+func (p *connectionPool) closeIdle() {
+	p.mutex.Lock()
+	inUse := len(p.inUseSlots)
+	var keep []*poolEntry
+	// The idle list is ordered from least to most recently used
+	for idx, entry := range p.idle {
+		remaining := len(p.idle) - idx
+		switch {
+		case !entry.usable():
+			entry.oConn.Close()
+		case time.Since(entry.lastUsed) > p.idleTimeout &&
+			len(keep)+remaining+inUse > p.minSize:
+			entry.oConn.Close()
+		default:
+			keep = append(keep, entry)
+		}
+	}
+	p.idle = keep
+	p.mutex.Unlock()
+	p.updateMetrics()
+}
+
+// fill opens idle connections until the pool has the minimum number of
+// connections
+func (p *connectionPool) fill() error {
+	for {
+		p.mutex.Lock()
+		count := len(p.idle) + len(p.inUseSlots)
+		closed := p.closed
+		p.mutex.Unlock()
+		if closed || count >= p.minSize || !Connected() {
+			return nil
+		}
+		entry, err := p.open()
+		if err != nil {
+			return err
+		}
+		p.mutex.Lock()
+		entry.lastUsed = time.Now()
+		p.idle = append(p.idle, entry)
+		p.mutex.Unlock()
+		p.updateMetrics()
+	}
+}
+
+// close closes the idle connections and stops connections in use from being
+// returned to the pool
+func (p *connectionPool) close() {
+	p.mutex.Lock()
+	p.closed = true
+	for _, entry := range p.idle {
+		entry.oConn.Close()
+	}
+	p.idle = nil
+	p.mutex.Unlock()
+	p.updateMetrics()
+}
+
+func (p *connectionPool) updateMetrics() {
+	p.mutex.Lock()
+	idle := len(p.idle)
+	p.mutex.Unlock()
+	metric := ginmetrics.GetMonitor().GetMetric(metricPoolConnections)
+	_ = metric.SetGaugeValue([]string{poolStateIdle}, float64(idle))
+	_ = metric.SetGaugeValue(
+		[]string{poolStateInUse},
+		float64(len(p.inUseSlots)),
+	)
+}
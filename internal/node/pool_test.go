@@ -0,0 +1,194 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ouroboros_mock "github.com/blinklabs-io/ouroboros-mock"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/node/nodetest"
+)
+
+// checkout borrows a connection from the pool, failing the test on error
+func checkout(t *testing.T, ctx context.Context) *PooledConnection {
+	t.Helper()
+	pConn, err := GetPooledConnection(ctx)
+	if err != nil {
+		t.Fatalf("failed to get pooled connection: %s", err)
+	}
+	return pConn
+}
+
+func TestConnectionPool(t *testing.T) {
+	testDefs := []struct {
+		name        string
+		maxSize     int
+		minSize     int
+		idleTimeout time.Duration
+		// Number of connections the mock node accepts
+		connections int
+		run         func(*testing.T, *connectionPool)
+	}{
+		{
+			name:        "reuses returned connection",
+			maxSize:     2,
+			connections: 1,
+			run: func(t *testing.T, p *connectionPool) {
+				pConn := checkout(t, context.Background())
+				entry := pConn.entry
+				pConn.Close()
+				if len(p.idle) != 1 || len(p.inUseSlots) != 0 {
+					t.Fatalf(
+						"unexpected pool size: %d idle, %d in use",
+						len(p.idle),
+						len(p.inUseSlots),
+					)
+				}
+				pConn = checkout(t, context.Background())
+				defer pConn.Close()
+				if pConn.entry != entry {
+					t.Fatalf("expected the idle connection to be reused")
+				}
+			},
+		},
+		{
+			name:        "discards broken connection",
+			maxSize:     2,
+			connections: 2,
+			run: func(t *testing.T, p *connectionPool) {
+				pConn := checkout(t, context.Background())
+				entry := pConn.entry
+				pConn.abandon()
+				pConn.Close()
+				if len(p.idle) != 0 {
+					t.Fatalf("broken connection returned to the pool")
+				}
+				pConn = checkout(t, context.Background())
+				defer pConn.Close()
+				if pConn.entry == entry {
+					t.Fatalf("broken connection was reused")
+				}
+			},
+		},
+		{
+			name:        "times out waiting for a connection",
+			maxSize:     1,
+			connections: 1,
+			run: func(t *testing.T, p *connectionPool) {
+				pConn := checkout(t, context.Background())
+				defer pConn.Close()
+				ctx, cancel := context.WithTimeout(
+					context.Background(),
+					50*time.Millisecond,
+				)
+				defer cancel()
+				_, err := GetPooledConnection(ctx)
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded, got %v", err)
+				}
+			},
+		},
+		{
+			name:        "waits for a returned connection",
+			maxSize:     1,
+			connections: 1,
+			run: func(t *testing.T, p *connectionPool) {
+				pConn := checkout(t, context.Background())
+				entry := pConn.entry
+				time.AfterFunc(50*time.Millisecond, func() { pConn.Close() })
+				ctx, cancel := context.WithTimeout(
+					context.Background(),
+					5*time.Second,
+				)
+				defer cancel()
+				pConn = checkout(t, ctx)
+				defer pConn.Close()
+				if pConn.entry != entry {
+					t.Fatalf("expected the returned connection to be reused")
+				}
+			},
+		},
+		{
+			name:        "context done closes connection",
+			maxSize:     2,
+			connections: 1,
+			run: func(t *testing.T, p *connectionPool) {
+				ctx, cancel := context.WithCancel(context.Background())
+				pConn := checkout(t, ctx)
+				cancel()
+				// The connection is closed from another goroutine
+				deadline := time.Now().Add(5 * time.Second)
+				for !pConn.entry.broken.Load() && time.Now().Before(deadline) {
+					time.Sleep(10 * time.Millisecond)
+				}
+				pConn.Close()
+				if len(p.idle) != 0 {
+					t.Fatalf("closed connection returned to the pool")
+				}
+			},
+		},
+		{
+			name:        "closes idle connections beyond minimum",
+			maxSize:     3,
+			minSize:     1,
+			idleTimeout: time.Minute,
+			connections: 2,
+			run: func(t *testing.T, p *connectionPool) {
+				pConn1 := checkout(t, context.Background())
+				pConn2 := checkout(t, context.Background())
+				pConn1.Close()
+				pConn2.Close()
+				// Both have been idle for too long, but only the least recently
+				// used is closed, to keep the minimum
+				for _, entry := range p.idle {
+					entry.lastUsed = time.Now().Add(-2 * time.Minute)
+				}
+				p.closeIdle()
+				if len(p.idle) != 1 || p.idle[0] != pConn2.entry {
+					t.Fatalf("unexpected idle connections after cleanup: %d", len(p.idle))
+				}
+				p.close()
+				if len(p.idle) != 0 {
+					t.Fatalf("idle connections left after closing the pool")
+				}
+			},
+		},
+	}
+	for _, testDef := range testDefs {
+		t.Run(testDef.name, func(t *testing.T) {
+			conversations := make(
+				[][]ouroboros_mock.ConversationEntry,
+				testDef.connections,
+			)
+			nodetest.StartMockNode(t, 16, conversations...)
+			// Swap in a pool for the test
+			getConnectionPool()
+			origPool := connPool
+			t.Cleanup(func() { connPool = origPool })
+			p := &connectionPool{
+				minSize:     testDef.minSize,
+				idleTimeout: testDef.idleTimeout,
+				inUseSlots:  make(chan struct{}, testDef.maxSize),
+			}
+			connPool = p
+			t.Cleanup(p.close)
+			testDef.run(t, p)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+)
+
+// SystemStartTime converts the system start query result, which is a year, day of
+// the year, and picoseconds within the day, to a time
+func SystemStartTime(systemStart *localstatequery.SystemStartResult) time.Time {
+	return time.Date(systemStart.Year, time.January, 1, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, systemStart.Day-1).
+		Add(time.Duration(systemStart.Picoseconds / 1000))
+}
+
+// eraStartOffset returns the start of an era relative to the system start
+func eraStartOffset(era localstatequery.EraHistoryResult) (time.Duration, error) {
+	return timespanOffset(era.Begin.Timespan)
+}
+
+// timespanOffset converts an era bound's time relative to the system start. The
+// node sends this in picoseconds, which exceeds 64 bits on long-running networks
+func timespanOffset(timespan any) (time.Duration, error) {
+	var picoseconds *big.Int
+	switch v := timespan.(type) {
+	case uint64:
+		picoseconds = new(big.Int).SetUint64(v)
+	case int64:
+		picoseconds = big.NewInt(v)
+	case big.Int:
+		picoseconds = &v
+	case *big.Int:
+		picoseconds = v
+	default:
+		return 0, fmt.Errorf("unexpected era start type: %T", v)
+	}
+	nanoseconds := new(big.Int).Quo(picoseconds, big.NewInt(1000))
+	if !nanoseconds.IsInt64() {
+		return 0, fmt.Errorf("era start out of range: %s", picoseconds)
+	}
+	return time.Duration(nanoseconds.Int64()), nil
+}
+
+// EraBoundTime returns the time of an era bound from the era history, which the
+// node sends relative to the system start
+func EraBoundTime(systemStart time.Time, timespan any) (time.Time, error) {
+	offset, err := timespanOffset(timespan)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return systemStart.Add(offset), nil
+}
+
+// SlotAtTime returns the slot number at the specified time, using the parameters
+// of the era that the time falls in
+func SlotAtTime(
+	systemStart time.Time,
+	eraHistory []localstatequery.EraHistoryResult,
+	t time.Time,
+) (uint64, error) {
+	if len(eraHistory) == 0 {
+		return 0, fmt.Errorf("empty era history")
+	}
+	// Find the last era that starts at or before the time
+	var era localstatequery.EraHistoryResult
+	var eraStart time.Time
+	found := false
+	for _, tmpEra := range eraHistory {
+		tmpEraStart, err := EraBoundTime(systemStart, tmpEra.Begin.Timespan)
+		if err != nil {
+			return 0, err
+		}
+		if tmpEraStart.After(t) {
+			break
+		}
+		era = tmpEra
+		eraStart = tmpEraStart
+		found = true
+	}
+	if !found {
+		return 0, fmt.Errorf("time is before the start of the era history")
+	}
+	if era.Params.SlotLength <= 0 {
+		return 0, fmt.Errorf("invalid slot length: %d", era.Params.SlotLength)
+	}
+	// The slot length is in milliseconds
+	elapsedSlots := t.Sub(eraStart).Milliseconds() / int64(era.Params.SlotLength)
+	return uint64(era.Begin.SlotNo) + uint64(elapsedSlots), nil
+}
+
+// SlotEra returns the index in the era history of the era that the slot belongs
+// to, or -1 if the slot is before the start of the era history. Slots past the
+// end of the era history belong to the last era
+func SlotEra(eraHistory []localstatequery.EraHistoryResult, slot uint64) int {
+	// Find the last era that starts at or before the slot
+	eraIdx := -1
+	for idx, era := range eraHistory {
+		if uint64(era.Begin.SlotNo) > slot {
+			break
+		}
+		eraIdx = idx
+	}
+	return eraIdx
+}
+
+// SlotPastHorizon returns whether the slot is past the end of the era history.
+// The node only knows the era parameters up to the end of the safe zone, so
+// conversions for later slots are projections that a hard fork could change
+func SlotPastHorizon(
+	eraHistory []localstatequery.EraHistoryResult,
+	slot uint64,
+) bool {
+	if len(eraHistory) == 0 {
+		return true
+	}
+	era := eraHistory[len(eraHistory)-1]
+	// An unbounded era has no end
+	return era.End.Timespan != nil && slot >= uint64(era.End.SlotNo)
+}
+
+// SlotTime returns the wall-clock start time of the specified slot, using the
+// parameters of the era that it belongs to
+func SlotTime(
+	systemStart time.Time,
+	eraHistory []localstatequery.EraHistoryResult,
+	slot uint64,
+) (time.Time, error) {
+	if len(eraHistory) == 0 {
+		return time.Time{}, fmt.Errorf("empty era history")
+	}
+	eraIdx := SlotEra(eraHistory, slot)
+	if eraIdx < 0 {
+		return time.Time{}, fmt.Errorf("slot is before the start of the era history")
+	}
+	era := eraHistory[eraIdx]
+	if era.Params.SlotLength <= 0 {
+		return time.Time{}, fmt.Errorf(
+			"invalid slot length: %d",
+			era.Params.SlotLength,
+		)
+	}
+	offset, err := eraStartOffset(era)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The slot length is in milliseconds
+	elapsed := time.Duration(slot-uint64(era.Begin.SlotNo)) *
+		time.Duration(era.Params.SlotLength) * time.Millisecond
+	return systemStart.Add(offset).Add(elapsed), nil
+}
+
+// EpochBounds describes the slots and times of an epoch. The end is nil when the
+// era history doesn't cover it yet, which happens briefly after a hard fork
+type EpochBounds struct {
+	FirstSlot uint64
+	LastSlot  *uint64
+	StartTime time.Time
+	EndTime   *time.Time
+}
+
+// GetEpochBounds returns the first and last slots of the specified epoch and
+// the times that it starts and ends
+func GetEpochBounds(
+	systemStart time.Time,
+	eraHistory []localstatequery.EraHistoryResult,
+	epochNo uint64,
+) (EpochBounds, error) {
+	// Find the last era that starts at or before the epoch
+	eraIdx := -1
+	for idx, era := range eraHistory {
+		if uint64(era.Begin.EpochNo) > epochNo {
+			break
+		}
+		eraIdx = idx
+	}
+	if eraIdx < 0 {
+		return EpochBounds{}, fmt.Errorf(
+			"epoch is before the start of the era history",
+		)
+	}
+	era := eraHistory[eraIdx]
+	// An unbounded era has no end
+	if era.End.Timespan != nil && uint64(era.End.EpochNo) <= epochNo {
+		if uint64(era.End.EpochNo) < epochNo {
+			return EpochBounds{}, fmt.Errorf(
+				"epoch is after the end of the era history",
+			)
+		}
+		// The epoch starts the next era, which the era history doesn't
+		// include yet
+		offset, err := timespanOffset(era.End.Timespan)
+		if err != nil {
+			return EpochBounds{}, err
+		}
+		return EpochBounds{
+			FirstSlot: uint64(era.End.SlotNo),
+			StartTime: systemStart.Add(offset),
+		}, nil
+	}
+	if era.Params.EpochLength <= 0 {
+		return EpochBounds{}, fmt.Errorf(
+			"invalid epoch length: %d",
+			era.Params.EpochLength,
+		)
+	}
+	epochLength := uint64(era.Params.EpochLength)
+	firstSlot := uint64(era.Begin.SlotNo) +
+		(epochNo-uint64(era.Begin.EpochNo))*epochLength
+	startTime, err := SlotTime(systemStart, eraHistory, firstSlot)
+	if err != nil {
+		return EpochBounds{}, err
+	}
+	// Hard forks happen on epoch boundaries, so the epoch ends with the era
+	// parameters that it started with
+	lastSlot := firstSlot + epochLength - 1
+	endTime := startTime.Add(
+		time.Duration(epochLength) *
+			time.Duration(era.Params.SlotLength) * time.Millisecond,
+	)
+	return EpochBounds{
+		FirstSlot: firstSlot,
+		LastSlot:  &lastSlot,
+		StartTime: startTime,
+		EndTime:   &endTime,
+	}, nil
+}
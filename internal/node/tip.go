@@ -0,0 +1,86 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"time"
+
+	ouroboros "github.com/blinklabs-io/gouroboros"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// StartTipPoller periodically exports the slot, block height, and age of the
+// node tip from LocalStateQuery until the context is done
+func StartTipPoller(ctx context.Context, cfg *config.Config) {
+	p := &poller{
+		name:     "chain tip",
+		interval: time.Duration(cfg.Metrics.TipPollInterval) * time.Second,
+		startFunc: func(oConn *ouroboros.Connection) {
+			oConn.LocalStateQuery().Client.Start()
+		},
+		pollFunc: pollTip,
+	}
+	go p.run(ctx)
+}
+
+func pollTip(oConn *ouroboros.Connection) error {
+	client := oConn.LocalStateQuery().Client
+	// Acquire the current ledger state
+	if err := client.Acquire(nil); err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	point, err := client.GetChainPoint()
+	if err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	blockNo, err := client.GetChainBlockNo()
+	if err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	// The system start and era history are needed to convert the tip slot to a
+	// wall-clock time
+	systemStart, err := client.GetSystemStart()
+	if err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	eraHistory, err := client.GetEraHistory()
+	if err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	if err := client.Release(); err != nil {
+		RecordProtocolError(localstatequery.ProtocolName)
+		return err
+	}
+	tipTime, err := SlotTime(SystemStartTime(systemStart), eraHistory, point.Slot)
+	if err != nil {
+		return err
+	}
+	metrics := ginmetrics.GetMonitor()
+	_ = metrics.GetMetric(metricTipSlot).SetGaugeValue(nil, float64(point.Slot))
+	_ = metrics.GetMetric(metricTipBlockHeight).
+		SetGaugeValue(nil, float64(blockNo))
+	_ = metrics.GetMetric(metricTipAge).
+		SetGaugeValue(nil, time.Since(tipTime).Seconds())
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/config"
+)
+
+// OpenConnection describes a currently open connection to the node
+type OpenConnection struct {
+	Id              uint64    `json:"id"`
+	Network         string    `json:"network"`
+	LocalAddress    string    `json:"local_address"`
+	RemoteAddress   string    `json:"remote_address"`
+	Endpoint        string    `json:"endpoint"`
+	OpenedAt        time.Time `json:"opened_at"`
+	ProtocolVersion uint16    `json:"protocol_version"`
+	Protocols       []string  `json:"protocols"`
+	Streaming       bool      `json:"streaming"`
+	Pooled          bool      `json:"pooled"`
+}
+
+var (
+	openConnections      = make(map[uint64]*trackedConn)
+	openConnectionsMutex sync.Mutex
+	lastConnectionId     atomic.Uint64
+)
+
+// trackedConn wraps the underlying connection to the node so that it can be
+// listed until it is closed
+type trackedConn struct {
+	net.Conn
+	id        uint64
+	info      OpenConnection
+	infoMutex sync.Mutex
+	closeOnce sync.Once
+}
+
+func trackConnection(
+	conn net.Conn,
+	endpoint config.NodeEndpoint,
+	connCfg ConnectionConfig,
+) *trackedConn {
+	tConn := &trackedConn{
+		Conn: conn,
+		id:   lastConnectionId.Add(1),
+	}
+	tConn.info = OpenConnection{
+		Id:            tConn.id,
+		Network:       conn.RemoteAddr().Network(),
+		LocalAddress:  conn.LocalAddr().String(),
+		RemoteAddress: conn.RemoteAddr().String(),
+		Endpoint:      endpoint.String(),
+		OpenedAt:      time.Now(),
+		Protocols: []string{
+			"handshake",
+			"keep-alive",
+			"chain-sync",
+			"local-state-query",
+			"local-tx-monitor",
+			"local-tx-submission",
+		},
+		Streaming: connCfg.ChainSyncEventChan != nil,
+		Pooled:    connCfg.Pooled,
+	}
+	openConnectionsMutex.Lock()
+	openConnections[tConn.id] = tConn
+	updateOpenConnectionsMetric(len(openConnections))
+	openConnectionsMutex.Unlock()
+	return tConn
+}
+
+func (t *trackedConn) setProtocolVersion(protocolVersion uint16) {
+	t.infoMutex.Lock()
+	defer t.infoMutex.Unlock()
+	t.info.ProtocolVersion = protocolVersion
+}
+
+func (t *trackedConn) Close() error {
+	t.closeOnce.Do(func() {
+		openConnectionsMutex.Lock()
+		delete(openConnections, t.id)
+		updateOpenConnectionsMetric(len(openConnections))
+		openConnectionsMutex.Unlock()
+	})
+	return t.Conn.Close()
+}
+
+// GetOpenConnections returns the currently open node connections, ordered by ID
+func GetOpenConnections() []OpenConnection {
+	openConnectionsMutex.Lock()
+	ret := make([]OpenConnection, 0, len(openConnections))
+	for _, tConn := range openConnections {
+		tConn.infoMutex.Lock()
+		ret = append(ret, tConn.info)
+		tConn.infoMutex.Unlock()
+	}
+	openConnectionsMutex.Unlock()
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Id < ret[j].Id
+	})
+	return ret
+}
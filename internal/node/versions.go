@@ -0,0 +1,101 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/blinklabs-io/gouroboros/cbor"
+	"github.com/blinklabs-io/gouroboros/muxer"
+	"github.com/blinklabs-io/gouroboros/protocol"
+	"github.com/blinklabs-io/gouroboros/protocol/handshake"
+)
+
+// versionFilterConn restricts the protocol versions offered to the node in the
+// handshake. gouroboros always offers every version that it supports, so we
+// rewrite its propose versions message on the way out
+type versionFilterConn struct {
+	net.Conn
+	versions []uint16
+	// Writes are serialized by the muxer, so this doesn't need a lock
+	proposed bool
+}
+
+// filterProtocolVersions wraps the connection so that only the specified
+// node-to-client protocol versions are offered in the handshake
+func filterProtocolVersions(conn net.Conn, versions []uint16) net.Conn {
+	return &versionFilterConn{
+		Conn:     conn,
+		versions: versions,
+	}
+}
+
+func (v *versionFilterConn) Write(data []byte) (int, error) {
+	if v.proposed {
+		return v.Conn.Write(data)
+	}
+	// The handshake is the first thing sent on the connection, and the muxer
+	// writes each segment in a single call
+	v.proposed = true
+	filtered, err := v.filterProposal(data)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := v.Conn.Write(filtered); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// filterProposal removes the versions that we don't want to offer from a muxer
+// segment containing the handshake propose versions message
+func (v *versionFilterConn) filterProposal(data []byte) ([]byte, error) {
+	var header muxer.SegmentHeader
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("failure reading handshake segment: %s", err)
+	}
+	headerSize := binary.Size(header)
+	if header.ProtocolId != handshake.ProtocolId ||
+		len(data) != headerSize+int(header.PayloadLength) {
+		return nil, fmt.Errorf("expected handshake as the first message to the node")
+	}
+	var msg handshake.MsgProposeVersions
+	if _, err := cbor.Decode(data[headerSize:], &msg); err != nil {
+		return nil, fmt.Errorf("failure decoding handshake proposal: %s", err)
+	}
+	for version := range msg.VersionMap {
+		if !slices.Contains(
+			v.versions,
+			version-protocol.ProtocolVersionNtCOffset,
+		) {
+			delete(msg.VersionMap, version)
+		}
+	}
+	payload, err := cbor.Encode(&msg)
+	if err != nil {
+		return nil, fmt.Errorf("failure encoding handshake proposal: %s", err)
+	}
+	header.PayloadLength = uint16(len(payload))
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.BigEndian, header); err != nil {
+		return nil, err
+	}
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
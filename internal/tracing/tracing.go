@@ -0,0 +1,164 @@
+// Copyright 2024 Blink Labs Software
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blinklabs-io/cardano-node-api/internal/version"
+)
+
+const (
+	ServiceName = "cardano-node-api"
+	tracerName  = "github.com/blinklabs-io/cardano-node-api"
+)
+
+var enabled bool
+
+// Enabled returns whether trace export has been configured
+func Enabled() bool {
+	return enabled
+}
+
+// Setup configures trace export from the standard OTEL_* env vars. Tracing is
+// only enabled when an OTLP endpoint or OTEL_TRACES_EXPORTER=otlp is set.
+// Otherwise the global no-op tracer provider is left in place. The returned
+// function flushes and stops the exporter
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	if !exportConfigured() {
+		return func(context.Context) error { return nil }, nil
+	}
+	var exporter *otlptrace.Exporter
+	var err error
+	switch otlpProtocol() {
+	case "grpc":
+		exporter, err = otlptracegrpc.New(ctx)
+	case "http/protobuf":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf(
+			"unsupported OTLP protocol: %s",
+			otlpProtocol(),
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %s", err)
+	}
+	serviceVersion := version.Version
+	if serviceVersion == "" {
+		serviceVersion = "devel"
+	}
+	// Values from OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override our defaults
+	res, err := resource.New(
+		ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(ServiceName),
+			semconv.ServiceVersion(serviceVersion),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %s", err)
+	}
+	// The sampler is configured from OTEL_TRACES_SAMPLER by the SDK
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+	enabled = true
+	return provider.Shutdown, nil
+}
+
+func exportConfigured() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "otlp":
+		return true
+	case "":
+		return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+			os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+	default:
+		// "none" or an exporter that we don't support
+		return false
+	}
+}
+
+func otlpProtocol() string {
+	if proto := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"); proto != "" {
+		return proto
+	}
+	if proto := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); proto != "" {
+		return proto
+	}
+	return "http/protobuf"
+}
+
+// StartSpan starts a child span of any span in the provided context
+func StartSpan(
+	ctx context.Context,
+	name string,
+	opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// EndSpan records any error on the span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Run calls the provided function inside a new span
+func Run(ctx context.Context, name string, fn func() error) error {
+	_, span := StartSpan(ctx, name)
+	err := fn()
+	EndSpan(span, err)
+	return err
+}
+
+// Call calls the provided function inside a new span and returns its result
+func Call[T any](ctx context.Context, name string, fn func() (T, error)) (T, error) {
+	_, span := StartSpan(ctx, name)
+	ret, err := fn()
+	EndSpan(span, err)
+	return ret, err
+}
@@ -23,6 +23,7 @@ import (
 	connect "connectrpc.com/connect"
 	"github.com/blinklabs-io/gouroboros/ledger"
 	// ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localstatequery"
 	query "github.com/utxorpc/go-codegen/utxorpc/v1alpha/query"
 	"github.com/utxorpc/go-codegen/utxorpc/v1alpha/query/queryconnect"
 
@@ -44,27 +45,39 @@ func (s *queryServiceServer) ReadParams(
 	log.Printf("Got a ReadParams request with fieldMask %v", fieldMask)
 	resp := &query.ReadParamsResponse{}
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
+	// Return the connection to the pool, which releases the ledger state
+	defer oConn.Close()
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		log.Printf("ERROR: %s", err)
+		return nil, err
+	}
 
 	// Get protoParams
-	protoParams, err := oConn.LocalStateQuery().Client.GetCurrentProtocolParams()
+	protoParams, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query protocol-params",
+		oConn.LocalStateQuery().Client.GetCurrentProtocolParams,
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
 	}
 
 	// Get chain point (slot and hash)
-	point, err := oConn.LocalStateQuery().Client.GetChainPoint()
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
@@ -94,17 +107,17 @@ func (s *queryServiceServer) ReadUtxos(
 	log.Printf("Got a ReadUtxos request with keys %v", keys)
 	resp := &query.ReadUtxosResponse{}
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
+	// Return the connection to the pool, which releases the ledger state
+	defer oConn.Close()
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		log.Printf("ERROR: %s", err)
+		return nil, err
+	}
 
 	// Setup our query input
 	var tmpTxIns []ledger.TransactionInput
@@ -118,14 +131,28 @@ func (s *queryServiceServer) ReadUtxos(
 	}
 
 	// Get UTxOs
-	utxos, err := oConn.LocalStateQuery().Client.GetUTxOByTxIn(tmpTxIns)
+	utxos, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query utxo-by-txin",
+		func() (*localstatequery.UTxOByTxInResult, error) {
+			return oConn.LocalStateQuery().Client.GetUTxOByTxIn(tmpTxIns)
+		},
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
 	}
 
 	// Get chain point (slot and hash)
-	point, err := oConn.LocalStateQuery().Client.GetChainPoint()
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
@@ -181,27 +208,41 @@ func (s *queryServiceServer) SearchUtxos(
 		// TODO: GetPaymentPart() GetDelegationPart()
 	}
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-	// Start client
-	oConn.LocalStateQuery().Client.Start()
+	// Return the connection to the pool, which releases the ledger state
+	defer oConn.Close()
+	if err := oConn.AcquireLocalState(ctx); err != nil {
+		log.Printf("ERROR: %s", err)
+		return nil, err
+	}
 
 	// Get UTxOs
-	utxos, err := oConn.LocalStateQuery().Client.GetUTxOByAddress(addresses)
+	utxos, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query utxo-by-address",
+		func() (*localstatequery.UTxOByAddressResult, error) {
+			return oConn.LocalStateQuery().Client.GetUTxOByAddress(addresses)
+		},
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
 	}
 
 	// Get chain point (slot and hash)
-	point, err := oConn.LocalStateQuery().Client.GetChainPoint()
+	point, err := node.Call(
+		ctx,
+		oConn,
+		localstatequery.ProtocolName,
+		"query chain-point",
+		oConn.LocalStateQuery().Client.GetChainPoint,
+	)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return nil, err
@@ -21,10 +21,10 @@ import (
 	"log"
 
 	connect "connectrpc.com/connect"
-	"github.com/blinklabs-io/adder/event"
 	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
 	"github.com/blinklabs-io/gouroboros/ledger"
-	ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxmonitor"
+	"github.com/blinklabs-io/gouroboros/protocol/localtxsubmission"
 	submit "github.com/utxorpc/go-codegen/utxorpc/v1alpha/submit"
 	"github.com/utxorpc/go-codegen/utxorpc/v1alpha/submit/submitconnect"
 	"golang.org/x/crypto/blake2b"
@@ -48,15 +48,13 @@ func (s *submitServiceServer) SubmitTx(
 	log.Printf("Got a SubmitTx request with %d transactions", len(txRawList))
 	resp := &submit.SubmitTxResponse{}
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
+	// Return the connection to the pool
+	defer oConn.Close()
 
 	// Loop through the transactions and submit each
 	errorList := make([]error, len(txRawList))
@@ -79,9 +77,17 @@ func (s *submitServiceServer) SubmitTx(
 			continue
 		}
 		// Submit the transaction
-		err = oConn.LocalTxSubmission().Client.SubmitTx(
-			uint16(txType),
-			txRawBytes,
+		err = node.Run(
+			ctx,
+			oConn,
+			localtxsubmission.ProtocolName,
+			"submit",
+			func() error {
+				return oConn.LocalTxSubmission().Client.SubmitTx(
+					uint16(txType),
+					txRawBytes,
+				)
+			},
 		)
 		if err != nil {
 			resp.Ref = append(resp.Ref, placeholderRef)
@@ -114,32 +120,9 @@ func (s *submitServiceServer) WaitForTx(
 	ref := req.Msg.GetRef() // [][]byte
 	log.Printf("Got a WaitForTx request with %d transactions", len(ref))
 
-	// Setup event channel
-	eventChan := make(chan event.Event, 10)
-	connCfg := node.ConnectionConfig{
-		ChainSyncEventChan: eventChan,
-	}
-	// Connect to node
-	oConn, err := node.GetConnection(&connCfg)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-
-	// Get our starting point
-	var point ocommon.Point
-	tip, err := oConn.ChainSync().Client.GetCurrentTip()
-	if err != nil {
-		log.Printf("ERROR: %s", err)
-		return err
-	}
-	point = tip.Point
-
-	// Start the sync with the node
-	err = oConn.ChainSync().Client.Sync([]ocommon.Point{point})
+	// Start the sync with the node from the current tip. This resumes on a new
+	// connection if the node restarts
+	syncStream, err := node.StartChainSyncStream(ctx, nil)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return err
@@ -147,10 +130,13 @@ func (s *submitServiceServer) WaitForTx(
 
 	// Wait for events
 	for {
-		evt, ok := <-eventChan
+		evt, ok := <-syncStream.Events()
 		if !ok {
-			log.Printf("ERROR: channel closed")
-			return fmt.Errorf("ERROR: channel closed")
+			if err := syncStream.Err(); err != nil {
+				log.Printf("ERROR: %s", err)
+				return err
+			}
+			return ctx.Err()
 		}
 
 		switch v := evt.Payload.(type) {
@@ -197,23 +183,28 @@ func (s *submitServiceServer) ReadMempool(
 	log.Printf("Got a ReadMempool request with %d transactions", len(txim))
 	resp := &submit.ReadMempoolResponse{}
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Get a connection to the node from the pool
+	oConn, err := node.GetPooledConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-
-	// Start LocalTxMonitor client
-	oConn.LocalTxMonitor().Client.Start()
+	// Return the connection to the pool, which releases the mempool snapshot
+	defer oConn.Close()
+	if err := oConn.AcquireMempool(ctx); err != nil {
+		log.Printf("ERROR: %s", err)
+		return nil, err
+	}
 
 	// Collect TX hashes from the mempool
 	mempool := []*submit.TxInMempool{}
 	for {
-		txRawBytes, err := oConn.LocalTxMonitor().Client.NextTx()
+		txRawBytes, err := node.Call(
+			ctx,
+			oConn,
+			localtxmonitor.ProtocolName,
+			"next-tx",
+			oConn.LocalTxMonitor().Client.NextTx,
+		)
 		if err != nil {
 			log.Printf("ERROR: %s", err)
 			return nil, err
@@ -264,8 +255,9 @@ func (s *submitServiceServer) WatchMempool(
 		fieldMask,
 	)
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Connect to node. The connection is closed when the client goes away,
+	// which stops any call waiting on the mempool
+	oConn, err := node.GetConnection(&node.ConnectionConfig{Context: ctx})
 	if err != nil {
 		return err
 	}
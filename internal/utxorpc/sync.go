@@ -21,7 +21,6 @@ import (
 	"log"
 
 	connect "connectrpc.com/connect"
-	"github.com/blinklabs-io/adder/event"
 	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
 	ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
 	sync "github.com/utxorpc/go-codegen/utxorpc/v1alpha/sync"
@@ -48,8 +47,9 @@ func (s *chainSyncServiceServer) FetchBlock(
 		fieldMask,
 	)
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Connect to node. The connection is closed if the request is cancelled or
+	// times out, which aborts the chain-sync calls
+	oConn, err := node.GetConnection(&node.ConnectionConfig{Context: ctx})
 	if err != nil {
 		return nil, err
 	}
@@ -114,8 +114,9 @@ func (s *chainSyncServiceServer) DumpHistory(
 		fieldMask,
 	)
 
-	// Connect to node
-	oConn, err := node.GetConnection(nil)
+	// Connect to node. The connection is closed if the request is cancelled or
+	// times out, which aborts the chain-sync calls
+	oConn, err := node.GetConnection(&node.ConnectionConfig{Context: ctx})
 	if err != nil {
 		return nil, err
 	}
@@ -171,24 +172,10 @@ func (s *chainSyncServiceServer) FollowTip(
 	intersect := req.Msg.GetIntersect() // []*BlockRef
 	log.Printf("Got a FollowTip request with intersect %v", intersect)
 
-	// Setup event channel
-	eventChan := make(chan event.Event, 10)
-	connCfg := node.ConnectionConfig{
-		ChainSyncEventChan: eventChan,
-	}
-	// Connect to node
-	oConn, err := node.GetConnection(&connCfg)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-
 	// Get our starting point
-	var point ocommon.Point
+	var points []ocommon.Point
 	if len(intersect) > 0 {
+		var point ocommon.Point
 		for _, blockRef := range intersect {
 			blockIdx := blockRef.GetIndex()
 			blockHash := blockRef.GetHash()
@@ -197,13 +184,12 @@ func (s *chainSyncServiceServer) FollowTip(
 			slot := uint64(blockIdx)
 			point = ocommon.NewPoint(slot, hash)
 		}
-	} else {
-		tip, _ := oConn.ChainSync().Client.GetCurrentTip()
-		point = tip.Point
+		points = []ocommon.Point{point}
 	}
 
-	// Start the sync with the node
-	err = oConn.ChainSync().Client.Sync([]ocommon.Point{point})
+	// Start the sync with the node, from the tip if no point was given. This
+	// resumes on a new connection if the node restarts
+	syncStream, err := node.StartChainSyncStream(ctx, points)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return err
@@ -211,10 +197,13 @@ func (s *chainSyncServiceServer) FollowTip(
 
 	// Wait for events
 	for {
-		evt, ok := <-eventChan
+		evt, ok := <-syncStream.Events()
 		if !ok {
-			log.Printf("ERROR: channel closed")
-			return fmt.Errorf("ERROR: channel closed")
+			if err := syncStream.Err(); err != nil {
+				log.Printf("ERROR: %s", err)
+				return err
+			}
+			return ctx.Err()
 		}
 
 		switch evt.Type {
@@ -20,9 +20,7 @@ import (
 	"log"
 
 	connect "connectrpc.com/connect"
-	"github.com/blinklabs-io/adder/event"
 	input_chainsync "github.com/blinklabs-io/adder/input/chainsync"
-	ocommon "github.com/blinklabs-io/gouroboros/protocol/common"
 	watch "github.com/utxorpc/go-codegen/utxorpc/v1alpha/watch"
 	"github.com/utxorpc/go-codegen/utxorpc/v1alpha/watch/watchconnect"
 
@@ -48,30 +46,9 @@ func (s *watchServiceServer) WatchTx(
 		fieldMask,
 	)
 
-	// Setup event channel
-	eventChan := make(chan event.Event, 10)
-	connCfg := node.ConnectionConfig{
-		ChainSyncEventChan: eventChan,
-	}
-	// Connect to node
-	oConn, err := node.GetConnection(&connCfg)
-	if err != nil {
-		log.Printf("ERROR: %s", err)
-		return err
-	}
-	defer func() {
-		// Close Ouroboros connection
-		oConn.Close()
-	}()
-
-	// Get current tip
-	tip, err := oConn.ChainSync().Client.GetCurrentTip()
-	if err != nil {
-		log.Printf("ERROR: %s", err)
-		return err
-	}
-	// Start the sync with the node
-	err = oConn.ChainSync().Client.Sync([]ocommon.Point{tip.Point})
+	// Start the sync with the node from the current tip. This resumes on a new
+	// connection if the node restarts
+	syncStream, err := node.StartChainSyncStream(ctx, nil)
 	if err != nil {
 		log.Printf("ERROR: %s", err)
 		return err
@@ -79,10 +56,13 @@ func (s *watchServiceServer) WatchTx(
 
 	// Wait for events
 	for {
-		evt, ok := <-eventChan
+		evt, ok := <-syncStream.Events()
 		if !ok {
-			log.Printf("ERROR: channel closed")
-			return fmt.Errorf("ERROR: channel closed")
+			if err := syncStream.Err(); err != nil {
+				log.Printf("ERROR: %s", err)
+				return err
+			}
+			return ctx.Err()
 		}
 
 		switch evt.Type {
@@ -16,11 +16,13 @@ package version
 
 import (
 	"fmt"
+	"runtime/debug"
 )
 
 // These are populated at build time
 var Version string
 var CommitHash string
+var BuildDate string
 
 func GetVersionString() string {
 	if Version != "" {
@@ -29,3 +31,21 @@ func GetVersionString() string {
 		return fmt.Sprintf("devel (commit %s)", CommitHash)
 	}
 }
+
+// GetDependencyVersion returns the version of a Go module dependency from the
+// build info, or an empty string if it's not available
+func GetDependencyVersion(modulePath string) string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range buildInfo.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}